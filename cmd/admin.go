@@ -596,7 +596,7 @@ func runCreateUser(c *cli.Context) error {
 			UID:  u.ID,
 		}
 
-		if err := auth_model.NewAccessToken(t); err != nil {
+		if err := auth_service.CreateAccessToken(ctx, nil, u, t); err != nil {
 			return err
 		}
 
@@ -678,7 +678,7 @@ func runDeleteUser(c *cli.Context) error {
 		return fmt.Errorf("The user %s does not match the provided id %d", user.Name, c.Int64("id"))
 	}
 
-	return user_service.DeleteUser(ctx, user, c.Bool("purge"))
+	return user_service.DeleteUser(ctx, nil, user, c.Bool("purge"))
 }
 
 func runGenerateAccessToken(c *cli.Context) error {
@@ -703,7 +703,7 @@ func runGenerateAccessToken(c *cli.Context) error {
 		UID:  user.ID,
 	}
 
-	if err := auth_model.NewAccessToken(t); err != nil {
+	if err := auth_service.CreateAccessToken(ctx, nil, user, t); err != nil {
 		return err
 	}
 
@@ -32,7 +32,7 @@ var CmdMigrateStorage = cli.Command{
 		cli.StringFlag{
 			Name:  "type, t",
 			Value: "",
-			Usage: "Type of stored files to copy.  Allowed types: 'attachments', 'lfs', 'avatars', 'repo-avatars', 'repo-archivers', 'packages'",
+			Usage: "Type of stored files to copy.  Allowed types: 'attachments', 'lfs', 'avatars', 'repo-avatars', 'repo-archivers', 'repo-bundles', 'packages'",
 		},
 		cli.StringFlag{
 			Name:  "storage, s",
@@ -117,6 +117,14 @@ func migrateRepoArchivers(ctx context.Context, dstStorage storage.ObjectStorage)
 	})
 }
 
+func migrateRepoBundles(ctx context.Context, dstStorage storage.ObjectStorage) error {
+	return db.Iterate(ctx, nil, func(ctx context.Context, bundle *repo_model.RepoBundle) error {
+		p := bundle.RelativePath()
+		_, err := storage.Copy(dstStorage, p, storage.RepoBundles, p)
+		return err
+	})
+}
+
 func migratePackages(ctx context.Context, dstStorage storage.ObjectStorage) error {
 	return db.Iterate(ctx, nil, func(ctx context.Context, pb *packages_model.PackageBlob) error {
 		p := packages_module.KeyToRelativePath(packages_module.BlobHash256Key(pb.HashSHA256))
@@ -189,6 +197,7 @@ func runMigrateStorage(ctx *cli.Context) error {
 		"avatars":        migrateAvatars,
 		"repo-avatars":   migrateRepoAvatars,
 		"repo-archivers": migrateRepoArchivers,
+		"repo-bundles":   migrateRepoBundles,
 		"packages":       migratePackages,
 	}
 
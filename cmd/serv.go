@@ -289,18 +289,14 @@ func runServ(c *cli.Context) error {
 		return nil
 	}
 
-	// Special handle for Windows.
-	if setting.IsWindows {
-		verb = strings.Replace(verb, "-", " ", 1)
-	}
-
-	var gitcmd *exec.Cmd
-	verbs := strings.Split(verb, " ")
-	if len(verbs) == 2 {
-		gitcmd = exec.CommandContext(ctx, verbs[0], verbs[1], repoPath)
-	} else {
-		gitcmd = exec.CommandContext(ctx, verb, repoPath)
+	// Run the service as a git sub command, so the config arguments of the repository can be passed to it.
+	// This also works on Windows, where the dashed executables can't be found.
+	args := make([]string, 0, 8)
+	for _, arg := range results.ServiceOptions.ConfigArgs() {
+		args = append(args, string(arg))
 	}
+	args = append(args, strings.TrimPrefix(verb, "git-"), repoPath)
+	gitcmd := exec.CommandContext(ctx, git.GitExecutable, args...)
 
 	process.SetSysProcAttribute(gitcmd)
 	gitcmd.Dir = setting.RepoRootPath
@@ -324,6 +320,8 @@ func runServ(c *cli.Context) error {
 	// to avoid breaking, here only use the minimal environment variables for the "gitea serv" command.
 	// it could be re-considered whether to use the same git.CommonGitCmdEnvs() as "git" command later.
 	gitcmd.Env = append(gitcmd.Env, git.CommonCmdServEnvs()...)
+	// the last value of the duplicated keys is used, it overrides the GIT_PROTOCOL passed by the ssh server
+	gitcmd.Env = append(gitcmd.Env, "GIT_PROTOCOL="+results.ServiceOptions.GitProtocol(os.Getenv("GIT_PROTOCOL")))
 
 	if err = gitcmd.Run(); err != nil {
 		return fail("Internal error", "Failed to execute git command: %v", err)
@@ -0,0 +1,192 @@
+// Copyright 2022 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"xorm.io/builder"
+)
+
+// ErrRunNotExist represents an error for a not existing run
+var ErrRunNotExist = util.NewNotExistErrorf("run does not exist")
+
+// ActionRun represents a run of a workflow file
+type ActionRun struct {
+	ID                int64
+	Title             string
+	RepoID            int64                  `xorm:"INDEX UNIQUE(repo_index)"`
+	Repo              *repo_model.Repository `xorm:"-"`
+	OwnerID           int64                  `xorm:"INDEX"`
+	WorkflowID        string                 `xorm:"INDEX"`                    // the name of the workflow file
+	Index             int64                  `xorm:"INDEX UNIQUE(repo_index)"` // a unique number for each run of a repository
+	TriggerUserID     int64                  `xorm:"INDEX"`
+	TriggerUser       *user_model.User       `xorm:"-"`
+	Ref               string                 `xorm:"INDEX"`
+	CommitSHA         string
+	Event             string
+	EventPayload      string `xorm:"LONGTEXT"`
+	IsForkPullRequest bool
+	Status            Status `xorm:"INDEX"`
+	Started           timeutil.TimeStamp
+	Stopped           timeutil.TimeStamp
+	Created           timeutil.TimeStamp `xorm:"created"`
+	Updated           timeutil.TimeStamp `xorm:"updated"`
+}
+
+// ActionRunIndex represents the per repository index of runs
+type ActionRunIndex db.ResourceIndex
+
+func init() {
+	db.RegisterModel(new(ActionRun))
+	db.RegisterModel(new(ActionRunIndex))
+}
+
+// Link returns the url of the run
+func (run *ActionRun) Link() string {
+	if run.Repo == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s/actions/runs/%d", run.Repo.Link(), run.Index)
+}
+
+// RefName returns the short name of the ref which triggered the run
+func (run *ActionRun) RefName() string {
+	return git.RefEndName(run.Ref)
+}
+
+// LoadAttributes loads the repository and the trigger user of the run
+func (run *ActionRun) LoadAttributes(ctx context.Context) error {
+	if run.Repo == nil {
+		repo, err := repo_model.GetRepositoryByID(ctx, run.RepoID)
+		if err != nil {
+			return err
+		}
+		run.Repo = repo
+	}
+	if err := run.Repo.LoadAttributes(ctx); err != nil {
+		return err
+	}
+
+	if run.TriggerUser == nil {
+		u, err := user_model.GetUserByID(ctx, run.TriggerUserID)
+		if user_model.IsErrUserNotExist(err) {
+			u = user_model.NewGhostUser()
+		} else if err != nil {
+			return err
+		}
+		run.TriggerUser = u
+	}
+
+	return nil
+}
+
+// InsertRun inserts a run together with its jobs
+func InsertRun(ctx context.Context, run *ActionRun, jobs []*ActionRunJob) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		index, err := db.GetNextResourceIndex(ctx, "action_run_index", run.RepoID)
+		if err != nil {
+			return err
+		}
+		run.Index = index
+		run.Status = StatusWaiting
+
+		if err := db.Insert(ctx, run); err != nil {
+			return err
+		}
+
+		for _, job := range jobs {
+			job.RunID = run.ID
+			job.RepoID = run.RepoID
+			job.OwnerID = run.OwnerID
+			job.CommitSHA = run.CommitSHA
+			if len(job.Needs) > 0 {
+				job.Status = StatusBlocked
+			} else {
+				job.Status = StatusWaiting
+			}
+		}
+		return db.Insert(ctx, jobs)
+	})
+}
+
+// GetRunByID returns the run with the given id
+func GetRunByID(ctx context.Context, id int64) (*ActionRun, error) {
+	run := &ActionRun{}
+	has, err := db.GetEngine(ctx).ID(id).Get(run)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrRunNotExist
+	}
+	return run, nil
+}
+
+// GetRunByIndex returns the run with the given index of the repository
+func GetRunByIndex(ctx context.Context, repoID, index int64) (*ActionRun, error) {
+	run := &ActionRun{}
+	has, err := db.GetEngine(ctx).Where("repo_id = ? AND `index` = ?", repoID, index).Get(run)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrRunNotExist
+	}
+	return run, nil
+}
+
+// UpdateRun updates the given columns of the run
+func UpdateRun(ctx context.Context, run *ActionRun, cols ...string) error {
+	sess := db.GetEngine(ctx).ID(run.ID)
+	if len(cols) > 0 {
+		sess.Cols(cols...)
+	}
+	_, err := sess.Update(run)
+	return err
+}
+
+// FindRunOptions are options for FindRuns
+type FindRunOptions struct {
+	db.ListOptions
+	RepoID     int64
+	WorkflowID string
+	CommitSHA  string
+	Status     []Status
+}
+
+// ToConds converts the options into a condition
+func (opts FindRunOptions) ToConds() builder.Cond {
+	cond := builder.NewCond()
+	if opts.RepoID > 0 {
+		cond = cond.And(builder.Eq{"repo_id": opts.RepoID})
+	}
+	if opts.WorkflowID != "" {
+		cond = cond.And(builder.Eq{"workflow_id": opts.WorkflowID})
+	}
+	if opts.CommitSHA != "" {
+		cond = cond.And(builder.Eq{"commit_sha": opts.CommitSHA})
+	}
+	if len(opts.Status) > 0 {
+		cond = cond.And(builder.In("status", opts.Status))
+	}
+	return cond
+}
+
+// FindRuns returns the runs matching the given options ordered by newest first
+func FindRuns(ctx context.Context, opts FindRunOptions) ([]*ActionRun, int64, error) {
+	runs := make([]*ActionRun, 0, 10)
+	sess := db.GetEngine(ctx).Where(opts.ToConds()).OrderBy("id DESC")
+	if opts.PageSize > 0 {
+		sess = db.SetSessionPagination(sess, &opts.ListOptions)
+	}
+	count, err := sess.FindAndCount(&runs)
+	return runs, count, err
+}
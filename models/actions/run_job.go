@@ -0,0 +1,203 @@
+// Copyright 2022 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"xorm.io/builder"
+)
+
+// ErrRunJobNotExist represents an error for a not existing job
+var ErrRunJobNotExist = util.NewNotExistErrorf("run job does not exist")
+
+// ActionRunJob represents a job of a run
+type ActionRunJob struct {
+	ID              int64
+	RunID           int64      `xorm:"INDEX"`
+	Run             *ActionRun `xorm:"-"`
+	RepoID          int64      `xorm:"INDEX"`
+	OwnerID         int64      `xorm:"INDEX"`
+	CommitSHA       string     `xorm:"INDEX"`
+	Name            string     `xorm:"VARCHAR(255)"`
+	JobID           string     `xorm:"VARCHAR(255)"` // the key of the job in the workflow file
+	WorkflowPayload []byte     `xorm:"LONGBLOB"`
+	Needs           []string   `xorm:"JSON TEXT"`
+	RunsOn          []string   `xorm:"JSON TEXT"`
+	Status          Status     `xorm:"INDEX"`
+	RunnerID        int64      `xorm:"INDEX"`
+
+	// the token the job clones the repository with, it is only valid while the job is running
+	Token          string `xorm:"-"`
+	TokenHash      string // sha256 of token
+	TokenSalt      string
+	TokenLastEight string `xorm:"INDEX token_last_eight"`
+
+	LogFilename string // file name of the log in the actions log storage
+	LogSize     int64  // the size of the log in bytes
+	Started     timeutil.TimeStamp
+	Stopped     timeutil.TimeStamp
+	Created     timeutil.TimeStamp `xorm:"created"`
+	Updated     timeutil.TimeStamp `xorm:"updated INDEX"`
+}
+
+func init() {
+	db.RegisterModel(new(ActionRunJob))
+}
+
+// Duration returns the time the job has been running
+func (job *ActionRunJob) Duration() timeutil.TimeStamp {
+	if job.Started == 0 {
+		return 0
+	}
+	if job.Stopped == 0 {
+		return timeutil.TimeStampNow() - job.Started
+	}
+	return job.Stopped - job.Started
+}
+
+// LoadRun loads the run the job belongs to
+func (job *ActionRunJob) LoadRun(ctx context.Context) error {
+	if job.Run == nil {
+		run, err := GetRunByID(ctx, job.RunID)
+		if err != nil {
+			return err
+		}
+		job.Run = run
+	}
+	return nil
+}
+
+// LoadAttributes loads the run of the job and its attributes
+func (job *ActionRunJob) LoadAttributes(ctx context.Context) error {
+	if err := job.LoadRun(ctx); err != nil {
+		return err
+	}
+	return job.Run.LoadAttributes(ctx)
+}
+
+// Link returns the url of the job
+func (job *ActionRunJob) Link() string {
+	if job.Run == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s/jobs/%d", job.Run.Link(), job.ID)
+}
+
+// GenerateToken creates a new random token for the job, a job which runs again gets a new one
+func (job *ActionRunJob) GenerateToken() (err error) {
+	job.Token, job.TokenSalt, job.TokenHash, err = generateToken()
+	if err != nil {
+		return err
+	}
+	job.TokenLastEight = job.Token[len(job.Token)-8:]
+	return nil
+}
+
+// GetRunningJobByToken returns the running job the token was generated for
+func GetRunningJobByToken(ctx context.Context, token string) (*ActionRunJob, error) {
+	if len(token) < 8 {
+		return nil, ErrRunJobNotExist
+	}
+
+	jobs := make([]*ActionRunJob, 0, 1)
+	if err := db.GetEngine(ctx).
+		Where("token_last_eight = ? AND status = ?", token[len(token)-8:], StatusRunning).
+		Find(&jobs); err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		if subtle.ConstantTimeCompare([]byte(job.TokenHash), []byte(auth_model.HashToken(token, job.TokenSalt))) == 1 {
+			return job, nil
+		}
+	}
+	return nil, ErrRunJobNotExist
+}
+
+// GetRunJobByID returns the job with the given id
+func GetRunJobByID(ctx context.Context, id int64) (*ActionRunJob, error) {
+	job := &ActionRunJob{}
+	has, err := db.GetEngine(ctx).ID(id).Get(job)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrRunJobNotExist
+	}
+	return job, nil
+}
+
+// GetRunJobsByRunID returns all jobs of a run
+func GetRunJobsByRunID(ctx context.Context, runID int64) ([]*ActionRunJob, error) {
+	jobs := make([]*ActionRunJob, 0, 5)
+	return jobs, db.GetEngine(ctx).Where("run_id = ?", runID).OrderBy("id").Find(&jobs)
+}
+
+// UpdateRunJob updates the given columns of the job if it matches the condition.
+// It returns the number of updated rows, so callers can detect a lost race.
+func UpdateRunJob(ctx context.Context, job *ActionRunJob, cond builder.Cond, cols ...string) (int64, error) {
+	sess := db.GetEngine(ctx).ID(job.ID)
+	if len(cols) > 0 {
+		sess.Cols(cols...)
+	}
+	if cond != nil {
+		sess.Where(cond)
+	}
+	return sess.Update(job)
+}
+
+// FindRunJobOptions are options for FindRunJobs
+type FindRunJobOptions struct {
+	db.ListOptions
+	RunID         int64
+	RepoID        int64
+	OwnerID       int64
+	RunnerID      int64
+	AfterID       int64
+	Statuses      []Status
+	UpdatedBefore timeutil.TimeStamp
+}
+
+// ToConds converts the options into a condition
+func (opts FindRunJobOptions) ToConds() builder.Cond {
+	cond := builder.NewCond()
+	if opts.RunID > 0 {
+		cond = cond.And(builder.Eq{"run_id": opts.RunID})
+	}
+	if opts.RepoID > 0 {
+		cond = cond.And(builder.Eq{"repo_id": opts.RepoID})
+	}
+	if opts.OwnerID > 0 {
+		cond = cond.And(builder.Eq{"owner_id": opts.OwnerID})
+	}
+	if opts.RunnerID > 0 {
+		cond = cond.And(builder.Eq{"runner_id": opts.RunnerID})
+	}
+	if opts.AfterID > 0 {
+		cond = cond.And(builder.Gt{"id": opts.AfterID})
+	}
+	if len(opts.Statuses) > 0 {
+		cond = cond.And(builder.In("status", opts.Statuses))
+	}
+	if opts.UpdatedBefore > 0 {
+		cond = cond.And(builder.Lt{"updated": opts.UpdatedBefore})
+	}
+	return cond
+}
+
+// FindRunJobs returns the jobs matching the given options ordered by oldest first
+func FindRunJobs(ctx context.Context, opts FindRunJobOptions) ([]*ActionRunJob, error) {
+	jobs := make([]*ActionRunJob, 0, 10)
+	sess := db.GetEngine(ctx).Where(opts.ToConds()).OrderBy("id")
+	if opts.PageSize > 0 {
+		sess = db.SetSessionPagination(sess, &opts.ListOptions)
+	}
+	return jobs, sess.Find(&jobs)
+}
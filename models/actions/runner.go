@@ -0,0 +1,243 @@
+// Copyright 2022 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/hex"
+	"time"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	gouuid "github.com/google/uuid"
+	"xorm.io/builder"
+)
+
+// ErrRunnerNotExist represents an error for a not existing runner
+var ErrRunnerNotExist = util.NewNotExistErrorf("runner does not exist")
+
+// ActionRunner represents a runner which executes jobs for a repository, an owner or the whole instance
+type ActionRunner struct {
+	ID          int64
+	UUID        string                 `xorm:"CHAR(36) UNIQUE"`
+	Name        string                 `xorm:"VARCHAR(255)"`
+	Version     string                 `xorm:"VARCHAR(64)"`
+	OwnerID     int64                  `xorm:"INDEX"` // org level runner, 0 means system
+	Owner       *user_model.User       `xorm:"-"`
+	RepoID      int64                  `xorm:"INDEX"` // repo level runner, if OwnerID also is zero, then it's a global
+	Repo        *repo_model.Repository `xorm:"-"`
+	Description string                 `xorm:"TEXT"`
+	Labels      []string               `xorm:"TEXT JSON"`
+
+	Token          string `xorm:"-"`
+	TokenHash      string `xorm:"UNIQUE"` // sha256 of token
+	TokenSalt      string
+	TokenLastEight string `xorm:"INDEX token_last_eight"`
+
+	LastOnline timeutil.TimeStamp `xorm:"INDEX"`
+	LastActive timeutil.TimeStamp `xorm:"INDEX"`
+
+	Created timeutil.TimeStamp `xorm:"created"`
+	Updated timeutil.TimeStamp `xorm:"updated"`
+}
+
+func init() {
+	db.RegisterModel(new(ActionRunner))
+}
+
+// IsOnline returns true if the runner has polled for jobs recently
+func (r *ActionRunner) IsOnline() bool {
+	return r.LastOnline.AddDuration(setting.Actions.RunnerTimeout) > timeutil.TimeStampNow()
+}
+
+// BelongsToOwnerName returns the name of the scope the runner belongs to
+func (r *ActionRunner) BelongsToOwnerName() string {
+	if r.RepoID != 0 && r.Repo != nil {
+		return r.Repo.FullName()
+	}
+	if r.OwnerID != 0 && r.Owner != nil {
+		return r.Owner.Name
+	}
+	return ""
+}
+
+// CanMatchLabels returns true if the runner provides all the labels a job runs on
+func (r *ActionRunner) CanMatchLabels(runsOn []string) bool {
+	for _, label := range runsOn {
+		if !util.SliceContainsString(r.Labels, label, true) {
+			return false
+		}
+	}
+	return true
+}
+
+// CanServe returns true if the runner is allowed to execute jobs of the given repository
+func (r *ActionRunner) CanServe(repo *repo_model.Repository) bool {
+	switch {
+	case r.RepoID != 0:
+		return r.RepoID == repo.ID
+	case r.OwnerID != 0:
+		return r.OwnerID == repo.OwnerID
+	default:
+		return true
+	}
+}
+
+// LoadAttributes loads the owner and the repository of the runner
+func (r *ActionRunner) LoadAttributes(ctx context.Context) error {
+	if r.OwnerID > 0 && r.Owner == nil {
+		u, err := user_model.GetUserByID(ctx, r.OwnerID)
+		if err != nil {
+			return err
+		}
+		r.Owner = u
+	}
+	if r.RepoID > 0 && r.Repo == nil {
+		repo, err := repo_model.GetRepositoryByID(ctx, r.RepoID)
+		if err != nil {
+			return err
+		}
+		r.Repo = repo
+	}
+	return nil
+}
+
+// generateToken creates a new random token and returns it together with its salt and salted hash
+func generateToken() (token, salt, hash string, err error) {
+	salt, err = util.CryptoRandomString(10)
+	if err != nil {
+		return "", "", "", err
+	}
+	b, err := util.CryptoRandomBytes(20)
+	if err != nil {
+		return "", "", "", err
+	}
+	token = hex.EncodeToString(b)
+	return token, salt, auth_model.HashToken(token, salt), nil
+}
+
+// GenerateToken creates a new random token for the runner
+func (r *ActionRunner) GenerateToken() (err error) {
+	r.Token, r.TokenSalt, r.TokenHash, err = generateToken()
+	if err != nil {
+		return err
+	}
+	r.TokenLastEight = r.Token[len(r.Token)-8:]
+	return nil
+}
+
+// CreateRunner registers a new runner with a freshly generated token
+func CreateRunner(ctx context.Context, r *ActionRunner) error {
+	r.UUID = gouuid.New().String()
+	if err := r.GenerateToken(); err != nil {
+		return err
+	}
+	return db.Insert(ctx, r)
+}
+
+// GetRunnerByID returns the runner with the given id
+func GetRunnerByID(ctx context.Context, id int64) (*ActionRunner, error) {
+	r := &ActionRunner{}
+	has, err := db.GetEngine(ctx).ID(id).Get(r)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrRunnerNotExist
+	}
+	return r, nil
+}
+
+// GetRunnerByUUID returns the runner with the given uuid
+func GetRunnerByUUID(ctx context.Context, uuid string) (*ActionRunner, error) {
+	r := &ActionRunner{}
+	has, err := db.GetEngine(ctx).Where("uuid = ?", uuid).Get(r)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrRunnerNotExist
+	}
+	return r, nil
+}
+
+// GetRunnerByUUIDAndToken returns the runner with the given uuid if the token matches
+func GetRunnerByUUIDAndToken(ctx context.Context, uuid, token string) (*ActionRunner, error) {
+	if uuid == "" || token == "" {
+		return nil, ErrRunnerNotExist
+	}
+	r, err := GetRunnerByUUID(ctx, uuid)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(r.TokenHash), []byte(auth_model.HashToken(token, r.TokenSalt))) != 1 {
+		return nil, ErrRunnerNotExist
+	}
+	return r, nil
+}
+
+// UpdateRunner updates the given columns of the runner
+func UpdateRunner(ctx context.Context, r *ActionRunner, cols ...string) error {
+	e := db.GetEngine(ctx).ID(r.ID)
+	if len(cols) > 0 {
+		e.Cols(cols...)
+	}
+	_, err := e.Update(r)
+	return err
+}
+
+// UpdateRunnerLastOnline marks the runner as online
+func UpdateRunnerLastOnline(ctx context.Context, r *ActionRunner) error {
+	// avoid updating the database on every single poll
+	if r.LastOnline.AddDuration(time.Minute) > timeutil.TimeStampNow() {
+		return nil
+	}
+	r.LastOnline = timeutil.TimeStampNow()
+	return UpdateRunner(ctx, r, "last_online")
+}
+
+// DeleteRunner deletes the runner with the given id
+func DeleteRunner(ctx context.Context, id int64) error {
+	n, err := db.GetEngine(ctx).ID(id).Delete(&ActionRunner{})
+	if err != nil {
+		return err
+	} else if n == 0 {
+		return ErrRunnerNotExist
+	}
+	return nil
+}
+
+// FindRunnerOptions are options for FindRunners
+type FindRunnerOptions struct {
+	db.ListOptions
+	OwnerID int64
+	RepoID  int64
+}
+
+// ToConds converts the options into a condition
+func (opts FindRunnerOptions) ToConds() builder.Cond {
+	cond := builder.NewCond()
+	if opts.RepoID > 0 {
+		cond = cond.And(builder.Eq{"repo_id": opts.RepoID})
+	}
+	if opts.OwnerID > 0 {
+		cond = cond.And(builder.Eq{"owner_id": opts.OwnerID})
+	}
+	return cond
+}
+
+// FindRunners returns the runners matching the given options
+func FindRunners(ctx context.Context, opts FindRunnerOptions) ([]*ActionRunner, error) {
+	runners := make([]*ActionRunner, 0, 10)
+	sess := db.GetEngine(ctx).Where(opts.ToConds()).OrderBy("last_online DESC, id DESC")
+	if opts.PageSize > 0 {
+		sess = db.SetSessionPagination(sess, &opts.ListOptions)
+	}
+	return runners, sess.Find(&runners)
+}
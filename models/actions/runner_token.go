@@ -0,0 +1,82 @@
+// Copyright 2022 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// ErrRunnerTokenNotExist represents an error for a not existing runner registration token
+var ErrRunnerTokenNotExist = util.NewNotExistErrorf("runner token does not exist")
+
+// ActionRunnerToken represents a token used to register runners of a repository, an owner or the whole instance
+type ActionRunnerToken struct {
+	ID       int64
+	Token    string `xorm:"UNIQUE"`
+	OwnerID  int64  `xorm:"INDEX"` // org level runner, 0 means system
+	RepoID   int64  `xorm:"INDEX"` // repo level runner, if OwnerID also is zero, then it's a global
+	IsActive bool
+
+	Created timeutil.TimeStamp `xorm:"created"`
+	Updated timeutil.TimeStamp `xorm:"updated"`
+}
+
+func init() {
+	db.RegisterModel(new(ActionRunnerToken))
+}
+
+// GetRunnerToken returns an active runner registration token
+func GetRunnerToken(ctx context.Context, token string) (*ActionRunnerToken, error) {
+	t := &ActionRunnerToken{}
+	has, err := db.GetEngine(ctx).Where("token = ? AND is_active = ?", token, true).Get(t)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrRunnerTokenNotExist
+	}
+	return t, nil
+}
+
+// NewRunnerToken creates a new registration token and deactivates the previous ones of the scope
+func NewRunnerToken(ctx context.Context, ownerID, repoID int64) (*ActionRunnerToken, error) {
+	token, err := util.CryptoRandomString(40)
+	if err != nil {
+		return nil, err
+	}
+	t := &ActionRunnerToken{
+		Token:    token,
+		OwnerID:  ownerID,
+		RepoID:   repoID,
+		IsActive: true,
+	}
+
+	return t, db.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := db.GetEngine(ctx).
+			Where("owner_id = ? AND repo_id = ?", ownerID, repoID).
+			Cols("is_active").
+			Update(&ActionRunnerToken{IsActive: false}); err != nil {
+			return err
+		}
+		return db.Insert(ctx, t)
+	})
+}
+
+// GetOrCreateRunnerToken returns the active registration token of the scope and creates one if none exists
+func GetOrCreateRunnerToken(ctx context.Context, ownerID, repoID int64) (*ActionRunnerToken, error) {
+	t := &ActionRunnerToken{}
+	has, err := db.GetEngine(ctx).
+		Where("owner_id = ? AND repo_id = ? AND is_active = ?", ownerID, repoID, true).
+		OrderBy("id DESC").
+		Get(t)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return NewRunnerToken(ctx, ownerID, repoID)
+	}
+	return t, nil
+}
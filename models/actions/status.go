@@ -0,0 +1,104 @@
+// Copyright 2022 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// Status represents the status of ActionRun and ActionRunJob
+type Status int
+
+const (
+	StatusUnknown   Status = iota // 0
+	StatusSuccess                 // 1
+	StatusFailure                 // 2
+	StatusCancelled               // 3
+	StatusSkipped                 // 4
+	StatusWaiting                 // 5, waits for a runner to pick it up
+	StatusRunning                 // 6
+	StatusBlocked                 // 7, waits for the jobs it needs
+)
+
+var statusNames = map[Status]string{
+	StatusUnknown:   "unknown",
+	StatusWaiting:   "waiting",
+	StatusRunning:   "running",
+	StatusSuccess:   "success",
+	StatusFailure:   "failure",
+	StatusCancelled: "cancelled",
+	StatusSkipped:   "skipped",
+	StatusBlocked:   "blocked",
+}
+
+// String returns the string name of the Status
+func (s Status) String() string {
+	return statusNames[s]
+}
+
+// ParseStatus returns the Status matching the given name
+func ParseStatus(name string) (Status, bool) {
+	for s, n := range statusNames {
+		if n == name {
+			return s, true
+		}
+	}
+	return StatusUnknown, false
+}
+
+// IsDone returns true if the status is a final state
+func (s Status) IsDone() bool {
+	return s.In(StatusSuccess, StatusFailure, StatusCancelled, StatusSkipped)
+}
+
+// In returns true if the status is one of the given statuses
+func (s Status) In(statuses ...Status) bool {
+	for _, v := range statuses {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// ToCommitStatusState converts the Status to the state used by commit statuses
+func (s Status) ToCommitStatusState() api.CommitStatusState {
+	switch s {
+	case StatusSuccess, StatusSkipped:
+		return api.CommitStatusSuccess
+	case StatusFailure:
+		return api.CommitStatusFailure
+	case StatusCancelled:
+		return api.CommitStatusError
+	default:
+		return api.CommitStatusPending
+	}
+}
+
+// AggregateJobStatus returns the status of a run based on the status of its jobs
+func AggregateJobStatus(jobs []*ActionRunJob) Status {
+	allDone := true
+	allWaiting := true
+	hasFailure := false
+	hasCancelled := false
+	for _, job := range jobs {
+		allDone = allDone && job.Status.IsDone()
+		allWaiting = allWaiting && job.Status.In(StatusWaiting, StatusBlocked)
+		hasFailure = hasFailure || job.Status == StatusFailure
+		hasCancelled = hasCancelled || job.Status == StatusCancelled
+	}
+
+	switch {
+	case allDone && hasFailure:
+		return StatusFailure
+	case allDone && hasCancelled:
+		return StatusCancelled
+	case allDone:
+		return StatusSuccess
+	case allWaiting:
+		return StatusWaiting
+	default:
+		return StatusRunning
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2022 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregateJobStatus(t *testing.T) {
+	jobsWith := func(statuses ...Status) []*ActionRunJob {
+		jobs := make([]*ActionRunJob, 0, len(statuses))
+		for _, s := range statuses {
+			jobs = append(jobs, &ActionRunJob{Status: s})
+		}
+		return jobs
+	}
+
+	cases := []struct {
+		Statuses []Status
+		Expected Status
+	}{
+		{[]Status{StatusWaiting, StatusBlocked}, StatusWaiting},
+		{[]Status{StatusRunning, StatusBlocked}, StatusRunning},
+		{[]Status{StatusSuccess, StatusWaiting}, StatusRunning},
+		{[]Status{StatusSuccess, StatusSkipped}, StatusSuccess},
+		{[]Status{StatusSuccess, StatusFailure, StatusCancelled}, StatusFailure},
+		{[]Status{StatusSuccess, StatusCancelled}, StatusCancelled},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.Expected, AggregateJobStatus(jobsWith(c.Statuses...)), "statuses: %v", c.Statuses)
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+	for s, name := range statusNames {
+		parsed, ok := ParseStatus(name)
+		assert.True(t, ok)
+		assert.Equal(t, s, parsed)
+	}
+
+	_, ok := ParseStatus("invalid")
+	assert.False(t, ok)
+}
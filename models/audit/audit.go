@@ -0,0 +1,190 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package audit
+
+import (
+	"context"
+	"strings"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// Action is the kind of an audit event
+type Action string
+
+// The actions which are audited
+const (
+	ActionUserSiteAdminGrant  Action = "user.site_admin.grant"
+	ActionUserSiteAdminRevoke Action = "user.site_admin.revoke"
+	ActionUserProhibitLogin   Action = "user.prohibit_login"
+	ActionUserAllowLogin      Action = "user.allow_login"
+	ActionUserDelete          Action = "user.delete"
+
+	ActionAccessTokenCreate Action = "access_token.create"
+	ActionAccessTokenDelete Action = "access_token.delete"
+
+	ActionTwoFactorEnable  Action = "two_factor.enable"
+	ActionTwoFactorDisable Action = "two_factor.disable"
+	ActionWebAuthnAdd      Action = "webauthn.add"
+	ActionWebAuthnRemove   Action = "webauthn.remove"
+
+	ActionCollaboratorAdd        Action = "repository.collaborator.add"
+	ActionCollaboratorRemove     Action = "repository.collaborator.remove"
+	ActionCollaboratorAccessMode Action = "repository.collaborator.access_mode"
+
+	ActionTeamCreate       Action = "organization.team.create"
+	ActionTeamUpdate       Action = "organization.team.update"
+	ActionTeamDelete       Action = "organization.team.delete"
+	ActionTeamMemberAdd    Action = "organization.team.member.add"
+	ActionTeamMemberRemove Action = "organization.team.member.remove"
+
+	ActionBranchProtectionUpdate Action = "repository.branch_protection.update"
+	ActionBranchProtectionDelete Action = "repository.branch_protection.delete"
+
+	ActionDeployKeyAdd    Action = "repository.deploy_key.add"
+	ActionDeployKeyDelete Action = "repository.deploy_key.delete"
+
+	ActionRepositoryVisibility Action = "repository.visibility"
+	ActionRepositoryDelete     Action = "repository.delete"
+)
+
+// IsValid checks whether the action is audited
+func (a Action) IsValid() bool {
+	for _, action := range Actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// Actions are all the actions which are audited
+var Actions = []Action{
+	ActionUserSiteAdminGrant,
+	ActionUserSiteAdminRevoke,
+	ActionUserProhibitLogin,
+	ActionUserAllowLogin,
+	ActionUserDelete,
+	ActionAccessTokenCreate,
+	ActionAccessTokenDelete,
+	ActionTwoFactorEnable,
+	ActionTwoFactorDisable,
+	ActionWebAuthnAdd,
+	ActionWebAuthnRemove,
+	ActionCollaboratorAdd,
+	ActionCollaboratorRemove,
+	ActionCollaboratorAccessMode,
+	ActionTeamCreate,
+	ActionTeamUpdate,
+	ActionTeamDelete,
+	ActionTeamMemberAdd,
+	ActionTeamMemberRemove,
+	ActionBranchProtectionUpdate,
+	ActionBranchProtectionDelete,
+	ActionDeployKeyAdd,
+	ActionDeployKeyDelete,
+	ActionRepositoryVisibility,
+	ActionRepositoryDelete,
+}
+
+// ScopeType is the type of the object which an audit event belongs to
+type ScopeType string
+
+// The scope types
+const (
+	ScopeSystem       ScopeType = "system"
+	ScopeUser         ScopeType = "user"
+	ScopeOrganization ScopeType = "organization"
+	ScopeRepository   ScopeType = "repository"
+)
+
+// ScopeTypes are all the scope types
+var ScopeTypes = []ScopeType{ScopeSystem, ScopeUser, ScopeOrganization, ScopeRepository}
+
+// Event is an audit event, the events are never updated or deleted.
+// The names of the actor and the scope are kept because they outlive them.
+type Event struct {
+	ID          int64     `xorm:"pk autoincr"`
+	Action      Action    `xorm:"INDEX NOT NULL"`
+	ActorID     int64     `xorm:"INDEX"`
+	ActorName   string    `xorm:"NOT NULL"`
+	ScopeType   ScopeType `xorm:"INDEX(scope) NOT NULL"`
+	ScopeID     int64     `xorm:"INDEX(scope)"`
+	ScopeName   string
+	Message     string             `xorm:"TEXT"`
+	IPAddress   string             `xorm:"VARCHAR(64)"`
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+}
+
+func init() {
+	db.RegisterModel(new(Event))
+}
+
+// TableName sets the table name of the audit events
+func (Event) TableName() string {
+	return "audit_event"
+}
+
+// InsertEvent appends an event to the audit log
+func InsertEvent(ctx context.Context, e *Event) error {
+	return db.Insert(ctx, e)
+}
+
+// FindEventsOptions are options for FindEvents
+type FindEventsOptions struct {
+	db.ListOptions
+	Action    Action
+	ActorID   int64
+	ActorName string
+	ScopeType ScopeType
+	ScopeID   int64
+	ScopeName string
+	Since     timeutil.TimeStamp
+	Before    timeutil.TimeStamp
+}
+
+// ToConds converts the options into a condition
+func (opts FindEventsOptions) ToConds() builder.Cond {
+	cond := builder.NewCond()
+	if opts.Action != "" {
+		cond = cond.And(builder.Eq{"action": opts.Action})
+	}
+	if opts.ActorID > 0 {
+		cond = cond.And(builder.Eq{"actor_id": opts.ActorID})
+	}
+	// the names are kept as they were when the events happened, so they are compared instead of the ids of deleted users
+	if opts.ActorName != "" {
+		cond = cond.And(builder.Expr("LOWER(actor_name) = ?", strings.ToLower(opts.ActorName)))
+	}
+	if opts.ScopeType != "" {
+		cond = cond.And(builder.Eq{"scope_type": opts.ScopeType})
+		if opts.ScopeID > 0 {
+			cond = cond.And(builder.Eq{"scope_id": opts.ScopeID})
+		}
+	}
+	if opts.ScopeName != "" {
+		cond = cond.And(builder.Expr("LOWER(scope_name) = ?", strings.ToLower(opts.ScopeName)))
+	}
+	if opts.Since > 0 {
+		cond = cond.And(builder.Gte{"created_unix": opts.Since})
+	}
+	if opts.Before > 0 {
+		cond = cond.And(builder.Lt{"created_unix": opts.Before})
+	}
+	return cond
+}
+
+// FindEvents returns the events matching the given options ordered by newest first
+func FindEvents(ctx context.Context, opts FindEventsOptions) ([]*Event, int64, error) {
+	events := make([]*Event, 0, 10)
+	sess := db.GetEngine(ctx).Where(opts.ToConds()).OrderBy("id DESC")
+	if opts.PageSize > 0 {
+		sess = db.SetSessionPagination(sess, &opts.ListOptions)
+	}
+	count, err := sess.FindAndCount(&events)
+	return events, count, err
+}
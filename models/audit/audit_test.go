@@ -0,0 +1,59 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package audit_test
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models/audit"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindEvents(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+	// there are no fixtures for the audit events, so they are not reset by PrepareTestDatabase
+	assert.NoError(t, db.DeleteAllRecords("audit_event"))
+
+	for _, e := range []*audit.Event{
+		{Action: audit.ActionAccessTokenCreate, ActorID: 2, ActorName: "user2", ScopeType: audit.ScopeUser, ScopeID: 2, ScopeName: "user2", CreatedUnix: 100},
+		{Action: audit.ActionCollaboratorAdd, ActorID: 2, ActorName: "user2", ScopeType: audit.ScopeRepository, ScopeID: 1, ScopeName: "user2/repo1", CreatedUnix: 200},
+		{Action: audit.ActionUserSiteAdminGrant, ActorID: 1, ActorName: "user1", ScopeType: audit.ScopeUser, ScopeID: 4, ScopeName: "user4", CreatedUnix: 300},
+	} {
+		// the times of the events are kept
+		_, err := db.GetEngine(db.DefaultContext).NoAutoTime().Insert(e)
+		assert.NoError(t, err)
+	}
+
+	events, count, err := audit.FindEvents(db.DefaultContext, audit.FindEventsOptions{})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, count)
+	if assert.Len(t, events, 3) {
+		assert.Equal(t, audit.ActionUserSiteAdminGrant, events[0].Action)
+	}
+
+	events, count, err = audit.FindEvents(db.DefaultContext, audit.FindEventsOptions{ActorID: 2, ListOptions: db.ListOptions{Page: 1, PageSize: 1}})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, audit.ActionCollaboratorAdd, events[0].Action)
+	}
+
+	_, count, err = audit.FindEvents(db.DefaultContext, audit.FindEventsOptions{ScopeType: audit.ScopeRepository, ScopeID: 1})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+
+	_, count, err = audit.FindEvents(db.DefaultContext, audit.FindEventsOptions{ActorName: "USER2", ScopeName: "user2"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+
+	events, count, err = audit.FindEvents(db.DefaultContext, audit.FindEventsOptions{Since: 150, Before: 300})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "user2/repo1", events[0].ScopeName)
+	}
+}
@@ -259,6 +259,10 @@ func deleteOAuth2Application(ctx context.Context, id, userid int64) error {
 	if _, err := sess.Where("application_id = ?", id).Delete(new(OAuth2Grant)); err != nil {
 		return err
 	}
+
+	if _, err := sess.Where("application_id = ?", id).Delete(new(OAuth2DeviceCode)); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -436,6 +440,23 @@ func (grant *OAuth2Grant) ScopeContains(scope string) bool {
 	return false
 }
 
+// AddScope merges the space separated scopes into the scope of the grant
+func (grant *OAuth2Grant) AddScope(ctx context.Context, scope string) error {
+	scopes := strings.Fields(grant.Scope)
+	for _, s := range strings.Fields(scope) {
+		if !grant.ScopeContains(s) {
+			scopes = append(scopes, s)
+		}
+	}
+	merged := strings.Join(scopes, " ")
+	if merged == grant.Scope {
+		return nil
+	}
+	grant.Scope = merged
+	_, err := db.GetEngine(ctx).ID(grant.ID).Cols("scope").Update(grant)
+	return err
+}
+
 // SetNonce updates the current nonce value of a grant
 func (grant *OAuth2Grant) SetNonce(ctx context.Context, nonce string) error {
 	grant.Nonce = nonce
@@ -491,6 +512,22 @@ func RevokeOAuth2Grant(ctx context.Context, grantID, userID int64) error {
 	return err
 }
 
+// RevokeOAuth2GrantsByUserID deletes all grants of the user together with their authorization codes and
+// the device codes the user approved, so the access and refresh tokens issued for them stop working
+func RevokeOAuth2GrantsByUserID(ctx context.Context, userID int64) error {
+	grantIDs := builder.Select("id").From("oauth2_grant").Where(builder.Eq{"oauth2_grant.user_id": userID})
+
+	if _, err := db.GetEngine(ctx).In("grant_id", grantIDs).
+		Delete(&OAuth2AuthorizationCode{}); err != nil {
+		return err
+	}
+
+	return db.DeleteBeans(ctx,
+		&OAuth2Grant{UserID: userID},
+		&OAuth2DeviceCode{UserID: userID},
+	)
+}
+
 // ErrOAuthClientIDInvalid will be thrown if client id cannot be found
 type ErrOAuthClientIDInvalid struct {
 	ClientID string
@@ -558,16 +595,12 @@ func GetActiveOAuth2SourceByName(name string) (*Source, error) {
 }
 
 func DeleteOAuth2RelictsByUserID(ctx context.Context, userID int64) error {
-	deleteCond := builder.Select("id").From("oauth2_grant").Where(builder.Eq{"oauth2_grant.user_id": userID})
-
-	if _, err := db.GetEngine(ctx).In("grant_id", deleteCond).
-		Delete(&OAuth2AuthorizationCode{}); err != nil {
+	if err := RevokeOAuth2GrantsByUserID(ctx, userID); err != nil {
 		return err
 	}
 
 	if err := db.DeleteBeans(ctx,
 		&OAuth2Application{UID: userID},
-		&OAuth2Grant{UserID: userID},
 	); err != nil {
 		return fmt.Errorf("DeleteBeans: %w", err)
 	}
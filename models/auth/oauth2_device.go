@@ -0,0 +1,253 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"xorm.io/builder"
+)
+
+// OAuth2DeviceCodeStatus represents the state of a device authorization request
+type OAuth2DeviceCodeStatus int
+
+const (
+	// OAuth2DeviceCodeStatusPending the user has not yet approved or denied the request
+	OAuth2DeviceCodeStatusPending OAuth2DeviceCodeStatus = iota
+	// OAuth2DeviceCodeStatusApproved the user has approved the request
+	OAuth2DeviceCodeStatusApproved
+	// OAuth2DeviceCodeStatusDenied the user has denied the request
+	OAuth2DeviceCodeStatusDenied
+)
+
+// userCodeChars excludes vowels and look-alike characters, see https://datatracker.ietf.org/doc/html/rfc8628#section-6.1
+const (
+	userCodeChars  = "BCDFGHJKLMNPQRSTVWXZ"
+	userCodeLength = 8
+)
+
+// OAuth2DeviceCode is a pending device authorization request (RFC 8628).
+// The device polls the token endpoint with the device code while the user
+// enters the user code on the verification page.
+type OAuth2DeviceCode struct {
+	ID             int64              `xorm:"pk autoincr"`
+	Application    *OAuth2Application `xorm:"-"`
+	ApplicationID  int64              `xorm:"INDEX"`
+	DeviceCode     string             `xorm:"INDEX unique"`
+	UserCode       string             `xorm:"INDEX unique"`
+	Scope          string             `xorm:"TEXT"`
+	ClientAddress  string             `xorm:"INDEX"` // the address the device has requested the code from
+	UserID         int64              `xorm:"INDEX"`
+	Status         OAuth2DeviceCodeStatus
+	Interval       int64
+	LastPolledUnix timeutil.TimeStamp
+	ValidUntil     timeutil.TimeStamp `xorm:"index"`
+	CreatedUnix    timeutil.TimeStamp `xorm:"created"`
+}
+
+func init() {
+	db.RegisterModel(new(OAuth2DeviceCode))
+}
+
+// TableName sets the table name to `oauth2_device_code`
+func (code *OAuth2DeviceCode) TableName() string {
+	return "oauth2_device_code"
+}
+
+// IsExpired returns true if the device code can no longer be used
+func (code *OAuth2DeviceCode) IsExpired() bool {
+	return code.ValidUntil <= timeutil.TimeStampNow()
+}
+
+// FormattedUserCode returns the user code split into two groups to make it easier to read and type
+func (code *OAuth2DeviceCode) FormattedUserCode() string {
+	return code.UserCode[:userCodeLength/2] + "-" + code.UserCode[userCodeLength/2:]
+}
+
+// LoadApplication loads the application the device code was issued to
+func (code *OAuth2DeviceCode) LoadApplication(ctx context.Context) (err error) {
+	if code.Application != nil {
+		return nil
+	}
+	code.Application, err = GetOAuth2ApplicationByID(ctx, code.ApplicationID)
+	return err
+}
+
+// Poll records a poll of the token endpoint by the device. It returns true if the device
+// polled faster than its interval, in which case the interval is increased by 5 seconds
+// as required by https://datatracker.ietf.org/doc/html/rfc8628#section-3.5
+func (code *OAuth2DeviceCode) Poll(ctx context.Context) (tooFast bool, err error) {
+	now := timeutil.TimeStampNow()
+	if code.LastPolledUnix > 0 && now < code.LastPolledUnix.Add(code.Interval) {
+		code.Interval += 5
+		tooFast = true
+	}
+	code.LastPolledUnix = now
+	_, err = db.GetEngine(ctx).ID(code.ID).Cols("interval", "last_polled_unix").Update(code)
+	return tooFast, err
+}
+
+// setStatus changes the status of a pending device code, it fails if the code has been used concurrently
+func (code *OAuth2DeviceCode) setStatus(ctx context.Context, userID int64, status OAuth2DeviceCodeStatus) error {
+	code.UserID = userID
+	code.Status = status
+	affected, err := db.GetEngine(ctx).
+		Where(builder.Eq{"id": code.ID, "status": OAuth2DeviceCodeStatusPending}).
+		Cols("user_id", "status").
+		Update(code)
+	if err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrOAuthDeviceCodeNotFound{UserCode: code.UserCode}
+	}
+	return nil
+}
+
+// Approve marks the device code as approved by the given user
+func (code *OAuth2DeviceCode) Approve(ctx context.Context, userID int64) error {
+	return code.setStatus(ctx, userID, OAuth2DeviceCodeStatusApproved)
+}
+
+// Deny marks the device code as denied by the given user
+func (code *OAuth2DeviceCode) Deny(ctx context.Context, userID int64) error {
+	return code.setStatus(ctx, userID, OAuth2DeviceCodeStatusDenied)
+}
+
+// Invalidate deletes the device code from the database so it can't be used twice
+func (code *OAuth2DeviceCode) Invalidate(ctx context.Context) (bool, error) {
+	affected, err := db.GetEngine(ctx).ID(code.ID).NoAutoCondition().Delete(code)
+	return affected > 0, err
+}
+
+func generateUserCode() (string, error) {
+	var sb strings.Builder
+	max := big.NewInt(int64(len(userCodeChars)))
+	for i := 0; i < userCodeLength; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteByte(userCodeChars[n.Int64()])
+	}
+	return sb.String(), nil
+}
+
+// NormalizeUserCode removes the separators and converts the user code entered by the user to upper case
+func NormalizeUserCode(userCode string) string {
+	return strings.ToUpper(strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, userCode))
+}
+
+// CreateOAuth2DeviceCode creates a new device code for the application requested from the given client address.
+// Expired device codes are removed at the same time.
+func CreateOAuth2DeviceCode(ctx context.Context, app *OAuth2Application, scope, clientAddress string) (*OAuth2DeviceCode, error) {
+	if _, err := db.GetEngine(ctx).Where(builder.Lte{"valid_until": timeutil.TimeStampNow()}).Delete(new(OAuth2DeviceCode)); err != nil {
+		return nil, err
+	}
+
+	rBytes, err := util.CryptoRandomBytes(32)
+	if err != nil {
+		return nil, err
+	}
+	code := &OAuth2DeviceCode{
+		Application:   app,
+		ApplicationID: app.ID,
+		// Add a prefix to the base32, this is in order to make it easier
+		// for code scanners to grab sensitive tokens.
+		DeviceCode:    "gtd_" + base32Lower.EncodeToString(rBytes),
+		Scope:         scope,
+		ClientAddress: clientAddress,
+		Status:        OAuth2DeviceCodeStatusPending,
+		Interval:      setting.OAuth2.DeviceCodePollingInterval,
+		ValidUntil:    timeutil.TimeStampNow().Add(setting.OAuth2.DeviceCodeExpirationTime),
+	}
+
+	// the user code is short, so retry a few times in the unlikely case of a collision
+	for i := 0; i < 5; i++ {
+		if code.UserCode, err = generateUserCode(); err != nil {
+			return nil, err
+		}
+		has, err := db.GetEngine(ctx).Exist(&OAuth2DeviceCode{UserCode: code.UserCode})
+		if err != nil {
+			return nil, err
+		} else if !has {
+			if err := db.Insert(ctx, code); err != nil {
+				return nil, err
+			}
+			return code, nil
+		}
+	}
+	return nil, fmt.Errorf("unable to generate a unique user code")
+}
+
+// CountOAuth2DeviceCodesSince returns the number of device codes which have been issued to an application since the given time.
+// If the client address is not empty, only the device codes requested from this address are counted.
+func CountOAuth2DeviceCodesSince(ctx context.Context, appID int64, clientAddress string, since timeutil.TimeStamp) (int64, error) {
+	cond := builder.Eq{"application_id": appID}.And(builder.Gte{"created_unix": since})
+	if clientAddress != "" {
+		cond = cond.And(builder.Eq{"client_address": clientAddress})
+	}
+	return db.GetEngine(ctx).Where(cond).Count(new(OAuth2DeviceCode))
+}
+
+// GetOAuth2DeviceCodeByDeviceCode returns the device code by the code polled by the device
+func GetOAuth2DeviceCodeByDeviceCode(ctx context.Context, deviceCode string) (*OAuth2DeviceCode, error) {
+	code := new(OAuth2DeviceCode)
+	if has, err := db.GetEngine(ctx).Where("device_code = ?", deviceCode).Get(code); err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrOAuthDeviceCodeNotFound{}
+	}
+	return code, nil
+}
+
+// GetOAuth2DeviceCodeByUserCode returns the unexpired pending device code by the code entered by the user
+func GetOAuth2DeviceCodeByUserCode(ctx context.Context, userCode string) (*OAuth2DeviceCode, error) {
+	userCode = NormalizeUserCode(userCode)
+	if len(userCode) != userCodeLength {
+		return nil, ErrOAuthDeviceCodeNotFound{UserCode: userCode}
+	}
+	code := new(OAuth2DeviceCode)
+	if has, err := db.GetEngine(ctx).Where(builder.Eq{"user_code": userCode, "status": OAuth2DeviceCodeStatusPending}.
+		And(builder.Gt{"valid_until": timeutil.TimeStampNow()})).Get(code); err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrOAuthDeviceCodeNotFound{UserCode: userCode}
+	}
+	return code, nil
+}
+
+// ErrOAuthDeviceCodeNotFound will be thrown if a device code or user code is unknown, expired or already used
+type ErrOAuthDeviceCodeNotFound struct {
+	UserCode string
+}
+
+// IsErrOAuthDeviceCodeNotFound checks if an error is a ErrOAuthDeviceCodeNotFound.
+func IsErrOAuthDeviceCodeNotFound(err error) bool {
+	_, ok := err.(ErrOAuthDeviceCodeNotFound)
+	return ok
+}
+
+// Error returns the error message
+func (err ErrOAuthDeviceCodeNotFound) Error() string {
+	return fmt.Sprintf("OAuth device code not found [user_code: %s]", err.UserCode)
+}
+
+// Unwrap unwraps this as a ErrNotExist err
+func (err ErrOAuthDeviceCodeNotFound) Unwrap() error {
+	return util.ErrNotExist
+}
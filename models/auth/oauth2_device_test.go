@@ -0,0 +1,106 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package auth_test
+
+import (
+	"testing"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeUserCode(t *testing.T) {
+	assert.Equal(t, "BCDFGHJK", auth_model.NormalizeUserCode("bcdf-ghjk"))
+	assert.Equal(t, "BCDFGHJK", auth_model.NormalizeUserCode("BCDF GHJK"))
+}
+
+func TestOAuth2DeviceCode(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+	app := unittest.AssertExistsAndLoadBean(t, &auth_model.OAuth2Application{ID: 2})
+
+	code, err := auth_model.CreateOAuth2DeviceCode(db.DefaultContext, app, "openid", "192.0.2.1")
+	assert.NoError(t, err)
+	assert.Len(t, code.UserCode, 8)
+	assert.Regexp(t, `^[A-Z]{4}-[A-Z]{4}$`, code.FormattedUserCode())
+	assert.False(t, code.IsExpired())
+
+	found, err := auth_model.GetOAuth2DeviceCodeByUserCode(db.DefaultContext, code.FormattedUserCode())
+	assert.NoError(t, err)
+	assert.Equal(t, code.ID, found.ID)
+
+	found, err = auth_model.GetOAuth2DeviceCodeByDeviceCode(db.DefaultContext, code.DeviceCode)
+	assert.NoError(t, err)
+	assert.Equal(t, code.UserCode, found.UserCode)
+
+	_, err = auth_model.GetOAuth2DeviceCodeByDeviceCode(db.DefaultContext, "gtd_unknown")
+	assert.True(t, auth_model.IsErrOAuthDeviceCodeNotFound(err))
+
+	// the first poll is always allowed, an immediate second one is too fast
+	tooFast, err := found.Poll(db.DefaultContext)
+	assert.NoError(t, err)
+	assert.False(t, tooFast)
+	tooFast, err = found.Poll(db.DefaultContext)
+	assert.NoError(t, err)
+	assert.True(t, tooFast)
+	unittest.AssertExistsAndLoadBean(t, &auth_model.OAuth2DeviceCode{ID: code.ID, Interval: code.Interval + 5})
+
+	assert.NoError(t, code.Approve(db.DefaultContext, 2))
+	unittest.AssertExistsAndLoadBean(t, &auth_model.OAuth2DeviceCode{ID: code.ID, UserID: 2, Status: auth_model.OAuth2DeviceCodeStatusApproved})
+	// a code can only be approved or denied once
+	assert.True(t, auth_model.IsErrOAuthDeviceCodeNotFound(code.Deny(db.DefaultContext, 2)))
+	_, err = auth_model.GetOAuth2DeviceCodeByUserCode(db.DefaultContext, code.UserCode)
+	assert.True(t, auth_model.IsErrOAuthDeviceCodeNotFound(err))
+
+	deleted, err := code.Invalidate(db.DefaultContext)
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+	unittest.AssertNotExistsBean(t, &auth_model.OAuth2DeviceCode{ID: code.ID})
+}
+
+func TestCountOAuth2DeviceCodesSince(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+	app := unittest.AssertExistsAndLoadBean(t, &auth_model.OAuth2Application{ID: 2})
+
+	now := timeutil.TimeStampNow()
+	_, err := auth_model.CreateOAuth2DeviceCode(db.DefaultContext, app, "", "192.0.2.1")
+	assert.NoError(t, err)
+
+	_, err = auth_model.CreateOAuth2DeviceCode(db.DefaultContext, app, "", "192.0.2.2")
+	assert.NoError(t, err)
+
+	count, err := auth_model.CountOAuth2DeviceCodesSince(db.DefaultContext, app.ID, "", now)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+	count, err = auth_model.CountOAuth2DeviceCodesSince(db.DefaultContext, app.ID, "192.0.2.1", now)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+	count, err = auth_model.CountOAuth2DeviceCodesSince(db.DefaultContext, app.ID, "192.0.2.3", now)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, count)
+	count, err = auth_model.CountOAuth2DeviceCodesSince(db.DefaultContext, app.ID+1, "", now)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, count)
+}
+
+func TestCreateOAuth2DeviceCode_RemovesExpired(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+	app := unittest.AssertExistsAndLoadBean(t, &auth_model.OAuth2Application{ID: 2})
+
+	expired, err := auth_model.CreateOAuth2DeviceCode(db.DefaultContext, app, "", "192.0.2.1")
+	assert.NoError(t, err)
+	_, err = db.GetEngine(db.DefaultContext).ID(expired.ID).Cols("valid_until").
+		Update(&auth_model.OAuth2DeviceCode{ValidUntil: timeutil.TimeStampNow() - 1})
+	assert.NoError(t, err)
+
+	_, err = auth_model.GetOAuth2DeviceCodeByUserCode(db.DefaultContext, expired.UserCode)
+	assert.True(t, auth_model.IsErrOAuthDeviceCodeNotFound(err))
+
+	_, err = auth_model.CreateOAuth2DeviceCode(db.DefaultContext, app, "", "192.0.2.1")
+	assert.NoError(t, err)
+	unittest.AssertNotExistsBean(t, &auth_model.OAuth2DeviceCode{ID: expired.ID})
+}
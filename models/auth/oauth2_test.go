@@ -149,6 +149,14 @@ func TestOAuth2Grant_ScopeContains(t *testing.T) {
 	assert.False(t, grant.ScopeContains("profile2"))
 }
 
+func TestOAuth2Grant_AddScope(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+	grant := unittest.AssertExistsAndLoadBean(t, &auth_model.OAuth2Grant{ID: 1, Scope: "openid profile"})
+	assert.NoError(t, grant.AddScope(db.DefaultContext, "profile email"))
+	assert.Equal(t, "openid profile email", grant.Scope)
+	unittest.AssertExistsAndLoadBean(t, &auth_model.OAuth2Grant{ID: 1, Scope: "openid profile email"})
+}
+
 func TestOAuth2Grant_GenerateNewAuthorizationCode(t *testing.T) {
 	assert.NoError(t, unittest.PrepareTestDatabase())
 	grant := unittest.AssertExistsAndLoadBean(t, &auth_model.OAuth2Grant{ID: 1})
@@ -0,0 +1,94 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	"context"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// SCIMResourceType is the type of a resource which is provisioned by SCIM
+type SCIMResourceType int
+
+// The resource types
+const (
+	SCIMResourceUser  SCIMResourceType = iota + 1 // 1
+	SCIMResourceGroup                             // 2
+)
+
+// SCIMExternalID is the identifier which a SCIM client has given to a user or a team
+type SCIMExternalID struct {
+	ID          int64              `xorm:"pk autoincr"`
+	Type        SCIMResourceType   `xorm:"UNIQUE(s) UNIQUE(e) NOT NULL"`
+	ResourceID  int64              `xorm:"UNIQUE(s) NOT NULL"`
+	ExternalID  string             `xorm:"UNIQUE(e) NOT NULL"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+}
+
+func init() {
+	db.RegisterModel(new(SCIMExternalID))
+}
+
+// GetSCIMExternalIDs returns the external ids of the given resources of a type by their resource id
+func GetSCIMExternalIDs(ctx context.Context, typ SCIMResourceType, resourceIDs []int64) (map[int64]string, error) {
+	ids := make([]*SCIMExternalID, 0, len(resourceIDs))
+	if len(resourceIDs) > 0 {
+		if err := db.GetEngine(ctx).Where(builder.Eq{"type": typ}.And(builder.In("resource_id", resourceIDs))).Find(&ids); err != nil {
+			return nil, err
+		}
+	}
+	result := make(map[int64]string, len(ids))
+	for _, id := range ids {
+		result[id.ResourceID] = id.ExternalID
+	}
+	return result, nil
+}
+
+// GetSCIMExternalID returns the external id of a resource, it is empty if the client has given none
+func GetSCIMExternalID(ctx context.Context, typ SCIMResourceType, resourceID int64) (string, error) {
+	id := &SCIMExternalID{}
+	has, err := db.GetEngine(ctx).Where("type = ? AND resource_id = ?", typ, resourceID).Get(id)
+	if err != nil || !has {
+		return "", err
+	}
+	return id.ExternalID, nil
+}
+
+// GetSCIMResourceID returns the id of the resource with the external id, it is 0 if there is no such resource
+func GetSCIMResourceID(ctx context.Context, typ SCIMResourceType, externalID string) (int64, error) {
+	id := &SCIMExternalID{}
+	has, err := db.GetEngine(ctx).Where("type = ? AND external_id = ?", typ, externalID).Get(id)
+	if err != nil || !has {
+		return 0, err
+	}
+	return id.ResourceID, nil
+}
+
+// SetSCIMExternalID sets the external id of a resource, an empty external id removes it
+func SetSCIMExternalID(ctx context.Context, typ SCIMResourceType, resourceID int64, externalID string) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		e := db.GetEngine(ctx)
+		id := &SCIMExternalID{}
+		has, err := e.Where("type = ? AND resource_id = ?", typ, resourceID).Get(id)
+		if err != nil {
+			return err
+		}
+		switch {
+		case externalID == "" && has:
+			_, err = e.ID(id.ID).Delete(id)
+		case externalID == "" || (has && id.ExternalID == externalID):
+		case has:
+			id.ExternalID = externalID
+			_, err = e.ID(id.ID).Cols("external_id").Update(id)
+		default:
+			_, err = e.Insert(&SCIMExternalID{Type: typ, ResourceID: resourceID, ExternalID: externalID})
+		}
+		return err
+	})
+}
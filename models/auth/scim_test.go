@@ -0,0 +1,52 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package auth_test
+
+import (
+	"testing"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSCIMExternalID(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	assert.NoError(t, auth_model.SetSCIMExternalID(db.DefaultContext, auth_model.SCIMResourceUser, 2, "ext-2"))
+	assert.NoError(t, auth_model.SetSCIMExternalID(db.DefaultContext, auth_model.SCIMResourceGroup, 2, "ext-2"))
+	assert.NoError(t, auth_model.SetSCIMExternalID(db.DefaultContext, auth_model.SCIMResourceUser, 4, "ext-4"))
+
+	externalID, err := auth_model.GetSCIMExternalID(db.DefaultContext, auth_model.SCIMResourceUser, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "ext-2", externalID)
+
+	// the external ids are unique per resource type
+	assert.Error(t, auth_model.SetSCIMExternalID(db.DefaultContext, auth_model.SCIMResourceUser, 5, "ext-4"))
+
+	assert.NoError(t, auth_model.SetSCIMExternalID(db.DefaultContext, auth_model.SCIMResourceUser, 2, "ext-two"))
+	assert.NoError(t, auth_model.SetSCIMExternalID(db.DefaultContext, auth_model.SCIMResourceUser, 4, ""))
+
+	externalIDs, err := auth_model.GetSCIMExternalIDs(db.DefaultContext, auth_model.SCIMResourceUser, []int64{2, 4})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64]string{2: "ext-two"}, externalIDs)
+
+	externalIDs, err = auth_model.GetSCIMExternalIDs(db.DefaultContext, auth_model.SCIMResourceGroup, []int64{4})
+	assert.NoError(t, err)
+	assert.Empty(t, externalIDs)
+
+	resourceID, err := auth_model.GetSCIMResourceID(db.DefaultContext, auth_model.SCIMResourceUser, "ext-two")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, resourceID)
+
+	resourceID, err = auth_model.GetSCIMResourceID(db.DefaultContext, auth_model.SCIMResourceUser, "ext-4")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, resourceID)
+
+	externalID, err = auth_model.GetSCIMExternalID(db.DefaultContext, auth_model.SCIMResourceUser, 4)
+	assert.NoError(t, err)
+	assert.Empty(t, externalID)
+}
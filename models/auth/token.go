@@ -5,6 +5,7 @@
 package auth
 
 import (
+	"context"
 	"crypto/subtle"
 	"encoding/hex"
 	"fmt"
@@ -67,6 +68,13 @@ type AccessToken struct {
 	TokenLastEight string `xorm:"INDEX token_last_eight"`
 	Scope          AccessTokenScope
 
+	// A fine-grained token can only access the repositories of ResourceOwnerID, either all of them or only RepoIDs,
+	// with at most the access mode of Units. It always expires.
+	ResourceOwnerID int64            `xorm:"INDEX NOT NULL DEFAULT 0"`
+	RepoIDs         []int64          `xorm:"JSON TEXT"`
+	Units           AccessTokenUnits `xorm:"JSON TEXT"`
+
+	ExpiresUnix       timeutil.TimeStamp `xorm:"NOT NULL DEFAULT 0"`
 	CreatedUnix       timeutil.TimeStamp `xorm:"INDEX created"`
 	UpdatedUnix       timeutil.TimeStamp `xorm:"INDEX updated"`
 	HasRecentActivity bool               `xorm:"-"`
@@ -154,6 +162,9 @@ func GetAccessTokenBySHA(token string) (*AccessToken, error) {
 			return nil, err
 		}
 		if has {
+			if token.IsExpired() {
+				return nil, ErrAccessTokenNotExist{lastEight}
+			}
 			return token, nil
 		}
 		successfulAccessTokenCache.Remove(token)
@@ -170,6 +181,9 @@ func GetAccessTokenBySHA(token string) (*AccessToken, error) {
 	for _, t := range tokens {
 		tempHash := HashToken(token, t.TokenSalt)
 		if subtle.ConstantTimeCompare([]byte(t.TokenHash), []byte(tempHash)) == 1 {
+			if t.IsExpired() {
+				return nil, ErrAccessTokenNotExist{lastEight}
+			}
 			if successfulAccessTokenCache != nil {
 				successfulAccessTokenCache.Add(token, t.ID)
 			}
@@ -227,6 +241,12 @@ func CountAccessTokens(opts ListAccessTokensOptions) (int64, error) {
 	return sess.Count(&AccessToken{})
 }
 
+// DeleteAccessTokensByUserID deletes all access tokens of the user
+func DeleteAccessTokensByUserID(ctx context.Context, userID int64) error {
+	_, err := db.GetEngine(ctx).Where("uid = ?", userID).Delete(&AccessToken{})
+	return err
+}
+
 // DeleteAccessTokenByID deletes access token by given ID.
 func DeleteAccessTokenByID(id, userID int64) error {
 	cnt, err := db.GetEngine(db.DefaultContext).ID(id).Delete(&AccessToken{
@@ -0,0 +1,91 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	"code.gitea.io/gitea/models/perm"
+	"code.gitea.io/gitea/models/unit"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// AccessTokenUnits maps the repository units a fine-grained access token can access to the granted access mode
+type AccessTokenUnits map[unit.Type]perm.AccessMode
+
+// FineGrainedTokenUnit is a repository unit which can be granted to a fine-grained access token
+type FineGrainedTokenUnit struct {
+	Name string
+	Type unit.Type
+}
+
+// FineGrainedTokenUnits are the repository units which can be granted to a fine-grained access token
+var FineGrainedTokenUnits = []FineGrainedTokenUnit{
+	{"code", unit.TypeCode},
+	{"issues", unit.TypeIssues},
+	{"pulls", unit.TypePullRequests},
+	{"releases", unit.TypeReleases},
+	{"packages", unit.TypePackages},
+}
+
+// FineGrainedTokenUnitByName returns the unit type of a fine-grained token unit name
+func FineGrainedTokenUnitByName(name string) (unit.Type, bool) {
+	for _, u := range FineGrainedTokenUnits {
+		if u.Name == name {
+			return u.Type, true
+		}
+	}
+	return unit.TypeInvalid, false
+}
+
+// IsFineGrained returns true if the token is restricted to the repositories of a user or an organization
+func (t *AccessToken) IsFineGrained() bool {
+	return t.ResourceOwnerID > 0
+}
+
+// IsExpired returns true if the token has an expiry date in the past
+func (t *AccessToken) IsExpired() bool {
+	return t.ExpiresUnix > 0 && t.ExpiresUnix <= timeutil.TimeStampNow()
+}
+
+// CanAccessRepo returns true if the token isn't restricted or the repository is one the token is restricted to
+func (t *AccessToken) CanAccessRepo(repoID, ownerID int64) bool {
+	if !t.IsFineGrained() {
+		return true
+	}
+	if ownerID != t.ResourceOwnerID {
+		return false
+	}
+	if len(t.RepoIDs) == 0 {
+		return true
+	}
+	for _, id := range t.RepoIDs {
+		if id == repoID {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAccessOwner returns true if the token isn't restricted or restricted to the given user or organization
+func (t *AccessToken) CanAccessOwner(ownerID int64) bool {
+	return !t.IsFineGrained() || ownerID == t.ResourceOwnerID
+}
+
+// UnitAccessMode returns the maximum access mode the token grants to the unit, which is unlimited for tokens which aren't fine-grained
+func (t *AccessToken) UnitAccessMode(unitType unit.Type) perm.AccessMode {
+	if !t.IsFineGrained() {
+		return perm.AccessModeOwner
+	}
+	return t.Units[unitType]
+}
+
+// UnitPermissions returns the access modes granted by a fine-grained token by unit name
+func (t *AccessToken) UnitPermissions() map[string]string {
+	permissions := make(map[string]string, len(t.Units))
+	for _, u := range FineGrainedTokenUnits {
+		if mode := t.Units[u.Type]; mode > perm.AccessModeNone {
+			permissions[u.Name] = mode.String()
+		}
+	}
+	return permissions
+}
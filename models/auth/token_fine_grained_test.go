@@ -0,0 +1,63 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package auth_test
+
+import (
+	"testing"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/perm"
+	"code.gitea.io/gitea/models/unit"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessToken_CanAccessRepo(t *testing.T) {
+	token := &auth_model.AccessToken{}
+	assert.False(t, token.IsFineGrained())
+	assert.True(t, token.CanAccessRepo(1, 2))
+	assert.EqualValues(t, perm.AccessModeOwner, token.UnitAccessMode(unit.TypeCode))
+
+	token = &auth_model.AccessToken{
+		ResourceOwnerID: 3,
+		Units:           auth_model.AccessTokenUnits{unit.TypeCode: perm.AccessModeRead},
+	}
+	assert.True(t, token.IsFineGrained())
+	assert.True(t, token.CanAccessRepo(3, 3))
+	assert.True(t, token.CanAccessRepo(32, 3))
+	assert.False(t, token.CanAccessRepo(1, 2))
+	assert.True(t, token.CanAccessOwner(3))
+	assert.False(t, token.CanAccessOwner(2))
+	assert.EqualValues(t, perm.AccessModeRead, token.UnitAccessMode(unit.TypeCode))
+	assert.EqualValues(t, perm.AccessModeNone, token.UnitAccessMode(unit.TypeIssues))
+
+	token.RepoIDs = []int64{3}
+	assert.True(t, token.CanAccessRepo(3, 3))
+	assert.False(t, token.CanAccessRepo(32, 3))
+}
+
+func TestGetAccessTokenBySHA_Expired(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+	token := &auth_model.AccessToken{
+		UID:             2,
+		Name:            "Fine-grained",
+		ResourceOwnerID: 2,
+		RepoIDs:         []int64{1},
+		Units:           auth_model.AccessTokenUnits{unit.TypeIssues: perm.AccessModeWrite},
+		ExpiresUnix:     timeutil.TimeStampNow().Add(60),
+	}
+	assert.NoError(t, auth_model.NewAccessToken(token))
+
+	loaded, err := auth_model.GetAccessTokenBySHA(token.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1}, loaded.RepoIDs)
+	assert.EqualValues(t, perm.AccessModeWrite, loaded.Units[unit.TypeIssues])
+
+	loaded.ExpiresUnix = timeutil.TimeStampNow() - 1
+	assert.NoError(t, auth_model.UpdateAccessToken(loaded))
+	_, err = auth_model.GetAccessTokenBySHA(token.Token)
+	assert.True(t, auth_model.IsErrAccessTokenNotExist(err))
+}
@@ -0,0 +1,208 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package issues
+
+import (
+	"context"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/util"
+
+	"xorm.io/builder"
+)
+
+// IssueSearchOptions represents the conditions of an issue search which is executed by the database
+type IssueSearchOptions struct {
+	Keyword           string
+	RepoIDs           []int64
+	IsPull            util.OptionalBool
+	IsClosed          util.OptionalBool
+	IncludedLabelIDs  [][]int64 // issues must have one label of every group
+	ExcludedLabelIDs  []int64
+	MilestoneIDs      []int64
+	PosterID          int64
+	AssigneeID        int64
+	CreatedAfterUnix  int64
+	CreatedBeforeUnix int64
+	UpdatedAfterUnix  int64
+	UpdatedBeforeUnix int64
+	SortType          string
+	Limit             int
+	Start             int
+}
+
+func (opts *IssueSearchOptions) toConds(withState bool) builder.Cond {
+	repoCond := builder.In("issue.repo_id", opts.RepoIDs)
+	cond := repoCond
+
+	if opts.Keyword != "" {
+		cond = cond.And(builder.Or(
+			db.BuildCaseInsensitiveLike("issue.name", opts.Keyword),
+			db.BuildCaseInsensitiveLike("issue.content", opts.Keyword),
+			builder.In("issue.id", builder.Select("issue_id").
+				From("comment").
+				Where(builder.And(
+					builder.Eq{"type": CommentTypeComment},
+					builder.In("issue_id", builder.Select("id").From("issue").Where(builder.In("repo_id", opts.RepoIDs))),
+					db.BuildCaseInsensitiveLike("content", opts.Keyword),
+				)),
+			),
+		))
+	}
+
+	if !opts.IsPull.IsNone() {
+		cond = cond.And(builder.Eq{"issue.is_pull": opts.IsPull.IsTrue()})
+	}
+	if withState && !opts.IsClosed.IsNone() {
+		cond = cond.And(builder.Eq{"issue.is_closed": opts.IsClosed.IsTrue()})
+	}
+
+	for _, labelIDs := range opts.IncludedLabelIDs {
+		cond = cond.And(builder.In("issue.id", builder.Select("issue_id").From("issue_label").Where(builder.In("label_id", labelIDs))))
+	}
+	if len(opts.ExcludedLabelIDs) > 0 {
+		cond = cond.And(builder.NotIn("issue.id", builder.Select("issue_id").From("issue_label").Where(builder.In("label_id", opts.ExcludedLabelIDs))))
+	}
+	if len(opts.MilestoneIDs) > 0 {
+		cond = cond.And(builder.In("issue.milestone_id", opts.MilestoneIDs))
+	}
+	if opts.PosterID != 0 {
+		cond = cond.And(builder.Eq{"issue.poster_id": opts.PosterID})
+	}
+	if opts.AssigneeID != 0 {
+		cond = cond.And(builder.In("issue.id", builder.Select("issue_id").From("issue_assignees").Where(builder.Eq{"assignee_id": opts.AssigneeID})))
+	}
+
+	if opts.CreatedAfterUnix != 0 {
+		cond = cond.And(builder.Gte{"issue.created_unix": opts.CreatedAfterUnix})
+	}
+	if opts.CreatedBeforeUnix != 0 {
+		cond = cond.And(builder.Lte{"issue.created_unix": opts.CreatedBeforeUnix})
+	}
+	if opts.UpdatedAfterUnix != 0 {
+		cond = cond.And(builder.Gte{"issue.updated_unix": opts.UpdatedAfterUnix})
+	}
+	if opts.UpdatedBeforeUnix != 0 {
+		cond = cond.And(builder.Lte{"issue.updated_unix": opts.UpdatedBeforeUnix})
+	}
+
+	return cond
+}
+
+// SearchIssueIDs returns the ids of the issues matching the options and the total number of matching issues
+func SearchIssueIDs(ctx context.Context, opts *IssueSearchOptions) (int64, []int64, error) {
+	cond := opts.toConds(true)
+
+	sess := db.GetEngine(ctx).Table("issue").Select("issue.id").Where(cond)
+	sortType := opts.SortType
+	if sortType == "relevance" {
+		// the database has no relevance score, show the recently updated issues first
+		sortType = "recentupdate"
+	}
+	sortIssuesSession(sess, sortType, 0)
+	if opts.Limit > 0 {
+		sess.Limit(opts.Limit, opts.Start)
+	}
+
+	ids := make([]int64, 0, opts.Limit)
+	if err := sess.Find(&ids); err != nil {
+		return 0, nil, err
+	}
+
+	total, err := db.GetEngine(ctx).Table("issue").Where(cond).Count()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return total, ids, nil
+}
+
+// IssueSearchFacets contains the number of issues matching the search options grouped by different fields
+type IssueSearchFacets struct {
+	OpenCount   int64
+	ClosedCount int64
+	Repos       map[int64]int64
+	Labels      map[int64]int64
+	Milestones  map[int64]int64
+}
+
+// CountIssueSearchFacets counts the issues matching the options grouped by repository, label and milestone
+// and counts the open and closed issues ignoring the state filter of the options.
+func CountIssueSearchFacets(ctx context.Context, opts *IssueSearchOptions) (*IssueSearchFacets, error) {
+	e := db.GetEngine(ctx)
+
+	facets := &IssueSearchFacets{
+		Repos:      make(map[int64]int64),
+		Labels:     make(map[int64]int64),
+		Milestones: make(map[int64]int64),
+	}
+
+	var states []struct {
+		IsClosed bool
+		Count    int64
+	}
+	if err := e.Table("issue").Select("issue.is_closed AS is_closed, COUNT(*) AS count").Where(opts.toConds(false)).GroupBy("issue.is_closed").Find(&states); err != nil {
+		return nil, err
+	}
+	for _, s := range states {
+		if s.IsClosed {
+			facets.ClosedCount = s.Count
+		} else {
+			facets.OpenCount = s.Count
+		}
+	}
+
+	cond := opts.toConds(true)
+	type groupCount struct {
+		ID    int64
+		Count int64
+	}
+	count := func(m map[int64]int64, sess db.Engine, column string, extraCond builder.Cond) error {
+		var counts []groupCount
+		if err := sess.Select(column + " AS id, COUNT(*) AS count").Where(builder.And(cond, extraCond)).GroupBy(column).Find(&counts); err != nil {
+			return err
+		}
+		for _, c := range counts {
+			m[c.ID] = c.Count
+		}
+		return nil
+	}
+
+	if err := count(facets.Repos, e.Table("issue"), "issue.repo_id", builder.NewCond()); err != nil {
+		return nil, err
+	}
+	if err := count(facets.Labels, e.Table("issue").Join("INNER", "issue_label", "issue_label.issue_id = issue.id"), "issue_label.label_id", builder.NewCond()); err != nil {
+		return nil, err
+	}
+	if err := count(facets.Milestones, e.Table("issue"), "issue.milestone_id", builder.Gt{"issue.milestone_id": 0}); err != nil {
+		return nil, err
+	}
+
+	return facets, nil
+}
+
+// GetIssuesWithAttributesByIDs returns the issues in the order of the ids and loads their attributes,
+// ids of issues which do not exist are skipped
+func GetIssuesWithAttributesByIDs(ctx context.Context, ids []int64) (IssueList, error) {
+	issues, err := GetIssuesByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	issueMap := make(map[int64]*Issue, len(issues))
+	for _, issue := range issues {
+		issueMap[issue.ID] = issue
+	}
+	ordered := make(IssueList, 0, len(issues))
+	for _, id := range ids {
+		if issue, ok := issueMap[id]; ok {
+			ordered = append(ordered, issue)
+		}
+	}
+
+	if err := ordered.loadAttributes(ctx); err != nil {
+		return nil, err
+	}
+	return ordered, nil
+}
@@ -381,6 +381,19 @@ func GetLabelIDsInRepoByNames(repoID int64, labelNames []string) ([]int64, error
 		Find(&labelIDs)
 }
 
+// GetLabelsByNamesInRepos returns the labels with the given names which can be used in the repositories,
+// this includes the labels of the organizations owning the repositories.
+func GetLabelsByNamesInRepos(ctx context.Context, repoIDs []int64, labelNames []string) ([]*Label, error) {
+	labels := make([]*Label, 0, len(labelNames))
+	return labels, db.GetEngine(ctx).
+		Where(builder.Or(
+			builder.In("repo_id", repoIDs),
+			builder.In("org_id", builder.Select("owner_id").From("repository").Where(builder.In("id", repoIDs))),
+		)).
+		In("name", labelNames).
+		Find(&labels)
+}
+
 // BuildLabelNamesIssueIDsCondition returns a builder where get issue ids match label names
 func BuildLabelNamesIssueIDsCondition(labelNames []string) *builder.Builder {
 	return builder.Select("issue_label.issue_id").
@@ -158,6 +158,16 @@ func GetMilestoneByRepoIDANDName(repoID int64, name string) (*Milestone, error)
 	return &mile, nil
 }
 
+// GetMilestoneIDsByNamesInRepos returns the ids of the milestones with the given names in the repositories
+func GetMilestoneIDsByNamesInRepos(ctx context.Context, repoIDs []int64, names []string) ([]int64, error) {
+	ids := make([]int64, 0, len(names))
+	return ids, db.GetEngine(ctx).Table("milestone").
+		In("repo_id", repoIDs).
+		In("name", names).
+		Cols("id").
+		Find(&ids)
+}
+
 // UpdateMilestone updates information of given milestone.
 func UpdateMilestone(m *Milestone, oldIsClosed bool) error {
 	ctx, committer, err := db.TxContext(db.DefaultContext)
@@ -453,6 +453,28 @@ var migrations = []Migration{
 	NewMigration("Add updated unix to LFSMetaObject", v1_19.AddUpdatedUnixToLFSMetaObject),
 	// v239 -> v240
 	NewMigration("Add scope for access_token", v1_19.AddScopeForAccessTokens),
+	// v240 -> v241
+	NewMigration("Add actions tables", v1_19.AddActionsTables),
+	// v241 -> v242
+	NewMigration("Add package remote table", v1_19.CreatePackageRemoteTable),
+	// v242 -> v243
+	NewMigration("Add code indexer refs to repository and repo_indexer_status", v1_19.AddCodeIndexerRefs),
+	// v243 -> v244
+	NewMigration("Add object format name to repository and widen commit id columns", v1_19.AddObjectFormatNameToRepository),
+	// v244 -> v245
+	NewMigration("Add partial clone and protocol v2 settings to repository", v1_19.AddGitServiceSettingsToRepository),
+	// v245 -> v246
+	NewMigration("Create repo bundle table", v1_19.CreateRepoBundleTable),
+	// v246 -> v247
+	NewMigration("Create repo maintenance table", v1_19.CreateRepoMaintenanceTable),
+	// v247 -> v248
+	NewMigration("Create scim external id table", v1_19.CreateSCIMExternalIDTable),
+	// v248 -> v249
+	NewMigration("Create audit event table", v1_19.CreateAuditEventTable),
+	// v249 -> v250
+	NewMigration("Create oauth2 device code table", v1_19.CreateOAuth2DeviceCodeTable),
+	// v250 -> v251
+	NewMigration("Add fine-grained columns to access token", v1_19.AddFineGrainedColumnsToAccessToken),
 }
 
 // GetCurrentDBVersion returns the current db version
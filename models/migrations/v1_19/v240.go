@@ -0,0 +1,101 @@
+// Copyright 2022 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_19 //nolint
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func AddActionsTables(x *xorm.Engine) error {
+	type ActionRunner struct {
+		ID          int64
+		UUID        string   `xorm:"CHAR(36) UNIQUE"`
+		Name        string   `xorm:"VARCHAR(255)"`
+		Version     string   `xorm:"VARCHAR(64)"`
+		OwnerID     int64    `xorm:"INDEX"`
+		RepoID      int64    `xorm:"INDEX"`
+		Description string   `xorm:"TEXT"`
+		Labels      []string `xorm:"TEXT JSON"`
+
+		TokenHash      string `xorm:"UNIQUE"`
+		TokenSalt      string
+		TokenLastEight string `xorm:"INDEX token_last_eight"`
+
+		LastOnline timeutil.TimeStamp `xorm:"INDEX"`
+		LastActive timeutil.TimeStamp `xorm:"INDEX"`
+
+		Created timeutil.TimeStamp `xorm:"created"`
+		Updated timeutil.TimeStamp `xorm:"updated"`
+	}
+
+	type ActionRunnerToken struct {
+		ID       int64
+		Token    string `xorm:"UNIQUE"`
+		OwnerID  int64  `xorm:"INDEX"`
+		RepoID   int64  `xorm:"INDEX"`
+		IsActive bool
+
+		Created timeutil.TimeStamp `xorm:"created"`
+		Updated timeutil.TimeStamp `xorm:"updated"`
+	}
+
+	type ActionRun struct {
+		ID                int64
+		Title             string
+		RepoID            int64  `xorm:"INDEX UNIQUE(repo_index)"`
+		OwnerID           int64  `xorm:"INDEX"`
+		WorkflowID        string `xorm:"INDEX"`
+		Index             int64  `xorm:"INDEX UNIQUE(repo_index)"`
+		TriggerUserID     int64  `xorm:"INDEX"`
+		Ref               string `xorm:"INDEX"`
+		CommitSHA         string
+		Event             string
+		EventPayload      string `xorm:"LONGTEXT"`
+		IsForkPullRequest bool
+		Status            int `xorm:"INDEX"`
+		Started           timeutil.TimeStamp
+		Stopped           timeutil.TimeStamp
+		Created           timeutil.TimeStamp `xorm:"created"`
+		Updated           timeutil.TimeStamp `xorm:"updated"`
+	}
+
+	type ActionRunJob struct {
+		ID              int64
+		RunID           int64    `xorm:"INDEX"`
+		RepoID          int64    `xorm:"INDEX"`
+		OwnerID         int64    `xorm:"INDEX"`
+		CommitSHA       string   `xorm:"INDEX"`
+		Name            string   `xorm:"VARCHAR(255)"`
+		JobID           string   `xorm:"VARCHAR(255)"`
+		WorkflowPayload []byte   `xorm:"LONGBLOB"`
+		Needs           []string `xorm:"JSON TEXT"`
+		RunsOn          []string `xorm:"JSON TEXT"`
+		Status          int      `xorm:"INDEX"`
+		RunnerID        int64    `xorm:"INDEX"`
+		TokenHash       string
+		TokenSalt       string
+		TokenLastEight  string `xorm:"INDEX token_last_eight"`
+		LogFilename     string
+		LogSize         int64
+		Started         timeutil.TimeStamp
+		Stopped         timeutil.TimeStamp
+		Created         timeutil.TimeStamp `xorm:"created"`
+		Updated         timeutil.TimeStamp `xorm:"updated INDEX"`
+	}
+
+	type ActionRunIndex struct {
+		GroupID  int64 `xorm:"pk"`
+		MaxIndex int64 `xorm:"index"`
+	}
+
+	return x.Sync(
+		new(ActionRunner),
+		new(ActionRunnerToken),
+		new(ActionRun),
+		new(ActionRunJob),
+		new(ActionRunIndex),
+	)
+}
@@ -0,0 +1,25 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_19 //nolint
+
+import (
+	"xorm.io/xorm"
+)
+
+func AddCodeIndexerRefs(x *xorm.Engine) error {
+	type Repository struct {
+		CodeIndexerBranches string `xorm:"TEXT"`
+		CodeIndexerTags     string `xorm:"TEXT"`
+	}
+
+	if err := x.Sync2(new(Repository)); err != nil {
+		return err
+	}
+
+	type RepoIndexerStatus struct {
+		Ref string `xorm:"VARCHAR(255) NOT NULL DEFAULT ''"`
+	}
+
+	return x.Sync2(new(RepoIndexerStatus))
+}
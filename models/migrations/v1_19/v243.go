@@ -0,0 +1,54 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_19 //nolint
+
+import (
+	"code.gitea.io/gitea/models/migrations/base"
+
+	"xorm.io/xorm"
+	"xorm.io/xorm/schemas"
+)
+
+func AddObjectFormatNameToRepository(x *xorm.Engine) error {
+	type Repository struct {
+		ObjectFormatName string `xorm:"VARCHAR(6) NOT NULL DEFAULT 'sha1'"`
+	}
+
+	if err := x.Sync2(new(Repository)); err != nil {
+		return err
+	}
+
+	// For SQLITE, the max length doesn't matter.
+	if x.Dialect().URI().DBType == schemas.SQLITE {
+		return nil
+	}
+
+	// the commit ids of the SHA-256 repositories are 64 characters long
+	for _, col := range []struct {
+		table, name string
+		nullable    bool
+	}{
+		{"pull_request", "merge_base", true},
+		{"pull_request", "merged_commit_id", true},
+		{"review", "commit_id", true},
+		{"review_state", "commit_sha", false},
+		{"comment", "commit_sha", true},
+		{"release", "sha1", true},
+		{"repo_archiver", "commit_id", true},
+		{"repo_indexer_status", "commit_sha", true},
+	} {
+		if err := base.ModifyColumn(x, col.table, &schemas.Column{
+			Name: col.name,
+			SQLType: schemas.SQLType{
+				Name: "VARCHAR",
+			},
+			Length:         64,
+			Nullable:       col.nullable,
+			DefaultIsEmpty: true,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
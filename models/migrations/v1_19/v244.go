@@ -0,0 +1,17 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_19 //nolint
+
+import (
+	"xorm.io/xorm"
+)
+
+func AddGitServiceSettingsToRepository(x *xorm.Engine) error {
+	type Repository struct {
+		DisablePartialClone bool `xorm:"NOT NULL DEFAULT false"`
+		DisableProtocolV2   bool `xorm:"NOT NULL DEFAULT false"`
+	}
+
+	return x.Sync2(new(Repository))
+}
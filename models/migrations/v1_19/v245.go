@@ -0,0 +1,22 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_19 //nolint
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func CreateRepoBundleTable(x *xorm.Engine) error {
+	type RepoBundle struct {
+		ID            int64              `xorm:"pk autoincr"`
+		RepoID        int64              `xorm:"UNIQUE"`
+		RefsHash      string             `xorm:"VARCHAR(64)"`
+		Size          int64              `xorm:"NOT NULL DEFAULT 0"`
+		GeneratedUnix timeutil.TimeStamp `xorm:"NOT NULL"`
+	}
+
+	return x.Sync2(new(RepoBundle))
+}
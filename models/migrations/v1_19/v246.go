@@ -0,0 +1,27 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_19 //nolint
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func CreateRepoMaintenanceTable(x *xorm.Engine) error {
+	type RepoMaintenance struct {
+		ID           int64 `xorm:"pk autoincr"`
+		RepoID       int64 `xorm:"UNIQUE"`
+		PushCount    int64 `xorm:"INDEX NOT NULL DEFAULT 0"`
+		LooseObjects int64 `xorm:"NOT NULL DEFAULT 0"`
+		Packs        int64 `xorm:"NOT NULL DEFAULT 0"`
+		LooseRefs    int64 `xorm:"NOT NULL DEFAULT 0"`
+		LastTasks    string
+		LastError    string             `xorm:"TEXT"`
+		LastDuration int64              `xorm:"NOT NULL DEFAULT 0"`
+		LastRunUnix  timeutil.TimeStamp `xorm:"INDEX"`
+	}
+
+	return x.Sync2(new(RepoMaintenance))
+}
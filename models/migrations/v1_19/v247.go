@@ -0,0 +1,23 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_19 //nolint
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func CreateSCIMExternalIDTable(x *xorm.Engine) error {
+	type SCIMExternalID struct {
+		ID          int64              `xorm:"pk autoincr"`
+		Type        int                `xorm:"UNIQUE(s) UNIQUE(e) NOT NULL"`
+		ResourceID  int64              `xorm:"UNIQUE(s) NOT NULL"`
+		ExternalID  string             `xorm:"UNIQUE(e) NOT NULL"`
+		CreatedUnix timeutil.TimeStamp `xorm:"created"`
+		UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+	}
+
+	return x.Sync2(new(SCIMExternalID))
+}
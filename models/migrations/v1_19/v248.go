@@ -0,0 +1,31 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_19 //nolint
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+type auditEvent struct {
+	ID          int64  `xorm:"pk autoincr"`
+	Action      string `xorm:"INDEX NOT NULL"`
+	ActorID     int64  `xorm:"INDEX"`
+	ActorName   string `xorm:"NOT NULL"`
+	ScopeType   string `xorm:"INDEX(scope) NOT NULL"`
+	ScopeID     int64  `xorm:"INDEX(scope)"`
+	ScopeName   string
+	Message     string             `xorm:"TEXT"`
+	IPAddress   string             `xorm:"VARCHAR(64)"`
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+}
+
+func (auditEvent) TableName() string {
+	return "audit_event"
+}
+
+func CreateAuditEventTable(x *xorm.Engine) error {
+	return x.Sync2(new(auditEvent))
+}
@@ -0,0 +1,33 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_19 //nolint
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+type oauth2DeviceCode struct {
+	ID             int64  `xorm:"pk autoincr"`
+	ApplicationID  int64  `xorm:"INDEX"`
+	DeviceCode     string `xorm:"INDEX unique"`
+	UserCode       string `xorm:"INDEX unique"`
+	Scope          string `xorm:"TEXT"`
+	ClientAddress  string `xorm:"INDEX"`
+	UserID         int64  `xorm:"INDEX"`
+	Status         int
+	Interval       int64
+	LastPolledUnix timeutil.TimeStamp
+	ValidUntil     timeutil.TimeStamp `xorm:"index"`
+	CreatedUnix    timeutil.TimeStamp `xorm:"created"`
+}
+
+func (oauth2DeviceCode) TableName() string {
+	return "oauth2_device_code"
+}
+
+func CreateOAuth2DeviceCodeTable(x *xorm.Engine) error {
+	return x.Sync2(new(oauth2DeviceCode))
+}
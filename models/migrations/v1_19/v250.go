@@ -0,0 +1,21 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_19 //nolint
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func AddFineGrainedColumnsToAccessToken(x *xorm.Engine) error {
+	type AccessToken struct {
+		ResourceOwnerID int64              `xorm:"INDEX NOT NULL DEFAULT 0"`
+		RepoIDs         []int64            `xorm:"JSON TEXT"`
+		Units           map[int]int        `xorm:"JSON TEXT"`
+		ExpiresUnix     timeutil.TimeStamp `xorm:"NOT NULL DEFAULT 0"`
+	}
+
+	return x.Sync2(new(AccessToken))
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"strings"
 
+	auth_model "code.gitea.io/gitea/models/auth"
 	"code.gitea.io/gitea/models/db"
 	git_model "code.gitea.io/gitea/models/git"
 	issues_model "code.gitea.io/gitea/models/issues"
@@ -414,6 +415,7 @@ func DeleteTeam(t *organization.Team) error {
 		&organization.TeamUser{OrgID: t.OrgID, TeamID: t.ID},
 		&organization.TeamUnit{TeamID: t.ID},
 		&organization.TeamInvite{TeamID: t.ID},
+		&auth_model.SCIMExternalID{Type: auth_model.SCIMResourceGroup, ResourceID: t.ID},
 	); err != nil {
 		return err
 	}
@@ -47,10 +47,14 @@ func (t TeamList) UnitMaxAccess(tp unit.Type) perm.AccessMode {
 // SearchTeamOptions holds the search options
 type SearchTeamOptions struct {
 	db.ListOptions
-	UserID      int64
-	Keyword     string
-	OrgID       int64
-	IncludeDesc bool
+	Paginator        db.Paginator // paginates by offset instead of the page of ListOptions if set
+	TeamID           int64
+	UserID           int64
+	Keyword          string
+	Name             string // the case-insensitive name of the team
+	OrgID            int64
+	IncludeDesc      bool
+	ExcludeOwnerTeam bool
 }
 
 func (opts *SearchTeamOptions) toCond() builder.Cond {
@@ -65,10 +69,22 @@ func (opts *SearchTeamOptions) toCond() builder.Cond {
 		cond = cond.And(keywordCond)
 	}
 
+	if len(opts.Name) > 0 {
+		cond = cond.And(builder.Eq{"`team`.lower_name": strings.ToLower(opts.Name)})
+	}
+
+	if opts.TeamID > 0 {
+		cond = cond.And(builder.Eq{"`team`.id": opts.TeamID})
+	}
+
 	if opts.OrgID > 0 {
 		cond = cond.And(builder.Eq{"`team`.org_id": opts.OrgID})
 	}
 
+	if opts.ExcludeOwnerTeam {
+		cond = cond.And(builder.Neq{"`team`.lower_name": strings.ToLower(OwnerTeamName)})
+	}
+
 	if opts.UserID > 0 {
 		cond = cond.And(builder.Eq{"team_user.uid": opts.UserID})
 	}
@@ -86,7 +102,11 @@ func SearchTeam(opts *SearchTeamOptions) (TeamList, int64, error) {
 	if opts.UserID > 0 {
 		sess = sess.Join("INNER", "team_user", "team_user.team_id = team.id")
 	}
-	sess = db.SetSessionPagination(sess, opts)
+	if opts.Paginator != nil {
+		sess = db.SetSessionPagination(sess, opts.Paginator)
+	} else {
+		sess = db.SetSessionPagination(sess, opts)
+	}
 
 	teams := make([]*Team, 0, opts.PageSize)
 	count, err := sess.Where(cond).OrderBy("lower_name").FindAndCount(&teams)
@@ -153,6 +153,24 @@ func TestGetUserTeams(t *testing.T) {
 	test(unittest.NonexistentID)
 }
 
+func TestSearchTeam(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+	test := func(opts *organization.SearchTeamOptions, expectedIDs []int64, expectedCount int64) {
+		teams, count, err := organization.SearchTeam(opts)
+		assert.NoError(t, err)
+		assert.EqualValues(t, expectedCount, count)
+		ids := make([]int64, 0, len(teams))
+		for _, team := range teams {
+			ids = append(ids, team.ID)
+		}
+		assert.Equal(t, expectedIDs, ids)
+	}
+	test(&organization.SearchTeamOptions{OrgID: 3, ExcludeOwnerTeam: true}, []int64{2, 12, 7}, 3)
+	test(&organization.SearchTeamOptions{OrgID: 3, Name: "TEAM1"}, []int64{2}, 1)
+	test(&organization.SearchTeamOptions{OrgID: 3, TeamID: 7}, []int64{7}, 1)
+	test(&organization.SearchTeamOptions{OrgID: 3, Paginator: db.NewAbsoluteListOptions(1, 2)}, []int64{2, 12}, 4)
+}
+
 func TestGetUserOrgTeams(t *testing.T) {
 	assert.NoError(t, unittest.PrepareTestDatabase())
 	test := func(orgID, userID int64) {
@@ -0,0 +1,121 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package alpine
+
+import (
+	"context"
+	"strings"
+
+	"code.gitea.io/gitea/models/db"
+	packages_model "code.gitea.io/gitea/models/packages"
+	alpine_module "code.gitea.io/gitea/modules/packages/alpine"
+
+	"xorm.io/builder"
+)
+
+type PackageSearchOptions struct {
+	OwnerID      int64
+	Branch       string
+	Repository   string
+	Architecture string
+	FileName     string
+}
+
+func (opts *PackageSearchOptions) toCond() builder.Cond {
+	var cond builder.Cond = builder.Eq{
+		"package.type":                packages_model.TypeAlpine,
+		"package.owner_id":            opts.OwnerID,
+		"package_version.is_internal": false,
+	}
+
+	if opts.FileName != "" {
+		cond = cond.And(builder.Eq{"package_file.lower_name": strings.ToLower(opts.FileName)})
+	}
+
+	props := make(map[string]string)
+	if opts.Branch != "" {
+		props[alpine_module.PropertyBranch] = opts.Branch
+	}
+	if opts.Repository != "" {
+		props[alpine_module.PropertyRepository] = opts.Repository
+	}
+	if opts.Architecture != "" {
+		props[alpine_module.PropertyArchitecture] = opts.Architecture
+	}
+
+	for name, value := range props {
+		cond = cond.And(builder.In("package_file.id", builder.Select("package_property.ref_id").
+			From("package_property").
+			Where(builder.Eq{
+				"package_property.ref_type": packages_model.PropertyTypeFile,
+				"package_property.name":     name,
+				"package_property.value":    value,
+			}),
+		))
+	}
+
+	return cond
+}
+
+// SearchPackages gets the package files matching the search options
+func SearchPackages(ctx context.Context, opts *PackageSearchOptions) ([]*packages_model.PackageFileDescriptor, error) {
+	pfs := make([]*packages_model.PackageFile, 0, 10)
+	err := db.GetEngine(ctx).
+		Table("package_file").
+		Select("package_file.*").
+		Join("INNER", "package_version", "package_version.id = package_file.version_id").
+		Join("INNER", "package", "package.id = package_version.package_id").
+		Where(opts.toCond()).
+		Asc("package.lower_name", "package_version.created_unix", "package_file.lower_name").
+		Find(&pfs)
+	if err != nil {
+		return nil, err
+	}
+
+	pfds := make([]*packages_model.PackageFileDescriptor, 0, len(pfs))
+	for _, pf := range pfs {
+		pfd, err := packages_model.GetPackageFileDescriptor(ctx, pf)
+		if err != nil {
+			return nil, err
+		}
+		pfds = append(pfds, pfd)
+	}
+	return pfds, nil
+}
+
+// GetBranches gets all available branches
+func GetBranches(ctx context.Context, ownerID int64) ([]string, error) {
+	return getDistinctPropertyValues(ctx, &PackageSearchOptions{OwnerID: ownerID}, alpine_module.PropertyBranch)
+}
+
+// GetRepositories gets all available repositories of a branch
+func GetRepositories(ctx context.Context, ownerID int64, branch string) ([]string, error) {
+	return getDistinctPropertyValues(ctx, &PackageSearchOptions{OwnerID: ownerID, Branch: branch}, alpine_module.PropertyRepository)
+}
+
+// GetArchitectures gets all available architectures of a branch and repository
+func GetArchitectures(ctx context.Context, ownerID int64, branch, repository string) ([]string, error) {
+	return getDistinctPropertyValues(ctx, &PackageSearchOptions{OwnerID: ownerID, Branch: branch, Repository: repository}, alpine_module.PropertyArchitecture)
+}
+
+func getDistinctPropertyValues(ctx context.Context, opts *PackageSearchOptions, propName string) ([]string, error) {
+	cond := builder.Eq{
+		"package_property.ref_type": packages_model.PropertyTypeFile,
+		"package_property.name":     propName,
+	}.And(builder.In("package_property.ref_id", builder.Select("package_file.id").
+		From("package_file").
+		Join("INNER", "package_version", "package_version.id = package_file.version_id").
+		Join("INNER", "package", "package.id = package_version.package_id").
+		Where(opts.toCond()),
+	))
+
+	values := make([]string, 0, 5)
+	err := db.GetEngine(ctx).
+		Table("package_property").
+		Select("DISTINCT package_property.value").
+		Where(cond).
+		Asc("package_property.value").
+		Find(&values)
+	return values, err
+}
@@ -0,0 +1,82 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package conda
+
+import (
+	"context"
+	"strings"
+
+	"code.gitea.io/gitea/models/db"
+	packages_model "code.gitea.io/gitea/models/packages"
+	conda_module "code.gitea.io/gitea/modules/packages/conda"
+
+	"xorm.io/builder"
+)
+
+// FileSearchOptions are the options to search the files of a channel.
+// The channel is always part of the search because the default channel is the empty string.
+type FileSearchOptions struct {
+	OwnerID  int64
+	Channel  string
+	Subdir   string
+	FileName string
+}
+
+func (opts *FileSearchOptions) toCond() builder.Cond {
+	var cond builder.Cond = builder.Eq{
+		"package.type":                packages_model.TypeConda,
+		"package.owner_id":            opts.OwnerID,
+		"package_version.is_internal": false,
+	}
+
+	if opts.FileName != "" {
+		cond = cond.And(builder.Eq{"package_file.lower_name": strings.ToLower(opts.FileName)})
+	}
+
+	props := map[string]string{
+		conda_module.PropertyChannel: opts.Channel,
+	}
+	if opts.Subdir != "" {
+		props[conda_module.PropertySubdir] = opts.Subdir
+	}
+
+	for name, value := range props {
+		cond = cond.And(builder.In("package_file.id", builder.Select("package_property.ref_id").
+			From("package_property").
+			Where(builder.Eq{
+				"package_property.ref_type": packages_model.PropertyTypeFile,
+				"package_property.name":     name,
+				"package_property.value":    value,
+			}),
+		))
+	}
+
+	return cond
+}
+
+// SearchFiles gets the package files matching the search options
+func SearchFiles(ctx context.Context, opts *FileSearchOptions) ([]*packages_model.PackageFileDescriptor, error) {
+	pfs := make([]*packages_model.PackageFile, 0, 10)
+	err := db.GetEngine(ctx).
+		Table("package_file").
+		Select("package_file.*").
+		Join("INNER", "package_version", "package_version.id = package_file.version_id").
+		Join("INNER", "package", "package.id = package_version.package_id").
+		Where(opts.toCond()).
+		Asc("package.lower_name", "package_version.created_unix", "package_file.lower_name").
+		Find(&pfs)
+	if err != nil {
+		return nil, err
+	}
+
+	pfds := make([]*packages_model.PackageFileDescriptor, 0, len(pfs))
+	for _, pf := range pfs {
+		pfd, err := packages_model.GetPackageFileDescriptor(ctx, pf)
+		if err != nil {
+			return nil, err
+		}
+		pfds = append(pfds, pfd)
+	}
+	return pfds, nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package packages
+
+import (
+	"context"
+	"strconv"
+
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/modules/util"
+
+	"xorm.io/builder"
+)
+
+const (
+	// PropertyProvenanceRepository is the version property which contains the id of the repository the version was built from
+	PropertyProvenanceRepository = "provenance.repository"
+	// PropertyProvenanceCommit is the version property which contains the commit sha the version was built from
+	PropertyProvenanceCommit = "provenance.commit"
+	// PropertyProvenanceRelease is the version property which contains the optional release tag the version was built from
+	PropertyProvenanceRelease = "provenance.release"
+)
+
+// Provenance describes the source a package version was built from
+type Provenance struct {
+	RepoID     int64
+	Repository *repo_model.Repository
+	CommitSHA  string
+	Release    string
+}
+
+// getProvenance creates the provenance from the version properties
+func getProvenance(ctx context.Context, pvps PackagePropertyList) (*Provenance, error) {
+	repoID, _ := strconv.ParseInt(pvps.GetByName(PropertyProvenanceRepository), 10, 64)
+	if repoID == 0 {
+		return nil, nil
+	}
+
+	repository, err := repo_model.GetRepositoryByID(ctx, repoID)
+	if err != nil && !repo_model.IsErrRepoNotExist(err) {
+		return nil, err
+	}
+
+	return &Provenance{
+		RepoID:     repoID,
+		Repository: repository,
+		CommitSHA:  pvps.GetByName(PropertyProvenanceCommit),
+		Release:    pvps.GetByName(PropertyProvenanceRelease),
+	}, nil
+}
+
+// SetVersionProvenance replaces the provenance of the package version
+func SetVersionProvenance(ctx context.Context, versionID int64, p *Provenance) error {
+	if p.RepoID == 0 || p.CommitSHA == "" {
+		return util.NewInvalidArgumentErrorf("repository and commit are required")
+	}
+
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		if err := DeleteVersionProvenance(ctx, versionID); err != nil {
+			return err
+		}
+
+		props := map[string]string{
+			PropertyProvenanceRepository: strconv.FormatInt(p.RepoID, 10),
+			PropertyProvenanceCommit:     p.CommitSHA,
+		}
+		if p.Release != "" {
+			props[PropertyProvenanceRelease] = p.Release
+		}
+		for name, value := range props {
+			if _, err := InsertProperty(ctx, PropertyTypeVersion, versionID, name, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteVersionProvenance removes the provenance of the package version
+func DeleteVersionProvenance(ctx context.Context, versionID int64) error {
+	for _, name := range []string{PropertyProvenanceRepository, PropertyProvenanceCommit, PropertyProvenanceRelease} {
+		if err := DeletePropertyByName(ctx, PropertyTypeVersion, versionID, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HasRepositoryProvenance tests if package versions were built from the repository
+func HasRepositoryProvenance(ctx context.Context, repositoryID int64) (bool, error) {
+	return db.GetEngine(ctx).Where(builder.Eq{
+		"ref_type": PropertyTypeVersion,
+		"name":     PropertyProvenanceRepository,
+		"value":    strconv.FormatInt(repositoryID, 10),
+	}).Exist(&PackageProperty{})
+}
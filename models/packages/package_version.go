@@ -154,6 +154,24 @@ func HasVersionFileReferences(ctx context.Context, versionID int64) (bool, error
 	})
 }
 
+// HasVersionsWithoutProperty checks if the package has versions which do not have the version property
+func HasVersionsWithoutProperty(ctx context.Context, packageID int64, propertyName string) (bool, error) {
+	return db.GetEngine(ctx).
+		Where(builder.Eq{
+			"package_id":  packageID,
+			"is_internal": false,
+		}.And(builder.NotIn(
+			"id",
+			builder.Select("ref_id").
+				From("package_property").
+				Where(builder.Eq{
+					"ref_type": PropertyTypeVersion,
+					"name":     propertyName,
+				}),
+		))).
+		Exist(&PackageVersion{})
+}
+
 // SearchValue describes a value to search
 // If ExactMatch is true, the field must match the value otherwise a LIKE search is performed.
 type SearchValue struct {
@@ -176,6 +194,7 @@ const (
 // Besides IsInternal are all fields optional and are not used if they have their default value (nil, "", 0)
 type PackageSearchOptions struct {
 	OwnerID         int64
+	OwnerCond       builder.Cond // only results are found whose owner matches the condition on the user table
 	RepoID          int64
 	Type            Type
 	PackageID       int64
@@ -198,6 +217,9 @@ func (opts *PackageSearchOptions) toConds() builder.Cond {
 	if opts.OwnerID != 0 {
 		cond = cond.And(builder.Eq{"package.owner_id": opts.OwnerID})
 	}
+	if opts.OwnerCond != nil {
+		cond = cond.And(builder.In("package.owner_id", builder.Select("`user`.id").From("`user`").Where(opts.OwnerCond)))
+	}
 	if opts.RepoID != 0 {
 		cond = cond.And(builder.Eq{"package.repo_id": opts.RepoID})
 	}
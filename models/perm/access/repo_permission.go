@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 
+	auth_model "code.gitea.io/gitea/models/auth"
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/models/organization"
 	perm_model "code.gitea.io/gitea/models/perm"
@@ -143,6 +144,36 @@ func (p *Permission) ColorFormat(s fmt.State) {
 	log.ColorFprintf(s, format, args...)
 }
 
+// RestrictByAccessToken limits the permission to the units and access modes granted by a fine-grained access token.
+// Nothing is granted on repositories the token is not restricted to, and never more than write access.
+func (p *Permission) RestrictByAccessToken(repo *repo_model.Repository, token *auth_model.AccessToken) {
+	if token == nil || !token.IsFineGrained() {
+		return
+	}
+
+	accessMode := perm_model.AccessModeNone
+	unitsMode := make(map[unit.Type]perm_model.AccessMode)
+	if token.CanAccessRepo(repo.ID, repo.OwnerID) {
+		for _, u := range p.Units {
+			mode := p.UnitAccessMode(u.Type)
+			if granted := token.UnitAccessMode(u.Type); granted < mode {
+				mode = granted
+			}
+			if mode > perm_model.AccessModeWrite {
+				mode = perm_model.AccessModeWrite
+			}
+			if mode > perm_model.AccessModeNone {
+				unitsMode[u.Type] = mode
+			}
+			if mode > accessMode {
+				accessMode = mode
+			}
+		}
+	}
+	p.AccessMode = accessMode
+	p.UnitsMode = unitsMode
+}
+
 // GetUserRepoPermission returns the user permissions to the repository
 func GetUserRepoPermission(ctx context.Context, repo *repo_model.Repository, user *user_model.User) (perm Permission, err error) {
 	if log.IsTrace() {
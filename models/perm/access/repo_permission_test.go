@@ -0,0 +1,58 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package access_test
+
+import (
+	"testing"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	perm_model "code.gitea.io/gitea/models/perm"
+	access_model "code.gitea.io/gitea/models/perm/access"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unit"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPermission_RestrictByAccessToken(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	user2 := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+	repo1 := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 1, OwnerID: 2})
+	repo2 := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 2, OwnerID: 2})
+
+	token := &auth_model.AccessToken{
+		ResourceOwnerID: 2,
+		RepoIDs:         []int64{1},
+		Units: auth_model.AccessTokenUnits{
+			unit.TypeCode:   perm_model.AccessModeRead,
+			unit.TypeIssues: perm_model.AccessModeWrite,
+		},
+	}
+
+	perm, err := access_model.GetUserRepoPermission(db.DefaultContext, repo1, user2)
+	assert.NoError(t, err)
+	assert.True(t, perm.IsOwner())
+	perm.RestrictByAccessToken(repo1, token)
+	assert.False(t, perm.IsAdmin())
+	assert.True(t, perm.CanRead(unit.TypeCode))
+	assert.False(t, perm.CanWrite(unit.TypeCode))
+	assert.True(t, perm.CanWrite(unit.TypeIssues))
+	assert.False(t, perm.CanRead(unit.TypePullRequests))
+
+	// the token is not restricted to this repository
+	perm, err = access_model.GetUserRepoPermission(db.DefaultContext, repo2, user2)
+	assert.NoError(t, err)
+	perm.RestrictByAccessToken(repo2, token)
+	assert.False(t, perm.HasAccess())
+
+	// other tokens don't change the permission
+	perm, err = access_model.GetUserRepoPermission(db.DefaultContext, repo2, user2)
+	assert.NoError(t, err)
+	perm.RestrictByAccessToken(repo2, &auth_model.AccessToken{})
+	assert.True(t, perm.IsOwner())
+}
@@ -11,6 +11,7 @@ import (
 
 	_ "image/jpeg" // Needed for jpeg support
 
+	actions_model "code.gitea.io/gitea/models/actions"
 	activities_model "code.gitea.io/gitea/models/activities"
 	admin_model "code.gitea.io/gitea/models/admin"
 	asymkey_model "code.gitea.io/gitea/models/asymkey"
@@ -145,6 +146,7 @@ func DeleteRepository(doer *user_model.User, uid, repoID int64) error {
 		&repo_model.PushMirror{RepoID: repoID},
 		&repo_model.Release{RepoID: repoID},
 		&repo_model.RepoIndexerStatus{RepoID: repoID},
+		&repo_model.RepoMaintenance{RepoID: repoID},
 		&repo_model.Redirect{RedirectRepoID: repoID},
 		&repo_model.RepoUnit{RepoID: repoID},
 		&repo_model.Star{RepoID: repoID},
@@ -152,6 +154,9 @@ func DeleteRepository(doer *user_model.User, uid, repoID int64) error {
 		&repo_model.Watch{RepoID: repoID},
 		&webhook.Webhook{RepoID: repoID},
 		&secret_model.Secret{RepoID: repoID},
+		&actions_model.ActionRun{RepoID: repoID},
+		&actions_model.ActionRunner{RepoID: repoID},
+		&actions_model.ActionRunnerToken{RepoID: repoID},
 	); err != nil {
 		return fmt.Errorf("deleteBeans: %w", err)
 	}
@@ -177,6 +182,27 @@ func DeleteRepository(doer *user_model.User, uid, repoID int64) error {
 		return err
 	}
 
+	// Remove action jobs and their logs
+	var actionJobs []*actions_model.ActionRunJob
+	if err = sess.Where("repo_id=?", repoID).Find(&actionJobs); err != nil {
+		return err
+	}
+
+	actionLogPaths := make([]string, 0, len(actionJobs))
+	for _, job := range actionJobs {
+		if job.LogFilename != "" {
+			actionLogPaths = append(actionLogPaths, job.LogFilename)
+		}
+	}
+
+	if _, err := db.DeleteByBean(ctx, &actions_model.ActionRunJob{RepoID: repoID}); err != nil {
+		return err
+	}
+
+	if err := db.DeleteResourceIndex(ctx, "action_run_index", repoID); err != nil {
+		return err
+	}
+
 	if repo.IsFork {
 		if _, err := db.Exec(ctx, "UPDATE `repository` SET num_forks=num_forks-1 WHERE id=?", repo.ForkID); err != nil {
 			return fmt.Errorf("decrease fork count: %w", err)
@@ -235,6 +261,17 @@ func DeleteRepository(doer *user_model.User, uid, repoID int64) error {
 		return err
 	}
 
+	// Remove the bundle
+	bundle, err := repo_model.GetRepoBundle(ctx, repoID)
+	if err != nil {
+		return err
+	}
+	if bundle != nil {
+		if err := repo_model.DeleteRepoBundle(ctx, bundle); err != nil {
+			return err
+		}
+	}
+
 	if repo.NumForks > 0 {
 		if _, err = sess.Exec("UPDATE `repository` SET fork_id=0,is_fork=? WHERE fork_id=?", false, repo.ID); err != nil {
 			log.Error("reset 'fork_id' and 'is_fork': %v", err)
@@ -289,11 +326,21 @@ func DeleteRepository(doer *user_model.User, uid, repoID int64) error {
 		system_model.RemoveStorageWithNotice(db.DefaultContext, storage.RepoArchives, "Delete repo archive file", archive)
 	}
 
+	// Remove the bundle file
+	if bundle != nil {
+		system_model.RemoveStorageWithNotice(db.DefaultContext, storage.RepoBundles, "Delete repo bundle file", bundle.RelativePath())
+	}
+
 	// Remove lfs objects
 	for _, lfsObj := range lfsPaths {
 		system_model.RemoveStorageWithNotice(db.DefaultContext, storage.LFS, "Delete orphaned LFS file", lfsObj)
 	}
 
+	// Remove action log files
+	for _, logPath := range actionLogPaths {
+		system_model.RemoveStorageWithNotice(db.DefaultContext, storage.Actions, "Delete action log file", logPath)
+	}
+
 	// Remove issue attachment files.
 	for _, attachment := range attachmentPaths {
 		system_model.RemoveStorageWithNotice(db.DefaultContext, storage.Attachments, "Delete issue attachment", attachment)
@@ -0,0 +1,60 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// RepoBundle represents the pre-generated git bundle of a repository, which clones can bootstrap from
+type RepoBundle struct { //revive:disable-line:exported
+	ID            int64              `xorm:"pk autoincr"`
+	RepoID        int64              `xorm:"UNIQUE"`
+	RefsHash      string             `xorm:"VARCHAR(64)"` // the hash of the bundled branches and tags, the bundle is stale when they have changed
+	Size          int64              `xorm:"NOT NULL DEFAULT 0"`
+	GeneratedUnix timeutil.TimeStamp `xorm:"NOT NULL"`
+}
+
+func init() {
+	db.RegisterModel(new(RepoBundle))
+}
+
+// RelativePath returns the bundle path relative to the bundle storage root,
+// every generated bundle gets a new path so a bundle is never overwritten while it is downloaded.
+func (bundle *RepoBundle) RelativePath() string {
+	return fmt.Sprintf("%d/%d.bundle", bundle.RepoID, bundle.GeneratedUnix)
+}
+
+// GetRepoBundle returns the bundle of the repository, or nil if it has no bundle
+func GetRepoBundle(ctx context.Context, repoID int64) (*RepoBundle, error) {
+	var bundle RepoBundle
+	has, err := db.GetEngine(ctx).Where("repo_id=?", repoID).Get(&bundle)
+	if err != nil {
+		return nil, err
+	}
+	if has {
+		return &bundle, nil
+	}
+	return nil, nil
+}
+
+// SaveRepoBundle inserts the bundle of the repository or updates the existing one
+func SaveRepoBundle(ctx context.Context, bundle *RepoBundle) error {
+	if bundle.ID == 0 {
+		_, err := db.GetEngine(ctx).Insert(bundle)
+		return err
+	}
+	_, err := db.GetEngine(ctx).ID(bundle.ID).Cols("refs_hash", "size", "generated_unix").Update(bundle)
+	return err
+}
+
+// DeleteRepoBundle deletes the bundle record
+func DeleteRepoBundle(ctx context.Context, bundle *RepoBundle) error {
+	_, err := db.GetEngine(ctx).ID(bundle.ID).Delete(new(RepoBundle))
+	return err
+}
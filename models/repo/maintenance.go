@@ -0,0 +1,110 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"context"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// RepoMaintenance tracks the activity of a repository since its last maintenance and the result of that maintenance,
+// the maintenance scheduler only visits the repositories which have been pushed to.
+type RepoMaintenance struct { //revive:disable-line:exported
+	ID     int64       `xorm:"pk autoincr"`
+	RepoID int64       `xorm:"UNIQUE"`
+	Repo   *Repository `xorm:"-"`
+
+	// PushCount is the number of the pushes since the last maintenance
+	PushCount int64 `xorm:"INDEX NOT NULL DEFAULT 0"`
+
+	// the statistics measured after the last maintenance
+	LooseObjects int64 `xorm:"NOT NULL DEFAULT 0"`
+	Packs        int64 `xorm:"NOT NULL DEFAULT 0"`
+	LooseRefs    int64 `xorm:"NOT NULL DEFAULT 0"`
+
+	LastTasks    string             // the comma separated tasks which have been run by the last maintenance
+	LastError    string             `xorm:"TEXT"`
+	LastDuration int64              `xorm:"NOT NULL DEFAULT 0"` // in milliseconds
+	LastRunUnix  timeutil.TimeStamp `xorm:"INDEX"`
+}
+
+func init() {
+	db.RegisterModel(new(RepoMaintenance))
+}
+
+// LoadRepo loads the repository of the maintenance record
+func (m *RepoMaintenance) LoadRepo(ctx context.Context) (err error) {
+	if m.Repo == nil {
+		m.Repo, err = GetRepositoryByID(ctx, m.RepoID)
+	}
+	return err
+}
+
+// GetRepoMaintenance returns the maintenance record of the repository, or nil if it has none yet
+func GetRepoMaintenance(ctx context.Context, repoID int64) (*RepoMaintenance, error) {
+	var m RepoMaintenance
+	has, err := db.GetEngine(ctx).Where("repo_id=?", repoID).Get(&m)
+	if err != nil {
+		return nil, err
+	}
+	if has {
+		return &m, nil
+	}
+	return nil, nil
+}
+
+// IncreaseRepoMaintenancePushCount records a push to the repository
+func IncreaseRepoMaintenancePushCount(ctx context.Context, repoID int64) error {
+	affected, err := db.GetEngine(ctx).Where("repo_id=?", repoID).Incr("push_count").Update(new(RepoMaintenance))
+	if err != nil || affected > 0 {
+		return err
+	}
+	if _, err = db.GetEngine(ctx).Insert(&RepoMaintenance{RepoID: repoID, PushCount: 1}); err != nil {
+		// another push could have inserted the record in the meantime
+		_, err = db.GetEngine(ctx).Where("repo_id=?", repoID).Incr("push_count").Update(new(RepoMaintenance))
+	}
+	return err
+}
+
+// SaveRepoMaintenanceResult saves the result of a maintenance, the pushes seen by the maintenance are subtracted
+// from the push count so the pushes which have happened during the maintenance are kept for the next one.
+func SaveRepoMaintenanceResult(ctx context.Context, m *RepoMaintenance, seenPushes int64) error {
+	if m.ID == 0 {
+		_, err := db.GetEngine(ctx).Insert(m)
+		return err
+	}
+	_, err := db.GetEngine(ctx).ID(m.ID).Decr("push_count", seenPushes).
+		Cols("loose_objects", "packs", "loose_refs", "last_tasks", "last_error", "last_duration", "last_run_unix").
+		Update(m)
+	return err
+}
+
+// FindRepoMaintenancesOptions represents the options to find the maintenance records
+type FindRepoMaintenancesOptions struct {
+	db.ListOptions
+	OnlyPushed bool
+}
+
+func (opts *FindRepoMaintenancesOptions) toConds() builder.Cond {
+	cond := builder.NewCond()
+	if opts.OnlyPushed {
+		cond = cond.And(builder.Gt{"push_count": 0})
+	}
+	return cond
+}
+
+// FindRepoMaintenances returns the maintenance records, the most recently maintained first
+func FindRepoMaintenances(ctx context.Context, opts FindRepoMaintenancesOptions) ([]*RepoMaintenance, int64, error) {
+	sess := db.GetEngine(ctx).Where(opts.toConds()).OrderBy("last_run_unix DESC, id DESC")
+	if opts.Page > 0 {
+		sess = db.SetSessionPagination(sess, &opts)
+	}
+	maintenances := make([]*RepoMaintenance, 0, opts.PageSize)
+	count, err := sess.FindAndCount(&maintenances)
+	return maintenances, count, err
+}
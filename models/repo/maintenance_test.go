@@ -0,0 +1,55 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package repo_test
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unittest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepoMaintenancePushCount(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	m, err := repo_model.GetRepoMaintenance(db.DefaultContext, 1)
+	assert.NoError(t, err)
+	assert.Nil(t, m)
+
+	assert.NoError(t, repo_model.IncreaseRepoMaintenancePushCount(db.DefaultContext, 1))
+	assert.NoError(t, repo_model.IncreaseRepoMaintenancePushCount(db.DefaultContext, 1))
+	assert.NoError(t, repo_model.IncreaseRepoMaintenancePushCount(db.DefaultContext, 2))
+
+	m, err = repo_model.GetRepoMaintenance(db.DefaultContext, 1)
+	assert.NoError(t, err)
+	if assert.NotNil(t, m) {
+		assert.EqualValues(t, 2, m.PushCount)
+	}
+
+	// a push which has happened during the maintenance is kept for the next one
+	m.LooseObjects = 3
+	m.LastTasks = "commit-graph"
+	assert.NoError(t, repo_model.SaveRepoMaintenanceResult(db.DefaultContext, m, 1))
+	m = unittest.AssertExistsAndLoadBean(t, &repo_model.RepoMaintenance{RepoID: 1})
+	assert.EqualValues(t, 1, m.PushCount)
+	assert.EqualValues(t, 3, m.LooseObjects)
+	assert.Equal(t, "commit-graph", m.LastTasks)
+
+	m2 := unittest.AssertExistsAndLoadBean(t, &repo_model.RepoMaintenance{RepoID: 2})
+	assert.NoError(t, repo_model.SaveRepoMaintenanceResult(db.DefaultContext, m2, m2.PushCount))
+
+	maintenances, count, err := repo_model.FindRepoMaintenances(db.DefaultContext, repo_model.FindRepoMaintenancesOptions{OnlyPushed: true})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+	if assert.Len(t, maintenances, 1) {
+		assert.EqualValues(t, 1, maintenances[0].RepoID)
+	}
+
+	_, count, err = repo_model.FindRepoMaintenances(db.DefaultContext, repo_model.FindRepoMaintenancesOptions{})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+}
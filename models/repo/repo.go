@@ -16,6 +16,7 @@ import (
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/models/unit"
 	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/markup"
 	"code.gitea.io/gitea/modules/setting"
@@ -162,12 +163,19 @@ type Repository struct {
 	Size                            int64              `xorm:"NOT NULL DEFAULT 0"`
 	CodeIndexerStatus               *RepoIndexerStatus `xorm:"-"`
 	StatsIndexerStatus              *RepoIndexerStatus `xorm:"-"`
+	CodeIndexerBranches             string             `xorm:"TEXT"`
+	CodeIndexerTags                 string             `xorm:"TEXT"`
 	IsFsckEnabled                   bool               `xorm:"NOT NULL DEFAULT true"`
 	CloseIssuesViaCommitInAnyBranch bool               `xorm:"NOT NULL DEFAULT false"`
+	DisablePartialClone             bool               `xorm:"NOT NULL DEFAULT false"`
+	DisableProtocolV2               bool               `xorm:"NOT NULL DEFAULT false"`
 	Topics                          []string           `xorm:"TEXT JSON"`
 
 	TrustModel TrustModelType
 
+	// ObjectFormatName is the hash algorithm of the git objects, sha1 or sha256
+	ObjectFormatName string `xorm:"VARCHAR(6) NOT NULL DEFAULT 'sha1'"`
+
 	// Avatar: ID(10-20)-md5(32) - must fit into 64 symbols
 	Avatar string `xorm:"VARCHAR(64)"`
 
@@ -269,10 +277,26 @@ func (repo *Repository) HTMLURL() string {
 	return setting.AppURL + url.PathEscape(repo.OwnerName) + "/" + url.PathEscape(repo.Name)
 }
 
+// GetObjectFormat returns the object format of the git repository
+func (repo *Repository) GetObjectFormat() git.ObjectFormat {
+	if repo.ObjectFormatName == "" {
+		return git.ObjectFormatSHA1
+	}
+	return git.ObjectFormat(repo.ObjectFormatName)
+}
+
+// GitServiceOptions returns the options of the git services of the repository
+func (repo *Repository) GitServiceOptions() git.ServiceOptions {
+	return git.ServiceOptions{
+		DisablePartialClone: repo.DisablePartialClone,
+		DisableProtocolV2:   repo.DisableProtocolV2,
+	}
+}
+
 // CommitLink make link to by commit full ID
 // note: won't check whether it's an right id
 func (repo *Repository) CommitLink(commitID string) (result string) {
-	if commitID == "" || commitID == "0000000000000000000000000000000000000000" {
+	if commitID == "" || git.IsEmptyCommitID(commitID) {
 		result = ""
 	} else {
 		result = repo.HTMLURL() + "/commit/" + url.PathEscape(commitID)
@@ -20,15 +20,18 @@ const (
 	RepoIndexerTypeCode RepoIndexerType = iota // 0
 	// RepoIndexerTypeStats repository stats indexer
 	RepoIndexerTypeStats // 1
+	// RepoIndexerTypeCommits commit message indexer
+	RepoIndexerTypeCommits // 2
 )
 
 // RepoIndexerStatus status of a repo's entry in the repo indexer
-// For now, implicitly refers to default branch
+// An empty Ref refers to the default branch, other refs are additionally indexed branches and tags
 type RepoIndexerStatus struct { //revive:disable-line:exported
 	ID          int64           `xorm:"pk autoincr"`
 	RepoID      int64           `xorm:"INDEX(s)"`
-	CommitSha   string          `xorm:"VARCHAR(40)"`
+	CommitSha   string          `xorm:"VARCHAR(64)"`
 	IndexerType RepoIndexerType `xorm:"INDEX(s) NOT NULL DEFAULT 0"`
+	Ref         string          `xorm:"VARCHAR(255) NOT NULL DEFAULT ''"`
 }
 
 func init() {
@@ -75,7 +78,7 @@ func GetIndexerStatus(ctx context.Context, repo *Repository, indexerType RepoInd
 		}
 	}
 	status := &RepoIndexerStatus{RepoID: repo.ID}
-	if has, err := db.GetEngine(ctx).Where("`indexer_type` = ?", indexerType).Get(status); err != nil {
+	if has, err := db.GetEngine(ctx).Where("`indexer_type` = ? AND `ref` = ''", indexerType).Get(status); err != nil {
 		return nil, err
 	} else if !has {
 		status.IndexerType = indexerType
@@ -112,3 +115,33 @@ func UpdateIndexerStatus(ctx context.Context, repo *Repository, indexerType Repo
 	}
 	return nil
 }
+
+// GetIndexerRefStatuses returns the statuses of the additionally indexed refs of a repository
+func GetIndexerRefStatuses(ctx context.Context, repoID int64, indexerType RepoIndexerType) ([]*RepoIndexerStatus, error) {
+	statuses := make([]*RepoIndexerStatus, 0, 10)
+	return statuses, db.GetEngine(ctx).
+		Where("`repo_id` = ? AND `indexer_type` = ? AND `ref` <> ''", repoID, indexerType).
+		OrderBy("`ref`").
+		Find(&statuses)
+}
+
+// UpdateIndexerRefStatus inserts or updates the status of an additionally indexed ref
+func UpdateIndexerRefStatus(ctx context.Context, status *RepoIndexerStatus) error {
+	if status.ID == 0 {
+		return db.Insert(ctx, status)
+	}
+	_, err := db.GetEngine(ctx).ID(status.ID).Cols("commit_sha").Update(status)
+	return err
+}
+
+// DeleteIndexerStatuses deletes the statuses of all repositories of an indexer type
+func DeleteIndexerStatuses(ctx context.Context, indexerType RepoIndexerType) error {
+	_, err := db.GetEngine(ctx).Where("`indexer_type` = ?", indexerType).Delete(&RepoIndexerStatus{})
+	return err
+}
+
+// DeleteIndexerStatusByID deletes the indexer status with the given id
+func DeleteIndexerStatusByID(ctx context.Context, id int64) error {
+	_, err := db.GetEngine(ctx).ID(id).Delete(&RepoIndexerStatus{})
+	return err
+}
@@ -101,6 +101,7 @@ func MainTest(m *testing.M, testOpts *TestOptions) {
 	setting.RepoAvatar.Storage.Path = filepath.Join(setting.AppDataPath, "repo-avatars")
 
 	setting.RepoArchive.Storage.Path = filepath.Join(setting.AppDataPath, "repo-archive")
+	setting.RepoBundle.Storage.Path = filepath.Join(setting.AppDataPath, "repo-bundle")
 
 	setting.Packages.Storage.Path = filepath.Join(setting.AppDataPath, "packages")
 
@@ -18,10 +18,12 @@ import (
 // SearchUserOptions contains the options for searching
 type SearchUserOptions struct {
 	db.ListOptions
+	Paginator db.Paginator // paginates by offset instead of the page of ListOptions if set
 
 	Keyword       string
 	Type          UserType
 	UID           int64
+	LoginName     string // the name the user signs in with, users without one are matched by their name
 	OrderBy       db.SearchOrderBy
 	Visible       []structs.VisibleType
 	Actor         *User // The user doing the search
@@ -62,6 +64,14 @@ func (opts *SearchUserOptions) toSearchQueryBase() *xorm.Session {
 		cond = cond.And(builder.Eq{"id": opts.UID})
 	}
 
+	if len(opts.LoginName) > 0 {
+		lowerLoginName := strings.ToLower(opts.LoginName)
+		cond = cond.And(builder.Or(
+			builder.Eq{"LOWER(login_name)": lowerLoginName},
+			builder.Eq{"login_name": ""}.And(builder.Eq{"lower_name": lowerLoginName}),
+		))
+	}
+
 	if !opts.IsActive.IsNone() {
 		cond = cond.And(builder.Eq{"is_active": opts.IsActive.IsTrue()})
 	}
@@ -113,7 +123,9 @@ func SearchUsers(opts *SearchUserOptions) (users []*User, _ int64, _ error) {
 
 	sessQuery := opts.toSearchQueryBase().OrderBy(opts.OrderBy.String())
 	defer sessQuery.Close()
-	if opts.Page != 0 {
+	if opts.Paginator != nil {
+		sessQuery = db.SetSessionPagination(sessQuery, opts.Paginator)
+	} else if opts.Page != 0 {
 		sessQuery = db.SetSessionPagination(sessQuery, opts)
 	}
 
@@ -121,6 +121,12 @@ func TestSearchUsers(t *testing.T) {
 
 	testUserSuccess(&user_model.SearchUserOptions{ListOptions: db.ListOptions{Page: 1}, IsTwoFactorEnabled: util.OptionalBoolTrue},
 		[]int64{24})
+
+	testUserSuccess(&user_model.SearchUserOptions{ListOptions: db.ListOptions{Page: 1}, LoginName: "USER4"},
+		[]int64{4})
+
+	testUserSuccess(&user_model.SearchUserOptions{OrderBy: "id ASC", Paginator: db.NewAbsoluteListOptions(3, 2)},
+		[]int64{5, 8})
 }
 
 func TestEmailNotificationPreferences(t *testing.T) {
@@ -0,0 +1,139 @@
+// Copyright 2022 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"strings"
+
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/gobwas/glob"
+)
+
+// Names of the events workflows can be triggered by
+const (
+	EventPush        = "push"
+	EventPullRequest = "pull_request"
+)
+
+// Activity types of the pull_request event
+const (
+	PullRequestOpened      = "opened"
+	PullRequestSynchronize = "synchronize"
+	PullRequestReopened    = "reopened"
+)
+
+// defaultPullRequestTypes are the activity types used if a workflow doesn't specify any
+var defaultPullRequestTypes = []string{PullRequestOpened, PullRequestSynchronize, PullRequestReopened}
+
+// Event describes an event which may trigger workflows
+type Event struct {
+	Name string
+	// Type is the activity type of the event, only used by pull requests
+	Type string
+	// Ref is the full name of the pushed ref or of the base branch of a pull request
+	Ref string
+	// ChangedFiles returns the files changed by the event, it's only called if a workflow filters by path
+	ChangedFiles func() ([]string, error)
+}
+
+// Match returns true if the workflow should be triggered by the event
+func (w *Workflow) Match(evt *Event) bool {
+	filter, ok := w.On[evt.Name]
+	if !ok {
+		return false
+	}
+	if filter == nil {
+		return true
+	}
+
+	switch evt.Name {
+	case EventPush:
+		if !matchPushRef(filter, evt.Ref) {
+			return false
+		}
+	case EventPullRequest:
+		types := []string(filter.Types)
+		if len(types) == 0 {
+			types = defaultPullRequestTypes
+		}
+		if !util.SliceContainsString(types, evt.Type) {
+			return false
+		}
+		if !matchIncludeExclude(filter.Branches, filter.BranchesIgnore, git.RefEndName(evt.Ref)) {
+			return false
+		}
+	}
+
+	if len(filter.Paths) == 0 && len(filter.PathsIgnore) == 0 || evt.ChangedFiles == nil {
+		return true
+	}
+	files, err := evt.ChangedFiles()
+	if err != nil {
+		log.Error("ChangedFiles: %v", err)
+		return false
+	}
+	for _, file := range files {
+		if matchIncludeExclude(filter.Paths, filter.PathsIgnore, file) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchPushRef(filter *EventFilter, ref string) bool {
+	hasBranchFilter := len(filter.Branches) > 0 || len(filter.BranchesIgnore) > 0
+	hasTagFilter := len(filter.Tags) > 0 || len(filter.TagsIgnore) > 0
+
+	switch {
+	case strings.HasPrefix(ref, git.BranchPrefix):
+		if !hasBranchFilter {
+			// a workflow which only filters tags isn't run for branches
+			return !hasTagFilter
+		}
+		return matchIncludeExclude(filter.Branches, filter.BranchesIgnore, strings.TrimPrefix(ref, git.BranchPrefix))
+	case strings.HasPrefix(ref, git.TagPrefix):
+		if !hasTagFilter {
+			return !hasBranchFilter
+		}
+		return matchIncludeExclude(filter.Tags, filter.TagsIgnore, strings.TrimPrefix(ref, git.TagPrefix))
+	default:
+		return false
+	}
+}
+
+// matchIncludeExclude checks the value against the patterns like GitHub does:
+// the patterns are evaluated in order and a pattern prefixed by "!" excludes the previously matched values.
+func matchIncludeExclude(include, exclude []string, value string) bool {
+	if len(include) > 0 {
+		matched := false
+		for _, pattern := range include {
+			if strings.HasPrefix(pattern, "!") {
+				if matchPattern(pattern[1:], value) {
+					matched = false
+				}
+			} else if matchPattern(pattern, value) {
+				matched = true
+			}
+		}
+		return matched
+	}
+	for _, pattern := range exclude {
+		if matchPattern(pattern, value) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchPattern(pattern, value string) bool {
+	g, err := glob.Compile(pattern, '/')
+	if err != nil {
+		log.Warn("Invalid workflow filter pattern %q: %v", pattern, err)
+		return false
+	}
+	return g.Match(value)
+}
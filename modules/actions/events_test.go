@@ -0,0 +1,67 @@
+// Copyright 2022 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkflowMatch(t *testing.T) {
+	parse := func(on string) *Workflow {
+		w, err := ParseWorkflow([]byte(on + "\njobs:\n  a:\n    steps:\n      - run: echo\n"))
+		assert.NoError(t, err)
+		return w
+	}
+	push := func(ref string) *Event {
+		return &Event{Name: EventPush, Ref: ref}
+	}
+	pull := func(typ, base string) *Event {
+		return &Event{Name: EventPullRequest, Type: typ, Ref: "refs/heads/" + base}
+	}
+
+	w := parse("on: push")
+	assert.True(t, w.Match(push("refs/heads/main")))
+	assert.True(t, w.Match(push("refs/tags/v1.0")))
+	assert.False(t, w.Match(pull(PullRequestOpened, "main")))
+
+	w = parse("on:\n  push:\n    branches: ['release/**', '!release/**-rc']")
+	assert.True(t, w.Match(push("refs/heads/release/v1/final")))
+	assert.False(t, w.Match(push("refs/heads/release/v1-rc")))
+	assert.False(t, w.Match(push("refs/heads/main")))
+	assert.False(t, w.Match(push("refs/tags/v1.0")))
+
+	w = parse("on:\n  push:\n    tags: ['v*']")
+	assert.True(t, w.Match(push("refs/tags/v1.0")))
+	assert.False(t, w.Match(push("refs/tags/latest")))
+	assert.False(t, w.Match(push("refs/heads/main")))
+
+	w = parse("on:\n  push:\n    branches-ignore: [wip/*]")
+	assert.True(t, w.Match(push("refs/heads/main")))
+	assert.False(t, w.Match(push("refs/heads/wip/test")))
+
+	w = parse("on: pull_request")
+	assert.True(t, w.Match(pull(PullRequestOpened, "main")))
+	assert.True(t, w.Match(pull(PullRequestSynchronize, "main")))
+	assert.False(t, w.Match(pull("closed", "main")))
+
+	w = parse("on:\n  pull_request:\n    types: [opened]\n    branches: [main]")
+	assert.True(t, w.Match(pull(PullRequestOpened, "main")))
+	assert.False(t, w.Match(pull(PullRequestSynchronize, "main")))
+	assert.False(t, w.Match(pull(PullRequestOpened, "dev")))
+
+	w = parse("on:\n  push:\n    paths: ['docs/**']")
+	evt := push("refs/heads/main")
+	evt.ChangedFiles = func() ([]string, error) { return []string{"README.md", "docs/a/b.md"}, nil }
+	assert.True(t, w.Match(evt))
+	evt.ChangedFiles = func() ([]string, error) { return []string{"main.go"}, nil }
+	assert.False(t, w.Match(evt))
+
+	w = parse("on:\n  push:\n    paths-ignore: ['**.md']")
+	evt.ChangedFiles = func() ([]string, error) { return []string{"README.md"}, nil }
+	assert.False(t, w.Match(evt))
+	evt.ChangedFiles = func() ([]string, error) { return []string{"README.md", "main.go"}, nil }
+	assert.True(t, w.Match(evt))
+}
@@ -0,0 +1,276 @@
+// Copyright 2022 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/setting"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Workflow represents a workflow file
+type Workflow struct {
+	Name string            `yaml:"name,omitempty"`
+	On   Events            `yaml:"on,omitempty"`
+	Env  map[string]string `yaml:"env,omitempty"`
+	Jobs Jobs              `yaml:"jobs"`
+}
+
+// Job represents a job of a workflow
+type Job struct {
+	ID             string            `yaml:"-"`
+	Name           string            `yaml:"name,omitempty"`
+	RunsOn         StringOrSlice     `yaml:"runs-on,omitempty"`
+	Needs          StringOrSlice     `yaml:"needs,omitempty"`
+	Env            map[string]string `yaml:"env,omitempty"`
+	TimeoutMinutes int               `yaml:"timeout-minutes,omitempty"`
+	Steps          []*Step           `yaml:"steps"`
+}
+
+// Step represents a step of a job
+type Step struct {
+	ID               string            `yaml:"id,omitempty"`
+	Name             string            `yaml:"name,omitempty"`
+	Run              string            `yaml:"run,omitempty"`
+	Uses             string            `yaml:"uses,omitempty"`
+	With             map[string]string `yaml:"with,omitempty"`
+	Env              map[string]string `yaml:"env,omitempty"`
+	Shell            string            `yaml:"shell,omitempty"`
+	WorkingDirectory string            `yaml:"working-directory,omitempty"`
+	ContinueOnError  bool              `yaml:"continue-on-error,omitempty"`
+	TimeoutMinutes   int               `yaml:"timeout-minutes,omitempty"`
+}
+
+// EventFilter holds the filters of an event a workflow can be triggered by
+type EventFilter struct {
+	Types          StringOrSlice `yaml:"types,omitempty"`
+	Branches       StringOrSlice `yaml:"branches,omitempty"`
+	BranchesIgnore StringOrSlice `yaml:"branches-ignore,omitempty"`
+	Tags           StringOrSlice `yaml:"tags,omitempty"`
+	TagsIgnore     StringOrSlice `yaml:"tags-ignore,omitempty"`
+	Paths          StringOrSlice `yaml:"paths,omitempty"`
+	PathsIgnore    StringOrSlice `yaml:"paths-ignore,omitempty"`
+}
+
+// Events maps the names of the events a workflow can be triggered by to their filters
+type Events map[string]*EventFilter
+
+// UnmarshalYAML supports the string, list and map forms of the "on" section
+func (e *Events) UnmarshalYAML(node *yaml.Node) error {
+	events := make(Events)
+	switch node.Kind {
+	case yaml.ScalarNode:
+		events[node.Value] = &EventFilter{}
+	case yaml.SequenceNode:
+		var names []string
+		if err := node.Decode(&names); err != nil {
+			return err
+		}
+		for _, name := range names {
+			events[name] = &EventFilter{}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			filter := &EventFilter{}
+			if value := node.Content[i+1]; value.Kind != yaml.ScalarNode || value.Tag != "!!null" {
+				if err := value.Decode(filter); err != nil {
+					return fmt.Errorf("invalid filter of event %q: %w", node.Content[i].Value, err)
+				}
+			}
+			events[node.Content[i].Value] = filter
+		}
+	default:
+		return fmt.Errorf("invalid \"on\" section at line %d", node.Line)
+	}
+	*e = events
+	return nil
+}
+
+// Jobs is the ordered list of jobs of a workflow
+type Jobs []*Job
+
+// UnmarshalYAML reads the jobs in the order they are defined in the file
+func (j *Jobs) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("invalid \"jobs\" section at line %d", node.Line)
+	}
+	jobs := make(Jobs, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		job := &Job{}
+		if err := node.Content[i+1].Decode(job); err != nil {
+			return fmt.Errorf("invalid job %q: %w", node.Content[i].Value, err)
+		}
+		job.ID = node.Content[i].Value
+		jobs = append(jobs, job)
+	}
+	*j = jobs
+	return nil
+}
+
+// MarshalYAML writes the jobs as a map keyed by their ids
+func (j Jobs) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, job := range j {
+		value := &yaml.Node{}
+		if err := value.Encode(job); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: job.ID}, value)
+	}
+	return node, nil
+}
+
+// StringOrSlice is a list of strings which may be written as a single string
+type StringOrSlice []string
+
+// UnmarshalYAML supports both a single string and a list of strings
+func (s *StringOrSlice) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*s = []string{node.Value}
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*s = list
+	return nil
+}
+
+// ParseWorkflow parses and validates the content of a workflow file
+func ParseWorkflow(content []byte) (*Workflow, error) {
+	w := &Workflow{}
+	if err := yaml.NewDecoder(bytes.NewReader(content)).Decode(w); err != nil {
+		return nil, err
+	}
+	if len(w.On) == 0 {
+		return nil, fmt.Errorf("no events defined")
+	}
+	if len(w.Jobs) == 0 {
+		return nil, fmt.Errorf("no jobs defined")
+	}
+
+	ids := make(map[string]bool, len(w.Jobs))
+	for _, job := range w.Jobs {
+		ids[job.ID] = true
+	}
+	for _, job := range w.Jobs {
+		if len(job.Steps) == 0 {
+			return nil, fmt.Errorf("job %q has no steps", job.ID)
+		}
+		for _, need := range job.Needs {
+			if !ids[need] || need == job.ID {
+				return nil, fmt.Errorf("job %q needs unknown job %q", job.ID, need)
+			}
+		}
+		for i, step := range job.Steps {
+			if (step.Run == "") == (step.Uses == "") {
+				return nil, fmt.Errorf("step %d of job %q must define exactly one of \"run\" or \"uses\"", i+1, job.ID)
+			}
+		}
+	}
+	if err := checkCycles(w.Jobs); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func checkCycles(jobs Jobs) error {
+	needs := make(map[string][]string, len(jobs))
+	for _, job := range jobs {
+		needs[job.ID] = job.Needs
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(jobs))
+	var visit func(id string) error
+	visit = func(id string) error {
+		switch state[id] {
+		case visiting:
+			return fmt.Errorf("job %q is part of a dependency cycle", id)
+		case visited:
+			return nil
+		}
+		state[id] = visiting
+		for _, need := range needs[id] {
+			if err := visit(need); err != nil {
+				return err
+			}
+		}
+		state[id] = visited
+		return nil
+	}
+	for _, job := range jobs {
+		if err := visit(job.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DisplayName returns the name of the job or its id if there is no name
+func (job *Job) DisplayName() string {
+	if job.Name != "" {
+		return job.Name
+	}
+	return job.ID
+}
+
+// SingleJobPayload returns the content of a workflow which only contains the given job.
+// The dependencies of the job are dropped because they are resolved before the job is handed to a runner.
+func (w *Workflow) SingleJobPayload(job *Job) ([]byte, error) {
+	copied := *job
+	copied.Needs = nil
+	single := &Workflow{
+		Name: w.Name,
+		Env:  w.Env,
+		Jobs: Jobs{&copied},
+	}
+	return yaml.Marshal(single)
+}
+
+// ListWorkflows returns the workflow files of the commit
+func ListWorkflows(commit *git.Commit) (git.Entries, error) {
+	tree, err := commit.SubTree(setting.Actions.WorkflowsPath)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	entries, err := tree.ListEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(git.Entries, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsRegular() && (strings.HasSuffix(entry.Name(), ".yml") || strings.HasSuffix(entry.Name(), ".yaml")) {
+			ret = append(ret, entry)
+		}
+	}
+	return ret, nil
+}
+
+// GetContentFromEntry reads the content of a workflow file
+func GetContentFromEntry(entry *git.TreeEntry) ([]byte, error) {
+	f, err := entry.Blob().DataAsync()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return io.ReadAll(f)
+}
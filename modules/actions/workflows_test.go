@@ -0,0 +1,79 @@
+// Copyright 2022 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWorkflow(t *testing.T) {
+	w, err := ParseWorkflow([]byte(`
+name: CI
+on:
+  push:
+    branches: [main]
+  pull_request:
+env:
+  FOO: bar
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make test
+  build:
+    name: Build it
+    runs-on: [linux, amd64]
+    needs: test
+    steps:
+      - uses: actions/checkout@v3
+      - run: make build
+        env:
+          TOKEN: abc
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, "CI", w.Name)
+	assert.Len(t, w.On, 2)
+	assert.Equal(t, StringOrSlice{"main"}, w.On[EventPush].Branches)
+	assert.NotNil(t, w.On[EventPullRequest])
+	assert.Equal(t, "bar", w.Env["FOO"])
+
+	assert.Len(t, w.Jobs, 2)
+	assert.Equal(t, "test", w.Jobs[0].ID)
+	assert.Equal(t, "test", w.Jobs[0].DisplayName())
+	assert.Equal(t, StringOrSlice{"ubuntu-latest"}, w.Jobs[0].RunsOn)
+	assert.Equal(t, "build", w.Jobs[1].ID)
+	assert.Equal(t, "Build it", w.Jobs[1].DisplayName())
+	assert.Equal(t, StringOrSlice{"linux", "amd64"}, w.Jobs[1].RunsOn)
+	assert.Equal(t, StringOrSlice{"test"}, w.Jobs[1].Needs)
+	assert.Len(t, w.Jobs[1].Steps, 2)
+	assert.Equal(t, "abc", w.Jobs[1].Steps[1].Env["TOKEN"])
+
+	payload, err := w.SingleJobPayload(w.Jobs[1])
+	assert.NoError(t, err)
+	single, err := ParseWorkflow(append([]byte("on: push\n"), payload...))
+	assert.NoError(t, err)
+	assert.Len(t, single.Jobs, 1)
+	assert.Equal(t, "build", single.Jobs[0].ID)
+	assert.Equal(t, "bar", single.Env["FOO"])
+
+	w, err = ParseWorkflow([]byte("on: [push, pull_request]\njobs:\n  a:\n    steps:\n      - run: echo\n"))
+	assert.NoError(t, err)
+	assert.Len(t, w.On, 2)
+
+	cases := map[string]string{
+		"no events":      "jobs:\n  a:\n    steps:\n      - run: echo\n",
+		"no jobs":        "on: push\n",
+		"no steps":       "on: push\njobs:\n  a:\n    runs-on: x\n",
+		"unknown need":   "on: push\njobs:\n  a:\n    needs: b\n    steps:\n      - run: echo\n",
+		"cycle":          "on: push\njobs:\n  a:\n    needs: b\n    steps:\n      - run: echo\n  b:\n    needs: a\n    steps:\n      - run: echo\n",
+		"run and uses":   "on: push\njobs:\n  a:\n    steps:\n      - run: echo\n        uses: foo\n",
+		"invalid syntax": "on: push\njobs: [\n",
+	}
+	for name, content := range cases {
+		_, err := ParseWorkflow([]byte(content))
+		assert.Error(t, err, name)
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"strings"
 	"time"
 
+	auth_model "code.gitea.io/gitea/models/auth"
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/models/unit"
 	user_model "code.gitea.io/gitea/models/user"
@@ -101,8 +102,12 @@ func (ctx *Context) GetData() map[string]interface{} {
 	return ctx.Data
 }
 
-// IsUserSiteAdmin returns true if current user is a site admin
+// IsUserSiteAdmin returns true if current user is a site admin.
+// Fine-grained access tokens never act with site admin permissions.
 func (ctx *Context) IsUserSiteAdmin() bool {
+	if token, ok := ctx.Data["ApiToken"].(*auth_model.AccessToken); ok && token.IsFineGrained() {
+		return false
+	}
 	return ctx.IsSigned && ctx.Doer.IsAdmin
 }
 
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"net/http"
 
+	auth_model "code.gitea.io/gitea/models/auth"
 	"code.gitea.io/gitea/models/organization"
 	packages_model "code.gitea.io/gitea/models/packages"
 	"code.gitea.io/gitea/models/perm"
@@ -134,6 +135,19 @@ func determineAccessMode(ctx *Context) (perm.AccessMode, error) {
 		}
 	}
 
+	// A fine-grained access token only grants access to the packages of its resource owner
+	if token, ok := ctx.Data["ApiToken"].(*auth_model.AccessToken); ok && token.IsFineGrained() {
+		if !token.CanAccessOwner(ctx.Package.Owner.ID) {
+			return perm.AccessModeNone, nil
+		}
+		if granted := token.UnitAccessMode(unit.TypePackages); granted < accessMode {
+			accessMode = granted
+		}
+		if accessMode > perm.AccessModeWrite {
+			accessMode = perm.AccessModeWrite
+		}
+	}
+
 	return accessMode, nil
 }
 
@@ -27,7 +27,7 @@ type BlameReader struct {
 	lastSha *string
 }
 
-var shaLineRegex = regexp.MustCompile("^([a-z0-9]{40})")
+var shaLineRegex = regexp.MustCompile("^([a-z0-9]{64}|[a-z0-9]{40})")
 
 // NextPart returns next part of blame (sequential code lines with the same commit)
 func (r *BlameReader) NextPart() (*BlamePart, error) {
@@ -36,6 +36,9 @@ var (
 	// SupportProcReceive version >= 2.29.0
 	SupportProcReceive bool
 
+	// SupportSHA256 version >= 2.29.0, it is always false for the gogit builds which can't read SHA-256 repositories
+	SupportSHA256 bool
+
 	gitVersion *version.Version
 )
 
@@ -190,6 +193,7 @@ func InitFull(ctx context.Context) (err error) {
 	}
 
 	SupportProcReceive = CheckGitVersionAtLeast("2.29") == nil
+	SupportSHA256 = sha256ObjectFormatReadable && CheckGitVersionAtLeast("2.29") == nil
 
 	if setting.LFS.StartServer {
 		if CheckGitVersionAtLeast("2.1.2") != nil {
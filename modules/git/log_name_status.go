@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"path"
 	"sort"
@@ -139,8 +140,12 @@ func (g *LogNameStatusRepoParser) Next(treepath string, paths2ids map[string]int
 	}
 
 	// Our "line" must look like: <commitid> SP (<parent> SP) * NUL
-	ret.CommitID = string(g.next[0:40])
-	parents := string(g.next[41:])
+	idx := bytes.IndexByte(g.next, ' ')
+	if idx < 0 {
+		return nil, fmt.Errorf("unexpected commit line: %q", g.next)
+	}
+	ret.CommitID = string(g.next[:idx])
+	parents := string(g.next[idx+1:])
 	if g.buffull {
 		more, err := g.rd.ReadString('\x00')
 		if err != nil {
@@ -0,0 +1,139 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package git
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// ObjectFormat is the hash algorithm which names the objects of a repository
+type ObjectFormat string
+
+const (
+	// ObjectFormatSHA1 is the object format of the repositories created by default
+	ObjectFormatSHA1 ObjectFormat = "sha1"
+	// ObjectFormatSHA256 is the object format of the repositories created with --object-format=sha256
+	ObjectFormatSHA256 ObjectFormat = "sha256"
+)
+
+// EmptySHA256 defines empty git SHA-256
+const EmptySHA256 = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// EmptyTreeSHA256 is the SHA-256 of an empty tree
+const EmptyTreeSHA256 = "6ef19b41225c5369f1c104d45d8d85efa9b057b53b14b4b9b939dd74decc5321"
+
+// SHA256FullLength is the full length of a git SHA-256
+const SHA256FullLength = 64
+
+// IsValid returns whether the object format is known
+func (f ObjectFormat) IsValid() bool {
+	return f == ObjectFormatSHA1 || f == ObjectFormatSHA256
+}
+
+// FullLength returns the length of the hex representation of the object ids
+func (f ObjectFormat) FullLength() int {
+	if f == ObjectFormatSHA256 {
+		return SHA256FullLength
+	}
+	return SHAFullLength
+}
+
+// RawLength returns the length of the binary representation of the object ids
+func (f ObjectFormat) RawLength() int {
+	return f.FullLength() / 2
+}
+
+// EmptyObjectID returns the all zero object id which git uses for a missing object
+func (f ObjectFormat) EmptyObjectID() string {
+	if f == ObjectFormatSHA256 {
+		return EmptySHA256
+	}
+	return EmptySHA
+}
+
+// EmptyTree returns the id of the empty tree
+func (f ObjectFormat) EmptyTree() string {
+	if f == ObjectFormatSHA256 {
+		return EmptyTreeSHA256
+	}
+	return EmptyTreeSHA
+}
+
+// NewHash returns the hash function of the object format
+func (f ObjectFormat) NewHash() hash.Hash {
+	if f == ObjectFormatSHA256 {
+		return sha256.New()
+	}
+	return sha1.New()
+}
+
+// SupportedObjectFormats returns the object formats which new repositories can be created with
+func SupportedObjectFormats() []ObjectFormat {
+	if SupportSHA256 {
+		return []ObjectFormat{ObjectFormatSHA1, ObjectFormatSHA256}
+	}
+	return []ObjectFormat{ObjectFormatSHA1}
+}
+
+// IsSupportedObjectFormat returns whether new repositories can be created with the object format
+func IsSupportedObjectFormat(f ObjectFormat) bool {
+	for _, supported := range SupportedObjectFormats() {
+		if f == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// ObjectFormatFromID returns the object format of a full length object id
+func ObjectFormatFromID(id string) (ObjectFormat, bool) {
+	switch len(id) {
+	case SHAFullLength:
+		return ObjectFormatSHA1, true
+	case SHA256FullLength:
+		return ObjectFormatSHA256, true
+	}
+	return "", false
+}
+
+// IsEmptyCommitID returns whether the commit id is the all zero id of one of the object formats,
+// git uses it for the old id of created and the new id of deleted references
+func IsEmptyCommitID(id string) bool {
+	return id == EmptySHA || id == EmptySHA256
+}
+
+// GetObjectFormatOfRepo returns the object format of the repository at the given path
+func GetObjectFormatOfRepo(ctx context.Context, repoPath string) (ObjectFormat, error) {
+	if CheckGitVersionAtLeast("2.29") != nil {
+		// git versions which don't know --show-object-format can only handle SHA-1 repositories
+		return ObjectFormatSHA1, nil
+	}
+	stdout, _, err := NewCommand(ctx, "rev-parse", "--show-object-format").RunStdString(&RunOpts{Dir: repoPath})
+	if err != nil {
+		return "", err
+	}
+	f := ObjectFormat(strings.TrimSpace(stdout))
+	if !f.IsValid() {
+		return "", fmt.Errorf("unknown object format %q of repository %s", f, repoPath)
+	}
+	return f, nil
+}
+
+// GetObjectFormat returns the object format of the repository
+func (repo *Repository) GetObjectFormat() (ObjectFormat, error) {
+	if repo.objectFormat != "" {
+		return repo.objectFormat, nil
+	}
+	f, err := GetObjectFormatOfRepo(repo.Ctx, repo.Path)
+	if err != nil {
+		return "", err
+	}
+	repo.objectFormat = f
+	return f, nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObjectFormatFromID(t *testing.T) {
+	f, ok := ObjectFormatFromID("feaf4ba6bc635fec442f46ddd4512416ec43c2c2")
+	assert.True(t, ok)
+	assert.Equal(t, ObjectFormatSHA1, f)
+
+	f, ok = ObjectFormatFromID(EmptyTreeSHA256)
+	assert.True(t, ok)
+	assert.Equal(t, ObjectFormatSHA256, f)
+
+	_, ok = ObjectFormatFromID("feaf4ba6bc")
+	assert.False(t, ok)
+
+	assert.True(t, IsEmptyCommitID(EmptySHA))
+	assert.True(t, IsEmptyCommitID(EmptySHA256))
+	assert.False(t, IsEmptyCommitID(EmptyTreeSHA))
+}
+
+func TestSHA256Repository(t *testing.T) {
+	if !SupportSHA256 {
+		t.Skip("SHA-256 repositories are not supported")
+	}
+
+	repoPath := t.TempDir()
+	assert.NoError(t, InitRepository(DefaultContext, repoPath, false, ObjectFormatSHA256))
+	assert.NoError(t, os.WriteFile(filepath.Join(repoPath, "README.md"), []byte("# SHA-256\n"), 0o644))
+	assert.NoError(t, NewCommand(DefaultContext, "add", "README.md").Run(&RunOpts{Dir: repoPath}))
+	// the temporary HOME of the tests has no identity configured
+	signature := &Signature{Name: "Gitea", Email: "gitea@example.com", When: time.Now()}
+	require.NoError(t, CommitChanges(repoPath, CommitChangesOptions{Committer: signature, Message: "Initial commit"}))
+
+	repo, err := openRepositoryWithDefaultContext(repoPath)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	objectFormat, err := repo.GetObjectFormat()
+	assert.NoError(t, err)
+	assert.Equal(t, ObjectFormatSHA256, objectFormat)
+
+	commit, err := repo.GetCommit("HEAD")
+	require.NoError(t, err)
+	assert.Len(t, commit.ID.String(), SHA256FullLength)
+	assert.Equal(t, "Initial commit\n", commit.Message())
+
+	sameCommit, err := repo.GetCommit(commit.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, commit.ID, sameCommit.ID)
+
+	entries, err := commit.Tree.ListEntries()
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "README.md", entries[0].Name())
+		assert.Len(t, entries[0].ID.String(), SHA256FullLength)
+
+		assert.Equal(t, entries[0].ID, ComputeBlobHash(ObjectFormatSHA256, []byte("# SHA-256\n")))
+
+		content, err := entries[0].Blob().GetBlobContent()
+		assert.NoError(t, err)
+		assert.Equal(t, "# SHA-256\n", content)
+	}
+}
@@ -98,9 +98,12 @@ func catBatchParseTreeEntries(ptree *Tree, rd *bufio.Reader, sz int64) ([]*TreeE
 	shaBuf := make([]byte, 40)
 	entries := make([]*TreeEntry, 0, 10)
 
+	// the tree entries are named like the tree itself
+	objectFormat := ptree.ID.ObjectFormat()
+
 loop:
 	for sz > 0 {
-		mode, fname, sha, count, err := ParseTreeLine(rd, modeBuf, fnameBuf, shaBuf)
+		mode, fname, sha, count, err := ParseTreeLine(objectFormat, rd, modeBuf, fnameBuf, shaBuf)
 		if err != nil {
 			if err == io.EOF {
 				break loop
@@ -7,6 +7,7 @@ package git
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/url"
@@ -63,7 +64,14 @@ func IsRepoURLAccessible(ctx context.Context, url string) bool {
 }
 
 // InitRepository initializes a new Git repository.
-func InitRepository(ctx context.Context, repoPath string, bare bool) error {
+func InitRepository(ctx context.Context, repoPath string, bare bool, objectFormat ObjectFormat) error {
+	if !objectFormat.IsValid() {
+		return fmt.Errorf("invalid object format: %q", objectFormat)
+	}
+	if objectFormat == ObjectFormatSHA256 && !SupportSHA256 {
+		return errors.New("sha256 object format requires git >= 2.29 and isn't supported by the gogit builds")
+	}
+
 	err := os.MkdirAll(repoPath, os.ModePerm)
 	if err != nil {
 		return err
@@ -73,6 +81,9 @@ func InitRepository(ctx context.Context, repoPath string, bare bool) error {
 	if bare {
 		cmd.AddArguments("--bare")
 	}
+	if objectFormat == ObjectFormatSHA256 {
+		cmd.AddArguments("--object-format=sha256")
+	}
 	_, _, err = cmd.RunStdString(&RunOpts{Dir: repoPath})
 	return err
 }
@@ -29,6 +29,7 @@ type Repository struct {
 	gogitRepo    *gogit.Repository
 	gogitStorage *filesystem.Storage
 	gpgSettings  *GPGSettings
+	objectFormat ObjectFormat
 
 	Ctx             context.Context
 	LastCommitCache *LastCommitCache
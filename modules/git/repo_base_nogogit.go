@@ -23,6 +23,8 @@ type Repository struct {
 
 	gpgSettings *GPGSettings
 
+	objectFormat ObjectFormat
+
 	batchCancel context.CancelFunc
 	batchReader *bufio.Reader
 	batchWriter WriteCloserError
@@ -3,7 +3,10 @@
 
 package git
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // FileBlame return the Blame object of file
 func (repo *Repository) FileBlame(revision, path, file string) ([]byte, error) {
@@ -20,8 +23,9 @@ func (repo *Repository) LineBlame(revision, path, file string, line uint) (*Comm
 	if err != nil {
 		return nil, err
 	}
-	if len(res) < 40 {
+	idx := strings.IndexByte(res, ' ')
+	if idx < SHAFullLength {
 		return nil, fmt.Errorf("invalid result of blame: %s", res)
 	}
-	return repo.GetCommit(res[:40])
+	return repo.GetCommit(res[:idx])
 }
@@ -5,8 +5,8 @@
 package git
 
 import (
+	"bufio"
 	"bytes"
-	"encoding/hex"
 	"fmt"
 	"io"
 	"strconv"
@@ -230,22 +230,18 @@ func (repo *Repository) CommitsByFileAndRange(revision, file string, page int) (
 	}()
 
 	commits := []*Commit{}
-	shaline := [41]byte{}
-	var sha1 SHA1
+	rd := bufio.NewReader(stdoutReader)
 	for {
-		n, err := io.ReadFull(stdoutReader, shaline[:])
-		if err != nil || n < 40 {
-			if err == io.EOF {
-				err = nil
-			}
-			return commits, err
+		shaline, err := rd.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
 		}
-		n, err = hex.Decode(sha1[:], shaline[0:40])
-		if n != 20 {
-			err = fmt.Errorf("invalid sha %q", string(shaline[:40]))
+		if shaline = strings.TrimSpace(shaline); len(shaline) == 0 {
+			return commits, nil
 		}
-		if err != nil {
-			return nil, err
+		sha1, parseErr := NewIDFromString(shaline)
+		if parseErr != nil {
+			return nil, fmt.Errorf("invalid sha %q", shaline)
 		}
 		commit, err := repo.getCommit(sha1)
 		if err != nil {
@@ -0,0 +1,148 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package git
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaintenanceTask is an incremental task which keeps a repository efficient to read,
+// unlike "git gc" every task only touches the part of the repository which needs it.
+type MaintenanceTask string
+
+// The maintenance tasks, they are named after the tasks of "git maintenance"
+const (
+	MaintenanceTaskPackRefs          MaintenanceTask = "pack-refs"
+	MaintenanceTaskLooseObjects      MaintenanceTask = "loose-objects"
+	MaintenanceTaskIncrementalRepack MaintenanceTask = "incremental-repack"
+	MaintenanceTaskCommitGraph       MaintenanceTask = "commit-graph"
+)
+
+// MaintenanceTasks are all the maintenance tasks in the order they should be run
+var MaintenanceTasks = []MaintenanceTask{
+	MaintenanceTaskPackRefs,
+	MaintenanceTaskLooseObjects,
+	MaintenanceTaskIncrementalRepack,
+	MaintenanceTaskCommitGraph,
+}
+
+// ObjectStats represents the object statistics of a repository reported by "git count-objects -v"
+type ObjectStats struct {
+	LooseObjects int64
+	LooseSize    int64 // in KiB
+	Packs        int64
+	PackSize     int64 // in KiB
+	Garbage      int64
+}
+
+// GetObjectStats returns the object statistics of the repository
+func GetObjectStats(ctx context.Context, repoPath string) (*ObjectStats, error) {
+	stdout, _, err := NewCommand(ctx, "count-objects", "-v").RunStdString(&RunOpts{Dir: repoPath})
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &ObjectStats{}
+	scanner := bufio.NewScanner(strings.NewReader(stdout))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ": ")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse %q of count-objects: %w", scanner.Text(), err)
+		}
+		switch key {
+		case "count":
+			stats.LooseObjects = n
+		case "size":
+			stats.LooseSize = n
+		case "packs":
+			stats.Packs = n
+		case "size-pack":
+			stats.PackSize = n
+		case "garbage":
+			stats.Garbage = n
+		}
+	}
+	return stats, scanner.Err()
+}
+
+// CountLooseRefs returns the number of the references of the bare repository which are not packed
+func CountLooseRefs(repoPath string) (int64, error) {
+	var count int64
+	err := filepath.WalkDir(filepath.Join(repoPath, "refs"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// RunMaintenanceTaskOptions represents the options of a maintenance task
+type RunMaintenanceTaskOptions struct {
+	Timeout time.Duration
+	// PruneExpire is passed to "git prune --expire", the unreachable loose objects are kept when it is empty
+	PruneExpire string
+}
+
+// RunMaintenanceTask runs the maintenance task on the repository
+func RunMaintenanceTask(ctx context.Context, repoPath string, task MaintenanceTask, opts RunMaintenanceTaskOptions) error {
+	runOpts := &RunOpts{Dir: repoPath, Timeout: opts.Timeout}
+	desc := fmt.Sprintf("Repository maintenance %s: %s", task, repoPath)
+
+	var cmds []*Command
+	switch task {
+	case MaintenanceTaskPackRefs:
+		cmds = append(cmds, NewCommand(ctx, "pack-refs", "--all"))
+	case MaintenanceTaskLooseObjects:
+		// without "-a" only the loose objects are packed into a new pack, "-d" removes the loose objects which have been packed
+		cmds = append(cmds, NewCommand(ctx, "repack", "-d", "-l", "-q"))
+		if opts.PruneExpire != "" {
+			// the expiry is set by config, it can be trusted
+			cmds = append(cmds, NewCommand(ctx, "prune", CmdArg("--expire="+opts.PruneExpire)))
+		}
+	case MaintenanceTaskIncrementalRepack:
+		if CheckGitVersionAtLeast("2.33") == nil {
+			// a geometric repack only rolls up the small packs, the large ones are left untouched
+			cmds = append(cmds, NewCommand(ctx, "repack", "-d", "-l", "-q", "--geometric=2"))
+		} else {
+			cmds = append(cmds, NewCommand(ctx, "repack", "-a", "-d", "-l", "-q"))
+		}
+	case MaintenanceTaskCommitGraph:
+		if CheckGitVersionAtLeast("2.18") != nil {
+			return nil
+		}
+		cmd := NewCommand(ctx, "commit-graph", "write", "--reachable")
+		if CheckGitVersionAtLeast("2.24") == nil {
+			// a split commit-graph only writes the commits which are not in the graph yet
+			cmd.AddArguments("--split")
+		}
+		if CheckGitVersionAtLeast("2.27") == nil {
+			cmd.AddArguments("--changed-paths")
+		}
+		cmds = append(cmds, cmd)
+	default:
+		return fmt.Errorf("unknown maintenance task: %s", task)
+	}
+
+	for _, cmd := range cmds {
+		if _, stderr, err := cmd.SetDescription(desc).RunStdString(runOpts); err != nil {
+			return fmt.Errorf("%s: %w - %s", cmd.String(), err, stderr)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunMaintenanceTask(t *testing.T) {
+	repoPath := t.TempDir()
+	assert.NoError(t, InitRepository(DefaultContext, repoPath, false, ObjectFormatSHA1))
+	assert.NoError(t, os.WriteFile(filepath.Join(repoPath, "README.md"), []byte("# Maintenance\n"), 0o644))
+	assert.NoError(t, NewCommand(DefaultContext, "add", "README.md").Run(&RunOpts{Dir: repoPath}))
+	// the temporary HOME of the tests has no identity configured
+	signature := &Signature{Name: "Gitea", Email: "gitea@example.com", When: time.Now()}
+	require.NoError(t, CommitChanges(repoPath, CommitChangesOptions{Committer: signature, Message: "Initial commit"}))
+	require.NoError(t, NewCommand(DefaultContext, "tag", "v1.0").Run(&RunOpts{Dir: repoPath}))
+
+	stats, err := GetObjectStats(DefaultContext, repoPath)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, stats.LooseObjects) // the blob, the tree and the commit
+	assert.EqualValues(t, 0, stats.Packs)
+
+	gitDir := filepath.Join(repoPath, ".git")
+	looseRefs, err := CountLooseRefs(gitDir)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, looseRefs)
+
+	opts := RunMaintenanceTaskOptions{PruneExpire: "2.weeks.ago"}
+	assert.NoError(t, RunMaintenanceTask(DefaultContext, repoPath, MaintenanceTaskPackRefs, opts))
+	looseRefs, err = CountLooseRefs(gitDir)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, looseRefs)
+
+	assert.NoError(t, RunMaintenanceTask(DefaultContext, repoPath, MaintenanceTaskLooseObjects, opts))
+	stats, err = GetObjectStats(DefaultContext, repoPath)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, stats.LooseObjects)
+	assert.EqualValues(t, 1, stats.Packs)
+
+	assert.NoError(t, RunMaintenanceTask(DefaultContext, repoPath, MaintenanceTaskIncrementalRepack, opts))
+	stats, err = GetObjectStats(DefaultContext, repoPath)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, stats.Packs)
+
+	assert.NoError(t, RunMaintenanceTask(DefaultContext, repoPath, MaintenanceTaskCommitGraph, opts))
+	if CheckGitVersionAtLeast("2.24") == nil {
+		assert.DirExists(t, filepath.Join(gitDir, "objects", "info", "commit-graphs"))
+	}
+
+	assert.Error(t, RunMaintenanceTask(DefaultContext, repoPath, "unknown", opts))
+}
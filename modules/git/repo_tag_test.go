@@ -182,7 +182,7 @@ func TestRepository_GetAnnotatedTag(t *testing.T) {
 	// Annotated tag's name should fail
 	tag3, err := bareRepo1.GetAnnotatedTag(aTagName)
 	assert.Error(t, err)
-	assert.Errorf(t, err, "Length must be 40: %d", len(aTagName))
+	assert.Errorf(t, err, "Length must be 40 or 64: %d", len(aTagName))
 	assert.Nil(t, tag3)
 
 	// Lightweight Tag should fail
@@ -0,0 +1,73 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package git
+
+import (
+	"strings"
+
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// ServiceOptions are the repository specific options of the git services upload-pack and receive-pack,
+// which are served to the clients over HTTP and SSH
+type ServiceOptions struct {
+	DisablePartialClone bool
+	DisableProtocolV2   bool
+	// BundleURI is the url of the pre-generated bundle which the clients could bootstrap from
+	BundleURI string
+}
+
+// IsPartialCloneEnabled returns whether the clients could clone and fetch with a filter
+func (opts ServiceOptions) IsPartialCloneEnabled() bool {
+	return !setting.Git.DisablePartialClone && !opts.DisablePartialClone
+}
+
+// IsProtocolV2Enabled returns whether the clients could use the git wire protocol version 2
+func (opts ServiceOptions) IsProtocolV2Enabled() bool {
+	return !setting.Git.DisableProtocolV2 && !opts.DisableProtocolV2
+}
+
+// ConfigArgs returns the "-c" arguments which configure the service, they override the global git config
+// because the partial clones could be disabled for the repository only
+func (opts ServiceOptions) ConfigArgs() []CmdArg {
+	var args []CmdArg
+	if opts.IsPartialCloneEnabled() {
+		// the blobs missing in a partial clone are fetched lazily by their ids
+		args = append(args, "-c", "uploadpack.allowFilter=true", "-c", "uploadpack.allowAnySHA1InWant=true")
+		if len(setting.Git.PartialCloneFilters) > 0 {
+			args = append(args, "-c", "uploadpackfilter.allow=false")
+			for _, filter := range setting.Git.PartialCloneFilters {
+				args = append(args, "-c", CmdArg("uploadpackfilter."+filter+".allow=true"))
+			}
+		}
+	} else {
+		args = append(args, "-c", "uploadpack.allowFilter=false", "-c", "uploadpack.allowAnySHA1InWant=false")
+	}
+	if opts.IsProtocolV2Enabled() {
+		args = append(args, "-c", "uploadpack.allowRefInWant=true")
+		// the bundle-uri capability is a protocol version 2 feature, git upload-pack can advertise it since v2.40
+		if opts.BundleURI != "" && CheckGitVersionAtLeast("2.40") == nil {
+			args = append(args, "-c", "uploadpack.advertiseBundleURIs=true",
+				"-c", "bundle.version=1", "-c", "bundle.mode=all",
+				"-c", CmdArg("bundle.clone.uri="+opts.BundleURI))
+		}
+	}
+	return args
+}
+
+// GitProtocol returns the value of GIT_PROTOCOL which passes the parameters requested by the client to the service,
+// it drops the request of the protocol version 2 when it is disabled so the service falls back to the version 0
+func (opts ServiceOptions) GitProtocol(protocol string) string {
+	if protocol == "" || opts.IsProtocolV2Enabled() {
+		return protocol
+	}
+	params := strings.Split(protocol, ":")
+	kept := params[:0]
+	for _, param := range params {
+		if param != "version=2" {
+			kept = append(kept, param)
+		}
+	}
+	return strings.Join(kept, ":")
+}
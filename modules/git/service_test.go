@@ -0,0 +1,61 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package git
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceOptionsConfigArgs(t *testing.T) {
+	defer func(filters []string) {
+		setting.Git.PartialCloneFilters = filters
+	}(setting.Git.PartialCloneFilters)
+
+	setting.Git.PartialCloneFilters = nil
+	assert.EqualValues(t, []CmdArg{
+		"-c", "uploadpack.allowFilter=true", "-c", "uploadpack.allowAnySHA1InWant=true",
+		"-c", "uploadpack.allowRefInWant=true",
+	}, ServiceOptions{}.ConfigArgs())
+
+	assert.EqualValues(t, []CmdArg{
+		"-c", "uploadpack.allowFilter=false", "-c", "uploadpack.allowAnySHA1InWant=false",
+	}, ServiceOptions{DisablePartialClone: true, DisableProtocolV2: true}.ConfigArgs())
+
+	setting.Git.PartialCloneFilters = []string{"blob:none", "tree"}
+	assert.EqualValues(t, []CmdArg{
+		"-c", "uploadpack.allowFilter=true", "-c", "uploadpack.allowAnySHA1InWant=true",
+		"-c", "uploadpackfilter.allow=false",
+		"-c", "uploadpackfilter.blob:none.allow=true",
+		"-c", "uploadpackfilter.tree.allow=true",
+	}, ServiceOptions{DisableProtocolV2: true}.ConfigArgs())
+}
+
+func TestServiceOptionsGitProtocol(t *testing.T) {
+	assert.Equal(t, "version=2", ServiceOptions{}.GitProtocol("version=2"))
+	assert.Equal(t, "", ServiceOptions{}.GitProtocol(""))
+
+	opts := ServiceOptions{DisableProtocolV2: true}
+	assert.Equal(t, "", opts.GitProtocol("version=2"))
+	assert.Equal(t, "version=1", opts.GitProtocol("version=1"))
+	assert.Equal(t, "object-format=sha256", opts.GitProtocol("version=2:object-format=sha256"))
+}
+
+func TestServiceOptionsBundleURI(t *testing.T) {
+	opts := ServiceOptions{BundleURI: "https://try.gitea.io/user2/repo1/clone.bundle"}
+	args := opts.ConfigArgs()
+	if CheckGitVersionAtLeast("2.40") != nil {
+		assert.NotContains(t, args, CmdArg("uploadpack.advertiseBundleURIs=true"))
+	} else {
+		assert.Contains(t, args, CmdArg("uploadpack.advertiseBundleURIs=true"))
+		assert.Contains(t, args, CmdArg("bundle.clone.uri=https://try.gitea.io/user2/repo1/clone.bundle"))
+	}
+
+	// the bundle-uri capability is never advertised over the protocol version 0
+	opts.DisableProtocolV2 = true
+	assert.NotContains(t, opts.ConfigArgs(), CmdArg("uploadpack.advertiseBundleURIs=true"))
+}
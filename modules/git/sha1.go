@@ -20,27 +20,19 @@ const EmptyTreeSHA = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
 // SHAFullLength is the full length of a git SHA
 const SHAFullLength = 40
 
-// SHAPattern can be used to determine if a string is an valid sha
-var shaPattern = regexp.MustCompile(`^[0-9a-f]{4,40}$`)
+// SHAPattern can be used to determine if a string is an valid sha,
+// the abbreviations are limited to the length of a SHA-1
+var shaPattern = regexp.MustCompile(`^[0-9a-f]{4,40}$|^[0-9a-f]{64}$`)
 
 // IsValidSHAPattern will check if the provided string matches the SHA Pattern
 func IsValidSHAPattern(sha string) bool {
 	return shaPattern.MatchString(sha)
 }
 
-// MustID always creates a new SHA1 from a [20]byte array with no validation of input.
-func MustID(b []byte) SHA1 {
-	var id SHA1
-	copy(id[:], b)
-	return id
-}
-
-// NewID creates a new SHA1 from a [20]byte array.
-func NewID(b []byte) (SHA1, error) {
-	if len(b) != 20 {
-		return SHA1{}, fmt.Errorf("Length must be 20: %v", b)
-	}
-	return MustID(b), nil
+// IsFullCommitID returns whether the provided string is a full length SHA-1 or SHA-256
+func IsFullCommitID(sha string) bool {
+	_, ok := ObjectFormatFromID(sha)
+	return ok && IsValidSHAPattern(sha)
 }
 
 // MustIDFromString always creates a new sha from a ID with no validation of input.
@@ -49,16 +41,15 @@ func MustIDFromString(s string) SHA1 {
 	return MustID(b)
 }
 
-// NewIDFromString creates a new SHA1 from a ID string of length 40.
+// NewIDFromString creates a new SHA1 from a ID string of length 40 or 64.
 func NewIDFromString(s string) (SHA1, error) {
-	var id SHA1
 	s = strings.TrimSpace(s)
-	if len(s) != SHAFullLength {
-		return id, fmt.Errorf("Length must be 40: %s", s)
+	if _, ok := ObjectFormatFromID(s); !ok {
+		return SHA1{}, fmt.Errorf("Length must be 40 or 64: %s", s)
 	}
 	b, err := hex.DecodeString(s)
 	if err != nil {
-		return id, err
+		return SHA1{}, err
 	}
 	return NewID(b)
 }
@@ -7,13 +7,33 @@
 package git
 
 import (
+	"fmt"
+
 	"github.com/go-git/go-git/v5/plumbing"
 )
 
+// sha256ObjectFormatReadable go-git only knows SHA-1 object ids
+const sha256ObjectFormatReadable = false
+
 // SHA1 a git commit name
 type SHA1 = plumbing.Hash
 
-// ComputeBlobHash compute the hash for a given blob content
-func ComputeBlobHash(content []byte) SHA1 {
+// MustID always creates a new SHA1 from a [20]byte array with no validation of input.
+func MustID(b []byte) SHA1 {
+	var id SHA1
+	copy(id[:], b)
+	return id
+}
+
+// NewID creates a new SHA1 from a [20]byte array.
+func NewID(b []byte) (SHA1, error) {
+	if len(b) != 20 {
+		return SHA1{}, fmt.Errorf("Length must be 20: %v", b)
+	}
+	return MustID(b), nil
+}
+
+// ComputeBlobHash compute the hash for a given blob content, the gogit builds only support the SHA-1 object format
+func ComputeBlobHash(objectFormat ObjectFormat, content []byte) SHA1 {
 	return plumbing.ComputeHash(plumbing.BlobObject, content)
 }
@@ -7,34 +7,68 @@
 package git
 
 import (
-	"crypto/sha1"
 	"encoding/hex"
+	"fmt"
 	"hash"
 	"strconv"
 )
 
-// SHA1 a git commit name
-type SHA1 [20]byte
+// sha256ObjectFormatReadable the object ids can be SHA-256 hashes
+const sha256ObjectFormatReadable = true
+
+// SHA1 a git object name, it is a SHA-256 in the repositories of the sha256 object format
+type SHA1 struct {
+	id   [SHA256FullLength / 2]byte
+	size int
+}
+
+// MustID always creates a new SHA1 from a 20 or 32 byte array with no validation of input.
+func MustID(b []byte) SHA1 {
+	var id SHA1
+	id.size = copy(id.id[:], b)
+	return id
+}
+
+// NewID creates a new SHA1 from a 20 or 32 byte array.
+func NewID(b []byte) (SHA1, error) {
+	if len(b) != SHAFullLength/2 && len(b) != SHA256FullLength/2 {
+		return SHA1{}, fmt.Errorf("Length must be 20 or 32: %v", b)
+	}
+	return MustID(b), nil
+}
 
 // String returns a string representation of the SHA
 func (s SHA1) String() string {
-	return hex.EncodeToString(s[:])
+	return hex.EncodeToString(s.id[:s.size])
 }
 
 // IsZero returns whether this SHA1 is all zeroes
 func (s SHA1) IsZero() bool {
-	var empty SHA1
-	return s == empty
+	var empty [SHA256FullLength / 2]byte
+	return s.id == empty
+}
+
+// RawValue returns the binary representation of the SHA
+func (s SHA1) RawValue() []byte {
+	return s.id[:s.size]
+}
+
+// ObjectFormat returns the object format of the SHA
+func (s SHA1) ObjectFormat() ObjectFormat {
+	if s.size == SHA256FullLength/2 {
+		return ObjectFormatSHA256
+	}
+	return ObjectFormatSHA1
 }
 
 // ComputeBlobHash compute the hash for a given blob content
-func ComputeBlobHash(content []byte) SHA1 {
-	return ComputeHash(ObjectBlob, content)
+func ComputeBlobHash(objectFormat ObjectFormat, content []byte) SHA1 {
+	return ComputeHash(objectFormat, ObjectBlob, content)
 }
 
 // ComputeHash compute the hash for a given ObjectType and content
-func ComputeHash(t ObjectType, content []byte) SHA1 {
-	h := NewHasher(t, int64(len(content)))
+func ComputeHash(objectFormat ObjectFormat, t ObjectType, content []byte) SHA1 {
+	h := NewHasher(objectFormat, t, int64(len(content)))
 	_, _ = h.Write(content)
 	return h.Sum()
 }
@@ -44,9 +78,9 @@ type Hasher struct {
 	hash.Hash
 }
 
-// NewHasher takes an object type and size and creates a hasher to generate a SHA
-func NewHasher(t ObjectType, size int64) Hasher {
-	h := Hasher{sha1.New()}
+// NewHasher takes an object format, an object type and size and creates a hasher to generate a SHA
+func NewHasher(objectFormat ObjectFormat, t ObjectType, size int64) Hasher {
+	h := Hasher{objectFormat.NewHash()}
 	_, _ = h.Write(t.Bytes())
 	_, _ = h.Write([]byte(" "))
 	_, _ = h.Write([]byte(strconv.FormatInt(size, 10)))
@@ -56,6 +90,5 @@ func NewHasher(t ObjectType, size int64) Hasher {
 
 // Sum generates a SHA1 for the provided hash
 func (h Hasher) Sum() (sha1 SHA1) {
-	copy(sha1[:], h.Hash.Sum(nil))
-	return sha1
+	return MustID(h.Hash.Sum(nil))
 }
@@ -13,6 +13,7 @@ func TestIsValidSHAPattern(t *testing.T) {
 	assert.True(t, IsValidSHAPattern("fee1"))
 	assert.True(t, IsValidSHAPattern("abc000"))
 	assert.True(t, IsValidSHAPattern("9023902390239023902390239023902390239023"))
+	assert.True(t, IsValidSHAPattern(EmptyTreeSHA256))
 	assert.False(t, IsValidSHAPattern("90239023902390239023902390239023902390239023"))
 	assert.False(t, IsValidSHAPattern("abc"))
 	assert.False(t, IsValidSHAPattern("123g"))
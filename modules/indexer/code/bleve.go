@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -28,10 +29,13 @@ import (
 	analyzer_custom "github.com/blevesearch/bleve/v2/analysis/analyzer/custom"
 	analyzer_keyword "github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
 	"github.com/blevesearch/bleve/v2/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/v2/analysis/token/ngram"
 	"github.com/blevesearch/bleve/v2/analysis/token/unicodenorm"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/single"
 	"github.com/blevesearch/bleve/v2/analysis/tokenizer/unicode"
 	"github.com/blevesearch/bleve/v2/index/upsidedown"
 	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search"
 	"github.com/blevesearch/bleve/v2/search/query"
 	"github.com/ethantkoenig/rupture"
 	"github.com/go-enry/go-enry/v2"
@@ -39,9 +43,33 @@ import (
 
 const (
 	unicodeNormalizeName = "unicodeNormalize"
+	trigramName          = "trigram"
 	maxBatchSize         = 16
 )
 
+// refQuery a query for the documents of the given ref
+func refQuery(ref string) *query.TermQuery {
+	q := bleve.NewTermQuery(documentRef(ref))
+	q.SetField("Ref")
+	return q
+}
+
+// filterQuery a query for the documents of the repositories and the ref of the options
+func filterQuery(opts *SearchOptions) query.Query {
+	if len(opts.RepoIDs) == 0 {
+		return refQuery(opts.Ref)
+	}
+
+	repoQueries := make([]query.Query, 0, len(opts.RepoIDs))
+	for _, repoID := range opts.RepoIDs {
+		repoQueries = append(repoQueries, numericEqualityQuery(repoID, "RepoID"))
+	}
+	return bleve.NewConjunctionQuery(
+		bleve.NewDisjunctionQuery(repoQueries...),
+		refQuery(opts.Ref),
+	)
+}
+
 // numericEqualityQuery a numeric equality query for the given value and field
 func numericEqualityQuery(value int64, field string) *query.NumericRangeQuery {
 	f := float64(value)
@@ -93,9 +121,11 @@ func openBleveIndexer(path string, latestVersion int) (bleve.Index, error) {
 // RepoIndexerData data stored in the repo indexer
 type RepoIndexerData struct {
 	RepoID    int64
+	Ref       string
 	CommitID  string
 	Content   string
 	Language  string
+	Symbols   []string
 	UpdatedAt time.Time
 }
 
@@ -105,9 +135,10 @@ func (d *RepoIndexerData) Type() string {
 }
 
 const (
-	repoIndexerAnalyzer      = "repoIndexerAnalyzer"
-	repoIndexerDocType       = "repoIndexerDocType"
-	repoIndexerLatestVersion = 5
+	repoIndexerAnalyzer        = "repoIndexerAnalyzer"
+	repoIndexerTrigramAnalyzer = "repoIndexerTrigramAnalyzer"
+	repoIndexerDocType         = "repoIndexerDocType"
+	repoIndexerLatestVersion   = 8
 )
 
 // createBleveIndexer create a bleve repo indexer if one does not already exist
@@ -119,13 +150,22 @@ func createBleveIndexer(path string, latestVersion int) (bleve.Index, error) {
 
 	textFieldMapping := bleve.NewTextFieldMapping()
 	textFieldMapping.IncludeInAll = false
-	docMapping.AddFieldMappingsAt("Content", textFieldMapping)
+	// the trigrams of the content are only used to find the candidates of a regular expression search
+	trigramFieldMapping := bleve.NewTextFieldMapping()
+	trigramFieldMapping.Name = "ContentTrigrams"
+	trigramFieldMapping.Analyzer = repoIndexerTrigramAnalyzer
+	trigramFieldMapping.Store = false
+	trigramFieldMapping.IncludeTermVectors = false
+	trigramFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("Content", textFieldMapping, trigramFieldMapping)
 
 	termFieldMapping := bleve.NewTextFieldMapping()
 	termFieldMapping.IncludeInAll = false
 	termFieldMapping.Analyzer = analyzer_keyword.Name
 	docMapping.AddFieldMappingsAt("Language", termFieldMapping)
 	docMapping.AddFieldMappingsAt("CommitID", termFieldMapping)
+	docMapping.AddFieldMappingsAt("Ref", termFieldMapping)
+	docMapping.AddFieldMappingsAt("Symbols", termFieldMapping)
 
 	timeFieldMapping := bleve.NewDateTimeFieldMapping()
 	timeFieldMapping.IncludeInAll = false
@@ -141,6 +181,19 @@ func createBleveIndexer(path string, latestVersion int) (bleve.Index, error) {
 		"token_filters": []string{unicodeNormalizeName, lowercase.Name},
 	}); err != nil {
 		return nil, err
+	} else if err := mapping.AddCustomTokenFilter(trigramName, map[string]interface{}{
+		"type": ngram.Name,
+		"min":  3.0,
+		"max":  3.0,
+	}); err != nil {
+		return nil, err
+	} else if err := mapping.AddCustomAnalyzer(repoIndexerTrigramAnalyzer, map[string]interface{}{
+		"type":          analyzer_custom.Name,
+		"char_filters":  []string{},
+		"tokenizer":     single.Name,
+		"token_filters": []string{lowercase.Name, trigramName},
+	}); err != nil {
+		return nil, err
 	}
 	mapping.DefaultAnalyzer = repoIndexerAnalyzer
 	mapping.AddDocumentMapping(repoIndexerDocType, docMapping)
@@ -180,7 +233,7 @@ func NewBleveIndexer(indexDir string) (*BleveIndexer, bool, error) {
 	return indexer, created, err
 }
 
-func (b *BleveIndexer) addUpdate(ctx context.Context, batchWriter git.WriteCloserError, batchReader *bufio.Reader, commitSha string,
+func (b *BleveIndexer) addUpdate(ctx context.Context, batchWriter git.WriteCloserError, batchReader *bufio.Reader, ref, commitSha string,
 	update fileUpdate, repo *repo_model.Repository, batch *gitea_bleve.FlushingBatch,
 ) error {
 	// Ignore vendored files in code search
@@ -203,7 +256,7 @@ func (b *BleveIndexer) addUpdate(ctx context.Context, batchWriter git.WriteClose
 	}
 
 	if size > setting.Indexer.MaxIndexerFileSize {
-		return b.addDelete(update.Filename, repo, batch)
+		return b.addDelete(ref, update.Filename, repo, batch)
 	}
 
 	if _, err := batchWriter.Write([]byte(update.BlobSha + "\n")); err != nil {
@@ -226,18 +279,22 @@ func (b *BleveIndexer) addUpdate(ctx context.Context, batchWriter git.WriteClose
 	if _, err = batchReader.Discard(1); err != nil {
 		return err
 	}
-	id := filenameIndexerID(repo.ID, update.Filename)
+	id := filenameIndexerID(repo.ID, ref, update.Filename)
+	content := string(charset.ToUTF8DropErrors(fileContents))
+	language := analyze.GetCodeLanguage(update.Filename, fileContents)
 	return batch.Index(id, &RepoIndexerData{
 		RepoID:    repo.ID,
+		Ref:       documentRef(ref),
 		CommitID:  commitSha,
-		Content:   string(charset.ToUTF8DropErrors(fileContents)),
-		Language:  analyze.GetCodeLanguage(update.Filename, fileContents),
+		Content:   content,
+		Language:  language,
+		Symbols:   symbolNames(language, content),
 		UpdatedAt: time.Now().UTC(),
 	})
 }
 
-func (b *BleveIndexer) addDelete(filename string, repo *repo_model.Repository, batch *gitea_bleve.FlushingBatch) error {
-	id := filenameIndexerID(repo.ID, filename)
+func (b *BleveIndexer) addDelete(ref, filename string, repo *repo_model.Repository, batch *gitea_bleve.FlushingBatch) error {
+	id := filenameIndexerID(repo.ID, ref, filename)
 	return batch.Delete(id)
 }
 
@@ -282,7 +339,7 @@ func (b *BleveIndexer) Ping() bool {
 }
 
 // Index indexes the data
-func (b *BleveIndexer) Index(ctx context.Context, repo *repo_model.Repository, sha string, changes *repoChanges) error {
+func (b *BleveIndexer) Index(ctx context.Context, repo *repo_model.Repository, ref, sha string, changes *repoChanges) error {
 	batch := gitea_bleve.NewFlushingBatch(b.indexer, maxBatchSize)
 	if len(changes.Updates) > 0 {
 
@@ -296,14 +353,14 @@ func (b *BleveIndexer) Index(ctx context.Context, repo *repo_model.Repository, s
 		defer cancel()
 
 		for _, update := range changes.Updates {
-			if err := b.addUpdate(ctx, batchWriter, batchReader, sha, update, repo, batch); err != nil {
+			if err := b.addUpdate(ctx, batchWriter, batchReader, ref, sha, update, repo, batch); err != nil {
 				return err
 			}
 		}
 		cancel()
 	}
 	for _, filename := range changes.RemovedFilenames {
-		if err := b.addDelete(filename, repo, batch); err != nil {
+		if err := b.addDelete(ref, filename, repo, batch); err != nil {
 			return err
 		}
 	}
@@ -312,7 +369,18 @@ func (b *BleveIndexer) Index(ctx context.Context, repo *repo_model.Repository, s
 
 // Delete deletes indexes by ids
 func (b *BleveIndexer) Delete(repoID int64) error {
-	query := numericEqualityQuery(repoID, "RepoID")
+	return b.deleteByQuery(numericEqualityQuery(repoID, "RepoID"))
+}
+
+// DeleteRef deletes the indexes of a ref of a repository
+func (b *BleveIndexer) DeleteRef(repoID int64, ref string) error {
+	return b.deleteByQuery(bleve.NewConjunctionQuery(
+		numericEqualityQuery(repoID, "RepoID"),
+		refQuery(ref),
+	))
+}
+
+func (b *BleveIndexer) deleteByQuery(query query.Query) error {
 	searchRequest := bleve.NewSearchRequestOptions(query, 2147483647, 0, false)
 	result, err := b.indexer.Search(searchRequest)
 	if err != nil {
@@ -329,39 +397,32 @@ func (b *BleveIndexer) Delete(repoID int64) error {
 
 // Search searches for files in the specified repo.
 // Returns the matching file-paths
-func (b *BleveIndexer) Search(ctx context.Context, repoIDs []int64, language, keyword string, page, pageSize int, isMatch bool) (int64, []*SearchResult, []*SearchResultLanguages, error) {
+func (b *BleveIndexer) Search(ctx context.Context, opts *SearchOptions) (int64, []*SearchResult, []*SearchResultLanguages, error) {
+	if opts.IsRegexp {
+		return b.searchRegexp(ctx, opts)
+	}
+
 	var (
 		indexerQuery query.Query
 		keywordQuery query.Query
 	)
 
-	if isMatch {
-		prefixQuery := bleve.NewPrefixQuery(keyword)
+	if opts.IsMatch {
+		prefixQuery := bleve.NewPrefixQuery(opts.Keyword)
 		prefixQuery.FieldVal = "Content"
 		keywordQuery = prefixQuery
 	} else {
-		phraseQuery := bleve.NewMatchPhraseQuery(keyword)
+		phraseQuery := bleve.NewMatchPhraseQuery(opts.Keyword)
 		phraseQuery.FieldVal = "Content"
 		phraseQuery.Analyzer = repoIndexerAnalyzer
 		keywordQuery = phraseQuery
 	}
 
-	if len(repoIDs) > 0 {
-		repoQueries := make([]query.Query, 0, len(repoIDs))
-		for _, repoID := range repoIDs {
-			repoQueries = append(repoQueries, numericEqualityQuery(repoID, "RepoID"))
-		}
-
-		indexerQuery = bleve.NewConjunctionQuery(
-			bleve.NewDisjunctionQuery(repoQueries...),
-			keywordQuery,
-		)
-	} else {
-		indexerQuery = keywordQuery
-	}
+	indexerQuery = bleve.NewConjunctionQuery(filterQuery(opts), keywordQuery)
 
 	// Save for reuse without language filter
 	facetQuery := indexerQuery
+	language := opts.Language
 	if len(language) > 0 {
 		languageQuery := bleve.NewMatchQuery(language)
 		languageQuery.FieldVal = "Language"
@@ -373,8 +434,8 @@ func (b *BleveIndexer) Search(ctx context.Context, repoIDs []int64, language, ke
 		)
 	}
 
-	from := (page - 1) * pageSize
-	searchRequest := bleve.NewSearchRequestOptions(indexerQuery, pageSize, from, false)
+	from := (opts.Page - 1) * opts.PageSize
+	searchRequest := bleve.NewSearchRequestOptions(indexerQuery, opts.PageSize, from, false)
 	searchRequest.Fields = []string{"Content", "RepoID", "Language", "CommitID", "UpdatedAt"}
 	searchRequest.IncludeLocations = true
 
@@ -392,6 +453,7 @@ func (b *BleveIndexer) Search(ctx context.Context, repoIDs []int64, language, ke
 	searchResults := make([]*SearchResult, len(result.Hits))
 	for i, hit := range result.Hits {
 		startIndex, endIndex := -1, -1
+		locs := make([][]int, 0, len(hit.Locations["Content"]))
 		for _, locations := range hit.Locations["Content"] {
 			location := locations[0]
 			locationStart := int(location.Start)
@@ -402,23 +464,17 @@ func (b *BleveIndexer) Search(ctx context.Context, repoIDs []int64, language, ke
 			if endIndex < 0 || locationEnd > endIndex {
 				endIndex = locationEnd
 			}
+			for _, location := range locations {
+				locs = append(locs, []int{int(location.Start), int(location.End)})
+			}
 		}
-		language := hit.Fields["Language"].(string)
-		var updatedUnix timeutil.TimeStamp
-		if t, err := time.Parse(time.RFC3339, hit.Fields["UpdatedAt"].(string)); err == nil {
-			updatedUnix = timeutil.TimeStamp(t.Unix())
-		}
-		searchResults[i] = &SearchResult{
-			RepoID:      int64(hit.Fields["RepoID"].(float64)),
-			StartIndex:  startIndex,
-			EndIndex:    endIndex,
-			Filename:    filenameOfIndexerID(hit.ID),
-			Content:     hit.Fields["Content"].(string),
-			CommitID:    hit.Fields["CommitID"].(string),
-			UpdatedUnix: updatedUnix,
-			Language:    language,
-			Color:       enry.GetColor(language),
-		}
+		sort.Slice(locs, func(i, j int) bool {
+			return locs[i][0] < locs[j][0]
+		})
+		searchResults[i] = convertBleveHit(hit)
+		searchResults[i].StartIndex = startIndex
+		searchResults[i].EndIndex = endIndex
+		searchResults[i].Matches = matchSpans(searchResults[i].Content, locs)
 	}
 
 	searchResultLanguages := make([]*SearchResultLanguages, 0, 10)
@@ -447,3 +503,104 @@ func (b *BleveIndexer) Search(ctx context.Context, repoIDs []int64, language, ke
 	}
 	return total, searchResults, searchResultLanguages, nil
 }
+
+// searchRegexp finds the candidates of a regular expression search by the trigrams of its literals,
+// the candidates are matched against the expression and the matching files are paginated
+func (b *BleveIndexer) searchRegexp(ctx context.Context, opts *SearchOptions) (int64, []*SearchResult, []*SearchResultLanguages, error) {
+	re, err := CompileRegexp(opts.Keyword)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	queries := []query.Query{filterQuery(opts)}
+	for _, trigram := range regexpTrigrams(re) {
+		trigramQuery := bleve.NewTermQuery(trigram)
+		trigramQuery.SetField("ContentTrigrams")
+		queries = append(queries, trigramQuery)
+	}
+
+	searchRequest := bleve.NewSearchRequestOptions(bleve.NewConjunctionQuery(queries...), maxRegexpCandidates, 0, false)
+	searchRequest.Fields = []string{"Content", "RepoID", "Language", "CommitID", "UpdatedAt"}
+	searchRequest.SortBy([]string{"_id"})
+
+	result, err := b.indexer.SearchInContext(ctx, searchRequest)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	matches := make([]*SearchResult, 0, opts.PageSize)
+	languageCounts := make(map[string]int)
+	for _, hit := range result.Hits {
+		searchResult := convertBleveHit(hit)
+		if !setRegexpMatches(searchResult, re) {
+			continue
+		}
+		if len(searchResult.Language) > 0 {
+			languageCounts[searchResult.Language]++
+		}
+		if len(opts.Language) == 0 || searchResult.Language == opts.Language {
+			matches = append(matches, searchResult)
+		}
+	}
+
+	searchResultLanguages := make([]*SearchResultLanguages, 0, len(languageCounts))
+	for language, count := range languageCounts {
+		searchResultLanguages = append(searchResultLanguages, &SearchResultLanguages{
+			Language: language,
+			Color:    enry.GetColor(language),
+			Count:    count,
+		})
+	}
+	sort.Slice(searchResultLanguages, func(i, j int) bool {
+		if searchResultLanguages[i].Count != searchResultLanguages[j].Count {
+			return searchResultLanguages[i].Count > searchResultLanguages[j].Count
+		}
+		return searchResultLanguages[i].Language < searchResultLanguages[j].Language
+	})
+	if len(searchResultLanguages) > 10 {
+		searchResultLanguages = searchResultLanguages[:10]
+	}
+
+	start := util.Min(util.Max(opts.Page-1, 0)*opts.PageSize, len(matches))
+	end := util.Min(start+opts.PageSize, len(matches))
+	return int64(len(matches)), matches[start:end], searchResultLanguages, nil
+}
+
+func convertBleveHit(hit *search.DocumentMatch) *SearchResult {
+	language := hit.Fields["Language"].(string)
+	var updatedUnix timeutil.TimeStamp
+	if t, err := time.Parse(time.RFC3339, hit.Fields["UpdatedAt"].(string)); err == nil {
+		updatedUnix = timeutil.TimeStamp(t.Unix())
+	}
+	return &SearchResult{
+		RepoID:      int64(hit.Fields["RepoID"].(float64)),
+		Filename:    filenameOfIndexerID(hit.ID),
+		Content:     hit.Fields["Content"].(string),
+		CommitID:    hit.Fields["CommitID"].(string),
+		UpdatedUnix: updatedUnix,
+		Language:    language,
+		Color:       enry.GetColor(language),
+	}
+}
+
+// SearchSymbol searches for the files which define the symbol opts.Keyword
+func (b *BleveIndexer) SearchSymbol(ctx context.Context, opts *SearchOptions) (int64, []*SearchResult, error) {
+	symbolQuery := bleve.NewTermQuery(opts.Keyword)
+	symbolQuery.SetField("Symbols")
+
+	from := (opts.Page - 1) * opts.PageSize
+	searchRequest := bleve.NewSearchRequestOptions(bleve.NewConjunctionQuery(filterQuery(opts), symbolQuery), opts.PageSize, from, false)
+	searchRequest.Fields = []string{"Content", "RepoID", "Language", "CommitID", "UpdatedAt"}
+	searchRequest.SortBy([]string{"_id"})
+
+	result, err := b.indexer.SearchInContext(ctx, searchRequest)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	searchResults := make([]*SearchResult, len(result.Hits))
+	for i, hit := range result.Hits {
+		searchResults[i] = convertBleveHit(hit)
+	}
+	return int64(result.Total), searchResults, nil
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -31,7 +32,7 @@ import (
 )
 
 const (
-	esRepoIndexerLatestVersion = 1
+	esRepoIndexerLatestVersion = 4
 	// multi-match-types, currently only 2 types are used
 	// Reference: https://www.elastic.co/guide/en/elasticsearch/reference/7.0/query-dsl-multi-match-query.html#multi-match-types
 	esMultiMatchTypeBestFields   = "best_fields"
@@ -121,6 +122,15 @@ const (
 				"content": {
 					"type": "text",
 					"term_vector": "with_positions_offsets",
+					"index": true,
+					"fields": {
+						"wildcard": {
+							"type": "wildcard"
+						}
+					}
+				},
+				"ref": {
+					"type": "keyword",
 					"index": true
 				},
 				"commit_id": {
@@ -131,6 +141,10 @@ const (
 					"type": "keyword",
 					"index": true
 				},
+				"symbols": {
+					"type": "keyword",
+					"index": true
+				},
 				"updated_at": {
 					"type": "long",
 					"index": true
@@ -212,7 +226,7 @@ func (b *ElasticSearchIndexer) Ping() bool {
 	return b.available
 }
 
-func (b *ElasticSearchIndexer) addUpdate(ctx context.Context, batchWriter git.WriteCloserError, batchReader *bufio.Reader, sha string, update fileUpdate, repo *repo_model.Repository) ([]elastic.BulkableRequest, error) {
+func (b *ElasticSearchIndexer) addUpdate(ctx context.Context, batchWriter git.WriteCloserError, batchReader *bufio.Reader, ref, sha string, update fileUpdate, repo *repo_model.Repository) ([]elastic.BulkableRequest, error) {
 	// Ignore vendored files in code search
 	if setting.Indexer.ExcludeVendored && analyze.IsVendor(update.Filename) {
 		return nil, nil
@@ -232,7 +246,7 @@ func (b *ElasticSearchIndexer) addUpdate(ctx context.Context, batchWriter git.Wr
 	}
 
 	if size > setting.Indexer.MaxIndexerFileSize {
-		return []elastic.BulkableRequest{b.addDelete(update.Filename, repo)}, nil
+		return []elastic.BulkableRequest{b.addDelete(ref, update.Filename, repo)}, nil
 	}
 
 	if _, err := batchWriter.Write([]byte(update.BlobSha + "\n")); err != nil {
@@ -255,7 +269,9 @@ func (b *ElasticSearchIndexer) addUpdate(ctx context.Context, batchWriter git.Wr
 	if _, err = batchReader.Discard(1); err != nil {
 		return nil, err
 	}
-	id := filenameIndexerID(repo.ID, update.Filename)
+	id := filenameIndexerID(repo.ID, ref, update.Filename)
+	content := string(charset.ToUTF8DropErrors(fileContents))
+	language := analyze.GetCodeLanguage(update.Filename, fileContents)
 
 	return []elastic.BulkableRequest{
 		elastic.NewBulkIndexRequest().
@@ -263,23 +279,25 @@ func (b *ElasticSearchIndexer) addUpdate(ctx context.Context, batchWriter git.Wr
 			Id(id).
 			Doc(map[string]interface{}{
 				"repo_id":    repo.ID,
-				"content":    string(charset.ToUTF8DropErrors(fileContents)),
+				"ref":        documentRef(ref),
+				"content":    content,
 				"commit_id":  sha,
-				"language":   analyze.GetCodeLanguage(update.Filename, fileContents),
+				"language":   language,
+				"symbols":    symbolNames(language, content),
 				"updated_at": timeutil.TimeStampNow(),
 			}),
 	}, nil
 }
 
-func (b *ElasticSearchIndexer) addDelete(filename string, repo *repo_model.Repository) elastic.BulkableRequest {
-	id := filenameIndexerID(repo.ID, filename)
+func (b *ElasticSearchIndexer) addDelete(ref, filename string, repo *repo_model.Repository) elastic.BulkableRequest {
+	id := filenameIndexerID(repo.ID, ref, filename)
 	return elastic.NewBulkDeleteRequest().
 		Index(b.indexerAliasName).
 		Id(id)
 }
 
 // Index will save the index data
-func (b *ElasticSearchIndexer) Index(ctx context.Context, repo *repo_model.Repository, sha string, changes *repoChanges) error {
+func (b *ElasticSearchIndexer) Index(ctx context.Context, repo *repo_model.Repository, ref, sha string, changes *repoChanges) error {
 	reqs := make([]elastic.BulkableRequest, 0)
 	if len(changes.Updates) > 0 {
 		// Now because of some insanity with git cat-file not immediately failing if not run in a valid git directory we need to run git rev-parse first!
@@ -292,7 +310,7 @@ func (b *ElasticSearchIndexer) Index(ctx context.Context, repo *repo_model.Repos
 		defer cancel()
 
 		for _, update := range changes.Updates {
-			updateReqs, err := b.addUpdate(ctx, batchWriter, batchReader, sha, update, repo)
+			updateReqs, err := b.addUpdate(ctx, batchWriter, batchReader, ref, sha, update, repo)
 			if err != nil {
 				return err
 			}
@@ -304,7 +322,7 @@ func (b *ElasticSearchIndexer) Index(ctx context.Context, repo *repo_model.Repos
 	}
 
 	for _, filename := range changes.RemovedFilenames {
-		reqs = append(reqs, b.addDelete(filename, repo))
+		reqs = append(reqs, b.addDelete(ref, filename, repo))
 	}
 
 	if len(reqs) > 0 {
@@ -325,6 +343,17 @@ func (b *ElasticSearchIndexer) Delete(repoID int64) error {
 	return b.checkError(err)
 }
 
+// DeleteRef deletes the indexes of a ref of a repository
+func (b *ElasticSearchIndexer) DeleteRef(repoID int64, ref string) error {
+	_, err := b.client.DeleteByQuery(b.indexerAliasName).
+		Query(elastic.NewBoolQuery().Filter(
+			elastic.NewTermQuery("repo_id", repoID),
+			elastic.NewTermQuery("ref", documentRef(ref)),
+		)).
+		Do(graceful.GetManager().HammerContext())
+	return b.checkError(err)
+}
+
 // indexPos find words positions for start and the following end on content. It will
 // return the beginning position of the first start and the ending position of the
 // first end following the start string.
@@ -341,9 +370,20 @@ func indexPos(content, start, end string) (int, int) {
 	return startIdx, startIdx + len(start) + endIdx + len(end)
 }
 
-func convertResult(searchResult *elastic.SearchResult, kw string, pageSize int) (int64, []*SearchResult, []*SearchResultLanguages, error) {
+func convertResult(searchResult *elastic.SearchResult, kw string, re *regexp.Regexp, pageSize int) (int64, []*SearchResult, []*SearchResultLanguages, error) {
 	hits := make([]*SearchResult, 0, pageSize)
 	for _, hit := range searchResult.Hits.Hits {
+		if re != nil {
+			result, err := convertHit(hit)
+			if err != nil {
+				return 0, nil, nil, err
+			}
+			// the lucene expression may match more than the expression, such a file is shown from the beginning
+			setRegexpMatches(result, re)
+			hits = append(hits, result)
+			continue
+		}
+
 		// FIXME: There is no way to get the position the keyword on the content currently on the same request.
 		// So we get it from content, this may made the query slower. See
 		// https://discuss.elastic.co/t/fetching-position-of-keyword-in-matched-document/94291
@@ -402,19 +442,52 @@ func extractAggs(searchResult *elastic.SearchResult) []*SearchResultLanguages {
 	return searchResultLanguages
 }
 
+func convertHit(hit *elastic.SearchHit) (*SearchResult, error) {
+	repoID, fileName := parseIndexerID(hit.Id)
+	res := make(map[string]interface{})
+	if err := json.Unmarshal(hit.Source, &res); err != nil {
+		return nil, err
+	}
+
+	language := res["language"].(string)
+	return &SearchResult{
+		RepoID:      repoID,
+		Filename:    fileName,
+		CommitID:    res["commit_id"].(string),
+		Content:     res["content"].(string),
+		UpdatedUnix: timeutil.TimeStamp(res["updated_at"].(float64)),
+		Language:    language,
+		Color:       enry.GetColor(language),
+	}, nil
+}
+
 // Search searches for codes and language stats by given conditions.
-func (b *ElasticSearchIndexer) Search(ctx context.Context, repoIDs []int64, language, keyword string, page, pageSize int, isMatch bool) (int64, []*SearchResult, []*SearchResultLanguages, error) {
-	searchType := esMultiMatchTypeBestFields
-	if isMatch {
-		searchType = esMultiMatchTypePhrasePrefix
+// A regular expression is searched by a regexp query of the wildcard field of the content.
+func (b *ElasticSearchIndexer) Search(ctx context.Context, opts *SearchOptions) (int64, []*SearchResult, []*SearchResultLanguages, error) {
+	var (
+		kwQuery elastic.Query
+		re      *regexp.Regexp
+	)
+	if opts.IsRegexp {
+		var err error
+		if re, err = CompileRegexp(opts.Keyword); err != nil {
+			return 0, nil, nil, err
+		}
+		kwQuery = elastic.NewRegexpQuery("content.wildcard", luceneRegexp(re))
+	} else {
+		searchType := esMultiMatchTypeBestFields
+		if opts.IsMatch {
+			searchType = esMultiMatchTypePhrasePrefix
+		}
+		kwQuery = elastic.NewMultiMatchQuery(opts.Keyword, "content").Type(searchType)
 	}
 
-	kwQuery := elastic.NewMultiMatchQuery(keyword, "content").Type(searchType)
 	query := elastic.NewBoolQuery()
 	query = query.Must(kwQuery)
-	if len(repoIDs) > 0 {
-		repoStrs := make([]interface{}, 0, len(repoIDs))
-		for _, repoID := range repoIDs {
+	query = query.Filter(elastic.NewTermQuery("ref", documentRef(opts.Ref)))
+	if len(opts.RepoIDs) > 0 {
+		repoStrs := make([]interface{}, 0, len(opts.RepoIDs))
+		for _, repoID := range opts.RepoIDs {
 			repoStrs = append(repoStrs, repoID)
 		}
 		repoQuery := elastic.NewTermsQuery("repo_id", repoStrs...)
@@ -423,36 +496,39 @@ func (b *ElasticSearchIndexer) Search(ctx context.Context, repoIDs []int64, lang
 
 	var (
 		start       int
-		kw          = "<em>" + keyword + "</em>"
+		kw          = "<em>" + opts.Keyword + "</em>"
 		aggregation = elastic.NewTermsAggregation().Field("language").Size(10).OrderByCountDesc()
 	)
 
-	if page > 0 {
-		start = (page - 1) * pageSize
+	if opts.Page > 0 {
+		start = (opts.Page - 1) * opts.PageSize
+	}
+
+	var highlight *elastic.Highlight
+	if re == nil {
+		highlight = elastic.NewHighlight().
+			Field("content").
+			NumOfFragments(0). // return all highting content on fragments
+			HighlighterType("fvh")
 	}
 
-	if len(language) == 0 {
+	if len(opts.Language) == 0 {
 		searchResult, err := b.client.Search().
 			Index(b.indexerAliasName).
 			Aggregation("language", aggregation).
 			Query(query).
-			Highlight(
-				elastic.NewHighlight().
-					Field("content").
-					NumOfFragments(0). // return all highting content on fragments
-					HighlighterType("fvh"),
-			).
+			Highlight(highlight).
 			Sort("repo_id", true).
-			From(start).Size(pageSize).
+			From(start).Size(opts.PageSize).
 			Do(ctx)
 		if err != nil {
 			return 0, nil, nil, b.checkError(err)
 		}
 
-		return convertResult(searchResult, kw, pageSize)
+		return convertResult(searchResult, kw, re, opts.PageSize)
 	}
 
-	langQuery := elastic.NewMatchQuery("language", language)
+	langQuery := elastic.NewMatchQuery("language", opts.Language)
 	countResult, err := b.client.Search().
 		Index(b.indexerAliasName).
 		Aggregation("language", aggregation).
@@ -467,24 +543,58 @@ func (b *ElasticSearchIndexer) Search(ctx context.Context, repoIDs []int64, lang
 	searchResult, err := b.client.Search().
 		Index(b.indexerAliasName).
 		Query(query).
-		Highlight(
-			elastic.NewHighlight().
-				Field("content").
-				NumOfFragments(0). // return all highting content on fragments
-				HighlighterType("fvh"),
-		).
+		Highlight(highlight).
 		Sort("repo_id", true).
-		From(start).Size(pageSize).
+		From(start).Size(opts.PageSize).
 		Do(ctx)
 	if err != nil {
 		return 0, nil, nil, b.checkError(err)
 	}
 
-	total, hits, _, err := convertResult(searchResult, kw, pageSize)
+	total, hits, _, err := convertResult(searchResult, kw, re, opts.PageSize)
 
 	return total, hits, extractAggs(countResult), err
 }
 
+// SearchSymbol searches for the files which define the symbol opts.Keyword
+func (b *ElasticSearchIndexer) SearchSymbol(ctx context.Context, opts *SearchOptions) (int64, []*SearchResult, error) {
+	query := elastic.NewBoolQuery().Filter(
+		elastic.NewTermQuery("symbols", opts.Keyword),
+		elastic.NewTermQuery("ref", documentRef(opts.Ref)),
+	)
+	if len(opts.RepoIDs) > 0 {
+		repoIDs := make([]interface{}, 0, len(opts.RepoIDs))
+		for _, repoID := range opts.RepoIDs {
+			repoIDs = append(repoIDs, repoID)
+		}
+		query = query.Filter(elastic.NewTermsQuery("repo_id", repoIDs...))
+	}
+
+	var start int
+	if opts.Page > 0 {
+		start = (opts.Page - 1) * opts.PageSize
+	}
+	searchResult, err := b.client.Search().
+		Index(b.indexerAliasName).
+		Query(query).
+		Sort("repo_id", true).
+		From(start).Size(opts.PageSize).
+		Do(ctx)
+	if err != nil {
+		return 0, nil, b.checkError(err)
+	}
+
+	hits := make([]*SearchResult, 0, len(searchResult.Hits.Hits))
+	for _, hit := range searchResult.Hits.Hits {
+		result, err := convertHit(hit)
+		if err != nil {
+			return 0, nil, err
+		}
+		hits = append(hits, result)
+	}
+	return searchResult.TotalHits(), hits, nil
+}
+
 // Close implements indexer
 func (b *ElasticSearchIndexer) Close() {
 	select {
@@ -12,6 +12,8 @@ import (
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/gobwas/glob"
 )
 
 type fileUpdate struct {
@@ -45,7 +47,96 @@ func getRepoChanges(ctx context.Context, repo *repo_model.Repository, revision s
 	if len(status.CommitSha) == 0 {
 		return genesisChanges(ctx, repo, revision)
 	}
-	return nonGenesisChanges(ctx, repo, revision)
+	return nonGenesisChanges(ctx, repo, "", status.CommitSha, revision)
+}
+
+// indexedRef is an additionally indexed branch or tag
+type indexedRef struct {
+	Name     string
+	CommitID string
+}
+
+// getIndexedRefs returns the branches and tags which match the code indexer settings of the repository.
+// At most setting.Indexer.MaxIndexerRefs refs are returned, the most recently created commits and tags win.
+func getIndexedRefs(ctx context.Context, repo *repo_model.Repository) ([]indexedRef, error) {
+	if setting.Indexer.MaxIndexerRefs <= 0 || (len(refPatterns(repo.CodeIndexerBranches)) == 0 && len(refPatterns(repo.CodeIndexerTags)) == 0) {
+		return nil, nil
+	}
+
+	stdout, _, err := git.NewCommand(ctx, "for-each-ref", "--sort=-creatordate", "--format=%(refname) %(objectname) %(*objectname)").
+		AddDynamicArguments(git.BranchPrefix, git.TagPrefix).
+		RunStdString(&git.RunOpts{Dir: repo.RepoPath()})
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]indexedRef, 0, setting.Indexer.MaxIndexerRefs)
+	for _, line := range strings.Split(stdout, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !IsIndexedRef(repo, fields[0]) {
+			continue
+		}
+		// annotated tags are peeled to the tagged commit
+		refs = append(refs, indexedRef{Name: fields[0], CommitID: fields[len(fields)-1]})
+		if len(refs) == setting.Indexer.MaxIndexerRefs {
+			break
+		}
+	}
+	return refs, nil
+}
+
+// IsIndexedRef returns true if the branch or tag is indexed in addition to the default branch
+// because it matches the code indexer settings of the repository
+func IsIndexedRef(repo *repo_model.Repository, refFullName string) bool {
+	switch {
+	case strings.HasPrefix(refFullName, git.BranchPrefix):
+		branch := strings.TrimPrefix(refFullName, git.BranchPrefix)
+		return branch != repo.DefaultBranch && matchRefPatterns(refPatterns(repo.CodeIndexerBranches), branch)
+	case strings.HasPrefix(refFullName, git.TagPrefix):
+		return matchRefPatterns(refPatterns(repo.CodeIndexerTags), strings.TrimPrefix(refFullName, git.TagPrefix))
+	}
+	return false
+}
+
+// ValidateRefPatterns checks a comma separated list of glob patterns for branch or tag names
+func ValidateRefPatterns(patterns string) error {
+	for _, expr := range strings.Split(patterns, ",") {
+		expr = strings.TrimSpace(expr)
+		if expr == "" {
+			continue
+		}
+		if _, err := glob.Compile(expr, '/'); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refPatterns parses a comma separated list of glob patterns for branch or tag names
+func refPatterns(patterns string) []glob.Glob {
+	globs := make([]glob.Glob, 0, 2)
+	for _, expr := range strings.Split(patterns, ",") {
+		expr = strings.TrimSpace(expr)
+		if expr == "" {
+			continue
+		}
+		g, err := glob.Compile(expr, '/')
+		if err != nil {
+			log.Warn("Invalid ref glob expression '%s' (skipped): %v", expr, err)
+			continue
+		}
+		globs = append(globs, g)
+	}
+	return globs
+}
+
+func matchRefPatterns(globs []glob.Glob, name string) bool {
+	for _, g := range globs {
+		if g.Match(name) {
+			return true
+		}
+	}
+	return false
 }
 
 func isIndexable(entry *git.TreeEntry) bool {
@@ -101,15 +192,15 @@ func genesisChanges(ctx context.Context, repo *repo_model.Repository, revision s
 	return &changes, err
 }
 
-// nonGenesisChanges get changes since the previous indexer update
-func nonGenesisChanges(ctx context.Context, repo *repo_model.Repository, revision string) (*repoChanges, error) {
-	diffCmd := git.NewCommand(ctx, "diff", "--name-status").AddDynamicArguments(repo.CodeIndexerStatus.CommitSha, revision)
+// nonGenesisChanges get changes of a ref since the previous indexer update
+func nonGenesisChanges(ctx context.Context, repo *repo_model.Repository, ref, previousRevision, revision string) (*repoChanges, error) {
+	diffCmd := git.NewCommand(ctx, "diff", "--name-status").AddDynamicArguments(previousRevision, revision)
 	stdout, _, runErr := diffCmd.RunStdString(&git.RunOpts{Dir: repo.RepoPath()})
 	if runErr != nil {
 		// previous commit sha may have been removed by a force push, so
 		// try rebuilding from scratch
 		log.Warn("git diff: %v", runErr)
-		if err := indexer.Delete(repo.ID); err != nil {
+		if err := indexer.DeleteRef(repo.ID, ref); err != nil {
 			return nil, err
 		}
 		return genesisChanges(ctx, repo, revision)
@@ -5,6 +5,7 @@ package code
 
 import (
 	"context"
+	"encoding/hex"
 	"os"
 	"runtime/pprof"
 	"strconv"
@@ -32,6 +33,7 @@ type SearchResult struct {
 	UpdatedUnix timeutil.TimeStamp
 	Language    string
 	Color       string
+	Matches     []MatchSpan // the positions of the matches in the content, at most maxMatchSpansPerFile
 }
 
 // SearchResultLanguages result of top languages count in search results
@@ -41,31 +43,70 @@ type SearchResultLanguages struct {
 	Count    int
 }
 
+// SearchOptions represents the options of a code search
+type SearchOptions struct {
+	RepoIDs  []int64
+	Ref      string // full name of an additionally indexed branch or tag, empty for the default branch
+	Keyword  string
+	Language string
+	IsMatch  bool
+	IsRegexp bool // the keyword is a regular expression, it takes precedence over IsMatch
+	Page     int
+	PageSize int
+}
+
 // Indexer defines an interface to index and search code contents
 type Indexer interface {
 	Ping() bool
 	SetAvailabilityChangeCallback(callback func(bool))
-	Index(ctx context.Context, repo *repo_model.Repository, sha string, changes *repoChanges) error
+	// Index indexes the changes of a ref, the ref is empty for the default branch
+	Index(ctx context.Context, repo *repo_model.Repository, ref, sha string, changes *repoChanges) error
+	// Delete deletes the documents of all refs of a repository
 	Delete(repoID int64) error
-	Search(ctx context.Context, repoIDs []int64, language, keyword string, page, pageSize int, isMatch bool) (int64, []*SearchResult, []*SearchResultLanguages, error)
+	// DeleteRef deletes the documents of a ref of a repository, the ref is empty for the default branch
+	DeleteRef(repoID int64, ref string) error
+	Search(ctx context.Context, opts *SearchOptions) (int64, []*SearchResult, []*SearchResultLanguages, error)
+	// SearchSymbol searches for the files which define the symbol opts.Keyword
+	SearchSymbol(ctx context.Context, opts *SearchOptions) (int64, []*SearchResult, error)
 	Close()
 }
 
-func filenameIndexerID(repoID int64, filename string) string {
-	return indexerID(repoID) + "_" + filename
+// headRef is stored as the ref of the documents of the default branch
+const headRef = "HEAD"
+
+// documentRef returns the ref stored in the documents of a ref
+func documentRef(ref string) string {
+	if ref == "" {
+		return headRef
+	}
+	return ref
+}
+
+func filenameIndexerID(repoID int64, ref, filename string) string {
+	return refIndexerID(repoID, ref) + "_" + filename
 }
 
 func indexerID(id int64) string {
 	return strconv.FormatInt(id, 36)
 }
 
+// refIndexerID returns the prefix of the ids of the documents of a ref.
+// The ref is hex encoded because it may contain the separator of the filename.
+func refIndexerID(repoID int64, ref string) string {
+	if ref == "" {
+		return indexerID(repoID)
+	}
+	return indexerID(repoID) + "." + hex.EncodeToString([]byte(ref))
+}
+
 func parseIndexerID(indexerID string) (int64, string) {
 	index := strings.IndexByte(indexerID, '_')
 	if index == -1 {
 		log.Error("Unexpected ID in repo indexer: %s", indexerID)
 	}
-	repoID, _ := strconv.ParseInt(indexerID[:index], 36, 64)
-	return repoID, indexerID[index+1:]
+	repoID, _, _ := strings.Cut(indexerID[:index], ".")
+	id, _ := strconv.ParseInt(repoID, 36, 64)
+	return id, indexerID[index+1:]
 }
 
 func filenameOfIndexerID(indexerID string) string {
@@ -103,11 +144,79 @@ func index(ctx context.Context, indexer Indexer, repoID int64) error {
 		return nil
 	}
 
-	if err := indexer.Index(ctx, repo, sha, changes); err != nil {
+	if err := indexer.Index(ctx, repo, "", sha, changes); err != nil {
 		return err
 	}
 
-	return repo_model.UpdateIndexerStatus(ctx, repo, repo_model.RepoIndexerTypeCode, sha)
+	if err := repo_model.UpdateIndexerStatus(ctx, repo, repo_model.RepoIndexerTypeCode, sha); err != nil {
+		return err
+	}
+
+	return indexRefs(ctx, indexer, repo)
+}
+
+// indexRefs indexes the additional branches and tags of a repository and removes
+// the refs which have been deleted or do not match the settings of the repository anymore
+func indexRefs(ctx context.Context, indexer Indexer, repo *repo_model.Repository) error {
+	refs, err := getIndexedRefs(ctx, repo)
+	if err != nil {
+		return err
+	}
+	indexedRefs := make(map[string]bool, len(refs))
+	for _, ref := range refs {
+		indexedRefs[ref.Name] = true
+	}
+
+	statuses, err := repo_model.GetIndexerRefStatuses(ctx, repo.ID, repo_model.RepoIndexerTypeCode)
+	if err != nil {
+		return err
+	}
+	statusByRef := make(map[string]*repo_model.RepoIndexerStatus, len(statuses))
+	for _, status := range statuses {
+		if indexedRefs[status.Ref] {
+			statusByRef[status.Ref] = status
+			continue
+		}
+		if err := indexer.DeleteRef(repo.ID, status.Ref); err != nil {
+			return err
+		}
+		if err := repo_model.DeleteIndexerStatusByID(ctx, status.ID); err != nil {
+			return err
+		}
+	}
+
+	for _, ref := range refs {
+		status, ok := statusByRef[ref.Name]
+		if !ok {
+			status = &repo_model.RepoIndexerStatus{
+				RepoID:      repo.ID,
+				IndexerType: repo_model.RepoIndexerTypeCode,
+				Ref:         ref.Name,
+			}
+		}
+		if status.CommitSha == ref.CommitID {
+			continue
+		}
+
+		var changes *repoChanges
+		if len(status.CommitSha) == 0 {
+			changes, err = genesisChanges(ctx, repo, ref.CommitID)
+		} else {
+			changes, err = nonGenesisChanges(ctx, repo, ref.Name, status.CommitSha, ref.CommitID)
+		}
+		if err != nil {
+			return err
+		}
+		if err := indexer.Index(ctx, repo, ref.Name, ref.CommitID, changes); err != nil {
+			return err
+		}
+
+		status.CommitSha = ref.CommitID
+		if err := repo_model.UpdateIndexerRefStatus(ctx, status); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Init initialize the repo indexer
@@ -309,9 +418,8 @@ func populateRepoIndexer(ctx context.Context) {
 	}
 
 	// if there is any existing repo indexer metadata in the DB, delete it
-	// since we are starting afresh. Also, xorm requires deletes to have a
-	// condition, and we want to delete everything, thus 1=1.
-	if err := db.DeleteAllRecords("repo_indexer_status"); err != nil {
+	// since we are starting afresh. The statuses of the other indexers are kept.
+	if err := repo_model.DeleteIndexerStatuses(ctx, repo_model.RepoIndexerTypeCode); err != nil {
 		log.Fatal("System error: %v", err)
 	}
 
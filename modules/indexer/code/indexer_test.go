@@ -8,6 +8,8 @@ import (
 	"path/filepath"
 	"testing"
 
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
 	"code.gitea.io/gitea/models/unittest"
 	"code.gitea.io/gitea/modules/git"
 
@@ -25,13 +27,18 @@ func TestMain(m *testing.M) {
 func testIndexer(name string, t *testing.T, indexer Indexer) {
 	t.Run(name, func(t *testing.T) {
 		var repoID int64 = 1
-		err := index(git.DefaultContext, indexer, repoID)
+		_, err := db.GetEngine(db.DefaultContext).ID(repoID).Cols("code_indexer_branches").Update(&repo_model.Repository{CodeIndexerBranches: "branch*"})
+		assert.NoError(t, err)
+		err = index(git.DefaultContext, indexer, repoID)
 		assert.NoError(t, err)
 		keywords := []struct {
 			RepoIDs []int64
+			Ref     string
 			Keyword string
+			Regexp  bool
 			IDs     []int64
 			Langs   int
+			Matches []MatchSpan
 		}{
 			{
 				RepoIDs: nil,
@@ -63,11 +70,61 @@ func testIndexer(name string, t *testing.T, indexer Indexer) {
 				IDs:     []int64{},
 				Langs:   0,
 			},
+			{
+				RepoIDs: []int64{repoID},
+				Keyword: "branch2",
+				IDs:     []int64{},
+				Langs:   0,
+			},
+			{
+				RepoIDs: []int64{repoID},
+				Ref:     "refs/heads/branch2",
+				Keyword: "branch2",
+				IDs:     []int64{repoID},
+				Langs:   1,
+			},
+			{
+				RepoIDs: []int64{repoID},
+				Ref:     "refs/heads/develop",
+				Keyword: "Description",
+				IDs:     []int64{},
+				Langs:   0,
+			},
+			{
+				RepoIDs: nil,
+				Keyword: `Desc\w+ for repo\d$`,
+				Regexp:  true,
+				IDs:     []int64{repoID},
+				Langs:   1,
+				Matches: []MatchSpan{{Line: 3, StartColumn: 0, EndColumn: 21}},
+			},
+			{
+				RepoIDs: nil,
+				Keyword: `(?i)^#\s+REPO|FOR`,
+				Regexp:  true,
+				IDs:     []int64{repoID},
+				Langs:   1,
+				Matches: []MatchSpan{{Line: 1, StartColumn: 0, EndColumn: 6}, {Line: 3, StartColumn: 12, EndColumn: 15}},
+			},
+			{
+				RepoIDs: nil,
+				Keyword: `desc.*repo2`,
+				Regexp:  true,
+				IDs:     []int64{},
+				Langs:   0,
+			},
 		}
 
 		for _, kw := range keywords {
 			t.Run(kw.Keyword, func(t *testing.T) {
-				total, res, langs, err := indexer.Search(context.TODO(), kw.RepoIDs, "", kw.Keyword, 1, 10, false)
+				total, res, langs, err := indexer.Search(context.TODO(), &SearchOptions{
+					RepoIDs:  kw.RepoIDs,
+					Ref:      kw.Ref,
+					Keyword:  kw.Keyword,
+					IsRegexp: kw.Regexp,
+					Page:     1,
+					PageSize: 10,
+				})
 				assert.NoError(t, err)
 				assert.EqualValues(t, len(kw.IDs), total)
 				assert.Len(t, langs, kw.Langs)
@@ -75,12 +132,44 @@ func testIndexer(name string, t *testing.T, indexer Indexer) {
 				ids := make([]int64, 0, len(res))
 				for _, hit := range res {
 					ids = append(ids, hit.RepoID)
-					assert.EqualValues(t, "# repo1\n\nDescription for repo1", hit.Content)
+					assert.EqualValues(t, "README.md", hit.Filename)
+					if kw.Ref == "" {
+						assert.EqualValues(t, "# repo1\n\nDescription for repo1", hit.Content)
+					}
+					if kw.Matches != nil {
+						assert.EqualValues(t, kw.Matches, hit.Matches)
+					}
 				}
 				assert.EqualValues(t, kw.IDs, ids)
 			})
 		}
 
+		statuses, err := repo_model.GetIndexerRefStatuses(db.DefaultContext, repoID, repo_model.RepoIndexerTypeCode)
+		assert.NoError(t, err)
+		if assert.Len(t, statuses, 1) {
+			assert.EqualValues(t, "refs/heads/branch2", statuses[0].Ref)
+		}
+
 		assert.NoError(t, indexer.Delete(repoID))
 	})
 }
+
+func TestIsIndexedRef(t *testing.T) {
+	repo := &repo_model.Repository{
+		DefaultBranch:       "main",
+		CodeIndexerBranches: "release/*, main, stable",
+		CodeIndexerTags:     "v*",
+	}
+
+	assert.True(t, IsIndexedRef(repo, "refs/heads/release/1.0"))
+	assert.True(t, IsIndexedRef(repo, "refs/heads/stable"))
+	assert.True(t, IsIndexedRef(repo, "refs/tags/v1.0.0"))
+	assert.False(t, IsIndexedRef(repo, "refs/heads/main"))
+	assert.False(t, IsIndexedRef(repo, "refs/heads/release/1.0/fix"))
+	assert.False(t, IsIndexedRef(repo, "refs/heads/v1.0.0"))
+	assert.False(t, IsIndexedRef(repo, "refs/tags/release/1.0"))
+	assert.False(t, IsIndexedRef(repo, "refs/pull/1/head"))
+
+	assert.NoError(t, ValidateRefPatterns("release/*, v[0-9]*"))
+	assert.Error(t, ValidateRefPatterns("release/[*"))
+}
@@ -0,0 +1,246 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package code
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/util"
+)
+
+const (
+	// maxRegexpCandidates is the maximum number of files which are matched against the regular expression of a search
+	maxRegexpCandidates = 1000
+	// maxMatchSpansPerFile is the maximum number of matches which are returned for a file
+	maxMatchSpansPerFile = 100
+)
+
+// MatchSpan is the position of a match in the content of a file, a match over several lines has a span per line
+type MatchSpan struct {
+	Line        int // 1-based number of the line
+	StartColumn int // byte offset of the start of the match in the line
+	EndColumn   int // byte offset of the end of the match in the line, exclusive
+}
+
+// CompileRegexp compiles the keyword of a regular expression search, `^` and `$` match at line boundaries.
+// The error of an invalid expression unwraps as util.ErrInvalidArgument.
+func CompileRegexp(keyword string) (*regexp.Regexp, error) {
+	if _, err := syntax.Parse(keyword, syntax.Perl); err != nil {
+		return nil, util.NewInvalidArgumentErrorf("%v", err)
+	}
+	return regexp.MustCompile("(?m)" + keyword), nil
+}
+
+// parseRegexp returns the syntax tree of a compiled regular expression
+func parseRegexp(re *regexp.Regexp) *syntax.Regexp {
+	parsed, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil {
+		// the expression has been compiled, so it can always be parsed
+		return &syntax.Regexp{Op: syntax.OpEmptyMatch}
+	}
+	return parsed.Simplify()
+}
+
+// requiredLiterals returns the lower cased literals which are contained in every match of the expression
+func requiredLiterals(re *syntax.Regexp) []string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return []string{strings.ToLower(string(re.Rune))}
+	case syntax.OpCapture, syntax.OpPlus:
+		return requiredLiterals(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min > 0 {
+			return requiredLiterals(re.Sub[0])
+		}
+	case syntax.OpConcat:
+		literals := make([]string, 0, len(re.Sub))
+		var sb strings.Builder
+		for _, sub := range re.Sub {
+			if sub.Op == syntax.OpLiteral {
+				sb.WriteString(strings.ToLower(string(sub.Rune)))
+				continue
+			}
+			if sb.Len() > 0 {
+				literals = append(literals, sb.String())
+				sb.Reset()
+			}
+			literals = append(literals, requiredLiterals(sub)...)
+		}
+		if sb.Len() > 0 {
+			literals = append(literals, sb.String())
+		}
+		return literals
+	}
+	return nil
+}
+
+// regexpTrigrams returns the lower cased trigrams which every file matching the expression contains
+func regexpTrigrams(re *regexp.Regexp) []string {
+	trigrams := make(container.Set[string])
+	for _, literal := range requiredLiterals(parseRegexp(re)) {
+		runes := []rune(literal)
+		for i := 0; i+3 <= len(runes); i++ {
+			trigrams.Add(string(runes[i : i+3]))
+		}
+	}
+	values := trigrams.Values()
+	sort.Strings(values)
+	return values
+}
+
+// luceneRegexp translates the expression to the syntax of lucene which matches the whole value of a field.
+// Lucene doesn't support assertions like `^` or `\b`, they are dropped so the matches must be checked again.
+func luceneRegexp(re *regexp.Regexp) string {
+	var sb strings.Builder
+	sb.WriteString(".*(")
+	writeLuceneRegexp(&sb, parseRegexp(re))
+	sb.WriteString(").*")
+	return sb.String()
+}
+
+// writeLuceneRune writes the rune, every printable ASCII character which isn't a letter or a digit is escaped
+func writeLuceneRune(sb *strings.Builder, r rune) {
+	if r >= ' ' && r < utf8.RuneSelf && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+		sb.WriteByte('\\')
+	}
+	sb.WriteRune(r)
+}
+
+func writeLuceneRegexp(sb *strings.Builder, re *syntax.Regexp) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			if re.Flags&syntax.FoldCase != 0 && unicode.SimpleFold(r) != r {
+				sb.WriteByte('[')
+				for f := r; ; {
+					writeLuceneRune(sb, f)
+					if f = unicode.SimpleFold(f); f == r {
+						break
+					}
+				}
+				sb.WriteByte(']')
+				continue
+			}
+			writeLuceneRune(sb, r)
+		}
+	case syntax.OpCharClass:
+		if len(re.Rune) == 0 {
+			sb.WriteString("()")
+			return
+		}
+		sb.WriteByte('[')
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			writeLuceneRune(sb, re.Rune[i])
+			if re.Rune[i+1] != re.Rune[i] {
+				sb.WriteByte('-')
+				writeLuceneRune(sb, re.Rune[i+1])
+			}
+		}
+		sb.WriteByte(']')
+	case syntax.OpAnyCharNotNL:
+		sb.WriteString("[^\n]")
+	case syntax.OpAnyChar:
+		sb.WriteByte('.')
+	case syntax.OpCapture:
+		sb.WriteByte('(')
+		writeLuceneRegexp(sb, re.Sub[0])
+		sb.WriteByte(')')
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest:
+		sb.WriteByte('(')
+		writeLuceneRegexp(sb, re.Sub[0])
+		sb.WriteByte(')')
+		switch re.Op {
+		case syntax.OpStar:
+			sb.WriteByte('*')
+		case syntax.OpPlus:
+			sb.WriteByte('+')
+		default:
+			sb.WriteByte('?')
+		}
+	case syntax.OpRepeat:
+		sb.WriteByte('(')
+		writeLuceneRegexp(sb, re.Sub[0])
+		sb.WriteString("){")
+		sb.WriteString(strconv.Itoa(re.Min))
+		if re.Max != re.Min {
+			sb.WriteByte(',')
+			if re.Max >= 0 {
+				sb.WriteString(strconv.Itoa(re.Max))
+			}
+		}
+		sb.WriteByte('}')
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			writeLuceneRegexp(sb, sub)
+		}
+	case syntax.OpAlternate:
+		sb.WriteByte('(')
+		for i, sub := range re.Sub {
+			if i > 0 {
+				sb.WriteByte('|')
+			}
+			writeLuceneRegexp(sb, sub)
+		}
+		sb.WriteByte(')')
+	default:
+		// the assertions and the empty match are dropped, the result matches more values
+	}
+}
+
+// matchSpans converts the byte offsets of the matches in the content to spans of lines
+func matchSpans(content string, locs [][]int) []MatchSpan {
+	lineStarts := []int{0}
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+
+	spans := make([]MatchSpan, 0, len(locs))
+	for _, loc := range locs {
+		start, end := loc[0], loc[1]
+		if start >= end {
+			continue
+		}
+		line := sort.SearchInts(lineStarts, start+1) - 1
+		for start < end && len(spans) < maxMatchSpansPerFile {
+			lineEnd := len(content)
+			if line+1 < len(lineStarts) {
+				lineEnd = lineStarts[line+1] - 1
+			}
+			spanEnd := util.Min(end, lineEnd)
+			if spanEnd > start {
+				spans = append(spans, MatchSpan{
+					Line:        line + 1,
+					StartColumn: start - lineStarts[line],
+					EndColumn:   spanEnd - lineStarts[line],
+				})
+			}
+			line++
+			if line >= len(lineStarts) {
+				break
+			}
+			start = lineStarts[line]
+		}
+	}
+	return spans
+}
+
+// setRegexpMatches sets the matches of the expression in the content of the result, it returns false if there is none
+func setRegexpMatches(result *SearchResult, re *regexp.Regexp) bool {
+	locs := re.FindAllStringIndex(result.Content, maxMatchSpansPerFile)
+	if len(locs) == 0 {
+		return false
+	}
+	result.StartIndex, result.EndIndex = locs[0][0], locs[0][1]
+	result.Matches = matchSpans(result.Content, locs)
+	return true
+}
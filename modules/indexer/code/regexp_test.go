@@ -0,0 +1,64 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package code
+
+import (
+	"errors"
+	"testing"
+
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompileRegexp(t *testing.T) {
+	re, err := CompileRegexp(`^func`)
+	assert.NoError(t, err)
+	assert.True(t, re.MatchString("package main\nfunc main() {}"))
+
+	_, err = CompileRegexp(`foo\(`)
+	assert.NoError(t, err)
+
+	_, err = CompileRegexp(`foo(`)
+	assert.True(t, errors.Is(err, util.ErrInvalidArgument))
+}
+
+func TestRegexpTrigrams(t *testing.T) {
+	cases := map[string][]string{
+		`foo\(.*ctx`:      {"ctx", "foo", "oo("},
+		`(?i)Func\s+Main`: {"ain", "fun", "mai", "unc"},
+		`abc(de)+`:        {"abc"},
+		`abc|def`:         {},
+		`x*yz`:            {},
+		`[ab]cde?`:        {},
+	}
+	for expr, expected := range cases {
+		re, err := CompileRegexp(expr)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, regexpTrigrams(re), expr)
+	}
+}
+
+func TestLuceneRegexp(t *testing.T) {
+	cases := map[string]string{
+		`foo\(.*ctx`:   ".*(foo\\(([^\n])*ctx).*",
+		`^a[0-9]{2,}$`: ".*(a[0-9]([0-9])+).*",
+		`(?i)ab|c?`:    ".*(([Aa][Bb]|([Cc])?)).*",
+		`\bx\b`:        ".*(x).*",
+	}
+	for expr, expected := range cases {
+		re, err := CompileRegexp(expr)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, luceneRegexp(re), expr)
+	}
+}
+
+func TestMatchSpans(t *testing.T) {
+	content := "first line\nsecond line\nthird"
+	assert.Equal(t, []MatchSpan{
+		{Line: 1, StartColumn: 6, EndColumn: 10},
+		{Line: 2, StartColumn: 0, EndColumn: 6},
+		{Line: 3, StartColumn: 0, EndColumn: 5},
+	}, matchSpans(content, [][]int{{6, 17}, {18, 18}, {23, 28}}))
+}
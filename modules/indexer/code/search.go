@@ -6,6 +6,7 @@ package code
 import (
 	"bytes"
 	"context"
+	"html"
 	"strings"
 
 	"code.gitea.io/gitea/modules/highlight"
@@ -23,6 +24,7 @@ type Result struct {
 	Color          string
 	LineNumbers    []int
 	FormattedLines string
+	Matches        []MatchSpan
 }
 
 func indices(content string, selectionStartIndex, selectionEndIndex int) (int, int) {
@@ -96,6 +98,18 @@ func searchResult(result *SearchResult, startIndex, endIndex int) (*Result, erro
 
 	highlighted, _ := highlight.Code(result.Filename, "", formattedLinesBuffer.String())
 
+	// the offsets of the matches in the shown lines
+	lineOffsets := make([]int, len(contentLines))
+	for i := 1; i < len(contentLines); i++ {
+		lineOffsets[i] = lineOffsets[i-1] + len(contentLines[i-1])
+	}
+	ranges := make([][2]int, 0, len(result.Matches))
+	for _, match := range result.Matches {
+		if i := match.Line - startLineNum; i >= 0 && i < len(contentLines) {
+			ranges = append(ranges, [2]int{lineOffsets[i] + match.StartColumn, lineOffsets[i] + match.EndColumn})
+		}
+	}
+
 	return &Result{
 		RepoID:         result.RepoID,
 		Filename:       result.Filename,
@@ -104,17 +118,75 @@ func searchResult(result *SearchResult, startIndex, endIndex int) (*Result, erro
 		Language:       result.Language,
 		Color:          result.Color,
 		LineNumbers:    lineNumbers,
-		FormattedLines: highlighted,
+		FormattedLines: highlightRanges(highlighted, ranges),
+		Matches:        result.Matches,
 	}, nil
 }
 
+// highlightRanges wraps the text of the ranges in the highlighted code with a search highlight, the ranges are byte
+// offsets in the unescaped text of the code and must be sorted. The highlight is closed before every tag of the code.
+func highlightRanges(code string, ranges [][2]int) string {
+	if len(ranges) == 0 {
+		return code
+	}
+
+	var sb strings.Builder
+	pos, r, open := 0, 0, false
+	for i := 0; i < len(code); {
+		for r < len(ranges) && pos >= ranges[r][1] {
+			r++
+		}
+		if !open && r < len(ranges) && pos >= ranges[r][0] && code[i] != '<' {
+			sb.WriteString(`<span class="search-highlight">`)
+			open = true
+		}
+
+		switch code[i] {
+		case '<':
+			if open {
+				sb.WriteString("</span>")
+				open = false
+			}
+			end := strings.IndexByte(code[i:], '>')
+			if end < 0 {
+				end = len(code) - i - 1
+			}
+			sb.WriteString(code[i : i+end+1])
+			i += end + 1
+			continue
+		case '&':
+			end := strings.IndexByte(code[i:], ';')
+			if end < 0 {
+				end = 0
+			}
+			entity := code[i : i+end+1]
+			sb.WriteString(entity)
+			i += end + 1
+			pos += len(html.UnescapeString(entity))
+		default:
+			sb.WriteByte(code[i])
+			i++
+			pos++
+		}
+
+		if open && pos >= ranges[r][1] {
+			sb.WriteString("</span>")
+			open = false
+		}
+	}
+	if open {
+		sb.WriteString("</span>")
+	}
+	return sb.String()
+}
+
 // PerformSearch perform a search on a repository
-func PerformSearch(ctx context.Context, repoIDs []int64, language, keyword string, page, pageSize int, isMatch bool) (int, []*Result, []*SearchResultLanguages, error) {
-	if len(keyword) == 0 {
+func PerformSearch(ctx context.Context, opts *SearchOptions) (int, []*Result, []*SearchResultLanguages, error) {
+	if len(opts.Keyword) == 0 {
 		return 0, nil, nil, nil
 	}
 
-	total, results, resultLanguages, err := indexer.Search(ctx, repoIDs, language, keyword, page, pageSize, isMatch)
+	total, results, resultLanguages, err := indexer.Search(ctx, opts)
 	if err != nil {
 		return 0, nil, nil, err
 	}
@@ -122,6 +194,9 @@ func PerformSearch(ctx context.Context, repoIDs []int64, language, keyword strin
 	displayResults := make([]*Result, len(results))
 
 	for i, result := range results {
+		if len(result.Matches) == 0 && result.StartIndex >= 0 && result.EndIndex > result.StartIndex {
+			result.Matches = matchSpans(result.Content, [][]int{{result.StartIndex, result.EndIndex}})
+		}
 		startIndex, endIndex := indices(result.Content, result.StartIndex, result.EndIndex)
 		displayResults[i], err = searchResult(result, startIndex, endIndex)
 		if err != nil {
@@ -130,3 +205,113 @@ func PerformSearch(ctx context.Context, repoIDs []int64, language, keyword strin
 	}
 	return int(total), displayResults, resultLanguages, nil
 }
+
+// maxSymbolLinesPerFile is the maximum number of lines of a file in the results of a symbol search
+const maxSymbolLinesPerFile = 50
+
+// SymbolLine a line which defines or references a symbol
+type SymbolLine struct {
+	Number        int
+	Kind          string // the kind of the definition, empty for a reference
+	FormattedLine string
+}
+
+// SymbolResult the lines of a file which define or reference a symbol
+type SymbolResult struct {
+	RepoID   int64
+	Filename string
+	CommitID string
+	Language string
+	Color    string
+	Lines    []*SymbolLine
+}
+
+func newSymbolResult(result *SearchResult) *SymbolResult {
+	return &SymbolResult{
+		RepoID:   result.RepoID,
+		Filename: result.Filename,
+		CommitID: result.CommitID,
+		Language: result.Language,
+		Color:    result.Color,
+	}
+}
+
+func formatSymbolLine(filename, line string) string {
+	highlighted, _ := highlight.Code(filename, "", strings.TrimSuffix(line, "\r"))
+	return highlighted
+}
+
+// FindDefinitions searches the definitions of the symbol opts.Keyword, the total is the number of files
+func FindDefinitions(ctx context.Context, opts *SearchOptions) (int, []*SymbolResult, error) {
+	if len(opts.Keyword) == 0 {
+		return 0, nil, nil
+	}
+
+	total, results, err := indexer.SearchSymbol(ctx, opts)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	symbolResults := make([]*SymbolResult, 0, len(results))
+	for _, result := range results {
+		lines := strings.Split(result.Content, "\n")
+		symbolResult := newSymbolResult(result)
+		for _, symbol := range ExtractSymbols(result.Language, result.Content) {
+			if symbol.Name != opts.Keyword || len(symbolResult.Lines) == maxSymbolLinesPerFile {
+				continue
+			}
+			symbolResult.Lines = append(symbolResult.Lines, &SymbolLine{
+				Number:        symbol.Line,
+				Kind:          symbol.Kind,
+				FormattedLine: formatSymbolLine(result.Filename, lines[symbol.Line-1]),
+			})
+		}
+		if len(symbolResult.Lines) > 0 {
+			symbolResults = append(symbolResults, symbolResult)
+		}
+	}
+	return int(total), symbolResults, nil
+}
+
+// FindReferences searches the lines which contain the symbol opts.Keyword as a word, the total is the number of files.
+// Definitions of the symbol are included and have a kind.
+func FindReferences(ctx context.Context, opts *SearchOptions) (int, []*SymbolResult, error) {
+	if len(opts.Keyword) == 0 {
+		return 0, nil, nil
+	}
+
+	total, results, _, err := indexer.Search(ctx, opts)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	symbolResults := make([]*SymbolResult, 0, len(results))
+	for _, result := range results {
+		definitions := make(map[int]string)
+		for _, symbol := range ExtractSymbols(result.Language, result.Content) {
+			if symbol.Name == opts.Keyword {
+				definitions[symbol.Line] = symbol.Kind
+			}
+		}
+
+		symbolResult := newSymbolResult(result)
+		for i, line := range strings.Split(result.Content, "\n") {
+			if !containsWord(line, opts.Keyword) {
+				continue
+			}
+			symbolResult.Lines = append(symbolResult.Lines, &SymbolLine{
+				Number:        i + 1,
+				Kind:          definitions[i+1],
+				FormattedLine: formatSymbolLine(result.Filename, line),
+			})
+			if len(symbolResult.Lines) == maxSymbolLinesPerFile {
+				break
+			}
+		}
+		// the full text search ignores the case and the boundaries of identifiers
+		if len(symbolResult.Lines) > 0 {
+			symbolResults = append(symbolResults, symbolResult)
+		}
+	}
+	return int(total), symbolResults, nil
+}
@@ -0,0 +1,18 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighlightRanges(t *testing.T) {
+	code := `<span class="nx">foo</span><span class="p">(</span><span class="s">&#34;a&lt;b&#34;</span>`
+	assert.Equal(t, code, highlightRanges(code, nil))
+	assert.Equal(t,
+		`<span class="nx">f<span class="search-highlight">oo</span></span><span class="p"><span class="search-highlight">(</span></span><span class="s">&#34;a<span class="search-highlight">&lt;</span>b&#34;</span>`,
+		highlightRanges(code, [][2]int{{1, 4}, {6, 7}}))
+}
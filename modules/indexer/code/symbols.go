@@ -0,0 +1,213 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package code
+
+import (
+	"regexp"
+	"strings"
+
+	"code.gitea.io/gitea/modules/container"
+)
+
+// Symbol is a definition found in the content of a file
+type Symbol struct {
+	Name string
+	Kind string
+	Line int
+}
+
+// symbolRule extracts the name of a definition from a line, the name is the first submatch
+type symbolRule struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+// symbolGroup extracts the names of grouped definitions like Go's `const ( ... )` blocks
+type symbolGroup struct {
+	start *regexp.Regexp // the first submatch is the kind of the definitions
+	end   *regexp.Regexp
+	item  *regexp.Regexp
+}
+
+type symbolLanguage struct {
+	rules  []symbolRule
+	groups []symbolGroup
+}
+
+func rule(kind, expr string) symbolRule {
+	return symbolRule{kind: kind, re: regexp.MustCompile(expr)}
+}
+
+var (
+	cFamilyRules = []symbolRule{
+		rule("macro", `^\s*#\s*define\s+([A-Za-z_]\w*)`),
+		rule("type", `^\s*(?:typedef\s+)?(?:struct|union|enum|class|namespace)\s+([A-Za-z_]\w*)\s*(?:[:{]|$)`),
+		rule("type", `^\s*typedef\s+[^;(]*?\b([A-Za-z_]\w*)\s*;`),
+		rule("func", `^[A-Za-z_][\w\s\*&:<>,]*?[\s\*&:]([A-Za-z_]\w*)\s*\([^;]*$`),
+	}
+	javaLikeRules = []symbolRule{
+		rule("type", `\b(?:class|interface|enum|record|struct|object)\s+([A-Za-z_]\w*)`),
+		rule("func", `^\s*(?:(?:public|private|protected|internal|static|final|abstract|synchronized|override|virtual|async|open|suspend|inline)\s+)+(?:fun\s+)?(?:<[^>]*>\s+)?(?:[\w\[\]<>?,.]+\s+)?([A-Za-z_]\w*)\s*\(`),
+		rule("func", `^\s*fun\s+(?:<[^>]*>\s+)?(?:[\w.]+\.)?([A-Za-z_]\w*)\s*\(`),
+	}
+	javaScriptRules = []symbolRule{
+		rule("func", `^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*([A-Za-z_$][\w$]*)`),
+		rule("class", `^\s*(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+([A-Za-z_$][\w$]*)`),
+		rule("type", `^\s*(?:export\s+)?(?:declare\s+)?(?:interface|type|enum)\s+([A-Za-z_$][\w$]*)`),
+		rule("var", `^\s*(?:export\s+)?(?:const|let|var)\s+([A-Za-z_$][\w$]*)`),
+	}
+
+	// symbolLanguages are heuristic rules to find definitions, the keys are the languages of the code indexer
+	symbolLanguages = map[string]*symbolLanguage{
+		"Go": {
+			rules: []symbolRule{
+				rule("func", `^func\s+(?:\([^)]*\)\s*)?([A-Za-z_]\w*)`),
+				rule("type", `^type\s+([A-Za-z_]\w*)`),
+				rule("const", `^const\s+([A-Za-z_]\w*)`),
+				rule("var", `^var\s+([A-Za-z_]\w*)`),
+			},
+			groups: []symbolGroup{{
+				start: regexp.MustCompile(`^(const|var|type)\s*\(\s*$`),
+				end:   regexp.MustCompile(`^\)`),
+				item:  regexp.MustCompile(`^\s+([A-Za-z_]\w*)\b`),
+			}},
+		},
+		"Python": {
+			rules: []symbolRule{
+				rule("func", `^\s*(?:async\s+)?def\s+([A-Za-z_]\w*)`),
+				rule("class", `^\s*class\s+([A-Za-z_]\w*)`),
+				rule("var", `^([A-Za-z_]\w*)\s*(?::[^=]+)?=[^=]`),
+			},
+		},
+		"JavaScript": {rules: javaScriptRules},
+		"TypeScript": {rules: javaScriptRules},
+		"TSX":        {rules: javaScriptRules},
+		"Vue":        {rules: javaScriptRules},
+		"Java":       {rules: javaLikeRules},
+		"Kotlin":     {rules: javaLikeRules},
+		"C#":         {rules: javaLikeRules},
+		"Scala":      {rules: javaLikeRules},
+		"C":          {rules: cFamilyRules},
+		"C++":        {rules: cFamilyRules},
+		"Rust": {
+			rules: []symbolRule{
+				rule("func", `^\s*(?:pub(?:\([^)]*\))?\s+)?(?:const\s+)?(?:async\s+)?(?:unsafe\s+)?(?:extern\s+"[^"]*"\s+)?fn\s+([A-Za-z_]\w*)`),
+				rule("type", `^\s*(?:pub(?:\([^)]*\))?\s+)?(?:struct|enum|trait|type|union)\s+([A-Za-z_]\w*)`),
+				rule("mod", `^\s*(?:pub(?:\([^)]*\))?\s+)?mod\s+([A-Za-z_]\w*)`),
+				rule("const", `^\s*(?:pub(?:\([^)]*\))?\s+)?(?:const|static)\s+(?:mut\s+)?([A-Za-z_]\w*)`),
+				rule("macro", `^\s*macro_rules!\s*([A-Za-z_]\w*)`),
+			},
+		},
+		"Ruby": {
+			rules: []symbolRule{
+				rule("func", `^\s*def\s+(?:self\.)?([A-Za-z_]\w*[?!=]?)`),
+				rule("class", `^\s*(?:class|module)\s+(?:[A-Z]\w*::)*([A-Z]\w*)`),
+			},
+		},
+		"PHP": {
+			rules: []symbolRule{
+				rule("func", `^\s*(?:(?:public|private|protected|static|abstract|final)\s+)*function\s+&?([A-Za-z_]\w*)`),
+				rule("class", `^\s*(?:(?:abstract|final|readonly)\s+)*(?:class|interface|trait|enum)\s+([A-Za-z_]\w*)`),
+			},
+		},
+		"Shell": {
+			rules: []symbolRule{
+				rule("func", `^\s*(?:function\s+)?([A-Za-z_][\w-]*)\s*\(\)`),
+				rule("func", `^\s*function\s+([A-Za-z_][\w-]*)`),
+			},
+		},
+	}
+)
+
+// HasSymbolSupport returns true if definitions can be extracted from files of the language
+func HasSymbolSupport(language string) bool {
+	_, ok := symbolLanguages[language]
+	return ok
+}
+
+// ExtractSymbols finds the definitions in the content of a file by heuristic rules of the language
+func ExtractSymbols(language, content string) []*Symbol {
+	lang, ok := symbolLanguages[language]
+	if !ok {
+		return nil
+	}
+
+	symbols := make([]*Symbol, 0, 10)
+	var group *symbolGroup
+	groupKind := ""
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+
+		if group != nil {
+			if group.end.MatchString(line) {
+				group = nil
+			} else if m := group.item.FindStringSubmatch(line); m != nil {
+				symbols = append(symbols, &Symbol{Name: m[1], Kind: groupKind, Line: i + 1})
+			}
+			continue
+		}
+
+		matched := false
+		for j := range lang.groups {
+			if m := lang.groups[j].start.FindStringSubmatch(line); m != nil {
+				group = &lang.groups[j]
+				groupKind = m[1]
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+
+		for _, r := range lang.rules {
+			if m := r.re.FindStringSubmatch(line); m != nil && !isSymbolKeyword(m[1]) {
+				symbols = append(symbols, &Symbol{Name: m[1], Kind: r.kind, Line: i + 1})
+				break
+			}
+		}
+	}
+	return symbols
+}
+
+// symbolKeywords are matched by the loose rules of some languages, but they are never definitions
+var symbolKeywords = container.SetOf("if", "for", "while", "switch", "return", "else", "catch", "new", "sizeof", "do")
+
+func isSymbolKeyword(name string) bool {
+	return symbolKeywords.Contains(name)
+}
+
+// symbolNames returns the unique names of the definitions in the content
+func symbolNames(language, content string) []string {
+	symbols := ExtractSymbols(language, content)
+	if len(symbols) == 0 {
+		return nil
+	}
+	names := make(container.Set[string], len(symbols))
+	for _, symbol := range symbols {
+		names.Add(symbol.Name)
+	}
+	return names.Values()
+}
+
+func isIdentifierByte(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// containsWord returns true if the line contains the name which is not part of a longer identifier
+func containsWord(line, name string) bool {
+	for start := 0; start < len(line); {
+		idx := strings.Index(line[start:], name)
+		if idx < 0 {
+			return false
+		}
+		idx += start
+		end := idx + len(name)
+		if (idx == 0 || !isIdentifierByte(line[idx-1])) && (end == len(line) || !isIdentifierByte(line[end])) {
+			return true
+		}
+		start = idx + 1
+	}
+	return false
+}
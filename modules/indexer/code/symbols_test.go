@@ -0,0 +1,93 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractSymbols(t *testing.T) {
+	cases := []struct {
+		Language string
+		Content  string
+		Expected []*Symbol
+	}{
+		{
+			Language: "Go",
+			Content: `package main
+
+const (
+	First = iota
+	Second
+)
+
+type Server struct{}
+
+func (s *Server) Serve() error {
+	if err := run(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func main() {}
+`,
+			Expected: []*Symbol{
+				{Name: "First", Kind: "const", Line: 4},
+				{Name: "Second", Kind: "const", Line: 5},
+				{Name: "Server", Kind: "type", Line: 8},
+				{Name: "Serve", Kind: "func", Line: 10},
+				{Name: "main", Kind: "func", Line: 17},
+			},
+		},
+		{
+			Language: "Python",
+			Content:  "VERSION = 1\n\nclass Parser:\n    async def parse(self):\n        if self.x == 1:\n            pass\n",
+			Expected: []*Symbol{
+				{Name: "VERSION", Kind: "var", Line: 1},
+				{Name: "Parser", Kind: "class", Line: 3},
+				{Name: "parse", Kind: "func", Line: 4},
+			},
+		},
+		{
+			Language: "JavaScript",
+			Content:  "export function initRepo() {\n  const el = null;\n}\nexport class Store {}\n",
+			Expected: []*Symbol{
+				{Name: "initRepo", Kind: "func", Line: 1},
+				{Name: "el", Kind: "var", Line: 2},
+				{Name: "Store", Kind: "class", Line: 4},
+			},
+		},
+		{
+			Language: "C",
+			Content:  "#define MAX 10\nstatic int add(int a, int b) {\n  if (a) {\n    return a + b;\n  }\n}\n",
+			Expected: []*Symbol{
+				{Name: "MAX", Kind: "macro", Line: 1},
+				{Name: "add", Kind: "func", Line: 2},
+			},
+		},
+		{
+			Language: "Markdown",
+			Content:  "# title\n",
+			Expected: nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Language, func(t *testing.T) {
+			assert.Equal(t, c.Expected, ExtractSymbols(c.Language, c.Content))
+		})
+	}
+}
+
+func TestContainsWord(t *testing.T) {
+	assert.True(t, containsWord("return Serve()", "Serve"))
+	assert.True(t, containsWord("Serve", "Serve"))
+	assert.True(t, containsWord("ServeHTTP(Serve)", "Serve"))
+	assert.False(t, containsWord("ServeHTTP()", "Serve"))
+	assert.False(t, containsWord("s.serve_files", "serve"))
+	assert.False(t, containsWord("", "Serve"))
+}
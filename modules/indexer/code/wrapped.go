@@ -76,12 +76,12 @@ func (w *wrappedIndexer) Ping() bool {
 	return indexer.Ping()
 }
 
-func (w *wrappedIndexer) Index(ctx context.Context, repo *repo_model.Repository, sha string, changes *repoChanges) error {
+func (w *wrappedIndexer) Index(ctx context.Context, repo *repo_model.Repository, ref, sha string, changes *repoChanges) error {
 	indexer, err := w.get()
 	if err != nil {
 		return err
 	}
-	return indexer.Index(ctx, repo, sha, changes)
+	return indexer.Index(ctx, repo, ref, sha, changes)
 }
 
 func (w *wrappedIndexer) Delete(repoID int64) error {
@@ -92,12 +92,28 @@ func (w *wrappedIndexer) Delete(repoID int64) error {
 	return indexer.Delete(repoID)
 }
 
-func (w *wrappedIndexer) Search(ctx context.Context, repoIDs []int64, language, keyword string, page, pageSize int, isMatch bool) (int64, []*SearchResult, []*SearchResultLanguages, error) {
+func (w *wrappedIndexer) DeleteRef(repoID int64, ref string) error {
+	indexer, err := w.get()
+	if err != nil {
+		return err
+	}
+	return indexer.DeleteRef(repoID, ref)
+}
+
+func (w *wrappedIndexer) Search(ctx context.Context, opts *SearchOptions) (int64, []*SearchResult, []*SearchResultLanguages, error) {
 	indexer, err := w.get()
 	if err != nil {
 		return 0, nil, nil, err
 	}
-	return indexer.Search(ctx, repoIDs, language, keyword, page, pageSize, isMatch)
+	return indexer.Search(ctx, opts)
+}
+
+func (w *wrappedIndexer) SearchSymbol(ctx context.Context, opts *SearchOptions) (int64, []*SearchResult, error) {
+	indexer, err := w.get()
+	if err != nil {
+		return 0, nil, err
+	}
+	return indexer.SearchSymbol(ctx, opts)
 }
 
 func (w *wrappedIndexer) Close() {
@@ -0,0 +1,353 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package commits
+
+import (
+	"context"
+	"os"
+
+	gitea_bleve "code.gitea.io/gitea/modules/indexer/bleve"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/blevesearch/bleve/v2"
+	analyzer_custom "github.com/blevesearch/bleve/v2/analysis/analyzer/custom"
+	analyzer_keyword "github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/v2/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/v2/analysis/token/unicodenorm"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/unicode"
+	"github.com/blevesearch/bleve/v2/index/upsidedown"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/ethantkoenig/rupture"
+)
+
+const (
+	unicodeNormalizeName       = "unicodeNormalize"
+	maxBatchSize               = 16
+	commitIndexerAnalyzer      = "commitIndexer"
+	commitIndexerDocType       = "commitIndexerDocType"
+	commitIndexerLatestVersion = 1
+)
+
+// numericEqualityQuery a numeric equality query for the given value and field
+func numericEqualityQuery(value int64, field string) *query.NumericRangeQuery {
+	f := float64(value)
+	tru := true
+	q := bleve.NewNumericRangeInclusiveQuery(&f, &f, &tru, &tru)
+	q.SetField(field)
+	return q
+}
+
+// numericRangeQuery an inclusive numeric range query, an unset bound is 0
+func numericRangeQuery(min, max int64, field string) *query.NumericRangeQuery {
+	var minF, maxF *float64
+	if min != 0 {
+		f := float64(min)
+		minF = &f
+	}
+	if max != 0 {
+		f := float64(max)
+		maxF = &f
+	}
+	tru := true
+	q := bleve.NewNumericRangeInclusiveQuery(minF, maxF, &tru, &tru)
+	q.SetField(field)
+	return q
+}
+
+func newMatchPhraseQuery(matchPhrase, field string) *query.MatchPhraseQuery {
+	q := bleve.NewMatchPhraseQuery(matchPhrase)
+	q.FieldVal = field
+	q.Analyzer = commitIndexerAnalyzer
+	return q
+}
+
+// identityQuery a query matching one of the values in the name or the email fields of an identity
+func identityQuery(values []string, nameField, emailField string) query.Query {
+	queries := make([]query.Query, 0, 2*len(values))
+	for _, value := range values {
+		queries = append(queries, newMatchPhraseQuery(value, nameField), newMatchPhraseQuery(value, emailField))
+	}
+	return bleve.NewDisjunctionQuery(queries...)
+}
+
+func addUnicodeNormalizeTokenFilter(m *mapping.IndexMappingImpl) error {
+	return m.AddCustomTokenFilter(unicodeNormalizeName, map[string]interface{}{
+		"type": unicodenorm.Name,
+		"form": unicodenorm.NFC,
+	})
+}
+
+// openBleveIndexer open the index at the specified path, checking for metadata
+// updates and bleve version updates.  If index needs to be created (or
+// re-created), returns (nil, nil)
+func openBleveIndexer(path string, latestVersion int) (bleve.Index, error) {
+	_, err := os.Stat(path)
+	if err != nil && os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	metadata, err := rupture.ReadIndexMetadata(path)
+	if err != nil {
+		return nil, err
+	}
+	if metadata.Version < latestVersion {
+		// the indexer is using a previous version, so we should delete it and
+		// re-populate
+		return nil, util.RemoveAll(path)
+	}
+
+	index, err := bleve.Open(path)
+	if err != nil && err == upsidedown.IncompatibleVersion {
+		// the indexer was built with a previous version of bleve, so we should
+		// delete it and re-populate
+		return nil, util.RemoveAll(path)
+	} else if err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// CommitIndexerData data stored in the commit indexer
+type CommitIndexerData struct {
+	RepoID         int64
+	CommitID       string
+	Message        string
+	AuthorName     string
+	AuthorEmail    string
+	AuthorUnix     int64
+	CommitterName  string
+	CommitterEmail string
+	CommitterUnix  int64
+}
+
+// Type returns the document type, for bleve's mapping.Classifier interface.
+func (d *CommitIndexerData) Type() string {
+	return commitIndexerDocType
+}
+
+// createBleveIndexer create a bleve commit indexer if one does not already exist
+func createBleveIndexer(path string, latestVersion int) (bleve.Index, error) {
+	docMapping := bleve.NewDocumentMapping()
+
+	numericFieldMapping := bleve.NewNumericFieldMapping()
+	numericFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("RepoID", numericFieldMapping)
+	docMapping.AddFieldMappingsAt("AuthorUnix", numericFieldMapping)
+	docMapping.AddFieldMappingsAt("CommitterUnix", numericFieldMapping)
+
+	keywordFieldMapping := bleve.NewTextFieldMapping()
+	keywordFieldMapping.Analyzer = analyzer_keyword.Name
+	keywordFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("CommitID", keywordFieldMapping)
+
+	textFieldMapping := bleve.NewTextFieldMapping()
+	textFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("Message", textFieldMapping)
+	docMapping.AddFieldMappingsAt("AuthorName", textFieldMapping)
+	docMapping.AddFieldMappingsAt("AuthorEmail", textFieldMapping)
+	docMapping.AddFieldMappingsAt("CommitterName", textFieldMapping)
+	docMapping.AddFieldMappingsAt("CommitterEmail", textFieldMapping)
+
+	mapping := bleve.NewIndexMapping()
+	if err := addUnicodeNormalizeTokenFilter(mapping); err != nil {
+		return nil, err
+	} else if err := mapping.AddCustomAnalyzer(commitIndexerAnalyzer, map[string]interface{}{
+		"type":          analyzer_custom.Name,
+		"char_filters":  []string{},
+		"tokenizer":     unicode.Name,
+		"token_filters": []string{unicodeNormalizeName, lowercase.Name},
+	}); err != nil {
+		return nil, err
+	}
+	mapping.DefaultAnalyzer = commitIndexerAnalyzer
+	mapping.AddDocumentMapping(commitIndexerDocType, docMapping)
+	mapping.AddDocumentMapping("_all", bleve.NewDocumentDisabledMapping())
+
+	indexer, err := bleve.New(path, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = rupture.WriteIndexMetadata(path, &rupture.IndexMetadata{
+		Version: latestVersion,
+	}); err != nil {
+		return nil, err
+	}
+	return indexer, nil
+}
+
+var _ Indexer = &BleveIndexer{}
+
+// BleveIndexer represents a bleve indexer implementation
+type BleveIndexer struct {
+	indexDir string
+	indexer  bleve.Index
+}
+
+// NewBleveIndexer creates a new bleve local indexer
+func NewBleveIndexer(indexDir string) (*BleveIndexer, bool, error) {
+	indexer := &BleveIndexer{
+		indexDir: indexDir,
+	}
+	created, err := indexer.init()
+	if err != nil {
+		indexer.Close()
+		return nil, false, err
+	}
+	return indexer, created, err
+}
+
+// init init the indexer
+func (b *BleveIndexer) init() (bool, error) {
+	var err error
+	b.indexer, err = openBleveIndexer(b.indexDir, commitIndexerLatestVersion)
+	if err != nil {
+		return false, err
+	}
+	if b.indexer != nil {
+		return false, nil
+	}
+
+	b.indexer, err = createBleveIndexer(b.indexDir, commitIndexerLatestVersion)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Close close the indexer
+func (b *BleveIndexer) Close() {
+	log.Debug("Closing commit indexer")
+	if b.indexer != nil {
+		err := b.indexer.Close()
+		if err != nil {
+			log.Error("Error whilst closing the commit indexer: %v", err)
+		}
+	}
+	log.Info("PID: %d Commit Indexer closed", os.Getpid())
+}
+
+// SetAvailabilityChangeCallback does nothing
+func (b *BleveIndexer) SetAvailabilityChangeCallback(callback func(bool)) {
+}
+
+// Ping does nothing
+func (b *BleveIndexer) Ping() bool {
+	return true
+}
+
+// Index adds the commits of a repository to the indexer
+func (b *BleveIndexer) Index(ctx context.Context, repoID int64, commits []*CommitData) error {
+	batch := gitea_bleve.NewFlushingBatch(b.indexer, maxBatchSize)
+	for _, commit := range commits {
+		if err := batch.Index(indexerID(repoID, commit.CommitID), &CommitIndexerData{
+			RepoID:         repoID,
+			CommitID:       commit.CommitID,
+			Message:        commit.Message,
+			AuthorName:     commit.AuthorName,
+			AuthorEmail:    commit.AuthorEmail,
+			AuthorUnix:     int64(commit.AuthorUnix),
+			CommitterName:  commit.CommitterName,
+			CommitterEmail: commit.CommitterEmail,
+			CommitterUnix:  int64(commit.CommitterUnix),
+		}); err != nil {
+			return err
+		}
+	}
+	return batch.Flush()
+}
+
+// Delete deletes the commits of a repository from the indexer
+func (b *BleveIndexer) Delete(repoID int64) error {
+	searchRequest := bleve.NewSearchRequestOptions(numericEqualityQuery(repoID, "RepoID"), 2147483647, 0, false)
+	result, err := b.indexer.Search(searchRequest)
+	if err != nil {
+		return err
+	}
+	batch := gitea_bleve.NewFlushingBatch(b.indexer, maxBatchSize)
+	for _, hit := range result.Hits {
+		if err = batch.Delete(hit.ID); err != nil {
+			return err
+		}
+	}
+	return batch.Flush()
+}
+
+// Search searches for commits by the message, the author, the committer and the commit time
+func (b *BleveIndexer) Search(ctx context.Context, opts *SearchOptions) (int64, []*CommitData, error) {
+	queries := make([]query.Query, 0, 5)
+	if len(opts.RepoIDs) > 0 {
+		repoQueries := make([]query.Query, 0, len(opts.RepoIDs))
+		for _, repoID := range opts.RepoIDs {
+			repoQueries = append(repoQueries, numericEqualityQuery(repoID, "RepoID"))
+		}
+		queries = append(queries, bleve.NewDisjunctionQuery(repoQueries...))
+	}
+	if len(opts.Keywords) > 0 {
+		keywordQueries := make([]query.Query, 0, len(opts.Keywords))
+		for _, keyword := range opts.Keywords {
+			keywordQueries = append(keywordQueries, newMatchPhraseQuery(keyword, "Message"))
+		}
+		queries = append(queries, bleve.NewDisjunctionQuery(keywordQueries...))
+	}
+	if len(opts.Authors) > 0 {
+		queries = append(queries, identityQuery(opts.Authors, "AuthorName", "AuthorEmail"))
+	}
+	if len(opts.Committers) > 0 {
+		queries = append(queries, identityQuery(opts.Committers, "CommitterName", "CommitterEmail"))
+	}
+	if opts.After > 0 || opts.Before > 0 {
+		queries = append(queries, numericRangeQuery(int64(opts.After), int64(opts.Before), "CommitterUnix"))
+	}
+
+	var indexerQuery query.Query = bleve.NewMatchAllQuery()
+	if len(queries) > 0 {
+		indexerQuery = bleve.NewConjunctionQuery(queries...)
+	}
+
+	from := (opts.Page - 1) * opts.PageSize
+	searchRequest := bleve.NewSearchRequestOptions(indexerQuery, opts.PageSize, from, false)
+	searchRequest.Fields = []string{"*"}
+	searchRequest.SortBy([]string{"-CommitterUnix", "CommitID"})
+
+	result, err := b.indexer.SearchInContext(ctx, searchRequest)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	commits := make([]*CommitData, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		commits = append(commits, convertBleveHit(hit))
+	}
+	return int64(result.Total), commits, nil
+}
+
+func convertBleveHit(hit *search.DocumentMatch) *CommitData {
+	stringField := func(name string) string {
+		value, _ := hit.Fields[name].(string)
+		return value
+	}
+	numericField := func(name string) int64 {
+		value, _ := hit.Fields[name].(float64)
+		return int64(value)
+	}
+	return &CommitData{
+		RepoID:         numericField("RepoID"),
+		CommitID:       stringField("CommitID"),
+		Message:        stringField("Message"),
+		AuthorName:     stringField("AuthorName"),
+		AuthorEmail:    stringField("AuthorEmail"),
+		AuthorUnix:     timeutil.TimeStamp(numericField("AuthorUnix")),
+		CommitterName:  stringField("CommitterName"),
+		CommitterEmail: stringField("CommitterEmail"),
+		CommitterUnix:  timeutil.TimeStamp(numericField("CommitterUnix")),
+	}
+}
@@ -0,0 +1,389 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package commits
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/olivere/elastic/v7"
+)
+
+const esCommitIndexerLatestVersion = 1
+
+var _ Indexer = &ElasticSearchIndexer{}
+
+// ElasticSearchIndexer implements Indexer interface
+type ElasticSearchIndexer struct {
+	client               *elastic.Client
+	indexerAliasName     string
+	available            bool
+	availabilityCallback func(bool)
+	stopTimer            chan struct{}
+	lock                 sync.RWMutex
+}
+
+type elasticLogger struct {
+	log.Logger
+}
+
+func (l elasticLogger) Printf(format string, args ...interface{}) {
+	_ = l.Logger.Log(2, l.Logger.GetLevel(), format, args...)
+}
+
+// NewElasticSearchIndexer creates a new elasticsearch indexer
+func NewElasticSearchIndexer(url, indexerName string) (*ElasticSearchIndexer, bool, error) {
+	opts := []elastic.ClientOptionFunc{
+		elastic.SetURL(url),
+		elastic.SetSniff(false),
+		elastic.SetHealthcheckInterval(10 * time.Second),
+		elastic.SetGzip(false),
+	}
+
+	logger := elasticLogger{log.GetLogger(log.DEFAULT)}
+
+	if logger.GetLevel() == log.TRACE || logger.GetLevel() == log.DEBUG {
+		opts = append(opts, elastic.SetTraceLog(logger))
+	} else if logger.GetLevel() == log.ERROR || logger.GetLevel() == log.CRITICAL || logger.GetLevel() == log.FATAL {
+		opts = append(opts, elastic.SetErrorLog(logger))
+	} else if logger.GetLevel() == log.INFO || logger.GetLevel() == log.WARN {
+		opts = append(opts, elastic.SetInfoLog(logger))
+	}
+
+	client, err := elastic.NewClient(opts...)
+	if err != nil {
+		return nil, false, err
+	}
+
+	indexer := &ElasticSearchIndexer{
+		client:           client,
+		indexerAliasName: indexerName,
+		available:        true,
+		stopTimer:        make(chan struct{}),
+	}
+
+	ticker := time.NewTicker(10 * time.Second)
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				indexer.checkAvailability()
+			case <-indexer.stopTimer:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	exists, err := indexer.init()
+	if err != nil {
+		indexer.Close()
+		return nil, false, err
+	}
+	return indexer, !exists, err
+}
+
+const (
+	defaultMapping = `{
+		"mappings": {
+			"properties": {
+				"repo_id": {
+					"type": "long",
+					"index": true
+				},
+				"commit_id": {
+					"type": "keyword",
+					"index": true
+				},
+				"message": {
+					"type": "text",
+					"index": true
+				},
+				"author_name": {
+					"type": "text",
+					"index": true
+				},
+				"author_email": {
+					"type": "text",
+					"index": true
+				},
+				"author_unix": {
+					"type": "long",
+					"index": true
+				},
+				"committer_name": {
+					"type": "text",
+					"index": true
+				},
+				"committer_email": {
+					"type": "text",
+					"index": true
+				},
+				"committer_unix": {
+					"type": "long",
+					"index": true
+				}
+			}
+		}
+	}`
+)
+
+// esCommitData is the document of a commit in elasticsearch
+type esCommitData struct {
+	RepoID         int64  `json:"repo_id"`
+	CommitID       string `json:"commit_id"`
+	Message        string `json:"message"`
+	AuthorName     string `json:"author_name"`
+	AuthorEmail    string `json:"author_email"`
+	AuthorUnix     int64  `json:"author_unix"`
+	CommitterName  string `json:"committer_name"`
+	CommitterEmail string `json:"committer_email"`
+	CommitterUnix  int64  `json:"committer_unix"`
+}
+
+func (b *ElasticSearchIndexer) realIndexerName() string {
+	return fmt.Sprintf("%s.v%d", b.indexerAliasName, esCommitIndexerLatestVersion)
+}
+
+// Init will initialize the indexer
+func (b *ElasticSearchIndexer) init() (bool, error) {
+	ctx := graceful.GetManager().HammerContext()
+	exists, err := b.client.IndexExists(b.realIndexerName()).Do(ctx)
+	if err != nil {
+		return false, b.checkError(err)
+	}
+	if !exists {
+		mapping := defaultMapping
+
+		createIndex, err := b.client.CreateIndex(b.realIndexerName()).BodyString(mapping).Do(ctx)
+		if err != nil {
+			return false, b.checkError(err)
+		}
+		if !createIndex.Acknowledged {
+			return false, fmt.Errorf("create index %s with %s failed", b.realIndexerName(), mapping)
+		}
+	}
+
+	// check version
+	r, err := b.client.Aliases().Do(ctx)
+	if err != nil {
+		return false, b.checkError(err)
+	}
+
+	realIndexerNames := r.IndicesByAlias(b.indexerAliasName)
+	if len(realIndexerNames) < 1 {
+		res, err := b.client.Alias().
+			Add(b.realIndexerName(), b.indexerAliasName).
+			Do(ctx)
+		if err != nil {
+			return false, b.checkError(err)
+		}
+		if !res.Acknowledged {
+			return false, fmt.Errorf("create alias %s to index %s failed", b.indexerAliasName, b.realIndexerName())
+		}
+	} else if len(realIndexerNames) >= 1 && realIndexerNames[0] < b.realIndexerName() {
+		log.Warn("Found older gitea indexer named %s, but we will create a new one %s and keep the old NOT DELETED. You can delete the old version after the upgrade succeed.",
+			realIndexerNames[0], b.realIndexerName())
+		res, err := b.client.Alias().
+			Remove(realIndexerNames[0], b.indexerAliasName).
+			Add(b.realIndexerName(), b.indexerAliasName).
+			Do(ctx)
+		if err != nil {
+			return false, b.checkError(err)
+		}
+		if !res.Acknowledged {
+			return false, fmt.Errorf("change alias %s to index %s failed", b.indexerAliasName, b.realIndexerName())
+		}
+	}
+
+	return exists, nil
+}
+
+// SetAvailabilityChangeCallback sets callback that will be triggered when availability changes
+func (b *ElasticSearchIndexer) SetAvailabilityChangeCallback(callback func(bool)) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.availabilityCallback = callback
+}
+
+// Ping checks if elastic is available
+func (b *ElasticSearchIndexer) Ping() bool {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return b.available
+}
+
+// Index adds the commits of a repository to the indexer
+func (b *ElasticSearchIndexer) Index(ctx context.Context, repoID int64, commits []*CommitData) error {
+	if len(commits) == 0 {
+		return nil
+	}
+
+	reqs := make([]elastic.BulkableRequest, 0, len(commits))
+	for _, commit := range commits {
+		reqs = append(reqs, elastic.NewBulkIndexRequest().
+			Index(b.indexerAliasName).
+			Id(indexerID(repoID, commit.CommitID)).
+			Doc(&esCommitData{
+				RepoID:         repoID,
+				CommitID:       commit.CommitID,
+				Message:        commit.Message,
+				AuthorName:     commit.AuthorName,
+				AuthorEmail:    commit.AuthorEmail,
+				AuthorUnix:     int64(commit.AuthorUnix),
+				CommitterName:  commit.CommitterName,
+				CommitterEmail: commit.CommitterEmail,
+				CommitterUnix:  int64(commit.CommitterUnix),
+			}))
+	}
+
+	_, err := b.client.Bulk().
+		Index(b.indexerAliasName).
+		Add(reqs...).
+		Do(ctx)
+	return b.checkError(err)
+}
+
+// Delete deletes the commits of a repository from the indexer
+func (b *ElasticSearchIndexer) Delete(repoID int64) error {
+	_, err := b.client.DeleteByQuery(b.indexerAliasName).
+		Query(elastic.NewTermsQuery("repo_id", repoID)).
+		Do(graceful.GetManager().HammerContext())
+	return b.checkError(err)
+}
+
+// esIdentityQuery a query matching one of the values in the name or the email fields of an identity
+func esIdentityQuery(values []string, nameField, emailField string) elastic.Query {
+	q := elastic.NewBoolQuery().MinimumNumberShouldMatch(1)
+	for _, value := range values {
+		q.Should(elastic.NewMatchPhraseQuery(nameField, value), elastic.NewMatchPhraseQuery(emailField, value))
+	}
+	return q
+}
+
+// Search searches for commits by the message, the author, the committer and the commit time
+func (b *ElasticSearchIndexer) Search(ctx context.Context, opts *SearchOptions) (int64, []*CommitData, error) {
+	query := elastic.NewBoolQuery()
+	if len(opts.RepoIDs) > 0 {
+		repoIDs := make([]interface{}, 0, len(opts.RepoIDs))
+		for _, repoID := range opts.RepoIDs {
+			repoIDs = append(repoIDs, repoID)
+		}
+		query.Filter(elastic.NewTermsQuery("repo_id", repoIDs...))
+	}
+	if len(opts.Keywords) > 0 {
+		keywordQuery := elastic.NewBoolQuery().MinimumNumberShouldMatch(1)
+		for _, keyword := range opts.Keywords {
+			keywordQuery.Should(elastic.NewMatchPhraseQuery("message", keyword))
+		}
+		query.Must(keywordQuery)
+	}
+	if len(opts.Authors) > 0 {
+		query.Filter(esIdentityQuery(opts.Authors, "author_name", "author_email"))
+	}
+	if len(opts.Committers) > 0 {
+		query.Filter(esIdentityQuery(opts.Committers, "committer_name", "committer_email"))
+	}
+	if opts.After > 0 || opts.Before > 0 {
+		rangeQuery := elastic.NewRangeQuery("committer_unix")
+		if opts.After > 0 {
+			rangeQuery.Gte(int64(opts.After))
+		}
+		if opts.Before > 0 {
+			rangeQuery.Lte(int64(opts.Before))
+		}
+		query.Filter(rangeQuery)
+	}
+
+	start := (opts.Page - 1) * opts.PageSize
+	searchResult, err := b.client.Search().
+		Index(b.indexerAliasName).
+		Query(query).
+		Sort("committer_unix", false).
+		Sort("commit_id", true).
+		From(start).Size(opts.PageSize).
+		Do(ctx)
+	if err != nil {
+		return 0, nil, b.checkError(err)
+	}
+
+	commits := make([]*CommitData, 0, len(searchResult.Hits.Hits))
+	for _, hit := range searchResult.Hits.Hits {
+		var data esCommitData
+		if err := json.Unmarshal(hit.Source, &data); err != nil {
+			return 0, nil, err
+		}
+		commits = append(commits, &CommitData{
+			RepoID:         data.RepoID,
+			CommitID:       data.CommitID,
+			Message:        data.Message,
+			AuthorName:     data.AuthorName,
+			AuthorEmail:    data.AuthorEmail,
+			AuthorUnix:     timeutil.TimeStamp(data.AuthorUnix),
+			CommitterName:  data.CommitterName,
+			CommitterEmail: data.CommitterEmail,
+			CommitterUnix:  timeutil.TimeStamp(data.CommitterUnix),
+		})
+	}
+	return searchResult.TotalHits(), commits, nil
+}
+
+// Close implements indexer
+func (b *ElasticSearchIndexer) Close() {
+	select {
+	case <-b.stopTimer:
+	default:
+		close(b.stopTimer)
+	}
+}
+
+func (b *ElasticSearchIndexer) checkError(err error) error {
+	var opErr *net.OpError
+	if !(elastic.IsConnErr(err) || (errors.As(err, &opErr) && (opErr.Op == "dial" || opErr.Op == "read"))) {
+		return err
+	}
+
+	b.setAvailability(false)
+
+	return err
+}
+
+func (b *ElasticSearchIndexer) checkAvailability() {
+	if b.Ping() {
+		return
+	}
+
+	// Request cluster state to check if elastic is available again
+	_, err := b.client.ClusterState().Do(graceful.GetManager().ShutdownContext())
+	if err != nil {
+		b.setAvailability(false)
+		return
+	}
+
+	b.setAvailability(true)
+}
+
+func (b *ElasticSearchIndexer) setAvailability(available bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.available == available {
+		return
+	}
+
+	b.available = available
+	if b.availabilityCallback != nil {
+		// Call the callback from within the lock to ensure that the ordering remains correct
+		b.availabilityCallback(b.available)
+	}
+}
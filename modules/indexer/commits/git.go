@@ -0,0 +1,124 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package commits
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// commitBatchSize is the number of commits which are indexed at once
+const commitBatchSize = 100
+
+// commitLogFormat prints the fields of a commit on separate lines followed by the raw message,
+// the commits are separated by NUL
+const commitLogFormat = "--format=%H%n%an%n%ae%n%at%n%cn%n%ce%n%ct%n%B"
+
+func getDefaultBranchSha(ctx context.Context, repo *repo_model.Repository) (string, error) {
+	stdout, _, err := git.NewCommand(ctx, "show-ref", "-s").AddDynamicArguments(git.BranchPrefix + repo.DefaultBranch).RunStdString(&git.RunOpts{Dir: repo.RepoPath()})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(stdout), nil
+}
+
+// isAncestor returns whether the commit is an ancestor of the other commit, it is false if the commit doesn't exist anymore
+func isAncestor(ctx context.Context, repo *repo_model.Repository, ancestor, commit string) bool {
+	err := git.NewCommand(ctx, "merge-base", "--is-ancestor").AddDynamicArguments(ancestor, commit).Run(&git.RunOpts{Dir: repo.RepoPath()})
+	return err == nil
+}
+
+// parseCommit parses the output of commitLogFormat for a commit
+func parseCommit(repoID int64, record string) (*CommitData, error) {
+	fields := strings.SplitN(record, "\n", 8)
+	if len(fields) < 7 {
+		return nil, fmt.Errorf("unexpected commit log format: %q", record)
+	}
+	authorUnix, err := strconv.ParseInt(fields[3], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	committerUnix, err := strconv.ParseInt(fields[6], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	commit := &CommitData{
+		RepoID:         repoID,
+		CommitID:       fields[0],
+		AuthorName:     fields[1],
+		AuthorEmail:    fields[2],
+		AuthorUnix:     timeutil.TimeStamp(authorUnix),
+		CommitterName:  fields[4],
+		CommitterEmail: fields[5],
+		CommitterUnix:  timeutil.TimeStamp(committerUnix),
+	}
+	if len(fields) == 8 {
+		commit.Message = strings.TrimRight(fields[7], "\n")
+	}
+	return commit, nil
+}
+
+// readCommits reads the commits of the revisions and passes them in batches to the handler
+func readCommits(ctx context.Context, repo *repo_model.Repository, revisions string, handle func([]*CommitData) error) error {
+	stdoutReader, stdoutWriter, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = stdoutReader.Close()
+		_ = stdoutWriter.Close()
+	}()
+
+	stderr := new(strings.Builder)
+	err = git.NewCommand(ctx, "log", "-z", commitLogFormat).AddDynamicArguments(revisions).Run(&git.RunOpts{
+		Dir:    repo.RepoPath(),
+		Stdout: stdoutWriter,
+		Stderr: stderr,
+		PipelineFunc: func(ctx context.Context, cancel context.CancelFunc) error {
+			_ = stdoutWriter.Close()
+			defer stdoutReader.Close()
+
+			reader := bufio.NewReader(stdoutReader)
+			batch := make([]*CommitData, 0, commitBatchSize)
+			for {
+				record, err := reader.ReadString('\x00')
+				if err != nil && err != io.EOF {
+					cancel()
+					return err
+				}
+				if record = strings.TrimSuffix(record, "\x00"); len(record) > 0 {
+					commit, parseErr := parseCommit(repo.ID, record)
+					if parseErr != nil {
+						cancel()
+						return parseErr
+					}
+					batch = append(batch, commit)
+				}
+				if len(batch) == commitBatchSize || err == io.EOF && len(batch) > 0 {
+					if err := handle(batch); err != nil {
+						cancel()
+						return err
+					}
+					batch = make([]*CommitData, 0, commitBatchSize)
+				}
+				if err == io.EOF {
+					return nil
+				}
+			}
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to read the commits of %s in %s: %w\nStderr: %s", revisions, repo.FullName(), err, stderr)
+	}
+	return nil
+}
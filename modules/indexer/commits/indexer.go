@@ -0,0 +1,417 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package commits
+
+import (
+	"context"
+	"os"
+	"runtime/pprof"
+	"strconv"
+	"time"
+
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/process"
+	"code.gitea.io/gitea/modules/queue"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// CommitData represents a commit stored in the commit indexer
+type CommitData struct {
+	RepoID         int64
+	CommitID       string
+	Message        string
+	AuthorName     string
+	AuthorEmail    string
+	AuthorUnix     timeutil.TimeStamp
+	CommitterName  string
+	CommitterEmail string
+	CommitterUnix  timeutil.TimeStamp
+}
+
+// SearchOptions represents the options of a commit search, the results are sorted by the commit time, the newest first
+type SearchOptions struct {
+	RepoIDs    []int64            // the repositories to search, all repositories if empty
+	Keywords   []string           // one of them must be contained in the message
+	Authors    []string           // one of them must be contained in the name or the email of the author
+	Committers []string           // one of them must be contained in the name or the email of the committer
+	After      timeutil.TimeStamp // only commits committed at or after this time, 0 for no limit
+	Before     timeutil.TimeStamp // only commits committed at or before this time, 0 for no limit
+	Page       int
+	PageSize   int
+}
+
+// Indexer defines an interface to index and search the commits of the default branches
+type Indexer interface {
+	Ping() bool
+	SetAvailabilityChangeCallback(callback func(bool))
+	Index(ctx context.Context, repoID int64, commits []*CommitData) error
+	Delete(repoID int64) error
+	Search(ctx context.Context, opts *SearchOptions) (int64, []*CommitData, error)
+	Close()
+}
+
+func indexerID(repoID int64, commitID string) string {
+	return strconv.FormatInt(repoID, 36) + "_" + commitID
+}
+
+// IndexerData represents data stored in the commit indexer queue
+type IndexerData struct {
+	RepoID int64
+}
+
+var indexerQueue queue.UniqueQueue
+
+// index indexes the commits of the default branch which have been added since the last update,
+// all commits are indexed again if the history has been rewritten
+func index(ctx context.Context, indexer Indexer, repoID int64) error {
+	repo, err := repo_model.GetRepositoryByID(ctx, repoID)
+	if repo_model.IsErrRepoNotExist(err) {
+		return indexer.Delete(repoID)
+	}
+	if err != nil {
+		return err
+	}
+	if repo.IsEmpty {
+		return nil
+	}
+
+	sha, err := getDefaultBranchSha(ctx, repo)
+	if err != nil {
+		return err
+	}
+	status, err := repo_model.GetIndexerStatus(ctx, repo, repo_model.RepoIndexerTypeCommits)
+	if err != nil {
+		return err
+	}
+	if status.CommitSha == sha {
+		return nil
+	}
+
+	revisions := sha
+	if len(status.CommitSha) > 0 {
+		if isAncestor(ctx, repo, status.CommitSha, sha) {
+			revisions = status.CommitSha + ".." + sha
+		} else if err := indexer.Delete(repo.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := readCommits(ctx, repo, revisions, func(commits []*CommitData) error {
+		return indexer.Index(ctx, repo.ID, commits)
+	}); err != nil {
+		return err
+	}
+
+	return repo_model.UpdateIndexerStatus(ctx, repo, repo_model.RepoIndexerTypeCommits, sha)
+}
+
+// Init initialize the commit indexer
+func Init() {
+	if !setting.Indexer.CommitIndexerEnabled {
+		indexer.Close()
+		return
+	}
+
+	ctx, cancel, finished := process.GetManager().AddTypedContext(context.Background(), "Service: CommitIndexer", process.SystemProcessType, false)
+
+	graceful.GetManager().RunAtTerminate(func() {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		cancel()
+		log.Debug("Closing commit indexer")
+		indexer.Close()
+		log.Info("PID: %d Commit Indexer closed", os.Getpid())
+		finished()
+	})
+
+	waitChannel := make(chan time.Duration, 1)
+
+	// Create the Queue
+	switch setting.Indexer.CommitType {
+	case "bleve", "elasticsearch":
+		handler := func(data ...queue.Data) []queue.Data {
+			idx, err := indexer.get()
+			if idx == nil || err != nil {
+				log.Error("Commit indexer handler: unable to get indexer!")
+				return data
+			}
+
+			unhandled := make([]queue.Data, 0, len(data))
+			for _, datum := range data {
+				indexerData, ok := datum.(*IndexerData)
+				if !ok {
+					log.Error("Unable to process provided datum: %v - not possible to cast to IndexerData", datum)
+					continue
+				}
+				log.Trace("IndexerData Process Repo: %d", indexerData.RepoID)
+
+				if err := index(ctx, indexer, indexerData.RepoID); err != nil {
+					log.Error("index: %v", err)
+					if indexer.Ping() {
+						continue
+					}
+					// Add back to queue
+					unhandled = append(unhandled, datum)
+				}
+			}
+			return unhandled
+		}
+
+		indexerQueue = queue.CreateUniqueQueue("commit_indexer", handler, &IndexerData{})
+		if indexerQueue == nil {
+			log.Fatal("Unable to create commit indexer queue")
+		}
+	default:
+		log.Fatal("Unknown commit indexer type; %s", setting.Indexer.CommitType)
+	}
+
+	go func() {
+		pprof.SetGoroutineLabels(ctx)
+		start := time.Now()
+		var (
+			cIndexer Indexer
+			populate bool
+			err      error
+		)
+		switch setting.Indexer.CommitType {
+		case "bleve":
+			log.Info("PID: %d Initializing Commit Indexer at: %s", os.Getpid(), setting.Indexer.CommitPath)
+			defer func() {
+				if err := recover(); err != nil {
+					log.Error("PANIC whilst initializing commit indexer: %v\nStacktrace: %s", err, log.Stack(2))
+					log.Error("The indexer files are likely corrupted and may need to be deleted")
+					log.Error("You can completely remove the \"%s\" directory to make Gitea recreate the indexes", setting.Indexer.CommitPath)
+				}
+			}()
+
+			cIndexer, populate, err = NewBleveIndexer(setting.Indexer.CommitPath)
+			if err != nil {
+				cancel()
+				indexer.Close()
+				close(waitChannel)
+				log.Fatal("PID: %d Unable to initialize the bleve Commit Indexer at path: %s Error: %v", os.Getpid(), setting.Indexer.CommitPath, err)
+			}
+		case "elasticsearch":
+			log.Info("PID: %d Initializing Commit Indexer at: %s", os.Getpid(), setting.Indexer.CommitConnStr)
+			defer func() {
+				if err := recover(); err != nil {
+					log.Error("PANIC whilst initializing commit indexer: %v\nStacktrace: %s", err, log.Stack(2))
+					log.Error("The indexer files are likely corrupted and may need to be deleted")
+					log.Error("You can completely remove the \"%s\" index to make Gitea recreate the indexes", setting.Indexer.CommitConnStr)
+				}
+			}()
+
+			cIndexer, populate, err = NewElasticSearchIndexer(setting.Indexer.CommitConnStr, setting.Indexer.CommitIndexerName)
+			if err != nil {
+				cancel()
+				indexer.Close()
+				close(waitChannel)
+				log.Fatal("PID: %d Unable to initialize the elasticsearch Commit Indexer connstr: %s Error: %v", os.Getpid(), setting.Indexer.CommitConnStr, err)
+			}
+		default:
+			log.Fatal("PID: %d Unknown Indexer type: %s", os.Getpid(), setting.Indexer.CommitType)
+		}
+
+		indexer.set(cIndexer)
+
+		if queue, ok := indexerQueue.(queue.Pausable); ok {
+			cIndexer.SetAvailabilityChangeCallback(func(available bool) {
+				if !available {
+					log.Info("Commit index queue paused")
+					queue.Pause()
+				} else {
+					log.Info("Commit index queue resumed")
+					queue.Resume()
+				}
+			})
+		}
+
+		// Start processing the queue
+		go graceful.GetManager().RunWithShutdownFns(indexerQueue.Run)
+
+		if populate {
+			go graceful.GetManager().RunWithShutdownContext(populateCommitIndexer)
+		}
+		select {
+		case waitChannel <- time.Since(start):
+		case <-graceful.GetManager().IsShutdown():
+		}
+
+		close(waitChannel)
+	}()
+
+	if setting.Indexer.StartupTimeout > 0 {
+		go func() {
+			pprof.SetGoroutineLabels(ctx)
+			timeout := setting.Indexer.StartupTimeout
+			if graceful.GetManager().IsChild() && setting.GracefulHammerTime > 0 {
+				timeout += setting.GracefulHammerTime
+			}
+			select {
+			case <-graceful.GetManager().IsShutdown():
+				log.Warn("Shutdown before Commit Indexer completed initialization")
+				cancel()
+				indexer.Close()
+			case duration, ok := <-waitChannel:
+				if !ok {
+					log.Warn("Commit Indexer Initialization failed")
+					cancel()
+					indexer.Close()
+					return
+				}
+				log.Info("Commit Indexer Initialization took %v", duration)
+			case <-time.After(timeout):
+				cancel()
+				indexer.Close()
+				log.Fatal("Commit Indexer Initialization Timed-Out after: %v", timeout)
+			}
+		}()
+	}
+}
+
+// UpdateRepoIndexer adds the new commits of a repository to the indexer, the commits of a deleted repository are removed
+func UpdateRepoIndexer(repo *repo_model.Repository) {
+	indexData := &IndexerData{RepoID: repo.ID}
+	if err := indexerQueue.Push(indexData); err != nil {
+		log.Error("Update commit index data %v failed: %v", indexData, err)
+	}
+}
+
+// IsAvailable checks if the commit indexer is available
+func IsAvailable() bool {
+	idx, err := indexer.get()
+	if err != nil {
+		log.Error("IsAvailable(): unable to get indexer: %v", err)
+		return false
+	}
+
+	return idx.Ping()
+}
+
+// IsIndexed returns whether the commits of the default branch of the repository have been indexed,
+// the indexer may not contain the latest commits yet
+func IsIndexed(ctx context.Context, repo *repo_model.Repository) (bool, error) {
+	if !setting.Indexer.CommitIndexerEnabled || repo.IsEmpty {
+		return false, nil
+	}
+	status, err := repo_model.GetIndexerStatus(ctx, repo, repo_model.RepoIndexerTypeCommits)
+	if err != nil {
+		return false, err
+	}
+	return len(status.CommitSha) > 0, nil
+}
+
+// Search searches the indexed commits
+func Search(ctx context.Context, opts *SearchOptions) (int64, []*CommitData, error) {
+	if opts.PageSize <= 0 {
+		opts.PageSize = setting.UI.RepoSearchPagingNum
+	}
+	if opts.Page <= 0 {
+		opts.Page = 1
+	}
+	return indexer.Search(ctx, opts)
+}
+
+// ParseSearchOptions parses a search string in the format of the git commit search, see git.NewSearchCommitsOptions.
+// The dates of `after:` and `before:` must have the format YYYY-MM-DD or RFC 3339, otherwise false is returned.
+func ParseSearchOptions(searchString string) (*SearchOptions, bool) {
+	gitOpts := git.NewSearchCommitsOptions(searchString, false)
+	opts := &SearchOptions{
+		Keywords:   gitOpts.Keywords,
+		Authors:    gitOpts.Authors,
+		Committers: gitOpts.Committers,
+	}
+
+	var ok bool
+	if len(gitOpts.After) > 0 {
+		if opts.After, ok = parseDate(gitOpts.After); !ok {
+			return nil, false
+		}
+	}
+	if len(gitOpts.Before) > 0 {
+		if opts.Before, ok = parseDate(gitOpts.Before); !ok {
+			return nil, false
+		}
+		if len(gitOpts.Before) == len("2006-01-02") {
+			// the whole day is included
+			opts.Before = timeutil.TimeStamp(opts.Before.AsTime().AddDate(0, 0, 1).Unix() - 1)
+		}
+	}
+	return opts, true
+}
+
+func parseDate(value string) (timeutil.TimeStamp, bool) {
+	if t, err := time.ParseInLocation("2006-01-02", value, setting.DefaultUILocation); err == nil {
+		return timeutil.TimeStamp(t.Unix()), true
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return timeutil.TimeStamp(t.Unix()), true
+	}
+	return 0, false
+}
+
+// populateCommitIndexer populate the commit indexer with pre-existing data. This
+// should only be run when the indexer is created for the first time.
+func populateCommitIndexer(ctx context.Context) {
+	log.Info("Populating the commit indexer with existing repositories")
+
+	exist, err := db.IsTableNotEmpty("repository")
+	if err != nil {
+		log.Fatal("System error: %v", err)
+	} else if !exist {
+		return
+	}
+
+	// if there is any existing commit indexer metadata in the DB, delete it
+	// since we are starting afresh.
+	if err := repo_model.DeleteIndexerStatuses(ctx, repo_model.RepoIndexerTypeCommits); err != nil {
+		log.Fatal("System error: %v", err)
+	}
+
+	var maxRepoID int64
+	if maxRepoID, err = db.GetMaxID("repository"); err != nil {
+		log.Fatal("System error: %v", err)
+	}
+
+	// start with the maximum existing repo ID and work backwards, so that we
+	// don't include repos that are created after gitea starts; such repos will
+	// already be added to the indexer, and we don't need to add them again.
+	for maxRepoID > 0 {
+		select {
+		case <-ctx.Done():
+			log.Info("Commit Indexer population shutdown before completion")
+			return
+		default:
+		}
+		ids, err := repo_model.GetUnindexedRepos(repo_model.RepoIndexerTypeCommits, maxRepoID, 0, 50)
+		if err != nil {
+			log.Error("populateCommitIndexer: %v", err)
+			return
+		} else if len(ids) == 0 {
+			break
+		}
+		for _, id := range ids {
+			select {
+			case <-ctx.Done():
+				log.Info("Commit Indexer population shutdown before completion")
+				return
+			default:
+			}
+			if err := indexerQueue.Push(&IndexerData{RepoID: id}); err != nil {
+				log.Error("indexerQueue.Push: %v", err)
+				return
+			}
+			maxRepoID = id - 1
+		}
+	}
+	log.Info("Done (re)populating the commit indexer with existing repositories")
+}
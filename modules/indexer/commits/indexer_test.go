@@ -0,0 +1,148 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package commits
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	_ "code.gitea.io/gitea/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	unittest.MainTest(m, &unittest.TestOptions{
+		GiteaRootPath: filepath.Join("..", "..", ".."),
+	})
+}
+
+func testIndexer(name string, t *testing.T, indexer Indexer) {
+	t.Run(name, func(t *testing.T) {
+		// user2/commits_search_test
+		var repoID int64 = 36
+		assert.NoError(t, index(git.DefaultContext, indexer, repoID))
+		assert.NoError(t, index(git.DefaultContext, indexer, 1))
+
+		repo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: repoID})
+		status, err := repo_model.GetIndexerStatus(db.DefaultContext, repo, repo_model.RepoIndexerTypeCommits)
+		assert.NoError(t, err)
+		assert.EqualValues(t, "9800fe78cabf4fe774fcf376f97fa2a0ed06987b", status.CommitSha)
+
+		kases := []struct {
+			Opts     SearchOptions
+			Messages []string
+		}{
+			{
+				Opts:     SearchOptions{RepoIDs: []int64{repoID}},
+				Messages: []string{"commit-5", "commit-4", "commit-3", "commit-2", "commit-1"},
+			},
+			{
+				Opts:     SearchOptions{Keywords: []string{"commit-3"}},
+				Messages: []string{"commit-3"},
+			},
+			{
+				Opts:     SearchOptions{Keywords: []string{"Initial"}},
+				Messages: []string{"Initial commit"},
+			},
+			{
+				Opts:     SearchOptions{Keywords: []string{"commit-1", "commit-2"}},
+				Messages: []string{"commit-2", "commit-1"},
+			},
+			{
+				Opts:     SearchOptions{RepoIDs: []int64{repoID}, Keywords: []string{"Initial"}},
+				Messages: []string{},
+			},
+			{
+				Opts:     SearchOptions{Authors: []string{"Bob"}},
+				Messages: []string{"commit-4", "commit-2"},
+			},
+			{
+				Opts:     SearchOptions{Authors: []string{"alice@example.com", "Tom"}},
+				Messages: []string{"commit-3", "commit-1"},
+			},
+			{
+				Opts:     SearchOptions{Committers: []string{"Alice"}},
+				Messages: []string{"commit-3", "commit-1"},
+			},
+			{
+				Opts:     SearchOptions{Authors: []string{"Tom"}, Committers: []string{"Alice"}},
+				Messages: []string{"commit-3"},
+			},
+			{
+				Opts:     SearchOptions{RepoIDs: []int64{repoID}, After: 1551586332, Before: 1551672732},
+				Messages: []string{"commit-4", "commit-3"},
+			},
+			{
+				Opts:     SearchOptions{RepoIDs: []int64{repoID}, Page: 2, PageSize: 2},
+				Messages: []string{"commit-3", "commit-2"},
+			},
+		}
+
+		for _, kase := range kases {
+			opts := kase.Opts
+			if opts.Page == 0 {
+				opts.Page, opts.PageSize = 1, 10
+			}
+			_, commits, err := indexer.Search(db.DefaultContext, &opts)
+			if assert.NoError(t, err) {
+				messages := make([]string, 0, len(commits))
+				for _, commit := range commits {
+					messages = append(messages, commit.Message)
+				}
+				assert.EqualValues(t, kase.Messages, messages, "%+v", kase.Opts)
+			}
+		}
+
+		total, commits, err := indexer.Search(db.DefaultContext, &SearchOptions{Keywords: []string{"commit-2"}, Page: 1, PageSize: 10})
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, total)
+		if assert.Len(t, commits, 1) {
+			assert.Equal(t, &CommitData{
+				RepoID:         repoID,
+				CommitID:       "c20caf78b5f9dd2d0d183876c5cd0e761c13f7f8",
+				Message:        "commit-2",
+				AuthorName:     "Bob",
+				AuthorEmail:    "Bob@example.com",
+				AuthorUnix:     1551499932,
+				CommitterName:  "Bob",
+				CommitterEmail: "Bob@example.com",
+				CommitterUnix:  1551499932,
+			}, commits[0])
+		}
+
+		assert.NoError(t, indexer.Delete(repoID))
+		total, _, err = indexer.Search(db.DefaultContext, &SearchOptions{RepoIDs: []int64{repoID}, Page: 1, PageSize: 10})
+		assert.NoError(t, err)
+		assert.EqualValues(t, 0, total)
+	})
+}
+
+func TestParseSearchOptions(t *testing.T) {
+	opts, ok := ParseSearchOptions("ABC-123 fix author:bob committer:alice@example.com after:2019-03-02 before:2019-03-03")
+	assert.True(t, ok)
+	after := time.Date(2019, 3, 2, 0, 0, 0, 0, setting.DefaultUILocation)
+	assert.Equal(t, &SearchOptions{
+		Keywords:   []string{"ABC-123", "fix"},
+		Authors:    []string{"bob"},
+		Committers: []string{"alice@example.com"},
+		After:      timeutil.TimeStamp(after.Unix()),
+		Before:     timeutil.TimeStamp(after.AddDate(0, 0, 2).Unix() - 1),
+	}, opts)
+
+	opts, ok = ParseSearchOptions("after:2019-03-02T10:00:00Z")
+	assert.True(t, ok)
+	assert.EqualValues(t, 1551520800, opts.After)
+
+	_, ok = ParseSearchOptions("fix after:yesterday")
+	assert.False(t, ok)
+}
@@ -15,6 +15,7 @@ import (
 
 	"github.com/blevesearch/bleve/v2"
 	"github.com/blevesearch/bleve/v2/analysis/analyzer/custom"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
 	"github.com/blevesearch/bleve/v2/analysis/token/lowercase"
 	"github.com/blevesearch/bleve/v2/analysis/token/unicodenorm"
 	"github.com/blevesearch/bleve/v2/analysis/tokenizer/unicode"
@@ -27,7 +28,10 @@ import (
 const (
 	issueIndexerAnalyzer      = "issueIndexer"
 	issueIndexerDocType       = "issueIndexerDocType"
-	issueIndexerLatestVersion = 1
+	issueIndexerLatestVersion = 2
+
+	// maxFacetTerms is the maximum number of labels and milestones which are counted
+	maxFacetTerms = 1000
 )
 
 // indexerID a bleve-compatible unique identifier for an integer id
@@ -44,11 +48,41 @@ func idOfIndexerID(indexerID string) (int64, error) {
 	return id, nil
 }
 
-// numericEqualityQuery a numeric equality query for the given value and field
-func numericEqualityQuery(value int64, field string) *query.NumericRangeQuery {
-	f := float64(value)
+// keywordQuery an exact match query for the given id and keyword field
+func keywordQuery(id int64, field string) *query.TermQuery {
+	q := bleve.NewTermQuery(strconv.FormatInt(id, 10))
+	q.SetField(field)
+	return q
+}
+
+// keywordsQuery a query matching one of the ids in the keyword field
+func keywordsQuery(ids []int64, field string) query.Query {
+	queries := make([]query.Query, 0, len(ids))
+	for _, id := range ids {
+		queries = append(queries, keywordQuery(id, field))
+	}
+	return bleve.NewDisjunctionQuery(queries...)
+}
+
+// numericRangeQuery an inclusive numeric range query, an unset bound is 0
+func numericRangeQuery(min, max int64, field string) *query.NumericRangeQuery {
+	var minF, maxF *float64
+	if min != 0 {
+		f := float64(min)
+		minF = &f
+	}
+	if max != 0 {
+		f := float64(max)
+		maxF = &f
+	}
 	tru := true
-	q := bleve.NewNumericRangeInclusiveQuery(&f, &f, &tru, &tru)
+	q := bleve.NewNumericRangeInclusiveQuery(minF, maxF, &tru, &tru)
+	q.SetField(field)
+	return q
+}
+
+func boolQuery(value bool, field string) *query.BoolFieldQuery {
+	q := bleve.NewBoolFieldQuery(value)
 	q.SetField(field)
 	return q
 }
@@ -104,22 +138,31 @@ func openIndexer(path string, latestVersion int) (bleve.Index, error) {
 	return index, nil
 }
 
-// BleveIndexerData an update to the issue indexer
-type BleveIndexerData IndexerData
-
-// Type returns the document type, for bleve's mapping.Classifier interface.
-func (i *BleveIndexerData) Type() string {
-	return issueIndexerDocType
-}
-
 // createIssueIndexer create an issue indexer if one does not already exist
 func createIssueIndexer(path string, latestVersion int) (bleve.Index, error) {
 	mapping := bleve.NewIndexMapping()
 	docMapping := bleve.NewDocumentMapping()
 
+	keywordFieldMapping := bleve.NewTextFieldMapping()
+	keywordFieldMapping.Analyzer = keyword.Name
+	keywordFieldMapping.Store = false
+	keywordFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("RepoID", keywordFieldMapping)
+	docMapping.AddFieldMappingsAt("LabelIDs", keywordFieldMapping)
+	docMapping.AddFieldMappingsAt("MilestoneID", keywordFieldMapping)
+	docMapping.AddFieldMappingsAt("PosterID", keywordFieldMapping)
+	docMapping.AddFieldMappingsAt("AssigneeIDs", keywordFieldMapping)
+
+	boolFieldMapping := bleve.NewBooleanFieldMapping()
+	boolFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("IsPull", boolFieldMapping)
+	docMapping.AddFieldMappingsAt("IsClosed", boolFieldMapping)
+
 	numericFieldMapping := bleve.NewNumericFieldMapping()
 	numericFieldMapping.IncludeInAll = false
-	docMapping.AddFieldMappingsAt("RepoID", numericFieldMapping)
+	docMapping.AddFieldMappingsAt("NumComments", numericFieldMapping)
+	docMapping.AddFieldMappingsAt("CreatedUnix", numericFieldMapping)
+	docMapping.AddFieldMappingsAt("UpdatedUnix", numericFieldMapping)
 
 	textFieldMapping := bleve.NewTextFieldMapping()
 	textFieldMapping.Store = false
@@ -204,20 +247,54 @@ func (b *BleveIndexer) Close() {
 	}
 }
 
+// BleveIndexerData is the document stored in the index, the ids are stored as keywords to support facets
+type BleveIndexerData struct {
+	RepoID      string
+	Title       string
+	Content     string
+	Comments    []string
+	IsPull      bool
+	IsClosed    bool
+	LabelIDs    []string
+	MilestoneID string
+	PosterID    string
+	AssigneeIDs []string
+	NumComments int
+	CreatedUnix int64
+	UpdatedUnix int64
+}
+
+// Type returns the document type, for bleve's mapping.Classifier interface.
+func (i *BleveIndexerData) Type() string {
+	return issueIndexerDocType
+}
+
+func int64sToStrings(ids []int64) []string {
+	strs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		strs = append(strs, strconv.FormatInt(id, 10))
+	}
+	return strs
+}
+
 // Index will save the index data
 func (b *BleveIndexer) Index(issues []*IndexerData) error {
 	batch := gitea_bleve.NewFlushingBatch(b.indexer, maxBatchSize)
 	for _, issue := range issues {
-		if err := batch.Index(indexerID(issue.ID), struct {
-			RepoID   int64
-			Title    string
-			Content  string
-			Comments []string
-		}{
-			RepoID:   issue.RepoID,
-			Title:    issue.Title,
-			Content:  issue.Content,
-			Comments: issue.Comments,
+		if err := batch.Index(indexerID(issue.ID), &BleveIndexerData{
+			RepoID:      strconv.FormatInt(issue.RepoID, 10),
+			Title:       issue.Title,
+			Content:     issue.Content,
+			Comments:    issue.Comments,
+			IsPull:      issue.IsPull,
+			IsClosed:    issue.IsClosed,
+			LabelIDs:    int64sToStrings(issue.LabelIDs),
+			MilestoneID: strconv.FormatInt(issue.MilestoneID, 10),
+			PosterID:    strconv.FormatInt(issue.PosterID, 10),
+			AssigneeIDs: int64sToStrings(issue.AssigneeIDs),
+			NumComments: issue.NumComments,
+			CreatedUnix: int64(issue.CreatedUnix),
+			UpdatedUnix: int64(issue.UpdatedUnix),
 		}); err != nil {
 			return err
 		}
@@ -236,27 +313,82 @@ func (b *BleveIndexer) Delete(ids ...int64) error {
 	return batch.Flush()
 }
 
+func (b *BleveIndexer) buildQuery(opts *SearchOptions, withState bool) query.Query {
+	must := make([]query.Query, 0, 10)
+	mustNot := make([]query.Query, 0, 2)
+
+	if len(opts.RepoIDs) > 0 {
+		must = append(must, keywordsQuery(opts.RepoIDs, "RepoID"))
+	}
+	if opts.Keyword != "" {
+		must = append(must, bleve.NewDisjunctionQuery(
+			newMatchPhraseQuery(opts.Keyword, "Title", issueIndexerAnalyzer),
+			newMatchPhraseQuery(opts.Keyword, "Content", issueIndexerAnalyzer),
+			newMatchPhraseQuery(opts.Keyword, "Comments", issueIndexerAnalyzer),
+		))
+	}
+	if !opts.IsPull.IsNone() {
+		must = append(must, boolQuery(opts.IsPull.IsTrue(), "IsPull"))
+	}
+	if withState && !opts.IsClosed.IsNone() {
+		must = append(must, boolQuery(opts.IsClosed.IsTrue(), "IsClosed"))
+	}
+	for _, labelIDs := range opts.IncludedLabelIDs {
+		must = append(must, keywordsQuery(labelIDs, "LabelIDs"))
+	}
+	for _, labelID := range opts.ExcludedLabelIDs {
+		mustNot = append(mustNot, keywordQuery(labelID, "LabelIDs"))
+	}
+	if len(opts.MilestoneIDs) > 0 {
+		must = append(must, keywordsQuery(opts.MilestoneIDs, "MilestoneID"))
+	}
+	if opts.PosterID != 0 {
+		must = append(must, keywordQuery(opts.PosterID, "PosterID"))
+	}
+	if opts.AssigneeID != 0 {
+		must = append(must, keywordQuery(opts.AssigneeID, "AssigneeIDs"))
+	}
+	if opts.CreatedAfter != 0 || opts.CreatedBefore != 0 {
+		must = append(must, numericRangeQuery(int64(opts.CreatedAfter), int64(opts.CreatedBefore), "CreatedUnix"))
+	}
+	if opts.UpdatedAfter != 0 || opts.UpdatedBefore != 0 {
+		must = append(must, numericRangeQuery(int64(opts.UpdatedAfter), int64(opts.UpdatedBefore), "UpdatedUnix"))
+	}
+
+	if len(must) == 0 {
+		must = append(must, bleve.NewMatchAllQuery())
+	}
+	q := bleve.NewBooleanQuery()
+	q.AddMust(must...)
+	q.AddMustNot(mustNot...)
+	return q
+}
+
 // Search searches for issues by given conditions.
 // Returns the matching issue IDs
-func (b *BleveIndexer) Search(ctx context.Context, keyword string, repoIDs []int64, limit, start int) (*SearchResult, error) {
-	var repoQueriesP []*query.NumericRangeQuery
-	for _, repoID := range repoIDs {
-		repoQueriesP = append(repoQueriesP, numericEqualityQuery(repoID, "RepoID"))
-	}
-	repoQueries := make([]query.Query, len(repoQueriesP))
-	for i, v := range repoQueriesP {
-		repoQueries[i] = query.Query(v)
-	}
-
-	indexerQuery := bleve.NewConjunctionQuery(
-		bleve.NewDisjunctionQuery(repoQueries...),
-		bleve.NewDisjunctionQuery(
-			newMatchPhraseQuery(keyword, "Title", issueIndexerAnalyzer),
-			newMatchPhraseQuery(keyword, "Content", issueIndexerAnalyzer),
-			newMatchPhraseQuery(keyword, "Comments", issueIndexerAnalyzer),
-		))
-	search := bleve.NewSearchRequestOptions(indexerQuery, limit, start, false)
-	search.SortBy([]string{"-_score"})
+func (b *BleveIndexer) Search(ctx context.Context, opts *SearchOptions) (*SearchResult, error) {
+	search := bleve.NewSearchRequestOptions(b.buildQuery(opts, true), opts.Limit, opts.Start, false)
+	switch opts.SortBy {
+	case SortByCreatedDesc:
+		search.SortBy([]string{"-CreatedUnix", "-_id"})
+	case SortByCreatedAsc:
+		search.SortBy([]string{"CreatedUnix", "_id"})
+	case SortByUpdatedDesc:
+		search.SortBy([]string{"-UpdatedUnix", "-_id"})
+	case SortByUpdatedAsc:
+		search.SortBy([]string{"UpdatedUnix", "_id"})
+	case SortByCommentsDesc:
+		search.SortBy([]string{"-NumComments", "-CreatedUnix"})
+	case SortByCommentsAsc:
+		search.SortBy([]string{"NumComments", "-CreatedUnix"})
+	default:
+		search.SortBy([]string{"-_score", "-UpdatedUnix"})
+	}
+	if opts.Facets {
+		search.AddFacet("RepoID", bleve.NewFacetRequest("RepoID", len(opts.RepoIDs)+1))
+		search.AddFacet("LabelIDs", bleve.NewFacetRequest("LabelIDs", maxFacetTerms))
+		search.AddFacet("MilestoneID", bleve.NewFacetRequest("MilestoneID", maxFacetTerms))
+	}
 
 	result, err := b.indexer.SearchInContext(ctx, search)
 	if err != nil {
@@ -264,7 +396,8 @@ func (b *BleveIndexer) Search(ctx context.Context, keyword string, repoIDs []int
 	}
 
 	ret := SearchResult{
-		Hits: make([]Match, 0, len(result.Hits)),
+		Total: int64(result.Total),
+		Hits:  make([]Match, 0, len(result.Hits)),
 	}
 	for _, hit := range result.Hits {
 		id, err := idOfIndexerID(hit.ID)
@@ -272,8 +405,51 @@ func (b *BleveIndexer) Search(ctx context.Context, keyword string, repoIDs []int
 			return nil, err
 		}
 		ret.Hits = append(ret.Hits, Match{
-			ID: id,
+			ID:    id,
+			Score: hit.Score,
 		})
 	}
+
+	if opts.Facets {
+		ret.Facets = newFacets()
+		for name, m := range map[string]map[int64]int64{
+			"RepoID":      ret.Facets.Repos,
+			"LabelIDs":    ret.Facets.Labels,
+			"MilestoneID": ret.Facets.Milestones,
+		} {
+			for _, term := range result.Facets[name].Terms.Terms() {
+				id, err := strconv.ParseInt(term.Term, 10, 64)
+				if err != nil || id == 0 {
+					continue
+				}
+				m[id] = int64(term.Count)
+			}
+		}
+
+		if err := b.countStates(ctx, opts, ret.Facets); err != nil {
+			return nil, err
+		}
+	}
 	return &ret, nil
 }
+
+// countStates counts the open and closed issues matching the options without the state filter
+func (b *BleveIndexer) countStates(ctx context.Context, opts *SearchOptions, facets *Facets) error {
+	search := bleve.NewSearchRequestOptions(b.buildQuery(opts, false), 0, 0, false)
+	search.AddFacet("IsClosed", bleve.NewFacetRequest("IsClosed", 2))
+
+	result, err := b.indexer.SearchInContext(ctx, search)
+	if err != nil {
+		return err
+	}
+
+	for _, term := range result.Facets["IsClosed"].Terms.Terms() {
+		// boolean fields are indexed as "T" and "F"
+		if term.Term == "T" {
+			facets.ClosedCount = int64(term.Count)
+		} else {
+			facets.OpenCount = int64(term.Count)
+		}
+	}
+	return nil
+}
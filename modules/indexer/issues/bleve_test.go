@@ -7,6 +7,8 @@ import (
 	"context"
 	"testing"
 
+	"code.gitea.io/gitea/modules/util"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -30,6 +32,12 @@ func TestBleveIndexAndSearch(t *testing.T) {
 				"test1",
 				"test2",
 			},
+			IsClosed:    true,
+			LabelIDs:    []int64{1, 2},
+			MilestoneID: 3,
+			PosterID:    4,
+			CreatedUnix: 1000,
+			UpdatedUnix: 3000,
 		},
 		{
 			ID:      2,
@@ -40,6 +48,13 @@ func TestBleveIndexAndSearch(t *testing.T) {
 				"LGTM",
 				"Good idea",
 			},
+			IsPull:      true,
+			LabelIDs:    []int64{2},
+			PosterID:    5,
+			AssigneeIDs: []int64{4},
+			NumComments: 2,
+			CreatedUnix: 2000,
+			UpdatedUnix: 2500,
 		},
 	})
 	assert.NoError(t, err)
@@ -75,7 +90,7 @@ func TestBleveIndexAndSearch(t *testing.T) {
 	}
 
 	for _, kw := range keywords {
-		res, err := indexer.Search(context.TODO(), kw.Keyword, []int64{2}, 10, 0)
+		res, err := indexer.Search(context.TODO(), &SearchOptions{Keyword: kw.Keyword, RepoIDs: []int64{2}, Limit: 10})
 		assert.NoError(t, err)
 
 		ids := make([]int64, 0, len(res.Hits))
@@ -84,4 +99,77 @@ func TestBleveIndexAndSearch(t *testing.T) {
 		}
 		assert.ElementsMatch(t, kw.IDs, ids)
 	}
+
+	filters := []struct {
+		Opts SearchOptions
+		IDs  []int64
+	}{
+		{
+			Opts: SearchOptions{IsClosed: util.OptionalBoolFalse},
+			IDs:  []int64{2},
+		},
+		{
+			Opts: SearchOptions{IsPull: util.OptionalBoolFalse},
+			IDs:  []int64{1},
+		},
+		{
+			Opts: SearchOptions{Keyword: "chinese", IncludedLabelIDs: [][]int64{{2}}},
+			IDs:  []int64{1, 2},
+		},
+		{
+			Opts: SearchOptions{IncludedLabelIDs: [][]int64{{2, 5}}, ExcludedLabelIDs: []int64{1}},
+			IDs:  []int64{2},
+		},
+		{
+			Opts: SearchOptions{MilestoneIDs: []int64{3, 7}},
+			IDs:  []int64{1},
+		},
+		{
+			Opts: SearchOptions{PosterID: 5},
+			IDs:  []int64{2},
+		},
+		{
+			Opts: SearchOptions{AssigneeID: 4},
+			IDs:  []int64{2},
+		},
+		{
+			Opts: SearchOptions{CreatedAfter: 1500},
+			IDs:  []int64{2},
+		},
+		{
+			Opts: SearchOptions{UpdatedBefore: 2500},
+			IDs:  []int64{2},
+		},
+		{
+			Opts: SearchOptions{RepoIDs: []int64{3}},
+			IDs:  []int64{},
+		},
+	}
+	for _, f := range filters {
+		f.Opts.Limit = 10
+		res, err := indexer.Search(context.TODO(), &f.Opts)
+		assert.NoError(t, err)
+
+		ids := make([]int64, 0, len(res.Hits))
+		for _, hit := range res.Hits {
+			ids = append(ids, hit.ID)
+		}
+		assert.ElementsMatch(t, f.IDs, ids)
+		assert.EqualValues(t, len(f.IDs), res.Total)
+	}
+
+	res, err := indexer.Search(context.TODO(), &SearchOptions{SortBy: SortByCreatedAsc, Limit: 1, Start: 1})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, res.Total)
+	assert.Len(t, res.Hits, 1)
+	assert.EqualValues(t, 2, res.Hits[0].ID)
+
+	res, err = indexer.Search(context.TODO(), &SearchOptions{RepoIDs: []int64{2}, IsClosed: util.OptionalBoolTrue, SortBy: SortByUpdatedDesc, Facets: true, Limit: 10})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, res.Total)
+	assert.EqualValues(t, 1, res.Facets.OpenCount)
+	assert.EqualValues(t, 1, res.Facets.ClosedCount)
+	assert.Equal(t, map[int64]int64{2: 1}, res.Facets.Repos)
+	assert.Equal(t, map[int64]int64{1: 1, 2: 1}, res.Facets.Labels)
+	assert.Equal(t, map[int64]int64{3: 1}, res.Facets.Milestones)
 }
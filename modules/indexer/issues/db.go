@@ -41,20 +41,53 @@ func (i *DBIndexer) Delete(ids ...int64) error {
 func (i *DBIndexer) Close() {
 }
 
-// Search dummy function
-func (i *DBIndexer) Search(ctx context.Context, kw string, repoIDs []int64, limit, start int) (*SearchResult, error) {
-	total, ids, err := issues_model.SearchIssueIDsByKeyword(ctx, kw, repoIDs, limit, start)
+// Search searches for issues by given conditions.
+func (i *DBIndexer) Search(ctx context.Context, opts *SearchOptions) (*SearchResult, error) {
+	dbOpts := &issues_model.IssueSearchOptions{
+		Keyword:           opts.Keyword,
+		RepoIDs:           opts.RepoIDs,
+		IsPull:            opts.IsPull,
+		IsClosed:          opts.IsClosed,
+		IncludedLabelIDs:  opts.IncludedLabelIDs,
+		ExcludedLabelIDs:  opts.ExcludedLabelIDs,
+		MilestoneIDs:      opts.MilestoneIDs,
+		PosterID:          opts.PosterID,
+		AssigneeID:        opts.AssigneeID,
+		CreatedAfterUnix:  int64(opts.CreatedAfter),
+		CreatedBeforeUnix: int64(opts.CreatedBefore),
+		UpdatedAfterUnix:  int64(opts.UpdatedAfter),
+		UpdatedBeforeUnix: int64(opts.UpdatedBefore),
+		SortType:          string(opts.SortBy),
+		Limit:             opts.Limit,
+		Start:             opts.Start,
+	}
+
+	total, ids, err := issues_model.SearchIssueIDs(ctx, dbOpts)
 	if err != nil {
 		return nil, err
 	}
 	result := SearchResult{
 		Total: total,
-		Hits:  make([]Match, 0, limit),
+		Hits:  make([]Match, 0, len(ids)),
 	}
 	for _, id := range ids {
 		result.Hits = append(result.Hits, Match{
 			ID: id,
 		})
 	}
+
+	if opts.Facets {
+		facets, err := issues_model.CountIssueSearchFacets(ctx, dbOpts)
+		if err != nil {
+			return nil, err
+		}
+		result.Facets = &Facets{
+			OpenCount:   facets.OpenCount,
+			ClosedCount: facets.ClosedCount,
+			Repos:       facets.Repos,
+			Labels:      facets.Labels,
+			Milestones:  facets.Milestones,
+		}
+	}
 	return &result, nil
 }
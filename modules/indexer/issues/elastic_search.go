@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/json"
 	"code.gitea.io/gitea/modules/log"
 
 	"github.com/olivere/elastic/v7"
@@ -86,34 +87,76 @@ func NewElasticSearchIndexer(url, indexerName string) (*ElasticSearchIndexer, er
 }
 
 const (
-	defaultMapping = `{
-		"mappings": {
-			"properties": {
-				"id": {
-					"type": "integer",
-					"index": true
-				},
-				"repo_id": {
-					"type": "integer",
-					"index": true
-				},
-				"title": {
-					"type": "text",
-					"index": true
-				},
-				"content": {
-					"type": "text",
-					"index": true
-				},
-				"comments": {
-					"type" : "text",
-					"index": true
-				}
-			}
+	// elasticIndexerLatestVersion is stored in the mapping, the index is repopulated if an older version is found
+	elasticIndexerLatestVersion = 2
+
+	defaultProperties = `{
+		"id": {
+			"type": "integer",
+			"index": true
+		},
+		"repo_id": {
+			"type": "integer",
+			"index": true
+		},
+		"title": {
+			"type": "text",
+			"index": true
+		},
+		"content": {
+			"type": "text",
+			"index": true
+		},
+		"comments": {
+			"type" : "text",
+			"index": true
+		},
+		"is_pull": {
+			"type": "boolean",
+			"index": true
+		},
+		"is_closed": {
+			"type": "boolean",
+			"index": true
+		},
+		"label_ids": {
+			"type": "integer",
+			"index": true
+		},
+		"milestone_id": {
+			"type": "integer",
+			"index": true
+		},
+		"poster_id": {
+			"type": "integer",
+			"index": true
+		},
+		"assignee_ids": {
+			"type": "integer",
+			"index": true
+		},
+		"num_comments": {
+			"type": "integer",
+			"index": true
+		},
+		"created_unix": {
+			"type": "long",
+			"index": true
+		},
+		"updated_unix": {
+			"type": "long",
+			"index": true
 		}
 	}`
 )
 
+var defaultMapping = fmt.Sprintf(`{
+		"_meta": {
+			"version": %d
+		},
+		"properties": %s
+	}`, elasticIndexerLatestVersion, defaultProperties)
+
 // Init will initialize the indexer
 func (b *ElasticSearchIndexer) Init() (bool, error) {
 	ctx := graceful.GetManager().HammerContext()
@@ -123,9 +166,7 @@ func (b *ElasticSearchIndexer) Init() (bool, error) {
 	}
 
 	if !exists {
-		mapping := defaultMapping
-
-		createIndex, err := b.client.CreateIndex(b.indexerName).BodyString(mapping).Do(ctx)
+		createIndex, err := b.client.CreateIndex(b.indexerName).BodyString(`{"mappings": ` + defaultMapping + `}`).Do(ctx)
 		if err != nil {
 			return false, b.checkError(err)
 		}
@@ -135,7 +176,49 @@ func (b *ElasticSearchIndexer) Init() (bool, error) {
 
 		return false, nil
 	}
-	return true, nil
+
+	version, err := b.mappingVersion(ctx)
+	if err != nil {
+		return false, b.checkError(err)
+	}
+	if version >= elasticIndexerLatestVersion {
+		return true, nil
+	}
+
+	// new fields can be added to an existing index, the issues have to be indexed again to fill them
+	log.Info("Updating the mapping of the issue indexer %s from version %d to %d", b.indexerName, version, elasticIndexerLatestVersion)
+	putMapping, err := b.client.PutMapping().Index(b.indexerName).BodyString(defaultMapping).Do(ctx)
+	if err != nil {
+		return false, b.checkError(err)
+	}
+	if !putMapping.Acknowledged {
+		return false, errors.New("init failed")
+	}
+	return false, nil
+}
+
+// mappingVersion returns the version stored in the mapping of the index, 0 if it has none
+func (b *ElasticSearchIndexer) mappingVersion(ctx context.Context) (int, error) {
+	mappings, err := b.client.GetMapping().Index(b.indexerName).Do(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var index struct {
+		Mappings struct {
+			Meta struct {
+				Version int `json:"version"`
+			} `json:"_meta"`
+		} `json:"mappings"`
+	}
+	bs, err := json.Marshal(mappings[b.indexerName])
+	if err != nil {
+		return 0, err
+	}
+	if err := json.Unmarshal(bs, &index); err != nil {
+		return 0, err
+	}
+	return index.Mappings.Meta.Version, nil
 }
 
 // SetAvailabilityChangeCallback sets callback that will be triggered when availability changes
@@ -152,6 +235,25 @@ func (b *ElasticSearchIndexer) Ping() bool {
 	return b.available
 }
 
+func elasticIndexerDocument(issue *IndexerData) map[string]interface{} {
+	return map[string]interface{}{
+		"id":           issue.ID,
+		"repo_id":      issue.RepoID,
+		"title":        issue.Title,
+		"content":      issue.Content,
+		"comments":     issue.Comments,
+		"is_pull":      issue.IsPull,
+		"is_closed":    issue.IsClosed,
+		"label_ids":    issue.LabelIDs,
+		"milestone_id": issue.MilestoneID,
+		"poster_id":    issue.PosterID,
+		"assignee_ids": issue.AssigneeIDs,
+		"num_comments": issue.NumComments,
+		"created_unix": issue.CreatedUnix,
+		"updated_unix": issue.UpdatedUnix,
+	}
+}
+
 // Index will save the index data
 func (b *ElasticSearchIndexer) Index(issues []*IndexerData) error {
 	if len(issues) == 0 {
@@ -161,13 +263,7 @@ func (b *ElasticSearchIndexer) Index(issues []*IndexerData) error {
 		_, err := b.client.Index().
 			Index(b.indexerName).
 			Id(fmt.Sprintf("%d", issue.ID)).
-			BodyJson(map[string]interface{}{
-				"id":       issue.ID,
-				"repo_id":  issue.RepoID,
-				"title":    issue.Title,
-				"content":  issue.Content,
-				"comments": issue.Comments,
-			}).
+			BodyJson(elasticIndexerDocument(issue)).
 			Do(graceful.GetManager().HammerContext())
 		return b.checkError(err)
 	}
@@ -178,13 +274,7 @@ func (b *ElasticSearchIndexer) Index(issues []*IndexerData) error {
 			elastic.NewBulkIndexRequest().
 				Index(b.indexerName).
 				Id(fmt.Sprintf("%d", issue.ID)).
-				Doc(map[string]interface{}{
-					"id":       issue.ID,
-					"repo_id":  issue.RepoID,
-					"title":    issue.Title,
-					"content":  issue.Content,
-					"comments": issue.Comments,
-				}),
+				Doc(elasticIndexerDocument(issue)),
 		)
 	}
 
@@ -223,42 +313,163 @@ func (b *ElasticSearchIndexer) Delete(ids ...int64) error {
 	return b.checkError(err)
 }
 
+func int64sToInterfaces(ids []int64) []interface{} {
+	values := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, id)
+	}
+	return values
+}
+
 // Search searches for issues by given conditions.
 // Returns the matching issue IDs
-func (b *ElasticSearchIndexer) Search(ctx context.Context, keyword string, repoIDs []int64, limit, start int) (*SearchResult, error) {
-	kwQuery := elastic.NewMultiMatchQuery(keyword, "title", "content", "comments")
+func (b *ElasticSearchIndexer) Search(ctx context.Context, opts *SearchOptions) (*SearchResult, error) {
 	query := elastic.NewBoolQuery()
-	query = query.Must(kwQuery)
-	if len(repoIDs) > 0 {
-		repoStrs := make([]interface{}, 0, len(repoIDs))
-		for _, repoID := range repoIDs {
-			repoStrs = append(repoStrs, repoID)
+	if opts.Keyword != "" {
+		query = query.Must(elastic.NewMultiMatchQuery(opts.Keyword, "title", "content", "comments"))
+	}
+	if len(opts.RepoIDs) > 0 {
+		query = query.Filter(elastic.NewTermsQuery("repo_id", int64sToInterfaces(opts.RepoIDs)...))
+	}
+	if !opts.IsPull.IsNone() {
+		query = query.Filter(elastic.NewTermQuery("is_pull", opts.IsPull.IsTrue()))
+	}
+	for _, labelIDs := range opts.IncludedLabelIDs {
+		query = query.Filter(elastic.NewTermsQuery("label_ids", int64sToInterfaces(labelIDs)...))
+	}
+	if len(opts.ExcludedLabelIDs) > 0 {
+		query = query.MustNot(elastic.NewTermsQuery("label_ids", int64sToInterfaces(opts.ExcludedLabelIDs)...))
+	}
+	if len(opts.MilestoneIDs) > 0 {
+		query = query.Filter(elastic.NewTermsQuery("milestone_id", int64sToInterfaces(opts.MilestoneIDs)...))
+	}
+	if opts.PosterID != 0 {
+		query = query.Filter(elastic.NewTermQuery("poster_id", opts.PosterID))
+	}
+	if opts.AssigneeID != 0 {
+		query = query.Filter(elastic.NewTermQuery("assignee_ids", opts.AssigneeID))
+	}
+	if opts.CreatedAfter != 0 || opts.CreatedBefore != 0 {
+		rangeQuery := elastic.NewRangeQuery("created_unix")
+		if opts.CreatedAfter != 0 {
+			rangeQuery = rangeQuery.Gte(opts.CreatedAfter)
+		}
+		if opts.CreatedBefore != 0 {
+			rangeQuery = rangeQuery.Lte(opts.CreatedBefore)
+		}
+		query = query.Filter(rangeQuery)
+	}
+	if opts.UpdatedAfter != 0 || opts.UpdatedBefore != 0 {
+		rangeQuery := elastic.NewRangeQuery("updated_unix")
+		if opts.UpdatedAfter != 0 {
+			rangeQuery = rangeQuery.Gte(opts.UpdatedAfter)
+		}
+		if opts.UpdatedBefore != 0 {
+			rangeQuery = rangeQuery.Lte(opts.UpdatedBefore)
 		}
-		repoQuery := elastic.NewTermsQuery("repo_id", repoStrs...)
-		query = query.Must(repoQuery)
+		query = query.Filter(rangeQuery)
 	}
-	searchResult, err := b.client.Search().
+
+	search := b.client.Search().
 		Index(b.indexerName).
 		Query(query).
-		Sort("_score", false).
-		From(start).Size(limit).
-		Do(ctx)
+		TrackTotalHits(true).
+		From(opts.Start).Size(opts.Limit)
+
+	// the state is applied as post filter so the facets count open and closed issues
+	if !opts.IsClosed.IsNone() {
+		search = search.PostFilter(elastic.NewTermQuery("is_closed", opts.IsClosed.IsTrue()))
+	}
+	if opts.Facets {
+		// aggregations ignore the post filter, the state has to be applied to the facets of the results
+		facets := elastic.NewFilterAggregation().Filter(elastic.NewMatchAllQuery())
+		if !opts.IsClosed.IsNone() {
+			facets = facets.Filter(elastic.NewTermQuery("is_closed", opts.IsClosed.IsTrue()))
+		}
+		search = search.
+			Aggregation("is_closed", elastic.NewTermsAggregation().Field("is_closed").Size(2)).
+			Aggregation("results", facets.
+				SubAggregation("repo_id", elastic.NewTermsAggregation().Field("repo_id").Size(len(opts.RepoIDs)+1)).
+				SubAggregation("label_ids", elastic.NewTermsAggregation().Field("label_ids").Size(maxFacetTerms)).
+				SubAggregation("milestone_id", elastic.NewTermsAggregation().Field("milestone_id").Size(maxFacetTerms)))
+	}
+
+	switch opts.SortBy {
+	case SortByCreatedDesc:
+		search = search.Sort("created_unix", false).Sort("id", false)
+	case SortByCreatedAsc:
+		search = search.Sort("created_unix", true).Sort("id", true)
+	case SortByUpdatedDesc:
+		search = search.Sort("updated_unix", false).Sort("id", false)
+	case SortByUpdatedAsc:
+		search = search.Sort("updated_unix", true).Sort("id", true)
+	case SortByCommentsDesc:
+		search = search.Sort("num_comments", false).Sort("created_unix", false)
+	case SortByCommentsAsc:
+		search = search.Sort("num_comments", true).Sort("created_unix", false)
+	default:
+		search = search.Sort("_score", false).Sort("updated_unix", false)
+	}
+
+	searchResult, err := search.Do(ctx)
 	if err != nil {
 		return nil, b.checkError(err)
 	}
 
-	hits := make([]Match, 0, limit)
+	hits := make([]Match, 0, opts.Limit)
 	for _, hit := range searchResult.Hits.Hits {
 		id, _ := strconv.ParseInt(hit.Id, 10, 64)
+		var score float64
+		if hit.Score != nil {
+			score = *hit.Score
+		}
 		hits = append(hits, Match{
-			ID: id,
+			ID:    id,
+			Score: score,
 		})
 	}
 
-	return &SearchResult{
+	result := &SearchResult{
 		Total: searchResult.TotalHits(),
 		Hits:  hits,
-	}, nil
+	}
+
+	if opts.Facets {
+		result.Facets = newFacets()
+		if agg, ok := searchResult.Aggregations.Terms("is_closed"); ok {
+			for _, bucket := range agg.Buckets {
+				// boolean terms are returned as 1 and 0
+				if bucket.KeyAsString != nil && *bucket.KeyAsString == "true" {
+					result.Facets.ClosedCount = bucket.DocCount
+				} else {
+					result.Facets.OpenCount = bucket.DocCount
+				}
+			}
+		}
+		results, ok := searchResult.Aggregations.Filter("results")
+		if !ok {
+			return result, nil
+		}
+		for name, m := range map[string]map[int64]int64{
+			"repo_id":      result.Facets.Repos,
+			"label_ids":    result.Facets.Labels,
+			"milestone_id": result.Facets.Milestones,
+		} {
+			agg, ok := results.Aggregations.Terms(name)
+			if !ok {
+				continue
+			}
+			for _, bucket := range agg.Buckets {
+				id, err := bucket.KeyNumber.Int64()
+				if err != nil || id == 0 {
+					continue
+				}
+				m[id] = bucket.DocCount
+			}
+		}
+	}
+
+	return result, nil
 }
 
 // Close implements indexer
@@ -14,23 +14,81 @@ import (
 	"code.gitea.io/gitea/models/db"
 	issues_model "code.gitea.io/gitea/models/issues"
 	repo_model "code.gitea.io/gitea/models/repo"
+	user_model "code.gitea.io/gitea/models/user"
 	"code.gitea.io/gitea/modules/graceful"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/process"
 	"code.gitea.io/gitea/modules/queue"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
 	"code.gitea.io/gitea/modules/util"
 )
 
 // IndexerData data stored in the issue indexer
 type IndexerData struct {
-	ID       int64    `json:"id"`
-	RepoID   int64    `json:"repo_id"`
-	Title    string   `json:"title"`
-	Content  string   `json:"content"`
-	Comments []string `json:"comments"`
-	IsDelete bool     `json:"is_delete"`
-	IDs      []int64  `json:"ids"`
+	ID          int64              `json:"id"`
+	RepoID      int64              `json:"repo_id"`
+	Title       string             `json:"title"`
+	Content     string             `json:"content"`
+	Comments    []string           `json:"comments"`
+	IsPull      bool               `json:"is_pull"`
+	IsClosed    bool               `json:"is_closed"`
+	LabelIDs    []int64            `json:"label_ids"`
+	MilestoneID int64              `json:"milestone_id"`
+	PosterID    int64              `json:"poster_id"`
+	AssigneeIDs []int64            `json:"assignee_ids"`
+	NumComments int                `json:"num_comments"`
+	CreatedUnix timeutil.TimeStamp `json:"created_unix"`
+	UpdatedUnix timeutil.TimeStamp `json:"updated_unix"`
+	IsDelete    bool               `json:"is_delete"`
+	IDs         []int64            `json:"ids"`
+}
+
+// SortBy defines the order of the search results, the values match the sort types of the issue lists
+type SortBy string
+
+const (
+	SortByScore        SortBy = "relevance"
+	SortByCreatedDesc  SortBy = "newest"
+	SortByCreatedAsc   SortBy = "oldest"
+	SortByUpdatedDesc  SortBy = "recentupdate"
+	SortByUpdatedAsc   SortBy = "leastupdate"
+	SortByCommentsDesc SortBy = "mostcomment"
+	SortByCommentsAsc  SortBy = "leastcomment"
+)
+
+// ParseSortBy converts the sort type of an issue list, unknown sort types result in the default order
+func ParseSortBy(sortType string, hasKeyword bool) SortBy {
+	switch s := SortBy(sortType); s {
+	case SortByScore, SortByCreatedDesc, SortByCreatedAsc, SortByUpdatedDesc, SortByUpdatedAsc, SortByCommentsDesc, SortByCommentsAsc:
+		return s
+	}
+	if hasKeyword {
+		return SortByScore
+	}
+	return SortByCreatedDesc
+}
+
+// SearchOptions represents the conditions of a search which are executed by the indexer
+type SearchOptions struct {
+	Keyword  string
+	RepoIDs  []int64 // the caller has to ensure the doer is allowed to read the issues of the repositories
+	IsPull   util.OptionalBool
+	IsClosed util.OptionalBool
+	// results must have one label of every group, a group contains the labels with the same name in different repositories
+	IncludedLabelIDs [][]int64
+	ExcludedLabelIDs []int64
+	MilestoneIDs     []int64 // results must have one of the milestones
+	PosterID         int64
+	AssigneeID       int64
+	CreatedAfter     timeutil.TimeStamp
+	CreatedBefore    timeutil.TimeStamp
+	UpdatedAfter     timeutil.TimeStamp
+	UpdatedBefore    timeutil.TimeStamp
+	SortBy           SortBy
+	Facets           bool // count the results by state, repository, label and milestone
+	Limit            int
+	Start            int
 }
 
 // Match represents on search result
@@ -39,10 +97,29 @@ type Match struct {
 	Score float64 `json:"score"`
 }
 
+// Facets contains the number of matching issues grouped by different fields.
+// The open and closed issues are counted without the IsClosed filter of the search options.
+type Facets struct {
+	OpenCount   int64
+	ClosedCount int64
+	Repos       map[int64]int64
+	Labels      map[int64]int64
+	Milestones  map[int64]int64
+}
+
+func newFacets() *Facets {
+	return &Facets{
+		Repos:      make(map[int64]int64),
+		Labels:     make(map[int64]int64),
+		Milestones: make(map[int64]int64),
+	}
+}
+
 // SearchResult represents search results
 type SearchResult struct {
-	Total int64
-	Hits  []Match
+	Total  int64
+	Hits   []Match
+	Facets *Facets
 }
 
 // Indexer defines an interface to indexer issues contents
@@ -52,7 +129,7 @@ type Indexer interface {
 	SetAvailabilityChangeCallback(callback func(bool))
 	Index(issue []*IndexerData) error
 	Delete(ids ...int64) error
-	Search(ctx context.Context, kw string, repoIDs []int64, limit, start int) (*SearchResult, error)
+	Search(ctx context.Context, opts *SearchOptions) (*SearchResult, error)
 	Close()
 }
 
@@ -107,7 +184,7 @@ func InitIssueIndexer(syncReindex bool) {
 
 	// Create the Queue
 	switch setting.Indexer.IssueType {
-	case "bleve", "elasticsearch":
+	case "bleve", "elasticsearch", "meilisearch":
 		handler := func(data ...queue.Data) []queue.Data {
 			indexer := holder.get()
 			if indexer == nil {
@@ -216,6 +293,21 @@ func InitIssueIndexer(syncReindex bool) {
 				holder.set(issueIndexer)
 				atTerminate(finished)
 			})
+		case "meilisearch":
+			graceful.GetManager().RunWithShutdownFns(func(_, atTerminate func(func())) {
+				pprof.SetGoroutineLabels(ctx)
+				issueIndexer, err := NewMeilisearchIndexer(setting.Indexer.IssueConnStr, setting.Indexer.IssueIndexerName)
+				if err != nil {
+					log.Fatal("Unable to initialize Meilisearch Issue Indexer at connection: %s Error: %v", util.SanitizeCredentialURLs(setting.Indexer.IssueConnStr), err)
+				}
+				exist, err := issueIndexer.Init()
+				if err != nil {
+					log.Fatal("Unable to issueIndexer.Init with connection %s Error: %v", util.SanitizeCredentialURLs(setting.Indexer.IssueConnStr), err)
+				}
+				populate = !exist
+				holder.set(issueIndexer)
+				atTerminate(finished)
+			})
 		case "db":
 			issueIndexer := &DBIndexer{}
 			holder.set(issueIndexer)
@@ -339,20 +431,70 @@ func UpdateRepoIndexer(ctx context.Context, repo *repo_model.Repository) {
 
 // UpdateIssueIndexer add/update an issue to the issue indexer
 func UpdateIssueIndexer(issue *issues_model.Issue) {
+	indexerData, err := getIndexerData(db.DefaultContext, issue)
+	if err != nil {
+		log.Error("Unable to collect the indexer data of issue %d: %v", issue.ID, err)
+		return
+	}
+	log.Debug("Adding to channel: %v", indexerData)
+	if err := issueIndexerQueue.Push(indexerData); err != nil {
+		log.Error("Unable to push to issue indexer: %v: Error: %v", indexerData, err)
+	}
+}
+
+// getIndexerData collects the data of the issue which is stored in the indexer.
+// Labels and assignees are always read from the database because the issue may contain outdated values.
+func getIndexerData(ctx context.Context, issue *issues_model.Issue) (*IndexerData, error) {
+	if issue.Comments == nil {
+		if err := issue.LoadDiscussComments(ctx); err != nil {
+			return nil, err
+		}
+	}
 	var comments []string
 	for _, comment := range issue.Comments {
 		if comment.Type == issues_model.CommentTypeComment {
 			comments = append(comments, comment.Content)
 		}
 	}
+
+	labels, err := issues_model.GetLabelsByIssueID(ctx, issue.ID)
+	if err != nil {
+		return nil, err
+	}
+	labelIDs := make([]int64, 0, len(labels))
+	for _, label := range labels {
+		labelIDs = append(labelIDs, label.ID)
+	}
+
+	assigneeIDs, err := issues_model.GetAssigneeIDsByIssue(ctx, issue.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &IndexerData{
+		ID:          issue.ID,
+		RepoID:      issue.RepoID,
+		Title:       issue.Title,
+		Content:     issue.Content,
+		Comments:    comments,
+		IsPull:      issue.IsPull,
+		IsClosed:    issue.IsClosed,
+		LabelIDs:    labelIDs,
+		MilestoneID: issue.MilestoneID,
+		PosterID:    issue.PosterID,
+		AssigneeIDs: assigneeIDs,
+		NumComments: issue.NumComments,
+		CreatedUnix: issue.CreatedUnix,
+		UpdatedUnix: issue.UpdatedUnix,
+	}, nil
+}
+
+// DeleteIssueIndexer deletes the issue from the issue indexer
+func DeleteIssueIndexer(issueID int64) {
 	indexerData := &IndexerData{
-		ID:       issue.ID,
-		RepoID:   issue.RepoID,
-		Title:    issue.Title,
-		Content:  issue.Content,
-		Comments: comments,
+		IDs:      []int64{issueID},
+		IsDelete: true,
 	}
-	log.Debug("Adding to channel: %v", indexerData)
 	if err := issueIndexerQueue.Push(indexerData); err != nil {
 		log.Error("Unable to push to issue indexer: %v: Error: %v", indexerData, err)
 	}
@@ -379,20 +521,48 @@ func DeleteRepoIssueIndexer(ctx context.Context, repo *repo_model.Repository) {
 	}
 }
 
-// SearchIssuesByKeyword search issue ids by keywords and repo id
+// SearchIssues searches issues with the structured options
 // WARNNING: You have to ensure user have permission to visit repoIDs' issues
-func SearchIssuesByKeyword(ctx context.Context, repoIDs []int64, keyword string) ([]int64, error) {
-	var issueIDs []int64
+func SearchIssues(ctx context.Context, opts *SearchOptions) (*SearchResult, error) {
 	indexer := holder.get()
-
 	if indexer == nil {
-		log.Error("SearchIssuesByKeyword(): unable to get indexer!")
+		log.Error("SearchIssues(): unable to get indexer!")
 		return nil, fmt.Errorf("unable to get issue indexer")
 	}
-	res, err := indexer.Search(ctx, keyword, repoIDs, 50, 0)
+	if opts.SortBy == "" {
+		opts.SortBy = ParseSortBy("", opts.Keyword != "")
+	}
+	return indexer.Search(ctx, opts)
+}
+
+// SearchIssuesByQuery parses the query like `is:open label:bug crash` and searches the issues matching it and the options.
+// WARNNING: You have to ensure user have permission to visit the issues of opts.RepoIDs
+func SearchIssuesByQuery(ctx context.Context, doer *user_model.User, query string, opts *SearchOptions) (*SearchResult, error) {
+	ok, err := ParseQuery(query).Apply(ctx, doer, opts)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return &SearchResult{
+			Hits:   []Match{},
+			Facets: newFacets(),
+		}, nil
+	}
+	return SearchIssues(ctx, opts)
+}
+
+// SearchIssuesByKeyword search issue ids by keywords and repo id
+// WARNNING: You have to ensure user have permission to visit repoIDs' issues
+func SearchIssuesByKeyword(ctx context.Context, repoIDs []int64, keyword string) ([]int64, error) {
+	res, err := SearchIssues(ctx, &SearchOptions{
+		Keyword: keyword,
+		RepoIDs: repoIDs,
+		Limit:   50,
+	})
 	if err != nil {
 		return nil, err
 	}
+	issueIDs := make([]int64, 0, len(res.Hits))
 	for _, r := range res.Hits {
 		issueIDs = append(issueIDs, r.ID)
 	}
@@ -12,6 +12,7 @@ import (
 
 	"code.gitea.io/gitea/models/unittest"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
 
 	_ "code.gitea.io/gitea/models"
 
@@ -66,6 +67,18 @@ func TestBleveSearchIssues(t *testing.T) {
 	ids, err = SearchIssuesByKeyword(context.TODO(), []int64{1}, "good")
 	assert.NoError(t, err)
 	assert.EqualValues(t, []int64{1}, ids)
+
+	res, err := SearchIssuesByQuery(context.TODO(), nil, "for label:label1 is:issue author:user1", &SearchOptions{RepoIDs: []int64{1}, Limit: 10})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, res.Total)
+	assert.Equal(t, []int64{1}, []int64{res.Hits[0].ID})
+
+	res, err = SearchIssuesByQuery(context.TODO(), nil, "for is:pr sort:oldest", &SearchOptions{RepoIDs: []int64{1}, Facets: true, Limit: 1, Start: 1})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, res.Total)
+	assert.Equal(t, []int64{3}, []int64{res.Hits[0].ID})
+	assert.Equal(t, map[int64]int64{1: 1, 4: 1}, res.Facets.Labels)
+	assert.Equal(t, map[int64]int64{1: 1, 3: 1}, res.Facets.Milestones)
 }
 
 func TestDBSearchIssues(t *testing.T) {
@@ -89,4 +102,43 @@ func TestDBSearchIssues(t *testing.T) {
 	ids, err = SearchIssuesByKeyword(context.TODO(), []int64{1}, "good")
 	assert.NoError(t, err)
 	assert.EqualValues(t, []int64{1}, ids)
+
+	res, err := SearchIssues(context.TODO(), &SearchOptions{
+		Keyword:  "for",
+		RepoIDs:  []int64{1},
+		IsClosed: util.OptionalBoolFalse,
+		SortBy:   SortByCreatedAsc,
+		Facets:   true,
+		Limit:    2,
+		Start:    1,
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 4, res.Total)
+	assert.Equal(t, []Match{{ID: 2}, {ID: 3}}, res.Hits)
+	assert.EqualValues(t, 4, res.Facets.OpenCount)
+	assert.EqualValues(t, 1, res.Facets.ClosedCount)
+	assert.Equal(t, map[int64]int64{1: 4}, res.Facets.Repos)
+	assert.Equal(t, map[int64]int64{1: 2, 4: 1}, res.Facets.Labels)
+	assert.Equal(t, map[int64]int64{1: 1, 3: 1}, res.Facets.Milestones)
+
+	res, err = SearchIssues(context.TODO(), &SearchOptions{
+		RepoIDs:          []int64{1},
+		IsPull:           util.OptionalBoolTrue,
+		IncludedLabelIDs: [][]int64{{1, 2}},
+		ExcludedLabelIDs: []int64{4},
+		Limit:            10,
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, res.Total)
+	assert.Empty(t, res.Hits)
+
+	res, err = SearchIssues(context.TODO(), &SearchOptions{
+		RepoIDs:      []int64{1},
+		MilestoneIDs: []int64{0},
+		PosterID:     1,
+		CreatedAfter: 946684800,
+		Limit:        10,
+	})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []Match{{ID: 1}, {ID: 11}}, res.Hits)
 }
@@ -0,0 +1,475 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package issues
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/json"
+)
+
+var _ Indexer = &MeilisearchIndexer{}
+
+// MeilisearchIndexer implements Indexer interface
+type MeilisearchIndexer struct {
+	client               *http.Client
+	baseURL              *url.URL
+	apiKey               string
+	indexerName          string
+	available            bool
+	availabilityCallback func(bool)
+	stopTimer            chan struct{}
+	lock                 sync.RWMutex
+}
+
+// MeilisearchError represents an error response of Meilisearch
+type MeilisearchError struct {
+	StatusCode int    `json:"-"`
+	Message    string `json:"message"`
+	Code       string `json:"code"`
+}
+
+func (e *MeilisearchError) Error() string {
+	return fmt.Sprintf("meilisearch: %s (%s, status %d)", e.Message, e.Code, e.StatusCode)
+}
+
+// NewMeilisearchIndexer creates a new meilisearch indexer.
+// The api key is taken from the password of the url, i.e. http://:apikey@localhost:7700
+func NewMeilisearchIndexer(connStr, indexerName string) (*MeilisearchIndexer, error) {
+	u, err := url.Parse(connStr)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported meilisearch url scheme: %s", u.Scheme)
+	}
+
+	var apiKey string
+	if u.User != nil {
+		apiKey, _ = u.User.Password()
+		u.User = nil
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	indexer := &MeilisearchIndexer{
+		client:      &http.Client{Timeout: 30 * time.Second},
+		baseURL:     u,
+		apiKey:      apiKey,
+		indexerName: indexerName,
+		available:   true,
+		stopTimer:   make(chan struct{}),
+	}
+
+	ticker := time.NewTicker(10 * time.Second)
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				indexer.checkAvailability()
+			case <-indexer.stopTimer:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return indexer, nil
+}
+
+// meilisearchSettings are applied on every start so changed settings reach existing indexes
+var meilisearchSettings = map[string]interface{}{
+	"searchableAttributes": []string{"title", "content", "comments"},
+	"filterableAttributes": meilisearchFilterableAttributes,
+	"sortableAttributes":   []string{"created_unix", "updated_unix", "num_comments"},
+	"typoTolerance": map[string]interface{}{
+		"enabled": true,
+	},
+	"faceting": map[string]interface{}{
+		"maxValuesPerFacet": maxFacetTerms,
+	},
+	"pagination": map[string]interface{}{
+		"maxTotalHits": 100000,
+	},
+}
+
+var meilisearchFilterableAttributes = []string{"repo_id", "is_pull", "is_closed", "label_ids", "milestone_id", "poster_id", "assignee_ids", "created_unix", "updated_unix"}
+
+// Init will initialize the indexer
+func (b *MeilisearchIndexer) Init() (bool, error) {
+	ctx := graceful.GetManager().HammerContext()
+
+	exists := true
+	if err := b.request(ctx, http.MethodGet, "/indexes/"+url.PathEscape(b.indexerName), nil, nil); err != nil {
+		var meiliErr *MeilisearchError
+		if !errors.As(err, &meiliErr) || meiliErr.StatusCode != http.StatusNotFound {
+			return false, b.checkError(err)
+		}
+		exists = false
+	}
+
+	if !exists {
+		err := b.request(ctx, http.MethodPost, "/indexes", map[string]interface{}{
+			"uid":        b.indexerName,
+			"primaryKey": "id",
+		}, nil)
+		if err != nil {
+			return false, b.checkError(err)
+		}
+	} else {
+		// documents of an index created by an older version lack the filterable fields and have to be indexed again
+		var settings struct {
+			FilterableAttributes []string `json:"filterableAttributes"`
+		}
+		if err := b.request(ctx, http.MethodGet, "/indexes/"+url.PathEscape(b.indexerName)+"/settings", nil, &settings); err != nil {
+			return false, b.checkError(err)
+		}
+		existing := make(container.Set[string])
+		existing.AddMultiple(settings.FilterableAttributes...)
+		for _, attr := range meilisearchFilterableAttributes {
+			if !existing.Contains(attr) {
+				exists = false
+				break
+			}
+		}
+	}
+
+	if err := b.request(ctx, http.MethodPatch, "/indexes/"+url.PathEscape(b.indexerName)+"/settings", meilisearchSettings, nil); err != nil {
+		return false, b.checkError(err)
+	}
+
+	return exists, nil
+}
+
+// SetAvailabilityChangeCallback sets callback that will be triggered when availability changes
+func (b *MeilisearchIndexer) SetAvailabilityChangeCallback(callback func(bool)) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.availabilityCallback = callback
+}
+
+// Ping checks if meilisearch is available
+func (b *MeilisearchIndexer) Ping() bool {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return b.available
+}
+
+// Index will save the index data
+func (b *MeilisearchIndexer) Index(issues []*IndexerData) error {
+	if len(issues) == 0 {
+		return nil
+	}
+
+	docs := make([]map[string]interface{}, 0, len(issues))
+	for _, issue := range issues {
+		docs = append(docs, map[string]interface{}{
+			"id":           issue.ID,
+			"repo_id":      issue.RepoID,
+			"title":        issue.Title,
+			"content":      issue.Content,
+			"comments":     issue.Comments,
+			"is_pull":      issue.IsPull,
+			"is_closed":    issue.IsClosed,
+			"label_ids":    issue.LabelIDs,
+			"milestone_id": issue.MilestoneID,
+			"poster_id":    issue.PosterID,
+			"assignee_ids": issue.AssigneeIDs,
+			"num_comments": issue.NumComments,
+			"created_unix": issue.CreatedUnix,
+			"updated_unix": issue.UpdatedUnix,
+		})
+	}
+
+	err := b.request(graceful.GetManager().HammerContext(), http.MethodPost, "/indexes/"+url.PathEscape(b.indexerName)+"/documents", docs, nil)
+	return b.checkError(err)
+}
+
+// Delete deletes indexes by ids
+func (b *MeilisearchIndexer) Delete(ids ...int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	err := b.request(graceful.GetManager().HammerContext(), http.MethodPost, "/indexes/"+url.PathEscape(b.indexerName)+"/documents/delete-batch", ids, nil)
+	return b.checkError(err)
+}
+
+// meilisearchIDsFilter builds a filter matching one of the ids
+func meilisearchIDsFilter(field string, ids []int64) string {
+	filters := make([]string, 0, len(ids))
+	for _, id := range ids {
+		filters = append(filters, fmt.Sprintf("%s = %d", field, id))
+	}
+	return strings.Join(filters, " OR ")
+}
+
+// buildFilter converts the options into a meilisearch filter expression
+func (b *MeilisearchIndexer) buildFilter(opts *SearchOptions, withState bool) string {
+	filters := make([]string, 0, 10)
+	if len(opts.RepoIDs) > 0 {
+		filters = append(filters, meilisearchIDsFilter("repo_id", opts.RepoIDs))
+	}
+	if !opts.IsPull.IsNone() {
+		filters = append(filters, fmt.Sprintf("is_pull = %t", opts.IsPull.IsTrue()))
+	}
+	if withState && !opts.IsClosed.IsNone() {
+		filters = append(filters, fmt.Sprintf("is_closed = %t", opts.IsClosed.IsTrue()))
+	}
+	for _, labelIDs := range opts.IncludedLabelIDs {
+		filters = append(filters, meilisearchIDsFilter("label_ids", labelIDs))
+	}
+	for _, labelID := range opts.ExcludedLabelIDs {
+		filters = append(filters, fmt.Sprintf("NOT label_ids = %d", labelID))
+	}
+	if len(opts.MilestoneIDs) > 0 {
+		filters = append(filters, meilisearchIDsFilter("milestone_id", opts.MilestoneIDs))
+	}
+	if opts.PosterID != 0 {
+		filters = append(filters, fmt.Sprintf("poster_id = %d", opts.PosterID))
+	}
+	if opts.AssigneeID != 0 {
+		filters = append(filters, fmt.Sprintf("assignee_ids = %d", opts.AssigneeID))
+	}
+	if opts.CreatedAfter != 0 {
+		filters = append(filters, fmt.Sprintf("created_unix >= %d", opts.CreatedAfter))
+	}
+	if opts.CreatedBefore != 0 {
+		filters = append(filters, fmt.Sprintf("created_unix <= %d", opts.CreatedBefore))
+	}
+	if opts.UpdatedAfter != 0 {
+		filters = append(filters, fmt.Sprintf("updated_unix >= %d", opts.UpdatedAfter))
+	}
+	if opts.UpdatedBefore != 0 {
+		filters = append(filters, fmt.Sprintf("updated_unix <= %d", opts.UpdatedBefore))
+	}
+
+	if len(filters) == 1 {
+		return filters[0]
+	}
+	for i := range filters {
+		filters[i] = "(" + filters[i] + ")"
+	}
+	return strings.Join(filters, " AND ")
+}
+
+type meilisearchSearchResult struct {
+	Hits []struct {
+		ID int64 `json:"id"`
+	} `json:"hits"`
+	EstimatedTotalHits int64                       `json:"estimatedTotalHits"`
+	TotalHits          *int64                      `json:"totalHits"`
+	FacetDistribution  map[string]map[string]int64 `json:"facetDistribution"`
+}
+
+// Search searches for issues by given conditions.
+// Returns the matching issue IDs
+func (b *MeilisearchIndexer) Search(ctx context.Context, opts *SearchOptions) (*SearchResult, error) {
+	body := map[string]interface{}{
+		"q":                    opts.Keyword,
+		"attributesToRetrieve": []string{"id"},
+	}
+	if opts.Limit > 0 && opts.Start%opts.Limit == 0 {
+		// page based requests return the exact number of hits instead of an estimation
+		body["hitsPerPage"] = opts.Limit
+		body["page"] = opts.Start/opts.Limit + 1
+	} else {
+		body["limit"] = opts.Limit
+		body["offset"] = opts.Start
+	}
+	if filter := b.buildFilter(opts, true); filter != "" {
+		body["filter"] = filter
+	}
+	if opts.Facets {
+		body["facets"] = []string{"repo_id", "label_ids", "milestone_id"}
+	}
+	switch opts.SortBy {
+	case SortByCreatedDesc:
+		body["sort"] = []string{"created_unix:desc"}
+	case SortByCreatedAsc:
+		body["sort"] = []string{"created_unix:asc"}
+	case SortByUpdatedDesc:
+		body["sort"] = []string{"updated_unix:desc"}
+	case SortByUpdatedAsc:
+		body["sort"] = []string{"updated_unix:asc"}
+	case SortByCommentsDesc:
+		body["sort"] = []string{"num_comments:desc", "created_unix:desc"}
+	case SortByCommentsAsc:
+		body["sort"] = []string{"num_comments:asc", "created_unix:desc"}
+	}
+
+	var result meilisearchSearchResult
+	if err := b.request(ctx, http.MethodPost, "/indexes/"+url.PathEscape(b.indexerName)+"/search", body, &result); err != nil {
+		return nil, b.checkError(err)
+	}
+
+	hits := make([]Match, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		hits = append(hits, Match{
+			ID: hit.ID,
+		})
+	}
+
+	total := result.EstimatedTotalHits
+	if result.TotalHits != nil {
+		total = *result.TotalHits
+	}
+
+	ret := &SearchResult{
+		Total: total,
+		Hits:  hits,
+	}
+
+	if opts.Facets {
+		ret.Facets = newFacets()
+		for name, m := range map[string]map[int64]int64{
+			"repo_id":      ret.Facets.Repos,
+			"label_ids":    ret.Facets.Labels,
+			"milestone_id": ret.Facets.Milestones,
+		} {
+			for value, count := range result.FacetDistribution[name] {
+				id, err := strconv.ParseInt(value, 10, 64)
+				if err != nil || id == 0 {
+					continue
+				}
+				m[id] = count
+			}
+		}
+
+		if err := b.countStates(ctx, opts, ret.Facets); err != nil {
+			return nil, err
+		}
+	}
+
+	return ret, nil
+}
+
+// countStates counts the open and closed issues matching the options without the state filter
+func (b *MeilisearchIndexer) countStates(ctx context.Context, opts *SearchOptions, facets *Facets) error {
+	body := map[string]interface{}{
+		"q":           opts.Keyword,
+		"hitsPerPage": 0,
+		"facets":      []string{"is_closed"},
+	}
+	if filter := b.buildFilter(opts, false); filter != "" {
+		body["filter"] = filter
+	}
+
+	var result meilisearchSearchResult
+	if err := b.request(ctx, http.MethodPost, "/indexes/"+url.PathEscape(b.indexerName)+"/search", body, &result); err != nil {
+		return b.checkError(err)
+	}
+
+	facets.OpenCount = result.FacetDistribution["is_closed"]["false"]
+	facets.ClosedCount = result.FacetDistribution["is_closed"]["true"]
+	return nil
+}
+
+// Close implements indexer
+func (b *MeilisearchIndexer) Close() {
+	select {
+	case <-b.stopTimer:
+	default:
+		close(b.stopTimer)
+	}
+}
+
+// request sends the body as json to the endpoint and decodes the response into result if it is not nil.
+// Write operations are processed asynchronously by meilisearch, the returned task is ignored.
+func (b *MeilisearchIndexer) request(ctx context.Context, method, path string, body, result interface{}) error {
+	var r io.Reader
+	if body != nil {
+		bs, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(bs)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, b.baseURL.String()+path, r)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if b.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+b.apiKey)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		meiliErr := &MeilisearchError{StatusCode: resp.StatusCode}
+		if err := json.NewDecoder(resp.Body).Decode(meiliErr); err != nil || meiliErr.Message == "" {
+			meiliErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return meiliErr
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func (b *MeilisearchIndexer) checkError(err error) error {
+	var opErr *net.OpError
+	if !(errors.As(err, &opErr) && (opErr.Op == "dial" || opErr.Op == "read")) {
+		return err
+	}
+
+	b.setAvailability(false)
+
+	return err
+}
+
+func (b *MeilisearchIndexer) checkAvailability() {
+	if b.Ping() {
+		return
+	}
+
+	// Request the health status to check if meilisearch is available again
+	var health struct {
+		Status string `json:"status"`
+	}
+	err := b.request(graceful.GetManager().ShutdownContext(), http.MethodGet, "/health", nil, &health)
+	if err != nil || health.Status != "available" {
+		b.setAvailability(false)
+		return
+	}
+
+	b.setAvailability(true)
+}
+
+func (b *MeilisearchIndexer) setAvailability(available bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.available == available {
+		return
+	}
+
+	b.available = available
+	if b.availabilityCallback != nil {
+		// Call the callback from within the lock to ensure that the ordering remains correct
+		b.availabilityCallback(b.available)
+	}
+}
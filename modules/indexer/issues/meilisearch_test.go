@@ -0,0 +1,134 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package issues
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMeilisearchIndexer(t *testing.T) {
+	var mu sync.Mutex
+	requests := map[string]string{}
+	indexExists := false
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		key := r.Method + " " + r.URL.Path
+		if key == "POST /indexes/gitea_issues/search" && strings.Contains(string(body), `"facets":["is_closed"]`) {
+			key = "facets"
+		}
+		if r.Method != http.MethodGet {
+			requests[key] = string(body)
+		}
+
+		switch key {
+		case "GET /indexes/gitea_issues":
+			if !indexExists {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"message":"Index gitea_issues not found.","code":"index_not_found"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"uid":"gitea_issues","primaryKey":"id"}`))
+		case "GET /indexes/gitea_issues/settings":
+			// return the settings which were applied before
+			_, _ = w.Write([]byte(requests["PATCH /indexes/gitea_issues/settings"]))
+		case "POST /indexes":
+			indexExists = true
+			w.WriteHeader(http.StatusAccepted)
+		case "PATCH /indexes/gitea_issues/settings", "POST /indexes/gitea_issues/documents", "POST /indexes/gitea_issues/documents/delete-batch":
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = w.Write([]byte(`{"taskUid":1,"status":"enqueued"}`))
+		case "facets":
+			_, _ = w.Write([]byte(`{"hits":[],"totalHits":8,"facetDistribution":{"is_closed":{"false":5,"true":3}}}`))
+		case "POST /indexes/gitea_issues/search":
+			_, _ = w.Write([]byte(`{"hits":[{"id":2},{"id":1}],"totalHits":5,"facetDistribution":{"repo_id":{"1":4,"2":1},"label_ids":{"4":1},"milestone_id":{"0":4,"3":1}}}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+
+	indexer, err := NewMeilisearchIndexer(strings.Replace(server.URL, "http://", "http://:secret@", 1), "gitea_issues")
+	assert.NoError(t, err)
+	defer indexer.Close()
+
+	exists, err := indexer.Init()
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	assert.Contains(t, requests, "POST /indexes")
+	assert.Contains(t, requests["PATCH /indexes/gitea_issues/settings"], `"filterableAttributes":["repo_id","is_pull","is_closed","label_ids","milestone_id","poster_id","assignee_ids","created_unix","updated_unix"]`)
+
+	exists, err = indexer.Init()
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	assert.NoError(t, indexer.Index([]*IndexerData{
+		{ID: 1, RepoID: 2, Title: "Issue search", Content: "As title", Comments: []string{"test1"}, LabelIDs: []int64{3}, CreatedUnix: 1000},
+	}))
+	var docs []map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(requests["POST /indexes/gitea_issues/documents"]), &docs))
+	assert.Len(t, docs, 1)
+	assert.EqualValues(t, 1, docs[0]["id"])
+	assert.EqualValues(t, 2, docs[0]["repo_id"])
+	assert.Equal(t, "Issue search", docs[0]["title"])
+	assert.Equal(t, []interface{}{float64(3)}, docs[0]["label_ids"])
+	assert.EqualValues(t, 1000, docs[0]["created_unix"])
+
+	assert.NoError(t, indexer.Delete(1, 2))
+	assert.Equal(t, "[1,2]", requests["POST /indexes/gitea_issues/documents/delete-batch"])
+
+	res, err := indexer.Search(context.Background(), &SearchOptions{
+		Keyword:          "serach",
+		RepoIDs:          []int64{1, 2},
+		IsClosed:         util.OptionalBoolFalse,
+		IncludedLabelIDs: [][]int64{{4, 6}},
+		ExcludedLabelIDs: []int64{5},
+		SortBy:           SortByCreatedAsc,
+		Facets:           true,
+		Limit:            10,
+		Start:            20,
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 5, res.Total)
+	assert.Equal(t, []Match{{ID: 2}, {ID: 1}}, res.Hits)
+	assert.EqualValues(t, 5, res.Facets.OpenCount)
+	assert.EqualValues(t, 3, res.Facets.ClosedCount)
+	assert.Equal(t, map[int64]int64{1: 4, 2: 1}, res.Facets.Repos)
+	assert.Equal(t, map[int64]int64{4: 1}, res.Facets.Labels)
+	assert.Equal(t, map[int64]int64{3: 1}, res.Facets.Milestones)
+
+	var search map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(requests["POST /indexes/gitea_issues/search"]), &search))
+	assert.Equal(t, "serach", search["q"])
+	assert.Equal(t, "(repo_id = 1 OR repo_id = 2) AND (is_closed = false) AND (label_ids = 4 OR label_ids = 6) AND (NOT label_ids = 5)", search["filter"])
+	assert.Equal(t, []interface{}{"created_unix:asc"}, search["sort"])
+	assert.EqualValues(t, 10, search["hitsPerPage"])
+	assert.EqualValues(t, 3, search["page"])
+	assert.Equal(t, []interface{}{"repo_id", "label_ids", "milestone_id"}, search["facets"])
+
+	assert.NoError(t, json.Unmarshal([]byte(requests["facets"]), &search))
+	assert.Equal(t, "(repo_id = 1 OR repo_id = 2) AND (label_ids = 4 OR label_ids = 6) AND (NOT label_ids = 5)", search["filter"])
+
+	server.Close()
+
+	assert.True(t, indexer.Ping())
+	assert.Error(t, indexer.Delete(1))
+	assert.False(t, indexer.Ping())
+}
@@ -0,0 +1,318 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package issues
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode"
+
+	issues_model "code.gitea.io/gitea/models/issues"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// Query is a parsed issue search query like `is:open label:bug author:alice created:>2023-01-01 crash`.
+// Qualifiers which can not be parsed are kept as part of the keyword.
+type Query struct {
+	Keyword        string
+	IsPull         util.OptionalBool
+	IsClosed       util.OptionalBool
+	Labels         [][]string // issues must have one label of every group, `label:a,b` creates a group
+	ExcludedLabels []string
+	Milestones     []string
+	NoMilestone    bool
+	Author         string
+	Assignee       string
+	CreatedAfter   timeutil.TimeStamp
+	CreatedBefore  timeutil.TimeStamp
+	UpdatedAfter   timeutil.TimeStamp
+	UpdatedBefore  timeutil.TimeStamp
+	SortBy         SortBy
+}
+
+var querySortBy = map[string]SortBy{
+	"created-desc":  SortByCreatedDesc,
+	"created-asc":   SortByCreatedAsc,
+	"updated-desc":  SortByUpdatedDesc,
+	"updated-asc":   SortByUpdatedAsc,
+	"comments-desc": SortByCommentsDesc,
+	"comments-asc":  SortByCommentsAsc,
+	"relevance":     SortByScore,
+}
+
+// ParseQuery parses the search query
+func ParseQuery(q string) *Query {
+	query := &Query{}
+	keywords := make([]string, 0, 5)
+
+	for _, token := range splitQuery(q) {
+		key, value, ok := strings.Cut(token, ":")
+		if !ok || value == "" || !query.parseQualifier(strings.ToLower(key), value) {
+			keywords = append(keywords, token)
+		}
+	}
+
+	query.Keyword = strings.Join(keywords, " ")
+	return query
+}
+
+// parseQualifier applies the qualifier to the query, it returns false if the qualifier is unknown or invalid
+func (q *Query) parseQualifier(key, value string) bool {
+	switch key {
+	case "is":
+		switch strings.ToLower(value) {
+		case "open":
+			q.IsClosed = util.OptionalBoolFalse
+		case "closed":
+			q.IsClosed = util.OptionalBoolTrue
+		case "issue":
+			q.IsPull = util.OptionalBoolFalse
+		case "pr", "pull":
+			q.IsPull = util.OptionalBoolTrue
+		default:
+			return false
+		}
+	case "no":
+		if strings.ToLower(value) != "milestone" {
+			return false
+		}
+		q.NoMilestone = true
+	case "label":
+		q.Labels = append(q.Labels, strings.Split(value, ","))
+	case "-label":
+		q.ExcludedLabels = append(q.ExcludedLabels, strings.Split(value, ",")...)
+	case "milestone":
+		q.Milestones = append(q.Milestones, value)
+	case "author":
+		q.Author = value
+	case "assignee":
+		q.Assignee = value
+	case "created":
+		after, before, ok := parseDateRange(value)
+		if !ok {
+			return false
+		}
+		q.CreatedAfter, q.CreatedBefore = after, before
+	case "updated":
+		after, before, ok := parseDateRange(value)
+		if !ok {
+			return false
+		}
+		q.UpdatedAfter, q.UpdatedBefore = after, before
+	case "sort":
+		sortBy, ok := querySortBy[strings.ToLower(value)]
+		if !ok {
+			sortBy = ParseSortBy(value, false)
+			if string(sortBy) != value {
+				return false
+			}
+		}
+		q.SortBy = sortBy
+	default:
+		return false
+	}
+	return true
+}
+
+// splitQuery splits the query at whitespaces which are not quoted, the quotes are removed
+func splitQuery(q string) []string {
+	tokens := make([]string, 0, 5)
+
+	var sb strings.Builder
+	quoted := false
+	for _, r := range q {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case unicode.IsSpace(r) && !quoted:
+			if sb.Len() > 0 {
+				tokens = append(tokens, sb.String())
+				sb.Reset()
+			}
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	if sb.Len() > 0 {
+		tokens = append(tokens, sb.String())
+	}
+	return tokens
+}
+
+func parseDay(value string) (time.Time, bool) {
+	t, err := time.ParseInLocation("2006-01-02", value, setting.DefaultUILocation)
+	return t, err == nil
+}
+
+// parseDateRange parses `2023-01-01`, `>2023-01-01`, `>=2023-01-01`, `<2023-01-01`, `<=2023-01-01` and
+// `2023-01-01..2023-02-01` into inclusive bounds, an open bound is returned as 0
+func parseDateRange(value string) (after, before timeutil.TimeStamp, ok bool) {
+	startOf := func(t time.Time) timeutil.TimeStamp {
+		return timeutil.TimeStamp(t.Unix())
+	}
+	endOf := func(t time.Time) timeutil.TimeStamp {
+		return timeutil.TimeStamp(t.AddDate(0, 0, 1).Unix() - 1)
+	}
+
+	if from, to, isRange := strings.Cut(value, ".."); isRange {
+		if from != "*" {
+			t, ok := parseDay(from)
+			if !ok {
+				return 0, 0, false
+			}
+			after = startOf(t)
+		}
+		if to != "*" {
+			t, ok := parseDay(to)
+			if !ok {
+				return 0, 0, false
+			}
+			before = endOf(t)
+		}
+		return after, before, after != 0 || before != 0
+	}
+
+	for _, prefix := range []string{">=", "<=", ">", "<", ""} {
+		if !strings.HasPrefix(value, prefix) {
+			continue
+		}
+		t, ok := parseDay(strings.TrimPrefix(value, prefix))
+		if !ok {
+			return 0, 0, false
+		}
+		switch prefix {
+		case ">=":
+			return startOf(t), 0, true
+		case "<=":
+			return 0, endOf(t), true
+		case ">":
+			return endOf(t) + 1, 0, true
+		case "<":
+			return 0, startOf(t) - 1, true
+		default:
+			return startOf(t), endOf(t), true
+		}
+	}
+	return 0, 0, false
+}
+
+// HasQualifiers tests if the query contains more than a keyword
+func (q *Query) HasQualifiers() bool {
+	return !q.IsPull.IsNone() || !q.IsClosed.IsNone() ||
+		len(q.Labels) > 0 || len(q.ExcludedLabels) > 0 || len(q.Milestones) > 0 || q.NoMilestone ||
+		q.Author != "" || q.Assignee != "" ||
+		q.CreatedAfter != 0 || q.CreatedBefore != 0 || q.UpdatedAfter != 0 || q.UpdatedBefore != 0 ||
+		q.SortBy != ""
+}
+
+// Apply resolves the names of the query in the repositories of the options and sets the conditions.
+// The user name `@me` refers to the doer. It returns false if a name can not be resolved, so no issue can match.
+func (q *Query) Apply(ctx context.Context, doer *user_model.User, opts *SearchOptions) (bool, error) {
+	opts.Keyword = q.Keyword
+	if !q.IsPull.IsNone() {
+		opts.IsPull = q.IsPull
+	}
+	if !q.IsClosed.IsNone() {
+		opts.IsClosed = q.IsClosed
+	}
+	if q.SortBy != "" {
+		opts.SortBy = q.SortBy
+	}
+	if q.CreatedAfter != 0 {
+		opts.CreatedAfter = q.CreatedAfter
+	}
+	if q.CreatedBefore != 0 {
+		opts.CreatedBefore = q.CreatedBefore
+	}
+	if q.UpdatedAfter != 0 {
+		opts.UpdatedAfter = q.UpdatedAfter
+	}
+	if q.UpdatedBefore != 0 {
+		opts.UpdatedBefore = q.UpdatedBefore
+	}
+
+	if len(q.Labels) > 0 || len(q.ExcludedLabels) > 0 {
+		names := make(container.Set[string])
+		for _, group := range q.Labels {
+			names.AddMultiple(group...)
+		}
+		names.AddMultiple(q.ExcludedLabels...)
+
+		labels, err := issues_model.GetLabelsByNamesInRepos(ctx, opts.RepoIDs, names.Values())
+		if err != nil {
+			return false, err
+		}
+		labelIDs := make(map[string][]int64, len(labels))
+		for _, label := range labels {
+			labelIDs[label.Name] = append(labelIDs[label.Name], label.ID)
+		}
+
+		for _, group := range q.Labels {
+			ids := make([]int64, 0, len(group))
+			for _, name := range group {
+				ids = append(ids, labelIDs[name]...)
+			}
+			if len(ids) == 0 {
+				return false, nil
+			}
+			opts.IncludedLabelIDs = append(opts.IncludedLabelIDs, ids)
+		}
+		for _, name := range q.ExcludedLabels {
+			opts.ExcludedLabelIDs = append(opts.ExcludedLabelIDs, labelIDs[name]...)
+		}
+	}
+
+	if len(q.Milestones) > 0 {
+		ids, err := issues_model.GetMilestoneIDsByNamesInRepos(ctx, opts.RepoIDs, q.Milestones)
+		if err != nil {
+			return false, err
+		}
+		if len(ids) == 0 && !q.NoMilestone {
+			return false, nil
+		}
+		opts.MilestoneIDs = ids
+	}
+	if q.NoMilestone {
+		opts.MilestoneIDs = append(opts.MilestoneIDs, 0)
+	}
+
+	resolveUser := func(name string) (int64, bool, error) {
+		if name == "@me" {
+			if doer == nil {
+				return 0, false, nil
+			}
+			return doer.ID, true, nil
+		}
+		u, err := user_model.GetUserByName(ctx, name)
+		if err != nil {
+			if user_model.IsErrUserNotExist(err) {
+				return 0, false, nil
+			}
+			return 0, false, err
+		}
+		return u.ID, true, nil
+	}
+
+	if q.Author != "" {
+		id, ok, err := resolveUser(q.Author)
+		if !ok || err != nil {
+			return false, err
+		}
+		opts.PosterID = id
+	}
+	if q.Assignee != "" {
+		id, ok, err := resolveUser(q.Assignee)
+		if !ok || err != nil {
+			return false, err
+		}
+		opts.AssigneeID = id
+	}
+
+	return true, nil
+}
@@ -0,0 +1,117 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package issues
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseQuery(t *testing.T) {
+	day := func(s string) timeutil.TimeStamp {
+		d, _ := time.ParseInLocation("2006-01-02", s, time.Local)
+		return timeutil.TimeStamp(d.Unix())
+	}
+
+	cases := []struct {
+		Query    string
+		Expected *Query
+	}{
+		{
+			Query:    "crash on start",
+			Expected: &Query{Keyword: "crash on start"},
+		},
+		{
+			Query: `is:open is:pr label:bug label:"help wanted",docs -label:wontfix crash`,
+			Expected: &Query{
+				Keyword:        "crash",
+				IsPull:         util.OptionalBoolTrue,
+				IsClosed:       util.OptionalBoolFalse,
+				Labels:         [][]string{{"bug"}, {"help wanted", "docs"}},
+				ExcludedLabels: []string{"wontfix"},
+			},
+		},
+		{
+			Query: `is:closed is:issue milestone:"v1.0" no:milestone author:alice assignee:@me sort:comments-desc`,
+			Expected: &Query{
+				IsPull:      util.OptionalBoolFalse,
+				IsClosed:    util.OptionalBoolTrue,
+				Milestones:  []string{"v1.0"},
+				NoMilestone: true,
+				Author:      "alice",
+				Assignee:    "@me",
+				SortBy:      SortByCommentsDesc,
+			},
+		},
+		{
+			Query:    "created:>2023-01-01 updated:<=2023-02-01",
+			Expected: &Query{CreatedAfter: day("2023-01-02"), UpdatedBefore: day("2023-02-02") - 1},
+		},
+		{
+			Query:    "created:2023-01-01 updated:2023-01-01..* sort:oldest",
+			Expected: &Query{CreatedAfter: day("2023-01-01"), CreatedBefore: day("2023-01-02") - 1, UpdatedAfter: day("2023-01-01"), SortBy: SortByCreatedAsc},
+		},
+		{
+			Query:    "created:<2023-01-01 updated:>=2023-01-01",
+			Expected: &Query{CreatedBefore: day("2023-01-01") - 1, UpdatedAfter: day("2023-01-01")},
+		},
+		{
+			Query:    "is:unknown created:yesterday sort:random http://example.com",
+			Expected: &Query{Keyword: "is:unknown created:yesterday sort:random http://example.com"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Query, func(t *testing.T) {
+			q := ParseQuery(c.Query)
+			assert.Equal(t, c.Expected, q)
+			assert.Equal(t, c.Expected.Keyword != c.Query, q.HasQualifiers())
+		})
+	}
+}
+
+func TestQueryApply(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	doer := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+
+	opts := &SearchOptions{RepoIDs: []int64{1, 3}, IsPull: util.OptionalBoolFalse}
+	ok, err := ParseQuery(`label:label1,orglabel3 -label:label2 milestone:milestone1 author:user2 assignee:@me is:open crash`).Apply(context.Background(), doer, opts)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "crash", opts.Keyword)
+	assert.Equal(t, util.OptionalBoolFalse, opts.IsPull)
+	assert.Equal(t, util.OptionalBoolFalse, opts.IsClosed)
+	assert.Len(t, opts.IncludedLabelIDs, 1)
+	assert.ElementsMatch(t, []int64{1, 3}, opts.IncludedLabelIDs[0])
+	assert.Equal(t, []int64{2}, opts.ExcludedLabelIDs)
+	assert.Equal(t, []int64{1}, opts.MilestoneIDs)
+	assert.EqualValues(t, 2, opts.PosterID)
+	assert.EqualValues(t, 2, opts.AssigneeID)
+
+	for _, q := range []string{"label:unknown", "milestone:unknown", "author:unknown", "label:pull-test-label"} {
+		ok, err = ParseQuery(q).Apply(context.Background(), doer, &SearchOptions{RepoIDs: []int64{1}})
+		assert.NoError(t, err)
+		assert.False(t, ok, q)
+	}
+
+	ok, err = ParseQuery("assignee:@me").Apply(context.Background(), nil, &SearchOptions{RepoIDs: []int64{1}})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	opts = &SearchOptions{RepoIDs: []int64{1}}
+	ok, err = ParseQuery("no:milestone -label:unknown").Apply(context.Background(), doer, opts)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []int64{0}, opts.MilestoneIDs)
+	assert.Empty(t, opts.ExcludedLabelIDs)
+}
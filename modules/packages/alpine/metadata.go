@@ -0,0 +1,253 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package alpine
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/base64"
+	"hash"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/validation"
+)
+
+const (
+	PropertyBranch       = "alpine.branch"
+	PropertyRepository   = "alpine.repository"
+	PropertyArchitecture = "alpine.architecture"
+	PropertyMetadata     = "alpine.metadata"
+
+	SettingKeyPrivate = "alpine.key.private"
+	SettingKeyPublic  = "alpine.key.public"
+
+	// RepositoryPackage and RepositoryVersion name the internal package version which stores the repository indices
+	RepositoryPackage = "_alpine"
+	RepositoryVersion = "_repository"
+
+	ArchitectureNoarch = "noarch"
+
+	pkginfoFile        = ".PKGINFO"
+	maxPkginfoFileSize = 2 * 1024 * 1024
+)
+
+var (
+	ErrMissingPkginfoFile  = util.NewInvalidArgumentErrorf(".PKGINFO file is missing")
+	ErrInvalidName         = util.NewInvalidArgumentErrorf("package name is invalid")
+	ErrInvalidVersion      = util.NewInvalidArgumentErrorf("package version is invalid")
+	ErrInvalidArchitecture = util.NewInvalidArgumentErrorf("package architecture is invalid")
+
+	// https://wiki.alpinelinux.org/wiki/APKBUILD_Reference#pkgname
+	namePattern = regexp.MustCompile(`\A[a-zA-Z0-9][a-zA-Z0-9+_.\-]*\z`)
+	// https://wiki.alpinelinux.org/wiki/APKBUILD_Reference#pkgver
+	versionPattern = regexp.MustCompile(`\A[0-9]+(?:\.[0-9]+)*[a-z]?(?:_(?:alpha|beta|pre|rc|cvs|svn|git|hg|p)[0-9]*)*(?:-r[0-9]+)?\z`)
+	// the architecture is used in paths
+	architecturePattern = regexp.MustCompile(`\A[a-z0-9][a-z0-9_]*\z`)
+)
+
+// Package represents an Alpine package
+type Package struct {
+	Name            string
+	Version         string
+	VersionMetadata VersionMetadata
+	FileMetadata    FileMetadata
+}
+
+// VersionMetadata represents the metadata of an Alpine package version
+type VersionMetadata struct {
+	Description string `json:"description,omitempty"`
+	License     string `json:"license,omitempty"`
+	ProjectURL  string `json:"project_url,omitempty"`
+	Maintainer  string `json:"maintainer,omitempty"`
+}
+
+// FileMetadata represents the metadata of an Alpine package file
+type FileMetadata struct {
+	Checksum      string   `json:"checksum"`
+	Packager      string   `json:"packager,omitempty"`
+	BuildDate     int64    `json:"build_date,omitempty"`
+	InstalledSize int64    `json:"installed_size,omitempty"`
+	Architecture  string   `json:"architecture"`
+	Origin        string   `json:"origin,omitempty"`
+	CommitHash    string   `json:"commit_hash,omitempty"`
+	InstallIf     string   `json:"install_if,omitempty"`
+	Provides      []string `json:"provides,omitempty"`
+	Dependencies  []string `json:"dependencies,omitempty"`
+}
+
+// ParsePackage parses the Alpine package file
+// An Alpine package consists of concatenated gzip compressed tar streams: an optional signature,
+// the control segment containing the .PKGINFO file and the data segment.
+// https://wiki.alpinelinux.org/wiki/Apk_spec
+func ParsePackage(r io.Reader) (*Package, error) {
+	// the reader must implement io.ByteReader so the gzip reader does not read past the end of a stream
+	br := bufio.NewReader(r)
+
+	h := sha1.New()
+
+	gzr, err := gzip.NewReader(&hashingByteReader{br, h})
+	if err != nil {
+		return nil, err
+	}
+	defer gzr.Close()
+
+	for {
+		gzr.Multistream(false)
+
+		tr := tar.NewReader(gzr)
+		for {
+			hd, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			if hd.Name != pkginfoFile {
+				continue
+			}
+
+			p, err := ParsePackageInfo(io.LimitReader(tr, maxPkginfoFileSize))
+			if err != nil {
+				return nil, err
+			}
+
+			// the checksum covers the whole control segment
+			if _, err := io.Copy(io.Discard, gzr); err != nil {
+				return nil, err
+			}
+
+			p.FileMetadata.Checksum = "Q1" + base64.StdEncoding.EncodeToString(h.Sum(nil))
+
+			return p, nil
+		}
+
+		// consume the rest of the stream, the end of the tar archive may be missing
+		if _, err := io.Copy(io.Discard, gzr); err != nil {
+			return nil, err
+		}
+
+		h = sha1.New()
+
+		err = gzr.Reset(&hashingByteReader{br, h})
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, ErrMissingPkginfoFile
+}
+
+// ParsePackageInfo parses a .PKGINFO file
+// https://wiki.alpinelinux.org/wiki/Alpine_package_format
+func ParsePackageInfo(r io.Reader) (*Package, error) {
+	p := &Package{}
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := s.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "pkgname":
+			p.Name = value
+		case "pkgver":
+			p.Version = value
+		case "pkgdesc":
+			p.VersionMetadata.Description = value
+		case "url":
+			p.VersionMetadata.ProjectURL = value
+		case "license":
+			p.VersionMetadata.License = value
+		case "maintainer":
+			p.VersionMetadata.Maintainer = value
+		case "packager":
+			p.FileMetadata.Packager = value
+		case "builddate":
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err == nil {
+				p.FileMetadata.BuildDate = n
+			}
+		case "size":
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err == nil {
+				p.FileMetadata.InstalledSize = n
+			}
+		case "arch":
+			p.FileMetadata.Architecture = value
+		case "origin":
+			p.FileMetadata.Origin = value
+		case "commit":
+			p.FileMetadata.CommitHash = value
+		case "install_if":
+			p.FileMetadata.InstallIf = value
+		case "provides":
+			if value != "" {
+				p.FileMetadata.Provides = append(p.FileMetadata.Provides, value)
+			}
+		case "depend":
+			if value != "" {
+				p.FileMetadata.Dependencies = append(p.FileMetadata.Dependencies, value)
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	if !namePattern.MatchString(p.Name) {
+		return nil, ErrInvalidName
+	}
+	if !versionPattern.MatchString(p.Version) {
+		return nil, ErrInvalidVersion
+	}
+	if !architecturePattern.MatchString(p.FileMetadata.Architecture) {
+		return nil, ErrInvalidArchitecture
+	}
+
+	if !validation.IsValidURL(p.VersionMetadata.ProjectURL) {
+		p.VersionMetadata.ProjectURL = ""
+	}
+
+	return p, nil
+}
+
+// hashingByteReader writes all read bytes to the hash
+type hashingByteReader struct {
+	r *bufio.Reader
+	h hash.Hash
+}
+
+func (r *hashingByteReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.h.Write(p[:n])
+	return n, err
+}
+
+func (r *hashingByteReader) ReadByte() (byte, error) {
+	b, err := r.r.ReadByte()
+	if err == nil {
+		r.h.Write([]byte{b})
+	}
+	return b, err
+}
@@ -0,0 +1,133 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package alpine
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/base64"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	packageName        = "gitea"
+	packageVersion     = "1.0.1-r0"
+	packageDescription = "Package Description"
+	packageProjectURL  = "https://gitea.io"
+	packageMaintainer  = "Gitea Authors <maintainer@gitea.io>"
+)
+
+func createPKGINFOContent(name, version string) []byte {
+	return []byte(`pkgname = ` + name + `
+pkgver = ` + version + `
+pkgdesc = ` + packageDescription + `
+url = ` + packageProjectURL + `
+# comment
+builddate = 1678834800
+packager = Gitea <pack@ag.er>
+size = 123456
+arch = aarch64
+origin = origin
+commit = 1111e709613fbc979651b09ac2bc27c6591a9999
+maintainer = ` + packageMaintainer + `
+license = MIT
+provides = common
+depend = gitea
+depend = gitea-cli`)
+}
+
+func createTarGz(files map[string][]byte) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	for name, content := range files {
+		hdr := &tar.Header{
+			Name: name,
+			Mode: 0o600,
+			Size: int64(len(content)),
+		}
+		tw.WriteHeader(hdr)
+		tw.Write(content)
+	}
+	tw.Close()
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestParsePackage(t *testing.T) {
+	t.Run("MissingPKGINFOFile", func(t *testing.T) {
+		data := createTarGz(map[string][]byte{"dummy.txt": {}})
+
+		p, err := ParsePackage(bytes.NewReader(data))
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrMissingPkginfoFile)
+	})
+
+	t.Run("InvalidPKGINFOFile", func(t *testing.T) {
+		data := createTarGz(map[string][]byte{".PKGINFO": {}})
+
+		p, err := ParsePackage(bytes.NewReader(data))
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrInvalidName)
+	})
+
+	t.Run("Valid", func(t *testing.T) {
+		signature := createTarGz(map[string][]byte{".SIGN.RSA.key.rsa.pub": []byte("signature")})
+		control := createTarGz(map[string][]byte{".PKGINFO": createPKGINFOContent(packageName, packageVersion)})
+		data := createTarGz(map[string][]byte{"usr/bin/gitea": []byte("binary")})
+
+		content := io.MultiReader(bytes.NewReader(signature), bytes.NewReader(control), bytes.NewReader(data))
+
+		p, err := ParsePackage(content)
+		assert.NoError(t, err)
+		assert.NotNil(t, p)
+
+		checksum := sha1.Sum(control)
+		assert.Equal(t, "Q1"+base64.StdEncoding.EncodeToString(checksum[:]), p.FileMetadata.Checksum)
+	})
+}
+
+func TestParsePackageInfo(t *testing.T) {
+	t.Run("InvalidName", func(t *testing.T) {
+		for _, name := range []string{"", "-gitea", "gi/tea"} {
+			p, err := ParsePackageInfo(bytes.NewReader(createPKGINFOContent(name, packageVersion)))
+			assert.Nil(t, p)
+			assert.ErrorIs(t, err, ErrInvalidName)
+		}
+	})
+
+	t.Run("InvalidVersion", func(t *testing.T) {
+		for _, version := range []string{"", "1.0.1-", "v1.0.1-r0", "1.0.1-r"} {
+			p, err := ParsePackageInfo(bytes.NewReader(createPKGINFOContent(packageName, version)))
+			assert.Nil(t, p)
+			assert.ErrorIs(t, err, ErrInvalidVersion)
+		}
+	})
+
+	t.Run("Valid", func(t *testing.T) {
+		p, err := ParsePackageInfo(bytes.NewReader(createPKGINFOContent(packageName, packageVersion)))
+		assert.NoError(t, err)
+		assert.NotNil(t, p)
+
+		assert.Equal(t, packageName, p.Name)
+		assert.Equal(t, packageVersion, p.Version)
+		assert.Equal(t, packageDescription, p.VersionMetadata.Description)
+		assert.Equal(t, packageProjectURL, p.VersionMetadata.ProjectURL)
+		assert.Equal(t, packageMaintainer, p.VersionMetadata.Maintainer)
+		assert.Equal(t, "MIT", p.VersionMetadata.License)
+		assert.Equal(t, "Gitea <pack@ag.er>", p.FileMetadata.Packager)
+		assert.Equal(t, int64(1678834800), p.FileMetadata.BuildDate)
+		assert.Equal(t, int64(123456), p.FileMetadata.InstalledSize)
+		assert.Equal(t, "aarch64", p.FileMetadata.Architecture)
+		assert.Equal(t, "origin", p.FileMetadata.Origin)
+		assert.Equal(t, "1111e709613fbc979651b09ac2bc27c6591a9999", p.FileMetadata.CommitHash)
+		assert.ElementsMatch(t, []string{"common"}, p.FileMetadata.Provides)
+		assert.ElementsMatch(t, []string{"gitea", "gitea-cli"}, p.FileMetadata.Dependencies)
+	})
+}
@@ -0,0 +1,195 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package cargo
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"regexp"
+
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/validation"
+
+	"github.com/hashicorp/go-version"
+)
+
+const PropertyYanked = "cargo.yanked"
+
+var (
+	ErrInvalidName    = util.NewInvalidArgumentErrorf("package name is invalid")
+	ErrInvalidVersion = util.NewInvalidArgumentErrorf("package version is invalid")
+)
+
+// https://doc.rust-lang.org/cargo/reference/manifest.html#the-name-field
+var namePattern = regexp.MustCompile(`\A[a-zA-Z][a-zA-Z0-9\-_]{0,63}\z`)
+
+// the publish request limits the size of the metadata json
+const maxMetadataSize = 10 * 1024 * 1024
+
+// Package represents a Cargo package
+type Package struct {
+	Name        string
+	Version     string
+	Metadata    *Metadata
+	Content     io.Reader
+	ContentSize int64
+}
+
+// Metadata represents the metadata of a Cargo package
+type Metadata struct {
+	Description      string              `json:"description,omitempty"`
+	Authors          []string            `json:"authors,omitempty"`
+	License          string              `json:"license,omitempty"`
+	ProjectURL       string              `json:"project_url,omitempty"`
+	RepositoryURL    string              `json:"repository_url,omitempty"`
+	DocumentationURL string              `json:"documentation_url,omitempty"`
+	Readme           string              `json:"readme,omitempty"`
+	Keywords         []string            `json:"keywords,omitempty"`
+	Categories       []string            `json:"categories,omitempty"`
+	Features         map[string][]string `json:"features,omitempty"`
+	Dependencies     []*Dependency       `json:"dependencies,omitempty"`
+	Links            string              `json:"links,omitempty"`
+}
+
+// Dependency represents a dependency of a Cargo package as stored in the index
+type Dependency struct {
+	Name            string   `json:"name"`
+	Req             string   `json:"req"`
+	Features        []string `json:"features"`
+	Optional        bool     `json:"optional"`
+	DefaultFeatures bool     `json:"default_features"`
+	Target          *string  `json:"target"`
+	Kind            string   `json:"kind"`
+	Registry        *string  `json:"registry"`
+	Package         *string  `json:"package,omitempty"`
+}
+
+// https://doc.rust-lang.org/cargo/reference/registry-web-api.html#publish
+type metadata struct {
+	Name          string              `json:"name"`
+	Vers          string              `json:"vers"`
+	Deps          []dependency        `json:"deps"`
+	Features      map[string][]string `json:"features"`
+	Authors       []string            `json:"authors"`
+	Description   string              `json:"description"`
+	Documentation string              `json:"documentation"`
+	Homepage      string              `json:"homepage"`
+	Readme        string              `json:"readme"`
+	Keywords      []string            `json:"keywords"`
+	Categories    []string            `json:"categories"`
+	License       string              `json:"license"`
+	Repository    string              `json:"repository"`
+	Links         string              `json:"links"`
+}
+
+type dependency struct {
+	Name               string   `json:"name"`
+	VersionReq         string   `json:"version_req"`
+	Features           []string `json:"features"`
+	Optional           bool     `json:"optional"`
+	DefaultFeatures    bool     `json:"default_features"`
+	Target             *string  `json:"target"`
+	Kind               string   `json:"kind"`
+	Registry           *string  `json:"registry"`
+	ExplicitNameInToml string   `json:"explicit_name_in_toml"`
+}
+
+// ParsePackage reads the metadata and the content of a publish request.
+// The request body consists of the length prefixed metadata json followed by the length prefixed crate file.
+func ParsePackage(r io.Reader) (*Package, error) {
+	var size uint32
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return nil, util.NewInvalidArgumentErrorf("publish request is incomplete")
+	}
+	if size > maxMetadataSize {
+		return nil, util.NewInvalidArgumentErrorf("metadata is too large")
+	}
+
+	p, err := parsePackage(io.LimitReader(r, int64(size)))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return nil, util.NewInvalidArgumentErrorf("publish request is incomplete")
+	}
+
+	p.Content = io.LimitReader(r, int64(size))
+	p.ContentSize = int64(size)
+
+	return p, nil
+}
+
+func parsePackage(r io.Reader) (*Package, error) {
+	var meta metadata
+	if err := json.NewDecoder(r).Decode(&meta); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, util.NewInvalidArgumentErrorf("metadata is incomplete")
+		}
+		return nil, util.NewInvalidArgumentErrorf("metadata is invalid: %v", err)
+	}
+
+	if !namePattern.MatchString(meta.Name) {
+		return nil, ErrInvalidName
+	}
+
+	if _, err := version.NewSemver(meta.Vers); err != nil {
+		return nil, ErrInvalidVersion
+	}
+
+	if !validation.IsValidURL(meta.Homepage) {
+		meta.Homepage = ""
+	}
+	if !validation.IsValidURL(meta.Documentation) {
+		meta.Documentation = ""
+	}
+	if !validation.IsValidURL(meta.Repository) {
+		meta.Repository = ""
+	}
+
+	dependencies := make([]*Dependency, 0, len(meta.Deps))
+	for _, dep := range meta.Deps {
+		d := &Dependency{
+			Name:            dep.Name,
+			Req:             dep.VersionReq,
+			Features:        dep.Features,
+			Optional:        dep.Optional,
+			DefaultFeatures: dep.DefaultFeatures,
+			Target:          dep.Target,
+			Kind:            dep.Kind,
+			Registry:        dep.Registry,
+		}
+		// a renamed dependency is listed in the index with its local name and the original package name
+		if dep.ExplicitNameInToml != "" {
+			name := dep.Name
+			d.Name = dep.ExplicitNameInToml
+			d.Package = &name
+		}
+		if d.Features == nil {
+			d.Features = []string{}
+		}
+		dependencies = append(dependencies, d)
+	}
+
+	return &Package{
+		Name:    meta.Name,
+		Version: meta.Vers,
+		Metadata: &Metadata{
+			Description:      meta.Description,
+			Authors:          meta.Authors,
+			License:          meta.License,
+			ProjectURL:       meta.Homepage,
+			RepositoryURL:    meta.Repository,
+			DocumentationURL: meta.Documentation,
+			Readme:           meta.Readme,
+			Keywords:         meta.Keywords,
+			Categories:       meta.Categories,
+			Features:         meta.Features,
+			Dependencies:     dependencies,
+			Links:            meta.Links,
+		},
+	}, nil
+}
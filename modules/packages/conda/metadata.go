@@ -0,0 +1,262 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package conda
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/bzip2"
+	"io"
+	"regexp"
+	"strings"
+
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/validation"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	PropertyChannel = "conda.channel"
+	PropertySubdir  = "conda.subdir"
+	PropertyIndex   = "conda.index"
+
+	SubdirNoarch = "noarch"
+
+	ExtensionTarBz2 = ".tar.bz2"
+	ExtensionConda  = ".conda"
+
+	indexFile         = "info/index.json"
+	aboutFile         = "info/about.json"
+	maxInfoFileSize   = 2 * 1024 * 1024
+	infoArchivePrefix = "info-"
+	infoArchiveSuffix = ".tar.zst"
+)
+
+var (
+	ErrInvalidStructure = util.NewInvalidArgumentErrorf("package structure is invalid")
+	ErrInvalidName      = util.NewInvalidArgumentErrorf("package name is invalid")
+	ErrInvalidVersion   = util.NewInvalidArgumentErrorf("package version is invalid")
+	ErrInvalidBuild     = util.NewInvalidArgumentErrorf("package build is invalid")
+	ErrInvalidSubdir    = util.NewInvalidArgumentErrorf("package subdir is invalid")
+
+	// https://docs.conda.io/projects/conda-build/en/latest/resources/package-spec.html#package-naming-conventions
+	namePattern    = regexp.MustCompile(`\A[a-z0-9][a-z0-9_\-.]*\z`)
+	versionPattern = regexp.MustCompile(`\A[\w.!+]+\z`)
+	buildPattern   = regexp.MustCompile(`\A[\w.+]+\z`)
+	// the subdir is used in paths
+	subdirPattern = regexp.MustCompile(`\A[a-z0-9][a-z0-9_\-]*\z`)
+)
+
+// Package represents a Conda package
+type Package struct {
+	Name            string
+	Version         string
+	Build           string
+	Subdir          string
+	IsCondaPackage  bool
+	VersionMetadata *VersionMetadata
+	// Index contains the raw content of the info/index.json file
+	Index string
+}
+
+// VersionMetadata represents the metadata of a Conda package version
+type VersionMetadata struct {
+	Description      string   `json:"description,omitempty"`
+	Summary          string   `json:"summary,omitempty"`
+	ProjectURL       string   `json:"project_url,omitempty"`
+	License          string   `json:"license,omitempty"`
+	LicenseFamily    string   `json:"license_family,omitempty"`
+	RepositoryURL    string   `json:"repository_url,omitempty"`
+	DocumentationURL string   `json:"documentation_url,omitempty"`
+	Keywords         []string `json:"keywords,omitempty"`
+}
+
+// https://docs.conda.io/projects/conda-build/en/latest/resources/package-spec.html#info-index-json
+type index struct {
+	Name          string `json:"name"`
+	Version       string `json:"version"`
+	Build         string `json:"build"`
+	Subdir        string `json:"subdir"`
+	License       string `json:"license"`
+	LicenseFamily string `json:"license_family"`
+}
+
+type about struct {
+	Description   string   `json:"description"`
+	Summary       string   `json:"summary"`
+	Home          string   `json:"home"`
+	License       string   `json:"license"`
+	LicenseFamily string   `json:"license_family"`
+	DevURL        string   `json:"dev_url"`
+	DocURL        string   `json:"doc_url"`
+	Keywords      []string `json:"keywords"`
+}
+
+// ParsePackageBZ2 parses the Conda package file in the legacy .tar.bz2 format
+// https://docs.conda.io/projects/conda/en/latest/user-guide/concepts/packages.html#package-formats
+func ParsePackageBZ2(r io.Reader) (*Package, error) {
+	return parseInfoTar(bzip2.NewReader(r))
+}
+
+// ParsePackageConda parses the Conda package file in the .conda format.
+// The file is a zip archive containing the zstd compressed tar archive of the info directory.
+// https://docs.conda.io/projects/conda/en/latest/user-guide/concepts/packages.html#package-formats
+func ParsePackageConda(r io.ReaderAt, size int64) (*Package, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, ErrInvalidStructure
+	}
+
+	for _, file := range archive.File {
+		if !strings.HasPrefix(file.Name, infoArchivePrefix) || !strings.HasSuffix(file.Name, infoArchiveSuffix) {
+			continue
+		}
+
+		f, err := archive.Open(file.Name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		zr, err := zstd.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+
+		p, err := parseInfoTar(zr)
+		if err != nil {
+			return nil, err
+		}
+		p.IsCondaPackage = true
+		return p, nil
+	}
+
+	return nil, ErrInvalidStructure
+}
+
+func parseInfoTar(r io.Reader) (*Package, error) {
+	var indexContent, aboutContent []byte
+
+	tr := tar.NewReader(r)
+	for {
+		hd, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, ErrInvalidStructure
+		}
+
+		if hd.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		switch hd.Name {
+		case indexFile:
+			if indexContent, err = readInfoFile(tr, hd.Size); err != nil {
+				return nil, err
+			}
+		case aboutFile:
+			if aboutContent, err = readInfoFile(tr, hd.Size); err != nil {
+				return nil, err
+			}
+		}
+
+		if indexContent != nil && aboutContent != nil {
+			break
+		}
+	}
+
+	if indexContent == nil {
+		return nil, ErrInvalidStructure
+	}
+
+	return parseInfoFiles(indexContent, aboutContent)
+}
+
+func readInfoFile(r io.Reader, size int64) ([]byte, error) {
+	if size > maxInfoFileSize {
+		return nil, ErrInvalidStructure
+	}
+	return io.ReadAll(io.LimitReader(r, maxInfoFileSize))
+}
+
+func parseInfoFiles(indexContent, aboutContent []byte) (*Package, error) {
+	var i index
+	if err := json.Unmarshal(indexContent, &i); err != nil {
+		return nil, ErrInvalidStructure
+	}
+
+	if !namePattern.MatchString(i.Name) {
+		return nil, ErrInvalidName
+	}
+	if !versionPattern.MatchString(i.Version) {
+		return nil, ErrInvalidVersion
+	}
+	if !buildPattern.MatchString(i.Build) {
+		return nil, ErrInvalidBuild
+	}
+	if i.Subdir == "" {
+		i.Subdir = SubdirNoarch
+	}
+	if !subdirPattern.MatchString(i.Subdir) {
+		return nil, ErrInvalidSubdir
+	}
+
+	var a about
+	if aboutContent != nil {
+		if err := json.Unmarshal(aboutContent, &a); err != nil {
+			return nil, ErrInvalidStructure
+		}
+	}
+
+	license := a.License
+	if license == "" {
+		license = i.License
+	}
+	licenseFamily := a.LicenseFamily
+	if licenseFamily == "" {
+		licenseFamily = i.LicenseFamily
+	}
+
+	if !validation.IsValidURL(a.Home) {
+		a.Home = ""
+	}
+	if !validation.IsValidURL(a.DevURL) {
+		a.DevURL = ""
+	}
+	if !validation.IsValidURL(a.DocURL) {
+		a.DocURL = ""
+	}
+
+	return &Package{
+		Name:    i.Name,
+		Version: i.Version,
+		Build:   i.Build,
+		Subdir:  i.Subdir,
+		VersionMetadata: &VersionMetadata{
+			Description:      a.Description,
+			Summary:          a.Summary,
+			ProjectURL:       a.Home,
+			License:          license,
+			LicenseFamily:    licenseFamily,
+			RepositoryURL:    a.DevURL,
+			DocumentationURL: a.DocURL,
+			Keywords:         a.Keywords,
+		},
+		Index: string(indexContent),
+	}, nil
+}
+
+// FileName returns the expected name of a package file
+func (p *Package) FileName() string {
+	ext := ExtensionTarBz2
+	if p.IsCondaPackage {
+		ext = ExtensionConda
+	}
+	return p.Name + "-" + p.Version + "-" + p.Build + ext
+}
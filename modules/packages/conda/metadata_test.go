@@ -0,0 +1,145 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package conda
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/dsnet/compress/bzip2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	packageName        = "gitea"
+	packageVersion     = "1.0.1"
+	packageBuild       = "py39_0"
+	packageSubdir      = "linux-64"
+	description        = "Package Description"
+	projectURL         = "https://gitea.com"
+	repositoryURL      = "https://gitea.com/gitea/gitea"
+	documentationURL   = "https://docs.gitea.com"
+	packageLicense     = "MIT"
+	packageLicenseType = "MIT"
+)
+
+func createInfoTar(index, about string) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range map[string]string{"info/index.json": index, "info/about.json": about} {
+		if content == "" {
+			continue
+		}
+		tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0o600,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		})
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	return buf.Bytes()
+}
+
+func createIndexJSON(name, version, build string) string {
+	return `{"name":"` + name + `","version":"` + version + `","build":"` + build + `","build_number":0,"subdir":"` + packageSubdir + `","depends":["python >=3.9"],"license":"` + packageLicense + `"}`
+}
+
+const aboutJSON = `{"description":"` + description + `","home":"` + projectURL + `","dev_url":"` + repositoryURL + `","doc_url":"` + documentationURL + `","license_family":"` + packageLicenseType + `","keywords":["gitea"]}`
+
+func TestParsePackage(t *testing.T) {
+	createArchiveTarBz2 := func(content []byte) *bytes.Reader {
+		var buf bytes.Buffer
+		bw, _ := bzip2.NewWriter(&buf, nil)
+		io.Copy(bw, bytes.NewReader(content))
+		bw.Close()
+		return bytes.NewReader(buf.Bytes())
+	}
+
+	createArchiveConda := func(content []byte) *bytes.Reader {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		w, _ := zw.Create("info-" + packageName + "-" + packageVersion + "-" + packageBuild + ".tar.zst")
+		zsw, _ := zstd.NewWriter(w)
+		zsw.Write(content)
+		zsw.Close()
+		zw.Close()
+		return bytes.NewReader(buf.Bytes())
+	}
+
+	t.Run("MissingIndexFile", func(t *testing.T) {
+		p, err := ParsePackageBZ2(createArchiveTarBz2(createInfoTar("", aboutJSON)))
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrInvalidStructure)
+
+		data := createArchiveConda(createInfoTar("", aboutJSON))
+		p, err = ParsePackageConda(data, int64(data.Len()))
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrInvalidStructure)
+	})
+
+	t.Run("InvalidName", func(t *testing.T) {
+		for _, name := range []string{"", "-gitea", "Gitea", "gi/tea"} {
+			p, err := ParsePackageBZ2(createArchiveTarBz2(createInfoTar(createIndexJSON(name, packageVersion, packageBuild), "")))
+			assert.Nil(t, p)
+			assert.ErrorIs(t, err, ErrInvalidName)
+		}
+	})
+
+	t.Run("InvalidVersion", func(t *testing.T) {
+		for _, version := range []string{"", "1.0-1", "1.0 1"} {
+			p, err := ParsePackageBZ2(createArchiveTarBz2(createInfoTar(createIndexJSON(packageName, version, packageBuild), "")))
+			assert.Nil(t, p)
+			assert.ErrorIs(t, err, ErrInvalidVersion)
+		}
+	})
+
+	t.Run("InvalidBuild", func(t *testing.T) {
+		p, err := ParsePackageBZ2(createArchiveTarBz2(createInfoTar(createIndexJSON(packageName, packageVersion, "py-39"), "")))
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrInvalidBuild)
+	})
+
+	t.Run("Valid", func(t *testing.T) {
+		index := createIndexJSON(packageName, packageVersion, packageBuild)
+		info := createInfoTar(index, aboutJSON)
+
+		check := func(t *testing.T, p *Package, err error, isConda bool) {
+			assert.NoError(t, err)
+			assert.NotNil(t, p)
+
+			assert.Equal(t, packageName, p.Name)
+			assert.Equal(t, packageVersion, p.Version)
+			assert.Equal(t, packageBuild, p.Build)
+			assert.Equal(t, packageSubdir, p.Subdir)
+			assert.Equal(t, isConda, p.IsCondaPackage)
+			assert.Equal(t, index, p.Index)
+			assert.Equal(t, description, p.VersionMetadata.Description)
+			assert.Equal(t, projectURL, p.VersionMetadata.ProjectURL)
+			assert.Equal(t, repositoryURL, p.VersionMetadata.RepositoryURL)
+			assert.Equal(t, documentationURL, p.VersionMetadata.DocumentationURL)
+			assert.Equal(t, packageLicense, p.VersionMetadata.License)
+			assert.Equal(t, packageLicenseType, p.VersionMetadata.LicenseFamily)
+			assert.ElementsMatch(t, []string{"gitea"}, p.VersionMetadata.Keywords)
+		}
+
+		t.Run(".tar.bz2", func(t *testing.T) {
+			p, err := ParsePackageBZ2(createArchiveTarBz2(info))
+			check(t, p, err, false)
+			assert.Equal(t, packageName+"-"+packageVersion+"-"+packageBuild+".tar.bz2", p.FileName())
+		})
+
+		t.Run(".conda", func(t *testing.T) {
+			data := createArchiveConda(info)
+			p, err := ParsePackageConda(data, int64(data.Len()))
+			check(t, p, err, true)
+			assert.Equal(t, packageName+"-"+packageVersion+"-"+packageBuild+".conda", p.FileName())
+		})
+	})
+}
@@ -0,0 +1,313 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package debian
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/validation"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+const (
+	PropertyDistribution = "debian.distribution"
+	PropertyComponent    = "debian.component"
+	PropertyArchitecture = "debian.architecture"
+	PropertyControl      = "debian.control"
+
+	SettingKeyPrivate = "debian.key.private"
+	SettingKeyPublic  = "debian.key.public"
+
+	// RepositoryPackage and RepositoryVersion name the internal package version which stores the repository files
+	RepositoryPackage = "_debian"
+	RepositoryVersion = "_repository"
+
+	ArchitectureAll = "all"
+
+	controlTar         = "control.tar"
+	maxControlFileSize = 2 * 1024 * 1024
+)
+
+var (
+	ErrMissingControlFile     = util.NewInvalidArgumentErrorf("control file is missing")
+	ErrUnsupportedCompression = util.NewInvalidArgumentErrorf("unsupported compression algorithm")
+	ErrInvalidName            = util.NewInvalidArgumentErrorf("package name is invalid")
+	ErrInvalidVersion         = util.NewInvalidArgumentErrorf("package version is invalid")
+	ErrInvalidArchitecture    = util.NewInvalidArgumentErrorf("package architecture is invalid")
+
+	// https://www.debian.org/doc/debian-policy/ch-controlfields.html#source
+	namePattern = regexp.MustCompile(`\A[a-z0-9][a-z0-9+\-.]+\z`)
+	// https://www.debian.org/doc/debian-policy/ch-controlfields.html#version
+	versionPattern = regexp.MustCompile(`\A(?:[0-9]:)?[a-zA-Z0-9.+~]+(?:-[a-zA-Z0-9.+\-~]+)?\z`)
+	// the architecture is used in file and directory names
+	architecturePattern = regexp.MustCompile(`\A[a-z0-9][a-z0-9\-]*\z`)
+)
+
+// Package represents a Debian package
+type Package struct {
+	Name         string
+	Version      string
+	Architecture string
+	Control      string
+	Metadata     *Metadata
+}
+
+// Metadata represents the metadata of a Debian package
+type Metadata struct {
+	Maintainer   string   `json:"maintainer,omitempty"`
+	ProjectURL   string   `json:"project_url,omitempty"`
+	Description  string   `json:"description,omitempty"`
+	Dependencies []string `json:"dependencies,omitempty"`
+}
+
+// ParsePackage parses the Debian package file
+// https://manpages.debian.org/bullseye/dpkg-dev/deb.5.en.html
+func ParsePackage(r io.Reader) (*Package, error) {
+	ar, err := newArReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		name, size, err := ar.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if !strings.HasPrefix(name, controlTar) {
+			continue
+		}
+
+		var inner io.Reader
+		switch name[len(controlTar):] {
+		case "":
+			inner = io.LimitReader(ar, size)
+		case ".gz":
+			gzr, err := gzip.NewReader(io.LimitReader(ar, size))
+			if err != nil {
+				return nil, err
+			}
+			defer gzr.Close()
+
+			inner = gzr
+		case ".xz":
+			xzr, err := xz.NewReader(io.LimitReader(ar, size))
+			if err != nil {
+				return nil, err
+			}
+
+			inner = xzr
+		case ".zst":
+			zr, err := zstd.NewReader(io.LimitReader(ar, size))
+			if err != nil {
+				return nil, err
+			}
+			defer zr.Close()
+
+			inner = zr
+		default:
+			return nil, ErrUnsupportedCompression
+		}
+
+		return parseControlTar(inner)
+	}
+
+	return nil, ErrMissingControlFile
+}
+
+func parseControlTar(r io.Reader) (*Package, error) {
+	tr := tar.NewReader(r)
+	for {
+		hd, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if hd.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if strings.TrimPrefix(hd.Name, "./") == "control" {
+			return ParseControlFile(io.LimitReader(tr, maxControlFileSize))
+		}
+	}
+
+	return nil, ErrMissingControlFile
+}
+
+// ParseControlFile parses a Debian control file to retrieve the metadata
+// https://www.debian.org/doc/debian-policy/ch-controlfields.html#binary-package-control-files-debian-control
+func ParseControlFile(r io.Reader) (*Package, error) {
+	p := &Package{
+		Metadata: &Metadata{},
+	}
+
+	key := ""
+	var depends strings.Builder
+	var control strings.Builder
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := s.Text()
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+
+		if line[0] == ' ' || line[0] == '\t' {
+			switch key {
+			case "Description":
+				p.Metadata.Description += "\n" + line
+			case "Depends":
+				depends.WriteString(trimmed)
+			}
+		} else {
+			parts := strings.SplitN(trimmed, ":", 2)
+			if len(parts) < 2 {
+				continue
+			}
+
+			key = parts[0]
+			value := strings.TrimSpace(parts[1])
+			switch key {
+			case "Package":
+				p.Name = value
+			case "Version":
+				p.Version = value
+			case "Architecture":
+				p.Architecture = value
+			case "Maintainer":
+				a, err := mail.ParseAddress(value)
+				if err != nil || a.Name == "" {
+					p.Metadata.Maintainer = value
+				} else {
+					p.Metadata.Maintainer = a.Name
+				}
+			case "Description":
+				p.Metadata.Description = value
+			case "Depends":
+				depends.WriteString(value)
+			case "Homepage":
+				if validation.IsValidURL(value) {
+					p.Metadata.ProjectURL = value
+				}
+			}
+		}
+
+		control.WriteString(line)
+		control.WriteString("\n")
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	if !namePattern.MatchString(p.Name) {
+		return nil, ErrInvalidName
+	}
+	if !versionPattern.MatchString(p.Version) {
+		return nil, ErrInvalidVersion
+	}
+	if !architecturePattern.MatchString(p.Architecture) {
+		return nil, ErrInvalidArchitecture
+	}
+
+	dependencies := strings.Split(depends.String(), ",")
+	for i := range dependencies {
+		dependencies[i] = strings.TrimSpace(dependencies[i])
+	}
+	if depends.Len() > 0 {
+		p.Metadata.Dependencies = dependencies
+	}
+
+	p.Control = strings.TrimSpace(control.String())
+
+	return p, nil
+}
+
+// arReader reads the members of an ar archive
+// https://manpages.debian.org/bullseye/manpages-dev/ar.5.en.html
+type arReader struct {
+	r      *bufio.Reader
+	remain int64
+	pad    int64
+}
+
+const (
+	arMagic      = "!<arch>\n"
+	arHeaderSize = 60
+)
+
+func newArReader(r io.Reader) (*arReader, error) {
+	br := bufio.NewReader(r)
+
+	magic := make([]byte, len(arMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != arMagic {
+		return nil, util.NewInvalidArgumentErrorf("not a Debian package")
+	}
+
+	return &arReader{r: br}, nil
+}
+
+// Next advances to the next member and returns its name and size
+func (ar *arReader) Next() (string, int64, error) {
+	if _, err := io.CopyN(io.Discard, ar.r, ar.remain+ar.pad); err != nil {
+		return "", 0, err
+	}
+
+	header := make([]byte, arHeaderSize)
+	if _, err := io.ReadFull(ar.r, header); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return "", 0, util.NewInvalidArgumentErrorf("invalid ar header")
+		}
+		return "", 0, err
+	}
+	if !bytes.Equal(header[58:60], []byte("`\n")) {
+		return "", 0, util.NewInvalidArgumentErrorf("invalid ar header")
+	}
+
+	var size int64
+	for _, c := range bytes.TrimSpace(header[48:58]) {
+		if c < '0' || c > '9' {
+			return "", 0, util.NewInvalidArgumentErrorf("invalid ar header")
+		}
+		size = size*10 + int64(c-'0')
+	}
+
+	ar.remain = size
+	ar.pad = size % 2
+
+	// GNU ar terminates the names with a slash
+	name := strings.TrimSuffix(string(bytes.TrimSpace(header[0:16])), "/")
+
+	return name, size, nil
+}
+
+func (ar *arReader) Read(p []byte) (int, error) {
+	if ar.remain == 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > ar.remain {
+		p = p[:ar.remain]
+	}
+	n, err := ar.r.Read(p)
+	ar.remain -= int64(n)
+	return n, err
+}
@@ -0,0 +1,131 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package goproxy
+
+import (
+	"archive/zip"
+	"io"
+	"strings"
+
+	"code.gitea.io/gitea/modules/util"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+const (
+	PropertyGoMod = "go.mod"
+
+	maxGoModFileSize = 16 * 1024 * 1024 // https://go.dev/ref/mod#zip-path-size-constraints
+)
+
+var (
+	ErrInvalidStructure  = util.NewInvalidArgumentErrorf("package has invalid structure")
+	ErrInvalidModulePath = util.NewInvalidArgumentErrorf("module path is invalid")
+	ErrInvalidVersion    = util.NewInvalidArgumentErrorf("module version is invalid")
+	ErrGoModFileTooLarge = util.NewInvalidArgumentErrorf("go.mod file is too large")
+	ErrGoModMismatch     = util.NewInvalidArgumentErrorf("go.mod module path does not match the module path of the archive")
+)
+
+// Package represents a Go module
+type Package struct {
+	Name    string
+	Version string
+	GoMod   string
+}
+
+// moduleRoot returns the "<module path>@<version>" directory a file of the module zip file is located in
+func moduleRoot(filename string) (string, bool) {
+	name, version, ok := strings.Cut(filename, "@")
+	if !ok {
+		return "", false
+	}
+	version, _, ok = strings.Cut(version, "/")
+	if !ok {
+		return "", false
+	}
+	return name + "@" + version, true
+}
+
+// ParsePackage parses the module zip file and validates its content against the module path.
+// All files must be located in the "<module path>@<version>/" directory.
+// https://go.dev/ref/mod#zip-files
+func ParsePackage(r io.ReaderAt, size int64) (*Package, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, util.NewInvalidArgumentErrorf("invalid zip file: %v", err)
+	}
+
+	if len(archive.File) == 0 {
+		return nil, ErrInvalidStructure
+	}
+
+	// the module path and version are only taken from the archive if all files share them
+	var root string
+	for _, file := range archive.File {
+		fileRoot, ok := moduleRoot(file.Name)
+		if !ok || (root != "" && fileRoot != root) {
+			return nil, ErrInvalidStructure
+		}
+		root = fileRoot
+	}
+
+	name, version, _ := strings.Cut(root, "@")
+
+	if err := module.CheckPath(name); err != nil {
+		return nil, ErrInvalidModulePath
+	}
+	if err := module.Check(name, version); err != nil || module.CanonicalVersion(version) != version {
+		return nil, ErrInvalidVersion
+	}
+
+	p := &Package{
+		Name:    name,
+		Version: version,
+	}
+
+	prefix := root + "/"
+
+	for _, file := range archive.File {
+		filename := strings.TrimPrefix(file.Name, prefix)
+		if filename == "" {
+			continue
+		}
+		if err := module.CheckFilePath(strings.TrimSuffix(filename, "/")); err != nil {
+			return nil, ErrInvalidStructure
+		}
+
+		if filename != "go.mod" {
+			continue
+		}
+
+		if file.UncompressedSize64 > maxGoModFileSize {
+			return nil, ErrGoModFileTooLarge
+		}
+
+		f, err := archive.Open(file.Name)
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(io.LimitReader(f, maxGoModFileSize))
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if modfile.ModulePath(data) != name {
+			return nil, ErrGoModMismatch
+		}
+
+		p.GoMod = string(data)
+	}
+
+	// modules without a go.mod file get a synthesized one
+	// https://go.dev/ref/mod#non-module-compat
+	if p.GoMod == "" {
+		p.GoMod = "module " + p.Name
+	}
+
+	return p, nil
+}
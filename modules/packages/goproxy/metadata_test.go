@@ -0,0 +1,148 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package goproxy
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	packageName    = "gitea.com/go-gitea/gitea"
+	packageVersion = "v0.0.1"
+)
+
+func TestParsePackage(t *testing.T) {
+	type file struct {
+		Name    string
+		Content string
+	}
+
+	// the files are added in the given order, the result must not depend on it
+	createArchive := func(files []file) *bytes.Reader {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for _, f := range files {
+			w, _ := zw.Create(f.Name)
+			w.Write([]byte(f.Content))
+		}
+		zw.Close()
+		return bytes.NewReader(buf.Bytes())
+	}
+
+	t.Run("InvalidArchive", func(t *testing.T) {
+		data := bytes.NewReader([]byte("module " + packageName))
+
+		p, err := ParsePackage(data, int64(data.Len()))
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, util.ErrInvalidArgument)
+	})
+
+	t.Run("EmptyPackage", func(t *testing.T) {
+		data := createArchive(nil)
+
+		p, err := ParsePackage(data, int64(data.Len()))
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrInvalidStructure)
+	})
+
+	t.Run("InvalidNameOrVersionStructure", func(t *testing.T) {
+		data := createArchive([]file{
+			{packageName + "/" + packageVersion + "/go.mod", ""},
+		})
+
+		p, err := ParsePackage(data, int64(data.Len()))
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrInvalidStructure)
+	})
+
+	t.Run("InvalidModulePath", func(t *testing.T) {
+		data := createArchive([]file{
+			{"-invalid@" + packageVersion + "/go.mod", ""},
+		})
+
+		p, err := ParsePackage(data, int64(data.Len()))
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrInvalidModulePath)
+	})
+
+	t.Run("InvalidVersion", func(t *testing.T) {
+		for _, version := range []string{"1.0.0", "v1.0", "v2.0.0"} {
+			data := createArchive([]file{
+				{packageName + "@" + version + "/go.mod", ""},
+			})
+
+			p, err := ParsePackage(data, int64(data.Len()))
+			assert.Nil(t, p)
+			assert.ErrorIs(t, err, ErrInvalidVersion)
+		}
+	})
+
+	t.Run("FileOutsideModule", func(t *testing.T) {
+		files := []file{
+			{packageName + "@" + packageVersion + "/go.mod", "module " + packageName},
+			{"other@" + packageVersion + "/file.go", "package other"},
+		}
+
+		for _, files := range [][]file{files, {files[1], files[0]}} {
+			data := createArchive(files)
+
+			p, err := ParsePackage(data, int64(data.Len()))
+			assert.Nil(t, p)
+			assert.ErrorIs(t, err, ErrInvalidStructure)
+		}
+	})
+
+	t.Run("FileOfOtherVersion", func(t *testing.T) {
+		data := createArchive([]file{
+			{packageName + "@" + packageVersion + "/go.mod", "module " + packageName},
+			{packageName + "@v0.0.2/main.go", "package main"},
+		})
+
+		p, err := ParsePackage(data, int64(data.Len()))
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrInvalidStructure)
+	})
+
+	t.Run("GoModMismatch", func(t *testing.T) {
+		data := createArchive([]file{
+			{packageName + "@" + packageVersion + "/go.mod", "module gitea.com/other/module"},
+		})
+
+		p, err := ParsePackage(data, int64(data.Len()))
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrGoModMismatch)
+	})
+
+	t.Run("Valid", func(t *testing.T) {
+		data := createArchive([]file{
+			{packageName + "@" + packageVersion + "/subdir/go.mod", "invalid"},
+			{packageName + "@" + packageVersion + "/go.mod", "module " + packageName},
+			{packageName + "@" + packageVersion + "/main.go", "package main"},
+		})
+
+		p, err := ParsePackage(data, int64(data.Len()))
+		assert.NotNil(t, p)
+		assert.NoError(t, err)
+		assert.Equal(t, packageName, p.Name)
+		assert.Equal(t, packageVersion, p.Version)
+		assert.Equal(t, "module "+packageName, p.GoMod)
+	})
+
+	t.Run("ValidWithoutGoMod", func(t *testing.T) {
+		data := createArchive([]file{
+			{packageName + "@" + packageVersion + "/main.go", "package main"},
+		})
+
+		p, err := ParsePackage(data, int64(data.Len()))
+		assert.NotNil(t, p)
+		assert.NoError(t, err)
+		assert.Equal(t, "module "+packageName, p.GoMod)
+	})
+}
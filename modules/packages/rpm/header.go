@@ -0,0 +1,188 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package rpm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"code.gitea.io/gitea/modules/util"
+)
+
+// https://rpm-software-management.github.io/rpm/manual/format.html
+const (
+	leadSize         = 96
+	headerIntroSize  = 16
+	headerIndexSize  = 16
+	maxHeaderEntries = 64 * 1024
+	maxHeaderData    = 64 * 1024 * 1024
+)
+
+var (
+	leadMagic   = []byte{0xed, 0xab, 0xee, 0xdb}
+	headerMagic = []byte{0x8e, 0xad, 0xe8, 0x01}
+
+	errInvalidHeader = util.NewInvalidArgumentErrorf("rpm header is invalid")
+)
+
+type tagType uint32
+
+const (
+	typeNull tagType = iota
+	typeChar
+	typeInt8
+	typeInt16
+	typeInt32
+	typeInt64
+	typeString
+	typeBin
+	typeStringArray
+	typeI18NString
+)
+
+type indexEntry struct {
+	Type   tagType
+	Offset uint32
+	Count  uint32
+}
+
+// header represents a parsed rpm header structure
+type header struct {
+	entries map[int32]*indexEntry
+	data    []byte
+	// size is the length of the header in bytes
+	size int64
+}
+
+func readHeader(r io.Reader) (*header, error) {
+	intro := make([]byte, headerIntroSize)
+	if _, err := io.ReadFull(r, intro); err != nil {
+		return nil, errInvalidHeader
+	}
+	if !bytes.Equal(intro[0:4], headerMagic) {
+		return nil, errInvalidHeader
+	}
+
+	count := binary.BigEndian.Uint32(intro[8:12])
+	size := binary.BigEndian.Uint32(intro[12:16])
+	if count > maxHeaderEntries || size > maxHeaderData {
+		return nil, errInvalidHeader
+	}
+
+	index := make([]byte, count*headerIndexSize)
+	if _, err := io.ReadFull(r, index); err != nil {
+		return nil, errInvalidHeader
+	}
+
+	h := &header{
+		entries: make(map[int32]*indexEntry, count),
+		data:    make([]byte, size),
+		size:    int64(headerIntroSize + len(index) + int(size)),
+	}
+	if _, err := io.ReadFull(r, h.data); err != nil {
+		return nil, errInvalidHeader
+	}
+
+	for i := 0; i < len(index); i += headerIndexSize {
+		e := &indexEntry{
+			Type:   tagType(binary.BigEndian.Uint32(index[i+4 : i+8])),
+			Offset: binary.BigEndian.Uint32(index[i+8 : i+12]),
+			Count:  binary.BigEndian.Uint32(index[i+12 : i+16]),
+		}
+		if e.Offset > size {
+			return nil, errInvalidHeader
+		}
+		h.entries[int32(binary.BigEndian.Uint32(index[i:i+4]))] = e
+	}
+
+	return h, nil
+}
+
+// String returns the value of a string tag or the first value of a string array tag
+func (h *header) String(tag int32) string {
+	if values := h.Strings(tag); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Strings returns the values of a string or string array tag
+func (h *header) Strings(tag int32) []string {
+	e, ok := h.entries[tag]
+	if !ok {
+		return nil
+	}
+
+	count := int(e.Count)
+	switch e.Type {
+	case typeString:
+		count = 1
+	case typeStringArray, typeI18NString:
+	default:
+		return nil
+	}
+
+	values := make([]string, 0, count)
+	data := h.data[e.Offset:]
+	for i := 0; i < count; i++ {
+		end := bytes.IndexByte(data, 0)
+		if end == -1 {
+			break
+		}
+		values = append(values, string(data[:end]))
+		data = data[end+1:]
+	}
+	return values
+}
+
+// Int returns the first value of an integer tag
+func (h *header) Int(tag int32) uint64 {
+	if values := h.Ints(tag); len(values) > 0 {
+		return values[0]
+	}
+	return 0
+}
+
+// Ints returns the values of an integer tag
+func (h *header) Ints(tag int32) []uint64 {
+	e, ok := h.entries[tag]
+	if !ok {
+		return nil
+	}
+
+	var width uint32
+	switch e.Type {
+	case typeChar, typeInt8:
+		width = 1
+	case typeInt16:
+		width = 2
+	case typeInt32:
+		width = 4
+	case typeInt64:
+		width = 8
+	default:
+		return nil
+	}
+
+	if uint64(e.Offset)+uint64(e.Count)*uint64(width) > uint64(len(h.data)) {
+		return nil
+	}
+
+	values := make([]uint64, 0, e.Count)
+	for i := uint32(0); i < e.Count; i++ {
+		b := h.data[e.Offset+i*width : e.Offset+(i+1)*width]
+		switch width {
+		case 1:
+			values = append(values, uint64(b[0]))
+		case 2:
+			values = append(values, uint64(binary.BigEndian.Uint16(b)))
+		case 4:
+			values = append(values, uint64(binary.BigEndian.Uint32(b)))
+		case 8:
+			values = append(values, binary.BigEndian.Uint64(b))
+		}
+	}
+	return values
+}
@@ -0,0 +1,359 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package rpm
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/validation"
+)
+
+const (
+	PropertyArchitecture = "rpm.architecture"
+	PropertyMetadata     = "rpm.metadata"
+
+	SettingKeyPrivate = "rpm.key.private"
+	SettingKeyPublic  = "rpm.key.public"
+
+	// RepositoryPackage and RepositoryVersion name the internal package version which stores the repodata files
+	RepositoryPackage = "_rpm"
+	RepositoryVersion = "_repository"
+)
+
+// https://github.com/rpm-software-management/rpm/blob/master/include/rpm/rpmtag.h
+const (
+	sigTagPayloadSize = 1007
+	sigTagLongArchive = 271
+
+	tagName            = 1000
+	tagVersion         = 1001
+	tagRelease         = 1002
+	tagEpoch           = 1003
+	tagSummary         = 1004
+	tagDescription     = 1005
+	tagBuildTime       = 1006
+	tagBuildHost       = 1007
+	tagSize            = 1009
+	tagVendor          = 1011
+	tagLicense         = 1014
+	tagPackager        = 1015
+	tagGroup           = 1016
+	tagURL             = 1020
+	tagArch            = 1022
+	tagFileModes       = 1030
+	tagFileFlags       = 1037
+	tagSourceRPM       = 1044
+	tagArchiveSize     = 1046
+	tagProvideName     = 1047
+	tagRequireFlags    = 1048
+	tagRequireName     = 1049
+	tagRequireVersion  = 1050
+	tagConflictFlags   = 1053
+	tagConflictName    = 1054
+	tagConflictVersion = 1055
+	tagChangelogTime   = 1080
+	tagChangelogName   = 1081
+	tagChangelogText   = 1082
+	tagObsoleteName    = 1090
+	tagProvideFlags    = 1112
+	tagProvideVersion  = 1113
+	tagObsoleteFlags   = 1114
+	tagObsoleteVersion = 1115
+	tagDirIndexes      = 1116
+	tagBaseNames       = 1117
+	tagDirNames        = 1118
+	tagLongSize        = 5009
+
+	senseLess       = 1 << 1
+	senseGreater    = 1 << 2
+	senseEqual      = 1 << 3
+	sensePrereq     = 1 << 6
+	senseScriptPre  = 1 << 9
+	senseScriptPost = 1 << 10
+
+	fileFlagGhost = 1 << 6
+	fileModeType  = 0o170000
+	fileModeDir   = 0o040000
+)
+
+var (
+	ErrInvalidName         = util.NewInvalidArgumentErrorf("package name is invalid")
+	ErrInvalidVersion      = util.NewInvalidArgumentErrorf("package version is invalid")
+	ErrInvalidArchitecture = util.NewInvalidArgumentErrorf("package architecture is invalid")
+
+	namePattern = regexp.MustCompile(`\A[a-zA-Z0-9][a-zA-Z0-9_.+\-]*\z`)
+	// the version and release must not contain dashes because they are separated by one
+	versionPattern = regexp.MustCompile(`\A[a-zA-Z0-9_.+~^]+\z`)
+	// the architecture is used in file and directory names
+	architecturePattern = regexp.MustCompile(`\A[a-zA-Z0-9_]+\z`)
+)
+
+// Package represents a RPM package
+type Package struct {
+	Name            string
+	Version         string
+	VersionMetadata *VersionMetadata
+	FileMetadata    *FileMetadata
+}
+
+// VersionMetadata represents the metadata of a package version
+type VersionMetadata struct {
+	License     string `json:"license,omitempty"`
+	ProjectURL  string `json:"project_url,omitempty"`
+	Summary     string `json:"summary,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// FileMetadata represents the metadata of a package file which is needed to build the repository indices
+type FileMetadata struct {
+	Architecture  string `json:"architecture,omitempty"`
+	Epoch         string `json:"epoch,omitempty"`
+	Version       string `json:"version,omitempty"`
+	Release       string `json:"release,omitempty"`
+	Vendor        string `json:"vendor,omitempty"`
+	Group         string `json:"group,omitempty"`
+	Packager      string `json:"packager,omitempty"`
+	SourceRpm     string `json:"source_rpm,omitempty"`
+	BuildHost     string `json:"build_host,omitempty"`
+	BuildTime     uint64 `json:"build_time,omitempty"`
+	InstalledSize uint64 `json:"installed_size,omitempty"`
+	ArchiveSize   uint64 `json:"archive_size,omitempty"`
+	HeaderStart   int64  `json:"header_start,omitempty"`
+	HeaderEnd     int64  `json:"header_end,omitempty"`
+
+	Provides  []*Entry `json:"provides,omitempty"`
+	Requires  []*Entry `json:"requires,omitempty"`
+	Conflicts []*Entry `json:"conflicts,omitempty"`
+	Obsoletes []*Entry `json:"obsoletes,omitempty"`
+
+	Files []*File `json:"files,omitempty"`
+
+	Changelogs []*Changelog `json:"changelogs,omitempty"`
+}
+
+// Entry represents a dependency relation
+type Entry struct {
+	Name    string `json:"name" xml:"name,attr"`
+	Flags   string `json:"flags,omitempty" xml:"flags,attr,omitempty"`
+	Epoch   string `json:"epoch,omitempty" xml:"epoch,attr,omitempty"`
+	Version string `json:"version,omitempty" xml:"ver,attr,omitempty"`
+	Release string `json:"release,omitempty" xml:"rel,attr,omitempty"`
+	Pre     string `json:"pre,omitempty" xml:"pre,attr,omitempty"`
+}
+
+// File represents a file contained in the package
+type File struct {
+	Path string `json:"path" xml:",chardata"`
+	Type string `json:"type,omitempty" xml:"type,attr,omitempty"`
+}
+
+// Changelog represents a changelog entry
+type Changelog struct {
+	Author string `json:"author,omitempty" xml:"author,attr"`
+	Date   int64  `json:"date,omitempty" xml:"date,attr"`
+	Text   string `json:"text,omitempty" xml:",chardata"`
+}
+
+// ParsePackage parses the headers of a RPM package file
+func ParsePackage(r io.Reader) (*Package, error) {
+	lead := make([]byte, leadSize)
+	if _, err := io.ReadFull(r, lead); err != nil || !bytes.Equal(lead[0:4], leadMagic) {
+		return nil, util.NewInvalidArgumentErrorf("not a RPM package")
+	}
+
+	sig, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// the signature header is padded to a multiple of 8 bytes
+	padding := (8 - sig.size%8) % 8
+	if _, err := io.CopyN(io.Discard, r, padding); err != nil {
+		return nil, errInvalidHeader
+	}
+
+	h, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	headerStart := leadSize + sig.size + padding
+
+	p := &Package{
+		Name:    h.String(tagName),
+		Version: fmt.Sprintf("%s-%s", h.String(tagVersion), h.String(tagRelease)),
+		VersionMetadata: &VersionMetadata{
+			License:     h.String(tagLicense),
+			Summary:     h.String(tagSummary),
+			Description: h.String(tagDescription),
+		},
+		FileMetadata: &FileMetadata{
+			Architecture: h.String(tagArch),
+			Version:      h.String(tagVersion),
+			Release:      h.String(tagRelease),
+			Vendor:       h.String(tagVendor),
+			Group:        h.String(tagGroup),
+			Packager:     h.String(tagPackager),
+			SourceRpm:    h.String(tagSourceRPM),
+			BuildHost:    h.String(tagBuildHost),
+			BuildTime:    h.Int(tagBuildTime),
+			HeaderStart:  headerStart,
+			HeaderEnd:    headerStart + h.size,
+			Provides:     getEntries(h, tagProvideName, tagProvideVersion, tagProvideFlags),
+			Requires:     getEntries(h, tagRequireName, tagRequireVersion, tagRequireFlags),
+			Conflicts:    getEntries(h, tagConflictName, tagConflictVersion, tagConflictFlags),
+			Obsoletes:    getEntries(h, tagObsoleteName, tagObsoleteVersion, tagObsoleteFlags),
+			Files:        getFiles(h),
+			Changelogs:   getChangelogs(h),
+		},
+	}
+
+	if !namePattern.MatchString(p.Name) {
+		return nil, ErrInvalidName
+	}
+	if !versionPattern.MatchString(p.FileMetadata.Version) || !versionPattern.MatchString(p.FileMetadata.Release) {
+		return nil, ErrInvalidVersion
+	}
+	if !architecturePattern.MatchString(p.FileMetadata.Architecture) {
+		return nil, ErrInvalidArchitecture
+	}
+
+	if url := h.String(tagURL); validation.IsValidURL(url) {
+		p.VersionMetadata.ProjectURL = url
+	}
+
+	if _, ok := h.entries[tagEpoch]; ok {
+		p.FileMetadata.Epoch = strconv.FormatUint(h.Int(tagEpoch), 10)
+	}
+
+	p.FileMetadata.InstalledSize = h.Int(tagLongSize)
+	if p.FileMetadata.InstalledSize == 0 {
+		p.FileMetadata.InstalledSize = h.Int(tagSize)
+	}
+
+	p.FileMetadata.ArchiveSize = sig.Int(sigTagLongArchive)
+	if p.FileMetadata.ArchiveSize == 0 {
+		p.FileMetadata.ArchiveSize = sig.Int(sigTagPayloadSize)
+	}
+	if p.FileMetadata.ArchiveSize == 0 {
+		p.FileMetadata.ArchiveSize = h.Int(tagArchiveSize)
+	}
+
+	return p, nil
+}
+
+func getEntries(h *header, nameTag, versionTag, flagsTag int32) []*Entry {
+	names := h.Strings(nameTag)
+	versions := h.Strings(versionTag)
+	flags := h.Ints(flagsTag)
+
+	if len(names) == 0 || len(names) != len(versions) || len(names) != len(flags) {
+		return nil
+	}
+
+	entries := make([]*Entry, 0, len(names))
+	for i := range names {
+		e := &Entry{
+			Name: names[i],
+		}
+
+		switch flags[i] & (senseLess | senseGreater | senseEqual) {
+		case senseLess:
+			e.Flags = "LT"
+		case senseGreater:
+			e.Flags = "GT"
+		case senseEqual:
+			e.Flags = "EQ"
+		case senseLess | senseEqual:
+			e.Flags = "LE"
+		case senseGreater | senseEqual:
+			e.Flags = "GE"
+		}
+
+		if flags[i]&(sensePrereq|senseScriptPre|senseScriptPost) != 0 {
+			e.Pre = "1"
+		}
+
+		if e.Flags != "" && versions[i] != "" {
+			e.Epoch, e.Version, e.Release = splitVersion(versions[i])
+		}
+
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// splitVersion splits [epoch:]version[-release] into its parts
+func splitVersion(v string) (string, string, string) {
+	epoch := "0"
+	if i := strings.IndexByte(v, ':'); i != -1 {
+		epoch, v = v[:i], v[i+1:]
+	}
+
+	release := ""
+	if i := strings.LastIndexByte(v, '-'); i != -1 {
+		v, release = v[:i], v[i+1:]
+	}
+
+	return epoch, v, release
+}
+
+func getFiles(h *header) []*File {
+	baseNames := h.Strings(tagBaseNames)
+	dirNames := h.Strings(tagDirNames)
+	dirIndexes := h.Ints(tagDirIndexes)
+	fileFlags := h.Ints(tagFileFlags)
+	fileModes := h.Ints(tagFileModes)
+
+	if len(baseNames) != len(dirIndexes) {
+		return nil
+	}
+
+	files := make([]*File, 0, len(baseNames))
+	for i := range baseNames {
+		if dirIndexes[i] >= uint64(len(dirNames)) {
+			return nil
+		}
+
+		f := &File{
+			Path: dirNames[dirIndexes[i]] + baseNames[i],
+		}
+		if i < len(fileModes) {
+			if fileModes[i]&fileModeType == fileModeDir {
+				f.Type = "dir"
+			}
+		}
+		if i < len(fileFlags) && fileFlags[i]&fileFlagGhost != 0 {
+			f.Type = "ghost"
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+func getChangelogs(h *header) []*Changelog {
+	times := h.Ints(tagChangelogTime)
+	names := h.Strings(tagChangelogName)
+	texts := h.Strings(tagChangelogText)
+
+	if len(times) != len(names) || len(times) != len(texts) {
+		return nil
+	}
+
+	changelogs := make([]*Changelog, 0, len(times))
+	for i := range times {
+		changelogs = append(changelogs, &Changelog{
+			Author: names[i],
+			Date:   int64(times[i]),
+			Text:   texts[i],
+		})
+	}
+	return changelogs
+}
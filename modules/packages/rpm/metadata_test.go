@@ -0,0 +1,204 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package rpm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	packageName         = "gitea"
+	packageVersion      = "1.0.1"
+	packageRelease      = "2.fc38"
+	packageArchitecture = "x86_64"
+	packageLicense      = "MIT"
+	summary             = "Summary"
+	description         = "Description with multiple lines.\nSecond line."
+	projectURL          = "https://gitea.io"
+)
+
+func createHeader(tags map[int32]interface{}) []byte {
+	keys := make([]int, 0, len(tags))
+	for tag := range tags {
+		keys = append(keys, int(tag))
+	}
+	sort.Ints(keys)
+
+	var index, data bytes.Buffer
+	for _, key := range keys {
+		var typ tagType
+		var count int
+		offset := data.Len()
+		switch v := tags[int32(key)].(type) {
+		case string:
+			typ, count = typeString, 1
+			data.WriteString(v + "\x00")
+		case []string:
+			typ, count = typeStringArray, len(v)
+			for _, s := range v {
+				data.WriteString(s + "\x00")
+			}
+		case []uint32:
+			for data.Len()%4 != 0 {
+				data.WriteByte(0)
+			}
+			offset = data.Len()
+			typ, count = typeInt32, len(v)
+			for _, i := range v {
+				binary.Write(&data, binary.BigEndian, i)
+			}
+		case []uint16:
+			for data.Len()%2 != 0 {
+				data.WriteByte(0)
+			}
+			offset = data.Len()
+			typ, count = typeInt16, len(v)
+			for _, i := range v {
+				binary.Write(&data, binary.BigEndian, i)
+			}
+		}
+		binary.Write(&index, binary.BigEndian, []uint32{uint32(key), uint32(typ), uint32(offset), uint32(count)})
+	}
+
+	var buf bytes.Buffer
+	buf.Write(headerMagic)
+	buf.Write([]byte{0, 0, 0, 0})
+	binary.Write(&buf, binary.BigEndian, []uint32{uint32(len(keys)), uint32(data.Len())})
+	buf.Write(index.Bytes())
+	buf.Write(data.Bytes())
+	return buf.Bytes()
+}
+
+func createPackage(tags map[int32]interface{}) []byte {
+	var buf bytes.Buffer
+	buf.Write(leadMagic)
+	buf.Write(make([]byte, leadSize-len(leadMagic)))
+	buf.Write(createHeader(map[int32]interface{}{sigTagPayloadSize: []uint32{123}}))
+	for buf.Len()%8 != 0 {
+		buf.WriteByte(0)
+	}
+	buf.Write(createHeader(tags))
+	buf.WriteString("payload")
+	return buf.Bytes()
+}
+
+func createTags(name, version, release, architecture string) map[int32]interface{} {
+	return map[int32]interface{}{
+		tagName:            name,
+		tagVersion:         version,
+		tagRelease:         release,
+		tagArch:            architecture,
+		tagEpoch:           []uint32{1},
+		tagLicense:         packageLicense,
+		tagSummary:         summary,
+		tagDescription:     description,
+		tagURL:             projectURL,
+		tagBuildTime:       []uint32{1678225964},
+		tagSize:            []uint32{4096},
+		tagRequireName:     []string{"glibc", "rpmlib(CompressedFileNames)"},
+		tagRequireVersion:  []string{"2.34", "3.0.4-1"},
+		tagRequireFlags:    []uint32{senseGreater | senseEqual, senseLess | senseEqual | (1 << 24)},
+		tagProvideName:     []string{name},
+		tagProvideVersion:  []string{"1:" + version + "-" + release},
+		tagProvideFlags:    []uint32{senseEqual},
+		tagBaseNames:       []string{"bin", "gitea"},
+		tagDirNames:        []string{"/usr/", "/usr/bin/"},
+		tagDirIndexes:      []uint32{0, 1},
+		tagFileModes:       []uint16{0o040755, 0o100755},
+		tagChangelogTime:   []uint32{1678225964},
+		tagChangelogName:   []string{"KN4CK3R <dummy@gitea.io>"},
+		tagChangelogText:   []string{"- Changelog message."},
+		tagConflictName:    []string{},
+		tagConflictVersion: []string{},
+		tagConflictFlags:   []uint32{},
+	}
+}
+
+func TestParsePackage(t *testing.T) {
+	t.Run("InvalidLead", func(t *testing.T) {
+		p, err := ParsePackage(bytes.NewReader([]byte("dummy")))
+		assert.Nil(t, p)
+		assert.Error(t, err)
+	})
+
+	t.Run("InvalidHeader", func(t *testing.T) {
+		data := createPackage(createTags(packageName, packageVersion, packageRelease, packageArchitecture))
+
+		p, err := ParsePackage(bytes.NewReader(data[:leadSize+20]))
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, errInvalidHeader)
+	})
+
+	t.Run("InvalidName", func(t *testing.T) {
+		for _, name := range []string{"", "-gitea", "gi/tea"} {
+			p, err := ParsePackage(bytes.NewReader(createPackage(createTags(name, packageVersion, packageRelease, packageArchitecture))))
+			assert.Nil(t, p)
+			assert.ErrorIs(t, err, ErrInvalidName)
+		}
+	})
+
+	t.Run("InvalidVersion", func(t *testing.T) {
+		for _, version := range []string{"", "1-0", "1/0"} {
+			p, err := ParsePackage(bytes.NewReader(createPackage(createTags(packageName, version, packageRelease, packageArchitecture))))
+			assert.Nil(t, p)
+			assert.ErrorIs(t, err, ErrInvalidVersion)
+		}
+	})
+
+	t.Run("InvalidArchitecture", func(t *testing.T) {
+		for _, architecture := range []string{"", "x86/64"} {
+			p, err := ParsePackage(bytes.NewReader(createPackage(createTags(packageName, packageVersion, packageRelease, architecture))))
+			assert.Nil(t, p)
+			assert.ErrorIs(t, err, ErrInvalidArchitecture)
+		}
+	})
+
+	t.Run("Valid", func(t *testing.T) {
+		p, err := ParsePackage(bytes.NewReader(createPackage(createTags(packageName, packageVersion, packageRelease, packageArchitecture))))
+		assert.NoError(t, err)
+		assert.NotNil(t, p)
+
+		assert.Equal(t, packageName, p.Name)
+		assert.Equal(t, packageVersion+"-"+packageRelease, p.Version)
+		assert.Equal(t, packageLicense, p.VersionMetadata.License)
+		assert.Equal(t, summary, p.VersionMetadata.Summary)
+		assert.Equal(t, description, p.VersionMetadata.Description)
+		assert.Equal(t, projectURL, p.VersionMetadata.ProjectURL)
+
+		fm := p.FileMetadata
+		assert.Equal(t, packageArchitecture, fm.Architecture)
+		assert.Equal(t, "1", fm.Epoch)
+		assert.Equal(t, packageVersion, fm.Version)
+		assert.Equal(t, packageRelease, fm.Release)
+		assert.EqualValues(t, 1678225964, fm.BuildTime)
+		assert.EqualValues(t, 4096, fm.InstalledSize)
+		assert.EqualValues(t, 123, fm.ArchiveSize)
+		assert.EqualValues(t, leadSize+40, fm.HeaderStart)
+		assert.Greater(t, fm.HeaderEnd, fm.HeaderStart)
+
+		assert.Equal(t, []*Entry{
+			{Name: "glibc", Flags: "GE", Epoch: "0", Version: "2.34"},
+			{Name: "rpmlib(CompressedFileNames)", Flags: "LE", Epoch: "0", Version: "3.0.4", Release: "1"},
+		}, fm.Requires)
+		assert.Equal(t, []*Entry{
+			{Name: packageName, Flags: "EQ", Epoch: "1", Version: packageVersion, Release: packageRelease},
+		}, fm.Provides)
+		assert.Empty(t, fm.Conflicts)
+		assert.Empty(t, fm.Obsoletes)
+
+		assert.Equal(t, []*File{
+			{Path: "/usr/bin", Type: "dir"},
+			{Path: "/usr/bin/gitea"},
+		}, fm.Files)
+
+		assert.Equal(t, []*Changelog{
+			{Author: "KN4CK3R <dummy@gitea.io>", Date: 1678225964, Text: "- Changelog message."},
+		}, fm.Changelogs)
+	})
+}
@@ -169,6 +169,8 @@ type CreateRepoOptions struct {
 	Status         repo_model.RepositoryStatus
 	TrustModel     repo_model.TrustModelType
 	MirrorInterval string
+	// ObjectFormatName is the object format of the new repository, the default one of the settings if it is empty
+	ObjectFormatName string
 }
 
 // CreateRepository creates a repository for the user/organization.
@@ -183,6 +185,13 @@ func CreateRepository(doer, u *user_model.User, opts CreateRepoOptions) (*repo_m
 		opts.DefaultBranch = setting.Repository.DefaultBranch
 	}
 
+	if len(opts.ObjectFormatName) == 0 {
+		opts.ObjectFormatName = setting.Repository.DefaultObjectFormat
+	}
+	if !git.IsSupportedObjectFormat(git.ObjectFormat(opts.ObjectFormatName)) {
+		return nil, fmt.Errorf("unsupported object format: %q", opts.ObjectFormatName)
+	}
+
 	// Check if label template exist
 	if len(opts.IssueLabels) > 0 {
 		if _, err := GetLabelTemplateFile(opts.IssueLabels); err != nil {
@@ -207,6 +216,7 @@ func CreateRepository(doer, u *user_model.User, opts CreateRepoOptions) (*repo_m
 		IsEmpty:                         !opts.AutoInit,
 		TrustModel:                      opts.TrustModel,
 		IsMirror:                        opts.IsMirror,
+		ObjectFormatName:                opts.ObjectFormatName,
 	}
 
 	var rollbackRepo *repo_model.Repository
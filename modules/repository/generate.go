@@ -205,7 +205,7 @@ func generateRepoCommit(ctx context.Context, repo, templateRepo, generateRepo *r
 		}
 	}
 
-	if err := git.InitRepository(ctx, tmpDir, false); err != nil {
+	if err := git.InitRepository(ctx, tmpDir, false, repo.GetObjectFormat()); err != nil {
 		return err
 	}
 
@@ -317,6 +317,8 @@ func GenerateRepository(ctx context.Context, doer, owner *user_model.User, templ
 		IsFsckEnabled: templateRepo.IsFsckEnabled,
 		TemplateID:    templateRepo.ID,
 		TrustModel:    templateRepo.TrustModel,
+		// the git content of the template is committed again in the new repository
+		ObjectFormatName: templateRepo.ObjectFormatName,
 	}
 
 	if err = CreateRepositoryByExample(ctx, doer, owner, generateRepo, false); err != nil {
@@ -336,7 +338,7 @@ func GenerateRepository(ctx context.Context, doer, owner *user_model.User, templ
 		}
 	}
 
-	if err = checkInitRepository(ctx, owner.Name, generateRepo.Name); err != nil {
+	if err = checkInitRepository(ctx, owner.Name, generateRepo.Name, generateRepo.GetObjectFormat()); err != nil {
 		return generateRepo, err
 	}
 
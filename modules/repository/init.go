@@ -227,7 +227,19 @@ func prepareRepoCommit(ctx context.Context, repo *repo_model.Repository, tmpDir,
 	)
 
 	// Clone to temporary path and do the init commit.
-	if stdout, _, err := git.NewCommand(ctx, "clone").AddDynamicArguments(repoPath, tmpDir).
+	// Cloning an empty repository always creates a SHA-1 repository, so the temporary
+	// repository of the other object formats is initialized with the origin added instead.
+	if objectFormat := repo.GetObjectFormat(); objectFormat != git.ObjectFormatSHA1 {
+		if err := git.InitRepository(ctx, tmpDir, false, objectFormat); err != nil {
+			return fmt.Errorf("git init: %w", err)
+		}
+		if stdout, _, err := git.NewCommand(ctx, "remote", "add", "origin").AddDynamicArguments(repoPath).
+			SetDescription(fmt.Sprintf("prepareRepoCommit (git remote add): %s to %s", repoPath, tmpDir)).
+			RunStdString(&git.RunOpts{Dir: tmpDir, Env: env}); err != nil {
+			log.Error("Failed to add remote %v to %s: stdout: %s\nError: %v", repo, tmpDir, stdout, err)
+			return fmt.Errorf("git remote add: %w", err)
+		}
+	} else if stdout, _, err := git.NewCommand(ctx, "clone").AddDynamicArguments(repoPath, tmpDir).
 		SetDescription(fmt.Sprintf("prepareRepoCommit (git clone): %s to %s", repoPath, tmpDir)).
 		RunStdString(&git.RunOpts{Dir: "", Env: env}); err != nil {
 		log.Error("Failed to clone from %v into %s: stdout: %s\nError: %v", repo, tmpDir, stdout, err)
@@ -360,7 +372,7 @@ func initRepoCommit(ctx context.Context, tmpPath string, repo *repo_model.Reposi
 	return nil
 }
 
-func checkInitRepository(ctx context.Context, owner, name string) (err error) {
+func checkInitRepository(ctx context.Context, owner, name string, objectFormat git.ObjectFormat) (err error) {
 	// Somehow the directory could exist.
 	repoPath := repo_model.RepoPath(owner, name)
 	isExist, err := util.IsExist(repoPath)
@@ -376,7 +388,7 @@ func checkInitRepository(ctx context.Context, owner, name string) (err error) {
 	}
 
 	// Init git bare new repository.
-	if err = git.InitRepository(ctx, repoPath, true); err != nil {
+	if err = git.InitRepository(ctx, repoPath, true, objectFormat); err != nil {
 		return fmt.Errorf("git.InitRepository: %w", err)
 	} else if err = createDelegateHooks(repoPath); err != nil {
 		return fmt.Errorf("createDelegateHooks: %w", err)
@@ -386,7 +398,7 @@ func checkInitRepository(ctx context.Context, owner, name string) (err error) {
 
 // InitRepository initializes README and .gitignore if needed.
 func initRepository(ctx context.Context, repoPath string, u *user_model.User, repo *repo_model.Repository, opts CreateRepoOptions) (err error) {
-	if err = checkInitRepository(ctx, repo.OwnerName, repo.Name); err != nil {
+	if err = checkInitRepository(ctx, repo.OwnerName, repo.Name, repo.GetObjectFormat()); err != nil {
 		return err
 	}
 
@@ -142,6 +142,12 @@ func MigrateRepositoryGitData(ctx context.Context, u *user_model.User,
 		return repo, fmt.Errorf("git.IsEmpty: %w", err)
 	}
 
+	objectFormat, err := gitRepo.GetObjectFormat()
+	if err != nil {
+		return repo, fmt.Errorf("GetObjectFormat: %w", err)
+	}
+	repo.ObjectFormatName = string(objectFormat)
+
 	if !repo.IsEmpty {
 		if len(repo.DefaultBranch) == 0 {
 			// Try to get HEAD branch and set it as default branch.
@@ -0,0 +1,456 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package scim
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/modules/json"
+)
+
+// Filter is a parsed filter expression, see RFC 7644 section 3.4.2.2
+type Filter interface {
+	// Match returns whether the resource, in its generic JSON form, matches the filter
+	Match(resource map[string]interface{}) bool
+}
+
+// ErrInvalidFilter is returned when a filter cannot be parsed
+type ErrInvalidFilter struct {
+	Filter string
+	Reason string
+}
+
+func (err ErrInvalidFilter) Error() string {
+	return fmt.Sprintf("invalid filter %q: %s", err.Filter, err.Reason)
+}
+
+// IsErrInvalidFilter checks if an error is a ErrInvalidFilter.
+func IsErrInvalidFilter(err error) bool {
+	_, ok := err.(ErrInvalidFilter)
+	return ok
+}
+
+// ParseFilter parses a filter expression
+func ParseFilter(filter string) (Filter, error) {
+	p := &filterParser{filter: filter}
+	if err := p.tokenize(); err != nil {
+		return nil, err
+	}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, p.errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	return f, nil
+}
+
+// EqualityFilter returns the attribute and the value of a filter which only compares one attribute with a string,
+// like `userName eq "john"`. Such filters can be resolved by a query instead of matching every resource.
+func EqualityFilter(f Filter) (attr, value string, ok bool) {
+	af, ok := f.(attrFilter)
+	if !ok || af.Op != "eq" || len(af.Path) != 1 {
+		return "", "", false
+	}
+	value, ok = af.Value.(string)
+	if !ok {
+		return "", "", false
+	}
+	return af.Path[0], value, true
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenValue
+	tokenOpenParen
+	tokenCloseParen
+	tokenOpenBracket
+	tokenCloseBracket
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value interface{} // the parsed value of a tokenValue
+}
+
+type filterParser struct {
+	filter string
+	tokens []token
+	pos    int
+}
+
+func (p *filterParser) errorf(format string, args ...interface{}) error {
+	return ErrInvalidFilter{Filter: p.filter, Reason: fmt.Sprintf(format, args...)}
+}
+
+func (p *filterParser) tokenize() error {
+	s := p.filter
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(':
+			p.tokens = append(p.tokens, token{kind: tokenOpenParen, text: "("})
+			i++
+		case c == ')':
+			p.tokens = append(p.tokens, token{kind: tokenCloseParen, text: ")"})
+			i++
+		case c == '[':
+			p.tokens = append(p.tokens, token{kind: tokenOpenBracket, text: "["})
+			i++
+		case c == ']':
+			p.tokens = append(p.tokens, token{kind: tokenCloseBracket, text: "]"})
+			i++
+		case c == '"':
+			// the strings are JSON strings
+			end := i + 1
+			for ; end < len(s) && s[end] != '"'; end++ {
+				if s[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(s) {
+				return p.errorf("unterminated string")
+			}
+			var value string
+			if err := json.Unmarshal([]byte(s[i:end+1]), &value); err != nil {
+				return p.errorf("invalid string %s", s[i:end+1])
+			}
+			p.tokens = append(p.tokens, token{kind: tokenValue, text: s[i : end+1], value: value})
+			i = end + 1
+		default:
+			end := i
+			for ; end < len(s) && !strings.ContainsRune(" \t()[]\"", rune(s[end])); end++ {
+			}
+			word := s[i:end]
+			tok := token{kind: tokenWord, text: word}
+			switch strings.ToLower(word) {
+			case "true":
+				tok = token{kind: tokenValue, text: word, value: true}
+			case "false":
+				tok = token{kind: tokenValue, text: word, value: false}
+			case "null":
+				tok = token{kind: tokenValue, text: word, value: nil}
+			default:
+				if n, err := strconv.ParseFloat(word, 64); err == nil {
+					tok = token{kind: tokenValue, text: word, value: n}
+				}
+			}
+			p.tokens = append(p.tokens, tok)
+			i = end
+		}
+	}
+	if len(p.tokens) == 0 {
+		return p.errorf("empty filter")
+	}
+	return nil
+}
+
+func (p *filterParser) peekWord(words ...string) bool {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokenWord {
+		return false
+	}
+	for _, word := range words {
+		if strings.EqualFold(p.tokens[p.pos].text, word) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *filterParser) expect(kind tokenKind, text string) error {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != kind {
+		return p.errorf("expected %q", text)
+	}
+	p.pos++
+	return nil
+}
+
+// or has the lowest precedence, and binds stronger than or and not binds strongest
+func (p *filterParser) parseOr() (Filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekWord("or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orFilter{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (Filter, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekWord("and") {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andFilter{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (Filter, error) {
+	if p.peekWord("not") {
+		p.pos++
+		if err := p.expect(tokenOpenParen, "("); err != nil {
+			return nil, err
+		}
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenCloseParen, ")"); err != nil {
+			return nil, err
+		}
+		return notFilter{f}, nil
+	}
+	if p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokenOpenParen {
+		p.pos++
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenCloseParen, ")"); err != nil {
+			return nil, err
+		}
+		return f, nil
+	}
+	return p.parseAttribute()
+}
+
+func (p *filterParser) parseAttribute() (Filter, error) {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokenWord {
+		return nil, p.errorf("expected an attribute")
+	}
+	path := splitAttrPath(p.tokens[p.pos].text)
+	p.pos++
+
+	// a value path filters the values of a multi-valued attribute
+	if p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokenOpenBracket {
+		p.pos++
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenCloseBracket, "]"); err != nil {
+			return nil, err
+		}
+		return valuePathFilter{Path: path, Filter: f}, nil
+	}
+
+	if !p.peekWord("eq", "ne", "co", "sw", "ew", "gt", "ge", "lt", "le", "pr") {
+		return nil, p.errorf("expected an operator after %s", strings.Join(path, "."))
+	}
+	op := strings.ToLower(p.tokens[p.pos].text)
+	p.pos++
+	if op == "pr" {
+		return attrFilter{Path: path, Op: op}, nil
+	}
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokenValue {
+		return nil, p.errorf("expected a value after %s", op)
+	}
+	value := p.tokens[p.pos].value
+	p.pos++
+	return attrFilter{Path: path, Op: op, Value: value}, nil
+}
+
+// splitAttrPath splits an attribute path into its attribute and its sub-attribute,
+// the schema URN prefix of the core schemas is removed.
+func splitAttrPath(path string) []string {
+	if strings.HasPrefix(strings.ToLower(path), "urn:") {
+		if i := strings.LastIndex(path, ":"); i >= 0 {
+			path = path[i+1:]
+		}
+	}
+	return strings.SplitN(path, ".", 2)
+}
+
+type orFilter struct{ Left, Right Filter }
+
+func (f orFilter) Match(resource map[string]interface{}) bool {
+	return f.Left.Match(resource) || f.Right.Match(resource)
+}
+
+type andFilter struct{ Left, Right Filter }
+
+func (f andFilter) Match(resource map[string]interface{}) bool {
+	return f.Left.Match(resource) && f.Right.Match(resource)
+}
+
+type notFilter struct{ Filter Filter }
+
+func (f notFilter) Match(resource map[string]interface{}) bool {
+	return !f.Filter.Match(resource)
+}
+
+type valuePathFilter struct {
+	Path   []string
+	Filter Filter
+}
+
+func (f valuePathFilter) Match(resource map[string]interface{}) bool {
+	for _, value := range resolveValues(resource, f.Path) {
+		if m, ok := value.(map[string]interface{}); ok && f.Filter.Match(m) {
+			return true
+		}
+	}
+	return false
+}
+
+type attrFilter struct {
+	Path  []string
+	Op    string
+	Value interface{}
+}
+
+func (f attrFilter) Match(resource map[string]interface{}) bool {
+	values := resolveValues(resource, f.Path)
+	if f.Op == "pr" {
+		for _, value := range values {
+			if value != nil && value != "" {
+				return true
+			}
+		}
+		return false
+	}
+	if f.Op == "ne" {
+		for _, value := range values {
+			if compareValues(value, "eq", f.Value) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, value := range values {
+		if compareValues(value, f.Op, f.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveValues returns the values of an attribute path, the values of multi-valued attributes are flattened.
+// The attribute names are case insensitive.
+func resolveValues(resource map[string]interface{}, path []string) []interface{} {
+	value, ok := lookupAttr(resource, path[0])
+	if !ok {
+		return nil
+	}
+	var values []interface{}
+	if list, ok := value.([]interface{}); ok {
+		values = list
+	} else {
+		values = []interface{}{value}
+	}
+	if len(path) == 1 {
+		return values
+	}
+	var result []interface{}
+	for _, v := range values {
+		if m, ok := v.(map[string]interface{}); ok {
+			result = append(result, resolveValues(m, path[1:])...)
+		}
+	}
+	return result
+}
+
+func lookupAttr(resource map[string]interface{}, name string) (interface{}, bool) {
+	if value, ok := resource[name]; ok {
+		return value, true
+	}
+	for key, value := range resource {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// compareValues compares a value of a resource with the value of a filter, strings are compared case insensitively
+func compareValues(value interface{}, op string, filterValue interface{}) bool {
+	switch fv := filterValue.(type) {
+	case string:
+		v, ok := value.(string)
+		if !ok {
+			return false
+		}
+		v, fv = strings.ToLower(v), strings.ToLower(fv)
+		switch op {
+		case "eq":
+			return v == fv
+		case "co":
+			return strings.Contains(v, fv)
+		case "sw":
+			return strings.HasPrefix(v, fv)
+		case "ew":
+			return strings.HasSuffix(v, fv)
+		case "gt":
+			return v > fv
+		case "ge":
+			return v >= fv
+		case "lt":
+			return v < fv
+		case "le":
+			return v <= fv
+		}
+	case float64:
+		v, ok := value.(float64)
+		if !ok {
+			return false
+		}
+		switch op {
+		case "eq":
+			return v == fv
+		case "gt":
+			return v > fv
+		case "ge":
+			return v >= fv
+		case "lt":
+			return v < fv
+		case "le":
+			return v <= fv
+		}
+	case bool:
+		v, ok := value.(bool)
+		return ok && op == "eq" && v == fv
+	case nil:
+		return op == "eq" && value == nil
+	}
+	return false
+}
+
+// ToMap converts a resource to its generic JSON form which filters and patches are applied to
+func ToMap(resource interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]interface{})
+	return m, json.Unmarshal(data, &m)
+}
+
+// FromMap converts the generic JSON form of a resource back to the resource
+func FromMap(m map[string]interface{}, resource interface{}) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, resource)
+}
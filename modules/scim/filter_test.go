@@ -0,0 +1,99 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package scim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFilter(t *testing.T) {
+	active := true
+	user, err := ToMap(&User{
+		Schemas:  []string{SchemaUser},
+		ID:       "2",
+		UserName: "user2",
+		Name:     &Name{GivenName: "User", FamilyName: "Two"},
+		Emails: []MultiValued{
+			{Value: "user2@example.com", Type: "work", Primary: true},
+			{Value: "user2@example.org", Type: "home"},
+		},
+		Active: &active,
+	})
+	assert.NoError(t, err)
+
+	kases := []struct {
+		filter string
+		match  bool
+	}{
+		{`userName eq "user2"`, true},
+		{`UserName EQ "USER2"`, true},
+		{`userName eq "user1"`, false},
+		{`userName ne "user1"`, true},
+		{`userName sw "us"`, true},
+		{`userName ew "2"`, true},
+		{`userName co "ser"`, true},
+		{`userName gt "user1"`, true},
+		{`userName lt "user1"`, false},
+		{`urn:ietf:params:scim:schemas:core:2.0:User:userName eq "user2"`, true},
+		{`name.familyName eq "Two"`, true},
+		{`name.formatted pr`, false},
+		{`externalId pr`, false},
+		{`active eq true`, true},
+		{`active eq false`, false},
+		{`emails.value eq "user2@example.org"`, true},
+		{`emails[type eq "work" and value co "example.com"]`, true},
+		{`emails[type eq "work" and value co "example.org"]`, false},
+		{`userName eq "user1" or userName eq "user2"`, true},
+		{`userName eq "user1" or userName eq "user2" and active eq false`, false},
+		{`(userName eq "user1" or userName eq "user2") and active eq true`, true},
+		{`not (userName eq "user2")`, false},
+	}
+	for _, kase := range kases {
+		f, err := ParseFilter(kase.filter)
+		if assert.NoError(t, err, kase.filter) {
+			assert.Equal(t, kase.match, f.Match(user), kase.filter)
+		}
+	}
+
+	for _, filter := range []string{
+		``,
+		`userName`,
+		`userName eq`,
+		`userName is "user2"`,
+		`userName eq "user2`,
+		`(userName eq "user2"`,
+		`emails[type eq "work"`,
+		`userName eq "user2" and`,
+	} {
+		_, err := ParseFilter(filter)
+		assert.True(t, IsErrInvalidFilter(err), filter)
+	}
+}
+
+func TestEqualityFilter(t *testing.T) {
+	kases := []struct {
+		filter string
+		attr   string
+		value  string
+		ok     bool
+	}{
+		{`userName eq "user2"`, "userName", "user2", true},
+		{`urn:ietf:params:scim:schemas:core:2.0:User:externalId eq "ext"`, "externalId", "ext", true},
+		{`userName ne "user2"`, "", "", false},
+		{`active eq true`, "", "", false},
+		{`name.familyName eq "Two"`, "", "", false},
+		{`userName eq "user1" or userName eq "user2"`, "", "", false},
+	}
+	for _, kase := range kases {
+		f, err := ParseFilter(kase.filter)
+		if assert.NoError(t, err, kase.filter) {
+			attr, value, ok := EqualityFilter(f)
+			assert.Equal(t, kase.ok, ok, kase.filter)
+			assert.Equal(t, kase.attr, attr, kase.filter)
+			assert.Equal(t, kase.value, value, kase.filter)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package scim
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// PatchOperation is an operation of a PATCH request
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// PatchRequest is the body of a PATCH request
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+// ErrInvalidPatch is returned when a patch operation cannot be applied
+type ErrInvalidPatch struct {
+	ScimType string
+	Detail   string
+}
+
+func (err ErrInvalidPatch) Error() string {
+	return err.Detail
+}
+
+// IsErrInvalidPatch checks if an error is a ErrInvalidPatch.
+func IsErrInvalidPatch(err error) bool {
+	_, ok := err.(ErrInvalidPatch)
+	return ok
+}
+
+func patchErrorf(scimType, format string, args ...interface{}) error {
+	return ErrInvalidPatch{ScimType: scimType, Detail: fmt.Sprintf(format, args...)}
+}
+
+// patchPath is a parsed path of a patch operation: attr, attr.sub, attr[filter] or attr[filter].sub
+type patchPath struct {
+	Attr   string
+	Filter Filter
+	// FilterValue is the attribute and value of a simple "eq" filter, they are used to create the missing value
+	FilterAttr  string
+	FilterValue interface{}
+	SubAttr     string
+}
+
+func parsePatchPath(path string) (*patchPath, error) {
+	p := &patchPath{}
+	if i := strings.IndexByte(path, '['); i >= 0 {
+		end := strings.LastIndexByte(path, ']')
+		if end < i {
+			return nil, patchErrorf(ErrorTypeInvalidPath, "invalid path %q", path)
+		}
+		filter, err := ParseFilter(path[i+1 : end])
+		if err != nil {
+			return nil, patchErrorf(ErrorTypeInvalidPath, "invalid path %q: %v", path, err)
+		}
+		p.Filter = filter
+		if f, ok := filter.(attrFilter); ok && f.Op == "eq" && len(f.Path) == 1 {
+			p.FilterAttr, p.FilterValue = f.Path[0], f.Value
+		}
+		p.Attr = splitAttrPath(path[:i])[0]
+		rest := path[end+1:]
+		if rest != "" {
+			if !strings.HasPrefix(rest, ".") || len(rest) == 1 {
+				return nil, patchErrorf(ErrorTypeInvalidPath, "invalid path %q", path)
+			}
+			p.SubAttr = rest[1:]
+		}
+	} else {
+		parts := splitAttrPath(path)
+		p.Attr = parts[0]
+		if len(parts) == 2 {
+			p.SubAttr = parts[1]
+		}
+	}
+	if p.Attr == "" {
+		return nil, patchErrorf(ErrorTypeInvalidPath, "invalid path %q", path)
+	}
+	return p, nil
+}
+
+// ApplyPatch applies the operations of a PATCH request to a resource in its generic JSON form
+func ApplyPatch(resource map[string]interface{}, ops []PatchOperation) error {
+	for _, op := range ops {
+		var err error
+		switch strings.ToLower(op.Op) {
+		case "add":
+			err = applyAddOrReplace(resource, op, false)
+		case "replace":
+			err = applyAddOrReplace(resource, op, true)
+		case "remove":
+			err = applyRemove(resource, op)
+		default:
+			err = patchErrorf(ErrorTypeInvalidSyntax, "unsupported operation %q", op.Op)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyAddOrReplace(resource map[string]interface{}, op PatchOperation, replace bool) error {
+	if op.Path == "" {
+		values, ok := op.Value.(map[string]interface{})
+		if !ok {
+			return patchErrorf(ErrorTypeInvalidValue, "the value of an operation without a path must be an object")
+		}
+		for name, value := range values {
+			if err := applyAddOrReplace(resource, PatchOperation{Op: op.Op, Path: name, Value: value}, replace); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	path, err := parsePatchPath(op.Path)
+	if err != nil {
+		return err
+	}
+	current, _ := lookupAttr(resource, path.Attr)
+
+	if path.Filter != nil {
+		list, _ := current.([]interface{})
+		matched := false
+		for i, element := range list {
+			m, ok := element.(map[string]interface{})
+			if !ok || !path.Filter.Match(m) {
+				continue
+			}
+			matched = true
+			if path.SubAttr == "" {
+				list[i] = op.Value
+			} else {
+				setAttr(m, path.SubAttr, op.Value)
+			}
+		}
+		if !matched {
+			// a value which matches a simple filter is created, e.g. emails[type eq "work"].value
+			if path.FilterAttr == "" || path.SubAttr == "" {
+				return patchErrorf(ErrorTypeNoTarget, "no value matches the path %q", op.Path)
+			}
+			list = append(list, map[string]interface{}{path.FilterAttr: path.FilterValue, path.SubAttr: op.Value})
+		}
+		setAttr(resource, path.Attr, list)
+		return nil
+	}
+
+	if path.SubAttr != "" {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			if current != nil {
+				return patchErrorf(ErrorTypeInvalidPath, "%s is not a complex attribute", path.Attr)
+			}
+			m = make(map[string]interface{})
+		}
+		setAttr(m, path.SubAttr, op.Value)
+		setAttr(resource, path.Attr, m)
+		return nil
+	}
+
+	// the values of an add operation are appended to a multi-valued attribute
+	if list, ok := current.([]interface{}); ok && !replace {
+		values, ok := op.Value.([]interface{})
+		if !ok {
+			values = []interface{}{op.Value}
+		}
+		for _, value := range values {
+			if !containsValue(list, value) {
+				list = append(list, value)
+			}
+		}
+		setAttr(resource, path.Attr, list)
+		return nil
+	}
+	setAttr(resource, path.Attr, op.Value)
+	return nil
+}
+
+func applyRemove(resource map[string]interface{}, op PatchOperation) error {
+	if op.Path == "" {
+		return patchErrorf(ErrorTypeNoTarget, "the path of a remove operation is required")
+	}
+	path, err := parsePatchPath(op.Path)
+	if err != nil {
+		return err
+	}
+	current, ok := lookupAttr(resource, path.Attr)
+	if !ok {
+		return nil
+	}
+
+	if path.Filter == nil && path.SubAttr == "" {
+		list, isList := current.([]interface{})
+		if !isList || op.Value == nil {
+			deleteAttr(resource, path.Attr)
+			return nil
+		}
+		// some clients state the values which are removed from a multi-valued attribute by the value of the operation
+		values, ok := op.Value.([]interface{})
+		if !ok {
+			values = []interface{}{op.Value}
+		}
+		remaining := make([]interface{}, 0, len(list))
+		for _, element := range list {
+			if !containsValue(values, element) {
+				remaining = append(remaining, element)
+			}
+		}
+		setAttr(resource, path.Attr, remaining)
+		return nil
+	}
+
+	if path.Filter == nil {
+		if m, ok := current.(map[string]interface{}); ok {
+			deleteAttr(m, path.SubAttr)
+		}
+		return nil
+	}
+
+	list, _ := current.([]interface{})
+	remaining := make([]interface{}, 0, len(list))
+	for _, element := range list {
+		m, ok := element.(map[string]interface{})
+		if !ok || !path.Filter.Match(m) {
+			remaining = append(remaining, element)
+			continue
+		}
+		if path.SubAttr != "" {
+			deleteAttr(m, path.SubAttr)
+			remaining = append(remaining, m)
+		}
+	}
+	setAttr(resource, path.Attr, remaining)
+	return nil
+}
+
+// containsValue returns whether a list contains the value, the values of complex attributes are compared by their "value"
+func containsValue(list []interface{}, value interface{}) bool {
+	for _, element := range list {
+		if reflect.DeepEqual(element, value) {
+			return true
+		}
+		a, aok := element.(map[string]interface{})
+		b, bok := value.(map[string]interface{})
+		if aok && bok {
+			av, _ := lookupAttr(a, "value")
+			bv, _ := lookupAttr(b, "value")
+			if av != nil && reflect.DeepEqual(av, bv) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// setAttr sets an attribute, the attribute names are case insensitive
+func setAttr(resource map[string]interface{}, name string, value interface{}) {
+	for key := range resource {
+		if strings.EqualFold(key, name) {
+			resource[key] = value
+			return
+		}
+	}
+	resource[name] = value
+}
+
+func deleteAttr(resource map[string]interface{}, name string) {
+	for key := range resource {
+		if strings.EqualFold(key, name) {
+			delete(resource, key)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package scim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyPatch(t *testing.T) {
+	newUser := func() *User {
+		return &User{
+			Schemas:  []string{SchemaUser},
+			UserName: "user2",
+			Name:     &Name{GivenName: "User", FamilyName: "Two"},
+			Emails:   []MultiValued{{Value: "user2@example.com", Type: "work", Primary: true}},
+		}
+	}
+	patch := func(t *testing.T, ops ...PatchOperation) *User {
+		m, err := ToMap(newUser())
+		assert.NoError(t, err)
+		assert.NoError(t, ApplyPatch(m, ops))
+		u := &User{}
+		assert.NoError(t, FromMap(m, u))
+		return u
+	}
+
+	t.Run("Replace", func(t *testing.T) {
+		u := patch(t,
+			PatchOperation{Op: "Replace", Path: "userName", Value: "user3"},
+			PatchOperation{Op: "replace", Path: "name.givenName", Value: "Renamed"},
+			PatchOperation{Op: "replace", Path: "active", Value: false},
+		)
+		assert.Equal(t, "user3", u.UserName)
+		assert.Equal(t, "Renamed Two", u.FullName())
+		assert.False(t, u.IsActive())
+	})
+
+	t.Run("ReplaceWithoutPath", func(t *testing.T) {
+		u := patch(t, PatchOperation{Op: "replace", Value: map[string]interface{}{
+			"displayName": "Display",
+			"name":        map[string]interface{}{"formatted": "Formatted"},
+		}})
+		assert.Equal(t, "Display", u.DisplayName)
+		assert.Equal(t, "Formatted", u.FullName())
+	})
+
+	t.Run("ReplaceFiltered", func(t *testing.T) {
+		u := patch(t,
+			PatchOperation{Op: "replace", Path: `emails[type eq "work"].value`, Value: "new@example.com"},
+			PatchOperation{Op: "replace", Path: `emails[type eq "home"].value`, Value: "home@example.com"},
+		)
+		assert.Equal(t, "new@example.com", u.PrimaryEmail())
+		assert.Len(t, u.Emails, 2)
+		assert.Equal(t, "home@example.com", u.Emails[1].Value)
+		assert.Equal(t, "home", u.Emails[1].Type)
+	})
+
+	t.Run("Add", func(t *testing.T) {
+		u := patch(t,
+			PatchOperation{Op: "add", Path: "emails", Value: []interface{}{map[string]interface{}{"value": "user2@example.org"}}},
+			PatchOperation{Op: "add", Path: "emails", Value: map[string]interface{}{"value": "user2@example.org"}},
+			PatchOperation{Op: "add", Path: "externalId", Value: "ext"},
+		)
+		assert.Len(t, u.Emails, 2)
+		assert.Equal(t, "ext", u.ExternalID)
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		u := patch(t,
+			PatchOperation{Op: "remove", Path: "name.familyName"},
+			PatchOperation{Op: "remove", Path: `emails[type eq "work"]`},
+		)
+		assert.Equal(t, "User", u.FullName())
+		assert.Empty(t, u.Emails)
+	})
+
+	t.Run("RemoveValues", func(t *testing.T) {
+		m := map[string]interface{}{"members": []interface{}{
+			map[string]interface{}{"value": "1"},
+			map[string]interface{}{"value": "2"},
+		}}
+		assert.NoError(t, ApplyPatch(m, []PatchOperation{{Op: "remove", Path: "members", Value: []interface{}{map[string]interface{}{"value": "1"}}}}))
+		assert.Equal(t, []interface{}{map[string]interface{}{"value": "2"}}, m["members"])
+
+		assert.NoError(t, ApplyPatch(m, []PatchOperation{{Op: "remove", Path: `members[value eq "2"]`}}))
+		assert.Empty(t, m["members"])
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, op := range []PatchOperation{
+			{Op: "move", Path: "userName"},
+			{Op: "remove"},
+			{Op: "replace", Value: "user3"},
+			{Op: "replace", Path: `emails[type eq "work"`, Value: "x"},
+			{Op: "replace", Path: `groups[type eq "direct"]`, Value: map[string]interface{}{}},
+			{Op: "replace", Path: "userName.sub", Value: "x"},
+		} {
+			m, err := ToMap(newUser())
+			assert.NoError(t, err)
+			err = ApplyPatch(m, []PatchOperation{op})
+			assert.True(t, IsErrInvalidPatch(err), "%+v", op)
+		}
+	})
+}
@@ -0,0 +1,35 @@
+// Copyright 2022 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package setting
+
+import (
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+)
+
+// Actions settings
+var (
+	Actions = struct {
+		LogStorage    Storage // how the created logs should be stored
+		Enabled       bool
+		WorkflowsPath string        `ini:"WORKFLOWS_PATH"`
+		RunnerTimeout time.Duration `ini:"RUNNER_TIMEOUT"`
+		MaxLogSize    int64         `ini:"-"`
+	}{
+		Enabled:       false,
+		WorkflowsPath: ".forgejo/workflows",
+		RunnerTimeout: 10 * time.Minute,
+	}
+)
+
+func newActions() {
+	sec := Cfg.Section("actions")
+	if err := sec.MapTo(&Actions); err != nil {
+		log.Fatal("Failed to map Actions settings: %v", err)
+	}
+
+	Actions.LogStorage = getStorage("actions_log", "", nil)
+	Actions.MaxLogSize = mustBytes(sec, "MAX_LOG_SIZE")
+}
@@ -5,6 +5,7 @@ package setting
 
 import (
 	"path/filepath"
+	"strings"
 	"time"
 
 	"code.gitea.io/gitea/modules/log"
@@ -28,6 +29,8 @@ var Git = struct {
 	LargeObjectThreshold      int64
 	DisableCoreProtectNTFS    bool
 	DisablePartialClone       bool
+	PartialCloneFilters       []string `ini:"PARTIAL_CLONE_FILTERS" delim:","`
+	DisableProtocolV2         bool     `ini:"DISABLE_PROTOCOL_V2"`
 	Timeout                   struct {
 		Default int
 		Migrate int
@@ -50,6 +53,8 @@ var Git = struct {
 	PullRequestPushMessage:    true,
 	LargeObjectThreshold:      1024 * 1024,
 	DisablePartialClone:       false,
+	PartialCloneFilters:       []string{},
+	DisableProtocolV2:         false,
 	Timeout: struct {
 		Default int
 		Migrate int
@@ -80,4 +85,29 @@ func newGit() {
 	} else {
 		Git.HomePath = filepath.Clean(Git.HomePath)
 	}
+
+	filters := make([]string, 0, len(Git.PartialCloneFilters))
+	for _, filter := range Git.PartialCloneFilters {
+		filter = strings.ToLower(strings.TrimSpace(filter))
+		if filter == "" {
+			continue
+		}
+		if !isValidPartialCloneFilter(filter) {
+			log.Fatal("Invalid filter %q in [git] PARTIAL_CLONE_FILTERS, the valid ones are %s", filter, strings.Join(partialCloneFilters, ", "))
+		}
+		filters = append(filters, filter)
+	}
+	Git.PartialCloneFilters = filters
+}
+
+// partialCloneFilters are the filter kinds which git upload-pack can be configured to allow by uploadpackfilter.<filter>.allow
+var partialCloneFilters = []string{"blob:none", "blob:limit", "tree", "sparse:oid", "object:type", "combine"}
+
+func isValidPartialCloneFilter(filter string) bool {
+	for _, f := range partialCloneFilters {
+		if f == filter {
+			return true
+		}
+	}
+	return false
 }
@@ -30,6 +30,13 @@ var Indexer = struct {
 	IncludePatterns    []glob.Glob
 	ExcludePatterns    []glob.Glob
 	ExcludeVendored    bool
+	MaxIndexerRefs     int
+
+	CommitIndexerEnabled bool
+	CommitType           string
+	CommitPath           string
+	CommitConnStr        string
+	CommitIndexerName    string
 }{
 	IssueType:        "bleve",
 	IssuePath:        "indexers/issues.bleve",
@@ -43,6 +50,13 @@ var Indexer = struct {
 	RepoIndexerName:    "gitea_codes",
 	MaxIndexerFileSize: 1024 * 1024,
 	ExcludeVendored:    true,
+	MaxIndexerRefs:     10,
+
+	CommitIndexerEnabled: false,
+	CommitType:           "bleve",
+	CommitPath:           "indexers/commits.bleve",
+	CommitConnStr:        "",
+	CommitIndexerName:    "gitea_commits",
 }
 
 func newIndexerService() {
@@ -76,7 +90,17 @@ func newIndexerService() {
 	Indexer.ExcludePatterns = IndexerGlobFromString(sec.Key("REPO_INDEXER_EXCLUDE").MustString(""))
 	Indexer.ExcludeVendored = sec.Key("REPO_INDEXER_EXCLUDE_VENDORED").MustBool(true)
 	Indexer.MaxIndexerFileSize = sec.Key("MAX_FILE_SIZE").MustInt64(1024 * 1024)
+	Indexer.MaxIndexerRefs = sec.Key("REPO_INDEXER_MAX_REFS").MustInt(10)
 	Indexer.StartupTimeout = sec.Key("STARTUP_TIMEOUT").MustDuration(30 * time.Second)
+
+	Indexer.CommitIndexerEnabled = sec.Key("COMMIT_INDEXER_ENABLED").MustBool(false)
+	Indexer.CommitType = sec.Key("COMMIT_INDEXER_TYPE").MustString("bleve")
+	Indexer.CommitPath = filepath.ToSlash(sec.Key("COMMIT_INDEXER_PATH").MustString(filepath.ToSlash(filepath.Join(AppDataPath, "indexers/commits.bleve"))))
+	if !filepath.IsAbs(Indexer.CommitPath) {
+		Indexer.CommitPath = filepath.ToSlash(filepath.Join(AppWorkPath, Indexer.CommitPath))
+	}
+	Indexer.CommitConnStr = sec.Key("COMMIT_INDEXER_CONN_STR").MustString("")
+	Indexer.CommitIndexerName = sec.Key("COMMIT_INDEXER_NAME").MustString("gitea_commits")
 }
 
 // IndexerGlobFromString parses a comma separated list of patterns and returns a glob.Glob slice suited for repo indexing
@@ -269,6 +269,19 @@ func newAccessLogService() {
 	}
 }
 
+func newAuditLogService() {
+	EnableAuditLog = Cfg.Section("log").Key("ENABLE_AUDIT_LOG").MustBool(false)
+	// the `MustString` updates the default value, and `log.AUDIT` is used by `generateNamedLogger("audit")` later
+	_ = Cfg.Section("log").Key("AUDIT").MustString("file")
+	if EnableAuditLog {
+		options := newDefaultLogOptions()
+		options.filename = filepath.Join(LogRootPath, "audit.log")
+		options.flags = "" // The audit events are JSON lines which carry their own time
+		options.bufferLength = Cfg.Section("log").Key("BUFFER_LEN").MustInt64(10000)
+		generateNamedLogger("audit", options)
+	}
+}
+
 func newRouterLogService() {
 	Cfg.Section("log").Key("ROUTER").MustString("console")
 	// Allow [log]  DISABLE_ROUTER_LOG to override [server] DISABLE_ROUTER_LOG
@@ -348,6 +361,7 @@ func NewLogServices(disableConsole bool) {
 	newLogService()
 	newRouterLogService()
 	newAccessLogService()
+	newAuditLogService()
 	NewXORMLogService(disableConsole)
 }
 
@@ -45,6 +45,7 @@ var (
 		DisableMigrations                       bool
 		DisableStars                            bool `ini:"DISABLE_STARS"`
 		DefaultBranch                           string
+		DefaultObjectFormat                     string
 		AllowAdoptionOfUnadoptedRepositories    bool
 		AllowDeleteOfUnadoptedRepositories      bool
 		DisableDownloadSourceArchives           bool
@@ -161,6 +162,7 @@ var (
 		DisableMigrations:                       false,
 		DisableStars:                            false,
 		DefaultBranch:                           "main",
+		DefaultObjectFormat:                     "sha1",
 		AllowForkWithoutMaximumLimit:            true,
 
 		// Repository editor settings
@@ -266,6 +268,10 @@ var (
 	RepoArchive = struct {
 		Storage
 	}{}
+
+	RepoBundle = struct {
+		Storage
+	}{}
 )
 
 func newRepository() {
@@ -276,6 +282,7 @@ func newRepository() {
 	Repository.UseCompatSSHURI = sec.Key("USE_COMPAT_SSH_URI").MustBool()
 	Repository.MaxCreationLimit = sec.Key("MAX_CREATION_LIMIT").MustInt(-1)
 	Repository.DefaultBranch = sec.Key("DEFAULT_BRANCH").MustString(Repository.DefaultBranch)
+	Repository.DefaultObjectFormat = sec.Key("DEFAULT_OBJECT_FORMAT").In(Repository.DefaultObjectFormat, []string{"sha1", "sha256"})
 	RepoRootPath = sec.Key("ROOT").MustString(path.Join(AppDataPath, "gitea-repositories"))
 	forcePathSeparator(RepoRootPath)
 	if !filepath.IsAbs(RepoRootPath) {
@@ -353,4 +360,5 @@ func newRepository() {
 	}
 
 	RepoArchive.Storage = getStorage("repo-archive", "", nil)
+	RepoBundle.Storage = getStorage("repo-bundle", "", nil)
 }
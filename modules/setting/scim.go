@@ -0,0 +1,25 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package setting
+
+import (
+	"code.gitea.io/gitea/modules/log"
+)
+
+// SCIM settings
+var (
+	SCIM = struct {
+		Enabled      bool
+		Organization string
+		AuthSource   string
+	}{
+		Enabled: false,
+	}
+)
+
+func newSCIMService() {
+	if err := Cfg.Section("scim").MapTo(&SCIM); err != nil {
+		log.Fatal("Failed to map SCIM settings: %v", err)
+	}
+}
@@ -370,6 +370,8 @@ var (
 	EnableAccessLog   bool
 	AccessLogTemplate string
 
+	EnableAuditLog bool
+
 	// Time settings
 	TimeFormat string
 	// UILocation is the location on the UI, so that we can display the time on UI.
@@ -406,6 +408,10 @@ var (
 		JWTSecretBase64            string `ini:"JWT_SECRET"`
 		JWTSigningPrivateKeyFile   string `ini:"JWT_SIGNING_PRIVATE_KEY_FILE"`
 		MaxTokenLength             int
+		DeviceCodeExpirationTime   int64
+		DeviceCodePollingInterval  int64
+		DeviceCodeRateLimit        int64
+		DeviceCodeAppRateLimit     int64
 	}{
 		Enable:                     true,
 		AccessTokenExpirationTime:  3600,
@@ -414,6 +420,10 @@ var (
 		JWTSigningAlgorithm:        "RS256",
 		JWTSigningPrivateKeyFile:   "jwt/private.pem",
 		MaxTokenLength:             math.MaxInt16,
+		DeviceCodeExpirationTime:   900,
+		DeviceCodePollingInterval:  5,
+		DeviceCodeRateLimit:        10,
+		DeviceCodeAppRateLimit:     1000,
 	}
 
 	// Metrics settings
@@ -936,6 +946,9 @@ func loadFromConf(allowEmpty bool, extraConfig string) {
 	if !filepath.IsAbs(OAuth2.JWTSigningPrivateKeyFile) {
 		OAuth2.JWTSigningPrivateKeyFile = filepath.Join(AppDataPath, OAuth2.JWTSigningPrivateKeyFile)
 	}
+	if OAuth2.DeviceCodePollingInterval < 1 {
+		OAuth2.DeviceCodePollingInterval = 1
+	}
 
 	sec = Cfg.Section("admin")
 	Admin.DefaultEmailNotification = sec.Key("DEFAULT_EMAIL_NOTIFICATIONS").MustString("enabled")
@@ -1074,6 +1087,8 @@ func loadFromConf(allowEmpty bool, extraConfig string) {
 
 	newPackages()
 
+	newActions()
+
 	if err = Cfg.Section("ui").MapTo(&UI); err != nil {
 		log.Fatal("Failed to map UI settings: %v", err)
 	} else if err = Cfg.Section("markdown").MapTo(&Markdown); err != nil {
@@ -1353,6 +1368,7 @@ func NewServices() {
 	newProject()
 	newMimeTypeMap()
 	newFederationService()
+	newSCIMService()
 }
 
 // NewServicesForInstall initializes the services for install
@@ -123,8 +123,14 @@ var (
 	// RepoArchives represents repository archives storage
 	RepoArchives ObjectStorage = uninitializedStorage
 
+	// RepoBundles represents repository bundles storage
+	RepoBundles ObjectStorage = uninitializedStorage
+
 	// Packages represents packages storage
 	Packages ObjectStorage = uninitializedStorage
+
+	// Actions represents actions storage
+	Actions ObjectStorage = uninitializedStorage
 )
 
 // Init init the stoarge
@@ -135,7 +141,9 @@ func Init() error {
 		initRepoAvatars,
 		initLFS,
 		initRepoArchives,
+		initRepoBundles,
 		initPackages,
+		initActions,
 	} {
 		if err := f(); err != nil {
 			return err
@@ -195,6 +203,12 @@ func initRepoArchives() (err error) {
 	return err
 }
 
+func initRepoBundles() (err error) {
+	log.Info("Initialising Repository Bundle storage with type: %s", setting.RepoBundle.Storage.Type)
+	RepoBundles, err = NewStorage(setting.RepoBundle.Storage.Type, &setting.RepoBundle.Storage)
+	return err
+}
+
 func initPackages() (err error) {
 	if !setting.Packages.Enabled {
 		Packages = discardStorage("Packages isn't enabled")
@@ -204,3 +218,13 @@ func initPackages() (err error) {
 	Packages, err = NewStorage(setting.Packages.Storage.Type, &setting.Packages.Storage)
 	return err
 }
+
+func initActions() (err error) {
+	if !setting.Actions.Enabled {
+		Actions = discardStorage("Actions isn't enabled")
+		return nil
+	}
+	log.Info("Initialising Actions storage with type: %s", setting.Actions.LogStorage.Type)
+	Actions, err = NewStorage(setting.Actions.LogStorage.Type, &setting.Actions.LogStorage)
+	return err
+}
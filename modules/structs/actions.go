@@ -0,0 +1,61 @@
+// Copyright 2022 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package structs
+
+import "encoding/json"
+
+// ActionRunnerRegisterOption options used to register a runner
+type ActionRunnerRegisterOption struct {
+	// registration token of a repository, an organization or the instance
+	Token       string   `json:"token"`
+	Name        string   `json:"name"`
+	Version     string   `json:"version"`
+	Description string   `json:"description"`
+	Labels      []string `json:"labels"`
+}
+
+// ActionRunner represents a registered runner
+type ActionRunner struct {
+	ID     int64    `json:"id"`
+	UUID   string   `json:"uuid"`
+	Name   string   `json:"name"`
+	Labels []string `json:"labels"`
+	// the token is only returned once, when the runner gets registered
+	Token string `json:"token,omitempty"`
+}
+
+// ActionTaskContext describes the event and the commit a task runs for
+type ActionTaskContext struct {
+	ServerURL     string          `json:"server_url"`
+	Repository    string          `json:"repository"`
+	RepositoryURL string          `json:"repository_url"`
+	Event         string          `json:"event"`
+	EventPayload  json.RawMessage `json:"event_payload"`
+	Ref           string          `json:"ref"`
+	RefName       string          `json:"ref_name"`
+	SHA           string          `json:"sha"`
+	Actor         string          `json:"actor"`
+	Workflow      string          `json:"workflow"`
+	RunNumber     int64           `json:"run_number"`
+	// Token can clone the repository over HTTP until the job is done
+	Token string `json:"token"`
+}
+
+// ActionTask represents a job handed to a runner
+type ActionTask struct {
+	ID      int64             `json:"id"`
+	Name    string            `json:"name"`
+	JobID   string            `json:"job_id"`
+	RunsOn  []string          `json:"runs_on"`
+	Context ActionTaskContext `json:"context"`
+	// the workflow file reduced to the single job which has to be run
+	Workflow string            `json:"workflow"`
+	Secrets  map[string]string `json:"secrets"`
+}
+
+// ActionTaskStateOption options to report the state of a task
+type ActionTaskStateOption struct {
+	// one of "running", "success", "failure", "cancelled" or "skipped"
+	Status string `json:"status"`
+}
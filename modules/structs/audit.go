@@ -0,0 +1,24 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package structs
+
+import "time"
+
+// AuditEvent represents an event of the audit log
+type AuditEvent struct {
+	ID     int64  `json:"id"`
+	Action string `json:"action"`
+	// ActorID is 0 for the actions of the system
+	ActorID   int64  `json:"actor_id"`
+	ActorName string `json:"actor_name"`
+	// ScopeType is the type of the object the event belongs to
+	// enum: system,user,organization,repository
+	ScopeType string `json:"scope_type"`
+	ScopeID   int64  `json:"scope_id"`
+	ScopeName string `json:"scope_name"`
+	Message   string `json:"message"`
+	IPAddress string `json:"ip_address"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created"`
+}
@@ -17,7 +17,26 @@ type Package struct {
 	Name       string      `json:"name"`
 	Version    string      `json:"version"`
 	// swagger:strfmt date-time
-	CreatedAt time.Time `json:"created_at"`
+	CreatedAt  time.Time          `json:"created_at"`
+	Provenance *PackageProvenance `json:"provenance,omitempty"`
+}
+
+// PackageProvenance represents the source a package version was built from
+type PackageProvenance struct {
+	Repository *Repository `json:"repository"`
+	CommitSHA  string      `json:"commit_sha"`
+	Release    string      `json:"release,omitempty"`
+}
+
+// SetPackageProvenanceOption options for setting the source a package version was built from
+type SetPackageProvenanceOption struct {
+	// name of a repository of the package owner
+	// required: true
+	Repository string `json:"repository" binding:"Required"`
+	// required: true
+	CommitSHA string `json:"commit_sha" binding:"Required"`
+	// optional tag of the release
+	Release string `json:"release"`
 }
 
 // PackageFile represents a package file
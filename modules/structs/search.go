@@ -0,0 +1,41 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package structs
+
+// GlobalSearchResults represents the results of a global search grouped by their kind
+type GlobalSearchResults struct {
+	Repositories       []*Repository       `json:"repositories"`
+	RepositoriesTotal  int64               `json:"repositories_total"`
+	Issues             []*Issue            `json:"issues"`
+	IssuesTotal        int64               `json:"issues_total"`
+	Users              []*User             `json:"users"`
+	UsersTotal         int64               `json:"users_total"`
+	Organizations      []*Organization     `json:"organizations"`
+	OrganizationsTotal int64               `json:"organizations_total"`
+	Code               []*CodeSearchResult `json:"code"`
+	CodeTotal          int64               `json:"code_total"`
+	Packages           []*Package          `json:"packages"`
+	PackagesTotal      int64               `json:"packages_total"`
+}
+
+// CodeSearchResult represents a file matching a code search
+type CodeSearchResult struct {
+	// full name of the repository of the file
+	Repository  string             `json:"repository"`
+	Filename    string             `json:"filename"`
+	CommitID    string             `json:"commit_id"`
+	Language    string             `json:"language"`
+	HTMLURL     string             `json:"html_url"`
+	LineNumbers []int              `json:"line_numbers"`
+	Matches     []*CodeSearchMatch `json:"matches"`
+}
+
+// CodeSearchMatch represents the position of a match in a line of a file
+type CodeSearchMatch struct {
+	Line int `json:"line"`
+	// byte offset of the start of the match in the line
+	StartColumn int `json:"start_column"`
+	// byte offset of the end of the match in the line, exclusive
+	EndColumn int `json:"end_column"`
+}
@@ -15,6 +15,14 @@ type AccessToken struct {
 	Name           string `json:"name"`
 	Token          string `json:"sha1"`
 	TokenLastEight string `json:"token_last_eight"`
+	// the user or organization a fine-grained token is restricted to, zero for unrestricted tokens
+	ResourceOwnerID int64 `json:"resource_owner_id,omitempty"`
+	// the repositories a fine-grained token is restricted to, all repositories of the resource owner if empty
+	RepositoryIDs []int64 `json:"repository_ids,omitempty"`
+	// the access granted to the repository units by a fine-grained token, "read" or "write" by unit
+	Permissions map[string]string `json:"permissions,omitempty"`
+	// swagger:strfmt date-time
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
 }
 
 // AccessTokenList represents a list of API access token.
@@ -25,6 +33,16 @@ type AccessTokenList []*AccessToken
 // swagger:parameters userCreateToken
 type CreateAccessTokenOption struct {
 	Name string `json:"name" binding:"Required"`
+	// restricts the token to the repositories of this user or organization
+	ResourceOwner string `json:"resource_owner"`
+	// restricts the token to these repositories of the resource owner
+	Repositories []string `json:"repositories"`
+	// the access to grant to the units of the repositories, "read" or "write" by unit name
+	// (code, issues, pulls, releases or packages), required for a restricted token
+	Permissions map[string]string `json:"permissions"`
+	// the expiry date, required for a restricted token
+	// swagger:strfmt date-time
+	ExpiresAt *time.Time `json:"expires_at"`
 }
 
 // CreateOAuth2ApplicationOptions holds options to create an oauth2 application
@@ -0,0 +1,200 @@
+// Copyright 2022 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	gocontext "context"
+	"errors"
+	"net/http"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/web"
+	actions_service "code.gitea.io/gitea/services/actions"
+)
+
+const (
+	runnerUUIDHeader  = "X-Runner-UUID"
+	runnerTokenHeader = "X-Runner-Token"
+
+	runnerContextKey = "ActionsRunner"
+)
+
+// Routes provides the endpoints used by runners to register and to fetch and report jobs.
+// These are mounted on `/api/actions` (not `/api/v1/actions`)
+func Routes(ctx gocontext.Context) *web.Route {
+	r := web.NewRoute()
+
+	r.Use(context.PackageContexter(ctx))
+
+	r.Group("/runner", func() {
+		r.Post("/register", Register)
+		r.Group("", func() {
+			r.Post("/fetch", FetchTask)
+			r.Group("/tasks/{id}", func() {
+				r.Post("/status", UpdateTaskStatus)
+				r.Put("/log", UploadTaskLog)
+			})
+		}, verifyRunner)
+	})
+
+	return r
+}
+
+func apiError(ctx *context.Context, status int, obj interface{}) {
+	var message string
+	if err, ok := obj.(error); ok {
+		message = err.Error()
+	} else if obj != nil {
+		message = obj.(string)
+	}
+	if status == http.StatusInternalServerError {
+		log.ErrorWithSkip(1, message)
+		message = http.StatusText(status)
+	} else {
+		log.Debug(message)
+	}
+
+	ctx.JSON(status, map[string]string{
+		"message": message,
+	})
+}
+
+func verifyRunner(ctx *context.Context) {
+	runner, err := actions_model.GetRunnerByUUIDAndToken(ctx, ctx.Req.Header.Get(runnerUUIDHeader), ctx.Req.Header.Get(runnerTokenHeader))
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusUnauthorized, "invalid runner credentials")
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := actions_model.UpdateRunnerLastOnline(ctx, runner); err != nil {
+		log.Error("UpdateRunnerLastOnline: %v", err)
+	}
+	ctx.Data[runnerContextKey] = runner
+}
+
+func getRunner(ctx *context.Context) *actions_model.ActionRunner {
+	return ctx.Data[runnerContextKey].(*actions_model.ActionRunner)
+}
+
+// Register registers a new runner using a registration token
+func Register(ctx *context.Context) {
+	var form api.ActionRunnerRegisterOption
+	if err := json.NewDecoder(ctx.Req.Body).Decode(&form); err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if form.Token == "" || form.Name == "" {
+		apiError(ctx, http.StatusBadRequest, "token and name are required")
+		return
+	}
+	if len(form.Name) > 255 || len(form.Version) > 64 {
+		apiError(ctx, http.StatusBadRequest, "name or version is too long")
+		return
+	}
+
+	token, err := actions_model.GetRunnerToken(ctx, form.Token)
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusUnauthorized, "invalid registration token")
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	runner := &actions_model.ActionRunner{
+		Name:        form.Name,
+		Version:     form.Version,
+		Description: form.Description,
+		OwnerID:     token.OwnerID,
+		RepoID:      token.RepoID,
+		Labels:      form.Labels,
+	}
+	if err := actions_model.CreateRunner(ctx, runner); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, &api.ActionRunner{
+		ID:     runner.ID,
+		UUID:   runner.UUID,
+		Name:   runner.Name,
+		Labels: runner.Labels,
+		Token:  runner.Token,
+	})
+}
+
+// FetchTask assigns a waiting job to the runner
+func FetchTask(ctx *context.Context) {
+	task, err := actions_service.PickTask(ctx, getRunner(ctx))
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if task == nil {
+		ctx.Status(http.StatusNoContent)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, task)
+}
+
+// UpdateTaskStatus reports the status of a job
+func UpdateTaskStatus(ctx *context.Context) {
+	var form api.ActionTaskStateOption
+	if err := json.NewDecoder(ctx.Req.Body).Decode(&form); err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	status, ok := actions_model.ParseStatus(form.Status)
+	if !ok {
+		apiError(ctx, http.StatusBadRequest, "invalid status")
+		return
+	}
+
+	if _, err := actions_service.UpdateTaskStatus(ctx, getRunner(ctx), ctx.ParamsInt64("id"), status); err != nil {
+		switch {
+		case errors.Is(err, util.ErrNotExist):
+			apiError(ctx, http.StatusNotFound, err)
+		case errors.Is(err, util.ErrInvalidArgument):
+			apiError(ctx, http.StatusBadRequest, err)
+		default:
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// UploadTaskLog stores the log of a job
+func UploadTaskLog(ctx *context.Context) {
+	if ctx.Req.ContentLength < 0 {
+		apiError(ctx, http.StatusLengthRequired, "the size of the log must be known")
+		return
+	}
+
+	if err := actions_service.UploadTaskLog(ctx, getRunner(ctx), ctx.ParamsInt64("id"), ctx.Req.Body, ctx.Req.ContentLength); err != nil {
+		switch {
+		case errors.Is(err, actions_service.ErrLogTooLarge):
+			apiError(ctx, http.StatusRequestEntityTooLarge, err)
+		case errors.Is(err, util.ErrNotExist):
+			apiError(ctx, http.StatusNotFound, err)
+		default:
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,51 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package cargo
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/services/auth"
+)
+
+var cargoPathPattern = regexp.MustCompile(`\A/api/packages/[^/]+/cargo/`)
+
+// Auth implements the Auth interface and authenticates cargo requests by the token without a scheme
+type Auth struct{}
+
+// Name represents the name of auth method
+func (a *Auth) Name() string {
+	return "cargo"
+}
+
+// Verify extracts the user from the access token which cargo sends without a scheme,
+// the other routes only accept tokens with a scheme
+// https://doc.rust-lang.org/cargo/reference/registry-web-api.html#web-api
+func (a *Auth) Verify(req *http.Request, w http.ResponseWriter, store auth.DataStore, sess auth.SessionStore) (*user_model.User, error) {
+	if !cargoPathPattern.MatchString(req.URL.Path) {
+		return nil, nil
+	}
+
+	h := req.Header.Get("Authorization")
+	if h == "" || strings.Contains(h, " ") {
+		return nil, nil
+	}
+
+	uid := auth.UserIDFromToken(h, store)
+	if uid <= 0 {
+		return nil, nil
+	}
+
+	u, err := user_model.GetUserByID(req.Context(), uid)
+	if err != nil {
+		log.Error("GetUserByID:  %v", err)
+		return nil, err
+	}
+
+	return u, nil
+}
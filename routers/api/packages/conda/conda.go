@@ -0,0 +1,210 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package conda
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/modules/context"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	conda_module "code.gitea.io/gitea/modules/packages/conda"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/routers/api/packages/helper"
+	packages_service "code.gitea.io/gitea/services/packages"
+	conda_service "code.gitea.io/gitea/services/packages/conda"
+)
+
+// the channel may consist of multiple segments which are used in paths
+var channelSegmentPattern = regexp.MustCompile(`\A[a-z0-9][a-z0-9_.\-]*\z`)
+
+func apiError(ctx *context.Context, status int, obj interface{}) {
+	helper.LogAndProcessError(ctx, status, obj, func(message string) {
+		ctx.PlainText(status, message)
+	})
+}
+
+// getChannel gets the channel from the request. The empty string is the default channel.
+func getChannel(ctx *context.Context) (string, bool) {
+	channel := strings.ToLower(strings.Trim(ctx.Params("channel"), "/"))
+	if channel == "" {
+		return "", true
+	}
+	for _, segment := range strings.Split(channel, "/") {
+		if !channelSegmentPattern.MatchString(segment) {
+			apiError(ctx, http.StatusBadRequest, "invalid channel")
+			return "", false
+		}
+	}
+	return channel, true
+}
+
+// GetChanneldata serves the channeldata.json file of a channel
+func GetChanneldata(ctx *context.Context) {
+	channel, ok := getChannel(ctx)
+	if !ok {
+		return
+	}
+
+	channeldata, err := conda_service.BuildChanneldata(ctx, ctx.Package.Owner.ID, channel)
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, channeldata)
+}
+
+// GetRepodata serves the repodata.json file of a channel and subdir
+func GetRepodata(ctx *context.Context) {
+	channel, ok := getChannel(ctx)
+	if !ok {
+		return
+	}
+
+	repodata, err := conda_service.BuildRepodata(ctx, ctx.Package.Owner.ID, channel, ctx.Params("subdir"))
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, repodata)
+}
+
+// DownloadPackageFile serves the content of a package file of a channel and subdir
+func DownloadPackageFile(ctx *context.Context) {
+	channel, ok := getChannel(ctx)
+	if !ok {
+		return
+	}
+
+	pfd, err := conda_service.GetPackageFileDescriptor(ctx, ctx.Package.Owner.ID, channel, ctx.Params("subdir"), ctx.Params("filename"))
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	s, pf, err := packages_service.GetPackageFileStream(ctx, pfd.File)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer s.Close()
+
+	ctx.ServeContent(s, &context.ServeHeaderOptions{
+		Filename:     pf.Name,
+		LastModified: pf.CreatedUnix.AsLocalTime(),
+	})
+}
+
+// UploadPackageFile adds a package file to a channel.
+// The subdir is read from the package.
+func UploadPackageFile(ctx *context.Context) {
+	channel, ok := getChannel(ctx)
+	if !ok {
+		return
+	}
+
+	filename := ctx.Params("filename")
+	isCondaPackage := strings.HasSuffix(strings.ToLower(filename), conda_module.ExtensionConda)
+	if !isCondaPackage && !strings.HasSuffix(strings.ToLower(filename), conda_module.ExtensionTarBz2) {
+		apiError(ctx, http.StatusBadRequest, fmt.Sprintf("the file must be a %s or %s file", conda_module.ExtensionTarBz2, conda_module.ExtensionConda))
+		return
+	}
+
+	upload, close, err := ctx.UploadStream()
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if close {
+		defer upload.Close()
+	}
+
+	buf, err := packages_module.CreateHashedBufferFromReader(upload, 32*1024*1024)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer buf.Close()
+
+	var pck *conda_module.Package
+	if isCondaPackage {
+		pck, err = conda_module.ParsePackageConda(buf, buf.Size())
+	} else {
+		pck, err = conda_module.ParsePackageBZ2(buf)
+	}
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			apiError(ctx, http.StatusBadRequest, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	// conda locates the package files by the name listed in the index
+	if pck.FileName() != filename {
+		apiError(ctx, http.StatusBadRequest, fmt.Sprintf("the file name must be %s", pck.FileName()))
+		return
+	}
+
+	if _, err := buf.Seek(0, io.SeekStart); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	_, _, err = packages_service.CreatePackageOrAddFileToExisting(
+		&packages_service.PackageCreationInfo{
+			PackageInfo: packages_service.PackageInfo{
+				Owner:       ctx.Package.Owner,
+				PackageType: packages_model.TypeConda,
+				Name:        pck.Name,
+				Version:     pck.Version,
+			},
+			Creator:  ctx.Doer,
+			Metadata: pck.VersionMetadata,
+		},
+		&packages_service.PackageFileCreationInfo{
+			PackageFileInfo: packages_service.PackageFileInfo{
+				Filename:     pck.FileName(),
+				CompositeKey: conda_service.CompositeKey(channel, pck.Subdir),
+			},
+			Creator: ctx.Doer,
+			Data:    buf,
+			IsLead:  true,
+			Properties: map[string]string{
+				conda_module.PropertyChannel: channel,
+				conda_module.PropertySubdir:  pck.Subdir,
+				conda_module.PropertyIndex:   pck.Index,
+			},
+		},
+	)
+	if err != nil {
+		switch err {
+		case packages_model.ErrDuplicatePackageFile:
+			apiError(ctx, http.StatusConflict, err)
+		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
+			apiError(ctx, http.StatusForbidden, err)
+		default:
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
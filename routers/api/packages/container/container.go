@@ -14,6 +14,7 @@ import (
 	"strconv"
 	"strings"
 
+	auth_model "code.gitea.io/gitea/models/auth"
 	packages_model "code.gitea.io/gitea/models/packages"
 	container_model "code.gitea.io/gitea/models/packages/container"
 	user_model "code.gitea.io/gitea/models/user"
@@ -141,6 +142,12 @@ func Authenticate(ctx *context.Context) {
 		u = user_model.NewGhostUser()
 	}
 
+	// the registry token would not carry the restrictions of a fine-grained access token
+	if token, ok := ctx.Data["ApiToken"].(*auth_model.AccessToken); ok && token.IsFineGrained() {
+		apiErrorDefined(ctx, errUnauthorized.WithMessage("fine-grained access tokens are not supported"))
+		return
+	}
+
 	token, err := packages_service.CreateAuthorizationToken(u)
 	if err != nil {
 		apiError(ctx, http.StatusInternalServerError, err)
@@ -412,11 +419,23 @@ func getBlobFromContext(ctx *context.Context) (*packages_model.PackageFileDescri
 		return nil, container_model.ErrContainerBlobNotExist
 	}
 
-	return workaroundGetContainerBlob(ctx, &container_model.BlobSearchOptions{
+	opts := &container_model.BlobSearchOptions{
 		OwnerID: ctx.Package.Owner.ID,
 		Image:   ctx.Params("image"),
 		Digest:  digest,
-	})
+	}
+
+	blob, err := workaroundGetContainerBlob(ctx, opts)
+	if err == container_model.ErrContainerBlobNotExist {
+		fetched, err := fetchRemoteBlob(ctx, opts.Image, digest)
+		if err != nil {
+			return nil, err
+		}
+		if fetched {
+			return workaroundGetContainerBlob(ctx, opts)
+		}
+	}
+	return blob, err
 }
 
 // https://github.com/opencontainers/distribution-spec/blob/main/spec.md#checking-if-content-exists-in-the-registry
@@ -426,7 +445,7 @@ func HeadBlob(ctx *context.Context) {
 		if err == container_model.ErrContainerBlobNotExist {
 			apiErrorDefined(ctx, errBlobUnknown)
 		} else {
-			apiError(ctx, http.StatusInternalServerError, err)
+			apiError(ctx, helper.RemoteErrorStatus(err), err)
 		}
 		return
 	}
@@ -445,7 +464,7 @@ func GetBlob(ctx *context.Context) {
 		if err == container_model.ErrContainerBlobNotExist {
 			apiErrorDefined(ctx, errBlobUnknown)
 		} else {
-			apiError(ctx, http.StatusInternalServerError, err)
+			apiError(ctx, helper.RemoteErrorStatus(err), err)
 		}
 		return
 	}
@@ -554,7 +573,17 @@ func getManifestFromContext(ctx *context.Context) (*packages_model.PackageFileDe
 		return nil, container_model.ErrContainerBlobNotExist
 	}
 
-	return workaroundGetContainerBlob(ctx, opts)
+	manifest, err := workaroundGetContainerBlob(ctx, opts)
+	if err == container_model.ErrContainerBlobNotExist {
+		fetched, err := fetchRemoteManifest(ctx, opts.Image, reference)
+		if err != nil {
+			return nil, err
+		}
+		if fetched {
+			return workaroundGetContainerBlob(ctx, opts)
+		}
+	}
+	return manifest, err
 }
 
 // https://github.com/opencontainers/distribution-spec/blob/main/spec.md#checking-if-content-exists-in-the-registry
@@ -564,7 +593,7 @@ func HeadManifest(ctx *context.Context) {
 		if err == container_model.ErrContainerBlobNotExist {
 			apiErrorDefined(ctx, errManifestUnknown)
 		} else {
-			apiError(ctx, http.StatusInternalServerError, err)
+			apiError(ctx, helper.RemoteErrorStatus(err), err)
 		}
 		return
 	}
@@ -584,7 +613,7 @@ func GetManifest(ctx *context.Context) {
 		if err == container_model.ErrContainerBlobNotExist {
 			apiErrorDefined(ctx, errManifestUnknown)
 		} else {
-			apiError(ctx, http.StatusInternalServerError, err)
+			apiError(ctx, helper.RemoteErrorStatus(err), err)
 		}
 		return
 	}
@@ -653,25 +682,36 @@ func DeleteManifest(ctx *context.Context) {
 func GetTagList(ctx *context.Context) {
 	image := ctx.Params("image")
 
-	if _, err := packages_model.GetPackageByName(ctx, ctx.Package.Owner.ID, packages_model.TypeContainer, image); err != nil {
-		if err == packages_model.ErrPackageNotExist {
-			apiErrorDefined(ctx, errNameUnknown)
-		} else {
-			apiError(ctx, http.StatusInternalServerError, err)
-		}
-		return
-	}
-
 	n := -1
 	if ctx.FormTrim("n") != "" {
 		n = ctx.FormInt("n")
 	}
 	last := ctx.FormTrim("last")
 
-	tags, err := container_model.GetImageTags(ctx, ctx.Package.Owner.ID, image, n, last)
+	tags, err := fetchRemoteTagList(ctx, image, n, last)
 	if err != nil {
-		apiError(ctx, http.StatusInternalServerError, err)
-		return
+		// list the already fetched tags if the remote registry is unavailable
+		if !errors.Is(err, util.ErrNotExist) {
+			log.Warn("Error fetching tags of container image %s from remote registry: %v", image, err)
+		}
+		tags = nil
+	}
+
+	if tags == nil {
+		if _, err := packages_model.GetPackageByName(ctx, ctx.Package.Owner.ID, packages_model.TypeContainer, image); err != nil {
+			if err == packages_model.ErrPackageNotExist {
+				apiErrorDefined(ctx, errNameUnknown)
+			} else {
+				apiError(ctx, http.StatusInternalServerError, err)
+			}
+			return
+		}
+
+		tags, err = container_model.GetImageTags(ctx, ctx.Package.Owner.ID, image, n, last)
+		if err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
 	}
 
 	type TagList struct {
@@ -0,0 +1,233 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package container
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	container_model "code.gitea.io/gitea/models/packages/container"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/json"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	"code.gitea.io/gitea/modules/packages/container/oci"
+	"code.gitea.io/gitea/modules/util"
+	packages_service "code.gitea.io/gitea/services/packages"
+	"code.gitea.io/gitea/services/packages/remote"
+)
+
+var errInvalidRemoteContent = util.NewInvalidArgumentErrorf("content of the remote registry is invalid")
+
+var manifestAcceptHeader = strings.Join([]string{
+	oci.MediaTypeImageManifest,
+	oci.MediaTypeImageIndex,
+	oci.MediaTypeDockerManifest,
+	oci.MediaTypeDockerManifestList,
+}, ", ")
+
+// fetchRemoteBlob requests the blob from the remote registry and stores it.
+// It returns false if the blob must not be fetched from a remote registry.
+func fetchRemoteBlob(ctx *context.Context, image, digest string) (bool, error) {
+	client, err := remote.GetClientForPackage(ctx, ctx.Package.Owner.ID, packages_model.TypeContainer, image)
+	if err != nil || client == nil {
+		return false, err
+	}
+
+	if err := fetchRemoteBlobWithClient(ctx, client, image, digest); err != nil {
+		if err == remote.ErrRemoteNotExist {
+			return false, container_model.ErrContainerBlobNotExist
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func fetchRemoteBlobWithClient(ctx *context.Context, client *remote.Client, image, digest string) error {
+	buf, err := client.Download(ctx, fmt.Sprintf("v2/%s/blobs/%s", image, url.PathEscape(digest)), nil)
+	if err != nil {
+		return err
+	}
+	defer buf.Close()
+
+	if digestFromHashSummer(buf) != digest {
+		return errInvalidRemoteContent
+	}
+
+	_, err = saveAsPackageBlob(
+		buf,
+		&packages_service.PackageInfo{
+			Owner: ctx.Package.Owner,
+			Name:  image,
+		},
+	)
+	return err
+}
+
+// fetchRemoteManifest requests the manifest and all referenced manifests and blobs from the remote registry and stores them.
+// It returns false if the manifest must not be fetched from a remote registry.
+func fetchRemoteManifest(ctx *context.Context, image, reference string) (bool, error) {
+	client, err := remote.GetClientForPackage(ctx, ctx.Package.Owner.ID, packages_model.TypeContainer, image)
+	if err != nil || client == nil {
+		return false, err
+	}
+
+	if err := fetchRemoteManifestWithClient(ctx, client, image, reference); err != nil {
+		if err == remote.ErrRemoteNotExist {
+			return false, container_model.ErrContainerBlobNotExist
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func fetchRemoteManifestWithClient(ctx *context.Context, client *remote.Client, image, reference string) error {
+	resp, err := client.Get(ctx, fmt.Sprintf("v2/%s/manifests/%s", image, url.PathEscape(reference)), http.Header{"Accept": []string{manifestAcceptHeader}})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	maxSize := maxManifestSize + 1
+	buf, err := packages_module.CreateHashedBufferFromReader(&io.LimitedReader{R: resp.Body, N: int64(maxSize)}, maxSize)
+	if err != nil {
+		return err
+	}
+	defer buf.Close()
+
+	if buf.Size() > maxManifestSize {
+		return errInvalidRemoteContent
+	}
+
+	isTagged := !oci.Digest(reference).Validate()
+	if !isTagged && digestFromHashSummer(buf) != reference {
+		return errInvalidRemoteContent
+	}
+
+	var schema oci.SchemaMediaBase
+	if err := json.NewDecoder(buf).Decode(&schema); err != nil {
+		return errInvalidRemoteContent
+	}
+	if _, err := buf.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	mediaType := oci.MediaType(strings.TrimSpace(strings.SplitN(resp.Header.Get("Content-Type"), ";", 2)[0]))
+	if !mediaType.IsValid() {
+		mediaType = schema.MediaType
+	}
+
+	// The referenced content must exist before the manifest can be stored
+	if mediaType.IsImageIndex() {
+		var index oci.Index
+		if err := json.NewDecoder(buf).Decode(&index); err != nil {
+			return errInvalidRemoteContent
+		}
+
+		for _, manifest := range index.Manifests {
+			if !manifest.Digest.Validate() {
+				return errInvalidRemoteContent
+			}
+			if err := fetchMissingRemoteContent(ctx, client, image, string(manifest.Digest), true); err != nil {
+				return err
+			}
+		}
+	} else if mediaType.IsImageManifest() {
+		var manifest oci.Manifest
+		if err := json.NewDecoder(buf).Decode(&manifest); err != nil {
+			return errInvalidRemoteContent
+		}
+
+		digests := []oci.Digest{manifest.Config.Digest}
+		for _, layer := range manifest.Layers {
+			digests = append(digests, layer.Digest)
+		}
+		for _, digest := range digests {
+			if !digest.Validate() {
+				return errInvalidRemoteContent
+			}
+			if err := fetchMissingRemoteContent(ctx, client, image, string(digest), false); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := buf.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	_, err = processManifest(
+		&manifestCreationInfo{
+			MediaType:  mediaType,
+			Owner:      ctx.Package.Owner,
+			Creator:    remote.Creator(),
+			Image:      image,
+			Reference:  reference,
+			IsTagged:   isTagged,
+			Properties: client.VersionProperties(),
+		},
+		buf,
+	)
+	if err != nil {
+		var namedError *namedError
+		if errors.As(err, &namedError) {
+			return fmt.Errorf("%w: %v", errInvalidRemoteContent, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// fetchMissingRemoteContent fetches the manifest or blob if it does not exist yet
+func fetchMissingRemoteContent(ctx *context.Context, client *remote.Client, image, digest string, isManifest bool) error {
+	_, err := workaroundGetContainerBlob(ctx, &container_model.BlobSearchOptions{
+		OwnerID:    ctx.Package.Owner.ID,
+		Image:      image,
+		Digest:     digest,
+		IsManifest: isManifest,
+	})
+	if err == nil {
+		return nil
+	}
+	if err != container_model.ErrContainerBlobNotExist {
+		return err
+	}
+
+	if isManifest {
+		return fetchRemoteManifestWithClient(ctx, client, image, digest)
+	}
+	return fetchRemoteBlobWithClient(ctx, client, image, digest)
+}
+
+// fetchRemoteTagList requests the tags of the image from the remote registry.
+// It returns nil if the tags must not be fetched from a remote registry.
+func fetchRemoteTagList(ctx *context.Context, image string, n int, last string) ([]string, error) {
+	client, err := remote.GetClientForPackage(ctx, ctx.Package.Owner.ID, packages_model.TypeContainer, image)
+	if err != nil || client == nil {
+		return nil, err
+	}
+
+	q := url.Values{}
+	if n >= 0 {
+		q.Set("n", strconv.Itoa(n))
+	}
+	if last != "" {
+		q.Set("last", last)
+	}
+
+	var tagList struct {
+		Tags []string `json:"tags"`
+	}
+	if err := client.GetJSON(ctx, fmt.Sprintf("v2/%s/tags/list?%s", image, q.Encode()), &tagList); err != nil {
+		return nil, err
+	}
+	if tagList.Tags == nil {
+		tagList.Tags = []string{}
+	}
+	return tagList.Tags, nil
+}
@@ -0,0 +1,294 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package debian
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/modules/context"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	debian_module "code.gitea.io/gitea/modules/packages/debian"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/routers/api/packages/helper"
+	packages_service "code.gitea.io/gitea/services/packages"
+	debian_service "code.gitea.io/gitea/services/packages/debian"
+)
+
+// the distribution and the component are used in paths and in the Release file
+var namePattern = regexp.MustCompile(`\A[a-z0-9][a-z0-9_.\-]*\z`)
+
+func apiError(ctx *context.Context, status int, obj interface{}) {
+	helper.LogAndProcessError(ctx, status, obj, func(message string) {
+		ctx.PlainText(status, message)
+	})
+}
+
+func getDistributionAndComponent(ctx *context.Context) (string, string, bool) {
+	distribution := strings.ToLower(ctx.Params("distribution"))
+	component := strings.ToLower(ctx.Params("component"))
+	if !namePattern.MatchString(distribution) || (ctx.Params("component") != "" && !namePattern.MatchString(component)) {
+		apiError(ctx, http.StatusBadRequest, "invalid distribution or component")
+		return "", "", false
+	}
+	return distribution, component, true
+}
+
+// GetRepositoryKey serves the public key used to sign the repository files
+func GetRepositoryKey(ctx *context.Context) {
+	_, pub, err := debian_service.GetOrCreateKeyPair(ctx.Package.Owner.ID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.ServeContent(strings.NewReader(pub), &context.ServeHeaderOptions{
+		ContentType: "application/pgp-keys",
+		Filename:    "repository.key",
+	})
+}
+
+// GetRepositoryFile serves the Release, Release.gpg and InRelease files of a distribution
+func GetRepositoryFile(ctx *context.Context) {
+	distribution, _, ok := getDistributionAndComponent(ctx)
+	if !ok {
+		return
+	}
+
+	serveRepositoryFile(ctx, distribution, ctx.Params("filename"), "text/plain")
+}
+
+// GetPackagesIndex serves the Packages file of a distribution, component and architecture
+func GetPackagesIndex(ctx *context.Context) {
+	distribution, component, ok := getDistributionAndComponent(ctx)
+	if !ok {
+		return
+	}
+
+	architecture := strings.TrimPrefix(ctx.Params("architecture"), "binary-")
+	if architecture == ctx.Params("architecture") {
+		apiError(ctx, http.StatusNotFound, nil)
+		return
+	}
+
+	filename := ctx.Params("filename")
+	contentType := "text/plain"
+	if strings.HasSuffix(filename, ".gz") {
+		contentType = "application/gzip"
+	}
+
+	serveRepositoryFile(ctx, debian_service.IndexCompositeKey(distribution, component, architecture), filename, contentType)
+}
+
+// serveRepositoryFile serves a stored file of the dists directory, the files are built when packages are uploaded or deleted
+func serveRepositoryFile(ctx *context.Context, compositeKey, filename, contentType string) {
+	pv, err := packages_model.GetInternalVersionByNameAndVersion(ctx, ctx.Package.Owner.ID, packages_model.TypeDebian, debian_module.RepositoryPackage, debian_module.RepositoryVersion)
+	if err != nil {
+		if err == packages_model.ErrPackageNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	s, pf, err := packages_service.GetFileStreamByPackageVersion(
+		ctx,
+		pv,
+		&packages_service.PackageFileInfo{
+			Filename:     filename,
+			CompositeKey: compositeKey,
+		},
+	)
+	if err != nil {
+		if err == packages_model.ErrPackageFileNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	defer s.Close()
+
+	ctx.ServeContent(s, &context.ServeHeaderOptions{
+		ContentType:  contentType,
+		Filename:     pf.Name,
+		LastModified: pf.CreatedUnix.AsLocalTime(),
+	})
+}
+
+// UploadPackageFile adds a package file to a distribution and component
+func UploadPackageFile(ctx *context.Context) {
+	distribution, component, ok := getDistributionAndComponent(ctx)
+	if !ok {
+		return
+	}
+
+	upload, close, err := ctx.UploadStream()
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if close {
+		defer upload.Close()
+	}
+
+	buf, err := packages_module.CreateHashedBufferFromReader(upload, 32*1024*1024)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer buf.Close()
+
+	pck, err := debian_module.ParsePackage(buf)
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			apiError(ctx, http.StatusBadRequest, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	if _, err := buf.Seek(0, io.SeekStart); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	_, _, err = packages_service.CreatePackageOrAddFileToExisting(
+		&packages_service.PackageCreationInfo{
+			PackageInfo: packages_service.PackageInfo{
+				Owner:       ctx.Package.Owner,
+				PackageType: packages_model.TypeDebian,
+				Name:        pck.Name,
+				Version:     pck.Version,
+			},
+			Creator:  ctx.Doer,
+			Metadata: pck.Metadata,
+		},
+		&packages_service.PackageFileCreationInfo{
+			PackageFileInfo: packages_service.PackageFileInfo{
+				Filename:     debian_service.FileName(pck.Name, pck.Version, pck.Architecture),
+				CompositeKey: debian_service.CompositeKey(distribution, component),
+			},
+			Creator: ctx.Doer,
+			Data:    buf,
+			IsLead:  true,
+			Properties: map[string]string{
+				debian_module.PropertyDistribution: distribution,
+				debian_module.PropertyComponent:    component,
+				debian_module.PropertyArchitecture: pck.Architecture,
+				debian_module.PropertyControl:      pck.Control,
+			},
+		},
+	)
+	if err != nil {
+		switch err {
+		case packages_model.ErrDuplicatePackageFile:
+			apiError(ctx, http.StatusConflict, err)
+		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
+			apiError(ctx, http.StatusForbidden, err)
+		default:
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	if err := debian_service.BuildSpecificRepositoryFiles(ctx, ctx.Package.Owner.ID, distribution); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
+
+// DownloadPackageFile serves the content of a package file of a distribution and component
+func DownloadPackageFile(ctx *context.Context) {
+	distribution, component, ok := getDistributionAndComponent(ctx)
+	if !ok {
+		return
+	}
+
+	pfd, err := debian_service.GetPackageFileDescriptor(ctx, ctx.Package.Owner.ID, distribution, component, ctx.Params("filename"))
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	s, pf, err := packages_service.GetPackageFileStream(ctx, pfd.File)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer s.Close()
+
+	ctx.ServeContent(s, &context.ServeHeaderOptions{
+		ContentType:  "application/vnd.debian.binary-package",
+		Filename:     pf.Name,
+		LastModified: pf.CreatedUnix.AsLocalTime(),
+	})
+}
+
+// DeletePackageFile removes a package file from a distribution and component
+func DeletePackageFile(ctx *context.Context) {
+	distribution, component, ok := getDistributionAndComponent(ctx)
+	if !ok {
+		return
+	}
+
+	name, version, architecture := ctx.Params("name"), ctx.Params("version"), ctx.Params("architecture")
+
+	pv, err := packages_model.GetVersionByNameAndVersion(ctx, ctx.Package.Owner.ID, packages_model.TypeDebian, name, version)
+	if err != nil {
+		if err == packages_model.ErrPackageNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	pf, err := packages_model.GetFileForVersionByName(ctx, pv.ID, debian_service.FileName(name, version, architecture), debian_service.CompositeKey(distribution, component))
+	if err != nil {
+		if err == packages_model.ErrPackageFileNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	pfs, err := packages_model.GetFilesByVersionID(ctx, pv.ID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	if len(pfs) == 1 {
+		if err := packages_service.RemovePackageVersion(ctx.Doer, pv); err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+	} else {
+		if err := packages_service.DeletePackageFile(ctx, pf); err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	if err := debian_service.BuildSpecificRepositoryFiles(ctx, ctx.Package.Owner.ID, distribution); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,267 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package goproxy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/modules/context"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	goproxy_module "code.gitea.io/gitea/modules/packages/goproxy"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/routers/api/packages/helper"
+	packages_service "code.gitea.io/gitea/services/packages"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+func apiError(ctx *context.Context, status int, obj interface{}) {
+	helper.LogAndProcessError(ctx, status, obj, func(message string) {
+		ctx.PlainText(status, message)
+	})
+}
+
+// moduleParams gets the module path and version from the request.
+// Both are case-encoded to be safe on case-insensitive file systems.
+// https://go.dev/ref/mod#goproxy-protocol
+func moduleParams(ctx *context.Context) (string, string, error) {
+	name, err := module.UnescapePath(ctx.Params("name"))
+	if err != nil {
+		return "", "", packages_model.ErrPackageNotExist
+	}
+
+	version := ctx.Params("version")
+	if version != "" {
+		if version, err = module.UnescapeVersion(version); err != nil {
+			return "", "", packages_model.ErrPackageNotExist
+		}
+	}
+
+	return name, version, nil
+}
+
+// https://go.dev/ref/mod#goproxy-protocol
+func EnumeratePackageVersions(ctx *context.Context) {
+	name, _, err := moduleParams(ctx)
+	if err != nil {
+		apiError(ctx, http.StatusNotFound, err)
+		return
+	}
+
+	pvs, err := packages_model.GetVersionsByPackageName(ctx, ctx.Package.Owner.ID, packages_model.TypeGo, name)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if len(pvs) == 0 {
+		apiError(ctx, http.StatusNotFound, packages_model.ErrPackageNotExist)
+		return
+	}
+
+	sort.Slice(pvs, func(i, j int) bool {
+		return semver.Compare(pvs[i].Version, pvs[j].Version) < 0
+	})
+
+	ctx.Resp.Header().Set("Content-Type", "text/plain;charset=utf-8")
+
+	// pseudo-versions are not listed, they are only resolved on request
+	for _, pv := range pvs {
+		if module.IsPseudoVersion(pv.Version) {
+			continue
+		}
+		fmt.Fprintln(ctx.Resp, pv.Version)
+	}
+}
+
+// https://go.dev/ref/mod#goproxy-protocol
+func PackageVersionMetadata(ctx *context.Context) {
+	pv, err := resolvePackage(ctx)
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, struct {
+		Version string    `json:"Version"`
+		Time    time.Time `json:"Time"`
+	}{
+		Version: pv.Version,
+		Time:    pv.CreatedUnix.AsLocalTime(),
+	})
+}
+
+// https://go.dev/ref/mod#goproxy-protocol
+func PackageVersionGoModContent(ctx *context.Context) {
+	pv, err := resolvePackage(ctx)
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	pps, err := packages_model.GetPropertiesByName(ctx, packages_model.PropertyTypeVersion, pv.ID, goproxy_module.PropertyGoMod)
+	if err != nil || len(pps) != 1 {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.PlainText(http.StatusOK, pps[0].Value)
+}
+
+// https://go.dev/ref/mod#goproxy-protocol
+func DownloadPackageFile(ctx *context.Context) {
+	pv, err := resolvePackage(ctx)
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	pfs, err := packages_model.GetFilesByVersionID(ctx, pv.ID)
+	if err != nil || len(pfs) != 1 {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	s, _, err := packages_service.GetPackageFileStream(ctx, pfs[0])
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	defer s.Close()
+
+	ctx.ServeContent(s, &context.ServeHeaderOptions{
+		ContentType:  "application/zip",
+		Filename:     pfs[0].Name,
+		LastModified: pfs[0].CreatedUnix.AsLocalTime(),
+	})
+}
+
+// resolvePackage gets the requested version or the latest version if "latest" is requested.
+// The latest version is the highest release version, or the highest pre-release or pseudo-version if there is no release.
+// https://go.dev/ref/mod#version-queries
+func resolvePackage(ctx *context.Context) (*packages_model.PackageVersion, error) {
+	name, version, err := moduleParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if version != "latest" {
+		return packages_model.GetVersionByNameAndVersion(ctx, ctx.Package.Owner.ID, packages_model.TypeGo, name, version)
+	}
+
+	pvs, err := packages_model.GetVersionsByPackageName(ctx, ctx.Package.Owner.ID, packages_model.TypeGo, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *packages_model.PackageVersion
+	for _, pv := range pvs {
+		if latest == nil || isNewerVersion(pv.Version, latest.Version) {
+			latest = pv
+		}
+	}
+	if latest == nil {
+		return nil, packages_model.ErrPackageNotExist
+	}
+	return latest, nil
+}
+
+func isNewerVersion(v, current string) bool {
+	if isRelease, isCurrentRelease := semver.Prerelease(v) == "", semver.Prerelease(current) == ""; isRelease != isCurrentRelease {
+		return isRelease
+	}
+	return semver.Compare(v, current) > 0
+}
+
+// UploadPackage adds a module zip to the registry
+func UploadPackage(ctx *context.Context) {
+	upload, close, err := ctx.UploadStream()
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if close {
+		defer upload.Close()
+	}
+
+	buf, err := packages_module.CreateHashedBufferFromReader(upload, 32*1024*1024)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer buf.Close()
+
+	gp, err := goproxy_module.ParsePackage(buf, buf.Size())
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			apiError(ctx, http.StatusBadRequest, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	if _, err := buf.Seek(0, io.SeekStart); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	_, _, err = packages_service.CreatePackageAndAddFile(
+		&packages_service.PackageCreationInfo{
+			PackageInfo: packages_service.PackageInfo{
+				Owner:       ctx.Package.Owner,
+				PackageType: packages_model.TypeGo,
+				Name:        gp.Name,
+				Version:     gp.Version,
+			},
+			Creator: ctx.Doer,
+			VersionProperties: map[string]string{
+				goproxy_module.PropertyGoMod: gp.GoMod,
+			},
+		},
+		&packages_service.PackageFileCreationInfo{
+			PackageFileInfo: packages_service.PackageFileInfo{
+				Filename: fmt.Sprintf("%v.zip", gp.Version),
+			},
+			Creator: ctx.Doer,
+			Data:    buf,
+			IsLead:  true,
+		},
+	)
+	if err != nil {
+		switch err {
+		case packages_model.ErrDuplicatePackageVersion:
+			apiError(ctx, http.StatusConflict, err)
+		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
+			apiError(ctx, http.StatusForbidden, err)
+		default:
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
@@ -4,12 +4,16 @@
 package helper
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+	packages_service "code.gitea.io/gitea/services/packages"
+	"code.gitea.io/gitea/services/packages/remote"
 )
 
 // LogAndProcessError logs an error and calls a custom callback with the processed error message.
@@ -35,3 +39,17 @@ func LogAndProcessError(ctx *context.Context, status int, obj interface{}, cb fu
 		cb(message)
 	}
 }
+
+// RemoteErrorStatus returns the status code for an error which occurred while fetching a package from a remote registry
+func RemoteErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, util.ErrNotExist):
+		return http.StatusNotFound
+	case errors.Is(err, remote.ErrRemoteUnavailable), errors.Is(err, util.ErrInvalidArgument):
+		return http.StatusBadGateway
+	case errors.Is(err, packages_service.ErrQuotaTotalCount), errors.Is(err, packages_service.ErrQuotaTypeSize), errors.Is(err, packages_service.ErrQuotaTotalSize):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -25,8 +25,10 @@ import (
 	"code.gitea.io/gitea/modules/log"
 	packages_module "code.gitea.io/gitea/modules/packages"
 	maven_module "code.gitea.io/gitea/modules/packages/maven"
+	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/routers/api/packages/helper"
 	packages_service "code.gitea.io/gitea/services/packages"
+	"code.gitea.io/gitea/services/packages/remote"
 )
 
 const (
@@ -80,6 +82,23 @@ func serveMavenMetadata(ctx *context.Context, params parameters) {
 	// /com/foo/project/maven-metadata.xml[.md5/.sha1/.sha256/.sha512]
 
 	packageName := params.GroupID + "-" + params.ArtifactID
+
+	client, err := remote.GetClientForPackage(ctx, ctx.Package.Owner.ID, packages_model.TypeMaven, packageName)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if client != nil {
+		err := serveRemoteMavenMetadata(ctx, client, params)
+		if err == nil {
+			return
+		}
+		// serve the already fetched versions if the remote repository is unavailable
+		if !errors.Is(err, util.ErrNotExist) {
+			log.Warn("Error fetching maven package %s from remote repository: %v", packageName, err)
+		}
+	}
+
 	pvs, err := packages_model.GetVersionsByPackageName(ctx, ctx.Package.Owner.ID, packages_model.TypeMaven, packageName)
 	if err != nil {
 		apiError(ctx, http.StatusInternalServerError, err)
@@ -101,7 +120,7 @@ func serveMavenMetadata(ctx *context.Context, params parameters) {
 		return pds[i].Version.CreatedUnix < pds[j].Version.CreatedUnix
 	})
 
-	xmlMetadata, err := xml.Marshal(createMetadataResponse(pds))
+	xmlMetadata, err := xml.Marshal(createMetadataResponse(params, pds))
 	if err != nil {
 		apiError(ctx, http.StatusInternalServerError, err)
 		return
@@ -143,16 +162,6 @@ func serveMavenMetadata(ctx *context.Context, params parameters) {
 func servePackageFile(ctx *context.Context, params parameters, serveContent bool) {
 	packageName := params.GroupID + "-" + params.ArtifactID
 
-	pv, err := packages_model.GetVersionByNameAndVersion(ctx, ctx.Package.Owner.ID, packages_model.TypeMaven, packageName, params.Version)
-	if err != nil {
-		if err == packages_model.ErrPackageNotExist {
-			apiError(ctx, http.StatusNotFound, err)
-		} else {
-			apiError(ctx, http.StatusInternalServerError, err)
-		}
-		return
-	}
-
 	filename := params.Filename
 
 	ext := strings.ToLower(filepath.Ext(filename))
@@ -160,9 +169,23 @@ func servePackageFile(ctx *context.Context, params parameters, serveContent bool
 		filename = filename[:len(filename)-len(ext)]
 	}
 
-	pf, err := packages_model.GetFileForVersionByName(ctx, pv.ID, filename, packages_model.EmptyFileKey)
+	pv, pf, err := getPackageFile(ctx, packageName, params.Version, filename)
+	if err == packages_model.ErrPackageNotExist || err == packages_model.ErrPackageFileNotExist {
+		client, err2 := remote.GetClientForPackage(ctx, ctx.Package.Owner.ID, packages_model.TypeMaven, packageName)
+		if err2 != nil {
+			apiError(ctx, http.StatusInternalServerError, err2)
+			return
+		}
+		if client != nil {
+			if err := fetchRemotePackageFile(ctx, client, params); err != nil {
+				apiError(ctx, helper.RemoteErrorStatus(err), err)
+				return
+			}
+			pv, pf, err = getPackageFile(ctx, packageName, params.Version, filename)
+		}
+	}
 	if err != nil {
-		if err == packages_model.ErrPackageFileNotExist {
+		if err == packages_model.ErrPackageNotExist || err == packages_model.ErrPackageFileNotExist {
 			apiError(ctx, http.StatusNotFound, err)
 		} else {
 			apiError(ctx, http.StatusInternalServerError, err)
@@ -318,8 +341,24 @@ func UploadPackageFile(ctx *context.Context) {
 		OverwriteExisting: params.IsMeta,
 	}
 
-	// If it's the package pom file extract the metadata
-	if ext == extensionPom {
+	if err := addPackageFile(ctx, pvci, pfci, buf); err != nil {
+		switch err {
+		case packages_model.ErrDuplicatePackageFile:
+			apiError(ctx, http.StatusBadRequest, err)
+		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
+			apiError(ctx, http.StatusForbidden, err)
+		default:
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
+
+// addPackageFile adds the file to the package version. If it's the package pom file the metadata is extracted.
+func addPackageFile(ctx *context.Context, pvci *packages_service.PackageCreationInfo, pfci *packages_service.PackageFileCreationInfo, buf *packages_module.HashedBuffer) error {
+	if filepath.Ext(pfci.Filename) == extensionPom {
 		pfci.IsLead = true
 
 		var err error
@@ -331,46 +370,42 @@ func UploadPackageFile(ctx *context.Context) {
 		if pvci.Metadata != nil {
 			pv, err := packages_model.GetVersionByNameAndVersion(ctx, pvci.Owner.ID, pvci.PackageType, pvci.Name, pvci.Version)
 			if err != nil && err != packages_model.ErrPackageNotExist {
-				apiError(ctx, http.StatusInternalServerError, err)
-				return
+				return err
 			}
 			if pv != nil {
 				raw, err := json.Marshal(pvci.Metadata)
 				if err != nil {
-					apiError(ctx, http.StatusInternalServerError, err)
-					return
+					return err
 				}
 				pv.MetadataJSON = string(raw)
 				if err := packages_model.UpdateVersion(ctx, pv); err != nil {
-					apiError(ctx, http.StatusInternalServerError, err)
-					return
+					return err
 				}
 			}
 		}
 
 		if _, err := buf.Seek(0, io.SeekStart); err != nil {
-			apiError(ctx, http.StatusInternalServerError, err)
-			return
+			return err
 		}
 	}
 
-	_, _, err = packages_service.CreatePackageOrAddFileToExisting(
+	_, _, err := packages_service.CreatePackageOrAddFileToExisting(
 		pvci,
 		pfci,
 	)
+	return err
+}
+
+func getPackageFile(ctx *context.Context, packageName, packageVersion, filename string) (*packages_model.PackageVersion, *packages_model.PackageFile, error) {
+	pv, err := packages_model.GetVersionByNameAndVersion(ctx, ctx.Package.Owner.ID, packages_model.TypeMaven, packageName, packageVersion)
 	if err != nil {
-		switch err {
-		case packages_model.ErrDuplicatePackageFile:
-			apiError(ctx, http.StatusBadRequest, err)
-		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
-			apiError(ctx, http.StatusForbidden, err)
-		default:
-			apiError(ctx, http.StatusInternalServerError, err)
-		}
-		return
+		return nil, nil, err
 	}
-
-	ctx.Status(http.StatusCreated)
+	pf, err := packages_model.GetFileForVersionByName(ctx, pv.ID, filename, packages_model.EmptyFileKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pv, pf, nil
 }
 
 func isChecksumExtension(ext string) bool {
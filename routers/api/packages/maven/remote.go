@@ -0,0 +1,115 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package maven
+
+import (
+	"encoding/hex"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/util"
+	packages_service "code.gitea.io/gitea/services/packages"
+	"code.gitea.io/gitea/services/packages/remote"
+)
+
+var errInvalidRemoteFile = util.NewInvalidArgumentErrorf("file of the remote registry is invalid")
+
+// remotePath returns the path of the file in the remote repository
+func remotePath(params parameters, filename string) string {
+	parts := strings.Split(params.GroupID, ".")
+	parts = append(parts, params.ArtifactID)
+	if params.Version != "" {
+		parts = append(parts, params.Version)
+	}
+	return strings.Join(append(parts, filename), "/")
+}
+
+// serveRemoteMavenMetadata passes the package index of the remote repository through
+func serveRemoteMavenMetadata(ctx *context.Context, client *remote.Client, params parameters) error {
+	resp, err := client.Get(ctx, remotePath(params, params.Filename), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	contentType := contentTypeXML
+	if isChecksumExtension(strings.ToLower(filepath.Ext(params.Filename))) {
+		contentType = "text/plain; charset=utf-8"
+	}
+	ctx.Resp.Header().Set("Content-Type", contentType)
+	if lastModified := resp.Header.Get("Last-Modified"); lastModified != "" {
+		ctx.Resp.Header().Set("Last-Modified", lastModified)
+	}
+	ctx.Resp.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(ctx.Resp, resp.Body); err != nil {
+		log.Error("Error writing remote maven metadata: %v", err)
+	}
+	return nil
+}
+
+// fetchRemotePackageFile requests the file of the package version from the remote repository and stores it
+func fetchRemotePackageFile(ctx *context.Context, client *remote.Client, params parameters) error {
+	filename := params.Filename
+	if ext := strings.ToLower(filepath.Ext(filename)); isChecksumExtension(ext) {
+		filename = filename[:len(filename)-len(ext)]
+	}
+
+	buf, err := client.Download(ctx, remotePath(params, filename), nil)
+	if err != nil {
+		return err
+	}
+	defer buf.Close()
+
+	// The checksum file is optional in a repository
+	checksum, err := client.Download(ctx, remotePath(params, filename+extensionSHA1), nil)
+	if err == nil {
+		defer checksum.Close()
+
+		hash, err := io.ReadAll(checksum)
+		if err != nil {
+			return err
+		}
+		_, hashSHA1, _, _ := buf.Sums()
+		// Some repositories append the filename to the hash
+		if fields := strings.Fields(string(hash)); len(fields) == 0 || !strings.EqualFold(fields[0], hex.EncodeToString(hashSHA1)) {
+			return errInvalidRemoteFile
+		}
+	} else if err != remote.ErrRemoteNotExist {
+		return err
+	}
+
+	pvci := &packages_service.PackageCreationInfo{
+		PackageInfo: packages_service.PackageInfo{
+			Owner:       ctx.Package.Owner,
+			PackageType: packages_model.TypeMaven,
+			Name:        params.GroupID + "-" + params.ArtifactID,
+			Version:     params.Version,
+		},
+		SemverCompatible:  false,
+		Creator:           remote.Creator(),
+		VersionProperties: client.VersionProperties(),
+	}
+	pfci := &packages_service.PackageFileCreationInfo{
+		PackageFileInfo: packages_service.PackageFileInfo{
+			Filename: filename,
+		},
+		Creator:           remote.Creator(),
+		Data:              buf,
+		IsLead:            false,
+		OverwriteExisting: params.IsMeta,
+	}
+
+	err = addPackageFile(ctx, pvci, pfci, buf)
+	// the file was fetched by a concurrent request
+	if err == packages_model.ErrDuplicatePackageFile {
+		return nil
+	}
+	return err
+}
@@ -14,12 +14,14 @@ import (
 	"code.gitea.io/gitea/models/db"
 	packages_model "code.gitea.io/gitea/models/packages"
 	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
 	packages_module "code.gitea.io/gitea/modules/packages"
 	npm_module "code.gitea.io/gitea/modules/packages/npm"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/routers/api/packages/helper"
 	packages_service "code.gitea.io/gitea/services/packages"
+	"code.gitea.io/gitea/services/packages/remote"
 
 	"github.com/hashicorp/go-version"
 )
@@ -49,6 +51,24 @@ func packageNameFromParams(ctx *context.Context) string {
 // PackageMetadata returns the metadata for a single package
 func PackageMetadata(ctx *context.Context) {
 	packageName := packageNameFromParams(ctx)
+	registryURL := setting.AppURL + "api/packages/" + ctx.Package.Owner.Name + "/npm"
+
+	client, err := remote.GetClientForPackage(ctx, ctx.Package.Owner.ID, packages_model.TypeNpm, packageName)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if client != nil {
+		metadata, err := fetchRemotePackageMetadata(ctx, client, registryURL, packageName)
+		if err == nil {
+			ctx.JSON(http.StatusOK, metadata)
+			return
+		}
+		// serve the already fetched versions if the remote registry is unavailable
+		if !errors.Is(err, util.ErrNotExist) {
+			log.Warn("Error fetching npm package %s from remote registry: %v", packageName, err)
+		}
+	}
 
 	pvs, err := packages_model.GetVersionsByPackageName(ctx, ctx.Package.Owner.ID, packages_model.TypeNpm, packageName)
 	if err != nil {
@@ -67,7 +87,7 @@ func PackageMetadata(ctx *context.Context) {
 	}
 
 	resp := createPackageMetadataResponse(
-		setting.AppURL+"api/packages/"+ctx.Package.Owner.Name+"/npm",
+		registryURL,
 		pds,
 	)
 
@@ -80,18 +100,31 @@ func DownloadPackageFile(ctx *context.Context) {
 	packageVersion := ctx.Params("version")
 	filename := ctx.Params("filename")
 
-	s, pf, err := packages_service.GetFileStreamByPackageNameAndVersion(
-		ctx,
-		&packages_service.PackageInfo{
-			Owner:       ctx.Package.Owner,
-			PackageType: packages_model.TypeNpm,
-			Name:        packageName,
-			Version:     packageVersion,
-		},
-		&packages_service.PackageFileInfo{
-			Filename: filename,
-		},
-	)
+	pi := &packages_service.PackageInfo{
+		Owner:       ctx.Package.Owner,
+		PackageType: packages_model.TypeNpm,
+		Name:        packageName,
+		Version:     packageVersion,
+	}
+	pfi := &packages_service.PackageFileInfo{
+		Filename: filename,
+	}
+
+	s, pf, err := packages_service.GetFileStreamByPackageNameAndVersion(ctx, pi, pfi)
+	if err == packages_model.ErrPackageNotExist {
+		client, err2 := remote.GetClientForPackage(ctx, ctx.Package.Owner.ID, packages_model.TypeNpm, packageName)
+		if err2 != nil {
+			apiError(ctx, http.StatusInternalServerError, err2)
+			return
+		}
+		if client != nil {
+			if err := fetchRemotePackageVersion(ctx, client, packageName, packageVersion, filename); err != nil {
+				apiError(ctx, helper.RemoteErrorStatus(err), err)
+				return
+			}
+			s, pf, err = packages_service.GetFileStreamByPackageNameAndVersion(ctx, pi, pfi)
+		}
+	}
 	if err != nil {
 		if err == packages_model.ErrPackageNotExist || err == packages_model.ErrPackageFileNotExist {
 			apiError(ctx, http.StatusNotFound, err)
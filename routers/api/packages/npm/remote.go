@@ -0,0 +1,141 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package npm
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/json"
+	npm_module "code.gitea.io/gitea/modules/packages/npm"
+	packages_service "code.gitea.io/gitea/services/packages"
+	"code.gitea.io/gitea/services/packages/remote"
+)
+
+// tarballFilename gets the file name from the tarball url of the remote registry
+func tarballFilename(tarball string) string {
+	u, err := url.Parse(tarball)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(path.Base(u.Path))
+}
+
+// fetchRemotePackageMetadata requests the package metadata from the remote registry.
+// The tarball urls are rewritten to point to this registry which fetches the files on first download.
+func fetchRemotePackageMetadata(ctx *context.Context, client *remote.Client, registryURL, packageName string) (map[string]interface{}, error) {
+	var metadata map[string]interface{}
+	if err := client.GetJSON(ctx, url.PathEscape(packageName), &metadata); err != nil {
+		return nil, err
+	}
+
+	versions, _ := metadata["versions"].(map[string]interface{})
+	for v, raw := range versions {
+		version, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		dist, ok := version["dist"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		tarball, _ := dist["tarball"].(string)
+		dist["tarball"] = fmt.Sprintf("%s/%s/-/%s/%s", registryURL, url.QueryEscape(packageName), url.PathEscape(v), url.PathEscape(tarballFilename(tarball)))
+	}
+
+	return metadata, nil
+}
+
+// fetchRemotePackageVersion requests the package version from the remote registry and stores it
+func fetchRemotePackageVersion(ctx *context.Context, client *remote.Client, packageName, packageVersion, filename string) error {
+	// only the requested version is decoded because old versions may contain fields in a different format
+	var metadata struct {
+		DistTags map[string]string      `json:"dist-tags"`
+		Versions map[string]interface{} `json:"versions"`
+	}
+	if err := client.GetJSON(ctx, url.PathEscape(packageName), &metadata); err != nil {
+		return err
+	}
+
+	raw, ok := metadata.Versions[packageVersion]
+	if !ok {
+		return packages_model.ErrPackageNotExist
+	}
+	var meta npm_module.PackageMetadataVersion
+	if data, err := json.Marshal(raw); err != nil {
+		return err
+	} else if err := json.Unmarshal(data, &meta); err != nil {
+		return fmt.Errorf("%w: %v", npm_module.ErrInvalidPackage, err)
+	}
+
+	if tarballFilename(meta.Dist.Tarball) != strings.ToLower(filename) {
+		return packages_model.ErrPackageFileNotExist
+	}
+
+	npmPackage, err := npm_module.ParsePackageMetadataVersion(&meta)
+	if err != nil {
+		return err
+	}
+
+	buf, err := client.Download(ctx, meta.Dist.Tarball, nil)
+	if err != nil {
+		return err
+	}
+	defer buf.Close()
+
+	_, hashSHA1, _, hashSHA512 := buf.Sums()
+	if meta.Dist.Integrity != "" {
+		if !npm_module.IsValidIntegrity(meta.Dist.Integrity, hashSHA1, hashSHA512) {
+			return npm_module.ErrInvalidIntegrity
+		}
+	} else if !strings.EqualFold(meta.Dist.Shasum, hex.EncodeToString(hashSHA1)) {
+		return npm_module.ErrInvalidIntegrity
+	}
+
+	pv, _, err := packages_service.CreatePackageAndAddFile(
+		&packages_service.PackageCreationInfo{
+			PackageInfo: packages_service.PackageInfo{
+				Owner:       ctx.Package.Owner,
+				PackageType: packages_model.TypeNpm,
+				Name:        npmPackage.Name,
+				Version:     npmPackage.Version,
+			},
+			SemverCompatible:  true,
+			Creator:           remote.Creator(),
+			Metadata:          npmPackage.Metadata,
+			VersionProperties: client.VersionProperties(),
+		},
+		&packages_service.PackageFileCreationInfo{
+			PackageFileInfo: packages_service.PackageFileInfo{
+				Filename: tarballFilename(meta.Dist.Tarball),
+			},
+			Creator: remote.Creator(),
+			Data:    buf,
+			IsLead:  true,
+		},
+	)
+	if err != nil {
+		// the version was fetched by a concurrent request
+		if err == packages_model.ErrDuplicatePackageVersion {
+			return nil
+		}
+		return err
+	}
+
+	for tag, version := range metadata.DistTags {
+		if version != npmPackage.Version {
+			continue
+		}
+		if err := setPackageTag(tag, pv, false); err != nil && err != errInvalidTagName {
+			return err
+		}
+	}
+
+	return nil
+}
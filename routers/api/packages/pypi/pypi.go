@@ -5,6 +5,7 @@ package pypi
 
 import (
 	"encoding/hex"
+	"errors"
 	"io"
 	"net/http"
 	"regexp"
@@ -12,12 +13,15 @@ import (
 
 	packages_model "code.gitea.io/gitea/models/packages"
 	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
 	packages_module "code.gitea.io/gitea/modules/packages"
 	pypi_module "code.gitea.io/gitea/modules/packages/pypi"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/modules/validation"
 	"code.gitea.io/gitea/routers/api/packages/helper"
 	packages_service "code.gitea.io/gitea/services/packages"
+	"code.gitea.io/gitea/services/packages/remote"
 )
 
 // https://peps.python.org/pep-0426/#name
@@ -45,6 +49,28 @@ func apiError(ctx *context.Context, status int, obj interface{}) {
 // PackageMetadata returns the metadata for a single package
 func PackageMetadata(ctx *context.Context) {
 	packageName := normalizer.Replace(ctx.Params("id"))
+	registryURL := setting.AppURL + "api/packages/" + ctx.Package.Owner.Name + "/pypi"
+
+	client, err := remote.GetClientForPackage(ctx, ctx.Package.Owner.ID, packages_model.TypePyPI, packageName)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if client != nil {
+		name, links, err := fetchRemoteFileLinks(ctx, client, packageName)
+		if err == nil {
+			ctx.Data["RegistryURL"] = registryURL
+			ctx.Data["PackageName"] = name
+			ctx.Data["PackageLowerName"] = strings.ToLower(packageName)
+			ctx.Data["RemoteFileLinks"] = links
+			ctx.HTML(http.StatusOK, "api/packages/pypi/simple")
+			return
+		}
+		// serve the already fetched versions if the remote registry is unavailable
+		if !errors.Is(err, util.ErrNotExist) {
+			log.Warn("Error fetching PyPI package %s from remote registry: %v", packageName, err)
+		}
+	}
 
 	pvs, err := packages_model.GetVersionsByPackageName(ctx, ctx.Package.Owner.ID, packages_model.TypePyPI, packageName)
 	if err != nil {
@@ -62,8 +88,8 @@ func PackageMetadata(ctx *context.Context) {
 		return
 	}
 
-	ctx.Data["RegistryURL"] = setting.AppURL + "api/packages/" + ctx.Package.Owner.Name + "/pypi"
-	ctx.Data["PackageDescriptor"] = pds[0]
+	ctx.Data["RegistryURL"] = registryURL
+	ctx.Data["PackageName"] = pds[0].Package.Name
 	ctx.Data["PackageDescriptors"] = pds
 	ctx.HTML(http.StatusOK, "api/packages/pypi/simple")
 }
@@ -74,18 +100,31 @@ func DownloadPackageFile(ctx *context.Context) {
 	packageVersion := ctx.Params("version")
 	filename := ctx.Params("filename")
 
-	s, pf, err := packages_service.GetFileStreamByPackageNameAndVersion(
-		ctx,
-		&packages_service.PackageInfo{
-			Owner:       ctx.Package.Owner,
-			PackageType: packages_model.TypePyPI,
-			Name:        packageName,
-			Version:     packageVersion,
-		},
-		&packages_service.PackageFileInfo{
-			Filename: filename,
-		},
-	)
+	pi := &packages_service.PackageInfo{
+		Owner:       ctx.Package.Owner,
+		PackageType: packages_model.TypePyPI,
+		Name:        packageName,
+		Version:     packageVersion,
+	}
+	pfi := &packages_service.PackageFileInfo{
+		Filename: filename,
+	}
+
+	s, pf, err := packages_service.GetFileStreamByPackageNameAndVersion(ctx, pi, pfi)
+	if err == packages_model.ErrPackageNotExist || err == packages_model.ErrPackageFileNotExist {
+		client, err2 := remote.GetClientForPackage(ctx, ctx.Package.Owner.ID, packages_model.TypePyPI, packageName)
+		if err2 != nil {
+			apiError(ctx, http.StatusInternalServerError, err2)
+			return
+		}
+		if client != nil {
+			if err := fetchRemotePackageFile(ctx, client, packageName, packageVersion, filename); err != nil {
+				apiError(ctx, helper.RemoteErrorStatus(err), err)
+				return
+			}
+			s, pf, err = packages_service.GetFileStreamByPackageNameAndVersion(ctx, pi, pfi)
+		}
+	}
 	if err != nil {
 		if err == packages_model.ErrPackageNotExist || err == packages_model.ErrPackageFileNotExist {
 			apiError(ctx, http.StatusNotFound, err)
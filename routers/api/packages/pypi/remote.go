@@ -0,0 +1,161 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package pypi
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/modules/context"
+	pypi_module "code.gitea.io/gitea/modules/packages/pypi"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/validation"
+	packages_service "code.gitea.io/gitea/services/packages"
+	"code.gitea.io/gitea/services/packages/remote"
+)
+
+var errInvalidRemoteFile = util.NewInvalidArgumentErrorf("file of the remote registry is invalid")
+
+// remoteInfo is the package information of the JSON API
+// https://warehouse.pypa.io/api-reference/json.html
+type remoteInfo struct {
+	Name           string `json:"name"`
+	Version        string `json:"version"`
+	Author         string `json:"author"`
+	Summary        string `json:"summary"`
+	Description    string `json:"description"`
+	HomePage       string `json:"home_page"`
+	License        string `json:"license"`
+	RequiresPython string `json:"requires_python"`
+}
+
+type remoteFile struct {
+	Filename string `json:"filename"`
+	URL      string `json:"url"`
+	Digests  struct {
+		SHA256 string `json:"sha256"`
+	} `json:"digests"`
+	RequiresPython string `json:"requires_python"`
+	Yanked         bool   `json:"yanked"`
+}
+
+// remoteFileLink is a file of the remote registry listed in the simple index
+type remoteFileLink struct {
+	Version        string
+	Filename       string
+	SHA256         string
+	RequiresPython string
+	Yanked         bool
+}
+
+// fetchRemoteFileLinks requests the files of all versions of the package from the remote registry
+func fetchRemoteFileLinks(ctx *context.Context, client *remote.Client, packageName string) (string, []*remoteFileLink, error) {
+	var project struct {
+		Info     remoteInfo              `json:"info"`
+		Releases map[string][]remoteFile `json:"releases"`
+	}
+	if err := client.GetJSON(ctx, fmt.Sprintf("pypi/%s/json", url.PathEscape(packageName)), &project); err != nil {
+		return "", nil, err
+	}
+
+	links := make([]*remoteFileLink, 0, len(project.Releases))
+	for version, files := range project.Releases {
+		for _, f := range files {
+			links = append(links, &remoteFileLink{
+				Version:        version,
+				Filename:       f.Filename,
+				SHA256:         f.Digests.SHA256,
+				RequiresPython: f.RequiresPython,
+				Yanked:         f.Yanked,
+			})
+		}
+	}
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].Filename < links[j].Filename
+	})
+
+	return project.Info.Name, links, nil
+}
+
+// fetchRemotePackageFile requests the file of the package version from the remote registry and stores it
+func fetchRemotePackageFile(ctx *context.Context, client *remote.Client, packageName, packageVersion, filename string) error {
+	var release struct {
+		Info remoteInfo   `json:"info"`
+		URLs []remoteFile `json:"urls"`
+	}
+	if err := client.GetJSON(ctx, fmt.Sprintf("pypi/%s/%s/json", url.PathEscape(packageName), url.PathEscape(packageVersion)), &release); err != nil {
+		return err
+	}
+
+	var file *remoteFile
+	for i := range release.URLs {
+		if strings.EqualFold(release.URLs[i].Filename, filename) {
+			file = &release.URLs[i]
+			break
+		}
+	}
+	if file == nil {
+		return packages_model.ErrPackageFileNotExist
+	}
+
+	name := normalizer.Replace(release.Info.Name)
+	if !isValidNameAndVersion(name, release.Info.Version) {
+		return errInvalidRemoteFile
+	}
+
+	buf, err := client.Download(ctx, file.URL, nil)
+	if err != nil {
+		return err
+	}
+	defer buf.Close()
+
+	_, _, hashSHA256, _ := buf.Sums()
+	if !strings.EqualFold(file.Digests.SHA256, hex.EncodeToString(hashSHA256)) {
+		return errInvalidRemoteFile
+	}
+
+	projectURL := release.Info.HomePage
+	if !validation.IsValidURL(projectURL) {
+		projectURL = ""
+	}
+
+	_, _, err = packages_service.CreatePackageOrAddFileToExisting(
+		&packages_service.PackageCreationInfo{
+			PackageInfo: packages_service.PackageInfo{
+				Owner:       ctx.Package.Owner,
+				PackageType: packages_model.TypePyPI,
+				Name:        name,
+				Version:     release.Info.Version,
+			},
+			SemverCompatible: false,
+			Creator:          remote.Creator(),
+			Metadata: &pypi_module.Metadata{
+				Author:         release.Info.Author,
+				Description:    release.Info.Description,
+				Summary:        release.Info.Summary,
+				ProjectURL:     projectURL,
+				License:        release.Info.License,
+				RequiresPython: release.Info.RequiresPython,
+			},
+			VersionProperties: client.VersionProperties(),
+		},
+		&packages_service.PackageFileCreationInfo{
+			PackageFileInfo: packages_service.PackageFileInfo{
+				Filename: file.Filename,
+			},
+			Creator: remote.Creator(),
+			Data:    buf,
+			IsLead:  true,
+		},
+	)
+	// the file was fetched by a concurrent request
+	if err == packages_model.ErrDuplicatePackageFile {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,256 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package scim
+
+import (
+	gocontext "context"
+	"net/http"
+	"strconv"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/organization"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/scim"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/services/auth"
+)
+
+const (
+	organizationContextKey = "SCIMOrganization"
+
+	// maxResults is the number of resources which are returned by a query at most
+	maxResults = 1000
+)
+
+// Routes provides the endpoints used by identity providers to provision users and groups.
+// These are mounted on `/api/scim/v2` (not `/api/v1/scim`)
+func Routes(ctx gocontext.Context) *web.Route {
+	r := web.NewRoute()
+
+	r.Use(context.PackageContexter(ctx))
+
+	authGroup := auth.NewGroup(&auth.OAuth2{})
+	r.Use(func(ctx *context.Context) {
+		var err error
+		ctx.Doer, err = authGroup.Verify(ctx.Req, ctx.Resp, ctx, ctx.Session)
+		if err != nil {
+			log.Error("Verify: %v", err)
+			apiError(ctx, http.StatusUnauthorized, "", "invalid access token")
+			return
+		}
+		ctx.IsSigned = ctx.Doer != nil
+	}, reqSiteAdminToken)
+
+	r.Get("/ServiceProviderConfig", ServiceProviderConfig)
+	r.Get("/ResourceTypes", ResourceTypes)
+	r.Get("/Schemas", Schemas)
+
+	r.Group("/Users", func() {
+		r.Get("", ListUsers)
+		r.Post("", CreateUser)
+		r.Group("/{id}", func() {
+			r.Get("", GetUser)
+			r.Put("", ReplaceUser)
+			r.Patch("", PatchUser)
+			r.Delete("", DeleteUser)
+		})
+	})
+
+	r.Group("/Groups", func() {
+		r.Get("", ListGroups)
+		r.Post("", CreateGroup)
+		r.Group("/{id}", func() {
+			r.Get("", GetGroup)
+			r.Put("", ReplaceGroup)
+			r.Patch("", PatchGroup)
+			r.Delete("", DeleteGroup)
+		})
+	}, reqOrganization)
+
+	return r
+}
+
+// reqSiteAdminToken requires an access token of a site administrator which has the sudo scope
+func reqSiteAdminToken(ctx *context.Context) {
+	if !ctx.IsSigned || ctx.Data["IsApiToken"] != true {
+		ctx.Resp.Header().Set("WWW-Authenticate", `Bearer realm="Gitea SCIM API"`)
+		apiError(ctx, http.StatusUnauthorized, "", "an access token is required")
+		return
+	}
+	scope, _ := ctx.Data["ApiTokenScope"].(auth_model.AccessTokenScope)
+	if allow, err := scope.HasScope(auth_model.AccessTokenScopeSudo); err != nil || !allow {
+		apiError(ctx, http.StatusForbidden, "", "the access token does not have the sudo scope")
+		return
+	}
+	if !ctx.Doer.IsAdmin || !ctx.Doer.IsActive || ctx.Doer.ProhibitLogin {
+		apiError(ctx, http.StatusForbidden, "", "the user of the access token is not a site administrator")
+		return
+	}
+}
+
+// reqOrganization loads the organization whose teams are the groups
+func reqOrganization(ctx *context.Context) {
+	if setting.SCIM.Organization == "" {
+		apiError(ctx, http.StatusNotFound, "", "groups are not supported")
+		return
+	}
+	org, err := organization.GetOrgByName(setting.SCIM.Organization)
+	if err != nil {
+		if organization.IsErrOrgNotExist(err) {
+			apiError(ctx, http.StatusNotFound, "", "groups are not supported")
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+	ctx.Data[organizationContextKey] = org
+}
+
+func getOrganization(ctx *context.Context) *organization.Organization {
+	return ctx.Data[organizationContextKey].(*organization.Organization)
+}
+
+func apiError(ctx *context.Context, status int, scimType string, obj interface{}) {
+	var message string
+	if err, ok := obj.(error); ok {
+		message = err.Error()
+	} else if obj != nil {
+		message = obj.(string)
+	}
+	if status == http.StatusInternalServerError {
+		log.ErrorWithSkip(1, message)
+		message = http.StatusText(status)
+	} else {
+		log.Debug(message)
+	}
+
+	writeResponse(ctx, status, scim.NewError(status, scimType, message))
+}
+
+func writeResponse(ctx *context.Context, status int, obj interface{}) {
+	ctx.Resp.Header().Set("Content-Type", scim.ContentType+";charset=utf-8")
+	ctx.Resp.WriteHeader(status)
+	if err := json.NewEncoder(ctx.Resp).Encode(obj); err != nil {
+		log.Error("Encode: %v", err)
+	}
+}
+
+func decodeRequest(ctx *context.Context, obj interface{}) bool {
+	if err := json.NewDecoder(ctx.Req.Body).Decode(obj); err != nil {
+		apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidSyntax, err)
+		return false
+	}
+	return true
+}
+
+func patchError(ctx *context.Context, err error) {
+	if scim.IsErrInvalidPatch(err) {
+		apiError(ctx, http.StatusBadRequest, err.(scim.ErrInvalidPatch).ScimType, err)
+		return
+	}
+	apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, err)
+}
+
+func resourceID(ctx *context.Context) int64 {
+	id, err := strconv.ParseInt(ctx.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0
+	}
+	return id
+}
+
+func resourceLocation(endpoint string, id int64) string {
+	return setting.AppURL + "api/scim/v2/" + endpoint + "/" + strconv.FormatInt(id, 10)
+}
+
+// listRange is the range of resources a query returns, it paginates the database queries
+type listRange struct {
+	startIndex int // 1-based
+	count      int
+}
+
+var _ db.Paginator = listRange{}
+
+// parseListRange returns the range of resources requested by the startIndex and count parameters
+func parseListRange(ctx *context.Context) listRange {
+	r := listRange{startIndex: ctx.FormInt("startIndex"), count: maxResults}
+	if r.startIndex < 1 {
+		r.startIndex = 1
+	}
+	if ctx.FormString("count") != "" {
+		r.count = ctx.FormInt("count")
+	}
+	if r.count < 0 {
+		r.count = 0
+	} else if r.count > maxResults {
+		r.count = maxResults
+	}
+	return r
+}
+
+// GetSkipTake returns the number of resources to skip and to return
+func (r listRange) GetSkipTake() (skip, take int) {
+	return r.startIndex - 1, r.count
+}
+
+// GetStartEnd returns the indexes of the first and behind the last resource
+func (r listRange) GetStartEnd() (start, end int) {
+	return r.startIndex - 1, r.startIndex - 1 + r.count
+}
+
+// IsListAll always returns false as the number of resources is limited
+func (r listRange) IsListAll() bool {
+	return false
+}
+
+// listResources applies the filter and the pagination of a query to the resources, it is only used for the few fixed resources of the discovery endpoints
+func listResources[T any](ctx *context.Context, resources []T) {
+	if filter := ctx.FormString("filter"); filter != "" {
+		f, err := scim.ParseFilter(filter)
+		if err != nil {
+			apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidFilter, err)
+			return
+		}
+		matched := make([]T, 0, len(resources))
+		for _, resource := range resources {
+			m, err := scim.ToMap(resource)
+			if err != nil {
+				apiError(ctx, http.StatusInternalServerError, "", err)
+				return
+			}
+			if f.Match(m) {
+				matched = append(matched, resource)
+			}
+		}
+		resources = matched
+	}
+
+	r := parseListRange(ctx)
+	total := len(resources)
+	start, end := r.GetStartEnd()
+	if start < len(resources) {
+		resources = resources[start:]
+	} else {
+		resources = resources[:0]
+	}
+	if end-start < len(resources) {
+		resources = resources[:end-start]
+	}
+	writeListResponse(ctx, r, total, resources)
+}
+
+// writeListResponse writes a page of the resources matched by a query
+func writeListResponse[T any](ctx *context.Context, r listRange, total int, resources []T) {
+	writeResponse(ctx, http.StatusOK, &scim.ListResponse{
+		Schemas:      []string{scim.SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   r.startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
@@ -0,0 +1,156 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package scim
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/scim"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+type supported struct {
+	Supported bool `json:"supported"`
+}
+
+// ServiceProviderConfig describes the features of the API, see RFC 7643 section 5
+func ServiceProviderConfig(ctx *context.Context) {
+	writeResponse(ctx, http.StatusOK, map[string]interface{}{
+		"schemas":          []string{scim.SchemaServiceProviderConfig},
+		"documentationUri": "https://docs.gitea.io/en-us/config-cheat-sheet/#scim-scim",
+		"patch":            supported{true},
+		"bulk": map[string]interface{}{
+			"supported":      false,
+			"maxOperations":  0,
+			"maxPayloadSize": 0,
+		},
+		"filter": map[string]interface{}{
+			"supported":  true,
+			"maxResults": maxResults,
+		},
+		"changePassword": supported{false},
+		"sort":           supported{false},
+		"etag":           supported{false},
+		"authenticationSchemes": []map[string]interface{}{
+			{
+				"type":        "oauthbearertoken",
+				"name":        "OAuth Bearer Token",
+				"description": "Authentication with an access token of a site administrator which has the sudo scope",
+				"primary":     true,
+			},
+		},
+		"meta": &scim.Meta{
+			ResourceType: "ServiceProviderConfig",
+			Location:     setting.AppURL + "api/scim/v2/ServiceProviderConfig",
+		},
+	})
+}
+
+func resourceTypes() []map[string]interface{} {
+	types := []map[string]interface{}{
+		{
+			"schemas":  []string{scim.SchemaResourceType},
+			"id":       "User",
+			"name":     "User",
+			"endpoint": "/Users",
+			"schema":   scim.SchemaUser,
+			"meta": &scim.Meta{
+				ResourceType: "ResourceType",
+				Location:     setting.AppURL + "api/scim/v2/ResourceTypes/User",
+			},
+		},
+	}
+	if setting.SCIM.Organization != "" {
+		types = append(types, map[string]interface{}{
+			"schemas":  []string{scim.SchemaResourceType},
+			"id":       "Group",
+			"name":     "Group",
+			"endpoint": "/Groups",
+			"schema":   scim.SchemaGroup,
+			"meta": &scim.Meta{
+				ResourceType: "ResourceType",
+				Location:     setting.AppURL + "api/scim/v2/ResourceTypes/Group",
+			},
+		})
+	}
+	return types
+}
+
+// ResourceTypes lists the resource types which are supported
+func ResourceTypes(ctx *context.Context) {
+	listResources(ctx, resourceTypes())
+}
+
+func attribute(name, typ string, multiValued, required bool, mutability string, subAttributes ...map[string]interface{}) map[string]interface{} {
+	attr := map[string]interface{}{
+		"name":        name,
+		"type":        typ,
+		"multiValued": multiValued,
+		"required":    required,
+		"caseExact":   false,
+		"mutability":  mutability,
+		"returned":    "default",
+		"uniqueness":  "none",
+	}
+	if name == "userName" {
+		attr["uniqueness"] = "server"
+	}
+	if len(subAttributes) > 0 {
+		attr["subAttributes"] = subAttributes
+	}
+	return attr
+}
+
+func schemas() []map[string]interface{} {
+	user := map[string]interface{}{
+		"id":          scim.SchemaUser,
+		"name":        "User",
+		"description": "User Account",
+		"attributes": []map[string]interface{}{
+			attribute("userName", "string", false, true, "readWrite"),
+			attribute("name", "complex", false, false, "readWrite",
+				attribute("formatted", "string", false, false, "readWrite"),
+				attribute("familyName", "string", false, false, "readWrite"),
+				attribute("givenName", "string", false, false, "readWrite"),
+			),
+			attribute("displayName", "string", false, false, "readWrite"),
+			attribute("emails", "complex", true, false, "readWrite",
+				attribute("value", "string", false, false, "readWrite"),
+				attribute("type", "string", false, false, "readWrite"),
+				attribute("primary", "boolean", false, false, "readWrite"),
+			),
+			attribute("active", "boolean", false, false, "readWrite"),
+		},
+		"meta": &scim.Meta{
+			ResourceType: "Schema",
+			Location:     setting.AppURL + "api/scim/v2/Schemas/" + scim.SchemaUser,
+		},
+	}
+	result := []map[string]interface{}{user}
+	if setting.SCIM.Organization != "" {
+		result = append(result, map[string]interface{}{
+			"id":          scim.SchemaGroup,
+			"name":        "Group",
+			"description": "Group",
+			"attributes": []map[string]interface{}{
+				attribute("displayName", "string", false, true, "readWrite"),
+				attribute("members", "complex", true, false, "readWrite",
+					attribute("value", "string", false, false, "immutable"),
+					attribute("display", "string", false, false, "readOnly"),
+				),
+			},
+			"meta": &scim.Meta{
+				ResourceType: "Schema",
+				Location:     setting.AppURL + "api/scim/v2/Schemas/" + scim.SchemaGroup,
+			},
+		})
+	}
+	return result
+}
+
+// Schemas lists the attributes of the resources which are supported
+func Schemas(ctx *context.Context) {
+	listResources(ctx, schemas())
+}
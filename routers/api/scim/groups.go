@@ -0,0 +1,378 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package scim
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/organization"
+	"code.gitea.io/gitea/models/perm"
+	unit_model "code.gitea.io/gitea/models/unit"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/scim"
+	org_service "code.gitea.io/gitea/services/org"
+)
+
+var invalidTeamNameChars = regexp.MustCompile(`[^\w.-]+`)
+
+// teamNameFromDisplayName returns the name of the team of a group, the characters which are not allowed are replaced
+func teamNameFromDisplayName(displayName string) string {
+	name := strings.Trim(invalidTeamNameChars.ReplaceAllString(displayName, "-"), "-")
+	if len(name) > 30 {
+		name = strings.TrimRight(name[:30], "-")
+	}
+	return name
+}
+
+func toSCIMGroup(t *organization.Team, externalID string) *scim.Group {
+	sg := &scim.Group{
+		Schemas:     []string{scim.SchemaGroup},
+		ID:          strconv.FormatInt(t.ID, 10),
+		ExternalID:  externalID,
+		DisplayName: t.Name,
+		Members:     make([]scim.MultiValued, 0, len(t.Members)),
+		Meta: &scim.Meta{
+			ResourceType: "Group",
+			Location:     resourceLocation("Groups", t.ID),
+		},
+	}
+	for _, u := range t.Members {
+		sg.Members = append(sg.Members, scim.MultiValued{
+			Value:   strconv.FormatInt(u.ID, 10),
+			Display: u.Name,
+			Ref:     resourceLocation("Users", u.ID),
+		})
+	}
+	return sg
+}
+
+func getTeam(ctx *context.Context) *organization.Team {
+	t, err := organization.GetTeamByID(ctx, resourceID(ctx))
+	if err != nil {
+		if organization.IsErrTeamNotExist(err) {
+			apiError(ctx, http.StatusNotFound, "", "the group does not exist")
+			return nil
+		}
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return nil
+	}
+	// the owners of the organization are not managed by SCIM
+	if t.OrgID != getOrganization(ctx).ID || t.IsOwnerTeam() {
+		apiError(ctx, http.StatusNotFound, "", "the group does not exist")
+		return nil
+	}
+	if err := t.LoadMembers(ctx); err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return nil
+	}
+	return t
+}
+
+func writeGroup(ctx *context.Context, status int, t *organization.Team) {
+	externalID, err := auth_model.GetSCIMExternalID(ctx, auth_model.SCIMResourceGroup, t.ID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+	if status == http.StatusCreated {
+		ctx.Resp.Header().Set("Location", resourceLocation("Groups", t.ID))
+	}
+	writeResponse(ctx, status, toSCIMGroup(t, externalID))
+}
+
+func teamError(ctx *context.Context, err error) {
+	switch {
+	case organization.IsErrTeamAlreadyExist(err):
+		apiError(ctx, http.StatusConflict, scim.ErrorTypeUniqueness, err)
+	case db.IsErrNameReserved(err):
+		apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, err)
+	default:
+		apiError(ctx, http.StatusInternalServerError, "", err)
+	}
+}
+
+// resolveMembers returns the users of the members of a group, it fails if a member is not a user
+func resolveMembers(ctx *context.Context, members []scim.MultiValued) map[int64]*user_model.User {
+	users := make(map[int64]*user_model.User, len(members))
+	for _, member := range members {
+		id, err := strconv.ParseInt(member.Value, 10, 64)
+		if err != nil {
+			apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, fmt.Sprintf("member %q is not a user", member.Value))
+			return nil
+		}
+		u, err := user_model.GetUserByID(ctx, id)
+		if err != nil || u.Type != user_model.UserTypeIndividual {
+			if err == nil || user_model.IsErrUserNotExist(err) {
+				apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, fmt.Sprintf("member %q is not a user", member.Value))
+				return nil
+			}
+			apiError(ctx, http.StatusInternalServerError, "", err)
+			return nil
+		}
+		users[id] = u
+	}
+	return users
+}
+
+// syncMembers adds and removes the members of a team to match the resolved members of a group
+func syncMembers(ctx *context.Context, t *organization.Team, users map[int64]*user_model.User) {
+	for _, u := range t.Members {
+		if _, ok := users[u.ID]; ok {
+			delete(users, u.ID)
+			continue
+		}
+		if err := org_service.RemoveTeamMember(ctx, ctx.Doer, t, u); err != nil {
+			teamError(ctx, err)
+			return
+		}
+	}
+	for _, u := range users {
+		if err := org_service.AddTeamMember(ctx, ctx.Doer, t, u); err != nil {
+			teamError(ctx, err)
+			return
+		}
+	}
+
+	if err := t.LoadMembers(ctx); err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+	}
+}
+
+// ListGroups queries the groups. Identity providers look up groups by their displayName or externalId,
+// so only these filters are supported as they can be resolved by the database.
+func ListGroups(ctx *context.Context) {
+	r := parseListRange(ctx)
+	opts := &organization.SearchTeamOptions{
+		Paginator:        r,
+		OrgID:            getOrganization(ctx).ID,
+		ExcludeOwnerTeam: true,
+	}
+
+	if filter := ctx.FormString("filter"); filter != "" {
+		f, err := scim.ParseFilter(filter)
+		if err != nil {
+			apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidFilter, err)
+			return
+		}
+		attr, value, ok := scim.EqualityFilter(f)
+		switch {
+		case ok && strings.EqualFold(attr, "displayName"):
+			opts.Name = value
+		case ok && strings.EqualFold(attr, "externalId"):
+			id, err := auth_model.GetSCIMResourceID(ctx, auth_model.SCIMResourceGroup, value)
+			if err != nil {
+				apiError(ctx, http.StatusInternalServerError, "", err)
+				return
+			}
+			if id == 0 {
+				writeListResponse(ctx, r, 0, []*scim.Group{})
+				return
+			}
+			opts.TeamID = id
+		default:
+			apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidFilter, "only the displayName and the externalId of groups can be filtered for equality")
+			return
+		}
+	}
+
+	teams, total, err := organization.SearchTeam(opts)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+	ids := make([]int64, 0, len(teams))
+	for _, t := range teams {
+		ids = append(ids, t.ID)
+	}
+	externalIDs, err := auth_model.GetSCIMExternalIDs(ctx, auth_model.SCIMResourceGroup, ids)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	// identity providers exclude the members of large groups from the queries
+	excludeMembers := false
+	for _, attr := range strings.Split(ctx.FormString("excludedAttributes"), ",") {
+		if strings.EqualFold(strings.TrimSpace(attr), "members") {
+			excludeMembers = true
+		}
+	}
+
+	resources := make([]*scim.Group, 0, len(teams))
+	for _, t := range teams {
+		if !excludeMembers {
+			if err := t.LoadMembers(ctx); err != nil {
+				apiError(ctx, http.StatusInternalServerError, "", err)
+				return
+			}
+		}
+		sg := toSCIMGroup(t, externalIDs[t.ID])
+		if excludeMembers {
+			sg.Members = nil
+		}
+		resources = append(resources, sg)
+	}
+	writeListResponse(ctx, r, int(total), resources)
+}
+
+// GetGroup returns a group
+func GetGroup(ctx *context.Context) {
+	t := getTeam(ctx)
+	if ctx.Written() {
+		return
+	}
+	writeGroup(ctx, http.StatusOK, t)
+}
+
+// CreateGroup provisions a group as a team of the organization
+func CreateGroup(ctx *context.Context) {
+	sg := &scim.Group{}
+	if !decodeRequest(ctx, sg) {
+		return
+	}
+	name := teamNameFromDisplayName(sg.DisplayName)
+	if name == "" {
+		apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, "displayName is required")
+		return
+	}
+
+	// the members are resolved first, so an invalid member doesn't leave a team behind
+	users := resolveMembers(ctx, sg.Members)
+	if ctx.Written() {
+		return
+	}
+
+	org := getOrganization(ctx)
+	t := &organization.Team{
+		OrgID:      org.ID,
+		Name:       name,
+		AccessMode: perm.AccessModeRead,
+		Units:      make([]*organization.TeamUnit, 0, len(unit_model.DefaultRepoUnits)),
+	}
+	for _, tp := range unit_model.DefaultRepoUnits {
+		t.Units = append(t.Units, &organization.TeamUnit{
+			OrgID:      org.ID,
+			Type:       tp,
+			AccessMode: t.AccessMode,
+		})
+	}
+	if err := org_service.NewTeam(ctx, ctx.Doer, t); err != nil {
+		teamError(ctx, err)
+		return
+	}
+	log.Trace("Team provisioned by SCIM: %s/%s", org.Name, t.Name)
+
+	syncMembers(ctx, t, users)
+	if ctx.Written() {
+		// the identity provider retries the request, which would conflict with the half provisioned team
+		if err := org_service.DeleteTeam(ctx, ctx.Doer, t); err != nil {
+			log.Error("Unable to delete the team %s/%s: %v", org.Name, t.Name, err)
+		}
+		return
+	}
+	if err := auth_model.SetSCIMExternalID(ctx, auth_model.SCIMResourceGroup, t.ID, sg.ExternalID); err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+	writeGroup(ctx, http.StatusCreated, t)
+}
+
+// updateGroup applies the attributes of a SCIM group to a team
+func updateGroup(ctx *context.Context, t *organization.Team, sg *scim.Group) {
+	name := teamNameFromDisplayName(sg.DisplayName)
+	if name == "" {
+		apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, "displayName is required")
+		return
+	}
+	users := resolveMembers(ctx, sg.Members)
+	if ctx.Written() {
+		return
+	}
+	if name != t.Name {
+		t.Name = name
+		if err := org_service.UpdateTeam(ctx, ctx.Doer, t, false, false); err != nil {
+			teamError(ctx, err)
+			return
+		}
+	}
+
+	syncMembers(ctx, t, users)
+	if ctx.Written() {
+		return
+	}
+	if err := auth_model.SetSCIMExternalID(ctx, auth_model.SCIMResourceGroup, t.ID, sg.ExternalID); err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+	writeGroup(ctx, http.StatusOK, t)
+}
+
+// ReplaceGroup replaces the name and the members of a group
+func ReplaceGroup(ctx *context.Context) {
+	t := getTeam(ctx)
+	if ctx.Written() {
+		return
+	}
+	sg := &scim.Group{}
+	if !decodeRequest(ctx, sg) {
+		return
+	}
+	updateGroup(ctx, t, sg)
+}
+
+// PatchGroup modifies the name and the members of a group
+func PatchGroup(ctx *context.Context) {
+	t := getTeam(ctx)
+	if ctx.Written() {
+		return
+	}
+	patch := &scim.PatchRequest{}
+	if !decodeRequest(ctx, patch) {
+		return
+	}
+
+	externalID, err := auth_model.GetSCIMExternalID(ctx, auth_model.SCIMResourceGroup, t.ID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+	m, err := scim.ToMap(toSCIMGroup(t, externalID))
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+	if err := scim.ApplyPatch(m, patch.Operations); err != nil {
+		patchError(ctx, err)
+		return
+	}
+	sg := &scim.Group{}
+	if err := scim.FromMap(m, sg); err != nil {
+		patchError(ctx, err)
+		return
+	}
+	updateGroup(ctx, t, sg)
+}
+
+// DeleteGroup deletes the team of a group
+func DeleteGroup(ctx *context.Context) {
+	t := getTeam(ctx)
+	if ctx.Written() {
+		return
+	}
+	if err := org_service.DeleteTeam(ctx, ctx.Doer, t); err != nil {
+		teamError(ctx, err)
+		return
+	}
+	log.Trace("Team deleted by SCIM: %s/%s", getOrganization(ctx).Name, t.Name)
+
+	ctx.Status(http.StatusNoContent)
+}
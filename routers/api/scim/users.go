@@ -0,0 +1,422 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package scim
+
+import (
+	gocontext "context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/scim"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+	user_service "code.gitea.io/gitea/services/user"
+)
+
+func toSCIMUser(u *user_model.User, externalID string) *scim.User {
+	active := u.IsActive && !u.ProhibitLogin
+	created, updated := u.CreatedUnix.AsTime(), u.UpdatedUnix.AsTime()
+	su := &scim.User{
+		Schemas:     []string{scim.SchemaUser},
+		ID:          strconv.FormatInt(u.ID, 10),
+		ExternalID:  externalID,
+		UserName:    u.LoginName,
+		DisplayName: u.DisplayName(),
+		Emails:      []scim.MultiValued{{Value: u.Email, Type: "work", Primary: true}},
+		Active:      &active,
+		Meta: &scim.Meta{
+			ResourceType: "User",
+			Created:      &created,
+			LastModified: &updated,
+			Location:     resourceLocation("Users", u.ID),
+		},
+	}
+	if su.UserName == "" {
+		su.UserName = u.Name
+	}
+	if u.FullName != "" {
+		su.Name = &scim.Name{Formatted: u.FullName}
+	}
+	return su
+}
+
+// getAuthSource returns the authentication source the provisioned users sign in with, it is nil if they sign in with a password
+func getAuthSource() (*auth_model.Source, error) {
+	if setting.SCIM.AuthSource == "" {
+		return nil, nil
+	}
+	sources, err := auth_model.AllActiveSources()
+	if err != nil {
+		return nil, err
+	}
+	for _, source := range sources {
+		if source.Name == setting.SCIM.AuthSource {
+			return source, nil
+		}
+	}
+	return nil, fmt.Errorf("the authentication source %q of SCIM does not exist or is not active", setting.SCIM.AuthSource)
+}
+
+// revokeCredentials deletes the access tokens and OAuth2 grants of a deactivated user, they would still work against the API otherwise
+func revokeCredentials(ctx gocontext.Context, u *user_model.User) error {
+	return db.WithTx(ctx, func(ctx gocontext.Context) error {
+		if err := auth_model.DeleteAccessTokensByUserID(ctx, u.ID); err != nil {
+			return err
+		}
+		return auth_model.RevokeOAuth2GrantsByUserID(ctx, u.ID)
+	})
+}
+
+// nameFromUserName returns the name of a new user, the local part is used if the userName is an email address
+func nameFromUserName(userName string) string {
+	if user_model.IsUsableUsername(userName) == nil {
+		return userName
+	}
+	if i := strings.IndexByte(userName, '@'); i > 0 && user_model.IsUsableUsername(userName[:i]) == nil {
+		return userName[:i]
+	}
+	return ""
+}
+
+// isUserNameUsed checks whether another user of the authentication source has the userName
+func isUserNameUsed(source *auth_model.Source, userName string, u *user_model.User) (bool, error) {
+	if source == nil {
+		return false, nil
+	}
+	other := &user_model.User{
+		LoginType:   source.Type,
+		LoginSource: source.ID,
+		LoginName:   userName,
+	}
+	has, err := user_model.GetUser(other)
+	return has && (u == nil || other.ID != u.ID), err
+}
+
+func getUser(ctx *context.Context) *user_model.User {
+	u, err := user_model.GetUserByID(ctx, resourceID(ctx))
+	if err != nil {
+		if user_model.IsErrUserNotExist(err) {
+			apiError(ctx, http.StatusNotFound, "", "the user does not exist")
+			return nil
+		}
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return nil
+	}
+	if u.Type != user_model.UserTypeIndividual {
+		apiError(ctx, http.StatusNotFound, "", "the user does not exist")
+		return nil
+	}
+	return u
+}
+
+func writeUser(ctx *context.Context, status int, u *user_model.User) {
+	externalID, err := auth_model.GetSCIMExternalID(ctx, auth_model.SCIMResourceUser, u.ID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+	if status == http.StatusCreated {
+		ctx.Resp.Header().Set("Location", resourceLocation("Users", u.ID))
+	}
+	writeResponse(ctx, status, toSCIMUser(u, externalID))
+}
+
+func userError(ctx *context.Context, err error) {
+	switch {
+	case user_model.IsErrUserAlreadyExist(err), user_model.IsErrEmailAlreadyUsed(err):
+		apiError(ctx, http.StatusConflict, scim.ErrorTypeUniqueness, err)
+	case user_model.IsErrEmailInvalid(err), user_model.IsErrEmailCharIsNotSupported(err),
+		db.IsErrNameReserved(err), db.IsErrNamePatternNotAllowed(err), db.IsErrNameCharsNotAllowed(err):
+		apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, err)
+	default:
+		apiError(ctx, http.StatusInternalServerError, "", err)
+	}
+}
+
+// ListUsers queries the users. Identity providers look up users by their userName or externalId,
+// so only these filters are supported as they can be resolved by the database.
+func ListUsers(ctx *context.Context) {
+	r := parseListRange(ctx)
+	opts := &user_model.SearchUserOptions{
+		Paginator: r,
+		Actor:     ctx.Doer,
+		Type:      user_model.UserTypeIndividual,
+		OrderBy:   db.SearchOrderByID,
+	}
+
+	if filter := ctx.FormString("filter"); filter != "" {
+		f, err := scim.ParseFilter(filter)
+		if err != nil {
+			apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidFilter, err)
+			return
+		}
+		attr, value, ok := scim.EqualityFilter(f)
+		switch {
+		case ok && strings.EqualFold(attr, "userName"):
+			opts.LoginName = value
+		case ok && strings.EqualFold(attr, "externalId"):
+			id, err := auth_model.GetSCIMResourceID(ctx, auth_model.SCIMResourceUser, value)
+			if err != nil {
+				apiError(ctx, http.StatusInternalServerError, "", err)
+				return
+			}
+			if id == 0 {
+				writeListResponse(ctx, r, 0, []*scim.User{})
+				return
+			}
+			opts.UID = id
+		default:
+			apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidFilter, "only the userName and the externalId of users can be filtered for equality")
+			return
+		}
+	}
+
+	users, total, err := user_model.SearchUsers(opts)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+	ids := make([]int64, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.ID)
+	}
+	externalIDs, err := auth_model.GetSCIMExternalIDs(ctx, auth_model.SCIMResourceUser, ids)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	resources := make([]*scim.User, 0, len(users))
+	for _, u := range users {
+		resources = append(resources, toSCIMUser(u, externalIDs[u.ID]))
+	}
+	writeListResponse(ctx, r, int(total), resources)
+}
+
+// GetUser returns a user
+func GetUser(ctx *context.Context) {
+	u := getUser(ctx)
+	if ctx.Written() {
+		return
+	}
+	writeUser(ctx, http.StatusOK, u)
+}
+
+// CreateUser provisions a user
+func CreateUser(ctx *context.Context) {
+	su := &scim.User{}
+	if !decodeRequest(ctx, su) {
+		return
+	}
+	if su.UserName == "" {
+		apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, "userName is required")
+		return
+	}
+	name := nameFromUserName(su.UserName)
+	if name == "" {
+		apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, fmt.Sprintf("userName %q is not a valid user name", su.UserName))
+		return
+	}
+	email := su.PrimaryEmail()
+	if email == "" && strings.Contains(su.UserName, "@") {
+		email = su.UserName
+	}
+	if email == "" {
+		apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, "an email is required")
+		return
+	}
+
+	source, err := getAuthSource()
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+	if used, err := isUserNameUsed(source, su.UserName, nil); err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	} else if used {
+		apiError(ctx, http.StatusConflict, scim.ErrorTypeUniqueness, fmt.Sprintf("userName %q is already used", su.UserName))
+		return
+	}
+
+	u := &user_model.User{
+		Name:          name,
+		FullName:      su.FullName(),
+		Email:         email,
+		LoginType:     auth_model.Plain,
+		LoginName:     su.UserName,
+		ProhibitLogin: !su.IsActive(),
+	}
+	if source != nil {
+		u.LoginType = source.Type
+		u.LoginSource = source.ID
+	} else {
+		// the users of password sign in have to reset the password before they can sign in
+		if u.Passwd, err = util.CryptoRandomString(40); err != nil {
+			apiError(ctx, http.StatusInternalServerError, "", err)
+			return
+		}
+	}
+	if err := user_model.CreateUser(u, &user_model.CreateUserOverwriteOptions{
+		IsActive: util.OptionalBoolOf(su.IsActive()),
+	}); err != nil {
+		userError(ctx, err)
+		return
+	}
+	log.Trace("User provisioned by SCIM: %s", u.Name)
+
+	if err := auth_model.SetSCIMExternalID(ctx, auth_model.SCIMResourceUser, u.ID, su.ExternalID); err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+	writeUser(ctx, http.StatusCreated, u)
+}
+
+// updateUser applies the attributes of a SCIM user to a user
+func updateUser(ctx *context.Context, u *user_model.User, su *scim.User) {
+	if su.UserName == "" {
+		apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, "userName is required")
+		return
+	}
+	if !su.IsActive() && u.ID == ctx.Doer.ID {
+		apiError(ctx, http.StatusBadRequest, scim.ErrorTypeMutability, "the user of the access token cannot be deactivated")
+		return
+	}
+
+	// the name of a user is not changed, the userName is its login name
+	if su.UserName != u.LoginName && (u.LoginName != "" || su.UserName != u.Name) {
+		source, err := getAuthSource()
+		if err != nil {
+			apiError(ctx, http.StatusInternalServerError, "", err)
+			return
+		}
+		if used, err := isUserNameUsed(source, su.UserName, u); err != nil {
+			apiError(ctx, http.StatusInternalServerError, "", err)
+			return
+		} else if used {
+			apiError(ctx, http.StatusConflict, scim.ErrorTypeUniqueness, fmt.Sprintf("userName %q is already used", su.UserName))
+			return
+		}
+		u.LoginName = su.UserName
+	}
+
+	emailChanged := false
+	if email := su.PrimaryEmail(); email != "" && !strings.EqualFold(email, u.Email) {
+		u.Email = email
+		emailChanged = true
+	}
+	u.FullName = su.FullName()
+	u.IsActive = su.IsActive()
+	u.ProhibitLogin = !su.IsActive()
+
+	if err := user_service.UpdateUser(ctx, ctx.Doer, u, emailChanged, "login_name", "email", "full_name", "is_active", "prohibit_login"); err != nil {
+		userError(ctx, err)
+		return
+	}
+	if !u.IsActive {
+		if err := revokeCredentials(ctx, u); err != nil {
+			apiError(ctx, http.StatusInternalServerError, "", err)
+			return
+		}
+	}
+	if err := auth_model.SetSCIMExternalID(ctx, auth_model.SCIMResourceUser, u.ID, su.ExternalID); err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+	writeUser(ctx, http.StatusOK, u)
+}
+
+// ReplaceUser replaces the attributes of a user
+func ReplaceUser(ctx *context.Context) {
+	u := getUser(ctx)
+	if ctx.Written() {
+		return
+	}
+	su := &scim.User{}
+	if !decodeRequest(ctx, su) {
+		return
+	}
+	updateUser(ctx, u, su)
+}
+
+// PatchUser modifies the attributes of a user
+func PatchUser(ctx *context.Context) {
+	u := getUser(ctx)
+	if ctx.Written() {
+		return
+	}
+	patch := &scim.PatchRequest{}
+	if !decodeRequest(ctx, patch) {
+		return
+	}
+
+	externalID, err := auth_model.GetSCIMExternalID(ctx, auth_model.SCIMResourceUser, u.ID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+	m, err := scim.ToMap(toSCIMUser(u, externalID))
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+	if err := scim.ApplyPatch(m, patch.Operations); err != nil {
+		patchError(ctx, err)
+		return
+	}
+	// some identity providers send the boolean as a string
+	if active, ok := m["active"].(string); ok {
+		m["active"] = strings.EqualFold(active, "true")
+	}
+	su := &scim.User{}
+	if err := scim.FromMap(m, su); err != nil {
+		patchError(ctx, err)
+		return
+	}
+	updateUser(ctx, u, su)
+}
+
+// DeleteUser deletes a user, users who own repositories, organizations or packages can only be deactivated
+func DeleteUser(ctx *context.Context) {
+	u := getUser(ctx)
+	if ctx.Written() {
+		return
+	}
+	if u.ID == ctx.Doer.ID {
+		apiError(ctx, http.StatusBadRequest, scim.ErrorTypeMutability, "the user of the access token cannot be deleted")
+		return
+	}
+
+	// the user is deactivated first, it stays that way if the user can't be deleted
+	u.IsActive = false
+	u.ProhibitLogin = true
+	if err := user_service.UpdateUser(ctx, ctx.Doer, u, false, "is_active", "prohibit_login"); err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+	if err := revokeCredentials(ctx, u); err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	if err := user_service.DeleteUser(ctx, ctx.Doer, u, false); err != nil {
+		if !models.IsErrUserOwnRepos(err) && !models.IsErrUserHasOrgs(err) && !models.IsErrUserOwnPackages(err) {
+			apiError(ctx, http.StatusInternalServerError, "", err)
+			return
+		}
+		log.Trace("User deactivated by SCIM instead of deleted: %s: %v", u.Name, err)
+	} else {
+		log.Trace("User deleted by SCIM: %s", u.Name)
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,103 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package admin
+
+import (
+	"fmt"
+	"net/http"
+
+	audit_model "code.gitea.io/gitea/models/audit"
+	"code.gitea.io/gitea/modules/context"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+	"code.gitea.io/gitea/services/convert"
+)
+
+// ListAuditEvents api for querying the audit log
+func ListAuditEvents(ctx *context.APIContext) {
+	// swagger:operation GET /admin/audit admin adminListAuditEvents
+	// ---
+	// summary: List the events of the audit log, newest first
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: action
+	//   in: query
+	//   description: only show events of this action, e.g. "access_token.create"
+	//   type: string
+	// - name: actor
+	//   in: query
+	//   description: only show events performed by the user with this name
+	//   type: string
+	// - name: scope_type
+	//   in: query
+	//   description: only show events in this type of scope
+	//   type: string
+	//   enum: [system, user, organization, repository]
+	// - name: scope
+	//   in: query
+	//   description: only show events in the scope with this name, e.g. "owner/repo" for repositories
+	//   type: string
+	// - name: since
+	//   in: query
+	//   description: Only show events created after the given time. This is a timestamp in RFC 3339 format
+	//   type: string
+	//   format: date-time
+	// - name: before
+	//   in: query
+	//   description: Only show events created before the given time. This is a timestamp in RFC 3339 format
+	//   type: string
+	//   format: date-time
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/AuditEventList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	before, since, err := context.GetQueryBeforeSince(ctx.Context)
+	if err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "GetQueryBeforeSince", err)
+		return
+	}
+
+	opts := audit_model.FindEventsOptions{
+		ListOptions: utils.GetListOptions(ctx),
+		Action:      audit_model.Action(ctx.FormTrim("action")),
+		ActorName:   ctx.FormTrim("actor"),
+		ScopeType:   audit_model.ScopeType(ctx.FormTrim("scope_type")),
+		ScopeName:   ctx.FormTrim("scope"),
+		Since:       timeutil.TimeStamp(since),
+		Before:      timeutil.TimeStamp(before),
+	}
+	if opts.Action != "" && !opts.Action.IsValid() {
+		ctx.Error(http.StatusUnprocessableEntity, "", fmt.Errorf("unknown action %q", opts.Action))
+		return
+	}
+
+	events, total, err := audit_model.FindEvents(ctx, opts)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindEvents", err)
+		return
+	}
+
+	apiEvents := make([]*api.AuditEvent, 0, len(events))
+	for _, e := range events {
+		apiEvents = append(apiEvents, convert.ToAuditEvent(e))
+	}
+
+	ctx.SetLinkHeader(int(total), opts.PageSize)
+	ctx.SetTotalCountHeader(total)
+	ctx.JSON(http.StatusOK, apiEvents)
+}
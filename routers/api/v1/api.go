@@ -108,7 +108,7 @@ func sudo() func(ctx *context.APIContext) {
 		}
 
 		if len(sudo) > 0 {
-			if ctx.IsSigned && ctx.Doer.IsAdmin {
+			if ctx.IsUserSiteAdmin() {
 				user, err := user_model.GetUserByName(ctx, sudo)
 				if err != nil {
 					if user_model.IsErrUserNotExist(err) {
@@ -190,6 +190,10 @@ func repoAssignment() func(ctx *context.APIContext) {
 			return
 		}
 
+		if token, ok := ctx.Data["ApiToken"].(*auth_model.AccessToken); ok {
+			ctx.Repo.Permission.RestrictByAccessToken(repo, token)
+		}
+
 		if !ctx.Repo.HasAccess() {
 			ctx.NotFound()
 			return
@@ -197,6 +201,16 @@ func repoAssignment() func(ctx *context.APIContext) {
 	}
 }
 
+// reqNotFineGrainedToken denies fine-grained access tokens, their permissions are only restricted to the token on the routes of
+// a repository or of the packages of an owner
+func reqNotFineGrainedToken() func(ctx *context.APIContext) {
+	return func(ctx *context.APIContext) {
+		if token, ok := ctx.Data["ApiToken"].(*auth_model.AccessToken); ok && token.IsFineGrained() {
+			ctx.Error(http.StatusForbidden, "reqNotFineGrainedToken", "a fine-grained token can only access the repositories and packages it has been created for")
+		}
+	}
+}
+
 func reqPackageAccess(accessMode perm.AccessMode) func(ctx *context.APIContext) {
 	return func(ctx *context.APIContext) {
 		if ctx.Package.AccessMode < accessMode && !ctx.IsUserSiteAdmin() {
@@ -659,467 +673,473 @@ func Routes(ctx gocontext.Context) *web.Route {
 	}))
 
 	m.Group("", func() {
-		// Miscellaneous (no scope required)
-		if setting.API.EnableSwagger {
-			m.Get("/swagger", func(ctx *context.APIContext) {
-				ctx.Redirect(setting.AppSubURL + "/api/swagger")
+		m.Group("", func() {
+			// Miscellaneous (no scope required)
+			if setting.API.EnableSwagger {
+				m.Get("/swagger", func(ctx *context.APIContext) {
+					ctx.Redirect(setting.AppSubURL + "/api/swagger")
+				})
+			}
+			m.Get("/version", misc.Version)
+			if setting.Federation.Enabled {
+				m.Get("/nodeinfo", misc.NodeInfo)
+				m.Group("/activitypub", func() {
+					m.Group("/user/{username}", func() {
+						m.Get("", activitypub.Person)
+						m.Post("/inbox", activitypub.ReqHTTPSignature(), activitypub.PersonInbox)
+					}, context_service.UserAssignmentAPI())
+				})
+			}
+			m.Get("/signing-key.gpg", misc.SigningKey)
+			m.Post("/markdown", bind(api.MarkdownOption{}), misc.Markdown)
+			m.Post("/markdown/raw", misc.MarkdownRaw)
+			m.Get("/search", reqExploreSignIn(), misc.Search)
+			m.Group("/settings", func() {
+				m.Get("/ui", settings.GetGeneralUISettings)
+				m.Get("/api", settings.GetGeneralAPISettings)
+				m.Get("/attachment", settings.GetGeneralAttachmentSettings)
+				m.Get("/repository", settings.GetGeneralRepoSettings)
 			})
-		}
-		m.Get("/version", misc.Version)
-		if setting.Federation.Enabled {
-			m.Get("/nodeinfo", misc.NodeInfo)
-			m.Group("/activitypub", func() {
-				m.Group("/user/{username}", func() {
-					m.Get("", activitypub.Person)
-					m.Post("/inbox", activitypub.ReqHTTPSignature(), activitypub.PersonInbox)
+
+			// Notifications (requires 'notification' scope)
+			m.Group("/notifications", func() {
+				m.Combo("").
+					Get(notify.ListNotifications).
+					Put(notify.ReadNotifications)
+				m.Get("/new", notify.NewAvailable)
+				m.Combo("/threads/{id}").
+					Get(notify.GetThread).
+					Patch(notify.ReadThread)
+			}, reqToken(auth_model.AccessTokenScopeNotification))
+
+			// Users (no scope required)
+			m.Group("/users", func() {
+				m.Get("/search", reqExploreSignIn(), user.Search)
+
+				m.Group("/{username}", func() {
+					m.Get("", reqExploreSignIn(), user.GetInfo)
+
+					if setting.Service.EnableUserHeatmap {
+						m.Get("/heatmap", user.GetUserHeatmapData)
+					}
+
+					m.Get("/repos", reqExploreSignIn(), user.ListUserRepos)
+					m.Group("/tokens", func() {
+						m.Combo("").Get(user.ListAccessTokens).
+							Post(bind(api.CreateAccessTokenOption{}), user.CreateAccessToken)
+						m.Combo("/{id}").Delete(user.DeleteAccessToken)
+					}, reqBasicAuth())
 				}, context_service.UserAssignmentAPI())
 			})
-		}
-		m.Get("/signing-key.gpg", misc.SigningKey)
-		m.Post("/markdown", bind(api.MarkdownOption{}), misc.Markdown)
-		m.Post("/markdown/raw", misc.MarkdownRaw)
-		m.Group("/settings", func() {
-			m.Get("/ui", settings.GetGeneralUISettings)
-			m.Get("/api", settings.GetGeneralAPISettings)
-			m.Get("/attachment", settings.GetGeneralAttachmentSettings)
-			m.Get("/repository", settings.GetGeneralRepoSettings)
-		})
 
-		// Notifications (requires 'notification' scope)
-		m.Group("/notifications", func() {
-			m.Combo("").
-				Get(notify.ListNotifications).
-				Put(notify.ReadNotifications)
-			m.Get("/new", notify.NewAvailable)
-			m.Combo("/threads/{id}").
-				Get(notify.GetThread).
-				Patch(notify.ReadThread)
-		}, reqToken(auth_model.AccessTokenScopeNotification))
-
-		// Users (no scope required)
-		m.Group("/users", func() {
-			m.Get("/search", reqExploreSignIn(), user.Search)
-
-			m.Group("/{username}", func() {
-				m.Get("", reqExploreSignIn(), user.GetInfo)
-
-				if setting.Service.EnableUserHeatmap {
-					m.Get("/heatmap", user.GetUserHeatmapData)
-				}
+			// (no scope required)
+			m.Group("/users", func() {
+				m.Group("/{username}", func() {
+					m.Get("/keys", user.ListPublicKeys)
+					m.Get("/gpg_keys", user.ListGPGKeys)
 
-				m.Get("/repos", reqExploreSignIn(), user.ListUserRepos)
-				m.Group("/tokens", func() {
-					m.Combo("").Get(user.ListAccessTokens).
-						Post(bind(api.CreateAccessTokenOption{}), user.CreateAccessToken)
-					m.Combo("/{id}").Delete(user.DeleteAccessToken)
-				}, reqBasicAuth())
-			}, context_service.UserAssignmentAPI())
-		})
+					m.Get("/followers", user.ListFollowers)
+					m.Group("/following", func() {
+						m.Get("", user.ListFollowing)
+						m.Get("/{target}", user.CheckFollowing)
+					})
+
+					m.Get("/starred", user.GetStarredRepos)
+
+					m.Get("/subscriptions", user.GetWatchedRepos)
+				}, context_service.UserAssignmentAPI())
+			}, reqToken(""))
 
-		// (no scope required)
-		m.Group("/users", func() {
-			m.Group("/{username}", func() {
-				m.Get("/keys", user.ListPublicKeys)
-				m.Get("/gpg_keys", user.ListGPGKeys)
+			m.Group("/user", func() {
+				m.Get("", user.GetAuthenticatedUser)
+				m.Group("/settings", func() {
+					m.Get("", reqToken(auth_model.AccessTokenScopeReadUser), user.GetUserSettings)
+					m.Patch("", reqToken(auth_model.AccessTokenScopeUser), bind(api.UserSettingsOptions{}), user.UpdateUserSettings)
+				})
+				m.Combo("/emails").Get(reqToken(auth_model.AccessTokenScopeReadUser), user.ListEmails).
+					Post(reqToken(auth_model.AccessTokenScopeUser), bind(api.CreateEmailOption{}), user.AddEmail).
+					Delete(reqToken(auth_model.AccessTokenScopeUser), bind(api.DeleteEmailOption{}), user.DeleteEmail)
 
-				m.Get("/followers", user.ListFollowers)
+				m.Get("/followers", user.ListMyFollowers)
 				m.Group("/following", func() {
-					m.Get("", user.ListFollowing)
-					m.Get("/{target}", user.CheckFollowing)
+					m.Get("", user.ListMyFollowing)
+					m.Group("/{username}", func() {
+						m.Get("", user.CheckMyFollowing)
+						m.Put("", reqToken(auth_model.AccessTokenScopeUserFollow), user.Follow)      // requires 'user:follow' scope
+						m.Delete("", reqToken(auth_model.AccessTokenScopeUserFollow), user.Unfollow) // requires 'user:follow' scope
+					}, context_service.UserAssignmentAPI())
+				})
+
+				// (admin:public_key scope)
+				m.Group("/keys", func() {
+					m.Combo("").Get(reqToken(auth_model.AccessTokenScopeReadPublicKey), user.ListMyPublicKeys).
+						Post(reqToken(auth_model.AccessTokenScopeWritePublicKey), bind(api.CreateKeyOption{}), user.CreatePublicKey)
+					m.Combo("/{id}").Get(reqToken(auth_model.AccessTokenScopeReadPublicKey), user.GetPublicKey).
+						Delete(reqToken(auth_model.AccessTokenScopeWritePublicKey), user.DeletePublicKey)
 				})
 
-				m.Get("/starred", user.GetStarredRepos)
+				// (admin:application scope)
+				m.Group("/applications", func() {
+					m.Combo("/oauth2").
+						Get(reqToken(auth_model.AccessTokenScopeReadApplication), user.ListOauth2Applications).
+						Post(reqToken(auth_model.AccessTokenScopeWriteApplication), bind(api.CreateOAuth2ApplicationOptions{}), user.CreateOauth2Application)
+					m.Combo("/oauth2/{id}").
+						Delete(reqToken(auth_model.AccessTokenScopeWriteApplication), user.DeleteOauth2Application).
+						Patch(reqToken(auth_model.AccessTokenScopeWriteApplication), bind(api.CreateOAuth2ApplicationOptions{}), user.UpdateOauth2Application).
+						Get(reqToken(auth_model.AccessTokenScopeReadApplication), user.GetOauth2Application)
+				})
 
-				m.Get("/subscriptions", user.GetWatchedRepos)
-			}, context_service.UserAssignmentAPI())
-		}, reqToken(""))
+				// (admin:gpg_key scope)
+				m.Group("/gpg_keys", func() {
+					m.Combo("").Get(reqToken(auth_model.AccessTokenScopeReadGPGKey), user.ListMyGPGKeys).
+						Post(reqToken(auth_model.AccessTokenScopeWriteGPGKey), bind(api.CreateGPGKeyOption{}), user.CreateGPGKey)
+					m.Combo("/{id}").Get(reqToken(auth_model.AccessTokenScopeReadGPGKey), user.GetGPGKey).
+						Delete(reqToken(auth_model.AccessTokenScopeWriteGPGKey), user.DeleteGPGKey)
+				})
+				m.Get("/gpg_key_token", reqToken(auth_model.AccessTokenScopeReadGPGKey), user.GetVerificationToken)
+				m.Post("/gpg_key_verify", reqToken(auth_model.AccessTokenScopeReadGPGKey), bind(api.VerifyGPGKeyOption{}), user.VerifyUserGPGKey)
+
+				// (repo scope)
+				m.Combo("/repos", reqToken(auth_model.AccessTokenScopeRepo)).Get(user.ListMyRepos).
+					Post(bind(api.CreateRepoOption{}), repo.Create)
+
+				// (repo scope)
+				m.Group("/starred", func() {
+					m.Get("", user.GetMyStarredRepos)
+					m.Group("/{username}/{reponame}", func() {
+						m.Get("", user.IsStarring)
+						m.Put("", user.Star)
+						m.Delete("", user.Unstar)
+					}, repoAssignment())
+				}, reqToken(auth_model.AccessTokenScopeRepo))
+				m.Get("/times", reqToken(auth_model.AccessTokenScopeRepo), repo.ListMyTrackedTimes)
+				m.Get("/stopwatches", reqToken(auth_model.AccessTokenScopeRepo), repo.GetStopwatches)
+				m.Get("/subscriptions", reqToken(auth_model.AccessTokenScopeRepo), user.GetMyWatchedRepos)
+				m.Get("/teams", reqToken(auth_model.AccessTokenScopeRepo), org.ListUserTeams)
+			}, reqToken(""))
 
-		m.Group("/user", func() {
-			m.Get("", user.GetAuthenticatedUser)
-			m.Group("/settings", func() {
-				m.Get("", reqToken(auth_model.AccessTokenScopeReadUser), user.GetUserSettings)
-				m.Patch("", reqToken(auth_model.AccessTokenScopeUser), bind(api.UserSettingsOptions{}), user.UpdateUserSettings)
-			})
-			m.Combo("/emails").Get(reqToken(auth_model.AccessTokenScopeReadUser), user.ListEmails).
-				Post(reqToken(auth_model.AccessTokenScopeUser), bind(api.CreateEmailOption{}), user.AddEmail).
-				Delete(reqToken(auth_model.AccessTokenScopeUser), bind(api.DeleteEmailOption{}), user.DeleteEmail)
+			// Repositories
+			m.Post("/org/{org}/repos", reqToken(auth_model.AccessTokenScopeAdminOrg), bind(api.CreateRepoOption{}), repo.CreateOrgRepoDeprecated)
 
-			m.Get("/followers", user.ListMyFollowers)
-			m.Group("/following", func() {
-				m.Get("", user.ListMyFollowing)
-				m.Group("/{username}", func() {
-					m.Get("", user.CheckMyFollowing)
-					m.Put("", reqToken(auth_model.AccessTokenScopeUserFollow), user.Follow)      // requires 'user:follow' scope
-					m.Delete("", reqToken(auth_model.AccessTokenScopeUserFollow), user.Unfollow) // requires 'user:follow' scope
-				}, context_service.UserAssignmentAPI())
-			})
+			m.Combo("/repositories/{id}", reqToken(auth_model.AccessTokenScopeRepo)).Get(repo.GetByID)
 
-			// (admin:public_key scope)
-			m.Group("/keys", func() {
-				m.Combo("").Get(reqToken(auth_model.AccessTokenScopeReadPublicKey), user.ListMyPublicKeys).
-					Post(reqToken(auth_model.AccessTokenScopeWritePublicKey), bind(api.CreateKeyOption{}), user.CreatePublicKey)
-				m.Combo("/{id}").Get(reqToken(auth_model.AccessTokenScopeReadPublicKey), user.GetPublicKey).
-					Delete(reqToken(auth_model.AccessTokenScopeWritePublicKey), user.DeletePublicKey)
-			})
+			m.Group("/repos", func() {
+				m.Get("/search", repo.Search)
 
-			// (admin:application scope)
-			m.Group("/applications", func() {
-				m.Combo("/oauth2").
-					Get(reqToken(auth_model.AccessTokenScopeReadApplication), user.ListOauth2Applications).
-					Post(reqToken(auth_model.AccessTokenScopeWriteApplication), bind(api.CreateOAuth2ApplicationOptions{}), user.CreateOauth2Application)
-				m.Combo("/oauth2/{id}").
-					Delete(reqToken(auth_model.AccessTokenScopeWriteApplication), user.DeleteOauth2Application).
-					Patch(reqToken(auth_model.AccessTokenScopeWriteApplication), bind(api.CreateOAuth2ApplicationOptions{}), user.UpdateOauth2Application).
-					Get(reqToken(auth_model.AccessTokenScopeReadApplication), user.GetOauth2Application)
-			})
+				m.Get("/issues/search", repo.SearchIssues)
 
-			// (admin:gpg_key scope)
-			m.Group("/gpg_keys", func() {
-				m.Combo("").Get(reqToken(auth_model.AccessTokenScopeReadGPGKey), user.ListMyGPGKeys).
-					Post(reqToken(auth_model.AccessTokenScopeWriteGPGKey), bind(api.CreateGPGKeyOption{}), user.CreateGPGKey)
-				m.Combo("/{id}").Get(reqToken(auth_model.AccessTokenScopeReadGPGKey), user.GetGPGKey).
-					Delete(reqToken(auth_model.AccessTokenScopeWriteGPGKey), user.DeleteGPGKey)
+				// (repo scope)
+				m.Post("/migrate", reqToken(auth_model.AccessTokenScopeRepo), bind(api.MigrateRepoOptions{}), repo.Migrate)
 			})
-			m.Get("/gpg_key_token", reqToken(auth_model.AccessTokenScopeReadGPGKey), user.GetVerificationToken)
-			m.Post("/gpg_key_verify", reqToken(auth_model.AccessTokenScopeReadGPGKey), bind(api.VerifyGPGKeyOption{}), user.VerifyUserGPGKey)
-
-			// (repo scope)
-			m.Combo("/repos", reqToken(auth_model.AccessTokenScopeRepo)).Get(user.ListMyRepos).
-				Post(bind(api.CreateRepoOption{}), repo.Create)
-
-			// (repo scope)
-			m.Group("/starred", func() {
-				m.Get("", user.GetMyStarredRepos)
-				m.Group("/{username}/{reponame}", func() {
-					m.Get("", user.IsStarring)
-					m.Put("", user.Star)
-					m.Delete("", user.Unstar)
-				}, repoAssignment())
+		}, reqNotFineGrainedToken())
+
+		// fine-grained tokens are only accepted on the routes of a repository or of the packages of an owner,
+		// there repoAssignment and context.PackageAssignmentAPI restrict the permissions to the token.
+		// Routes creating or moving a repository into another owner are still denied to them.
+		m.Group("/repos/{username}/{reponame}", func() {
+			m.Combo("").Get(reqAnyRepoReader(), repo.Get).
+				Delete(reqToken(auth_model.AccessTokenScopeDeleteRepo), reqOwner(), repo.Delete).
+				Patch(reqToken(auth_model.AccessTokenScopeRepo), reqAdmin(), bind(api.EditRepoOption{}), repo.Edit)
+			m.Post("/generate", reqToken(auth_model.AccessTokenScopeRepo), reqNotFineGrainedToken(), reqRepoReader(unit.TypeCode), bind(api.GenerateRepoOption{}), repo.Generate)
+			m.Group("/transfer", func() {
+				m.Post("", reqOwner(), bind(api.TransferRepoOption{}), repo.Transfer)
+				m.Post("/accept", repo.AcceptTransfer)
+				m.Post("/reject", repo.RejectTransfer)
+			}, reqToken(auth_model.AccessTokenScopeRepo), reqNotFineGrainedToken())
+			m.Combo("/notifications", reqToken(auth_model.AccessTokenScopeNotification)).
+				Get(notify.ListRepoNotifications).
+				Put(notify.ReadRepoNotifications)
+			m.Group("/hooks/git", func() {
+				m.Combo("").Get(reqToken(auth_model.AccessTokenScopeReadRepoHook), repo.ListGitHooks)
+				m.Group("/{id}", func() {
+					m.Combo("").Get(reqToken(auth_model.AccessTokenScopeReadRepoHook), repo.GetGitHook).
+						Patch(reqToken(auth_model.AccessTokenScopeWriteRepoHook), bind(api.EditGitHookOption{}), repo.EditGitHook).
+						Delete(reqToken(auth_model.AccessTokenScopeWriteRepoHook), repo.DeleteGitHook)
+				})
+			}, reqAdmin(), reqGitHook(), context.ReferencesGitRepo(true))
+			m.Group("/hooks", func() {
+				m.Combo("").Get(reqToken(auth_model.AccessTokenScopeReadRepoHook), repo.ListHooks).
+					Post(reqToken(auth_model.AccessTokenScopeWriteRepoHook), bind(api.CreateHookOption{}), repo.CreateHook)
+				m.Group("/{id}", func() {
+					m.Combo("").Get(reqToken(auth_model.AccessTokenScopeReadRepoHook), repo.GetHook).
+						Patch(reqToken(auth_model.AccessTokenScopeWriteRepoHook), bind(api.EditHookOption{}), repo.EditHook).
+						Delete(reqToken(auth_model.AccessTokenScopeWriteRepoHook), repo.DeleteHook)
+					m.Post("/tests", reqToken(auth_model.AccessTokenScopeReadRepoHook), context.ReferencesGitRepo(), context.RepoRefForAPI, repo.TestHook)
+				})
+			}, reqAdmin(), reqWebhooksEnabled())
+			m.Group("/collaborators", func() {
+				m.Get("", reqAnyRepoReader(), repo.ListCollaborators)
+				m.Group("/{collaborator}", func() {
+					m.Combo("").Get(reqAnyRepoReader(), repo.IsCollaborator).
+						Put(reqAdmin(), bind(api.AddCollaboratorOption{}), repo.AddCollaborator).
+						Delete(reqAdmin(), repo.DeleteCollaborator)
+					m.Get("/permission", repo.GetRepoPermissions)
+				})
 			}, reqToken(auth_model.AccessTokenScopeRepo))
-			m.Get("/times", reqToken(auth_model.AccessTokenScopeRepo), repo.ListMyTrackedTimes)
-			m.Get("/stopwatches", reqToken(auth_model.AccessTokenScopeRepo), repo.GetStopwatches)
-			m.Get("/subscriptions", reqToken(auth_model.AccessTokenScopeRepo), user.GetMyWatchedRepos)
-			m.Get("/teams", reqToken(auth_model.AccessTokenScopeRepo), org.ListUserTeams)
-		}, reqToken(""))
-
-		// Repositories
-		m.Post("/org/{org}/repos", reqToken(auth_model.AccessTokenScopeAdminOrg), bind(api.CreateRepoOption{}), repo.CreateOrgRepoDeprecated)
-
-		m.Combo("/repositories/{id}", reqToken(auth_model.AccessTokenScopeRepo)).Get(repo.GetByID)
-
-		m.Group("/repos", func() {
-			m.Get("/search", repo.Search)
-
-			m.Get("/issues/search", repo.SearchIssues)
-
-			// (repo scope)
-			m.Post("/migrate", reqToken(auth_model.AccessTokenScopeRepo), bind(api.MigrateRepoOptions{}), repo.Migrate)
-
-			m.Group("/{username}/{reponame}", func() {
-				m.Combo("").Get(reqAnyRepoReader(), repo.Get).
-					Delete(reqToken(auth_model.AccessTokenScopeDeleteRepo), reqOwner(), repo.Delete).
-					Patch(reqToken(auth_model.AccessTokenScopeRepo), reqAdmin(), bind(api.EditRepoOption{}), repo.Edit)
-				m.Post("/generate", reqToken(auth_model.AccessTokenScopeRepo), reqRepoReader(unit.TypeCode), bind(api.GenerateRepoOption{}), repo.Generate)
-				m.Group("/transfer", func() {
-					m.Post("", reqOwner(), bind(api.TransferRepoOption{}), repo.Transfer)
-					m.Post("/accept", repo.AcceptTransfer)
-					m.Post("/reject", repo.RejectTransfer)
-				}, reqToken(auth_model.AccessTokenScopeRepo))
-				m.Combo("/notifications", reqToken(auth_model.AccessTokenScopeNotification)).
-					Get(notify.ListRepoNotifications).
-					Put(notify.ReadRepoNotifications)
-				m.Group("/hooks/git", func() {
-					m.Combo("").Get(reqToken(auth_model.AccessTokenScopeReadRepoHook), repo.ListGitHooks)
-					m.Group("/{id}", func() {
-						m.Combo("").Get(reqToken(auth_model.AccessTokenScopeReadRepoHook), repo.GetGitHook).
-							Patch(reqToken(auth_model.AccessTokenScopeWriteRepoHook), bind(api.EditGitHookOption{}), repo.EditGitHook).
-							Delete(reqToken(auth_model.AccessTokenScopeWriteRepoHook), repo.DeleteGitHook)
-					})
-				}, reqAdmin(), reqGitHook(), context.ReferencesGitRepo(true))
-				m.Group("/hooks", func() {
-					m.Combo("").Get(reqToken(auth_model.AccessTokenScopeReadRepoHook), repo.ListHooks).
-						Post(reqToken(auth_model.AccessTokenScopeWriteRepoHook), bind(api.CreateHookOption{}), repo.CreateHook)
+			m.Get("/assignees", reqToken(auth_model.AccessTokenScopeRepo), reqAnyRepoReader(), repo.GetAssignees)
+			m.Get("/reviewers", reqToken(auth_model.AccessTokenScopeRepo), reqAnyRepoReader(), repo.GetReviewers)
+			m.Group("/teams", func() {
+				m.Get("", reqAnyRepoReader(), repo.ListTeams)
+				m.Combo("/{team}").Get(reqAnyRepoReader(), repo.IsTeam).
+					Put(reqAdmin(), repo.AddTeam).
+					Delete(reqAdmin(), repo.DeleteTeam)
+			}, reqToken(auth_model.AccessTokenScopeRepo))
+			m.Get("/raw/*", context.ReferencesGitRepo(), context.RepoRefForAPI, reqRepoReader(unit.TypeCode), repo.GetRawFile)
+			m.Get("/media/*", context.ReferencesGitRepo(), context.RepoRefForAPI, reqRepoReader(unit.TypeCode), repo.GetRawFileOrLFS)
+			m.Get("/archive/*", reqRepoReader(unit.TypeCode), repo.GetArchive)
+			m.Combo("/forks").Get(repo.ListForks).
+				Post(reqToken(auth_model.AccessTokenScopeRepo), reqNotFineGrainedToken(), reqRepoReader(unit.TypeCode), bind(api.CreateForkOption{}), repo.CreateFork)
+			m.Group("/branches", func() {
+				m.Get("", repo.ListBranches)
+				m.Get("/*", repo.GetBranch)
+				m.Delete("/*", reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeCode), repo.DeleteBranch)
+				m.Post("", reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeCode), bind(api.CreateBranchRepoOption{}), repo.CreateBranch)
+			}, context.ReferencesGitRepo(), reqRepoReader(unit.TypeCode))
+			m.Group("/branch_protections", func() {
+				m.Get("", repo.ListBranchProtections)
+				m.Post("", bind(api.CreateBranchProtectionOption{}), repo.CreateBranchProtection)
+				m.Group("/{name}", func() {
+					m.Get("", repo.GetBranchProtection)
+					m.Patch("", bind(api.EditBranchProtectionOption{}), repo.EditBranchProtection)
+					m.Delete("", repo.DeleteBranchProtection)
+				})
+			}, reqToken(auth_model.AccessTokenScopeRepo), reqAdmin())
+			m.Group("/tags", func() {
+				m.Get("", repo.ListTags)
+				m.Get("/*", repo.GetTag)
+				m.Post("", reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeCode), bind(api.CreateTagOption{}), repo.CreateTag)
+				m.Delete("/*", reqToken(auth_model.AccessTokenScopeRepo), repo.DeleteTag)
+			}, reqRepoReader(unit.TypeCode), context.ReferencesGitRepo(true))
+			m.Group("/keys", func() {
+				m.Combo("").Get(repo.ListDeployKeys).
+					Post(bind(api.CreateKeyOption{}), repo.CreateDeployKey)
+				m.Combo("/{id}").Get(repo.GetDeployKey).
+					Delete(repo.DeleteDeploykey)
+			}, reqToken(auth_model.AccessTokenScopeRepo), reqAdmin())
+			m.Group("/times", func() {
+				m.Combo("").Get(repo.ListTrackedTimesByRepository)
+				m.Combo("/{timetrackingusername}").Get(repo.ListTrackedTimesByUser)
+			}, mustEnableIssues, reqToken(auth_model.AccessTokenScopeRepo))
+			m.Group("/wiki", func() {
+				m.Combo("/page/{pageName}").
+					Get(repo.GetWikiPage).
+					Patch(mustNotBeArchived, reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeWiki), bind(api.CreateWikiPageOptions{}), repo.EditWikiPage).
+					Delete(mustNotBeArchived, reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeWiki), repo.DeleteWikiPage)
+				m.Get("/revisions/{pageName}", repo.ListPageRevisions)
+				m.Post("/new", mustNotBeArchived, reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeWiki), bind(api.CreateWikiPageOptions{}), repo.NewWikiPage)
+				m.Get("/pages", repo.ListWikiPages)
+			}, mustEnableWiki)
+			m.Group("/issues", func() {
+				m.Combo("").Get(repo.ListIssues).
+					Post(reqToken(auth_model.AccessTokenScopeRepo), mustNotBeArchived, bind(api.CreateIssueOption{}), repo.CreateIssue)
+				m.Group("/comments", func() {
+					m.Get("", repo.ListRepoIssueComments)
 					m.Group("/{id}", func() {
-						m.Combo("").Get(reqToken(auth_model.AccessTokenScopeReadRepoHook), repo.GetHook).
-							Patch(reqToken(auth_model.AccessTokenScopeWriteRepoHook), bind(api.EditHookOption{}), repo.EditHook).
-							Delete(reqToken(auth_model.AccessTokenScopeWriteRepoHook), repo.DeleteHook)
-						m.Post("/tests", reqToken(auth_model.AccessTokenScopeReadRepoHook), context.ReferencesGitRepo(), context.RepoRefForAPI, repo.TestHook)
-					})
-				}, reqAdmin(), reqWebhooksEnabled())
-				m.Group("/collaborators", func() {
-					m.Get("", reqAnyRepoReader(), repo.ListCollaborators)
-					m.Group("/{collaborator}", func() {
-						m.Combo("").Get(reqAnyRepoReader(), repo.IsCollaborator).
-							Put(reqAdmin(), bind(api.AddCollaboratorOption{}), repo.AddCollaborator).
-							Delete(reqAdmin(), repo.DeleteCollaborator)
-						m.Get("/permission", repo.GetRepoPermissions)
-					})
-				}, reqToken(auth_model.AccessTokenScopeRepo))
-				m.Get("/assignees", reqToken(auth_model.AccessTokenScopeRepo), reqAnyRepoReader(), repo.GetAssignees)
-				m.Get("/reviewers", reqToken(auth_model.AccessTokenScopeRepo), reqAnyRepoReader(), repo.GetReviewers)
-				m.Group("/teams", func() {
-					m.Get("", reqAnyRepoReader(), repo.ListTeams)
-					m.Combo("/{team}").Get(reqAnyRepoReader(), repo.IsTeam).
-						Put(reqAdmin(), repo.AddTeam).
-						Delete(reqAdmin(), repo.DeleteTeam)
-				}, reqToken(auth_model.AccessTokenScopeRepo))
-				m.Get("/raw/*", context.ReferencesGitRepo(), context.RepoRefForAPI, reqRepoReader(unit.TypeCode), repo.GetRawFile)
-				m.Get("/media/*", context.ReferencesGitRepo(), context.RepoRefForAPI, reqRepoReader(unit.TypeCode), repo.GetRawFileOrLFS)
-				m.Get("/archive/*", reqRepoReader(unit.TypeCode), repo.GetArchive)
-				m.Combo("/forks").Get(repo.ListForks).
-					Post(reqToken(auth_model.AccessTokenScopeRepo), reqRepoReader(unit.TypeCode), bind(api.CreateForkOption{}), repo.CreateFork)
-				m.Group("/branches", func() {
-					m.Get("", repo.ListBranches)
-					m.Get("/*", repo.GetBranch)
-					m.Delete("/*", reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeCode), repo.DeleteBranch)
-					m.Post("", reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeCode), bind(api.CreateBranchRepoOption{}), repo.CreateBranch)
-				}, context.ReferencesGitRepo(), reqRepoReader(unit.TypeCode))
-				m.Group("/branch_protections", func() {
-					m.Get("", repo.ListBranchProtections)
-					m.Post("", bind(api.CreateBranchProtectionOption{}), repo.CreateBranchProtection)
-					m.Group("/{name}", func() {
-						m.Get("", repo.GetBranchProtection)
-						m.Patch("", bind(api.EditBranchProtectionOption{}), repo.EditBranchProtection)
-						m.Delete("", repo.DeleteBranchProtection)
-					})
-				}, reqToken(auth_model.AccessTokenScopeRepo), reqAdmin())
-				m.Group("/tags", func() {
-					m.Get("", repo.ListTags)
-					m.Get("/*", repo.GetTag)
-					m.Post("", reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeCode), bind(api.CreateTagOption{}), repo.CreateTag)
-					m.Delete("/*", reqToken(auth_model.AccessTokenScopeRepo), repo.DeleteTag)
-				}, reqRepoReader(unit.TypeCode), context.ReferencesGitRepo(true))
-				m.Group("/keys", func() {
-					m.Combo("").Get(repo.ListDeployKeys).
-						Post(bind(api.CreateKeyOption{}), repo.CreateDeployKey)
-					m.Combo("/{id}").Get(repo.GetDeployKey).
-						Delete(repo.DeleteDeploykey)
-				}, reqToken(auth_model.AccessTokenScopeRepo), reqAdmin())
-				m.Group("/times", func() {
-					m.Combo("").Get(repo.ListTrackedTimesByRepository)
-					m.Combo("/{timetrackingusername}").Get(repo.ListTrackedTimesByUser)
-				}, mustEnableIssues, reqToken(auth_model.AccessTokenScopeRepo))
-				m.Group("/wiki", func() {
-					m.Combo("/page/{pageName}").
-						Get(repo.GetWikiPage).
-						Patch(mustNotBeArchived, reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeWiki), bind(api.CreateWikiPageOptions{}), repo.EditWikiPage).
-						Delete(mustNotBeArchived, reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeWiki), repo.DeleteWikiPage)
-					m.Get("/revisions/{pageName}", repo.ListPageRevisions)
-					m.Post("/new", mustNotBeArchived, reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeWiki), bind(api.CreateWikiPageOptions{}), repo.NewWikiPage)
-					m.Get("/pages", repo.ListWikiPages)
-				}, mustEnableWiki)
-				m.Group("/issues", func() {
-					m.Combo("").Get(repo.ListIssues).
-						Post(reqToken(auth_model.AccessTokenScopeRepo), mustNotBeArchived, bind(api.CreateIssueOption{}), repo.CreateIssue)
-					m.Group("/comments", func() {
-						m.Get("", repo.ListRepoIssueComments)
-						m.Group("/{id}", func() {
-							m.Combo("").
-								Get(repo.GetIssueComment).
-								Patch(mustNotBeArchived, reqToken(auth_model.AccessTokenScopeRepo), bind(api.EditIssueCommentOption{}), repo.EditIssueComment).
-								Delete(reqToken(auth_model.AccessTokenScopeRepo), repo.DeleteIssueComment)
-							m.Combo("/reactions").
-								Get(repo.GetIssueCommentReactions).
-								Post(reqToken(auth_model.AccessTokenScopeRepo), bind(api.EditReactionOption{}), repo.PostIssueCommentReaction).
-								Delete(reqToken(auth_model.AccessTokenScopeRepo), bind(api.EditReactionOption{}), repo.DeleteIssueCommentReaction)
-							m.Group("/assets", func() {
-								m.Combo("").
-									Get(repo.ListIssueCommentAttachments).
-									Post(reqToken(auth_model.AccessTokenScopeRepo), mustNotBeArchived, repo.CreateIssueCommentAttachment)
-								m.Combo("/{asset}").
-									Get(repo.GetIssueCommentAttachment).
-									Patch(reqToken(auth_model.AccessTokenScopeRepo), mustNotBeArchived, bind(api.EditAttachmentOptions{}), repo.EditIssueCommentAttachment).
-									Delete(reqToken(auth_model.AccessTokenScopeRepo), mustNotBeArchived, repo.DeleteIssueCommentAttachment)
-							}, mustEnableAttachments)
-						})
-					})
-					m.Group("/{index}", func() {
-						m.Combo("").Get(repo.GetIssue).
-							Patch(reqToken(auth_model.AccessTokenScopeRepo), bind(api.EditIssueOption{}), repo.EditIssue).
-							Delete(reqToken(auth_model.AccessTokenScopeRepo), reqAdmin(), context.ReferencesGitRepo(), repo.DeleteIssue)
-						m.Group("/comments", func() {
-							m.Combo("").Get(repo.ListIssueComments).
-								Post(reqToken(auth_model.AccessTokenScopeRepo), mustNotBeArchived, bind(api.CreateIssueCommentOption{}), repo.CreateIssueComment)
-							m.Combo("/{id}", reqToken(auth_model.AccessTokenScopeRepo)).Patch(bind(api.EditIssueCommentOption{}), repo.EditIssueCommentDeprecated).
-								Delete(repo.DeleteIssueCommentDeprecated)
-						})
-						m.Get("/timeline", repo.ListIssueCommentsAndTimeline)
-						m.Group("/labels", func() {
-							m.Combo("").Get(repo.ListIssueLabels).
-								Post(reqToken(auth_model.AccessTokenScopeRepo), bind(api.IssueLabelsOption{}), repo.AddIssueLabels).
-								Put(reqToken(auth_model.AccessTokenScopeRepo), bind(api.IssueLabelsOption{}), repo.ReplaceIssueLabels).
-								Delete(reqToken(auth_model.AccessTokenScopeRepo), repo.ClearIssueLabels)
-							m.Delete("/{id}", reqToken(auth_model.AccessTokenScopeRepo), repo.DeleteIssueLabel)
-						})
-						m.Group("/times", func() {
-							m.Combo("").
-								Get(repo.ListTrackedTimes).
-								Post(bind(api.AddTimeOption{}), repo.AddTime).
-								Delete(repo.ResetIssueTime)
-							m.Delete("/{id}", repo.DeleteTime)
-						}, reqToken(auth_model.AccessTokenScopeRepo))
-						m.Combo("/deadline").Post(reqToken(auth_model.AccessTokenScopeRepo), bind(api.EditDeadlineOption{}), repo.UpdateIssueDeadline)
-						m.Group("/stopwatch", func() {
-							m.Post("/start", reqToken(auth_model.AccessTokenScopeRepo), repo.StartIssueStopwatch)
-							m.Post("/stop", reqToken(auth_model.AccessTokenScopeRepo), repo.StopIssueStopwatch)
-							m.Delete("/delete", reqToken(auth_model.AccessTokenScopeRepo), repo.DeleteIssueStopwatch)
-						})
-						m.Group("/subscriptions", func() {
-							m.Get("", repo.GetIssueSubscribers)
-							m.Get("/check", reqToken(auth_model.AccessTokenScopeRepo), repo.CheckIssueSubscription)
-							m.Put("/{user}", reqToken(auth_model.AccessTokenScopeRepo), repo.AddIssueSubscription)
-							m.Delete("/{user}", reqToken(auth_model.AccessTokenScopeRepo), repo.DelIssueSubscription)
-						})
+						m.Combo("").
+							Get(repo.GetIssueComment).
+							Patch(mustNotBeArchived, reqToken(auth_model.AccessTokenScopeRepo), bind(api.EditIssueCommentOption{}), repo.EditIssueComment).
+							Delete(reqToken(auth_model.AccessTokenScopeRepo), repo.DeleteIssueComment)
 						m.Combo("/reactions").
-							Get(repo.GetIssueReactions).
-							Post(reqToken(auth_model.AccessTokenScopeRepo), bind(api.EditReactionOption{}), repo.PostIssueReaction).
-							Delete(reqToken(auth_model.AccessTokenScopeRepo), bind(api.EditReactionOption{}), repo.DeleteIssueReaction)
+							Get(repo.GetIssueCommentReactions).
+							Post(reqToken(auth_model.AccessTokenScopeRepo), bind(api.EditReactionOption{}), repo.PostIssueCommentReaction).
+							Delete(reqToken(auth_model.AccessTokenScopeRepo), bind(api.EditReactionOption{}), repo.DeleteIssueCommentReaction)
 						m.Group("/assets", func() {
 							m.Combo("").
-								Get(repo.ListIssueAttachments).
-								Post(reqToken(auth_model.AccessTokenScopeRepo), mustNotBeArchived, repo.CreateIssueAttachment)
+								Get(repo.ListIssueCommentAttachments).
+								Post(reqToken(auth_model.AccessTokenScopeRepo), mustNotBeArchived, repo.CreateIssueCommentAttachment)
 							m.Combo("/{asset}").
-								Get(repo.GetIssueAttachment).
-								Patch(reqToken(auth_model.AccessTokenScopeRepo), mustNotBeArchived, bind(api.EditAttachmentOptions{}), repo.EditIssueAttachment).
-								Delete(reqToken(auth_model.AccessTokenScopeRepo), mustNotBeArchived, repo.DeleteIssueAttachment)
+								Get(repo.GetIssueCommentAttachment).
+								Patch(reqToken(auth_model.AccessTokenScopeRepo), mustNotBeArchived, bind(api.EditAttachmentOptions{}), repo.EditIssueCommentAttachment).
+								Delete(reqToken(auth_model.AccessTokenScopeRepo), mustNotBeArchived, repo.DeleteIssueCommentAttachment)
 						}, mustEnableAttachments)
 					})
-				}, mustEnableIssuesOrPulls)
-				m.Group("/labels", func() {
-					m.Combo("").Get(repo.ListLabels).
-						Post(reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeIssues, unit.TypePullRequests), bind(api.CreateLabelOption{}), repo.CreateLabel)
-					m.Combo("/{id}").Get(repo.GetLabel).
-						Patch(reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeIssues, unit.TypePullRequests), bind(api.EditLabelOption{}), repo.EditLabel).
-						Delete(reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeIssues, unit.TypePullRequests), repo.DeleteLabel)
-				})
-				m.Post("/markdown", reqToken(auth_model.AccessTokenScopeRepo), bind(api.MarkdownOption{}), misc.Markdown)
-				m.Post("/markdown/raw", reqToken(auth_model.AccessTokenScopeRepo), misc.MarkdownRaw)
-				m.Group("/milestones", func() {
-					m.Combo("").Get(repo.ListMilestones).
-						Post(reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeIssues, unit.TypePullRequests), bind(api.CreateMilestoneOption{}), repo.CreateMilestone)
-					m.Combo("/{id}").Get(repo.GetMilestone).
-						Patch(reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeIssues, unit.TypePullRequests), bind(api.EditMilestoneOption{}), repo.EditMilestone).
-						Delete(reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeIssues, unit.TypePullRequests), repo.DeleteMilestone)
-				})
-				m.Get("/stargazers", repo.ListStargazers)
-				m.Get("/subscribers", repo.ListSubscribers)
-				m.Group("/subscription", func() {
-					m.Get("", user.IsWatching)
-					m.Put("", reqToken(auth_model.AccessTokenScopeRepo), user.Watch)
-					m.Delete("", reqToken(auth_model.AccessTokenScopeRepo), user.Unwatch)
 				})
-				m.Group("/releases", func() {
-					m.Combo("").Get(repo.ListReleases).
-						Post(reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeReleases), context.ReferencesGitRepo(), bind(api.CreateReleaseOption{}), repo.CreateRelease)
-					m.Combo("/latest").Get(repo.GetLatestRelease)
-					m.Group("/{id}", func() {
-						m.Combo("").Get(repo.GetRelease).
-							Patch(reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeReleases), context.ReferencesGitRepo(), bind(api.EditReleaseOption{}), repo.EditRelease).
-							Delete(reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeReleases), repo.DeleteRelease)
-						m.Group("/assets", func() {
-							m.Combo("").Get(repo.ListReleaseAttachments).
-								Post(reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeReleases), repo.CreateReleaseAttachment)
-							m.Combo("/{asset}").Get(repo.GetReleaseAttachment).
-								Patch(reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeReleases), bind(api.EditAttachmentOptions{}), repo.EditReleaseAttachment).
-								Delete(reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeReleases), repo.DeleteReleaseAttachment)
-						})
+				m.Group("/{index}", func() {
+					m.Combo("").Get(repo.GetIssue).
+						Patch(reqToken(auth_model.AccessTokenScopeRepo), bind(api.EditIssueOption{}), repo.EditIssue).
+						Delete(reqToken(auth_model.AccessTokenScopeRepo), reqAdmin(), context.ReferencesGitRepo(), repo.DeleteIssue)
+					m.Group("/comments", func() {
+						m.Combo("").Get(repo.ListIssueComments).
+							Post(reqToken(auth_model.AccessTokenScopeRepo), mustNotBeArchived, bind(api.CreateIssueCommentOption{}), repo.CreateIssueComment)
+						m.Combo("/{id}", reqToken(auth_model.AccessTokenScopeRepo)).Patch(bind(api.EditIssueCommentOption{}), repo.EditIssueCommentDeprecated).
+							Delete(repo.DeleteIssueCommentDeprecated)
 					})
-					m.Group("/tags", func() {
-						m.Combo("/{tag}").
-							Get(repo.GetReleaseByTag).
-							Delete(reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeReleases), repo.DeleteReleaseByTag)
+					m.Get("/timeline", repo.ListIssueCommentsAndTimeline)
+					m.Group("/labels", func() {
+						m.Combo("").Get(repo.ListIssueLabels).
+							Post(reqToken(auth_model.AccessTokenScopeRepo), bind(api.IssueLabelsOption{}), repo.AddIssueLabels).
+							Put(reqToken(auth_model.AccessTokenScopeRepo), bind(api.IssueLabelsOption{}), repo.ReplaceIssueLabels).
+							Delete(reqToken(auth_model.AccessTokenScopeRepo), repo.ClearIssueLabels)
+						m.Delete("/{id}", reqToken(auth_model.AccessTokenScopeRepo), repo.DeleteIssueLabel)
 					})
-				}, reqRepoReader(unit.TypeReleases))
-				m.Post("/mirror-sync", reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeCode), repo.MirrorSync)
-				m.Post("/push_mirrors-sync", reqAdmin(), reqToken(auth_model.AccessTokenScopeRepo), repo.PushMirrorSync)
-				m.Group("/push_mirrors", func() {
-					m.Combo("").Get(repo.ListPushMirrors).
-						Post(bind(api.CreatePushMirrorOption{}), repo.AddPushMirror)
-					m.Combo("/{name}").
-						Delete(repo.DeletePushMirrorByRemoteName).
-						Get(repo.GetPushMirrorByName)
-				}, reqAdmin(), reqToken(auth_model.AccessTokenScopeRepo))
-
-				m.Get("/editorconfig/{filename}", context.ReferencesGitRepo(), context.RepoRefForAPI, reqRepoReader(unit.TypeCode), repo.GetEditorconfig)
-				m.Group("/pulls", func() {
-					m.Combo("").Get(repo.ListPullRequests).
-						Post(reqToken(auth_model.AccessTokenScopeRepo), mustNotBeArchived, bind(api.CreatePullRequestOption{}), repo.CreatePullRequest)
-					m.Group("/{index}", func() {
-						m.Combo("").Get(repo.GetPullRequest).
-							Patch(reqToken(auth_model.AccessTokenScopeRepo), bind(api.EditPullRequestOption{}), repo.EditPullRequest)
-						m.Get(".{diffType:diff|patch}", repo.DownloadPullDiffOrPatch)
-						m.Post("/update", reqToken(auth_model.AccessTokenScopeRepo), repo.UpdatePullRequest)
-						m.Get("/commits", repo.GetPullRequestCommits)
-						m.Get("/files", repo.GetPullRequestFiles)
-						m.Combo("/merge").Get(repo.IsPullRequestMerged).
-							Post(reqToken(auth_model.AccessTokenScopeRepo), mustNotBeArchived, bind(forms.MergePullRequestForm{}), repo.MergePullRequest).
-							Delete(reqToken(auth_model.AccessTokenScopeRepo), mustNotBeArchived, repo.CancelScheduledAutoMerge)
-						m.Group("/reviews", func() {
+					m.Group("/times", func() {
+						m.Combo("").
+							Get(repo.ListTrackedTimes).
+							Post(bind(api.AddTimeOption{}), repo.AddTime).
+							Delete(repo.ResetIssueTime)
+						m.Delete("/{id}", repo.DeleteTime)
+					}, reqToken(auth_model.AccessTokenScopeRepo))
+					m.Combo("/deadline").Post(reqToken(auth_model.AccessTokenScopeRepo), bind(api.EditDeadlineOption{}), repo.UpdateIssueDeadline)
+					m.Group("/stopwatch", func() {
+						m.Post("/start", reqToken(auth_model.AccessTokenScopeRepo), repo.StartIssueStopwatch)
+						m.Post("/stop", reqToken(auth_model.AccessTokenScopeRepo), repo.StopIssueStopwatch)
+						m.Delete("/delete", reqToken(auth_model.AccessTokenScopeRepo), repo.DeleteIssueStopwatch)
+					})
+					m.Group("/subscriptions", func() {
+						m.Get("", repo.GetIssueSubscribers)
+						m.Get("/check", reqToken(auth_model.AccessTokenScopeRepo), repo.CheckIssueSubscription)
+						m.Put("/{user}", reqToken(auth_model.AccessTokenScopeRepo), repo.AddIssueSubscription)
+						m.Delete("/{user}", reqToken(auth_model.AccessTokenScopeRepo), repo.DelIssueSubscription)
+					})
+					m.Combo("/reactions").
+						Get(repo.GetIssueReactions).
+						Post(reqToken(auth_model.AccessTokenScopeRepo), bind(api.EditReactionOption{}), repo.PostIssueReaction).
+						Delete(reqToken(auth_model.AccessTokenScopeRepo), bind(api.EditReactionOption{}), repo.DeleteIssueReaction)
+					m.Group("/assets", func() {
+						m.Combo("").
+							Get(repo.ListIssueAttachments).
+							Post(reqToken(auth_model.AccessTokenScopeRepo), mustNotBeArchived, repo.CreateIssueAttachment)
+						m.Combo("/{asset}").
+							Get(repo.GetIssueAttachment).
+							Patch(reqToken(auth_model.AccessTokenScopeRepo), mustNotBeArchived, bind(api.EditAttachmentOptions{}), repo.EditIssueAttachment).
+							Delete(reqToken(auth_model.AccessTokenScopeRepo), mustNotBeArchived, repo.DeleteIssueAttachment)
+					}, mustEnableAttachments)
+				})
+			}, mustEnableIssuesOrPulls)
+			m.Group("/labels", func() {
+				m.Combo("").Get(repo.ListLabels).
+					Post(reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeIssues, unit.TypePullRequests), bind(api.CreateLabelOption{}), repo.CreateLabel)
+				m.Combo("/{id}").Get(repo.GetLabel).
+					Patch(reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeIssues, unit.TypePullRequests), bind(api.EditLabelOption{}), repo.EditLabel).
+					Delete(reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeIssues, unit.TypePullRequests), repo.DeleteLabel)
+			})
+			m.Post("/markdown", reqToken(auth_model.AccessTokenScopeRepo), bind(api.MarkdownOption{}), misc.Markdown)
+			m.Post("/markdown/raw", reqToken(auth_model.AccessTokenScopeRepo), misc.MarkdownRaw)
+			m.Group("/milestones", func() {
+				m.Combo("").Get(repo.ListMilestones).
+					Post(reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeIssues, unit.TypePullRequests), bind(api.CreateMilestoneOption{}), repo.CreateMilestone)
+				m.Combo("/{id}").Get(repo.GetMilestone).
+					Patch(reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeIssues, unit.TypePullRequests), bind(api.EditMilestoneOption{}), repo.EditMilestone).
+					Delete(reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeIssues, unit.TypePullRequests), repo.DeleteMilestone)
+			})
+			m.Get("/stargazers", repo.ListStargazers)
+			m.Get("/subscribers", repo.ListSubscribers)
+			m.Group("/subscription", func() {
+				m.Get("", user.IsWatching)
+				m.Put("", reqToken(auth_model.AccessTokenScopeRepo), user.Watch)
+				m.Delete("", reqToken(auth_model.AccessTokenScopeRepo), user.Unwatch)
+			})
+			m.Group("/releases", func() {
+				m.Combo("").Get(repo.ListReleases).
+					Post(reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeReleases), context.ReferencesGitRepo(), bind(api.CreateReleaseOption{}), repo.CreateRelease)
+				m.Combo("/latest").Get(repo.GetLatestRelease)
+				m.Group("/{id}", func() {
+					m.Combo("").Get(repo.GetRelease).
+						Patch(reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeReleases), context.ReferencesGitRepo(), bind(api.EditReleaseOption{}), repo.EditRelease).
+						Delete(reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeReleases), repo.DeleteRelease)
+					m.Group("/assets", func() {
+						m.Combo("").Get(repo.ListReleaseAttachments).
+							Post(reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeReleases), repo.CreateReleaseAttachment)
+						m.Combo("/{asset}").Get(repo.GetReleaseAttachment).
+							Patch(reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeReleases), bind(api.EditAttachmentOptions{}), repo.EditReleaseAttachment).
+							Delete(reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeReleases), repo.DeleteReleaseAttachment)
+					})
+				})
+				m.Group("/tags", func() {
+					m.Combo("/{tag}").
+						Get(repo.GetReleaseByTag).
+						Delete(reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeReleases), repo.DeleteReleaseByTag)
+				})
+			}, reqRepoReader(unit.TypeReleases))
+			m.Post("/mirror-sync", reqToken(auth_model.AccessTokenScopeRepo), reqRepoWriter(unit.TypeCode), repo.MirrorSync)
+			m.Post("/push_mirrors-sync", reqAdmin(), reqToken(auth_model.AccessTokenScopeRepo), repo.PushMirrorSync)
+			m.Group("/push_mirrors", func() {
+				m.Combo("").Get(repo.ListPushMirrors).
+					Post(bind(api.CreatePushMirrorOption{}), repo.AddPushMirror)
+				m.Combo("/{name}").
+					Delete(repo.DeletePushMirrorByRemoteName).
+					Get(repo.GetPushMirrorByName)
+			}, reqAdmin(), reqToken(auth_model.AccessTokenScopeRepo))
+
+			m.Get("/editorconfig/{filename}", context.ReferencesGitRepo(), context.RepoRefForAPI, reqRepoReader(unit.TypeCode), repo.GetEditorconfig)
+			m.Group("/pulls", func() {
+				m.Combo("").Get(repo.ListPullRequests).
+					Post(reqToken(auth_model.AccessTokenScopeRepo), mustNotBeArchived, bind(api.CreatePullRequestOption{}), repo.CreatePullRequest)
+				m.Group("/{index}", func() {
+					m.Combo("").Get(repo.GetPullRequest).
+						Patch(reqToken(auth_model.AccessTokenScopeRepo), bind(api.EditPullRequestOption{}), repo.EditPullRequest)
+					m.Get(".{diffType:diff|patch}", repo.DownloadPullDiffOrPatch)
+					m.Post("/update", reqToken(auth_model.AccessTokenScopeRepo), repo.UpdatePullRequest)
+					m.Get("/commits", repo.GetPullRequestCommits)
+					m.Get("/files", repo.GetPullRequestFiles)
+					m.Combo("/merge").Get(repo.IsPullRequestMerged).
+						Post(reqToken(auth_model.AccessTokenScopeRepo), mustNotBeArchived, bind(forms.MergePullRequestForm{}), repo.MergePullRequest).
+						Delete(reqToken(auth_model.AccessTokenScopeRepo), mustNotBeArchived, repo.CancelScheduledAutoMerge)
+					m.Group("/reviews", func() {
+						m.Combo("").
+							Get(repo.ListPullReviews).
+							Post(reqToken(auth_model.AccessTokenScopeRepo), bind(api.CreatePullReviewOptions{}), repo.CreatePullReview)
+						m.Group("/{id}", func() {
 							m.Combo("").
-								Get(repo.ListPullReviews).
-								Post(reqToken(auth_model.AccessTokenScopeRepo), bind(api.CreatePullReviewOptions{}), repo.CreatePullReview)
-							m.Group("/{id}", func() {
-								m.Combo("").
-									Get(repo.GetPullReview).
-									Delete(reqToken(auth_model.AccessTokenScopeRepo), repo.DeletePullReview).
-									Post(reqToken(auth_model.AccessTokenScopeRepo), bind(api.SubmitPullReviewOptions{}), repo.SubmitPullReview)
-								m.Combo("/comments").
-									Get(repo.GetPullReviewComments)
-								m.Post("/dismissals", reqToken(auth_model.AccessTokenScopeRepo), bind(api.DismissPullReviewOptions{}), repo.DismissPullReview)
-								m.Post("/undismissals", reqToken(auth_model.AccessTokenScopeRepo), repo.UnDismissPullReview)
-							})
+								Get(repo.GetPullReview).
+								Delete(reqToken(auth_model.AccessTokenScopeRepo), repo.DeletePullReview).
+								Post(reqToken(auth_model.AccessTokenScopeRepo), bind(api.SubmitPullReviewOptions{}), repo.SubmitPullReview)
+							m.Combo("/comments").
+								Get(repo.GetPullReviewComments)
+							m.Post("/dismissals", reqToken(auth_model.AccessTokenScopeRepo), bind(api.DismissPullReviewOptions{}), repo.DismissPullReview)
+							m.Post("/undismissals", reqToken(auth_model.AccessTokenScopeRepo), repo.UnDismissPullReview)
 						})
-						m.Combo("/requested_reviewers", reqToken(auth_model.AccessTokenScopeRepo)).
-							Delete(bind(api.PullReviewRequestOptions{}), repo.DeleteReviewRequests).
-							Post(bind(api.PullReviewRequestOptions{}), repo.CreateReviewRequests)
 					})
-				}, mustAllowPulls, reqRepoReader(unit.TypeCode), context.ReferencesGitRepo())
-				m.Group("/statuses", func() {
-					m.Combo("/{sha}").Get(repo.GetCommitStatuses).
-						Post(reqToken(auth_model.AccessTokenScopeRepoStatus), reqRepoWriter(unit.TypeCode), bind(api.CreateStatusOption{}), repo.NewCommitStatus)
-				}, reqRepoReader(unit.TypeCode))
+					m.Combo("/requested_reviewers", reqToken(auth_model.AccessTokenScopeRepo)).
+						Delete(bind(api.PullReviewRequestOptions{}), repo.DeleteReviewRequests).
+						Post(bind(api.PullReviewRequestOptions{}), repo.CreateReviewRequests)
+				})
+			}, mustAllowPulls, reqRepoReader(unit.TypeCode), context.ReferencesGitRepo())
+			m.Group("/statuses", func() {
+				m.Combo("/{sha}").Get(repo.GetCommitStatuses).
+					Post(reqToken(auth_model.AccessTokenScopeRepoStatus), reqRepoWriter(unit.TypeCode), bind(api.CreateStatusOption{}), repo.NewCommitStatus)
+			}, reqRepoReader(unit.TypeCode))
+			m.Group("/commits", func() {
+				m.Get("", context.ReferencesGitRepo(), repo.GetAllCommits)
+				m.Group("/{ref}", func() {
+					m.Get("/status", repo.GetCombinedCommitStatusByRef)
+					m.Get("/statuses", repo.GetCommitStatusesByRef)
+				}, context.ReferencesGitRepo())
+			}, reqRepoReader(unit.TypeCode))
+			m.Group("/git", func() {
 				m.Group("/commits", func() {
-					m.Get("", context.ReferencesGitRepo(), repo.GetAllCommits)
-					m.Group("/{ref}", func() {
-						m.Get("/status", repo.GetCombinedCommitStatusByRef)
-						m.Get("/statuses", repo.GetCommitStatusesByRef)
-					}, context.ReferencesGitRepo())
-				}, reqRepoReader(unit.TypeCode))
-				m.Group("/git", func() {
-					m.Group("/commits", func() {
-						m.Get("/{sha}", repo.GetSingleCommit)
-						m.Get("/{sha}.{diffType:diff|patch}", repo.DownloadCommitDiffOrPatch)
-					})
-					m.Get("/refs", repo.GetGitAllRefs)
-					m.Get("/refs/*", repo.GetGitRefs)
-					m.Get("/trees/{sha}", repo.GetTree)
-					m.Get("/blobs/{sha}", repo.GetBlob)
-					m.Get("/tags/{sha}", repo.GetAnnotatedTag)
-					m.Get("/notes/{sha}", repo.GetNote)
-				}, context.ReferencesGitRepo(true), reqRepoReader(unit.TypeCode))
-				m.Post("/diffpatch", reqRepoWriter(unit.TypeCode), reqToken(auth_model.AccessTokenScopeRepo), bind(api.ApplyDiffPatchFileOptions{}), repo.ApplyDiffPatch)
-				m.Group("/contents", func() {
-					m.Get("", repo.GetContentsList)
-					m.Get("/*", repo.GetContents)
-					m.Group("/*", func() {
-						m.Post("", bind(api.CreateFileOptions{}), reqRepoBranchWriter, repo.CreateFile)
-						m.Put("", bind(api.UpdateFileOptions{}), reqRepoBranchWriter, repo.UpdateFile)
-						m.Delete("", bind(api.DeleteFileOptions{}), reqRepoBranchWriter, repo.DeleteFile)
-					}, reqToken(auth_model.AccessTokenScopeRepo))
-				}, reqRepoReader(unit.TypeCode))
-				m.Get("/signing-key.gpg", misc.SigningKey)
-				m.Group("/topics", func() {
-					m.Combo("").Get(repo.ListTopics).
-						Put(reqToken(auth_model.AccessTokenScopeRepo), reqAdmin(), bind(api.RepoTopicOptions{}), repo.UpdateTopics)
-					m.Group("/{topic}", func() {
-						m.Combo("").Put(reqToken(auth_model.AccessTokenScopeRepo), repo.AddTopic).
-							Delete(reqToken(auth_model.AccessTokenScopeRepo), repo.DeleteTopic)
-					}, reqAdmin())
-				}, reqAnyRepoReader())
-				m.Get("/issue_templates", context.ReferencesGitRepo(), repo.GetIssueTemplates)
-				m.Get("/languages", reqRepoReader(unit.TypeCode), repo.GetLanguages)
-			}, repoAssignment())
-		})
+					m.Get("/{sha}", repo.GetSingleCommit)
+					m.Get("/{sha}.{diffType:diff|patch}", repo.DownloadCommitDiffOrPatch)
+				})
+				m.Get("/refs", repo.GetGitAllRefs)
+				m.Get("/refs/*", repo.GetGitRefs)
+				m.Get("/trees/{sha}", repo.GetTree)
+				m.Get("/blobs/{sha}", repo.GetBlob)
+				m.Get("/tags/{sha}", repo.GetAnnotatedTag)
+				m.Get("/notes/{sha}", repo.GetNote)
+			}, context.ReferencesGitRepo(true), reqRepoReader(unit.TypeCode))
+			m.Post("/diffpatch", reqRepoWriter(unit.TypeCode), reqToken(auth_model.AccessTokenScopeRepo), bind(api.ApplyDiffPatchFileOptions{}), repo.ApplyDiffPatch)
+			m.Group("/contents", func() {
+				m.Get("", repo.GetContentsList)
+				m.Get("/*", repo.GetContents)
+				m.Group("/*", func() {
+					m.Post("", bind(api.CreateFileOptions{}), reqRepoBranchWriter, repo.CreateFile)
+					m.Put("", bind(api.UpdateFileOptions{}), reqRepoBranchWriter, repo.UpdateFile)
+					m.Delete("", bind(api.DeleteFileOptions{}), reqRepoBranchWriter, repo.DeleteFile)
+				}, reqToken(auth_model.AccessTokenScopeRepo))
+			}, reqRepoReader(unit.TypeCode))
+			m.Get("/signing-key.gpg", misc.SigningKey)
+			m.Group("/topics", func() {
+				m.Combo("").Get(repo.ListTopics).
+					Put(reqToken(auth_model.AccessTokenScopeRepo), reqAdmin(), bind(api.RepoTopicOptions{}), repo.UpdateTopics)
+				m.Group("/{topic}", func() {
+					m.Combo("").Put(reqToken(auth_model.AccessTokenScopeRepo), repo.AddTopic).
+						Delete(reqToken(auth_model.AccessTokenScopeRepo), repo.DeleteTopic)
+				}, reqAdmin())
+			}, reqAnyRepoReader())
+			m.Get("/issue_templates", context.ReferencesGitRepo(), repo.GetIssueTemplates)
+			m.Get("/languages", reqRepoReader(unit.TypeCode), repo.GetLanguages)
+		}, repoAssignment())
 
 		// NOTE: these are Gitea package management API - see packages.CommonRoutes and packages.DockerContainerRoutes for endpoints that implement package manager APIs
 		m.Group("/packages/{username}", func() {
@@ -1127,106 +1147,112 @@ func Routes(ctx gocontext.Context) *web.Route {
 				m.Get("", reqToken(auth_model.AccessTokenScopeReadPackage), packages.GetPackage)
 				m.Delete("", reqToken(auth_model.AccessTokenScopeDeletePackage), reqPackageAccess(perm.AccessModeWrite), packages.DeletePackage)
 				m.Get("/files", reqToken(auth_model.AccessTokenScopeReadPackage), packages.ListPackageFiles)
+				m.Combo("/provenance", reqToken(auth_model.AccessTokenScopeWritePackage), reqPackageAccess(perm.AccessModeWrite)).
+					Put(bind(api.SetPackageProvenanceOption{}), packages.SetPackageProvenance).
+					Delete(packages.DeletePackageProvenance)
 			})
 			m.Get("/", reqToken(auth_model.AccessTokenScopeReadPackage), packages.ListPackages)
 		}, context_service.UserAssignmentAPI(), context.PackageAssignmentAPI(), reqPackageAccess(perm.AccessModeRead))
 
-		// Organizations
-		m.Get("/user/orgs", reqToken(auth_model.AccessTokenScopeReadOrg), org.ListMyOrgs)
-		m.Group("/users/{username}/orgs", func() {
-			m.Get("", reqToken(auth_model.AccessTokenScopeReadOrg), org.ListUserOrgs)
-			m.Get("/{org}/permissions", reqToken(auth_model.AccessTokenScopeReadOrg), org.GetUserOrgsPermissions)
-		}, context_service.UserAssignmentAPI())
-		m.Post("/orgs", reqToken(auth_model.AccessTokenScopeWriteOrg), bind(api.CreateOrgOption{}), org.Create)
-		m.Get("/orgs", reqToken(auth_model.AccessTokenScopeReadOrg), org.GetAll)
-		m.Group("/orgs/{org}", func() {
-			m.Combo("").Get(reqToken(auth_model.AccessTokenScopeReadOrg), org.Get).
-				Patch(reqToken(auth_model.AccessTokenScopeWriteOrg), reqOrgOwnership(), bind(api.EditOrgOption{}), org.Edit).
-				Delete(reqToken(auth_model.AccessTokenScopeWriteOrg), reqOrgOwnership(), org.Delete)
-			m.Combo("/repos").Get(reqToken(auth_model.AccessTokenScopeReadOrg), user.ListOrgRepos).
-				Post(reqToken(auth_model.AccessTokenScopeWriteOrg), bind(api.CreateRepoOption{}), repo.CreateOrgRepo)
-			m.Group("/members", func() {
-				m.Get("", reqToken(auth_model.AccessTokenScopeReadOrg), org.ListMembers)
-				m.Combo("/{username}").Get(reqToken(auth_model.AccessTokenScopeReadOrg), org.IsMember).
-					Delete(reqToken(auth_model.AccessTokenScopeWriteOrg), reqOrgOwnership(), org.DeleteMember)
-			})
-			m.Group("/public_members", func() {
-				m.Get("", reqToken(auth_model.AccessTokenScopeReadOrg), org.ListPublicMembers)
-				m.Combo("/{username}").Get(reqToken(auth_model.AccessTokenScopeReadOrg), org.IsPublicMember).
-					Put(reqToken(auth_model.AccessTokenScopeWriteOrg), reqOrgMembership(), org.PublicizeMember).
-					Delete(reqToken(auth_model.AccessTokenScopeWriteOrg), reqOrgMembership(), org.ConcealMember)
-			})
-			m.Group("/teams", func() {
-				m.Get("", reqToken(auth_model.AccessTokenScopeReadOrg), org.ListTeams)
-				m.Post("", reqToken(auth_model.AccessTokenScopeWriteOrg), reqOrgOwnership(), bind(api.CreateTeamOption{}), org.CreateTeam)
-				m.Get("/search", reqToken(auth_model.AccessTokenScopeReadOrg), org.SearchTeam)
-			}, reqOrgMembership())
-			m.Group("/labels", func() {
-				m.Get("", reqToken(auth_model.AccessTokenScopeReadOrg), org.ListLabels)
-				m.Post("", reqToken(auth_model.AccessTokenScopeWriteOrg), reqOrgOwnership(), bind(api.CreateLabelOption{}), org.CreateLabel)
-				m.Combo("/{id}").Get(reqToken(auth_model.AccessTokenScopeReadOrg), org.GetLabel).
-					Patch(reqToken(auth_model.AccessTokenScopeWriteOrg), reqOrgOwnership(), bind(api.EditLabelOption{}), org.EditLabel).
-					Delete(reqToken(auth_model.AccessTokenScopeWriteOrg), reqOrgOwnership(), org.DeleteLabel)
-			})
-			m.Group("/hooks", func() {
-				m.Combo("").Get(org.ListHooks).
-					Post(bind(api.CreateHookOption{}), org.CreateHook)
-				m.Combo("/{id}").Get(org.GetHook).
-					Patch(bind(api.EditHookOption{}), org.EditHook).
-					Delete(org.DeleteHook)
-			}, reqToken(auth_model.AccessTokenScopeAdminOrgHook), reqOrgOwnership(), reqWebhooksEnabled())
-		}, orgAssignment(true))
-		m.Group("/teams/{teamid}", func() {
-			m.Combo("").Get(reqToken(auth_model.AccessTokenScopeReadOrg), org.GetTeam).
-				Patch(reqToken(auth_model.AccessTokenScopeWriteOrg), reqOrgOwnership(), bind(api.EditTeamOption{}), org.EditTeam).
-				Delete(reqToken(auth_model.AccessTokenScopeWriteOrg), reqOrgOwnership(), org.DeleteTeam)
-			m.Group("/members", func() {
-				m.Get("", reqToken(auth_model.AccessTokenScopeReadOrg), org.GetTeamMembers)
-				m.Combo("/{username}").
-					Get(reqToken(auth_model.AccessTokenScopeReadOrg), org.GetTeamMember).
-					Put(reqToken(auth_model.AccessTokenScopeWriteOrg), reqOrgOwnership(), org.AddTeamMember).
-					Delete(reqToken(auth_model.AccessTokenScopeWriteOrg), reqOrgOwnership(), org.RemoveTeamMember)
-			})
-			m.Group("/repos", func() {
-				m.Get("", reqToken(auth_model.AccessTokenScopeReadOrg), org.GetTeamRepos)
-				m.Combo("/{org}/{reponame}").
-					Put(reqToken(auth_model.AccessTokenScopeWriteOrg), org.AddTeamRepository).
-					Delete(reqToken(auth_model.AccessTokenScopeWriteOrg), org.RemoveTeamRepository).
-					Get(reqToken(auth_model.AccessTokenScopeReadOrg), org.GetTeamRepo)
-			})
-		}, orgAssignment(false, true), reqToken(""), reqTeamMembership())
+		m.Group("", func() {
+			// Organizations
+			m.Get("/user/orgs", reqToken(auth_model.AccessTokenScopeReadOrg), org.ListMyOrgs)
+			m.Group("/users/{username}/orgs", func() {
+				m.Get("", reqToken(auth_model.AccessTokenScopeReadOrg), org.ListUserOrgs)
+				m.Get("/{org}/permissions", reqToken(auth_model.AccessTokenScopeReadOrg), org.GetUserOrgsPermissions)
+			}, context_service.UserAssignmentAPI())
+			m.Post("/orgs", reqToken(auth_model.AccessTokenScopeWriteOrg), bind(api.CreateOrgOption{}), org.Create)
+			m.Get("/orgs", reqToken(auth_model.AccessTokenScopeReadOrg), org.GetAll)
+			m.Group("/orgs/{org}", func() {
+				m.Combo("").Get(reqToken(auth_model.AccessTokenScopeReadOrg), org.Get).
+					Patch(reqToken(auth_model.AccessTokenScopeWriteOrg), reqOrgOwnership(), bind(api.EditOrgOption{}), org.Edit).
+					Delete(reqToken(auth_model.AccessTokenScopeWriteOrg), reqOrgOwnership(), org.Delete)
+				m.Combo("/repos").Get(reqToken(auth_model.AccessTokenScopeReadOrg), user.ListOrgRepos).
+					Post(reqToken(auth_model.AccessTokenScopeWriteOrg), bind(api.CreateRepoOption{}), repo.CreateOrgRepo)
+				m.Group("/members", func() {
+					m.Get("", reqToken(auth_model.AccessTokenScopeReadOrg), org.ListMembers)
+					m.Combo("/{username}").Get(reqToken(auth_model.AccessTokenScopeReadOrg), org.IsMember).
+						Delete(reqToken(auth_model.AccessTokenScopeWriteOrg), reqOrgOwnership(), org.DeleteMember)
+				})
+				m.Group("/public_members", func() {
+					m.Get("", reqToken(auth_model.AccessTokenScopeReadOrg), org.ListPublicMembers)
+					m.Combo("/{username}").Get(reqToken(auth_model.AccessTokenScopeReadOrg), org.IsPublicMember).
+						Put(reqToken(auth_model.AccessTokenScopeWriteOrg), reqOrgMembership(), org.PublicizeMember).
+						Delete(reqToken(auth_model.AccessTokenScopeWriteOrg), reqOrgMembership(), org.ConcealMember)
+				})
+				m.Group("/teams", func() {
+					m.Get("", reqToken(auth_model.AccessTokenScopeReadOrg), org.ListTeams)
+					m.Post("", reqToken(auth_model.AccessTokenScopeWriteOrg), reqOrgOwnership(), bind(api.CreateTeamOption{}), org.CreateTeam)
+					m.Get("/search", reqToken(auth_model.AccessTokenScopeReadOrg), org.SearchTeam)
+				}, reqOrgMembership())
+				m.Group("/labels", func() {
+					m.Get("", reqToken(auth_model.AccessTokenScopeReadOrg), org.ListLabels)
+					m.Post("", reqToken(auth_model.AccessTokenScopeWriteOrg), reqOrgOwnership(), bind(api.CreateLabelOption{}), org.CreateLabel)
+					m.Combo("/{id}").Get(reqToken(auth_model.AccessTokenScopeReadOrg), org.GetLabel).
+						Patch(reqToken(auth_model.AccessTokenScopeWriteOrg), reqOrgOwnership(), bind(api.EditLabelOption{}), org.EditLabel).
+						Delete(reqToken(auth_model.AccessTokenScopeWriteOrg), reqOrgOwnership(), org.DeleteLabel)
+				})
+				m.Group("/hooks", func() {
+					m.Combo("").Get(org.ListHooks).
+						Post(bind(api.CreateHookOption{}), org.CreateHook)
+					m.Combo("/{id}").Get(org.GetHook).
+						Patch(bind(api.EditHookOption{}), org.EditHook).
+						Delete(org.DeleteHook)
+				}, reqToken(auth_model.AccessTokenScopeAdminOrgHook), reqOrgOwnership(), reqWebhooksEnabled())
+			}, orgAssignment(true))
+			m.Group("/teams/{teamid}", func() {
+				m.Combo("").Get(reqToken(auth_model.AccessTokenScopeReadOrg), org.GetTeam).
+					Patch(reqToken(auth_model.AccessTokenScopeWriteOrg), reqOrgOwnership(), bind(api.EditTeamOption{}), org.EditTeam).
+					Delete(reqToken(auth_model.AccessTokenScopeWriteOrg), reqOrgOwnership(), org.DeleteTeam)
+				m.Group("/members", func() {
+					m.Get("", reqToken(auth_model.AccessTokenScopeReadOrg), org.GetTeamMembers)
+					m.Combo("/{username}").
+						Get(reqToken(auth_model.AccessTokenScopeReadOrg), org.GetTeamMember).
+						Put(reqToken(auth_model.AccessTokenScopeWriteOrg), reqOrgOwnership(), org.AddTeamMember).
+						Delete(reqToken(auth_model.AccessTokenScopeWriteOrg), reqOrgOwnership(), org.RemoveTeamMember)
+				})
+				m.Group("/repos", func() {
+					m.Get("", reqToken(auth_model.AccessTokenScopeReadOrg), org.GetTeamRepos)
+					m.Combo("/{org}/{reponame}").
+						Put(reqToken(auth_model.AccessTokenScopeWriteOrg), org.AddTeamRepository).
+						Delete(reqToken(auth_model.AccessTokenScopeWriteOrg), org.RemoveTeamRepository).
+						Get(reqToken(auth_model.AccessTokenScopeReadOrg), org.GetTeamRepo)
+				})
+			}, orgAssignment(false, true), reqToken(""), reqTeamMembership())
 
-		m.Group("/admin", func() {
-			m.Group("/cron", func() {
-				m.Get("", admin.ListCronTasks)
-				m.Post("/{task}", admin.PostCronTask)
-			})
-			m.Get("/orgs", admin.GetAllOrgs)
-			m.Group("/users", func() {
-				m.Get("", admin.GetAllUsers)
-				m.Post("", bind(api.CreateUserOption{}), admin.CreateUser)
-				m.Group("/{username}", func() {
-					m.Combo("").Patch(bind(api.EditUserOption{}), admin.EditUser).
-						Delete(admin.DeleteUser)
-					m.Group("/keys", func() {
-						m.Post("", bind(api.CreateKeyOption{}), admin.CreatePublicKey)
-						m.Delete("/{id}", admin.DeleteUserPublicKey)
-					})
-					m.Get("/orgs", org.ListUserOrgs)
-					m.Post("/orgs", bind(api.CreateOrgOption{}), admin.CreateOrg)
-					m.Post("/repos", bind(api.CreateRepoOption{}), admin.CreateRepo)
-				}, context_service.UserAssignmentAPI())
-			})
-			m.Group("/unadopted", func() {
-				m.Get("", admin.ListUnadoptedRepositories)
-				m.Post("/{username}/{reponame}", admin.AdoptRepository)
-				m.Delete("/{username}/{reponame}", admin.DeleteUnadoptedRepository)
-			})
-		}, reqToken(auth_model.AccessTokenScopeSudo), reqSiteAdmin())
+			m.Group("/admin", func() {
+				m.Get("/audit", admin.ListAuditEvents)
+				m.Group("/cron", func() {
+					m.Get("", admin.ListCronTasks)
+					m.Post("/{task}", admin.PostCronTask)
+				})
+				m.Get("/orgs", admin.GetAllOrgs)
+				m.Group("/users", func() {
+					m.Get("", admin.GetAllUsers)
+					m.Post("", bind(api.CreateUserOption{}), admin.CreateUser)
+					m.Group("/{username}", func() {
+						m.Combo("").Patch(bind(api.EditUserOption{}), admin.EditUser).
+							Delete(admin.DeleteUser)
+						m.Group("/keys", func() {
+							m.Post("", bind(api.CreateKeyOption{}), admin.CreatePublicKey)
+							m.Delete("/{id}", admin.DeleteUserPublicKey)
+						})
+						m.Get("/orgs", org.ListUserOrgs)
+						m.Post("/orgs", bind(api.CreateOrgOption{}), admin.CreateOrg)
+						m.Post("/repos", bind(api.CreateRepoOption{}), admin.CreateRepo)
+					}, context_service.UserAssignmentAPI())
+				})
+				m.Group("/unadopted", func() {
+					m.Get("", admin.ListUnadoptedRepositories)
+					m.Post("/{username}/{reponame}", admin.AdoptRepository)
+					m.Delete("/{username}/{reponame}", admin.DeleteUnadoptedRepository)
+				})
+			}, reqToken(auth_model.AccessTokenScopeSudo), reqSiteAdmin())
 
-		m.Group("/topics", func() {
-			m.Get("/search", repo.TopicSearch)
-		})
+			m.Group("/topics", func() {
+				m.Get("/search", repo.TopicSearch)
+			})
+		}, reqNotFineGrainedToken())
 	}, sudo())
 
 	return m
@@ -0,0 +1,115 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package misc
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models/organization"
+	access_model "code.gitea.io/gitea/models/perm/access"
+	"code.gitea.io/gitea/modules/context"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/services/convert"
+	search_service "code.gitea.io/gitea/services/search"
+)
+
+// Search searches the repositories, issues, users, organizations, code and packages which the doer can see
+func Search(ctx *context.APIContext) {
+	// swagger:operation GET /search miscellaneous globalSearch
+	// ---
+	// summary: Search repositories, issues, users, organizations, code and packages
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: q
+	//   in: query
+	//   description: keyword
+	//   type: string
+	//   required: true
+	// - name: limit
+	//   in: query
+	//   description: maximum number of results of every kind
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/GlobalSearchResults"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	keyword := ctx.FormTrim("q")
+	if keyword == "" {
+		ctx.Error(http.StatusUnprocessableEntity, "", "keyword is required")
+		return
+	}
+
+	results, err := search_service.Search(ctx, ctx.Doer, &search_service.Options{
+		Keyword:  keyword,
+		PageSize: convert.ToCorrectPageSize(ctx.FormInt("limit")),
+	})
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "Search", err)
+		return
+	}
+
+	apiResults := &api.GlobalSearchResults{
+		Repositories:       make([]*api.Repository, 0, len(results.Repos)),
+		RepositoriesTotal:  results.ReposTotal,
+		Issues:             convert.ToAPIIssueList(ctx, results.Issues),
+		IssuesTotal:        results.IssuesTotal,
+		Users:              make([]*api.User, 0, len(results.Users)),
+		UsersTotal:         results.UsersTotal,
+		Organizations:      make([]*api.Organization, 0, len(results.Orgs)),
+		OrganizationsTotal: results.OrgsTotal,
+		Code:               make([]*api.CodeSearchResult, 0, len(results.Code)),
+		CodeTotal:          int64(results.CodeTotal),
+		Packages:           make([]*api.Package, 0, len(results.Packages)),
+		PackagesTotal:      results.PackagesTotal,
+	}
+
+	for _, repo := range results.Repos {
+		accessMode, err := access_model.AccessLevel(ctx, ctx.Doer, repo)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "AccessLevel", err)
+			return
+		}
+		apiResults.Repositories = append(apiResults.Repositories, convert.ToRepo(ctx, repo, accessMode))
+	}
+	for _, user := range results.Users {
+		apiResults.Users = append(apiResults.Users, convert.ToUser(user, ctx.Doer))
+	}
+	for _, org := range results.Orgs {
+		apiResults.Organizations = append(apiResults.Organizations, convert.ToOrganization(organization.OrgFromUser(org)))
+	}
+	for _, result := range results.Code {
+		repo := results.CodeRepos[result.RepoID]
+		matches := make([]*api.CodeSearchMatch, 0, len(result.Matches))
+		for _, match := range result.Matches {
+			matches = append(matches, &api.CodeSearchMatch{
+				Line:        match.Line,
+				StartColumn: match.StartColumn,
+				EndColumn:   match.EndColumn,
+			})
+		}
+		apiResults.Code = append(apiResults.Code, &api.CodeSearchResult{
+			Repository:  repo.FullName(),
+			Filename:    result.Filename,
+			CommitID:    result.CommitID,
+			Language:    result.Language,
+			HTMLURL:     repo.HTMLURL() + "/src/commit/" + util.PathEscapeSegments(result.CommitID) + "/" + util.PathEscapeSegments(result.Filename),
+			LineNumbers: result.LineNumbers,
+			Matches:     matches,
+		})
+	}
+	for _, pd := range results.Packages {
+		apiPackage, err := convert.ToPackage(ctx, pd, ctx.Doer)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "ToPackage", err)
+			return
+		}
+		apiResults.Packages = append(apiResults.Packages, apiPackage)
+	}
+
+	ctx.JSON(http.StatusOK, apiResults)
+}
@@ -4,15 +4,21 @@
 package packages
 
 import (
+	"errors"
 	"net/http"
 
 	"code.gitea.io/gitea/models/packages"
+	repo_model "code.gitea.io/gitea/models/repo"
 	"code.gitea.io/gitea/modules/context"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/web"
 	"code.gitea.io/gitea/routers/api/v1/utils"
 	"code.gitea.io/gitea/services/convert"
 	packages_service "code.gitea.io/gitea/services/packages"
+	alpine_service "code.gitea.io/gitea/services/packages/alpine"
+	debian_service "code.gitea.io/gitea/services/packages/debian"
+	rpm_service "code.gitea.io/gitea/services/packages/rpm"
 )
 
 // ListPackages gets all packages of an owner
@@ -40,7 +46,7 @@ func ListPackages(ctx *context.APIContext) {
 	//   in: query
 	//   description: package type filter
 	//   type: string
-	//   enum: [composer, conan, container, generic, helm, maven, npm, nuget, pub, pypi, rubygems, vagrant]
+	//   enum: [alpine, cargo, composer, conan, conda, container, debian, generic, go, helm, maven, npm, nuget, pub, pypi, rpm, rubygems, vagrant]
 	// - name: q
 	//   in: query
 	//   description: name filter
@@ -167,6 +173,23 @@ func DeletePackage(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "RemovePackageVersion", err)
 		return
 	}
+	switch ctx.Package.Descriptor.Package.Type {
+	case packages.TypeAlpine:
+		if err := alpine_service.BuildAllRepositoryFiles(ctx, ctx.Package.Owner.ID); err != nil {
+			ctx.Error(http.StatusInternalServerError, "BuildAllRepositoryFiles", err)
+			return
+		}
+	case packages.TypeDebian:
+		if err := debian_service.BuildAllRepositoryFiles(ctx, ctx.Package.Owner.ID); err != nil {
+			ctx.Error(http.StatusInternalServerError, "BuildAllRepositoryFiles", err)
+			return
+		}
+	case packages.TypeRpm:
+		if err := rpm_service.BuildRepositoryFiles(ctx, ctx.Package.Owner.ID); err != nil {
+			ctx.Error(http.StatusInternalServerError, "BuildRepositoryFiles", err)
+			return
+		}
+	}
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -211,3 +234,124 @@ func ListPackageFiles(ctx *context.APIContext) {
 
 	ctx.JSON(http.StatusOK, apiPackageFiles)
 }
+
+// SetPackageProvenance sets the source a package was built from
+func SetPackageProvenance(ctx *context.APIContext) {
+	// swagger:operation PUT /packages/{owner}/{type}/{name}/{version}/provenance package setPackageProvenance
+	// ---
+	// summary: Set the repository, commit and release a package was built from
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// - name: version
+	//   in: path
+	//   description: version of the package
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/SetPackageProvenanceOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Package"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.SetPackageProvenanceOption)
+
+	repo, err := repo_model.GetRepositoryByName(ctx.Package.Owner.ID, form.Repository)
+	if err != nil {
+		if repo_model.IsErrRepoNotExist(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetRepositoryByName", err)
+		}
+		return
+	}
+
+	if err := packages_service.SetVersionProvenance(ctx, ctx.Doer, ctx.Package.Descriptor, repo, form.CommitSHA, form.Release); err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+		} else if errors.Is(err, util.ErrPermissionDenied) {
+			ctx.Error(http.StatusForbidden, "", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "SetVersionProvenance", err)
+		}
+		return
+	}
+
+	pd, err := packages.GetPackageDescriptor(ctx, ctx.Package.Descriptor.Version)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetPackageDescriptor", err)
+		return
+	}
+
+	apiPackage, err := convert.ToPackage(ctx, pd, ctx.Doer)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "Error converting package for api", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, apiPackage)
+}
+
+// DeletePackageProvenance removes the source a package was built from
+func DeletePackageProvenance(ctx *context.APIContext) {
+	// swagger:operation DELETE /packages/{owner}/{type}/{name}/{version}/provenance package deletePackageProvenance
+	// ---
+	// summary: Remove the repository, commit and release a package was built from
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// - name: version
+	//   in: path
+	//   description: version of the package
+	//   type: string
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	if err := packages.DeleteVersionProvenance(ctx, ctx.Package.Descriptor.Version.ID); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeleteVersionProvenance", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
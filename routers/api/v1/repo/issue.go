@@ -189,8 +189,12 @@ func SearchIssues(ctx *context.APIContext) {
 	if strings.IndexByte(keyword, 0) >= 0 {
 		keyword = ""
 	}
+	// mentions and review requests are not stored in the issue indexer,
+	// otherwise the query and the filters are executed by it
+	useIndexer := len(keyword) > 0 && !ctx.FormBool("mentioned") && !ctx.FormBool("review_requested")
+
 	var issueIDs []int64
-	if len(keyword) > 0 && len(repoIDs) > 0 {
+	if len(keyword) > 0 && len(repoIDs) > 0 && !useIndexer {
 		if issueIDs, err = issue_indexer.SearchIssuesByKeyword(ctx, repoIDs, keyword); err != nil {
 			ctx.Error(http.StatusInternalServerError, "SearchIssuesByKeyword", err)
 			return
@@ -228,9 +232,34 @@ func SearchIssues(ctx *context.APIContext) {
 		limit = setting.API.MaxResponseItems
 	}
 
-	// Only fetch the issues if we either don't have a keyword or the search returned issues
-	// This would otherwise return all issues if no issues were found by the search.
-	if len(keyword) == 0 || len(issueIDs) > 0 || len(includedLabelNames) > 0 || len(includedMilestones) > 0 {
+	if useIndexer {
+		page := ctx.FormInt("page")
+		if page <= 0 {
+			page = 1
+		}
+		searchOpts := &issue_indexer.SearchOptions{
+			RepoIDs:       repoIDs,
+			IsClosed:      isClosed,
+			IsPull:        isPull,
+			UpdatedAfter:  timeutil.TimeStamp(since),
+			UpdatedBefore: timeutil.TimeStamp(before),
+			SortBy:        issue_indexer.SortByScore,
+			Limit:         limit,
+			Start:         (page - 1) * limit,
+		}
+		if ctx.IsSigned && ctx.FormBool("created") {
+			searchOpts.PosterID = ctx.Doer.ID
+		}
+		if ctx.IsSigned && ctx.FormBool("assigned") {
+			searchOpts.AssigneeID = ctx.Doer.ID
+		}
+		if issues, filteredCount, err = issue_service.SearchIssues(ctx, ctx.Doer, keyword, includedLabelNames, includedMilestones, searchOpts); err != nil {
+			ctx.Error(http.StatusInternalServerError, "SearchIssues", err)
+			return
+		}
+	} else if len(keyword) == 0 || len(issueIDs) > 0 || len(includedLabelNames) > 0 || len(includedMilestones) > 0 {
+		// Only fetch the issues if we either don't have a keyword or the search returned issues
+		// This would otherwise return all issues if no issues were found by the search.
 		issuesOpt := &issues_model.IssuesOptions{
 			ListOptions: db.ListOptions{
 				Page:     ctx.FormInt("page"),
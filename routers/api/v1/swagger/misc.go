@@ -20,3 +20,10 @@ type swaggerResponseStringSlice struct {
 	// in:body
 	Body []string `json:"body"`
 }
+
+// GlobalSearchResults
+// swagger:response GlobalSearchResults
+type swaggerResponseGlobalSearchResults struct {
+	// in:body
+	Body api.GlobalSearchResults `json:"body"`
+}
@@ -171,4 +171,7 @@ type swaggerParameterBodies struct {
 
 	// in:body
 	CreatePushMirrorOption api.CreatePushMirrorOption
+
+	// in:body
+	SetPackageProvenanceOption api.SetPackageProvenanceOption
 }
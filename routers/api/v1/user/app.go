@@ -13,8 +13,11 @@ import (
 	auth_model "code.gitea.io/gitea/models/auth"
 	"code.gitea.io/gitea/modules/context"
 	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/modules/web"
 	"code.gitea.io/gitea/routers/api/v1/utils"
+	auth_service "code.gitea.io/gitea/services/auth"
 	"code.gitea.io/gitea/services/convert"
 )
 
@@ -58,11 +61,7 @@ func ListAccessTokens(ctx *context.APIContext) {
 
 	apiTokens := make([]*api.AccessToken, len(tokens))
 	for i := range tokens {
-		apiTokens[i] = &api.AccessToken{
-			ID:             tokens[i].ID,
-			Name:           tokens[i].Name,
-			TokenLastEight: tokens[i].TokenLastEight,
-		}
+		apiTokens[i] = convert.ToAccessToken(tokens[i])
 	}
 
 	ctx.SetTotalCountHeader(count)
@@ -101,6 +100,28 @@ func CreateAccessToken(ctx *context.APIContext) {
 		Name: form.Name,
 	}
 
+	if form.ResourceOwner != "" {
+		opts := &auth_service.FineGrainedTokenOptions{
+			ResourceOwner: form.ResourceOwner,
+			Repositories:  form.Repositories,
+			Permissions:   form.Permissions,
+		}
+		if form.ExpiresAt != nil {
+			opts.ExpiresUnix = timeutil.TimeStamp(form.ExpiresAt.Unix())
+		}
+		if err := auth_service.RestrictAccessToken(ctx, ctx.Doer, t, opts); err != nil {
+			if errors.Is(err, util.ErrInvalidArgument) {
+				ctx.Error(http.StatusBadRequest, "RestrictAccessToken", err)
+			} else {
+				ctx.Error(http.StatusInternalServerError, "RestrictAccessToken", err)
+			}
+			return
+		}
+	} else if len(form.Repositories) > 0 || len(form.Permissions) > 0 {
+		ctx.Error(http.StatusBadRequest, "CreateAccessToken", errors.New("repositories and permissions require a resource owner"))
+		return
+	}
+
 	exist, err := auth_model.AccessTokenByNameExists(t)
 	if err != nil {
 		ctx.InternalServerError(err)
@@ -111,16 +132,13 @@ func CreateAccessToken(ctx *context.APIContext) {
 		return
 	}
 
-	if err := auth_model.NewAccessToken(t); err != nil {
+	if err := auth_service.CreateAccessToken(ctx, ctx.Doer, ctx.Doer, t); err != nil {
 		ctx.Error(http.StatusInternalServerError, "NewAccessToken", err)
 		return
 	}
-	ctx.JSON(http.StatusCreated, &api.AccessToken{
-		Name:           t.Name,
-		Token:          t.Token,
-		ID:             t.ID,
-		TokenLastEight: t.TokenLastEight,
-	})
+	apiToken := convert.ToAccessToken(t)
+	apiToken.Token = t.Token
+	ctx.JSON(http.StatusCreated, apiToken)
 }
 
 // DeleteAccessToken delete access tokens
@@ -178,7 +196,7 @@ func DeleteAccessToken(ctx *context.APIContext) {
 		return
 	}
 
-	if err := auth_model.DeleteAccessTokenByID(tokenID, ctx.Doer.ID); err != nil {
+	if err := auth_service.DeleteAccessToken(ctx, ctx.Doer, tokenID); err != nil {
 		if auth_model.IsErrAccessTokenNotExist(err) {
 			ctx.NotFound()
 		} else {
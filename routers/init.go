@@ -15,6 +15,7 @@ import (
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/highlight"
 	code_indexer "code.gitea.io/gitea/modules/indexer/code"
+	commits_indexer "code.gitea.io/gitea/modules/indexer/commits"
 	issue_indexer "code.gitea.io/gitea/modules/indexer/issues"
 	stats_indexer "code.gitea.io/gitea/modules/indexer/stats"
 	"code.gitea.io/gitea/modules/log"
@@ -30,11 +31,14 @@ import (
 	"code.gitea.io/gitea/modules/translation"
 	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/modules/web"
+	actions_router "code.gitea.io/gitea/routers/api/actions"
 	packages_router "code.gitea.io/gitea/routers/api/packages"
+	scim_router "code.gitea.io/gitea/routers/api/scim"
 	apiv1 "code.gitea.io/gitea/routers/api/v1"
 	"code.gitea.io/gitea/routers/common"
 	"code.gitea.io/gitea/routers/private"
 	web_routers "code.gitea.io/gitea/routers/web"
+	actions_service "code.gitea.io/gitea/services/actions"
 	"code.gitea.io/gitea/services/auth"
 	"code.gitea.io/gitea/services/auth/source/oauth2"
 	"code.gitea.io/gitea/services/automerge"
@@ -114,6 +118,9 @@ func GlobalInitInstalled(ctx context.Context) {
 
 	mustInitCtx(ctx, git.InitFull)
 	log.Info("Git Version: %s (home: %s)", git.VersionInfo(), git.HomeDir())
+	if !git.IsSupportedObjectFormat(git.ObjectFormat(setting.Repository.DefaultObjectFormat)) {
+		log.Fatal("The default object format %q of new repositories isn't supported by this git version or build", setting.Repository.DefaultObjectFormat)
+	}
 	log.Info("AppPath: %s", setting.AppPath)
 	log.Info("AppWorkPath: %s", setting.AppWorkPath)
 	log.Info("Custom path: %s", setting.CustomPath)
@@ -154,10 +161,12 @@ func GlobalInitInstalled(ctx context.Context) {
 	// Booting long running goroutines.
 	issue_indexer.InitIssueIndexer(false)
 	code_indexer.Init()
+	commits_indexer.Init()
 	mustInit(stats_indexer.Init)
 
 	mirror_service.InitSyncMirrors()
 	mustInit(webhook.Init)
+	mustInit(actions_service.Init)
 	mustInit(pull_service.Init)
 	mustInit(automerge.Init)
 	mustInit(task.Init)
@@ -188,6 +197,16 @@ func NormalRoutes(ctx context.Context) *web.Route {
 	r.Mount("/api/v1", apiv1.Routes(ctx))
 	r.Mount("/api/internal", private.Routes())
 
+	if setting.Actions.Enabled {
+		// This implements the protocol used by runners to execute workflows
+		r.Mount("/api/actions", actions_router.Routes(ctx))
+	}
+
+	if setting.SCIM.Enabled {
+		// This implements the SCIM 2.0 protocol used by identity providers to provision users and groups
+		r.Mount("/api/scim/v2", scim_router.Routes(ctx))
+	}
+
 	if setting.Packages.Enabled {
 		// Add endpoints to match common package manager APIs
 
@@ -164,7 +164,7 @@ func HookPostReceive(ctx *gitea_context.PrivateContext) {
 		branch := git.RefEndName(opts.RefFullNames[i])
 
 		// If we've pushed a branch (and not deleted it)
-		if newCommitID != git.EmptySHA && strings.HasPrefix(refFullName, git.BranchPrefix) {
+		if !git.IsEmptyCommitID(newCommitID) && strings.HasPrefix(refFullName, git.BranchPrefix) {
 
 			// First ensure we have the repository loaded, we're allowed pulls requests and we can get the base repo
 			if repo == nil {
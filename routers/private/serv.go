@@ -165,6 +165,7 @@ func ServCommand(ctx *context.PrivateContext) {
 		repo.Owner = owner
 		repo.OwnerName = ownerName
 		results.RepoID = repo.ID
+		results.ServiceOptions = repo_service.GitServiceOptions(ctx, repo, results.IsWiki)
 
 		if repo.IsBeingCreated() {
 			ctx.JSON(http.StatusInternalServerError, private.ErrServCommand{
@@ -147,9 +147,12 @@ func DashboardPost(ctx *context.Context) {
 			ctx.Flash.Error(ctx.Tr("admin.dashboard.task.unknown", form.Op))
 		}
 	}
-	if form.From == "monitor" {
+	switch form.From {
+	case "monitor":
 		ctx.Redirect(setting.AppSubURL + "/admin/monitor")
-	} else {
+	case "maintenance":
+		ctx.Redirect(setting.AppSubURL + "/admin/repos/maintenance")
+	default:
 		ctx.Redirect(setting.AppSubURL + "/admin")
 	}
 }
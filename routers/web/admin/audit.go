@@ -0,0 +1,88 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	audit_model "code.gitea.io/gitea/models/audit"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+const (
+	tplAuditEvents base.TplName = "admin/audit"
+)
+
+// parseAuditDate parses a date of the filter form, it returns 0 if the date is empty or invalid
+func parseAuditDate(value string) timeutil.TimeStamp {
+	if value == "" {
+		return 0
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, setting.DefaultUILocation)
+	if err != nil {
+		return 0
+	}
+	return timeutil.TimeStamp(t.Unix())
+}
+
+// AuditEvents shows the audit log
+func AuditEvents(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("admin.audit")
+	ctx.Data["PageIsAdmin"] = true
+	ctx.Data["PageIsAdminAudit"] = true
+
+	page := ctx.FormInt("page")
+	if page <= 1 {
+		page = 1
+	}
+
+	opts := audit_model.FindEventsOptions{
+		ListOptions: db.ListOptions{
+			Page:     page,
+			PageSize: setting.UI.Admin.NoticePagingNum,
+		},
+		Action:    audit_model.Action(ctx.FormTrim("action")),
+		ActorName: ctx.FormTrim("actor"),
+		ScopeType: audit_model.ScopeType(ctx.FormTrim("scope_type")),
+		ScopeName: ctx.FormTrim("scope"),
+		Since:     parseAuditDate(ctx.FormTrim("since")),
+	}
+	// the events of the "until" day are included
+	if until := parseAuditDate(ctx.FormTrim("until")); until > 0 {
+		opts.Before = until.AddDuration(24 * time.Hour)
+	}
+
+	events, total, err := audit_model.FindEvents(ctx, opts)
+	if err != nil {
+		ctx.ServerError("FindEvents", err)
+		return
+	}
+
+	ctx.Data["Events"] = events
+	ctx.Data["Total"] = total
+	ctx.Data["Actions"] = audit_model.Actions
+	ctx.Data["ScopeTypes"] = audit_model.ScopeTypes
+	ctx.Data["FilterAction"] = opts.Action
+	ctx.Data["FilterActor"] = opts.ActorName
+	ctx.Data["FilterScopeType"] = opts.ScopeType
+	ctx.Data["FilterScope"] = opts.ScopeName
+	ctx.Data["FilterSince"] = ctx.FormTrim("since")
+	ctx.Data["FilterUntil"] = ctx.FormTrim("until")
+
+	pager := context.NewPagination(int(total), opts.PageSize, page, 5)
+	pager.AddParamString("action", string(opts.Action))
+	pager.AddParamString("actor", opts.ActorName)
+	pager.AddParamString("scope_type", string(opts.ScopeType))
+	pager.AddParamString("scope", opts.ScopeName)
+	pager.AddParamString("since", ctx.FormTrim("since"))
+	pager.AddParamString("until", ctx.FormTrim("until"))
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(http.StatusOK, tplAuditEvents)
+}
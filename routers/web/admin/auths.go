@@ -24,6 +24,7 @@ import (
 	"code.gitea.io/gitea/services/auth/source/ldap"
 	"code.gitea.io/gitea/services/auth/source/oauth2"
 	pam_service "code.gitea.io/gitea/services/auth/source/pam"
+	"code.gitea.io/gitea/services/auth/source/saml"
 	"code.gitea.io/gitea/services/auth/source/smtp"
 	"code.gitea.io/gitea/services/auth/source/sspi"
 	"code.gitea.io/gitea/services/forms"
@@ -72,6 +73,7 @@ var (
 			{auth.SMTP.String(), auth.SMTP},
 			{auth.OAuth2.String(), auth.OAuth2},
 			{auth.SSPI.String(), auth.SSPI},
+			{auth.SAML.String(), auth.SAML},
 		}
 		if pam.Supported {
 			items = append(items, dropdownItem{auth.Names[auth.PAM], auth.PAM})
@@ -231,6 +233,39 @@ func parseSSPIConfig(ctx *context.Context, form forms.AuthenticationForm) (*sspi
 	}, nil
 }
 
+func parseSAMLConfig(ctx *context.Context, form forms.AuthenticationForm) (*saml.Source, error) {
+	if !saml.IsSecureAppURL() {
+		return nil, errors.New(ctx.Tr("admin.auths.saml_requires_https", setting.AppURL))
+	}
+	metadata := form.SAMLIdentityProviderMetadata
+	if form.SAMLIdentityProviderMetadataURL != "" {
+		data, err := saml.FetchIdentityProviderMetadata(ctx, form.SAMLIdentityProviderMetadataURL)
+		if err != nil {
+			ctx.Data["Err_SAMLIdentityProviderMetadataURL"] = true
+			return nil, errors.New(ctx.Tr("admin.auths.saml_metadata_fetch_failed", err))
+		}
+		metadata = string(data)
+	}
+	if _, err := saml.ParseIdentityProviderMetadata([]byte(metadata)); err != nil {
+		ctx.Data["Err_SAMLIdentityProviderMetadata"] = true
+		return nil, errors.New(ctx.Tr("admin.auths.saml_invalid_metadata", err))
+	}
+
+	return &saml.Source{
+		IdentityProviderMetadata:    metadata,
+		IdentityProviderMetadataURL: form.SAMLIdentityProviderMetadataURL,
+		NameIDFormat:                form.SAMLNameIDFormat,
+		AttributeUsername:           form.SAMLAttributeUsername,
+		AttributeEmail:              form.SAMLAttributeEmail,
+		AttributeFullName:           form.SAMLAttributeFullName,
+		AttributeGroups:             form.SAMLAttributeGroups,
+		GroupTeamMap:                form.SAMLGroupTeamMap,
+		GroupTeamMapRemoval:         form.SAMLGroupTeamMapRemoval,
+		AutoCreateUsers:             form.SAMLAutoCreateUsers,
+		SkipLocalTwoFA:              form.SkipLocalTwoFA,
+	}, nil
+}
+
 // NewAuthSourcePost response for adding an auth source
 func NewAuthSourcePost(ctx *context.Context) {
 	form := *web.GetForm(ctx).(*forms.AuthenticationForm)
@@ -282,6 +317,13 @@ func NewAuthSourcePost(ctx *context.Context) {
 			ctx.RenderWithErr(ctx.Tr("admin.auths.login_source_of_type_exist"), tplAuthNew, form)
 			return
 		}
+	case auth.SAML:
+		var err error
+		config, err = parseSAMLConfig(ctx, form)
+		if err != nil {
+			ctx.RenderWithErr(err.Error(), tplAuthNew, form)
+			return
+		}
 	default:
 		ctx.Error(http.StatusBadRequest)
 		return
@@ -393,6 +435,12 @@ func EditAuthSourcePost(ctx *context.Context) {
 			ctx.RenderWithErr(err.Error(), tplAuthEdit, form)
 			return
 		}
+	case auth.SAML:
+		config, err = parseSAMLConfig(ctx, form)
+		if err != nil {
+			ctx.RenderWithErr(err.Error(), tplAuthEdit, form)
+			return
+		}
 	default:
 		ctx.Error(http.StatusBadRequest)
 		return
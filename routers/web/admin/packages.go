@@ -14,6 +14,9 @@ import (
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/util"
 	packages_service "code.gitea.io/gitea/services/packages"
+	alpine_service "code.gitea.io/gitea/services/packages/alpine"
+	debian_service "code.gitea.io/gitea/services/packages/debian"
+	rpm_service "code.gitea.io/gitea/services/packages/rpm"
 )
 
 const (
@@ -92,11 +95,35 @@ func DeletePackageVersion(ctx *context.Context) {
 		return
 	}
 
+	p, err := packages_model.GetPackageByID(db.DefaultContext, pv.PackageID)
+	if err != nil {
+		ctx.ServerError("GetPackageByID", err)
+		return
+	}
+
 	if err := packages_service.RemovePackageVersion(ctx.Doer, pv); err != nil {
 		ctx.ServerError("RemovePackageVersion", err)
 		return
 	}
 
+	switch p.Type {
+	case packages_model.TypeAlpine:
+		if err := alpine_service.BuildAllRepositoryFiles(ctx, p.OwnerID); err != nil {
+			ctx.ServerError("BuildAllRepositoryFiles", err)
+			return
+		}
+	case packages_model.TypeDebian:
+		if err := debian_service.BuildAllRepositoryFiles(ctx, p.OwnerID); err != nil {
+			ctx.ServerError("BuildAllRepositoryFiles", err)
+			return
+		}
+	case packages_model.TypeRpm:
+		if err := rpm_service.BuildRepositoryFiles(ctx, p.OwnerID); err != nil {
+			ctx.ServerError("BuildRepositoryFiles", err)
+			return
+		}
+	}
+
 	ctx.Flash.Success(ctx.Tr("packages.settings.delete.success"))
 	ctx.JSON(http.StatusOK, map[string]interface{}{
 		"redirect": setting.AppSubURL + "/admin/packages?page=" + url.QueryEscape(ctx.FormString("page")) + "&q=" + url.QueryEscape(ctx.FormString("q")) + "&type=" + url.QueryEscape(ctx.FormString("type")),
@@ -24,6 +24,7 @@ import (
 const (
 	tplRepos          base.TplName = "admin/repo/list"
 	tplUnadoptedRepos base.TplName = "admin/repo/unadopted"
+	tplMaintenance    base.TplName = "admin/repo/maintenance"
 )
 
 // Repos show all the repositories
@@ -63,6 +64,48 @@ func DeleteRepo(ctx *context.Context) {
 	})
 }
 
+// RepoMaintenances shows the results of the maintenance of the repositories
+func RepoMaintenances(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("admin.repos.maintenance")
+	ctx.Data["PageIsAdmin"] = true
+	ctx.Data["PageIsAdminRepositories"] = true
+
+	page := ctx.FormInt("page")
+	if page <= 1 {
+		page = 1
+	}
+	onlyPushed := ctx.FormBool("pushed")
+
+	maintenances, total, err := repo_model.FindRepoMaintenances(ctx, repo_model.FindRepoMaintenancesOptions{
+		ListOptions: db.ListOptions{
+			Page:     page,
+			PageSize: setting.UI.Admin.RepoPagingNum,
+		},
+		OnlyPushed: onlyPushed,
+	})
+	if err != nil {
+		ctx.ServerError("FindRepoMaintenances", err)
+		return
+	}
+	for _, m := range maintenances {
+		if err := m.LoadRepo(ctx); err != nil && !repo_model.IsErrRepoNotExist(err) {
+			ctx.ServerError("LoadRepo", err)
+			return
+		}
+	}
+	ctx.Data["Maintenances"] = maintenances
+	ctx.Data["Total"] = total
+	if onlyPushed {
+		ctx.Data["OnlyPushed"] = true
+	}
+
+	pager := context.NewPagination(int(total), setting.UI.Admin.RepoPagingNum, page, 5)
+	pager.AddParam(ctx, "pushed", "OnlyPushed")
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(http.StatusOK, tplMaintenance)
+}
+
 // UnadoptedRepos lists the unadopted repositories
 func UnadoptedRepos(ctx *context.Context) {
 	ctx.Data["Title"] = ctx.Tr("admin.repositories")
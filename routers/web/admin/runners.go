@@ -0,0 +1,41 @@
+// Copyright 2022 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package admin
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/setting"
+	shared "code.gitea.io/gitea/routers/web/shared/actions"
+)
+
+const (
+	tplRunners base.TplName = "admin/actions/runners"
+)
+
+// Runners shows all runners of the instance
+func Runners(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("actions.runners")
+	ctx.Data["PageIsAdmin"] = true
+	ctx.Data["PageIsAdminRunners"] = true
+
+	shared.SetRunnersContext(ctx, 0, 0)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.HTML(http.StatusOK, tplRunners)
+}
+
+// ResetRunnerRegistrationToken resets the registration token of the global runners
+func ResetRunnerRegistrationToken(ctx *context.Context) {
+	shared.RunnerResetRegistrationToken(ctx, 0, 0, setting.AppSubURL+"/admin/runners")
+}
+
+// DeleteRunner deletes a runner
+func DeleteRunner(ctx *context.Context) {
+	shared.RunnerDeletePost(ctx, 0, 0, setting.AppSubURL+"/admin/runners")
+}
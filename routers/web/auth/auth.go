@@ -158,6 +158,11 @@ func SignIn(ctx *context.Context) {
 	ctx.Data["PageIsSignIn"] = true
 	ctx.Data["PageIsLogin"] = true
 	ctx.Data["EnableSSPI"] = auth.IsSSPIEnabled()
+	ctx.Data["SAMLSources"], err = auth.ActiveSources(auth.SAML)
+	if err != nil {
+		ctx.ServerError("UserSignIn", err)
+		return
+	}
 
 	if setting.Service.EnableCaptcha && setting.Service.RequireCaptchaForLogin {
 		context.SetCaptchaData(ctx)
@@ -182,6 +187,11 @@ func SignInPost(ctx *context.Context) {
 	ctx.Data["PageIsSignIn"] = true
 	ctx.Data["PageIsLogin"] = true
 	ctx.Data["EnableSSPI"] = auth.IsSSPIEnabled()
+	ctx.Data["SAMLSources"], err = auth.ActiveSources(auth.SAML)
+	if err != nil {
+		ctx.ServerError("UserSignIn", err)
+		return
+	}
 
 	if ctx.HasError() {
 		ctx.HTML(http.StatusOK, tplSignIn)
@@ -0,0 +1,329 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	gocontext "context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+
+	"code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/services/auth/source/oauth2"
+	"code.gitea.io/gitea/services/forms"
+)
+
+const (
+	tplDeviceVerification base.TplName = "user/auth/device"
+
+	// deviceCodeGrantType is the grant type of the device authorization grant
+	// https://datatracker.ietf.org/doc/html/rfc8628#section-3.4
+	deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+)
+
+// DeviceAuthorizationResponse represents a successful device authorization response
+// https://datatracker.ietf.org/doc/html/rfc8628#section-3.2
+type DeviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval"`
+}
+
+func deviceVerificationURI() string {
+	return setting.AppURL + "login/device"
+}
+
+// deviceClientAddress returns the address of the client without the port
+func deviceClientAddress(ctx *context.Context) string {
+	host, _, err := net.SplitHostPort(ctx.RemoteAddr())
+	if err != nil {
+		return ctx.RemoteAddr()
+	}
+	return host
+}
+
+// isDeviceCodeRateLimited checks if the application has issued too many device codes in the last minute.
+// Public clients are identified by their client id only, so the limit is applied per client address to
+// keep others from using up the codes of an application. The limit of the application is only a backstop.
+func isDeviceCodeRateLimited(ctx *context.Context, app *auth.OAuth2Application, clientAddress string) (bool, error) {
+	since := timeutil.TimeStampNow().Add(-60)
+	for _, limit := range []struct {
+		clientAddress string
+		max           int64
+	}{
+		{clientAddress, setting.OAuth2.DeviceCodeRateLimit},
+		{"", setting.OAuth2.DeviceCodeAppRateLimit},
+	} {
+		if limit.max <= 0 {
+			continue
+		}
+		count, err := auth.CountOAuth2DeviceCodesSince(ctx, app.ID, limit.clientAddress, since)
+		if err != nil {
+			return false, err
+		}
+		if count >= limit.max {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// authenticateDeviceClient loads the application and checks the client secret of confidential clients.
+// Public clients, like most CLI tools, can't keep a secret and are identified by their client id only.
+func authenticateDeviceClient(ctx *context.Context, clientID, clientSecret string) *auth.OAuth2Application {
+	if clientID == "" || clientSecret == "" {
+		if username, password, ok := ctx.Req.BasicAuth(); ok {
+			if clientID == "" {
+				clientID = username
+			}
+			if clientSecret == "" {
+				clientSecret = password
+			}
+		}
+	}
+
+	app, err := auth.GetOAuth2ApplicationByClientID(ctx, clientID)
+	if err != nil {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidClient,
+			ErrorDescription: fmt.Sprintf("cannot load client with client id: %q", clientID),
+		})
+		return nil
+	}
+	if app.ConfidentialClient && !app.ValidateClientSecret([]byte(clientSecret)) {
+		errorDescription := "invalid client secret"
+		if clientSecret == "" {
+			errorDescription = "invalid empty client secret"
+		}
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidClient,
+			ErrorDescription: errorDescription,
+		})
+		return nil
+	}
+	return app
+}
+
+// DeviceAuthorizationOAuth issues a device code and a user code to a device
+func DeviceAuthorizationOAuth(ctx *context.Context) {
+	form := web.GetForm(ctx).(*forms.DeviceAuthorizationForm)
+
+	app := authenticateDeviceClient(ctx, form.ClientID, form.ClientSecret)
+	if app == nil {
+		return
+	}
+
+	clientAddress := deviceClientAddress(ctx)
+	limited, err := isDeviceCodeRateLimited(ctx, app, clientAddress)
+	if err != nil {
+		ctx.ServerError("CountOAuth2DeviceCodesSince", err)
+		return
+	}
+	if limited {
+		ctx.JSON(http.StatusTooManyRequests, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeSlowDown,
+			ErrorDescription: "too many device codes have been requested, try again later",
+		})
+		return
+	}
+
+	code, err := auth.CreateOAuth2DeviceCode(ctx, app, form.Scope, clientAddress)
+	if err != nil {
+		log.Error("CreateOAuth2DeviceCode: %v", err)
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+			ErrorDescription: "cannot create device code",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, &DeviceAuthorizationResponse{
+		DeviceCode:              code.DeviceCode,
+		UserCode:                code.FormattedUserCode(),
+		VerificationURI:         deviceVerificationURI(),
+		VerificationURIComplete: deviceVerificationURI() + "?user_code=" + url.QueryEscape(code.FormattedUserCode()),
+		ExpiresIn:               setting.OAuth2.DeviceCodeExpirationTime,
+		Interval:                code.Interval,
+	})
+}
+
+// DeviceVerification shows the page to enter a user code and to confirm the device authorization request
+func DeviceVerification(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("auth.device_title")
+
+	userCode := ctx.FormString("user_code")
+	if userCode == "" {
+		ctx.HTML(http.StatusOK, tplDeviceVerification)
+		return
+	}
+
+	code, err := auth.GetOAuth2DeviceCodeByUserCode(ctx, userCode)
+	if err != nil {
+		if auth.IsErrOAuthDeviceCodeNotFound(err) {
+			ctx.Data["UserCode"] = userCode
+			ctx.RenderWithErr(ctx.Tr("auth.device_code_invalid"), tplDeviceVerification, nil)
+			return
+		}
+		ctx.ServerError("GetOAuth2DeviceCodeByUserCode", err)
+		return
+	}
+	if err := code.LoadApplication(ctx); err != nil {
+		ctx.ServerError("LoadApplication", err)
+		return
+	}
+
+	ctx.Data["DeviceCode"] = code
+	ctx.Data["Application"] = code.Application
+	ctx.HTML(http.StatusOK, tplDeviceVerification)
+}
+
+// DeviceVerificationPost approves or denies a device authorization request
+func DeviceVerificationPost(ctx *context.Context) {
+	form := web.GetForm(ctx).(*forms.DeviceVerificationForm)
+
+	code, err := auth.GetOAuth2DeviceCodeByUserCode(ctx, form.UserCode)
+	if err != nil {
+		if auth.IsErrOAuthDeviceCodeNotFound(err) {
+			ctx.Flash.Error(ctx.Tr("auth.device_code_invalid"))
+			ctx.Redirect(setting.AppSubURL + "/login/device")
+			return
+		}
+		ctx.ServerError("GetOAuth2DeviceCodeByUserCode", err)
+		return
+	}
+
+	if !form.Approve {
+		if err := code.Deny(ctx, ctx.Doer.ID); err != nil && !auth.IsErrOAuthDeviceCodeNotFound(err) {
+			ctx.ServerError("Deny", err)
+			return
+		}
+		ctx.Flash.Info(ctx.Tr("auth.device_denied"))
+		ctx.Redirect(setting.AppSubURL + "/login/device")
+		return
+	}
+
+	if err := code.LoadApplication(ctx); err != nil {
+		ctx.ServerError("LoadApplication", err)
+		return
+	}
+	// the grant is only changed if the code is still pending, so a denied or used code can't widen it
+	err = db.WithTx(ctx, func(dbCtx gocontext.Context) error {
+		if err := code.Approve(dbCtx, ctx.Doer.ID); err != nil {
+			return err
+		}
+		grant, err := code.Application.GetGrantByUserID(dbCtx, ctx.Doer.ID)
+		if err != nil {
+			return err
+		}
+		if grant == nil {
+			_, err = code.Application.CreateGrant(dbCtx, ctx.Doer.ID, code.Scope)
+			return err
+		}
+		return grant.AddScope(dbCtx, code.Scope)
+	})
+	if err != nil {
+		if auth.IsErrOAuthDeviceCodeNotFound(err) {
+			ctx.Flash.Error(ctx.Tr("auth.device_code_invalid"))
+			ctx.Redirect(setting.AppSubURL + "/login/device")
+			return
+		}
+		ctx.ServerError("Approve", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("auth.device_approved", code.Application.Name))
+	ctx.Redirect(setting.AppSubURL + "/login/device")
+}
+
+func handleDeviceCode(ctx *context.Context, form forms.AccessTokenForm, serverKey, clientKey oauth2.JWTSigningKey) {
+	app := authenticateDeviceClient(ctx, form.ClientID, form.ClientSecret)
+	if app == nil {
+		return
+	}
+
+	code, err := auth.GetOAuth2DeviceCodeByDeviceCode(ctx, form.DeviceCode)
+	if err != nil || code.ApplicationID != app.ID {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidGrant,
+			ErrorDescription: "invalid device code",
+		})
+		return
+	}
+	if code.IsExpired() {
+		if _, err := code.Invalidate(ctx); err != nil {
+			log.Error("Unable to remove expired device code: %v", err)
+		}
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeExpiredToken,
+			ErrorDescription: "device code expired",
+		})
+		return
+	}
+
+	switch code.Status {
+	case auth.OAuth2DeviceCodeStatusPending:
+		// "A variant of "authorization_pending", the authorization request is still pending and polling should continue,
+		// but the interval MUST be increased by 5 seconds for this and all subsequent requests."
+		// https://datatracker.ietf.org/doc/html/rfc8628#section-3.5
+		tooFast, err := code.Poll(ctx)
+		if err != nil {
+			log.Error("Unable to update device code: %v", err)
+		}
+		if tooFast {
+			handleAccessTokenError(ctx, AccessTokenError{
+				ErrorCode:        AccessTokenErrorCodeSlowDown,
+				ErrorDescription: fmt.Sprintf("polling too fast, the interval is now %d seconds", code.Interval),
+			})
+			return
+		}
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeAuthorizationPending,
+			ErrorDescription: "the user has not yet completed the authorization",
+		})
+		return
+	case auth.OAuth2DeviceCodeStatusDenied:
+		if _, err := code.Invalidate(ctx); err != nil {
+			log.Error("Unable to remove denied device code: %v", err)
+		}
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeAccessDenied,
+			ErrorDescription: "the user denied the authorization request",
+		})
+		return
+	}
+
+	// remove the device code from database to deny duplicate usage
+	if deleted, err := code.Invalidate(ctx); err != nil || !deleted {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidGrant,
+			ErrorDescription: "invalid device code",
+		})
+		return
+	}
+	grant, err := app.GetGrantByUserID(ctx, code.UserID)
+	if err != nil || grant == nil {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidGrant,
+			ErrorDescription: "grant does not exist",
+		})
+		return
+	}
+	resp, tokenErr := newAccessTokenResponse(ctx, grant, serverKey, clientKey)
+	if tokenErr != nil {
+		handleAccessTokenError(ctx, *tokenErr)
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,164 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models/auth"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/web/middleware"
+	"code.gitea.io/gitea/services/auth/source/saml"
+)
+
+func getActiveSAMLSource(ctx *context.Context) (*auth.Source, *saml.Source) {
+	source, err := auth.GetActiveSourceByName(ctx.Params(":provider"), auth.SAML)
+	if err != nil {
+		if auth.IsErrSourceNotExist(err) {
+			ctx.NotFound("GetActiveSourceByName", err)
+		} else {
+			ctx.ServerError("GetActiveSourceByName", err)
+		}
+		return nil, nil
+	}
+	return source, source.Cfg.(*saml.Source)
+}
+
+const samlRequestCookieName = "saml_request"
+
+// setSAMLRequestCookie binds the authentication request to the browser, the response of the identity provider is posted
+// across sites so the cookie has to be sent with cross-site requests, which browsers only allow for secure cookies
+func setSAMLRequestCookie(ctx *context.Context, requestID string, maxAge int) {
+	middleware.SetCookie(ctx.Resp, samlRequestCookieName, requestID,
+		maxAge,
+		setting.AppSubURL+"/user/saml/",
+		"",
+		saml.IsSecureAppURL(),
+		true,
+		middleware.SameSite(http.SameSiteNoneMode))
+}
+
+// SignInSAML sends the user to the identity provider of a SAML source
+func SignInSAML(ctx *context.Context) {
+	_, cfg := getActiveSAMLSource(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	// the page which has sent the user to sign in is remembered by the cookie
+	redirectTo := ctx.FormString("redirect_to")
+	if len(redirectTo) == 0 {
+		redirectTo = ctx.GetCookie("redirect_to")
+	}
+	if !saml.IsSecureAppURL() {
+		log.Warn("SAML sign in via %s will fail, the ROOT_URL %s doesn't use https so the browser doesn't send the request cookie back", ctx.Params(":provider"), setting.AppURL)
+	}
+	redirectURL, requestID, err := cfg.SignInURL(redirectTo)
+	if err != nil {
+		ctx.ServerError("SignInURL", err)
+		return
+	}
+	setSAMLRequestCookie(ctx, requestID, 10*60)
+	ctx.Redirect(redirectURL)
+}
+
+// SignInSAMLCallback is the assertion consumer service which the identity provider posts its response to
+func SignInSAMLCallback(ctx *context.Context) {
+	source, cfg := getActiveSAMLSource(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	requestID := ctx.GetCookie(samlRequestCookieName)
+	setSAMLRequestCookie(ctx, "", -1)
+	u, redirectTo, err := cfg.Authenticate(ctx.FormString("SAMLResponse"), ctx.FormString("RelayState"), requestID)
+	if err != nil {
+		if user_model.IsErrUserProhibitLogin(err) {
+			log.Info("Failed authentication attempt for %s from %s: %v", err.(user_model.ErrUserProhibitLogin).Name, ctx.RemoteAddr(), err)
+			ctx.Data["Title"] = ctx.Tr("auth.prohibit_login")
+			ctx.HTML(http.StatusOK, "user/auth/prohibit_login")
+			return
+		}
+		log.Info("Failed SAML authentication attempt via %s from %s: %v", source.Name, ctx.RemoteAddr(), err)
+		if user_model.IsErrUserNotExist(err) {
+			ctx.Flash.Error(ctx.Tr("auth.saml.signin.error.user_not_exist"))
+		} else {
+			ctx.Flash.Error(ctx.Tr("auth.saml.signin.error"))
+		}
+		ctx.Redirect(setting.AppSubURL + "/user/login")
+		return
+	}
+
+	// First of all if the source can skip local two fa we're done
+	if cfg.IsSkipLocalTwoFA() {
+		handleSAMLSignIn(ctx, u, redirectTo)
+		return
+	}
+
+	hasTOTPtwofa, err := auth.HasTwoFactorByUID(u.ID)
+	if err != nil {
+		ctx.ServerError("UserSignIn", err)
+		return
+	}
+	hasWebAuthnTwofa, err := auth.HasWebAuthnRegistrationsByUID(u.ID)
+	if err != nil {
+		ctx.ServerError("UserSignIn", err)
+		return
+	}
+	if !hasTOTPtwofa && !hasWebAuthnTwofa {
+		handleSAMLSignIn(ctx, u, redirectTo)
+		return
+	}
+
+	updates := map[string]interface{}{
+		// User will need to use 2FA TOTP or WebAuthn, save data
+		"twofaUid":      u.ID,
+		"twofaRemember": false,
+	}
+	if hasTOTPtwofa {
+		updates["totpEnrolled"] = u.ID
+	}
+	if err := updateSession(ctx, nil, updates); err != nil {
+		ctx.ServerError("UserSignIn: Unable to update session", err)
+		return
+	}
+	// the second factor is checked by another request, it picks the redirect up from the cookie
+	if len(redirectTo) > 0 {
+		middleware.SetRedirectToCookie(ctx.Resp, redirectTo)
+	}
+
+	if hasWebAuthnTwofa {
+		ctx.Redirect(setting.AppSubURL + "/user/webauthn")
+		return
+	}
+	ctx.Redirect(setting.AppSubURL + "/user/two_factor")
+}
+
+func handleSAMLSignIn(ctx *context.Context, u *user_model.User, redirectTo string) {
+	redirect := handleSignInFull(ctx, u, false, false)
+	if ctx.Written() {
+		return
+	}
+	ctx.RedirectToFirst(redirectTo, redirect)
+}
+
+// SAMLMetadata serves the metadata of the service provider which the identity provider is configured with
+func SAMLMetadata(ctx *context.Context) {
+	_, cfg := getActiveSAMLSource(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	metadata, err := cfg.Metadata()
+	if err != nil {
+		ctx.ServerError("Metadata", err)
+		return
+	}
+	ctx.Resp.Header().Set("Content-Type", "application/samlmetadata+xml")
+	ctx.Resp.WriteHeader(http.StatusOK)
+	_, _ = ctx.Resp.Write(metadata)
+}
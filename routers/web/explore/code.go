@@ -27,6 +27,7 @@ func Code(ctx *context.Context) {
 
 	ctx.Data["UsersIsDisabled"] = setting.Service.Explore.DisableUsersPage
 	ctx.Data["IsRepoIndexerEnabled"] = setting.Indexer.RepoIndexerEnabled
+	ctx.Data["IsCommitIndexerEnabled"] = setting.Indexer.CommitIndexerEnabled
 	ctx.Data["Title"] = ctx.Tr("explore")
 	ctx.Data["PageIsExplore"] = true
 	ctx.Data["PageIsExploreCode"] = true
@@ -36,6 +37,7 @@ func Code(ctx *context.Context) {
 
 	queryType := ctx.FormTrim("t")
 	isMatch := queryType == "match"
+	isRegexp := queryType == "regexp"
 
 	ctx.Data["Keyword"] = keyword
 	ctx.Data["Language"] = language
@@ -47,6 +49,14 @@ func Code(ctx *context.Context) {
 		return
 	}
 
+	if isRegexp {
+		if _, err := code_indexer.CompileRegexp(keyword); err != nil {
+			ctx.Data["SearchError"] = err.Error()
+			ctx.HTML(http.StatusOK, tplExploreCode)
+			return
+		}
+	}
+
 	page := ctx.FormInt("page")
 	if page <= 0 {
 		page = 1
@@ -77,7 +87,15 @@ func Code(ctx *context.Context) {
 	)
 
 	if (len(repoIDs) > 0) || isAdmin {
-		total, searchResults, searchResultLanguages, err = code_indexer.PerformSearch(ctx, repoIDs, language, keyword, page, setting.UI.RepoSearchPagingNum, isMatch)
+		total, searchResults, searchResultLanguages, err = code_indexer.PerformSearch(ctx, &code_indexer.SearchOptions{
+			RepoIDs:  repoIDs,
+			Keyword:  keyword,
+			Language: language,
+			IsMatch:  isMatch,
+			IsRegexp: isRegexp,
+			Page:     page,
+			PageSize: setting.UI.RepoSearchPagingNum,
+		})
 		if err != nil {
 			if code_indexer.IsAvailable() {
 				ctx.ServerError("SearchResults", err)
@@ -0,0 +1,106 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package explore
+
+import (
+	"net/http"
+
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/context"
+	commits_indexer "code.gitea.io/gitea/modules/indexer/commits"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+const (
+	// tplExploreCommits explore commits page template
+	tplExploreCommits base.TplName = "explore/commits"
+)
+
+// Commits render explore commits page, it searches the indexed commits of the repositories whose code the doer can read
+func Commits(ctx *context.Context) {
+	if !setting.Indexer.CommitIndexerEnabled {
+		ctx.Redirect(setting.AppSubURL + "/explore")
+		return
+	}
+
+	ctx.Data["UsersIsDisabled"] = setting.Service.Explore.DisableUsersPage
+	ctx.Data["IsRepoIndexerEnabled"] = setting.Indexer.RepoIndexerEnabled
+	ctx.Data["IsCommitIndexerEnabled"] = setting.Indexer.CommitIndexerEnabled
+	ctx.Data["Title"] = ctx.Tr("explore")
+	ctx.Data["PageIsExplore"] = true
+	ctx.Data["PageIsExploreCommits"] = true
+
+	keyword := ctx.FormTrim("q")
+	ctx.Data["Keyword"] = keyword
+	if keyword == "" {
+		ctx.HTML(http.StatusOK, tplExploreCommits)
+		return
+	}
+
+	opts, ok := commits_indexer.ParseSearchOptions(keyword)
+	if !ok {
+		ctx.Data["SearchError"] = true
+		ctx.HTML(http.StatusOK, tplExploreCommits)
+		return
+	}
+
+	page := ctx.FormInt("page")
+	if page <= 0 {
+		page = 1
+	}
+
+	// guest user or non-admin user
+	if ctx.Doer == nil || !ctx.Doer.IsAdmin {
+		repoIDs, err := repo_model.FindUserCodeAccessibleRepoIDs(ctx, ctx.Doer)
+		if err != nil {
+			ctx.ServerError("FindUserCodeAccessibleRepoIDs", err)
+			return
+		}
+		if len(repoIDs) == 0 {
+			ctx.HTML(http.StatusOK, tplExploreCommits)
+			return
+		}
+		opts.RepoIDs = repoIDs
+	}
+	opts.Page = page
+	opts.PageSize = setting.UI.RepoSearchPagingNum
+
+	total, commits, err := commits_indexer.Search(ctx, opts)
+	if err != nil {
+		if commits_indexer.IsAvailable() {
+			ctx.ServerError("Search", err)
+			return
+		}
+		ctx.Data["CommitIndexerUnavailable"] = true
+	}
+
+	repoIDs := make(container.Set[int64], len(commits))
+	for _, commit := range commits {
+		repoIDs.Add(commit.RepoID)
+	}
+	repoMaps, err := repo_model.GetRepositoriesMapByIDs(repoIDs.Values())
+	if err != nil {
+		ctx.ServerError("GetRepositoriesMapByIDs", err)
+		return
+	}
+
+	// the index may still contain the commits of deleted repositories
+	results := make([]*commits_indexer.CommitData, 0, len(commits))
+	for _, commit := range commits {
+		if _, ok := repoMaps[commit.RepoID]; ok {
+			results = append(results, commit)
+		}
+	}
+
+	ctx.Data["Commits"] = results
+	ctx.Data["RepoMaps"] = repoMaps
+
+	pager := context.NewPagination(int(total), setting.UI.RepoSearchPagingNum, page, 5)
+	pager.SetDefaultParams(ctx)
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(http.StatusOK, tplExploreCommits)
+}
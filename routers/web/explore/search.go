@@ -0,0 +1,55 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package explore
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/setting"
+	search_service "code.gitea.io/gitea/services/search"
+)
+
+const (
+	// tplExploreSearch explore global search page template
+	tplExploreSearch base.TplName = "explore/global_search"
+	// globalSearchPageSize is the number of results of every kind on the global search page
+	globalSearchPageSize = 5
+)
+
+// Search render the global search page, it shows the first results of every kind which the doer can see
+func Search(ctx *context.Context) {
+	ctx.Data["UsersIsDisabled"] = setting.Service.Explore.DisableUsersPage
+	ctx.Data["IsRepoIndexerEnabled"] = setting.Indexer.RepoIndexerEnabled
+	ctx.Data["IsCommitIndexerEnabled"] = setting.Indexer.CommitIndexerEnabled
+	ctx.Data["IsPackageEnabled"] = setting.Packages.Enabled
+	ctx.Data["Title"] = ctx.Tr("explore.search")
+	ctx.Data["PageIsExplore"] = true
+	ctx.Data["PageIsExploreSearch"] = true
+
+	keyword := ctx.FormTrim("q")
+	ctx.Data["Keyword"] = keyword
+	if keyword == "" {
+		ctx.HTML(http.StatusOK, tplExploreSearch)
+		return
+	}
+
+	results, err := search_service.Search(ctx, ctx.Doer, &search_service.Options{
+		Keyword:  keyword,
+		PageSize: globalSearchPageSize,
+	})
+	if err != nil {
+		ctx.ServerError("Search", err)
+		return
+	}
+
+	ctx.Data["Results"] = results
+	// the lists of the explore pages are reused
+	ctx.Data["Repos"] = results.Repos
+	ctx.Data["SearchResults"] = results.Code
+	ctx.Data["RepoMaps"] = results.CodeRepos
+
+	ctx.HTML(http.StatusOK, tplExploreSearch)
+}
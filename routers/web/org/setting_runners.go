@@ -0,0 +1,40 @@
+// Copyright 2022 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package org
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	shared "code.gitea.io/gitea/routers/web/shared/actions"
+)
+
+const (
+	tplSettingsRunners base.TplName = "org/settings/runners"
+)
+
+// Runners renders the runners of the organization
+func Runners(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("actions.runners")
+	ctx.Data["PageIsOrgSettings"] = true
+	ctx.Data["PageIsOrgSettingsRunners"] = true
+
+	shared.SetRunnersContext(ctx, ctx.Org.Organization.ID, 0)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.HTML(http.StatusOK, tplSettingsRunners)
+}
+
+// ResetRunnerRegistrationToken resets the registration token of the organization
+func ResetRunnerRegistrationToken(ctx *context.Context) {
+	shared.RunnerResetRegistrationToken(ctx, ctx.Org.Organization.ID, 0, ctx.Org.OrgLink+"/settings/runners")
+}
+
+// DeleteRunner deletes a runner of the organization
+func DeleteRunner(ctx *context.Context) {
+	shared.RunnerDeletePost(ctx, ctx.Org.Organization.ID, 0, ctx.Org.OrgLink+"/settings/runners")
+}
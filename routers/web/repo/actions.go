@@ -0,0 +1,91 @@
+// Copyright 2022 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/httpcache"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/util"
+	shared "code.gitea.io/gitea/routers/web/shared/actions"
+	actions_service "code.gitea.io/gitea/services/actions"
+)
+
+const (
+	tplSettingsRunners base.TplName = "repo/settings/runners"
+)
+
+// Runners renders the runners of the repository
+func Runners(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("actions.runners")
+	ctx.Data["PageIsSettingsRunners"] = true
+
+	shared.SetRunnersContext(ctx, 0, ctx.Repo.Repository.ID)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.HTML(http.StatusOK, tplSettingsRunners)
+}
+
+// ResetRunnerRegistrationToken resets the registration token of the repository
+func ResetRunnerRegistrationToken(ctx *context.Context) {
+	shared.RunnerResetRegistrationToken(ctx, 0, ctx.Repo.Repository.ID, ctx.Repo.RepoLink+"/settings/runners")
+}
+
+// DeleteRunner deletes a runner of the repository
+func DeleteRunner(ctx *context.Context) {
+	shared.RunnerDeletePost(ctx, 0, ctx.Repo.Repository.ID, ctx.Repo.RepoLink+"/settings/runners")
+}
+
+// ActionJobLog serves the log of a job as plain text
+func ActionJobLog(ctx *context.Context) {
+	run, err := actions_model.GetRunByIndex(ctx, ctx.Repo.Repository.ID, ctx.ParamsInt64("index"))
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			ctx.NotFound("GetRunByIndex", err)
+		} else {
+			ctx.ServerError("GetRunByIndex", err)
+		}
+		return
+	}
+	job, err := actions_model.GetRunJobByID(ctx, ctx.ParamsInt64("jobid"))
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			ctx.NotFound("GetRunJobByID", err)
+		} else {
+			ctx.ServerError("GetRunJobByID", err)
+		}
+		return
+	}
+	if job.RunID != run.ID {
+		ctx.NotFound("ActionJobLog", nil)
+		return
+	}
+
+	f, err := actions_service.OpenJobLog(job)
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			ctx.PlainText(http.StatusOK, ctx.Tr("actions.runs.job_log_empty"))
+		} else {
+			ctx.ServerError("OpenJobLog", err)
+		}
+		return
+	}
+	defer f.Close()
+
+	// the log gets replaced while the job is running, so it must not be cached
+	ctx.Resp.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	ctx.Resp.Header().Set("X-Content-Type-Options", "nosniff")
+	httpcache.AddCacheControlToHeader(ctx.Resp.Header(), 0)
+	if _, err := io.Copy(ctx.Resp, f); err != nil {
+		log.Error("Unable to serve log of job %d: %v", job.ID, err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 
+	auth_model "code.gitea.io/gitea/models/auth"
 	access_model "code.gitea.io/gitea/models/perm/access"
 	repo_model "code.gitea.io/gitea/models/repo"
 	"code.gitea.io/gitea/modules/context"
@@ -104,8 +105,10 @@ func GetAttachment(ctx *context.Context) {
 		return
 	}
 
+	token, _ := ctx.Data["ApiToken"].(*auth_model.AccessToken)
 	if repository == nil { // If not linked
-		if !(ctx.IsSigned && attach.UploaderID == ctx.Doer.ID) { // We block if not the uploader
+		// We block if not the uploader, fine-grained tokens only grant access to repositories
+		if !(ctx.IsSigned && attach.UploaderID == ctx.Doer.ID) || (token != nil && token.IsFineGrained()) {
 			ctx.Error(http.StatusNotFound)
 			return
 		}
@@ -115,6 +118,7 @@ func GetAttachment(ctx *context.Context) {
 			ctx.Error(http.StatusInternalServerError, "GetUserRepoPermission", err.Error())
 			return
 		}
+		perm.RestrictByAccessToken(repository, token)
 		if !perm.CanRead(unitType) {
 			ctx.Error(http.StatusNotFound)
 			return
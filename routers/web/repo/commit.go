@@ -20,6 +20,7 @@ import (
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/gitgraph"
+	commits_indexer "code.gitea.io/gitea/modules/indexer/commits"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/services/gitdiff"
@@ -187,11 +188,25 @@ func SearchCommits(ctx *context.Context) {
 	}
 
 	all := ctx.FormBool("all")
-	opts := git.NewSearchCommitsOptions(query, all)
-	commits, err := ctx.Repo.Commit.SearchCommits(opts)
-	if err != nil {
-		ctx.ServerError("SearchCommits", err)
-		return
+	var (
+		commits []*git.Commit
+		indexed bool
+		err     error
+	)
+	if !all && ctx.Repo.IsViewBranch && ctx.Repo.BranchName == ctx.Repo.Repository.DefaultBranch {
+		commits, indexed, err = searchIndexedCommits(ctx, query)
+		if err != nil {
+			ctx.ServerError("searchIndexedCommits", err)
+			return
+		}
+	}
+	if !indexed {
+		opts := git.NewSearchCommitsOptions(query, all)
+		commits, err = ctx.Repo.Commit.SearchCommits(opts)
+		if err != nil {
+			ctx.ServerError("SearchCommits", err)
+			return
+		}
 	}
 	ctx.Data["CommitCount"] = len(commits)
 	ctx.Data["Commits"] = git_model.ConvertFromGitCommit(ctx, commits, ctx.Repo.Repository)
@@ -206,6 +221,47 @@ func SearchCommits(ctx *context.Context) {
 	ctx.HTML(http.StatusOK, tplCommits)
 }
 
+// searchIndexedCommits searches the commits of the default branch by the commit indexer like git would do,
+// false is returned if the commits of the repository haven't been indexed or the query can't be handled by the indexer
+func searchIndexedCommits(ctx *context.Context, query string) ([]*git.Commit, bool, error) {
+	if !setting.Indexer.CommitIndexerEnabled || !commits_indexer.IsAvailable() {
+		return nil, false, nil
+	}
+	if indexed, err := commits_indexer.IsIndexed(ctx, ctx.Repo.Repository); err != nil || !indexed {
+		return nil, false, err
+	}
+	opts, ok := commits_indexer.ParseSearchOptions(query)
+	if !ok {
+		return nil, false, nil
+	}
+	for _, keyword := range opts.Keywords {
+		if git.IsValidSHAPattern(keyword) {
+			// git looks up the keywords which could be commit ids
+			return nil, false, nil
+		}
+	}
+	opts.RepoIDs = []int64{ctx.Repo.Repository.ID}
+	opts.Page = 1
+	opts.PageSize = 100 // like the git search
+
+	_, results, err := commits_indexer.Search(ctx, opts)
+	if err != nil {
+		return nil, false, err
+	}
+	commits := make([]*git.Commit, 0, len(results))
+	for _, result := range results {
+		commit, err := ctx.Repo.GitRepo.GetCommit(result.CommitID)
+		if git.IsErrNotExist(err) {
+			// the index is updated asynchronously after the history has been rewritten
+			continue
+		} else if err != nil {
+			return nil, false, err
+		}
+		commits = append(commits, commit)
+	}
+	return commits, true, nil
+}
+
 // FileHistory show a file's reversions
 func FileHistory(ctx *context.Context) {
 	ctx.Data["IsRepoToolbarCommits"] = true
@@ -283,7 +339,7 @@ func Diff(ctx *context.Context) {
 		}
 		return
 	}
-	if len(commitID) != git.SHAFullLength {
+	if !git.IsFullCommitID(commitID) {
 		commitID = commit.ID.String()
 	}
 
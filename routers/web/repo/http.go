@@ -18,6 +18,7 @@ import (
 	"sync"
 	"time"
 
+	actions_model "code.gitea.io/gitea/models/actions"
 	"code.gitea.io/gitea/models/auth"
 	"code.gitea.io/gitea/models/perm"
 	access_model "code.gitea.io/gitea/models/perm/access"
@@ -28,6 +29,7 @@ import (
 	"code.gitea.io/gitea/modules/log"
 	repo_module "code.gitea.io/gitea/modules/repository"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/util"
 	repo_service "code.gitea.io/gitea/services/repository"
@@ -153,6 +155,15 @@ func httpBase(ctx *context.Context) (h *serviceHandler) {
 		askAuth = askAuth || (repo.Owner.Visibility != structs.VisibleTypePublic)
 	}
 
+	// the token of an actions job can only clone the repository the job runs for
+	if job, ok := ctx.Data["ActionsJob"].(*actions_model.ActionRunJob); ok {
+		if !repoExist || isWiki || !isPull || job.RepoID != repo.ID {
+			ctx.PlainText(http.StatusForbidden, "The token of an actions job can only clone its repository.")
+			return
+		}
+		askAuth = false
+	}
+
 	// check access
 	if askAuth {
 		// rely on the results of Contexter
@@ -180,15 +191,19 @@ func httpBase(ctx *context.Context) (h *serviceHandler) {
 			return
 		}
 
+		token, _ := ctx.Data["ApiToken"].(*auth.AccessToken)
+
 		if repoExist {
 			p, err := access_model.GetUserRepoPermission(ctx, repo, ctx.Doer)
 			if err != nil {
 				ctx.ServerError("GetUserRepoPermission", err)
 				return
 			}
+			p.RestrictByAccessToken(repo, token)
 
 			// Because of special ref "refs/for" .. , need delay write permission check
-			if git.SupportProcReceive {
+			// The hooks only know the user, so the write permission of a fine-grained token has to be checked now
+			if git.SupportProcReceive && (token == nil || !token.IsFineGrained()) {
 				accessMode = perm.AccessModeRead
 			}
 
@@ -233,6 +248,11 @@ func httpBase(ctx *context.Context) (h *serviceHandler) {
 			return
 		}
 
+		if token, ok := ctx.Data["ApiToken"].(*auth.AccessToken); ok && token.IsFineGrained() {
+			ctx.PlainText(http.StatusForbidden, "A fine-grained access token cannot create repositories.")
+			return
+		}
+
 		if owner.IsOrganization() && !setting.Repository.EnablePushCreateOrg {
 			ctx.PlainText(http.StatusForbidden, "Push to create is not enabled for organizations.")
 			return
@@ -274,9 +294,10 @@ func httpBase(ctx *context.Context) (h *serviceHandler) {
 	w := ctx.Resp
 	r := ctx.Req
 	cfg := &serviceConfig{
-		UploadPack:  true,
-		ReceivePack: true,
-		Env:         environ,
+		UploadPack:     true,
+		ReceivePack:    true,
+		Env:            environ,
+		ServiceOptions: repo_service.GitServiceOptions(ctx, repo, isWiki),
 	}
 
 	r.URL.Path = strings.ToLower(r.URL.Path) // blue: In case some repo name has upper case name
@@ -286,7 +307,7 @@ func httpBase(ctx *context.Context) (h *serviceHandler) {
 		dir = repo_model.RepoPath(username, wikiRepoName)
 	}
 
-	return &serviceHandler{cfg, w, r, dir, cfg.Env}
+	return &serviceHandler{cfg, w, r, dir, cfg.Env, repo}
 }
 
 var (
@@ -308,7 +329,7 @@ func dummyInfoRefs(ctx *context.Context) {
 			}
 		}()
 
-		if err := git.InitRepository(ctx, tmpDir, true); err != nil {
+		if err := git.InitRepository(ctx, tmpDir, true, git.ObjectFormatSHA1); err != nil {
 			log.Error("Failed to init bare repo for git-receive-pack cache: %v", err)
 			return
 		}
@@ -332,9 +353,10 @@ func dummyInfoRefs(ctx *context.Context) {
 }
 
 type serviceConfig struct {
-	UploadPack  bool
-	ReceivePack bool
-	Env         []string
+	UploadPack     bool
+	ReceivePack    bool
+	Env            []string
+	ServiceOptions git.ServiceOptions
 }
 
 type serviceHandler struct {
@@ -343,6 +365,7 @@ type serviceHandler struct {
 	r       *http.Request
 	dir     string
 	environ []string
+	repo    *repo_model.Repository
 }
 
 func (h *serviceHandler) setHeaderNoCache() {
@@ -465,12 +488,12 @@ func serviceRPC(ctx gocontext.Context, h serviceHandler, service string) {
 	// set this for allow pre-receive and post-receive execute
 	h.environ = append(h.environ, "SSH_ORIGINAL_COMMAND="+service)
 
-	if protocol := h.r.Header.Get("Git-Protocol"); protocol != "" && safeGitProtocolHeader.MatchString(protocol) {
+	if protocol := h.cfg.ServiceOptions.GitProtocol(h.r.Header.Get("Git-Protocol")); protocol != "" && safeGitProtocolHeader.MatchString(protocol) {
 		h.environ = append(h.environ, "GIT_PROTOCOL="+protocol)
 	}
 
 	var stderr bytes.Buffer
-	cmd := git.NewCommand(h.r.Context(), git.CmdArgCheck(service), "--stateless-rpc").AddDynamicArguments(h.dir)
+	cmd := git.NewCommand(h.r.Context(), h.cfg.ServiceOptions.ConfigArgs()...).AddArguments(git.CmdArgCheck(service), "--stateless-rpc").AddDynamicArguments(h.dir)
 	cmd.SetDescription(fmt.Sprintf("%s %s %s [repo_path: %s]", git.GitExecutable, service, "--stateless-rpc", h.dir))
 	if err := cmd.Run(&git.RunOpts{
 		Dir:               h.dir,
@@ -537,12 +560,12 @@ func GetInfoRefs(ctx *context.Context) {
 	if hasAccess(ctx, getServiceType(h.r), *h, false) {
 		service := getServiceType(h.r)
 
-		if protocol := h.r.Header.Get("Git-Protocol"); protocol != "" && safeGitProtocolHeader.MatchString(protocol) {
+		if protocol := h.cfg.ServiceOptions.GitProtocol(h.r.Header.Get("Git-Protocol")); protocol != "" && safeGitProtocolHeader.MatchString(protocol) {
 			h.environ = append(h.environ, "GIT_PROTOCOL="+protocol)
 		}
 		h.environ = append(os.Environ(), h.environ...)
 
-		refs, _, err := git.NewCommand(ctx, git.CmdArgCheck(service), "--stateless-rpc", "--advertise-refs", ".").RunStdBytes(&git.RunOpts{Env: h.environ, Dir: h.dir})
+		refs, _, err := git.NewCommand(ctx, h.cfg.ServiceOptions.ConfigArgs()...).AddArguments(git.CmdArgCheck(service), "--stateless-rpc", "--advertise-refs", ".").RunStdBytes(&git.RunOpts{Env: h.environ, Dir: h.dir})
 		if err != nil {
 			log.Error(fmt.Sprintf("%v - %s", err, string(refs)))
 		}
@@ -602,6 +625,48 @@ func GetPackFile(ctx *context.Context) {
 	}
 }
 
+// GetCloneBundle serves the pre-generated bundle of the repository which clones could bootstrap from
+func GetCloneBundle(ctx *context.Context) {
+	h := httpBase(ctx)
+	if h == nil {
+		return
+	}
+	if h.cfg.ServiceOptions.BundleURI == "" {
+		ctx.NotFound("GetCloneBundle", nil)
+		return
+	}
+	bundle, err := repo_model.GetRepoBundle(ctx, h.repo.ID)
+	if err != nil {
+		ctx.ServerError("GetRepoBundle", err)
+		return
+	} else if bundle == nil {
+		ctx.NotFound("GetCloneBundle", nil)
+		return
+	}
+
+	downloadName := h.repo.Name + ".bundle"
+	if setting.RepoBundle.ServeDirect {
+		// If we have a signed url (S3, object storage), redirect to this directly.
+		u, err := storage.RepoBundles.URL(bundle.RelativePath(), downloadName)
+		if u != nil && err == nil {
+			ctx.Redirect(u.String())
+			return
+		}
+	}
+
+	fr, err := storage.RepoBundles.Open(bundle.RelativePath())
+	if err != nil {
+		ctx.ServerError("Open", err)
+		return
+	}
+	defer fr.Close()
+
+	ctx.ServeContent(fr, &context.ServeHeaderOptions{
+		Filename:     downloadName,
+		LastModified: bundle.GeneratedUnix.AsLocalTime(),
+	})
+}
+
 // GetIdxFile implements Git dumb HTTP
 func GetIdxFile(ctx *context.Context) {
 	h := httpBase(ctx)
@@ -180,8 +180,22 @@ func issues(ctx *context.Context, milestoneID, projectID int64, isPullOption uti
 		keyword = ""
 	}
 
+	page := ctx.FormInt("page")
+	if page <= 1 {
+		page = 1
+	}
+
+	var mileIDs []int64
+	if milestoneID > 0 {
+		mileIDs = []int64{milestoneID}
+	}
+
+	// mentions, review requests and projects are not stored in the issue indexer,
+	// all other conditions are executed by it so the counts match the paginated results
+	useIndexer := len(keyword) > 0 && mentionedID == 0 && reviewRequestedID == 0 && projectID == 0
+
 	var issueIDs []int64
-	if len(keyword) > 0 {
+	if len(keyword) > 0 && !useIndexer {
 		issueIDs, err = issue_indexer.SearchIssuesByKeyword(ctx, []int64{repo.ID}, keyword)
 		if err != nil {
 			if issue_indexer.IsAvailable() {
@@ -195,8 +209,59 @@ func issues(ctx *context.Context, milestoneID, projectID int64, isPullOption uti
 		}
 	}
 
-	var issueStats *issues_model.IssueStats
-	if forceEmpty {
+	isShowClosed := ctx.FormString("state") == "closed"
+
+	var (
+		issueStats   *issues_model.IssueStats
+		searchResult *issue_indexer.SearchResult
+	)
+	if useIndexer {
+		opts := &issue_indexer.SearchOptions{
+			RepoIDs:  []int64{repo.ID},
+			IsPull:   isPullOption,
+			IsClosed: util.OptionalBoolOf(isShowClosed),
+			SortBy:   issue_indexer.ParseSortBy(sortType, true),
+			Facets:   true,
+			Limit:    setting.UI.IssuePagingNum,
+			Start:    (page - 1) * setting.UI.IssuePagingNum,
+		}
+		if assigneeID > 0 {
+			opts.AssigneeID = assigneeID
+		}
+		if posterID > 0 {
+			opts.PosterID = posterID
+		}
+		opts.MilestoneIDs = mileIDs
+		for _, labelID := range labelIDs {
+			if labelID > 0 {
+				opts.IncludedLabelIDs = append(opts.IncludedLabelIDs, []int64{labelID})
+			} else {
+				opts.ExcludedLabelIDs = append(opts.ExcludedLabelIDs, -labelID)
+			}
+		}
+
+		searchResult, err = issue_indexer.SearchIssuesByQuery(ctx, ctx.Doer, keyword, opts)
+		// if open issues are zero and close don't, use closed as default
+		if err == nil && len(ctx.FormString("state")) == 0 && opts.IsClosed.IsFalse() &&
+			searchResult.Facets.OpenCount == 0 && searchResult.Facets.ClosedCount != 0 {
+			opts.IsClosed = util.OptionalBoolTrue
+			searchResult, err = issue_indexer.SearchIssues(ctx, opts)
+		}
+		if err != nil {
+			if !issue_indexer.IsAvailable() {
+				ctx.Data["IssueIndexerUnavailable"] = true
+				searchResult = &issue_indexer.SearchResult{Facets: &issue_indexer.Facets{}}
+			} else {
+				ctx.ServerError("issueIndexer.Search", err)
+				return
+			}
+		}
+		isShowClosed = opts.IsClosed.IsTrue()
+		issueStats = &issues_model.IssueStats{
+			OpenCount:   searchResult.Facets.OpenCount,
+			ClosedCount: searchResult.Facets.ClosedCount,
+		}
+	} else if forceEmpty {
 		issueStats = &issues_model.IssueStats{}
 	} else {
 		issueStats, err = issues_model.GetIssueStats(&issues_model.IssueStatsOptions{
@@ -216,32 +281,33 @@ func issues(ctx *context.Context, milestoneID, projectID int64, isPullOption uti
 		}
 	}
 
-	isShowClosed := ctx.FormString("state") == "closed"
 	// if open issues are zero and close don't, use closed as default
-	if len(ctx.FormString("state")) == 0 && issueStats.OpenCount == 0 && issueStats.ClosedCount != 0 {
+	if !useIndexer && len(ctx.FormString("state")) == 0 && issueStats.OpenCount == 0 && issueStats.ClosedCount != 0 {
 		isShowClosed = true
 	}
 
-	page := ctx.FormInt("page")
-	if page <= 1 {
-		page = 1
-	}
-
 	var total int
-	if !isShowClosed {
+	if useIndexer {
+		total = int(searchResult.Total)
+	} else if !isShowClosed {
 		total = int(issueStats.OpenCount)
 	} else {
 		total = int(issueStats.ClosedCount)
 	}
 	pager := context.NewPagination(total, setting.UI.IssuePagingNum, page, 5)
 
-	var mileIDs []int64
-	if milestoneID > 0 {
-		mileIDs = []int64{milestoneID}
-	}
-
 	var issues []*issues_model.Issue
-	if forceEmpty {
+	if useIndexer {
+		ids := make([]int64, 0, len(searchResult.Hits))
+		for _, hit := range searchResult.Hits {
+			ids = append(ids, hit.ID)
+		}
+		issues, err = issues_model.GetIssuesWithAttributesByIDs(ctx, ids)
+		if err != nil {
+			ctx.ServerError("GetIssuesWithAttributesByIDs", err)
+			return
+		}
+	} else if forceEmpty {
 		issues = []*issues_model.Issue{}
 	} else {
 		issues, err = issues_model.Issues(ctx, &issues_model.IssuesOptions{
@@ -2322,8 +2388,12 @@ func SearchIssues(ctx *context.Context) {
 	if strings.IndexByte(keyword, 0) >= 0 {
 		keyword = ""
 	}
+	// mentions and review requests are not stored in the issue indexer,
+	// otherwise the query and the filters are executed by it
+	useIndexer := len(keyword) > 0 && !ctx.FormBool("mentioned") && !ctx.FormBool("review_requested")
+
 	var issueIDs []int64
-	if len(keyword) > 0 && len(repoIDs) > 0 {
+	if len(keyword) > 0 && len(repoIDs) > 0 && !useIndexer {
 		if issueIDs, err = issue_indexer.SearchIssuesByKeyword(ctx, repoIDs, keyword); err != nil {
 			ctx.Error(http.StatusInternalServerError, "SearchIssuesByKeyword", err.Error())
 			return
@@ -2361,9 +2431,34 @@ func SearchIssues(ctx *context.Context) {
 		limit = setting.API.MaxResponseItems
 	}
 
-	// Only fetch the issues if we either don't have a keyword or the search returned issues
-	// This would otherwise return all issues if no issues were found by the search.
-	if len(keyword) == 0 || len(issueIDs) > 0 || len(includedLabelNames) > 0 || len(includedMilestones) > 0 {
+	if useIndexer {
+		page := ctx.FormInt("page")
+		if page <= 0 {
+			page = 1
+		}
+		searchOpts := &issue_indexer.SearchOptions{
+			RepoIDs:       repoIDs,
+			IsClosed:      isClosed,
+			IsPull:        isPull,
+			UpdatedAfter:  timeutil.TimeStamp(since),
+			UpdatedBefore: timeutil.TimeStamp(before),
+			SortBy:        issue_indexer.SortByScore,
+			Limit:         limit,
+			Start:         (page - 1) * limit,
+		}
+		if ctx.IsSigned && ctx.FormBool("created") {
+			searchOpts.PosterID = ctx.Doer.ID
+		}
+		if ctx.IsSigned && ctx.FormBool("assigned") {
+			searchOpts.AssigneeID = ctx.Doer.ID
+		}
+		if issues, filteredCount, err = issue_service.SearchIssues(ctx, ctx.Doer, keyword, includedLabelNames, includedMilestones, searchOpts); err != nil {
+			ctx.Error(http.StatusInternalServerError, "SearchIssues", err.Error())
+			return
+		}
+	} else if len(keyword) == 0 || len(issueIDs) > 0 || len(includedLabelNames) > 0 || len(includedMilestones) > 0 {
+		// Only fetch the issues if we either don't have a keyword or the search returned issues
+		// This would otherwise return all issues if no issues were found by the search.
 		issuesOpt := &issues_model.IssuesOptions{
 			ListOptions: db.ListOptions{
 				Page:     ctx.FormInt("page"),
@@ -5,6 +5,7 @@ package repo
 
 import (
 	"net/http"
+	"strconv"
 
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/models/packages"
@@ -17,6 +18,9 @@ import (
 
 const (
 	tplPackagesList base.TplName = "repo/packages"
+
+	// packageSourceBuilt lists the package versions built from the repository instead of the linked packages
+	packageSourceBuilt = "built"
 )
 
 // Packages displays a list of all packages in the repository
@@ -27,20 +31,34 @@ func Packages(ctx *context.Context) {
 	}
 	query := ctx.FormTrim("q")
 	packageType := ctx.FormTrim("type")
+	source := ctx.FormTrim("source")
 
-	pvs, total, err := packages.SearchLatestVersions(ctx, &packages.PackageSearchOptions{
+	opts := &packages.PackageSearchOptions{
 		Paginator: &db.ListOptions{
 			PageSize: setting.UI.PackagesPagingNum,
 			Page:     page,
 		},
 		OwnerID:    ctx.ContextUser.ID,
-		RepoID:     ctx.Repo.Repository.ID,
 		Type:       packages.Type(packageType),
 		Name:       packages.SearchValue{Value: query},
 		IsInternal: util.OptionalBoolFalse,
-	})
+	}
+
+	var pvs []*packages.PackageVersion
+	var total int64
+	var err error
+	if source == packageSourceBuilt {
+		// Every version is listed because each one can be built from a different commit
+		opts.Properties = map[string]string{
+			packages.PropertyProvenanceRepository: strconv.FormatInt(ctx.Repo.Repository.ID, 10),
+		}
+		pvs, total, err = packages.SearchVersions(ctx, opts)
+	} else {
+		opts.RepoID = ctx.Repo.Repository.ID
+		pvs, total, err = packages.SearchLatestVersions(ctx, opts)
+	}
 	if err != nil {
-		ctx.ServerError("SearchLatestVersions", err)
+		ctx.ServerError("SearchVersions", err)
 		return
 	}
 
@@ -55,12 +73,21 @@ func Packages(ctx *context.Context) {
 		ctx.ServerError("HasRepositoryPackages", err)
 		return
 	}
+	if !hasPackages {
+		hasPackages, err = packages.HasRepositoryProvenance(ctx, ctx.Repo.Repository.ID)
+		if err != nil {
+			ctx.ServerError("HasRepositoryProvenance", err)
+			return
+		}
+	}
 
 	ctx.Data["Title"] = ctx.Tr("packages.title")
 	ctx.Data["IsPackagesPage"] = true
 	ctx.Data["ContextUser"] = ctx.ContextUser
 	ctx.Data["Query"] = query
 	ctx.Data["PackageType"] = packageType
+	ctx.Data["PackageSource"] = source
+	ctx.Data["IsBuiltFromRepository"] = source == packageSourceBuilt
 	ctx.Data["AvailableTypes"] = packages.TypeList
 	ctx.Data["HasPackages"] = hasPackages
 	if ctx.Repo != nil {
@@ -73,6 +100,7 @@ func Packages(ctx *context.Context) {
 	pager := context.NewPagination(int(total), setting.UI.PackagesPagingNum, page, 5)
 	pager.AddParam(ctx, "q", "Query")
 	pager.AddParam(ctx, "type", "PackageType")
+	pager.AddParam(ctx, "source", "PackageSource")
 	ctx.Data["Page"] = pager
 
 	ctx.HTML(http.StatusOK, tplPackagesList)
@@ -4,15 +4,47 @@
 package repo
 
 import (
+	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 
+	repo_model "code.gitea.io/gitea/models/repo"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/git"
 	code_indexer "code.gitea.io/gitea/modules/indexer/code"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
 )
 
-const tplSearch base.TplName = "repo/search"
+const (
+	tplSearch       base.TplName = "repo/search"
+	tplSearchSymbol base.TplName = "repo/search_symbol"
+)
+
+// searchRef is a branch or tag which is indexed in addition to the default branch
+type searchRef struct {
+	Ref   string
+	Name  string
+	IsTag bool
+}
+
+func getIndexedSearchRefs(ctx *context.Context) ([]*searchRef, error) {
+	statuses, err := repo_model.GetIndexerRefStatuses(ctx, ctx.Repo.Repository.ID, repo_model.RepoIndexerTypeCode)
+	if err != nil {
+		return nil, err
+	}
+	indexedRefs := make([]*searchRef, 0, len(statuses))
+	for _, status := range statuses {
+		indexedRefs = append(indexedRefs, &searchRef{
+			Ref:   status.Ref,
+			Name:  git.RefEndName(status.Ref),
+			IsTag: strings.HasPrefix(status.Ref, git.TagPrefix),
+		})
+	}
+	return indexedRefs, nil
+}
 
 // Search render repository search page
 func Search(ctx *context.Context) {
@@ -26,10 +58,21 @@ func Search(ctx *context.Context) {
 
 	queryType := ctx.FormTrim("t")
 	isMatch := queryType == "match"
+	isRegexp := queryType == "regexp"
+
+	indexedRefs, err := getIndexedSearchRefs(ctx)
+	if err != nil {
+		ctx.ServerError("GetIndexerRefStatuses", err)
+		return
+	}
+	ref := ctx.FormTrim("ref")
 
 	ctx.Data["Keyword"] = keyword
 	ctx.Data["Language"] = language
 	ctx.Data["queryType"] = queryType
+	ctx.Data["Ref"] = ref
+	ctx.Data["RefName"] = git.RefEndName(ref)
+	ctx.Data["IndexedRefs"] = indexedRefs
 	ctx.Data["PageIsViewCode"] = true
 
 	if keyword == "" {
@@ -37,13 +80,29 @@ func Search(ctx *context.Context) {
 		return
 	}
 
+	if isRegexp {
+		if _, err := code_indexer.CompileRegexp(keyword); err != nil {
+			ctx.Data["SearchError"] = err.Error()
+			ctx.HTML(http.StatusOK, tplSearch)
+			return
+		}
+	}
+
 	page := ctx.FormInt("page")
 	if page <= 0 {
 		page = 1
 	}
 
-	total, searchResults, searchResultLanguages, err := code_indexer.PerformSearch(ctx, []int64{ctx.Repo.Repository.ID},
-		language, keyword, page, setting.UI.RepoSearchPagingNum, isMatch)
+	total, searchResults, searchResultLanguages, err := code_indexer.PerformSearch(ctx, &code_indexer.SearchOptions{
+		RepoIDs:  []int64{ctx.Repo.Repository.ID},
+		Ref:      ref,
+		Keyword:  keyword,
+		Language: language,
+		IsMatch:  isMatch,
+		IsRegexp: isRegexp,
+		Page:     page,
+		PageSize: setting.UI.RepoSearchPagingNum,
+	})
 	if err != nil {
 		if code_indexer.IsAvailable() {
 			ctx.ServerError("SearchResults", err)
@@ -61,7 +120,93 @@ func Search(ctx *context.Context) {
 	pager := context.NewPagination(total, setting.UI.RepoSearchPagingNum, page, 5)
 	pager.SetDefaultParams(ctx)
 	pager.AddParam(ctx, "l", "Language")
+	pager.AddParam(ctx, "ref", "Ref")
 	ctx.Data["Page"] = pager
 
 	ctx.HTML(http.StatusOK, tplSearch)
 }
+
+// SearchSymbol render the definitions or the references of a symbol, a single definition is shown directly
+func SearchSymbol(ctx *context.Context) {
+	if !setting.Indexer.RepoIndexerEnabled {
+		ctx.NotFound("SearchSymbol", nil)
+		return
+	}
+
+	name := ctx.FormTrim("q")
+	isReferences := ctx.FormString("type") == "references"
+
+	// the definitions are searched on the default branch if the ref of the viewed file is not indexed
+	indexedRefs, err := getIndexedSearchRefs(ctx)
+	if err != nil {
+		ctx.ServerError("GetIndexerRefStatuses", err)
+		return
+	}
+	ref := ""
+	for _, indexedRef := range indexedRefs {
+		if indexedRef.Ref == ctx.FormTrim("ref") {
+			ref = indexedRef.Ref
+		}
+	}
+
+	ctx.Data["Keyword"] = name
+	ctx.Data["IsReferences"] = isReferences
+	ctx.Data["Ref"] = ref
+	ctx.Data["RefName"] = git.RefEndName(ref)
+	ctx.Data["PageIsViewCode"] = true
+
+	if name == "" {
+		ctx.HTML(http.StatusOK, tplSearchSymbol)
+		return
+	}
+
+	page := ctx.FormInt("page")
+	if page <= 0 {
+		page = 1
+	}
+
+	opts := &code_indexer.SearchOptions{
+		RepoIDs:  []int64{ctx.Repo.Repository.ID},
+		Ref:      ref,
+		Keyword:  name,
+		Page:     page,
+		PageSize: setting.UI.RepoSearchPagingNum,
+	}
+	var (
+		total   int
+		results []*code_indexer.SymbolResult
+	)
+	if isReferences {
+		total, results, err = code_indexer.FindReferences(ctx, opts)
+	} else {
+		total, results, err = code_indexer.FindDefinitions(ctx, opts)
+	}
+	if err != nil {
+		if code_indexer.IsAvailable() {
+			ctx.ServerError("SearchSymbol", err)
+			return
+		}
+		ctx.Data["CodeIndexerUnavailable"] = true
+	} else {
+		ctx.Data["CodeIndexerUnavailable"] = !code_indexer.IsAvailable()
+	}
+
+	if !isReferences && total == 1 && len(results) == 1 && len(results[0].Lines) == 1 {
+		ctx.Redirect(fmt.Sprintf("%s/src/commit/%s/%s#L%d", ctx.Repo.RepoLink, url.PathEscape(results[0].CommitID),
+			util.PathEscapeSegments(results[0].Filename), results[0].Lines[0].Number))
+		return
+	}
+
+	ctx.Data["SourcePath"] = ctx.Repo.Repository.HTMLURL()
+	ctx.Data["SymbolResults"] = results
+
+	pager := context.NewPagination(total, setting.UI.RepoSearchPagingNum, page, 5)
+	pager.AddParam(ctx, "q", "Keyword")
+	pager.AddParam(ctx, "ref", "Ref")
+	if isReferences {
+		pager.AddParamString("type", "references")
+	}
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(http.StatusOK, tplSearchSymbol)
+}
@@ -73,6 +73,18 @@ func SettingsCtxData(ctx *context.Context) {
 	ctx.Data["SigningKeyAvailable"] = len(signing) > 0
 	ctx.Data["SigningSettings"] = setting.Repository.Signing
 	ctx.Data["CodeIndexerEnabled"] = setting.Indexer.RepoIndexerEnabled
+	ctx.Data["GitDisablePartialClone"] = setting.Git.DisablePartialClone
+	ctx.Data["GitDisableProtocolV2"] = setting.Git.DisableProtocolV2
+
+	if setting.Indexer.RepoIndexerEnabled {
+		statuses, err := repo_model.GetIndexerRefStatuses(ctx, ctx.Repo.Repository.ID, repo_model.RepoIndexerTypeCode)
+		if err != nil {
+			ctx.ServerError("GetIndexerRefStatuses", err)
+			return
+		}
+		ctx.Data["CodeIndexerRefStatuses"] = statuses
+		ctx.Data["CodeIndexerMaxRefs"] = setting.Indexer.MaxIndexerRefs
+	}
 
 	if ctx.Doer.IsAdmin {
 		if setting.Indexer.RepoIndexerEnabled {
@@ -184,7 +196,7 @@ func SettingsPost(ctx *context.Context) {
 		}
 
 		repo.IsPrivate = form.Private
-		if err := repo_service.UpdateRepository(repo, visibilityChanged); err != nil {
+		if err := repo_service.UpdateRepository(ctx, ctx.Doer, repo, visibilityChanged); err != nil {
 			ctx.ServerError("UpdateRepository", err)
 			return
 		}
@@ -523,7 +535,7 @@ func SettingsPost(ctx *context.Context) {
 			return
 		}
 		if repoChanged {
-			if err := repo_service.UpdateRepository(repo, false); err != nil {
+			if err := repo_service.UpdateRepository(ctx, ctx.Doer, repo, false); err != nil {
 				ctx.ServerError("UpdateRepository", err)
 				return
 			}
@@ -542,7 +554,7 @@ func SettingsPost(ctx *context.Context) {
 		}
 
 		if changed {
-			if err := repo_service.UpdateRepository(repo, false); err != nil {
+			if err := repo_service.UpdateRepository(ctx, ctx.Doer, repo, false); err != nil {
 				ctx.ServerError("UpdateRepository", err)
 				return
 			}
@@ -552,6 +564,46 @@ func SettingsPost(ctx *context.Context) {
 		ctx.Flash.Success(ctx.Tr("repo.settings.update_settings_success"))
 		ctx.Redirect(ctx.Repo.RepoLink + "/settings")
 
+	case "code_indexer":
+		if !setting.Indexer.RepoIndexerEnabled {
+			ctx.NotFound("", nil)
+			return
+		}
+
+		for _, patterns := range []string{form.CodeIndexerBranches, form.CodeIndexerTags} {
+			if err := code.ValidateRefPatterns(patterns); err != nil {
+				ctx.Flash.Error(ctx.Tr("repo.settings.code_indexer.invalid_pattern", err.Error()))
+				ctx.Redirect(ctx.Repo.RepoLink + "/settings")
+				return
+			}
+		}
+
+		repo.CodeIndexerBranches = strings.TrimSpace(form.CodeIndexerBranches)
+		repo.CodeIndexerTags = strings.TrimSpace(form.CodeIndexerTags)
+		if err := repo_model.UpdateRepositoryCols(ctx, repo, "code_indexer_branches", "code_indexer_tags"); err != nil {
+			ctx.ServerError("UpdateRepositoryCols", err)
+			return
+		}
+		code.UpdateRepoIndexer(repo)
+
+		log.Trace("Repository code search settings updated: %s/%s", ctx.Repo.Owner.Name, repo.Name)
+
+		ctx.Flash.Success(ctx.Tr("repo.settings.update_settings_success"))
+		ctx.Redirect(ctx.Repo.RepoLink + "/settings")
+
+	case "clone":
+		repo.DisablePartialClone = !form.EnablePartialClone
+		repo.DisableProtocolV2 = !form.EnableProtocolV2
+		if err := repo_model.UpdateRepositoryCols(ctx, repo, "disable_partial_clone", "disable_protocol_v2"); err != nil {
+			ctx.ServerError("UpdateRepositoryCols", err)
+			return
+		}
+
+		log.Trace("Repository clone settings updated: %s/%s", ctx.Repo.Owner.Name, repo.Name)
+
+		ctx.Flash.Success(ctx.Tr("repo.settings.update_settings_success"))
+		ctx.Redirect(ctx.Repo.RepoLink + "/settings")
+
 	case "admin":
 		if !ctx.Doer.IsAdmin {
 			ctx.Error(http.StatusForbidden)
@@ -562,7 +614,7 @@ func SettingsPost(ctx *context.Context) {
 			repo.IsFsckEnabled = form.EnableHealthCheck
 		}
 
-		if err := repo_service.UpdateRepository(repo, false); err != nil {
+		if err := repo_service.UpdateRepository(ctx, ctx.Doer, repo, false); err != nil {
 			ctx.ServerError("UpdateRepository", err)
 			return
 		}
@@ -938,7 +990,7 @@ func CollaborationPost(ctx *context.Context) {
 		}
 	}
 
-	if err = repo_module.AddCollaborator(ctx, ctx.Repo.Repository, u); err != nil {
+	if err = repo_service.AddCollaborator(ctx, ctx.Doer, ctx.Repo.Repository, u); err != nil {
 		ctx.ServerError("AddCollaborator", err)
 		return
 	}
@@ -953,10 +1005,16 @@ func CollaborationPost(ctx *context.Context) {
 
 // ChangeCollaborationAccessMode response for changing access of a collaboration
 func ChangeCollaborationAccessMode(ctx *context.Context) {
-	if err := repo_model.ChangeCollaborationAccessMode(
+	u, err := user_model.GetUserByID(ctx, ctx.FormInt64("uid"))
+	if err != nil {
+		log.Error("GetUserByID: %v", err)
+		return
+	}
+	if err := repo_service.ChangeCollaborationAccessMode(
 		ctx,
+		ctx.Doer,
 		ctx.Repo.Repository,
-		ctx.FormInt64("uid"),
+		u,
 		perm.AccessMode(ctx.FormInt("mode"))); err != nil {
 		log.Error("ChangeCollaborationAccessMode: %v", err)
 	}
@@ -964,7 +1022,9 @@ func ChangeCollaborationAccessMode(ctx *context.Context) {
 
 // DeleteCollaboration delete a collaboration for a repository
 func DeleteCollaboration(ctx *context.Context) {
-	if err := models.DeleteCollaboration(ctx.Repo.Repository, ctx.FormInt64("id")); err != nil {
+	if u, err := user_model.GetUserByID(ctx, ctx.FormInt64("id")); err != nil {
+		ctx.Flash.Error("GetUserByID: " + err.Error())
+	} else if err := repo_service.DeleteCollaboration(ctx, ctx.Doer, ctx.Repo.Repository, u); err != nil {
 		ctx.Flash.Error("DeleteCollaboration: " + err.Error())
 	} else {
 		ctx.Flash.Success(ctx.Tr("repo.settings.remove_collaborator_success"))
@@ -1175,7 +1235,7 @@ func DeployKeysPost(ctx *context.Context) {
 		return
 	}
 
-	key, err := asymkey_model.AddDeployKey(ctx.Repo.Repository.ID, form.Title, content, !form.IsWritable)
+	key, err := asymkey_service.AddDeployKey(ctx, ctx.Doer, ctx.Repo.Repository, form.Title, content, !form.IsWritable)
 	if err != nil {
 		ctx.Data["HasError"] = true
 		switch {
@@ -1218,7 +1278,8 @@ func DeleteSecret(ctx *context.Context) {
 
 // DeleteDeployKey response for deleting a deploy key
 func DeleteDeployKey(ctx *context.Context) {
-	if err := asymkey_service.DeleteDeployKey(ctx.Doer, ctx.FormInt64("id")); err != nil {
+	id := ctx.FormInt64("id")
+	if err := asymkey_service.DeleteDeployKey(ctx, ctx.Doer, id); err != nil {
 		ctx.Flash.Error("DeleteDeployKey: " + err.Error())
 	} else {
 		ctx.Flash.Success(ctx.Tr("repo.settings.deploy_key_deletion_success"))
@@ -0,0 +1,84 @@
+// Copyright 2022 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"errors"
+	"net/http"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// SetRunnersContext loads the runners and the registration token of the given scope.
+// Owner and repository id are both 0 for the runners of the instance.
+func SetRunnersContext(ctx *context.Context, ownerID, repoID int64) {
+	runners, err := actions_model.FindRunners(ctx, actions_model.FindRunnerOptions{
+		OwnerID: ownerID,
+		RepoID:  repoID,
+	})
+	if err != nil {
+		ctx.ServerError("FindRunners", err)
+		return
+	}
+	for _, runner := range runners {
+		if err := runner.LoadAttributes(ctx); err != nil {
+			ctx.ServerError("LoadAttributes", err)
+			return
+		}
+	}
+
+	token, err := actions_model.GetOrCreateRunnerToken(ctx, ownerID, repoID)
+	if err != nil {
+		ctx.ServerError("GetOrCreateRunnerToken", err)
+		return
+	}
+
+	ctx.Data["Runners"] = runners
+	ctx.Data["RegistrationToken"] = token.Token
+	ctx.Data["RunnerRegisterURL"] = setting.AppURL + "api/actions/runner/register"
+}
+
+// RunnerResetRegistrationToken replaces the registration token of the given scope
+func RunnerResetRegistrationToken(ctx *context.Context, ownerID, repoID int64, redirectTo string) {
+	if _, err := actions_model.NewRunnerToken(ctx, ownerID, repoID); err != nil {
+		ctx.ServerError("NewRunnerToken", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("actions.runners.reset_registration_token_success"))
+	ctx.Redirect(redirectTo)
+}
+
+// RunnerDeletePost deletes a runner of the given scope
+func RunnerDeletePost(ctx *context.Context, ownerID, repoID int64, redirectTo string) {
+	runner, err := actions_model.GetRunnerByID(ctx, ctx.FormInt64("id"))
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			ctx.NotFound("GetRunnerByID", err)
+		} else {
+			ctx.ServerError("GetRunnerByID", err)
+		}
+		return
+	}
+	// the instance admin is allowed to delete every runner
+	if (ownerID != 0 || repoID != 0) && (runner.OwnerID != ownerID || runner.RepoID != repoID) {
+		ctx.NotFound("RunnerDeletePost", nil)
+		return
+	}
+
+	if err := actions_model.DeleteRunner(ctx, runner.ID); err != nil {
+		log.Error("DeleteRunner %d: %v", runner.ID, err)
+		ctx.Flash.Error(ctx.Tr("actions.runners.delete_runner_failed"))
+	} else {
+		ctx.Flash.Success(ctx.Tr("actions.runners.delete_runner_success"))
+	}
+
+	ctx.JSON(http.StatusOK, map[string]interface{}{
+		"redirect": redirectTo,
+	})
+}
@@ -34,6 +34,7 @@ func CodeSearch(ctx *context.Context) {
 
 	queryType := ctx.FormTrim("t")
 	isMatch := queryType == "match"
+	isRegexp := queryType == "regexp"
 
 	ctx.Data["Keyword"] = keyword
 	ctx.Data["Language"] = language
@@ -45,6 +46,14 @@ func CodeSearch(ctx *context.Context) {
 		return
 	}
 
+	if isRegexp {
+		if _, err := code_indexer.CompileRegexp(keyword); err != nil {
+			ctx.Data["SearchError"] = err.Error()
+			ctx.HTML(http.StatusOK, tplUserCode)
+			return
+		}
+	}
+
 	var (
 		repoIDs []int64
 		err     error
@@ -68,7 +77,15 @@ func CodeSearch(ctx *context.Context) {
 	)
 
 	if len(repoIDs) > 0 {
-		total, searchResults, searchResultLanguages, err = code_indexer.PerformSearch(ctx, repoIDs, language, keyword, page, setting.UI.RepoSearchPagingNum, isMatch)
+		total, searchResults, searchResultLanguages, err = code_indexer.PerformSearch(ctx, &code_indexer.SearchOptions{
+			RepoIDs:  repoIDs,
+			Keyword:  keyword,
+			Language: language,
+			IsMatch:  isMatch,
+			IsRegexp: isRegexp,
+			Page:     page,
+			PageSize: setting.UI.RepoSearchPagingNum,
+		})
 		if err != nil {
 			if code_indexer.IsAvailable() {
 				ctx.ServerError("SearchResults", err)
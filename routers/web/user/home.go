@@ -22,6 +22,7 @@ import (
 	"code.gitea.io/gitea/models/unit"
 	user_model "code.gitea.io/gitea/models/user"
 	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/container"
 	"code.gitea.io/gitea/modules/context"
 	issue_indexer "code.gitea.io/gitea/modules/indexer/issues"
 	"code.gitea.io/gitea/modules/json"
@@ -435,9 +436,33 @@ func buildIssueOverview(ctx *context.Context, unitType unit.Type) {
 	keyword := strings.Trim(ctx.FormString("q"), " ")
 	ctx.Data["Keyword"] = keyword
 
+	// Mentions and review requests are not stored in the issue indexer, otherwise the query
+	// and the filters are executed by it so the counts match the paginated results.
+	useIndexer := len(keyword) > 0 && filterMode != issues_model.FilterModeMention && filterMode != issues_model.FilterModeReviewRequested
+	var (
+		searchRepoIDs []int64
+		err           error
+	)
+	if useIndexer {
+		// the repositories are collected without the filter mode, so the other filter modes can be counted
+		repoOpts := *opts
+		repoOpts.AssigneeID = 0
+		repoOpts.PosterID = 0
+		searchRepoIDs, err = issues_model.GetRepoIDsForIssuesOptions(&repoOpts, ctxUser)
+		if err != nil {
+			ctx.ServerError("GetRepoIDsForIssuesOptions", err)
+			return
+		}
+	}
+
 	// Execute keyword search for issues.
 	// USING NON-FINAL STATE OF opts FOR A QUERY.
-	issueIDsFromSearch, err := issueIDsFromSearch(ctx, ctxUser, keyword, opts)
+	searchKeyword := keyword
+	if useIndexer {
+		// only used for the counts of mentions and review requests
+		searchKeyword = issue_indexer.ParseQuery(keyword).Keyword
+	}
+	issueIDsFromSearch, err := issueIDsFromSearch(ctx, ctxUser, searchKeyword, opts)
 	if err != nil {
 		ctx.ServerError("issueIDsFromSearch", err)
 		return
@@ -448,7 +473,7 @@ func buildIssueOverview(ctx *context.Context, unitType unit.Type) {
 
 	if len(issueIDsFromSearch) > 0 {
 		opts.IssueIDs = issueIDsFromSearch
-	} else if len(keyword) > 0 {
+	} else if len(searchKeyword) > 0 {
 		forceEmpty = true
 	}
 
@@ -459,7 +484,7 @@ func buildIssueOverview(ctx *context.Context, unitType unit.Type) {
 	// Filter repos and count issues in them. Count will be used later.
 	// USING NON-FINAL STATE OF opts FOR A QUERY.
 	var issueCountByRepo map[int64]int64
-	if !forceEmpty {
+	if !forceEmpty && !useIndexer {
 		issueCountByRepo, err = issues_model.CountIssuesByRepo(ctx, opts)
 		if err != nil {
 			ctx.ServerError("CountIssuesByRepo", err)
@@ -501,8 +526,24 @@ func buildIssueOverview(ctx *context.Context, unitType unit.Type) {
 
 	// Slice of Issues that will be displayed on the overview page
 	// USING FINAL STATE OF opts FOR A QUERY.
-	var issues []*issues_model.Issue
-	if !forceEmpty {
+	var (
+		issues   []*issues_model.Issue
+		overview *indexerIssueOverview
+	)
+	if useIndexer {
+		overview, err = searchIssueOverview(ctx, keyword, filterMode, searchRepoIDs, repoIDs, opts)
+		if err != nil {
+			if issue_indexer.IsAvailable() {
+				ctx.ServerError("searchIssueOverview", err)
+				return
+			}
+			ctx.Data["IssueIndexerUnavailable"] = true
+			overview = &indexerIssueOverview{CountByRepo: map[int64]int64{}}
+		}
+		issues = overview.Issues
+		issueCountByRepo = overview.CountByRepo
+		isShowClosed = overview.IsShowClosed
+	} else if !forceEmpty {
 		issues, err = issues_model.Issues(ctx, opts)
 		if err != nil {
 			ctx.ServerError("Issues", err)
@@ -570,15 +611,24 @@ func buildIssueOverview(ctx *context.Context, unitType unit.Type) {
 	} else {
 		issueStats = &issues_model.IssueStats{}
 	}
+	if overview != nil {
+		issueStats.OpenCount = overview.OpenCount
+		issueStats.ClosedCount = overview.ClosedCount
+		issueStats.YourRepositoriesCount = overview.YourRepositoriesCount
+		issueStats.AssignCount = overview.AssignCount
+		issueStats.CreateCount = overview.CreateCount
+	}
 
 	// Will be posted to ctx.Data.
 	var shownIssues int
-	if !isShowClosed {
+	if overview != nil {
+		shownIssues = int(overview.Total)
+	} else if !isShowClosed {
 		shownIssues = int(issueStats.OpenCount)
 	} else {
 		shownIssues = int(issueStats.ClosedCount)
 	}
-	if len(repoIDs) != 0 {
+	if len(repoIDs) != 0 && overview == nil {
 		shownIssues = 0
 		for _, repoID := range repoIDs {
 			shownIssues += int(issueCountByRepo[repoID])
@@ -706,6 +756,132 @@ func issueIDsFromSearch(ctx *context.Context, ctxUser *user_model.User, keyword
 	return issueIDsFromSearch, nil
 }
 
+// indexerIssueOverview contains the issues and counts of the issue overview which are searched by the issue indexer
+type indexerIssueOverview struct {
+	Issues                []*issues_model.Issue
+	Total                 int64
+	IsShowClosed          bool
+	OpenCount             int64
+	ClosedCount           int64
+	CountByRepo           map[int64]int64
+	YourRepositoriesCount int64
+	AssignCount           int64
+	CreateCount           int64
+}
+
+// searchIssueOverview executes the query and the filters of the issue overview by the issue indexer.
+// searchRepoIDs are the repositories the doer can see issues of, selectedRepoIDs restrict the listed issues.
+func searchIssueOverview(ctx *context.Context, query string, filterMode int, searchRepoIDs, selectedRepoIDs []int64, opts *issues_model.IssuesOptions) (*indexerIssueOverview, error) {
+	overview := &indexerIssueOverview{
+		Issues:       []*issues_model.Issue{},
+		IsShowClosed: opts.IsClosed.IsTrue(),
+		CountByRepo:  map[int64]int64{},
+	}
+	if len(searchRepoIDs) == 0 {
+		return overview, nil
+	}
+
+	base := issue_indexer.SearchOptions{
+		RepoIDs:  searchRepoIDs,
+		IsPull:   opts.IsPull,
+		IsClosed: opts.IsClosed,
+		SortBy:   issue_indexer.ParseSortBy(opts.SortType, true),
+	}
+	for _, labelID := range opts.LabelIDs {
+		if labelID > 0 {
+			base.IncludedLabelIDs = append(base.IncludedLabelIDs, []int64{labelID})
+		} else {
+			base.ExcludedLabelIDs = append(base.ExcludedLabelIDs, -labelID)
+		}
+	}
+	ok, err := issue_indexer.ParseQuery(query).Apply(ctx, ctx.Doer, &base)
+	if err != nil || !ok {
+		return overview, err
+	}
+	overview.IsShowClosed = base.IsClosed.IsTrue()
+
+	count := func(o issue_indexer.SearchOptions) (int64, error) {
+		o.Limit = 0
+		res, err := issue_indexer.SearchIssues(ctx, &o)
+		if err != nil {
+			return 0, err
+		}
+		return res.Total, nil
+	}
+
+	listRepoIDs := searchRepoIDs
+	if len(selectedRepoIDs) > 0 {
+		visible := make(container.Set[int64])
+		visible.AddMultiple(searchRepoIDs...)
+		listRepoIDs = make([]int64, 0, len(selectedRepoIDs))
+		for _, repoID := range selectedRepoIDs {
+			if visible.Contains(repoID) {
+				listRepoIDs = append(listRepoIDs, repoID)
+			}
+		}
+		if len(listRepoIDs) == 0 {
+			return overview, nil
+		}
+	}
+
+	tabs := base
+	tabs.RepoIDs = listRepoIDs
+	if overview.YourRepositoriesCount, err = count(tabs); err != nil {
+		return nil, err
+	}
+	tabs.AssigneeID = ctx.Doer.ID
+	if overview.AssignCount, err = count(tabs); err != nil {
+		return nil, err
+	}
+	tabs.AssigneeID = base.AssigneeID
+	tabs.PosterID = ctx.Doer.ID
+	if overview.CreateCount, err = count(tabs); err != nil {
+		return nil, err
+	}
+
+	list := base
+	switch filterMode {
+	case issues_model.FilterModeAssign:
+		list.AssigneeID = ctx.Doer.ID
+	case issues_model.FilterModeCreate:
+		list.PosterID = ctx.Doer.ID
+	}
+	list.Facets = true
+
+	// the repositories are counted over all visible repositories
+	if len(selectedRepoIDs) > 0 {
+		res, err := issue_indexer.SearchIssues(ctx, &list)
+		if err != nil {
+			return nil, err
+		}
+		overview.CountByRepo = res.Facets.Repos
+		list.RepoIDs = listRepoIDs
+	}
+
+	list.Limit = opts.PageSize
+	list.Start = (opts.Page - 1) * opts.PageSize
+	res, err := issue_indexer.SearchIssues(ctx, &list)
+	if err != nil {
+		return nil, err
+	}
+	if len(selectedRepoIDs) == 0 {
+		overview.CountByRepo = res.Facets.Repos
+	}
+	overview.Total = res.Total
+	overview.OpenCount = res.Facets.OpenCount
+	overview.ClosedCount = res.Facets.ClosedCount
+
+	ids := make([]int64, 0, len(res.Hits))
+	for _, hit := range res.Hits {
+		ids = append(ids, hit.ID)
+	}
+	overview.Issues, err = issues_model.GetIssuesWithAttributesByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return overview, nil
+}
+
 func loadRepoByIDs(ctxUser *user_model.User, issueCountByRepo map[int64]int64, unitType unit.Type) (map[int64]*repo_model.Repository, error) {
 	totalRes := make(map[int64]*repo_model.Repository, len(issueCountByRepo))
 	repoIDs := make([]int64, 0, 500)
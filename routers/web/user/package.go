@@ -5,6 +5,7 @@ package user
 
 import (
 	"net/http"
+	"sort"
 
 	"code.gitea.io/gitea/models/db"
 	org_model "code.gitea.io/gitea/models/organization"
@@ -14,14 +15,21 @@ import (
 	access_model "code.gitea.io/gitea/models/perm/access"
 	repo_model "code.gitea.io/gitea/models/repo"
 	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/container"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
+	alpine_module "code.gitea.io/gitea/modules/packages/alpine"
+	conda_module "code.gitea.io/gitea/modules/packages/conda"
+	debian_module "code.gitea.io/gitea/modules/packages/debian"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/modules/web"
 	shared_user "code.gitea.io/gitea/routers/web/shared/user"
 	"code.gitea.io/gitea/services/forms"
 	packages_service "code.gitea.io/gitea/services/packages"
+	alpine_service "code.gitea.io/gitea/services/packages/alpine"
+	debian_service "code.gitea.io/gitea/services/packages/debian"
+	rpm_service "code.gitea.io/gitea/services/packages/rpm"
 )
 
 const (
@@ -163,6 +171,36 @@ func ViewPackageVersion(ctx *context.Context) {
 	ctx.Data["IsPackagesPage"] = true
 	ctx.Data["PackageDescriptor"] = pd
 
+	switch pd.Package.Type {
+	case packages_model.TypeAlpine:
+		// a version can be published to multiple branches and repositories
+		repositories := make(container.Set[string])
+		for _, pfd := range pd.Files {
+			repositories.Add(pfd.Properties.GetByName(alpine_module.PropertyBranch) + "/" + pfd.Properties.GetByName(alpine_module.PropertyRepository))
+		}
+		alpineRepositories := repositories.Values()
+		sort.Strings(alpineRepositories)
+		ctx.Data["AlpineRepositories"] = alpineRepositories
+	case packages_model.TypeConda:
+		// a version can be published to multiple channels
+		channels := make(container.Set[string])
+		for _, pfd := range pd.Files {
+			channels.Add(pfd.Properties.GetByName(conda_module.PropertyChannel))
+		}
+		condaChannels := channels.Values()
+		sort.Strings(condaChannels)
+		ctx.Data["CondaChannels"] = condaChannels
+	case packages_model.TypeDebian:
+		// a version can be published to multiple distributions and components
+		sources := make(container.Set[string])
+		for _, pfd := range pd.Files {
+			sources.Add(pfd.Properties.GetByName(debian_module.PropertyDistribution) + " " + pfd.Properties.GetByName(debian_module.PropertyComponent))
+		}
+		debianSources := sources.Values()
+		sort.Strings(debianSources)
+		ctx.Data["DebianSources"] = debianSources
+	}
+
 	var (
 		total int64
 		pvs   []*packages_model.PackageVersion
@@ -209,6 +247,17 @@ func ViewPackageVersion(ctx *context.Context) {
 	}
 	ctx.Data["HasRepositoryAccess"] = hasRepositoryAccess
 
+	hasProvenanceAccess := false
+	if pd.Provenance != nil && pd.Provenance.Repository != nil {
+		permission, err := access_model.GetUserRepoPermission(ctx, pd.Provenance.Repository, ctx.Doer)
+		if err != nil {
+			ctx.ServerError("GetUserRepoPermission", err)
+			return
+		}
+		hasProvenanceAccess = permission.HasAccess()
+	}
+	ctx.Data["HasProvenanceAccess"] = hasProvenanceAccess
+
 	ctx.HTML(http.StatusOK, tplPackagesView)
 }
 
@@ -369,6 +418,16 @@ func PackageSettingsPost(ctx *context.Context) {
 		return
 	case "delete":
 		err := packages_service.RemovePackageVersion(ctx.Doer, ctx.Package.Descriptor.Version)
+		if err == nil {
+			switch ctx.Package.Descriptor.Package.Type {
+			case packages_model.TypeAlpine:
+				err = alpine_service.BuildAllRepositoryFiles(ctx, ctx.Package.Owner.ID)
+			case packages_model.TypeDebian:
+				err = debian_service.BuildAllRepositoryFiles(ctx, ctx.Package.Owner.ID)
+			case packages_model.TypeRpm:
+				err = rpm_service.BuildRepositoryFiles(ctx, ctx.Package.Owner.ID)
+			}
+		}
 		if err != nil {
 			log.Error("Error deleting package: %v", err)
 			ctx.Flash.Error(ctx.Tr("packages.settings.delete.error"))
@@ -5,13 +5,19 @@
 package setting
 
 import (
+	"errors"
 	"net/http"
+	"strings"
+	"time"
 
 	auth_model "code.gitea.io/gitea/models/auth"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/modules/web"
+	auth_service "code.gitea.io/gitea/services/auth"
 	"code.gitea.io/gitea/services/forms"
 )
 
@@ -53,6 +59,42 @@ func ApplicationsPost(ctx *context.Context) {
 		Scope: scope,
 	}
 
+	if form.ResourceOwner != "" {
+		opts := &auth_service.FineGrainedTokenOptions{
+			ResourceOwner: form.ResourceOwner,
+			Permissions:   make(map[string]string),
+		}
+		for _, name := range strings.Split(form.Repositories, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				opts.Repositories = append(opts.Repositories, name)
+			}
+		}
+		for _, u := range auth_model.FineGrainedTokenUnits {
+			if permission := ctx.FormString("permission_" + u.Name); permission != "" {
+				opts.Permissions[u.Name] = permission
+			}
+		}
+		if form.ExpiresAt != "" {
+			expiresAt, err := time.ParseInLocation("2006-01-02", form.ExpiresAt, setting.DefaultUILocation)
+			if err != nil {
+				ctx.Flash.Error(ctx.Tr("settings.fine_grained_token_invalid_expiry"))
+				ctx.Redirect(setting.AppSubURL + "/user/settings/applications")
+				return
+			}
+			// the token is valid until the end of the day
+			opts.ExpiresUnix = timeutil.TimeStamp(expiresAt.AddDate(0, 0, 1).Unix() - 1)
+		}
+		if err := auth_service.RestrictAccessToken(ctx, ctx.Doer, t, opts); err != nil {
+			if errors.Is(err, util.ErrInvalidArgument) {
+				ctx.Flash.Error(ctx.Tr("settings.fine_grained_token_invalid", err.Error()))
+				ctx.Redirect(setting.AppSubURL + "/user/settings/applications")
+				return
+			}
+			ctx.ServerError("RestrictAccessToken", err)
+			return
+		}
+	}
+
 	exist, err := auth_model.AccessTokenByNameExists(t)
 	if err != nil {
 		ctx.ServerError("AccessTokenByNameExists", err)
@@ -64,7 +106,7 @@ func ApplicationsPost(ctx *context.Context) {
 		return
 	}
 
-	if err := auth_model.NewAccessToken(t); err != nil {
+	if err := auth_service.CreateAccessToken(ctx, ctx.Doer, ctx.Doer, t); err != nil {
 		ctx.ServerError("NewAccessToken", err)
 		return
 	}
@@ -77,7 +119,8 @@ func ApplicationsPost(ctx *context.Context) {
 
 // DeleteApplication response for delete user access token
 func DeleteApplication(ctx *context.Context) {
-	if err := auth_model.DeleteAccessTokenByID(ctx.FormInt64("id"), ctx.Doer.ID); err != nil {
+	id := ctx.FormInt64("id")
+	if err := auth_service.DeleteAccessToken(ctx, ctx.Doer, id); err != nil {
 		ctx.Flash.Error("DeleteAccessTokenByID: " + err.Error())
 	} else {
 		ctx.Flash.Success(ctx.Tr("settings.delete_token_success"))
@@ -95,6 +138,7 @@ func loadApplicationsData(ctx *context.Context) {
 		return
 	}
 	ctx.Data["Tokens"] = tokens
+	ctx.Data["FineGrainedTokenUnits"] = auth_model.FineGrainedTokenUnits
 	ctx.Data["EnableOAuth2"] = setting.OAuth2.Enable
 	if setting.OAuth2.Enable {
 		ctx.Data["Applications"], err = auth_model.GetOAuth2ApplicationsByUserID(ctx, ctx.Doer.ID)
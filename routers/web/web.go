@@ -307,6 +307,13 @@ func RegisterRoutes(m *web.Route) {
 		}
 	}
 
+	actionsEnabled := func(ctx *context.Context) {
+		if !setting.Actions.Enabled {
+			ctx.Error(http.StatusForbidden)
+			return
+		}
+	}
+
 	feedEnabled := func(ctx *context.Context) {
 		if !setting.EnableFeed {
 			ctx.Error(http.StatusNotFound)
@@ -341,6 +348,8 @@ func RegisterRoutes(m *web.Route) {
 		m.Get("/users/sitemap-{idx}.xml", sitemapEnabled, explore.Users)
 		m.Get("/organizations", explore.Organizations)
 		m.Get("/code", explore.Code)
+		m.Get("/commits", explore.Commits)
+		m.Get("/search", explore.Search)
 		m.Get("/topics/search", explore.TopicSearch)
 	}, ignExploreSignIn)
 	m.Group("/issues", func() {
@@ -396,7 +405,12 @@ func RegisterRoutes(m *web.Route) {
 		// TODO manage redirection
 		m.Post("/authorize", web.Bind(forms.AuthorizationForm{}), auth.AuthorizeOAuth)
 	}, ignSignInAndCsrf, reqSignIn)
+	m.Group("/login/device", func() {
+		m.Get("", auth.DeviceVerification)
+		m.Post("", web.Bind(forms.DeviceVerificationForm{}), auth.DeviceVerificationPost)
+	}, reqSignIn)
 	m.Get("/login/oauth/userinfo", ignSignInAndCsrf, auth.InfoOAuth)
+	m.Post("/login/oauth/device/code", CorsHandler(), web.Bind(forms.DeviceAuthorizationForm{}), ignSignInAndCsrf, auth.DeviceAuthorizationOAuth)
 	m.Post("/login/oauth/access_token", CorsHandler(), web.Bind(forms.AccessTokenForm{}), ignSignInAndCsrf, auth.AccessTokenOAuth)
 	m.Get("/login/oauth/keys", ignSignInAndCsrf, auth.OIDCKeys)
 	m.Post("/login/oauth/introspect", CorsHandler(), web.Bind(forms.IntrospectTokenForm{}), ignSignInAndCsrf, auth.IntrospectOAuth)
@@ -467,6 +481,16 @@ func RegisterRoutes(m *web.Route) {
 					m.Get("/preview", user_setting.PackagesRulePreview)
 				})
 			})
+			m.Group("/remotes", func() {
+				m.Group("/add", func() {
+					m.Get("", user_setting.PackagesRemoteAdd)
+					m.Post("", web.Bind(forms.PackageRemoteForm{}), user_setting.PackagesRemoteAddPost)
+				})
+				m.Group("/{id}", func() {
+					m.Get("", user_setting.PackagesRemoteEdit)
+					m.Post("", web.Bind(forms.PackageRemoteForm{}), user_setting.PackagesRemoteEditPost)
+				})
+			})
 		}, packagesEnabled)
 		m.Get("/organization", user_setting.Organization)
 		m.Get("/repos", user_setting.Repos)
@@ -495,6 +519,11 @@ func RegisterRoutes(m *web.Route) {
 			m.Get("/{provider}", auth.SignInOAuth)
 			m.Get("/{provider}/callback", auth.SignInOAuthCallback)
 		})
+		m.Group("/saml/{provider}", func() {
+			m.Get("", auth.SignInSAML)
+			m.Post("/acs", ignSignInAndCsrf, auth.SignInSAMLCallback)
+			m.Get("/metadata", auth.SAMLMetadata)
+		})
 	})
 	// ***** END: User *****
 
@@ -549,6 +578,7 @@ func RegisterRoutes(m *web.Route) {
 		m.Group("/repos", func() {
 			m.Get("", admin.Repos)
 			m.Combo("/unadopted").Get(admin.UnadoptedRepos).Post(admin.AdoptOrDeleteRepository)
+			m.Get("/maintenance", admin.RepoMaintenances)
 			m.Post("/delete", admin.DeleteRepo)
 		})
 
@@ -557,6 +587,12 @@ func RegisterRoutes(m *web.Route) {
 			m.Post("/delete", admin.DeletePackageVersion)
 		}, packagesEnabled)
 
+		m.Group("/runners", func() {
+			m.Get("", admin.Runners)
+			m.Post("/reset_registration_token", admin.ResetRunnerRegistrationToken)
+			m.Post("/delete", admin.DeleteRunner)
+		}, actionsEnabled)
+
 		m.Group("/hooks", func() {
 			m.Get("", admin.DefaultOrSystemWebhooks)
 			m.Post("/delete", admin.DeleteDefaultOrSystemWebhook)
@@ -606,6 +642,8 @@ func RegisterRoutes(m *web.Route) {
 			m.Post("/empty", admin.EmptyNotices)
 		})
 
+		m.Get("/audit", admin.AuditEvents)
+
 		m.Group("/applications", func() {
 			m.Get("", admin.Applications)
 			m.Post("/oauth2", web.Bind(forms.EditOAuth2ApplicationForm{}), admin.ApplicationsPost)
@@ -623,6 +661,7 @@ func RegisterRoutes(m *web.Route) {
 	}, func(ctx *context.Context) {
 		ctx.Data["EnableOAuth2"] = setting.OAuth2.Enable
 		ctx.Data["EnablePackages"] = setting.Packages.Enabled
+		ctx.Data["EnableActions"] = setting.Actions.Enabled
 	}, adminReq)
 	// ***** END: Admin *****
 
@@ -780,6 +819,12 @@ func RegisterRoutes(m *web.Route) {
 					m.Post("/delete", org.SecretsDelete)
 				})
 
+				m.Group("/runners", func() {
+					m.Get("", org.Runners)
+					m.Post("/reset_registration_token", org.ResetRunnerRegistrationToken)
+					m.Post("/delete", org.DeleteRunner)
+				}, actionsEnabled)
+
 				m.Route("/delete", "GET,POST", org.SettingsDelete)
 
 				m.Group("/packages", func() {
@@ -795,10 +840,21 @@ func RegisterRoutes(m *web.Route) {
 							m.Get("/preview", org.PackagesRulePreview)
 						})
 					})
+					m.Group("/remotes", func() {
+						m.Group("/add", func() {
+							m.Get("", org.PackagesRemoteAdd)
+							m.Post("", web.Bind(forms.PackageRemoteForm{}), org.PackagesRemoteAddPost)
+						})
+						m.Group("/{id}", func() {
+							m.Get("", org.PackagesRemoteEdit)
+							m.Post("", web.Bind(forms.PackageRemoteForm{}), org.PackagesRemoteEditPost)
+						})
+					})
 				}, packagesEnabled)
 			}, func(ctx *context.Context) {
 				ctx.Data["EnableOAuth2"] = setting.OAuth2.Enable
 				ctx.Data["EnablePackages"] = setting.Packages.Enabled
+				ctx.Data["EnableActions"] = setting.Actions.Enabled
 			})
 		}, context.OrgAssignment(true, true))
 	}, reqSignIn)
@@ -970,6 +1026,12 @@ func RegisterRoutes(m *web.Route) {
 				})
 			})
 
+			m.Group("/runners", func() {
+				m.Get("", repo.Runners)
+				m.Post("/reset_registration_token", repo.ResetRunnerRegistrationToken)
+				m.Post("/delete", repo.DeleteRunner)
+			}, actionsEnabled)
+
 			m.Group("/lfs", func() {
 				m.Get("/", repo.LFSFiles)
 				m.Get("/show/{oid}", repo.LFSFileGet)
@@ -986,6 +1048,7 @@ func RegisterRoutes(m *web.Route) {
 		}, func(ctx *context.Context) {
 			ctx.Data["PageIsSettings"] = true
 			ctx.Data["LFSStartServer"] = setting.LFS.StartServer
+			ctx.Data["EnableActions"] = setting.Actions.Enabled
 		})
 	}, reqSignIn, context.RepoAssignment, context.UnitTypes(), reqRepoAdmin, context.RepoRef())
 
@@ -1111,7 +1174,7 @@ func RegisterRoutes(m *web.Route) {
 					Post(web.Bind(forms.UploadRepoFileForm{}), repo.UploadFilePost)
 				m.Combo("/_diffpatch/*").Get(repo.NewDiffPatch).
 					Post(web.Bind(forms.EditRepoFileForm{}), repo.NewDiffPatchPost)
-				m.Combo("/_cherrypick/{sha:([a-f0-9]{7,40})}/*").Get(repo.CherryPick).
+				m.Combo("/_cherrypick/{sha:([a-f0-9]{7,64})}/*").Get(repo.CherryPick).
 					Post(web.Bind(forms.CherryPickForm{}), repo.CherryPickPost)
 			}, repo.MustBeEditable)
 			m.Group("", func() {
@@ -1131,6 +1194,10 @@ func RegisterRoutes(m *web.Route) {
 		}, context.RepoMustNotBeArchived(), reqRepoCodeWriter, repo.MustBeNotEmpty)
 	}, reqSignIn, context.RepoAssignment, context.UnitTypes())
 
+	m.Group("/{username}/{reponame}/actions", func() {
+		m.Get("/runs/{index}/jobs/{jobid}", repo.ActionJobLog)
+	}, ignSignIn, context.RepoAssignment, context.UnitTypes(), reqRepoCodeReader, actionsEnabled)
+
 	// Releases
 	m.Group("/{username}/{reponame}", func() {
 		m.Group("/tags", func() {
@@ -1245,8 +1312,8 @@ func RegisterRoutes(m *web.Route) {
 					reqRepoWikiWriter,
 					web.Bind(forms.NewWikiForm{}),
 					repo.WikiPost)
-			m.Get("/commit/{sha:[a-f0-9]{7,40}}", repo.SetEditorconfigIfExists, repo.SetDiffViewStyle, repo.SetWhitespaceBehavior, repo.Diff)
-			m.Get("/commit/{sha:[a-f0-9]{7,40}}.{ext:patch|diff}", repo.RawDiff)
+			m.Get("/commit/{sha:[a-f0-9]{7,64}}", repo.SetEditorconfigIfExists, repo.SetDiffViewStyle, repo.SetWhitespaceBehavior, repo.Diff)
+			m.Get("/commit/{sha:[a-f0-9]{7,64}}.{ext:patch|diff}", repo.RawDiff)
 		}, repo.MustEnableWiki, func(ctx *context.Context) {
 			ctx.Data["PageIsWiki"] = true
 			ctx.Data["CloneButtonOriginLink"] = ctx.Repo.Repository.WikiCloneLink()
@@ -1357,8 +1424,8 @@ func RegisterRoutes(m *web.Route) {
 
 		m.Group("", func() {
 			m.Get("/graph", repo.Graph)
-			m.Get("/commit/{sha:([a-f0-9]{7,40})$}", repo.SetEditorconfigIfExists, repo.SetDiffViewStyle, repo.SetWhitespaceBehavior, repo.Diff)
-			m.Get("/cherry-pick/{sha:([a-f0-9]{7,40})$}", repo.SetEditorconfigIfExists, repo.CherryPick)
+			m.Get("/commit/{sha:([a-f0-9]{7,64})$}", repo.SetEditorconfigIfExists, repo.SetDiffViewStyle, repo.SetWhitespaceBehavior, repo.Diff)
+			m.Get("/cherry-pick/{sha:([a-f0-9]{7,64})$}", repo.SetEditorconfigIfExists, repo.CherryPick)
 		}, repo.MustBeNotEmpty, context.RepoRef(), reqRepoCodeReader)
 
 		m.Group("/src", func() {
@@ -1372,7 +1439,7 @@ func RegisterRoutes(m *web.Route) {
 		m.Group("", func() {
 			m.Get("/forks", repo.Forks)
 		}, context.RepoRef(), reqRepoCodeReader)
-		m.Get("/commit/{sha:([a-f0-9]{7,40})}.{ext:patch|diff}",
+		m.Get("/commit/{sha:([a-f0-9]{7,64})}.{ext:patch|diff}",
 			repo.MustBeNotEmpty, reqRepoCodeReader, repo.RawDiff)
 	}, ignSignIn, context.RepoAssignment, context.UnitTypes())
 
@@ -1382,6 +1449,7 @@ func RegisterRoutes(m *web.Route) {
 		m.Get("/stars", repo.Stars)
 		m.Get("/watchers", repo.Watchers)
 		m.Get("/search", reqRepoCodeReader, repo.Search)
+		m.Get("/symbols", reqRepoCodeReader, repo.SearchSymbol)
 	}, ignSignIn, context.RepoAssignment, context.RepoRef(), context.UnitTypes())
 
 	m.Group("/{username}", func() {
@@ -1411,14 +1479,15 @@ func RegisterRoutes(m *web.Route) {
 				m.PostOptions("/git-upload-pack", repo.ServiceUploadPack)
 				m.PostOptions("/git-receive-pack", repo.ServiceReceivePack)
 				m.GetOptions("/info/refs", repo.GetInfoRefs)
+				m.GetOptions("/clone.bundle", repo.GetCloneBundle)
 				m.GetOptions("/HEAD", repo.GetTextFile("HEAD"))
 				m.GetOptions("/objects/info/alternates", repo.GetTextFile("objects/info/alternates"))
 				m.GetOptions("/objects/info/http-alternates", repo.GetTextFile("objects/info/http-alternates"))
 				m.GetOptions("/objects/info/packs", repo.GetInfoPacks)
 				m.GetOptions("/objects/info/{file:[^/]*}", repo.GetTextFile(""))
-				m.GetOptions("/objects/{head:[0-9a-f]{2}}/{hash:[0-9a-f]{38}}", repo.GetLooseObject)
-				m.GetOptions("/objects/pack/pack-{file:[0-9a-f]{40}}.pack", repo.GetPackFile)
-				m.GetOptions("/objects/pack/pack-{file:[0-9a-f]{40}}.idx", repo.GetIdxFile)
+				m.GetOptions("/objects/{head:[0-9a-f]{2}}/{hash:(?:[0-9a-f]{38}|[0-9a-f]{62})}", repo.GetLooseObject)
+				m.GetOptions("/objects/pack/pack-{file:(?:[0-9a-f]{40}|[0-9a-f]{64})}.pack", repo.GetPackFile)
+				m.GetOptions("/objects/pack/pack-{file:(?:[0-9a-f]{40}|[0-9a-f]{64})}.idx", repo.GetIdxFile)
 			}, ignSignInAndCsrf, context_service.UserAssignmentWeb())
 		})
 	})
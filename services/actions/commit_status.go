@@ -0,0 +1,59 @@
+// Copyright 2022 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	git_model "code.gitea.io/gitea/models/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/util"
+	files_service "code.gitea.io/gitea/services/repository/files"
+)
+
+// createCommitStatus reports the status of the job as commit status of the commit the job runs for
+func createCommitStatus(ctx context.Context, job *actions_model.ActionRunJob) {
+	if err := job.LoadAttributes(ctx); err != nil {
+		log.Error("LoadAttributes of job %d: %v", job.ID, err)
+		return
+	}
+	run := job.Run
+
+	workflowName := strings.TrimSuffix(strings.TrimSuffix(path.Base(run.WorkflowID), ".yml"), ".yaml")
+	status := &git_model.CommitStatus{
+		State:       job.Status.ToCommitStatusState(),
+		TargetURL:   fmt.Sprintf("%s/actions/runs/%d/jobs/%d", run.Repo.HTMLURL(), run.Index, job.ID),
+		Description: describeJobStatus(job),
+		Context:     fmt.Sprintf("%s / %s (%s)", workflowName, job.Name, run.Event),
+	}
+
+	if err := files_service.CreateCommitStatus(ctx, run.Repo, run.TriggerUser, run.CommitSHA, status); err != nil {
+		log.Error("CreateCommitStatus for job %d: %v", job.ID, err)
+	}
+}
+
+func describeJobStatus(job *actions_model.ActionRunJob) string {
+	switch job.Status {
+	case actions_model.StatusWaiting:
+		return "Waiting to run"
+	case actions_model.StatusBlocked:
+		return "Blocked by required jobs"
+	case actions_model.StatusRunning:
+		return "Has started running"
+	case actions_model.StatusSuccess:
+		return fmt.Sprintf("Successful in %s", util.SecToTime(int64(job.Duration())))
+	case actions_model.StatusFailure:
+		return fmt.Sprintf("Failing after %s", util.SecToTime(int64(job.Duration())))
+	case actions_model.StatusCancelled:
+		return "Has been cancelled"
+	case actions_model.StatusSkipped:
+		return "Has been skipped"
+	default:
+		return "Unknown status"
+	}
+}
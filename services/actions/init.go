@@ -0,0 +1,19 @@
+// Copyright 2022 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"code.gitea.io/gitea/modules/notification"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// Init registers the notifier which creates runs for the workflows triggered by repository events
+func Init() error {
+	if !setting.Actions.Enabled {
+		return nil
+	}
+
+	notification.RegisterNotifier(NewNotifier())
+	return nil
+}
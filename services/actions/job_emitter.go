@@ -0,0 +1,113 @@
+// Copyright 2022 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// resolveRun unblocks or skips the jobs which wait for other jobs of the run and updates the status of the run.
+// It has to be called every time a job of the run changes its status.
+func resolveRun(ctx context.Context, runID int64) error {
+	var changed []*actions_model.ActionRunJob
+	err := db.WithTx(ctx, func(ctx context.Context) error {
+		run, err := actions_model.GetRunByID(ctx, runID)
+		if err != nil {
+			return err
+		}
+		jobs, err := actions_model.GetRunJobsByRunID(ctx, runID)
+		if err != nil {
+			return err
+		}
+
+		for _, job := range resolveBlockedJobs(jobs) {
+			cols := []string{"status"}
+			if job.Status.IsDone() {
+				cols = append(cols, "stopped")
+			}
+			n, err := actions_model.UpdateRunJob(ctx, job, builder.Eq{"status": actions_model.StatusBlocked}, cols...)
+			if err != nil {
+				return err
+			}
+			if n > 0 {
+				job.Run = run
+				changed = append(changed, job)
+			}
+		}
+
+		status := actions_model.AggregateJobStatus(jobs)
+		if status == run.Status {
+			return nil
+		}
+		run.Status = status
+		cols := []string{"status"}
+		if run.Started == 0 && status != actions_model.StatusWaiting {
+			run.Started = timeutil.TimeStampNow()
+			cols = append(cols, "started")
+		}
+		if status.IsDone() {
+			run.Stopped = timeutil.TimeStampNow()
+			cols = append(cols, "stopped")
+		}
+		return actions_model.UpdateRun(ctx, run, cols...)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, job := range changed {
+		createCommitStatus(ctx, job)
+	}
+	return nil
+}
+
+// resolveBlockedJobs changes the status of the blocked jobs whose needed jobs are done:
+// they become waiting if all needed jobs succeeded and skipped otherwise.
+// It returns the changed jobs.
+func resolveBlockedJobs(jobs []*actions_model.ActionRunJob) []*actions_model.ActionRunJob {
+	byID := make(map[string]*actions_model.ActionRunJob, len(jobs))
+	for _, job := range jobs {
+		byID[job.JobID] = job
+	}
+
+	var changed []*actions_model.ActionRunJob
+	// skipping a job can resolve other jobs, so repeat until nothing changes anymore
+	for {
+		progress := false
+		for _, job := range jobs {
+			if job.Status != actions_model.StatusBlocked {
+				continue
+			}
+			allDone, allSucceeded := true, true
+			for _, need := range job.Needs {
+				needed, ok := byID[need]
+				if !ok {
+					continue
+				}
+				allDone = allDone && needed.Status.IsDone()
+				allSucceeded = allSucceeded && needed.Status == actions_model.StatusSuccess
+			}
+			if !allDone {
+				continue
+			}
+			if allSucceeded {
+				job.Status = actions_model.StatusWaiting
+			} else {
+				job.Status = actions_model.StatusSkipped
+				job.Stopped = timeutil.TimeStampNow()
+			}
+			changed = append(changed, job)
+			progress = true
+		}
+		if !progress {
+			return changed
+		}
+	}
+}
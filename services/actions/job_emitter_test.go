@@ -0,0 +1,32 @@
+// Copyright 2022 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"testing"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveBlockedJobs(t *testing.T) {
+	jobs := []*actions_model.ActionRunJob{
+		{JobID: "build", Status: actions_model.StatusSuccess},
+		{JobID: "lint", Status: actions_model.StatusFailure},
+		{JobID: "test", Status: actions_model.StatusBlocked, Needs: []string{"build"}},
+		{JobID: "deploy", Status: actions_model.StatusBlocked, Needs: []string{"build", "lint"}},
+		{JobID: "notify", Status: actions_model.StatusBlocked, Needs: []string{"deploy"}},
+		{JobID: "release", Status: actions_model.StatusBlocked, Needs: []string{"test"}},
+	}
+
+	changed := resolveBlockedJobs(jobs)
+	assert.Len(t, changed, 3)
+
+	assert.Equal(t, actions_model.StatusWaiting, jobs[2].Status)
+	assert.Equal(t, actions_model.StatusSkipped, jobs[3].Status)
+	assert.Equal(t, actions_model.StatusSkipped, jobs[4].Status)
+	// test is waiting but not done, so release stays blocked
+	assert.Equal(t, actions_model.StatusBlocked, jobs[5].Status)
+}
@@ -0,0 +1,157 @@
+// Copyright 2022 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+
+	issues_model "code.gitea.io/gitea/models/issues"
+	"code.gitea.io/gitea/models/perm"
+	access_model "code.gitea.io/gitea/models/perm/access"
+	repo_model "code.gitea.io/gitea/models/repo"
+	user_model "code.gitea.io/gitea/models/user"
+	actions_module "code.gitea.io/gitea/modules/actions"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification/base"
+	"code.gitea.io/gitea/modules/repository"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/services/convert"
+)
+
+type actionsNotifier struct {
+	base.NullNotifier
+}
+
+var _ base.Notifier = &actionsNotifier{}
+
+// NewNotifier create a new actionsNotifier notifier
+func NewNotifier() base.Notifier {
+	return &actionsNotifier{}
+}
+
+func (n *actionsNotifier) NotifyPushCommits(ctx context.Context, pusher *user_model.User, repo *repo_model.Repository, opts *repository.PushUpdateOptions, commits *repository.PushCommits) {
+	apiPusher := convert.ToUser(pusher, nil)
+	apiCommits, apiHeadCommit, err := commits.ToAPIPayloadCommits(ctx, repo.RepoPath(), repo.HTMLURL())
+	if err != nil {
+		log.Error("commits.ToAPIPayloadCommits failed: %v", err)
+		return
+	}
+
+	input := &notifyInput{
+		Repo: repo,
+		Doer: pusher,
+		Event: &actions_module.Event{
+			Name: actions_module.EventPush,
+			Ref:  opts.RefFullName,
+		},
+		Ref:       opts.RefFullName,
+		CommitSHA: opts.NewCommitID,
+		Payload: &api.PushPayload{
+			Ref:          opts.RefFullName,
+			Before:       opts.OldCommitID,
+			After:        opts.NewCommitID,
+			CompareURL:   setting.AppURL + commits.CompareURL,
+			Commits:      apiCommits,
+			TotalCommits: commits.Len,
+			HeadCommit:   apiHeadCommit,
+			Repo:         convert.ToRepo(ctx, repo, perm.AccessModeOwner),
+			Pusher:       apiPusher,
+			Sender:       apiPusher,
+		},
+	}
+	if !opts.IsNewRef() {
+		input.ChangedSince = opts.OldCommitID
+	}
+
+	if err := notify(ctx, input); err != nil {
+		log.Error("notify actions of push to %s in %s: %v", opts.RefFullName, repo.FullName(), err)
+	}
+}
+
+func (n *actionsNotifier) NotifyNewPullRequest(ctx context.Context, pull *issues_model.PullRequest, _ []*user_model.User) {
+	if err := pull.LoadIssue(ctx); err != nil {
+		log.Error("pull.LoadIssue: %v", err)
+		return
+	}
+	if err := pull.Issue.LoadPoster(ctx); err != nil {
+		log.Error("pull.Issue.LoadPoster: %v", err)
+		return
+	}
+	notifyPullRequest(ctx, pull.Issue.Poster, pull, api.HookIssueOpened, actions_module.PullRequestOpened)
+}
+
+func (n *actionsNotifier) NotifyPullRequestSynchronized(ctx context.Context, doer *user_model.User, pull *issues_model.PullRequest) {
+	notifyPullRequest(ctx, doer, pull, api.HookIssueSynchronized, actions_module.PullRequestSynchronize)
+}
+
+func (n *actionsNotifier) NotifyIssueChangeStatus(ctx context.Context, doer *user_model.User, _ string, issue *issues_model.Issue, _ *issues_model.Comment, isClosed bool) {
+	if !issue.IsPull || isClosed {
+		return
+	}
+	if err := issue.LoadPullRequest(ctx); err != nil {
+		log.Error("issue.LoadPullRequest: %v", err)
+		return
+	}
+	notifyPullRequest(ctx, doer, issue.PullRequest, api.HookIssueReOpened, actions_module.PullRequestReopened)
+}
+
+func notifyPullRequest(ctx context.Context, doer *user_model.User, pull *issues_model.PullRequest, action api.HookIssueAction, activityType string) {
+	if err := pull.LoadAttributes(ctx); err != nil {
+		log.Error("pull.LoadAttributes: %v", err)
+		return
+	}
+	if err := pull.LoadBaseRepo(ctx); err != nil {
+		log.Error("pull.LoadBaseRepo: %v", err)
+		return
+	}
+	if err := pull.LoadHeadRepo(ctx); err != nil {
+		log.Error("pull.LoadHeadRepo: %v", err)
+		return
+	}
+
+	headCommitID, err := getPullRequestHeadCommitID(ctx, pull)
+	if err != nil {
+		log.Error("getPullRequestHeadCommitID: %v", err)
+		return
+	}
+
+	mode, _ := access_model.AccessLevel(ctx, doer, pull.BaseRepo)
+	input := &notifyInput{
+		Repo: pull.BaseRepo,
+		Doer: doer,
+		Event: &actions_module.Event{
+			Name: actions_module.EventPullRequest,
+			Type: activityType,
+			Ref:  git.BranchPrefix + pull.BaseBranch,
+		},
+		Ref:          pull.GetGitRefName(),
+		CommitSHA:    headCommitID,
+		ChangedSince: pull.MergeBase,
+		Payload: &api.PullRequestPayload{
+			Action:      action,
+			Index:       pull.Issue.Index,
+			PullRequest: convert.ToAPIPullRequest(ctx, pull, nil),
+			Repository:  convert.ToRepo(ctx, pull.BaseRepo, mode),
+			Sender:      convert.ToUser(doer, nil),
+		},
+		Title:             pull.Issue.Title,
+		IsForkPullRequest: pull.HeadRepoID != pull.BaseRepoID,
+	}
+
+	if err := notify(ctx, input); err != nil {
+		log.Error("notify actions of pull request %d in %s: %v", pull.Index, pull.BaseRepo.FullName(), err)
+	}
+}
+
+func getPullRequestHeadCommitID(ctx context.Context, pull *issues_model.PullRequest) (string, error) {
+	gitRepo, closer, err := git.RepositoryFromContextOrOpen(ctx, pull.BaseRepo.RepoPath())
+	if err != nil {
+		return "", err
+	}
+	defer closer.Close()
+
+	return gitRepo.GetRefCommitID(pull.GetGitRefName())
+}
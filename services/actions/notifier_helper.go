@@ -0,0 +1,136 @@
+// Copyright 2022 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	repo_model "code.gitea.io/gitea/models/repo"
+	user_model "code.gitea.io/gitea/models/user"
+	actions_module "code.gitea.io/gitea/modules/actions"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+type notifyInput struct {
+	Repo  *repo_model.Repository
+	Doer  *user_model.User
+	Event *actions_module.Event
+
+	// Ref and CommitSHA point to the commit the workflows are read from and run for
+	Ref       string
+	CommitSHA string
+	// ChangedSince is the commit used to compute the changed files for path filters
+	ChangedSince string
+
+	Payload           api.Payloader
+	Title             string
+	IsForkPullRequest bool
+}
+
+// notify detects the workflows which are triggered by the event and creates runs for them
+func notify(ctx context.Context, input *notifyInput) error {
+	if input.Repo.IsEmpty || input.Repo.IsArchived {
+		return nil
+	}
+
+	gitRepo, err := git.OpenRepository(ctx, input.Repo.RepoPath())
+	if err != nil {
+		return fmt.Errorf("git.OpenRepository: %w", err)
+	}
+	defer gitRepo.Close()
+
+	commit, err := gitRepo.GetCommit(input.CommitSHA)
+	if err != nil {
+		return fmt.Errorf("gitRepo.GetCommit: %w", err)
+	}
+
+	entries, err := actions_module.ListWorkflows(commit)
+	if err != nil {
+		return fmt.Errorf("ListWorkflows: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	if input.ChangedSince != "" {
+		var changedFiles []string
+		input.Event.ChangedFiles = func() ([]string, error) {
+			if changedFiles == nil {
+				changedFiles, err = commit.GetFilesChangedSinceCommit(input.ChangedSince)
+			}
+			return changedFiles, err
+		}
+	}
+
+	payload, err := json.Marshal(input.Payload)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	title := input.Title
+	if title == "" {
+		title = commit.Summary()
+	}
+
+	for _, entry := range entries {
+		content, err := actions_module.GetContentFromEntry(entry)
+		if err != nil {
+			return fmt.Errorf("GetContentFromEntry: %w", err)
+		}
+		workflow, err := actions_module.ParseWorkflow(content)
+		if err != nil {
+			log.Warn("Ignoring invalid workflow %q in %s@%s: %v", entry.Name(), input.Repo.FullName(), input.CommitSHA, err)
+			continue
+		}
+		if !workflow.Match(input.Event) {
+			continue
+		}
+
+		run := &actions_model.ActionRun{
+			Title:             title,
+			RepoID:            input.Repo.ID,
+			Repo:              input.Repo,
+			OwnerID:           input.Repo.OwnerID,
+			WorkflowID:        entry.Name(),
+			TriggerUserID:     input.Doer.ID,
+			TriggerUser:       input.Doer,
+			Ref:               input.Ref,
+			CommitSHA:         input.CommitSHA,
+			Event:             input.Event.Name,
+			EventPayload:      string(payload),
+			IsForkPullRequest: input.IsForkPullRequest,
+		}
+
+		jobs := make([]*actions_model.ActionRunJob, 0, len(workflow.Jobs))
+		for _, job := range workflow.Jobs {
+			jobPayload, err := workflow.SingleJobPayload(job)
+			if err != nil {
+				return fmt.Errorf("SingleJobPayload: %w", err)
+			}
+			jobs = append(jobs, &actions_model.ActionRunJob{
+				Run:             run,
+				Name:            job.DisplayName(),
+				JobID:           job.ID,
+				WorkflowPayload: jobPayload,
+				Needs:           job.Needs,
+				RunsOn:          job.RunsOn,
+			})
+		}
+
+		if err := actions_model.InsertRun(ctx, run, jobs); err != nil {
+			return fmt.Errorf("InsertRun: %w", err)
+		}
+		log.Trace("Created run %d of workflow %q in %s", run.Index, run.WorkflowID, input.Repo.FullName())
+
+		for _, job := range jobs {
+			createCommitStatus(ctx, job)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+// Copyright 2022 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	secret_model "code.gitea.io/gitea/models/secret"
+	"code.gitea.io/gitea/modules/log"
+	secret_module "code.gitea.io/gitea/modules/secret"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"xorm.io/builder"
+)
+
+// ErrLogTooLarge is returned if an uploaded log exceeds the configured limit
+var ErrLogTooLarge = util.NewInvalidArgumentErrorf("log is too large")
+
+// PickTask assigns the oldest waiting job the runner is able to execute to the runner.
+// It returns nil if there is no such job.
+func PickTask(ctx context.Context, runner *actions_model.ActionRunner) (*api.ActionTask, error) {
+	// the labels are stored as JSON, so the jobs are matched page by page until one can be executed by the runner.
+	// The pages follow the id instead of an offset as picked jobs are no longer waiting.
+	const pageSize = 50
+	var afterID int64
+	for {
+		jobs, err := actions_model.FindRunJobs(ctx, actions_model.FindRunJobOptions{
+			ListOptions: db.ListOptions{Page: 1, PageSize: pageSize},
+			RepoID:      runner.RepoID,
+			OwnerID:     runner.OwnerID,
+			AfterID:     afterID,
+			Statuses:    []actions_model.Status{actions_model.StatusWaiting},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		task, err := pickTaskOfJobs(ctx, runner, jobs)
+		if task != nil || err != nil {
+			return task, err
+		}
+		if len(jobs) < pageSize {
+			return nil, nil
+		}
+		afterID = jobs[len(jobs)-1].ID
+	}
+}
+
+func pickTaskOfJobs(ctx context.Context, runner *actions_model.ActionRunner, jobs []*actions_model.ActionRunJob) (*api.ActionTask, error) {
+	for _, job := range jobs {
+		if !runner.CanMatchLabels(job.RunsOn) {
+			continue
+		}
+
+		// the job stays waiting if it can't be handed to the runner
+		var task *api.ActionTask
+		err := db.WithTx(ctx, func(ctx context.Context) error {
+			if err := job.GenerateToken(); err != nil {
+				return err
+			}
+			job.Status = actions_model.StatusRunning
+			job.RunnerID = runner.ID
+			job.Started = timeutil.TimeStampNow()
+			n, err := actions_model.UpdateRunJob(ctx, job, builder.Eq{"status": actions_model.StatusWaiting}, "status", "runner_id", "started", "token_hash", "token_salt", "token_last_eight")
+			if err != nil {
+				return err
+			}
+			if n == 0 {
+				// another runner was faster
+				return nil
+			}
+
+			if err := resolveRun(ctx, job.RunID); err != nil {
+				return err
+			}
+			task, err = toTask(ctx, job)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if task == nil {
+			continue
+		}
+
+		runner.LastActive = timeutil.TimeStampNow()
+		if err := actions_model.UpdateRunner(ctx, runner, "last_active"); err != nil {
+			log.Error("UpdateRunner: %v", err)
+		}
+		createCommitStatus(ctx, job)
+
+		return task, nil
+	}
+	return nil, nil
+}
+
+func toTask(ctx context.Context, job *actions_model.ActionRunJob) (*api.ActionTask, error) {
+	if err := job.LoadAttributes(ctx); err != nil {
+		return nil, err
+	}
+	run := job.Run
+
+	secrets, err := getSecretsOfRun(ctx, run)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.ActionTask{
+		ID:     job.ID,
+		Name:   job.Name,
+		JobID:  job.JobID,
+		RunsOn: job.RunsOn,
+		Context: api.ActionTaskContext{
+			ServerURL:     setting.AppURL,
+			Repository:    run.Repo.FullName(),
+			RepositoryURL: run.Repo.CloneLink().HTTPS,
+			Event:         run.Event,
+			EventPayload:  []byte(run.EventPayload),
+			Ref:           run.Ref,
+			RefName:       run.RefName(),
+			SHA:           run.CommitSHA,
+			Actor:         run.TriggerUser.Name,
+			Workflow:      run.WorkflowID,
+			RunNumber:     run.Index,
+			Token:         job.Token,
+		},
+		Workflow: string(job.WorkflowPayload),
+		Secrets:  secrets,
+	}, nil
+}
+
+// getSecretsOfRun returns the decrypted secrets of the owner and the repository of the run.
+// Secrets of the repository take precedence and pull requests from forks don't get any secrets.
+func getSecretsOfRun(ctx context.Context, run *actions_model.ActionRun) (map[string]string, error) {
+	secrets := map[string]string{}
+	if run.IsForkPullRequest {
+		return secrets, nil
+	}
+
+	ownerSecrets, err := secret_model.FindSecrets(ctx, secret_model.FindSecretsOptions{OwnerID: run.Repo.OwnerID})
+	if err != nil {
+		return nil, err
+	}
+	repoSecrets, err := secret_model.FindSecrets(ctx, secret_model.FindSecretsOptions{RepoID: run.Repo.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, secret := range append(ownerSecrets, repoSecrets...) {
+		v, err := secret_module.DecryptSecret(setting.SecretKey, secret.Data)
+		if err != nil {
+			log.Error("Unable to decrypt secret %d (%s): %v", secret.ID, secret.Name, err)
+			continue
+		}
+		secrets[secret.Name] = v
+	}
+	return secrets, nil
+}
+
+// getTaskOfRunner returns the job with the given id if it's assigned to the runner
+func getTaskOfRunner(ctx context.Context, runner *actions_model.ActionRunner, jobID int64) (*actions_model.ActionRunJob, error) {
+	job, err := actions_model.GetRunJobByID(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.RunnerID != runner.ID {
+		return nil, actions_model.ErrRunJobNotExist
+	}
+	return job, nil
+}
+
+// UpdateTaskStatus updates the status of a job reported by the runner which executes it
+func UpdateTaskStatus(ctx context.Context, runner *actions_model.ActionRunner, jobID int64, status actions_model.Status) (*actions_model.ActionRunJob, error) {
+	job, err := getTaskOfRunner(ctx, runner, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != actions_model.StatusRunning {
+		return nil, util.NewInvalidArgumentErrorf("job is not running")
+	}
+
+	switch {
+	case status == actions_model.StatusRunning:
+		// a heartbeat of the runner, it keeps the job from being treated as a zombie
+		_, err := actions_model.UpdateRunJob(ctx, job, nil, "updated")
+		return job, err
+	case !status.IsDone():
+		return nil, util.NewInvalidArgumentErrorf("invalid status %q", status)
+	}
+
+	job.Status = status
+	job.Stopped = timeutil.TimeStampNow()
+	n, err := actions_model.UpdateRunJob(ctx, job, builder.Eq{"status": actions_model.StatusRunning}, "status", "stopped")
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, util.NewInvalidArgumentErrorf("job is not running")
+	}
+
+	if err := resolveRun(ctx, job.RunID); err != nil {
+		return nil, err
+	}
+	createCommitStatus(ctx, job)
+
+	return job, nil
+}
+
+// UploadTaskLog stores the log of a job, a later upload replaces the previous one
+func UploadTaskLog(ctx context.Context, runner *actions_model.ActionRunner, jobID int64, r io.Reader, size int64) error {
+	job, err := getTaskOfRunner(ctx, runner, jobID)
+	if err != nil {
+		return err
+	}
+	if setting.Actions.MaxLogSize > 0 && size > setting.Actions.MaxLogSize {
+		return ErrLogTooLarge
+	}
+
+	if job.LogFilename == "" {
+		job.LogFilename = fmt.Sprintf("%d/%d/%d.log", job.RepoID, job.RunID, job.ID)
+	}
+	written, err := storage.Actions.Save(job.LogFilename, r, size)
+	if err != nil {
+		return err
+	}
+
+	job.LogSize = written
+	_, err = actions_model.UpdateRunJob(ctx, job, nil, "log_filename", "log_size")
+	return err
+}
+
+// OpenJobLog opens the stored log of a job
+func OpenJobLog(job *actions_model.ActionRunJob) (storage.Object, error) {
+	if job.LogFilename == "" {
+		return nil, util.ErrNotExist
+	}
+	return storage.Actions.Open(job.LogFilename)
+}
+
+// StopZombieJobs fails the running jobs whose runners didn't report anything for a while
+func StopZombieJobs(ctx context.Context) error {
+	jobs, err := actions_model.FindRunJobs(ctx, actions_model.FindRunJobOptions{
+		Statuses:      []actions_model.Status{actions_model.StatusRunning},
+		UpdatedBefore: timeutil.TimeStampNow().AddDuration(-setting.Actions.RunnerTimeout),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		job.Status = actions_model.StatusFailure
+		job.Stopped = timeutil.TimeStampNow()
+		n, err := actions_model.UpdateRunJob(ctx, job, builder.Eq{"status": actions_model.StatusRunning}, "status", "stopped")
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			continue
+		}
+		log.Info("Stopped zombie job %d of run %d", job.ID, job.RunID)
+
+		if err := resolveRun(ctx, job.RunID); err != nil {
+			return err
+		}
+		createCommitStatus(ctx, job)
+	}
+	return nil
+}
@@ -4,26 +4,52 @@
 package asymkey
 
 import (
+	"context"
+
 	"code.gitea.io/gitea/models"
 	asymkey_model "code.gitea.io/gitea/models/asymkey"
+	audit_model "code.gitea.io/gitea/models/audit"
 	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
 	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/services/audit"
 )
 
+// AddDeployKey adds a deploy key to the repository
+func AddDeployKey(ctx context.Context, doer *user_model.User, repo *repo_model.Repository, name, content string, readOnly bool) (*asymkey_model.DeployKey, error) {
+	key, err := asymkey_model.AddDeployKey(repo.ID, name, content, readOnly)
+	if err != nil {
+		return nil, err
+	}
+	audit.RecordRepository(ctx, audit_model.ActionDeployKeyAdd, doer, repo, "Added deploy key %q with write access: %t", key.Name, !key.IsReadOnly())
+	return key, nil
+}
+
 // DeleteDeployKey deletes deploy key from its repository authorized_keys file if needed.
-func DeleteDeployKey(doer *user_model.User, id int64) error {
-	ctx, committer, err := db.TxContext(db.DefaultContext)
+func DeleteDeployKey(ctx context.Context, doer *user_model.User, id int64) error {
+	key, err := asymkey_model.GetDeployKeyByID(ctx, id)
+	if err != nil {
+		if asymkey_model.IsErrDeployKeyNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	txCtx, committer, err := db.TxContext(db.DefaultContext)
 	if err != nil {
 		return err
 	}
 	defer committer.Close()
 
-	if err := models.DeleteDeployKey(ctx, doer, id); err != nil {
+	if err := models.DeleteDeployKey(txCtx, doer, id); err != nil {
 		return err
 	}
 	if err := committer.Commit(); err != nil {
 		return err
 	}
 
+	if repo, err := repo_model.GetRepositoryByID(ctx, key.RepoID); err == nil {
+		audit.RecordRepository(ctx, audit_model.ActionDeployKeyDelete, doer, repo, "Deleted deploy key %q", key.Name)
+	}
 	return asymkey_model.RewriteAllPublicKeys()
 }
@@ -0,0 +1,147 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package audit
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	audit_model "code.gitea.io/gitea/models/audit"
+	"code.gitea.io/gitea/models/organization"
+	repo_model "code.gitea.io/gitea/models/repo"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// logEvent is the line written to the audit logger
+type logEvent struct {
+	Time      string `json:"time"`
+	Action    string `json:"action"`
+	ActorID   int64  `json:"actor_id"`
+	ActorName string `json:"actor_name"`
+	ScopeType string `json:"scope_type"`
+	ScopeID   int64  `json:"scope_id"`
+	ScopeName string `json:"scope_name"`
+	Message   string `json:"message"`
+	IPAddress string `json:"ip_address,omitempty"`
+}
+
+// remoteAddr returns the address of the client if the context belongs to a request
+func remoteAddr(ctx context.Context) string {
+	r, ok := ctx.(interface{ RemoteAddr() string })
+	if !ok {
+		return ""
+	}
+	addr := r.RemoteAddr()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// record appends an event to the audit log. A failure is only logged because it must not undo the audited action.
+func record(ctx context.Context, action audit_model.Action, doer *user_model.User, scopeType audit_model.ScopeType, scopeID int64, scopeName, format string, args ...interface{}) {
+	e := &audit_model.Event{
+		Action:    action,
+		ScopeType: scopeType,
+		ScopeID:   scopeID,
+		ScopeName: scopeName,
+		Message:   fmt.Sprintf(format, args...),
+		IPAddress: remoteAddr(ctx),
+	}
+	if doer != nil {
+		e.ActorID = doer.ID
+		e.ActorName = doer.Name
+	}
+	if err := audit_model.InsertEvent(ctx, e); err != nil {
+		log.Error("Unable to record audit event %s [%s]: %v", e.Action, e.Message, err)
+	}
+
+	if !setting.EnableAuditLog {
+		return
+	}
+	if e.CreatedUnix == 0 {
+		e.CreatedUnix = timeutil.TimeStampNow()
+	}
+	line, err := json.Marshal(&logEvent{
+		Time:      e.CreatedUnix.AsTime().UTC().Format("2006-01-02T15:04:05Z"),
+		Action:    string(e.Action),
+		ActorID:   e.ActorID,
+		ActorName: e.ActorName,
+		ScopeType: string(e.ScopeType),
+		ScopeID:   e.ScopeID,
+		ScopeName: e.ScopeName,
+		Message:   e.Message,
+		IPAddress: e.IPAddress,
+	})
+	if err != nil {
+		log.Error("Unable to marshal audit event %s: %v", e.Action, err)
+		return
+	}
+	if err := log.GetLogger("audit").SendLog(log.INFO, "", "", 0, string(line), ""); err != nil {
+		log.Error("Unable to write audit event %s: %v", e.Action, err)
+	}
+}
+
+// RecordSystem records an action which affects the whole instance
+func RecordSystem(ctx context.Context, action audit_model.Action, doer *user_model.User, format string, args ...interface{}) {
+	record(ctx, action, doer, audit_model.ScopeSystem, 0, "", format, args...)
+}
+
+// RecordUser records an action on the account of a user
+func RecordUser(ctx context.Context, action audit_model.Action, doer, u *user_model.User, format string, args ...interface{}) {
+	record(ctx, action, doer, audit_model.ScopeUser, u.ID, u.Name, format, args...)
+}
+
+// RecordOrganization records an action on an organization
+func RecordOrganization(ctx context.Context, action audit_model.Action, doer *user_model.User, org *organization.Organization, format string, args ...interface{}) {
+	record(ctx, action, doer, audit_model.ScopeOrganization, org.ID, org.Name, format, args...)
+}
+
+// RecordTeam records an action on a team in the scope of its organization
+func RecordTeam(ctx context.Context, action audit_model.Action, doer *user_model.User, t *organization.Team, format string, args ...interface{}) {
+	org, err := organization.GetOrgByID(ctx, t.OrgID)
+	if err != nil {
+		log.Error("Unable to get the organization %d of team %d: %v", t.OrgID, t.ID, err)
+		record(ctx, action, doer, audit_model.ScopeOrganization, t.OrgID, "", format, args...)
+		return
+	}
+	RecordOrganization(ctx, action, doer, org, format, args...)
+}
+
+// RecordRepository records an action on a repository
+func RecordRepository(ctx context.Context, action audit_model.Action, doer *user_model.User, repo *repo_model.Repository, format string, args ...interface{}) {
+	record(ctx, action, doer, audit_model.ScopeRepository, repo.ID, repo.FullName(), format, args...)
+}
+
+// RecordUserPrivileges records the changes of the site administrator and the prohibit login flags of a user
+func RecordUserPrivileges(ctx context.Context, doer, u *user_model.User, wasAdmin, wasProhibitLogin bool) {
+	if u.IsAdmin != wasAdmin {
+		if u.IsAdmin {
+			RecordUser(ctx, audit_model.ActionUserSiteAdminGrant, doer, u, "Granted site administrator privileges to %s", u.Name)
+		} else {
+			RecordUser(ctx, audit_model.ActionUserSiteAdminRevoke, doer, u, "Revoked site administrator privileges from %s", u.Name)
+		}
+	}
+	if u.ProhibitLogin != wasProhibitLogin {
+		if u.ProhibitLogin {
+			RecordUser(ctx, audit_model.ActionUserProhibitLogin, doer, u, "Prohibited %s from signing in", u.Name)
+		} else {
+			RecordUser(ctx, audit_model.ActionUserAllowLogin, doer, u, "Allowed %s to sign in", u.Name)
+		}
+	}
+}
+
+// RecordRepositoryVisibility records the change of the visibility of a repository
+func RecordRepositoryVisibility(ctx context.Context, doer *user_model.User, repo *repo_model.Repository) {
+	visibility := "public"
+	if repo.IsPrivate {
+		visibility = "private"
+	}
+	RecordRepository(ctx, audit_model.ActionRepositoryVisibility, doer, repo, "Made the repository %s", visibility)
+}
@@ -5,15 +5,18 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
+	actions_model "code.gitea.io/gitea/models/actions"
 	auth_model "code.gitea.io/gitea/models/auth"
 	user_model "code.gitea.io/gitea/models/user"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/modules/web/middleware"
 )
 
@@ -99,11 +102,24 @@ func (b *Basic) Verify(req *http.Request, w http.ResponseWriter, store DataStore
 		}
 
 		store.GetData()["IsApiToken"] = true
+		store.GetData()["ApiToken"] = token
 		return u, nil
 	} else if !auth_model.IsErrAccessTokenNotExist(err) && !auth_model.IsErrAccessTokenEmpty(err) {
 		log.Error("GetAccessTokenBySha: %v", err)
 	}
 
+	// the token of a running actions job can only clone the repository the job runs for, see the git http handler
+	if isGitRawReleaseOrLFSPath(req) {
+		job, err := actions_model.GetRunningJobByToken(req.Context(), authToken)
+		if err == nil {
+			log.Trace("Basic Authorization: Valid token of actions job[%d]", job.ID)
+			store.GetData()["ActionsJob"] = job
+			return nil, nil
+		} else if !errors.Is(err, util.ErrNotExist) {
+			log.Error("GetRunningJobByToken: %v", err)
+		}
+	}
+
 	if !setting.Service.EnableBasicAuth {
 		return nil, nil
 	}
@@ -59,8 +59,8 @@ func (o *OAuth2) Name() string {
 }
 
 // userIDFromToken returns the user id corresponding to the OAuth token.
-// It will set 'IsApiToken' to true if the token is an API token and
-// set 'ApiTokenScope' to the scope of the access token
+// It will set 'IsApiToken' to true if the token is an API token,
+// set 'ApiTokenScope' to the scope of the access token and 'ApiToken' to the access token itself
 func (o *OAuth2) userIDFromToken(req *http.Request, store DataStore) int64 {
 	_ = req.ParseForm()
 
@@ -83,6 +83,12 @@ func (o *OAuth2) userIDFromToken(req *http.Request, store DataStore) int64 {
 		return 0
 	}
 
+	return UserIDFromToken(tokenSHA, store)
+}
+
+// UserIDFromToken returns the user id corresponding to the access token or OAuth token and stores the token
+// in the data store like OAuth2 does. It is used by the auth methods of clients which send the token in their own way.
+func UserIDFromToken(tokenSHA string, store DataStore) int64 {
 	// Let's see if token is valid.
 	if strings.Contains(tokenSHA, ".") {
 		uid := CheckOAuthAccessToken(tokenSHA)
@@ -105,6 +111,7 @@ func (o *OAuth2) userIDFromToken(req *http.Request, store DataStore) int64 {
 	}
 	store.GetData()["IsApiToken"] = true
 	store.GetData()["ApiTokenScope"] = t.Scope
+	store.GetData()["ApiToken"] = t
 	return t.UID
 }
 
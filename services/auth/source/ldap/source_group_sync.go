@@ -4,11 +4,11 @@
 package ldap
 
 import (
-	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/models/organization"
 	user_model "code.gitea.io/gitea/models/user"
 	"code.gitea.io/gitea/modules/log"
+	org_service "code.gitea.io/gitea/services/org"
 )
 
 // SyncLdapGroupsToTeams maps LDAP groups to organization and team memberships
@@ -46,7 +46,7 @@ func (source *Source) SyncLdapGroupsToTeams(user *user_model.User, ldapTeamAdd,
 			} else {
 				continue
 			}
-			err := models.AddTeamMember(team, user.ID)
+			err := org_service.AddTeamMember(db.DefaultContext, nil, team, user)
 			if err != nil {
 				log.Error("LDAP group sync: Could not add user to team: %v", err)
 			}
@@ -85,7 +85,7 @@ func removeMappedMemberships(user *user_model.User, ldapTeamRemove map[string][]
 			} else {
 				continue
 			}
-			err = models.RemoveTeamMember(team, user.ID)
+			err = org_service.RemoveTeamMember(db.DefaultContext, nil, team, user)
 			if err != nil {
 				log.Error("LDAP group sync: Could not remove user from team: %v", err)
 			}
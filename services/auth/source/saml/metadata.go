@@ -0,0 +1,182 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package saml
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"code.gitea.io/gitea/modules/proxy"
+)
+
+// The SAML bindings which are used to exchange the messages with the identity provider
+const (
+	BindingHTTPRedirect = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+	BindingHTTPPost     = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+)
+
+// NameIDFormatUnspecified lets the identity provider choose the format of the name identifier
+const NameIDFormatUnspecified = "urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified"
+
+// maxMetadataSize is the maximum size of the metadata which is fetched from the identity provider
+const maxMetadataSize = 1 << 20
+
+type keyDescriptorXML struct {
+	Use              string   `xml:"use,attr"`
+	X509Certificates []string `xml:"http://www.w3.org/2000/09/xmldsig# KeyInfo>X509Data>X509Certificate"`
+}
+
+type endpointXML struct {
+	Binding  string `xml:"Binding,attr"`
+	Location string `xml:"Location,attr"`
+}
+
+type entityDescriptorXML struct {
+	EntityID          string `xml:"entityID,attr"`
+	IDPSSODescriptors []struct {
+		KeyDescriptors       []keyDescriptorXML `xml:"KeyDescriptor"`
+		SingleSignOnServices []endpointXML      `xml:"SingleSignOnService"`
+	} `xml:"IDPSSODescriptor"`
+}
+
+type entitiesDescriptorXML struct {
+	EntityDescriptors []entityDescriptorXML `xml:"EntityDescriptor"`
+}
+
+// IdentityProvider is what the service provider needs to know of the identity provider, it is read from its metadata
+type IdentityProvider struct {
+	EntityID     string
+	SSOURL       string // the location of the single sign-on service with the HTTP-Redirect binding
+	Certificates []*x509.Certificate
+}
+
+// ParseIdentityProviderMetadata parses the metadata of an identity provider,
+// the metadata could be an EntityDescriptor or an EntitiesDescriptor which contains the identity provider.
+func ParseIdentityProviderMetadata(data []byte) (*IdentityProvider, error) {
+	root, err := parseXML(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metadata: %w", err)
+	}
+
+	var descriptors []entityDescriptorXML
+	switch {
+	case isElement(root, nsMetadata, "EntityDescriptor"):
+		var descriptor entityDescriptorXML
+		if err := xml.Unmarshal(data, &descriptor); err != nil {
+			return nil, fmt.Errorf("invalid metadata: %w", err)
+		}
+		descriptors = append(descriptors, descriptor)
+	case isElement(root, nsMetadata, "EntitiesDescriptor"):
+		var entities entitiesDescriptorXML
+		if err := xml.Unmarshal(data, &entities); err != nil {
+			return nil, fmt.Errorf("invalid metadata: %w", err)
+		}
+		descriptors = entities.EntityDescriptors
+	default:
+		return nil, fmt.Errorf("metadata has an unexpected root element %s", root.Tag)
+	}
+
+	for _, descriptor := range descriptors {
+		for _, idp := range descriptor.IDPSSODescriptors {
+			provider := &IdentityProvider{EntityID: descriptor.EntityID}
+			for _, sso := range idp.SingleSignOnServices {
+				if sso.Binding == BindingHTTPRedirect {
+					provider.SSOURL = sso.Location
+					break
+				}
+			}
+			for _, key := range idp.KeyDescriptors {
+				if key.Use != "" && key.Use != "signing" {
+					continue
+				}
+				for _, encoded := range key.X509Certificates {
+					der, err := decodeBase64(encoded)
+					if err != nil {
+						return nil, fmt.Errorf("invalid certificate in metadata: %w", err)
+					}
+					cert, err := x509.ParseCertificate(der)
+					if err != nil {
+						return nil, fmt.Errorf("invalid certificate in metadata: %w", err)
+					}
+					provider.Certificates = append(provider.Certificates, cert)
+				}
+			}
+
+			if provider.EntityID == "" {
+				return nil, errors.New("metadata has no entityID")
+			} else if provider.SSOURL == "" {
+				return nil, errors.New("identity provider has no single sign-on service with the HTTP-Redirect binding")
+			} else if len(provider.Certificates) == 0 {
+				return nil, errors.New("identity provider has no signing certificate")
+			}
+			return provider, nil
+		}
+	}
+	return nil, errors.New("metadata has no identity provider")
+}
+
+// FetchIdentityProviderMetadata downloads the metadata of an identity provider
+func FetchIdentityProviderMetadata(ctx context.Context, metadataURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{
+		Timeout:   time.Minute,
+		Transport: &http.Transport{Proxy: proxy.Proxy()},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s fetching %s", resp.Status, metadataURL)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxMetadataSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxMetadataSize {
+		return nil, fmt.Errorf("metadata of %s is larger than %d bytes", metadataURL, maxMetadataSize)
+	}
+	return data, nil
+}
+
+type spEntityDescriptorXML struct {
+	XMLName         xml.Name `xml:"urn:oasis:names:tc:SAML:2.0:metadata EntityDescriptor"`
+	EntityID        string   `xml:"entityID,attr"`
+	SPSSODescriptor struct {
+		AuthnRequestsSigned        bool   `xml:"AuthnRequestsSigned,attr"`
+		WantAssertionsSigned       bool   `xml:"WantAssertionsSigned,attr"`
+		ProtocolSupportEnumeration string `xml:"protocolSupportEnumeration,attr"`
+		NameIDFormat               string `xml:"NameIDFormat"`
+		AssertionConsumerService   struct {
+			endpointXML
+			Index int `xml:"index,attr"`
+		}
+	}
+}
+
+// ServiceProviderMetadata returns the metadata of the service provider which the identity provider is configured with
+func ServiceProviderMetadata(entityID, acsURL, nameIDFormat string) ([]byte, error) {
+	var descriptor spEntityDescriptorXML
+	descriptor.EntityID = entityID
+	descriptor.SPSSODescriptor.WantAssertionsSigned = true
+	descriptor.SPSSODescriptor.ProtocolSupportEnumeration = nsProtocol
+	descriptor.SPSSODescriptor.NameIDFormat = nameIDFormat
+	descriptor.SPSSODescriptor.AssertionConsumerService.Binding = BindingHTTPPost
+	descriptor.SPSSODescriptor.AssertionConsumerService.Location = acsURL
+	data, err := xml.MarshalIndent(descriptor, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
@@ -0,0 +1,33 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package saml
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	mc "gitea.com/go-chi/cache"
+)
+
+// assertionMutex serializes the check and the record of the consumed assertions,
+// the cache has no primitive which adds a key only if it is absent
+var assertionMutex sync.Mutex
+
+// markAssertionUsed records in the cache that an assertion has been consumed until it expires and returns false
+// when it has been consumed before. Concurrent posts of an assertion to this instance are serialized, so only one
+// of them is accepted. The instances of a cluster which share the cache see the consumed assertions too, but they
+// don't lock each other, so the same assertion posted to two instances at the same moment could pass on both.
+func markAssertionUsed(c mc.Cache, sourceID int64, assertionID string, expiry, now time.Time) bool {
+	key := fmt.Sprintf("saml_assertion_%d_%s", sourceID, assertionID)
+
+	assertionMutex.Lock()
+	defer assertionMutex.Unlock()
+
+	if c.IsExist(key) {
+		return false
+	}
+	ttl := int64(expiry.Sub(now).Seconds()) + 1
+	return c.Put(key, true, ttl) == nil
+}
@@ -0,0 +1,136 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// requestTimeout is how long the identity provider is given to answer an authentication request
+const requestTimeout = 10 * time.Minute
+
+type authnRequestXML struct {
+	XMLName                     xml.Name `xml:"urn:oasis:names:tc:SAML:2.0:protocol AuthnRequest"`
+	ID                          string   `xml:"ID,attr"`
+	Version                     string   `xml:"Version,attr"`
+	IssueInstant                string   `xml:"IssueInstant,attr"`
+	Destination                 string   `xml:"Destination,attr"`
+	AssertionConsumerServiceURL string   `xml:"AssertionConsumerServiceURL,attr"`
+	ProtocolBinding             string   `xml:"ProtocolBinding,attr"`
+	Issuer                      string   `xml:"urn:oasis:names:tc:SAML:2.0:assertion Issuer"`
+	NameIDPolicy                struct {
+		Format      string `xml:"Format,attr,omitempty"`
+		AllowCreate bool   `xml:"AllowCreate,attr"`
+	} `xml:"urn:oasis:names:tc:SAML:2.0:protocol NameIDPolicy"`
+}
+
+// AuthnRequestOptions represents the options of an authentication request
+type AuthnRequestOptions struct {
+	EntityID     string // the entity ID of the service provider
+	ACSURL       string
+	NameIDFormat string
+	// RedirectTo is where the user is sent after signing in, it is carried by the RelayState
+	RedirectTo string
+	Secret     string // the key which the RelayState is signed with
+	Now        time.Time
+}
+
+// AuthnRequestURL returns the URL which sends the user to the identity provider with an authentication request
+// by the HTTP-Redirect binding, the answer of the identity provider is validated against the returned RelayState
+// and has to be posted by the browser which has been sent with the returned request ID.
+func AuthnRequestURL(idp *IdentityProvider, opts AuthnRequestOptions) (redirectURL, requestID string, err error) {
+	nonce := make([]byte, 12)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", "", err
+	}
+	requestID = "id-" + base64.RawURLEncoding.EncodeToString(nonce)
+
+	req := authnRequestXML{
+		ID:                          requestID,
+		Version:                     "2.0",
+		IssueInstant:                opts.Now.UTC().Format(time.RFC3339),
+		Destination:                 idp.SSOURL,
+		AssertionConsumerServiceURL: opts.ACSURL,
+		ProtocolBinding:             BindingHTTPPost,
+		Issuer:                      opts.EntityID,
+	}
+	req.NameIDPolicy.Format = opts.NameIDFormat
+	req.NameIDPolicy.AllowCreate = true
+	data, err := xml.Marshal(req)
+	if err != nil {
+		return "", "", err
+	}
+
+	// the HTTP-Redirect binding compresses the message by DEFLATE without the zlib header
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := w.Write(data); err != nil {
+		return "", "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", "", err
+	}
+
+	u, err := url.Parse(idp.SSOURL)
+	if err != nil {
+		return "", "", err
+	}
+	query := u.Query()
+	query.Set("SAMLRequest", base64.StdEncoding.EncodeToString(buf.Bytes()))
+	query.Set("RelayState", signRelayState(opts.Secret, requestID, opts.Now.Add(requestTimeout), opts.RedirectTo))
+	u.RawQuery = query.Encode()
+	return u.String(), requestID, nil
+}
+
+// The RelayState is returned by the identity provider with the response. The response is posted across sites,
+// so the session cookie is not sent with it, instead the RelayState carries the ID of the request which
+// the response must be in response to and it is signed so it cannot be forged.
+func signRelayState(secret, requestID string, expiry time.Time, redirectTo string) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(strings.Join([]string{requestID, strconv.FormatInt(expiry.Unix(), 10), redirectTo}, "\n")))
+	return payload + "." + relayStateMAC(secret, payload)
+}
+
+func relayStateMAC(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("saml-relay-state\n" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// verifyRelayState verifies the RelayState and returns the request ID and the redirect it carries
+func verifyRelayState(secret, relayState string, now time.Time) (requestID, redirectTo string, err error) {
+	payload, mac, ok := strings.Cut(relayState, ".")
+	if !ok || !hmac.Equal([]byte(mac), []byte(relayStateMAC(secret, payload))) {
+		return "", "", errors.New("invalid RelayState")
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", "", errors.New("invalid RelayState")
+	}
+	fields := strings.SplitN(string(decoded), "\n", 3)
+	if len(fields) != 3 {
+		return "", "", errors.New("invalid RelayState")
+	}
+	expiry, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return "", "", errors.New("invalid RelayState")
+	}
+	if now.Unix() >= expiry {
+		return "", "", errors.New("authentication request has expired")
+	}
+	return fields[0], fields[2], nil
+}
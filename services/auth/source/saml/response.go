@@ -0,0 +1,258 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package saml
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+)
+
+const (
+	statusSuccess        = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	subjectMethodBearer  = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+	defaultMaxClockSkew  = 3 * time.Minute
+	maxAssertionLifetime = time.Hour
+)
+
+type assertionXML struct {
+	ID      string `xml:"ID,attr"`
+	Issuer  string `xml:"Issuer"`
+	Subject struct {
+		NameID               string `xml:"NameID"`
+		SubjectConfirmations []struct {
+			Method string `xml:"Method,attr"`
+			Data   struct {
+				NotOnOrAfter string `xml:"NotOnOrAfter,attr"`
+				Recipient    string `xml:"Recipient,attr"`
+				InResponseTo string `xml:"InResponseTo,attr"`
+			} `xml:"SubjectConfirmationData"`
+		} `xml:"SubjectConfirmation"`
+	} `xml:"Subject"`
+	Conditions struct {
+		NotBefore            string `xml:"NotBefore,attr"`
+		NotOnOrAfter         string `xml:"NotOnOrAfter,attr"`
+		AudienceRestrictions []struct {
+			Audiences []string `xml:"Audience"`
+		} `xml:"AudienceRestriction"`
+	} `xml:"Conditions"`
+	AuthnStatements []struct {
+		SessionIndex string `xml:"SessionIndex,attr"`
+	} `xml:"AuthnStatement"`
+	Attributes []struct {
+		Name         string   `xml:"Name,attr"`
+		FriendlyName string   `xml:"FriendlyName,attr"`
+		Values       []string `xml:"AttributeValue"`
+	} `xml:"AttributeStatement>Attribute"`
+}
+
+// Assertion is the identity of the user which has been asserted by the identity provider
+type Assertion struct {
+	ID           string
+	NameID       string
+	SessionIndex string
+	// Expiry is when the assertion cannot be used any more, it must be remembered until then against replays
+	Expiry time.Time
+	// Attributes contains the values of the attributes by their names and by their friendly names
+	Attributes map[string][]string
+}
+
+// Attribute returns the first value of an attribute
+func (a *Assertion) Attribute(name string) string {
+	if values := a.Attributes[name]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// ResponseOptions are what a response of the identity provider is validated against
+type ResponseOptions struct {
+	EntityID     string // the entity ID of the service provider
+	ACSURL       string
+	RequestID    string // the ID of the authentication request which has been sent to the identity provider
+	Now          time.Time
+	MaxClockSkew time.Duration
+}
+
+// ParseResponse verifies the signature of a response of the identity provider, validates it and returns its assertion,
+// either the response or the assertion must be signed by the identity provider.
+func ParseResponse(idp *IdentityProvider, data []byte, opts ResponseOptions) (*Assertion, error) {
+	if opts.MaxClockSkew == 0 {
+		opts.MaxClockSkew = defaultMaxClockSkew
+	}
+
+	response, err := parseXML(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	if !isElement(response, nsProtocol, "Response") {
+		return nil, fmt.Errorf("unexpected element %s", response.Tag)
+	}
+
+	// once the response has been verified, only its signed content is used
+	responseSigned := hasSignature(response)
+	if responseSigned {
+		canonical, err := verifySignature(response, idp.Certificates, opts.Now)
+		if err != nil {
+			return nil, fmt.Errorf("invalid response signature: %w", err)
+		}
+		if response, err = parseXML(canonical); err != nil {
+			return nil, err
+		}
+	}
+
+	if issuers := childElements(response, nsAssertion, "Issuer"); len(issuers) > 0 && strings.TrimSpace(issuers[0].Text()) != idp.EntityID {
+		return nil, fmt.Errorf("response is issued by %q rather than the identity provider", issuers[0].Text())
+	}
+	if dest := response.SelectAttrValue("Destination", ""); dest != "" && dest != opts.ACSURL {
+		return nil, fmt.Errorf("response is sent to %q rather than %q", dest, opts.ACSURL)
+	}
+	if inResponseTo := response.SelectAttrValue("InResponseTo", ""); inResponseTo != opts.RequestID {
+		return nil, fmt.Errorf("response is in response to %q rather than %q", inResponseTo, opts.RequestID)
+	}
+	if err := checkStatus(response); err != nil {
+		return nil, err
+	}
+
+	if len(childElements(response, nsAssertion, "EncryptedAssertion")) > 0 {
+		return nil, errors.New("encrypted assertions are not supported")
+	}
+	assertionEl, err := childElement(response, nsAssertion, "Assertion")
+	if err != nil {
+		return nil, err
+	}
+	var canonical []byte
+	if hasSignature(assertionEl) || !responseSigned {
+		if canonical, err = verifySignature(assertionEl, idp.Certificates, opts.Now); err != nil {
+			return nil, fmt.Errorf("invalid assertion signature: %w", err)
+		}
+	} else if canonical, err = canonicalize(assertionEl); err != nil {
+		return nil, err
+	}
+
+	var assertion assertionXML
+	if err := xml.Unmarshal(canonical, &assertion); err != nil {
+		return nil, fmt.Errorf("invalid assertion: %w", err)
+	}
+	return validateAssertion(idp, &assertion, opts)
+}
+
+func checkStatus(response *etree.Element) error {
+	status, err := childElement(response, nsProtocol, "Status")
+	if err != nil {
+		return err
+	}
+	code, err := childElement(status, nsProtocol, "StatusCode")
+	if err != nil {
+		return err
+	}
+	if code.SelectAttrValue("Value", "") == statusSuccess {
+		return nil
+	}
+	// the second level status code tells why the authentication has failed
+	detail := code.SelectAttrValue("Value", "")
+	for _, sub := range childElements(code, nsProtocol, "StatusCode") {
+		detail += " " + sub.SelectAttrValue("Value", "")
+	}
+	for _, msg := range childElements(status, nsProtocol, "StatusMessage") {
+		detail += ": " + msg.Text()
+	}
+	return fmt.Errorf("authentication failed at the identity provider: %s", detail)
+}
+
+func validateAssertion(idp *IdentityProvider, assertion *assertionXML, opts ResponseOptions) (*Assertion, error) {
+	assertion.Issuer = strings.TrimSpace(assertion.Issuer)
+	assertion.Subject.NameID = strings.TrimSpace(assertion.Subject.NameID)
+	if assertion.Issuer != idp.EntityID {
+		return nil, fmt.Errorf("assertion is issued by %q rather than the identity provider", assertion.Issuer)
+	}
+	if assertion.ID == "" {
+		return nil, errors.New("assertion has no ID")
+	}
+	if assertion.Subject.NameID == "" {
+		return nil, errors.New("assertion has no NameID")
+	}
+
+	conditions := assertion.Conditions
+	if conditions.NotBefore != "" {
+		notBefore, err := time.Parse(time.RFC3339, conditions.NotBefore)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NotBefore: %w", err)
+		}
+		if opts.Now.Add(opts.MaxClockSkew).Before(notBefore) {
+			return nil, errors.New("assertion is not valid yet")
+		}
+	}
+	expiry := opts.Now.Add(maxAssertionLifetime)
+	if conditions.NotOnOrAfter != "" {
+		notOnOrAfter, err := time.Parse(time.RFC3339, conditions.NotOnOrAfter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NotOnOrAfter: %w", err)
+		}
+		if !opts.Now.Add(-opts.MaxClockSkew).Before(notOnOrAfter) {
+			return nil, errors.New("assertion has expired")
+		}
+		if notOnOrAfter.Before(expiry) {
+			expiry = notOnOrAfter
+		}
+	}
+	// every audience restriction must be satisfied
+	for _, restriction := range conditions.AudienceRestrictions {
+		found := false
+		for _, audience := range restriction.Audiences {
+			if audience == opts.EntityID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("assertion is not intended for %q", opts.EntityID)
+		}
+	}
+
+	// the assertion must be confirmed as a bearer assertion which has been sent to us in response to our request
+	confirmed := false
+	for _, confirmation := range assertion.Subject.SubjectConfirmations {
+		if confirmation.Method != subjectMethodBearer {
+			continue
+		}
+		data := confirmation.Data
+		if data.Recipient != opts.ACSURL || data.InResponseTo != opts.RequestID {
+			continue
+		}
+		notOnOrAfter, err := time.Parse(time.RFC3339, data.NotOnOrAfter)
+		if err != nil || !opts.Now.Add(-opts.MaxClockSkew).Before(notOnOrAfter) {
+			continue
+		}
+		if notOnOrAfter.Before(expiry) {
+			expiry = notOnOrAfter
+		}
+		confirmed = true
+		break
+	}
+	if !confirmed {
+		return nil, errors.New("assertion has no valid bearer subject confirmation")
+	}
+
+	result := &Assertion{
+		ID:         assertion.ID,
+		NameID:     assertion.Subject.NameID,
+		Expiry:     expiry.Add(opts.MaxClockSkew),
+		Attributes: make(map[string][]string),
+	}
+	if len(assertion.AuthnStatements) > 0 {
+		result.SessionIndex = assertion.AuthnStatements[0].SessionIndex
+	}
+	for _, attr := range assertion.Attributes {
+		result.Attributes[attr.Name] = append(result.Attributes[attr.Name], attr.Values...)
+		if attr.FriendlyName != "" && attr.FriendlyName != attr.Name {
+			result.Attributes[attr.FriendlyName] = append(result.Attributes[attr.FriendlyName], attr.Values...)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,276 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package saml
+
+import (
+	"crypto/x509"
+	"encoding/xml"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	mc "gitea.com/go-chi/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+// The fixtures are a response of an identity provider with an assertion which is signed by the certificate of its metadata
+var fixtureOptions = ResponseOptions{
+	EntityID:  "https://try.gitea.io/user/saml/corporate/metadata",
+	ACSURL:    "https://try.gitea.io/user/saml/corporate/acs",
+	RequestID: "id-request",
+	Now:       time.Date(2023, 1, 1, 0, 1, 0, 0, time.UTC),
+}
+
+func loadFixtures(t *testing.T) (*IdentityProvider, string) {
+	metadata, err := os.ReadFile("testdata/idp-metadata.xml")
+	assert.NoError(t, err)
+	idp, err := ParseIdentityProviderMetadata(metadata)
+	assert.NoError(t, err)
+	response, err := os.ReadFile("testdata/response.xml")
+	assert.NoError(t, err)
+	return idp, string(response)
+}
+
+func TestParseIdentityProviderMetadata(t *testing.T) {
+	idp, _ := loadFixtures(t)
+	assert.Equal(t, "https://idp.example.com/metadata", idp.EntityID)
+	assert.Equal(t, "https://idp.example.com/sso/redirect", idp.SSOURL)
+	assert.Len(t, idp.Certificates, 1)
+
+	_, err := ParseIdentityProviderMetadata([]byte(`<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" entityID="x"></md:EntityDescriptor>`))
+	assert.Error(t, err)
+}
+
+func TestParseResponse(t *testing.T) {
+	idp, response := loadFixtures(t)
+
+	assertion, err := ParseResponse(idp, []byte(response), fixtureOptions)
+	assert.NoError(t, err)
+	assert.Equal(t, "_assertion", assertion.ID)
+	assert.Equal(t, "jdoe", assertion.NameID)
+	assert.Equal(t, "_session", assertion.SessionIndex)
+	assert.Equal(t, "john.doe", assertion.Attribute("uid"))
+	assert.Equal(t, "john.doe", assertion.Attribute("urn:oid:0.9.2342.19200300.100.1.1"))
+	assert.Equal(t, "john.doe@example.com", assertion.Attribute("mail"))
+	assert.Equal(t, "John Doe", assertion.Attribute("displayName"))
+	assert.Equal(t, []string{"developers", "ops"}, assertion.Attributes["groups"])
+	assert.Equal(t, time.Date(2023, 1, 1, 0, 5, 0, 0, time.UTC).Add(defaultMaxClockSkew), assertion.Expiry)
+
+	t.Run("Tampered", func(t *testing.T) {
+		_, err := ParseResponse(idp, []byte(strings.Replace(response, ">jdoe<", ">admin<", 1)), fixtureOptions)
+		assert.ErrorContains(t, err, "not valid for any of the certificates")
+	})
+
+	t.Run("InjectedComment", func(t *testing.T) {
+		// comments are not signed, they must not truncate the signed value
+		assertion, err := ParseResponse(idp, []byte(strings.Replace(response, ">jdoe<", ">j<!---->doe<", 1)), fixtureOptions)
+		assert.NoError(t, err)
+		assert.Equal(t, "jdoe", assertion.NameID)
+	})
+
+	t.Run("RedeclaredNamespace", func(t *testing.T) {
+		// the assertion redeclares the namespace of the response, it is signed with the assertion
+		_, err := ParseResponse(idp, []byte(strings.Replace(response, `<saml:NameID `, `<saml:NameID xmlns:saml="urn:example:evil" `, 1)), fixtureOptions)
+		assert.ErrorContains(t, err, "not valid for any of the certificates")
+
+		_, err = ParseResponse(idp, []byte(strings.Replace(response, `<saml:Assertion xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" `, `<saml:Assertion xmlns:saml="urn:example:evil" `, 1)), fixtureOptions)
+		assert.Error(t, err)
+	})
+
+	t.Run("WrappedAssertion", func(t *testing.T) {
+		// an unsigned assertion next to the signed one must not be accepted
+		wrapped := strings.Replace(response, `<saml:Assertion `, `<saml:Assertion ID="_evil" Version="2.0"><saml:Issuer>https://idp.example.com/metadata</saml:Issuer></saml:Assertion><saml:Assertion `, 1)
+		_, err := ParseResponse(idp, []byte(wrapped), fixtureOptions)
+		assert.Error(t, err)
+	})
+
+	t.Run("UnknownCertificate", func(t *testing.T) {
+		_, err := ParseResponse(&IdentityProvider{EntityID: idp.EntityID}, []byte(response), fixtureOptions)
+		assert.ErrorContains(t, err, "not valid for any of the certificates")
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		opts := fixtureOptions
+		opts.Now = opts.Now.Add(time.Hour)
+		_, err := ParseResponse(idp, []byte(response), opts)
+		assert.ErrorContains(t, err, "expired")
+	})
+
+	t.Run("CertificateNotValidYet", func(t *testing.T) {
+		opts := fixtureOptions
+		opts.Now = opts.Now.Add(-time.Hour)
+		_, err := ParseResponse(idp, []byte(response), opts)
+		assert.ErrorContains(t, err, "Cert is not valid at this time")
+	})
+
+	t.Run("OtherRequest", func(t *testing.T) {
+		opts := fixtureOptions
+		opts.RequestID = "id-other"
+		_, err := ParseResponse(idp, []byte(response), opts)
+		assert.Error(t, err)
+	})
+
+	t.Run("OtherAudience", func(t *testing.T) {
+		opts := fixtureOptions
+		opts.EntityID = "https://other.example.com/metadata"
+		_, err := ParseResponse(idp, []byte(response), opts)
+		assert.ErrorContains(t, err, "not intended for")
+	})
+}
+
+// loadInteropFixtures loads a response of a real identity provider and the certificates of its metadata,
+// they are taken from the test data of github.com/crewjam/saml.
+func loadInteropFixtures(t *testing.T, name string) (*IdentityProvider, string) {
+	metadata, err := os.ReadFile("testdata/" + name + "-idp-metadata.xml")
+	assert.NoError(t, err)
+	// the metadata has no single sign-on service with the HTTP-Redirect binding, only the certificates are used
+	var descriptor entityDescriptorXML
+	assert.NoError(t, xml.Unmarshal(metadata, &descriptor))
+	idp := &IdentityProvider{EntityID: descriptor.EntityID}
+	for _, key := range descriptor.IDPSSODescriptors[0].KeyDescriptors {
+		for _, encoded := range key.X509Certificates {
+			der, err := decodeBase64(encoded)
+			assert.NoError(t, err)
+			cert, err := x509.ParseCertificate(der)
+			assert.NoError(t, err)
+			idp.Certificates = append(idp.Certificates, cert)
+		}
+	}
+	response, err := os.ReadFile("testdata/" + name + "-response.xml")
+	assert.NoError(t, err)
+	return idp, string(response)
+}
+
+func TestParseResponseInterop(t *testing.T) {
+	t.Run("Google", func(t *testing.T) {
+		// the response is signed rather than the assertion
+		idp, response := loadInteropFixtures(t, "google")
+		opts := ResponseOptions{
+			EntityID:  "https://29ee6d2e.ngrok.io/saml/metadata",
+			ACSURL:    "https://29ee6d2e.ngrok.io/saml/acs",
+			RequestID: "id-fd419a5ab0472645427f8e07d87a3a5dd0b2e9a6",
+			Now:       time.Date(2016, 1, 5, 16, 55, 39, 0, time.UTC),
+		}
+
+		assertion, err := ParseResponse(idp, []byte(response), opts)
+		assert.NoError(t, err)
+		assert.Equal(t, "ross@octolabs.io", assertion.NameID)
+		assert.Equal(t, "_9e764952e6a261e19409a3825581033d", assertion.SessionIndex)
+		assert.Equal(t, "Ross", assertion.Attribute("firstName"))
+		assert.Equal(t, "Kinder", assertion.Attribute("lastName"))
+
+		t.Run("InjectedComment", func(t *testing.T) {
+			assertion, err := ParseResponse(idp, []byte(strings.Replace(response, "ross@octolabs.io", "ross@<!-- and a comment -->octolabs.io", 1)), opts)
+			assert.NoError(t, err)
+			assert.Equal(t, "ross@octolabs.io", assertion.NameID)
+
+			_, err = ParseResponse(idp, []byte(strings.Replace(response, "ross@octolabs.io", "ross@octolabs.io<!---->.evil.com", 1)), opts)
+			assert.ErrorContains(t, err, "not valid for any of the certificates")
+		})
+
+		t.Run("RedeclaredNamespace", func(t *testing.T) {
+			// the assertion namespace is declared by the unsigned assertion inside the signed response
+			_, err := ParseResponse(idp, []byte(strings.Replace(response, `<saml2:NameID>`, `<saml2:NameID xmlns:saml2="urn:example:evil">`, 1)), opts)
+			assert.ErrorContains(t, err, "not valid for any of the certificates")
+
+			// unused namespace declarations are not part of the canonical form
+			assertion, err := ParseResponse(idp, []byte(strings.Replace(response, `<saml2p:Status>`, `<saml2p:Status xmlns:evil="urn:example:evil">`, 1)), opts)
+			assert.NoError(t, err)
+			assert.Equal(t, "ross@octolabs.io", assertion.NameID)
+		})
+
+		t.Run("NotValidYet", func(t *testing.T) {
+			opts := opts
+			opts.Now = opts.Now.Add(-15 * time.Minute)
+			_, err := ParseResponse(idp, []byte(response), opts)
+			assert.ErrorContains(t, err, "not valid yet")
+		})
+	})
+
+	t.Run("OneLoginSHA1", func(t *testing.T) {
+		idp, response := loadInteropFixtures(t, "onelogin")
+		opts := ResponseOptions{
+			EntityID:  "https://29ee6d2e.ngrok.io/saml/metadata",
+			ACSURL:    "https://29ee6d2e.ngrok.io/saml/acs",
+			RequestID: "id-d40c15c104b52691eccf0a2a5c8a15595be75423",
+			Now:       time.Date(2016, 1, 5, 17, 53, 12, 0, time.UTC),
+		}
+
+		_, err := ParseResponse(idp, []byte(response), opts)
+		assert.ErrorContains(t, err, "unsupported signature algorithm")
+	})
+}
+
+func TestRelayState(t *testing.T) {
+	now := time.Now()
+	relayState := signRelayState("secret", "id-request", now.Add(requestTimeout), "/org/repo")
+
+	requestID, redirectTo, err := verifyRelayState("secret", relayState, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "id-request", requestID)
+	assert.Equal(t, "/org/repo", redirectTo)
+
+	_, _, err = verifyRelayState("other", relayState, now)
+	assert.Error(t, err)
+	_, _, err = verifyRelayState("secret", relayState, now.Add(requestTimeout))
+	assert.Error(t, err)
+}
+
+func TestMarkAssertionUsed(t *testing.T) {
+	c, err := mc.NewCacher(mc.Options{Adapter: "memory", Interval: 60})
+	assert.NoError(t, err)
+	// the memory adapter is shared by all cachers
+	assert.NoError(t, c.Flush())
+
+	now := time.Now()
+	assert.True(t, markAssertionUsed(c, 1, "_assertion", now.Add(time.Minute), now))
+	assert.False(t, markAssertionUsed(c, 1, "_assertion", now.Add(time.Minute), now))
+	assert.True(t, markAssertionUsed(c, 2, "_assertion", now.Add(time.Minute), now))
+}
+
+// slowCache widens the window between the check and the record of an assertion
+type slowCache struct {
+	mc.Cache
+}
+
+func (c slowCache) IsExist(key string) bool {
+	exist := c.Cache.IsExist(key)
+	time.Sleep(10 * time.Millisecond)
+	return exist
+}
+
+func TestMarkAssertionUsedConcurrently(t *testing.T) {
+	cacher, err := mc.NewCacher(mc.Options{Adapter: "memory", Interval: 60})
+	assert.NoError(t, err)
+	// the memory adapter is shared by all cachers
+	assert.NoError(t, cacher.Flush())
+	c := slowCache{cacher}
+
+	now := time.Now()
+	var accepted int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if markAssertionUsed(c, 1, "_replayed", now.Add(time.Minute), now) {
+				atomic.AddInt32(&accepted, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.EqualValues(t, 1, accepted)
+}
+
+func TestServiceProviderMetadata(t *testing.T) {
+	data, err := ServiceProviderMetadata(fixtureOptions.EntityID, fixtureOptions.ACSURL, NameIDFormatUnspecified)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `entityID="https://try.gitea.io/user/saml/corporate/metadata"`)
+	assert.Contains(t, string(data), `Location="https://try.gitea.io/user/saml/corporate/acs"`)
+	assert.Contains(t, string(data), `WantAssertionsSigned="true"`)
+}
@@ -0,0 +1,132 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package saml
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+// The signatures are verified by goxmldsig, SHA-1 is not accepted although the library supports it.
+// ECDSA is not accepted either, the library does not decode the ECDSA signature values of XML signatures.
+var (
+	digestAlgorithms = map[string]bool{
+		"http://www.w3.org/2001/04/xmlenc#sha256":       true,
+		"http://www.w3.org/2001/04/xmldsig-more#sha384": true,
+		"http://www.w3.org/2001/04/xmlenc#sha512":       true,
+	}
+	signatureAlgorithms = map[string]bool{
+		dsig.RSASHA256SignatureMethod: true,
+		dsig.RSASHA384SignatureMethod: true,
+		dsig.RSASHA512SignatureMethod: true,
+	}
+)
+
+// errNotSigned is returned when the element has no signature
+var errNotSigned = errors.New("element is not signed")
+
+// hasSignature returns whether the element has an enveloped signature
+func hasSignature(el *etree.Element) bool {
+	return len(childElements(el, dsig.Namespace, dsig.SignatureTag)) > 0
+}
+
+// verifySignature verifies the enveloped signature of the element by the trusted certificates, which must be valid at now,
+// and returns the canonical form of the element without the signature. Only the returned bytes are covered by the signature,
+// the caller must read the signed content from them rather than from the element, a document could be wrapped
+// around the signed element.
+func verifySignature(el *etree.Element, certs []*x509.Certificate, now time.Time) ([]byte, error) {
+	sigs := childElements(el, dsig.Namespace, dsig.SignatureTag)
+	if len(sigs) == 0 {
+		return nil, errNotSigned
+	} else if len(sigs) > 1 {
+		return nil, errors.New("element has multiple signatures")
+	}
+	if err := checkSignatures(el); err != nil {
+		return nil, err
+	}
+
+	// the namespaces of the ancestors are part of the signed element
+	signed, err := detach(el)
+	if err != nil {
+		return nil, err
+	}
+
+	var verified *etree.Element
+	var lastErr error
+	for _, cert := range certs {
+		// every certificate is tried alone, the library only uses a certificate without KeyInfo if it is the only one
+		ctx := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{Roots: []*x509.Certificate{cert}})
+		ctx.Clock = dsig.NewFakeClockAt(now)
+		if verified, lastErr = ctx.Validate(signed); lastErr == nil {
+			break
+		}
+	}
+	if verified == nil {
+		if lastErr != nil {
+			return nil, fmt.Errorf("signature is not valid for any of the certificates of the identity provider: %w", lastErr)
+		}
+		return nil, errors.New("signature is not valid for any of the certificates of the identity provider")
+	}
+	return canonicalize(verified)
+}
+
+// checkSignatures checks the signatures of the element and of its descendants before the library verifies one of them,
+// the library accepts more than that. Every signature must cover exactly the element it is enveloped in by accepted algorithms.
+func checkSignatures(el *etree.Element) error {
+	for _, child := range el.ChildElements() {
+		var err error
+		if isElement(child, dsig.Namespace, dsig.SignatureTag) {
+			err = checkSignature(el, child)
+		} else {
+			err = checkSignatures(child)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkSignature(el, sig *etree.Element) error {
+	signedInfo, err := childElement(sig, dsig.Namespace, dsig.SignedInfoTag)
+	if err != nil {
+		return err
+	}
+	sigMethod, err := childElement(signedInfo, dsig.Namespace, dsig.SignatureMethodTag)
+	if err != nil {
+		return err
+	}
+	if alg := sigMethod.SelectAttrValue(dsig.AlgorithmAttr, ""); !signatureAlgorithms[alg] {
+		return fmt.Errorf("unsupported signature algorithm %q", alg)
+	}
+
+	ref, err := childElement(signedInfo, dsig.Namespace, dsig.ReferenceTag)
+	if err != nil {
+		return err
+	}
+	id := el.SelectAttrValue(dsig.DefaultIdAttr, "")
+	if uri := ref.SelectAttrValue(dsig.URIAttr, ""); id == "" || uri != "#"+id {
+		return fmt.Errorf("signature reference %q does not match the element ID %q", uri, id)
+	}
+	digestMethod, err := childElement(ref, dsig.Namespace, dsig.DigestMethodTag)
+	if err != nil {
+		return err
+	}
+	if alg := digestMethod.SelectAttrValue(dsig.AlgorithmAttr, ""); !digestAlgorithms[alg] {
+		return fmt.Errorf("unsupported digest algorithm %q", alg)
+	}
+	return nil
+}
+
+// decodeBase64 decodes the base64 content of an element which could be wrapped into lines
+func decodeBase64(s string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+}
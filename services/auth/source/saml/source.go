@@ -0,0 +1,101 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package saml
+
+import (
+	"net/url"
+	"strings"
+
+	"code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+//   _________   _____      _____  .____
+//  /   _____/  /  _  \    /     \ |    |
+//  \_____  \  /  /_\  \  /  \ /  \|    |
+//  /        \/    |    \/    Y    \    |___
+// /_______  /\____|__  /\____|__  /_______ \
+//         \/         \/         \/        \/
+
+// Source holds configuration for the SAML 2.0 service provider
+type Source struct {
+	IdentityProviderMetadata    string // the metadata XML of the identity provider
+	IdentityProviderMetadataURL string // where the metadata is imported from, if set it is refreshed when the source is saved
+	NameIDFormat                string
+	AttributeUsername           string // Username attribute, the NameID is used if it is not set
+	AttributeEmail              string // E-mail attribute
+	AttributeFullName           string // Full name attribute
+	AttributeGroups             string // Attribute containing the groups of the user
+	GroupTeamMap                string // Map SAML groups to teams
+	GroupTeamMapRemoval         bool   // Remove user from teams which are synchronized and user is not a member of the corresponding SAML group
+	AutoCreateUsers             bool   // Create a local user when an unknown user signs in
+	SkipLocalTwoFA              bool   `json:",omitempty"` // Skip Local 2fa for users authenticated with this source
+
+	// reference to the authSource
+	authSource *auth.Source
+}
+
+// FromDB fills up a SAMLConfig from serialized format.
+func (source *Source) FromDB(bs []byte) error {
+	return json.UnmarshalHandleDoubleEncode(bs, &source)
+}
+
+// ToDB exports a SAMLConfig to a serialized format.
+func (source *Source) ToDB() ([]byte, error) {
+	return json.Marshal(source)
+}
+
+// SetAuthSource sets the related AuthSource
+func (source *Source) SetAuthSource(authSource *auth.Source) {
+	source.authSource = authSource
+}
+
+// IsSkipLocalTwoFA returns if this source should skip local 2fa for password authentication
+func (source *Source) IsSkipLocalTwoFA() bool {
+	return source.SkipLocalTwoFA
+}
+
+// IdentityProvider parses the stored metadata of the identity provider
+func (source *Source) IdentityProvider() (*IdentityProvider, error) {
+	return ParseIdentityProviderMetadata([]byte(source.IdentityProviderMetadata))
+}
+
+// SAMLPath returns the path of the SAML endpoints of a source below the application URL
+func SAMLPath(sourceName string) string {
+	return "user/saml/" + url.PathEscape(sourceName)
+}
+
+// EntityID returns the entity ID of the service provider, it is the URL of its metadata
+func (source *Source) EntityID() string {
+	return strings.TrimSuffix(setting.AppURL, "/") + "/" + SAMLPath(source.authSource.Name) + "/metadata"
+}
+
+// ACSURL returns the URL of the assertion consumer service which the identity provider posts its responses to
+func (source *Source) ACSURL() string {
+	return strings.TrimSuffix(setting.AppURL, "/") + "/" + SAMLPath(source.authSource.Name) + "/acs"
+}
+
+// IsSecureAppURL returns true if the instance is served by https. The response of the identity provider is posted
+// across sites, browsers only send the cookie which binds the sign in to the browser with it when the cookie is secure.
+func IsSecureAppURL() bool {
+	return strings.HasPrefix(setting.AppURL, "https://")
+}
+
+// nameIDFormat returns the format of the name identifier which is requested from the identity provider
+func (source *Source) nameIDFormat() string {
+	if source.NameIDFormat == "" {
+		return NameIDFormatUnspecified
+	}
+	return source.NameIDFormat
+}
+
+// Metadata returns the metadata of the service provider which the identity provider is configured with
+func (source *Source) Metadata() ([]byte, error) {
+	return ServiceProviderMetadata(source.EntityID(), source.ACSURL(), source.nameIDFormat())
+}
+
+func init() {
+	auth.RegisterTypeConfig(auth.SAML, &Source{})
+}
@@ -0,0 +1,165 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package saml
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/cache"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/services/mailer"
+)
+
+var (
+	// ErrAssertionReplayed is returned when an assertion is posted more than once
+	ErrAssertionReplayed = errors.New("the SAML assertion has already been used")
+	// ErrRequestNotBound is returned when a response is posted by another browser than the one which has sent the request
+	ErrRequestNotBound = errors.New("the SAML response does not belong to the authentication request of this browser")
+)
+
+// SignInURL returns the URL which sends the user to the identity provider to sign in and the ID of the request,
+// which has to be remembered by the browser and passed to Authenticate with the response
+func (source *Source) SignInURL(redirectTo string) (redirectURL, requestID string, err error) {
+	idp, err := source.IdentityProvider()
+	if err != nil {
+		return "", "", err
+	}
+	return AuthnRequestURL(idp, AuthnRequestOptions{
+		EntityID:     source.EntityID(),
+		ACSURL:       source.ACSURL(),
+		NameIDFormat: source.nameIDFormat(),
+		RedirectTo:   redirectTo,
+		Secret:       setting.SecretKey,
+		Now:          time.Now(),
+	})
+}
+
+// Authenticate validates a response of the identity provider which has been posted to the assertion consumer service
+// with its RelayState by the browser which has remembered the boundRequestID. It returns the user which has signed in,
+// creating a local user if enabled, and where the user wanted to go before signing in.
+func (source *Source) Authenticate(samlResponse, relayState, boundRequestID string) (*user_model.User, string, error) {
+	now := time.Now()
+	requestID, redirectTo, err := verifyRelayState(setting.SecretKey, relayState, now)
+	if err != nil {
+		return nil, "", err
+	}
+	if boundRequestID == "" || subtle.ConstantTimeCompare([]byte(requestID), []byte(boundRequestID)) != 1 {
+		return nil, "", ErrRequestNotBound
+	}
+	data, err := decodeBase64(samlResponse)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid SAMLResponse: %w", err)
+	}
+	idp, err := source.IdentityProvider()
+	if err != nil {
+		return nil, "", err
+	}
+	assertion, err := ParseResponse(idp, data, ResponseOptions{
+		EntityID:  source.EntityID(),
+		ACSURL:    source.ACSURL(),
+		RequestID: requestID,
+		Now:       now,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	c := cache.GetCache()
+	if c == nil {
+		return nil, "", errors.New("SAML sign in requires the cache to detect replayed assertions")
+	}
+	if !markAssertionUsed(c, source.authSource.ID, assertion.ID, assertion.Expiry, now) {
+		return nil, "", ErrAssertionReplayed
+	}
+
+	user, err := source.getOrCreateUser(assertion)
+	if err != nil {
+		return nil, "", err
+	}
+	if user.ProhibitLogin {
+		return nil, "", user_model.ErrUserProhibitLogin{UID: user.ID, Name: user.Name}
+	}
+
+	var groups []string
+	if source.AttributeGroups != "" {
+		groups = assertion.Attributes[source.AttributeGroups]
+	}
+	source.SyncGroupsToTeams(user, groups)
+
+	return user, redirectTo, nil
+}
+
+// getOrCreateUser returns the user which is identified by the NameID of the assertion
+func (source *Source) getOrCreateUser(assertion *Assertion) (*user_model.User, error) {
+	fullName := ""
+	if source.AttributeFullName != "" {
+		fullName = strings.TrimSpace(assertion.Attribute(source.AttributeFullName))
+	}
+
+	user := &user_model.User{
+		LoginType:   auth.SAML,
+		LoginSource: source.authSource.ID,
+		LoginName:   assertion.NameID,
+	}
+	has, err := user_model.GetUser(user)
+	if err != nil {
+		return nil, err
+	}
+	if has {
+		if fullName != "" && user.FullName != fullName {
+			user.FullName = fullName
+			if err := user_model.UpdateUserCols(db.DefaultContext, user, "full_name"); err != nil {
+				return nil, err
+			}
+		}
+		return user, nil
+	}
+
+	if !source.AutoCreateUsers {
+		return nil, user_model.ErrUserNotExist{Name: assertion.NameID}
+	}
+
+	username := assertion.NameID
+	if source.AttributeUsername != "" {
+		username = strings.TrimSpace(assertion.Attribute(source.AttributeUsername))
+	}
+	if username == "" {
+		return nil, fmt.Errorf("SAML assertion has no %s attribute", source.AttributeUsername)
+	}
+	email := ""
+	if source.AttributeEmail != "" {
+		email = strings.TrimSpace(assertion.Attribute(source.AttributeEmail))
+	}
+	// Fallback.
+	if email == "" {
+		email = fmt.Sprintf("%s@localhost", username)
+	}
+
+	user = &user_model.User{
+		LowerName:   strings.ToLower(username),
+		Name:        username,
+		FullName:    fullName,
+		Email:       email,
+		LoginType:   auth.SAML,
+		LoginSource: source.authSource.ID,
+		LoginName:   assertion.NameID,
+	}
+	overwriteDefault := &user_model.CreateUserOverwriteOptions{
+		IsActive: util.OptionalBoolTrue,
+	}
+	if err := user_model.CreateUser(user, overwriteDefault); err != nil {
+		return nil, err
+	}
+
+	mailer.SendRegisterNotifyMail(user)
+
+	return user, nil
+}
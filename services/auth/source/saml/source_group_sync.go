@@ -0,0 +1,105 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package saml
+
+import (
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/organization"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/util"
+	org_service "code.gitea.io/gitea/services/org"
+)
+
+// parse SAML group team map and return map of SAML groups to organizations teams
+func (source *Source) mapGroupsToTeams() map[string]map[string][]string {
+	groupsToTeams := make(map[string]map[string][]string)
+	if source.GroupTeamMap == "" {
+		return groupsToTeams
+	}
+	if err := json.Unmarshal([]byte(source.GroupTeamMap), &groupsToTeams); err != nil {
+		log.Error("Failed to unmarshall SAML teams map: %v", err)
+	}
+	return groupsToTeams
+}
+
+// getMappedMemberships returns the organizations and teams to modify the users membership by the asserted groups
+func (source *Source) getMappedMemberships(groups []string) (map[string][]string, map[string][]string) {
+	membershipsToAdd := map[string][]string{}
+	membershipsToRemove := map[string][]string{}
+	for group, memberships := range source.mapGroupsToTeams() {
+		if util.SliceContainsString(groups, group) {
+			for org, teams := range memberships {
+				membershipsToAdd[org] = append(membershipsToAdd[org], teams...)
+			}
+		} else {
+			for org, teams := range memberships {
+				membershipsToRemove[org] = append(membershipsToRemove[org], teams...)
+			}
+		}
+	}
+	return membershipsToAdd, membershipsToRemove
+}
+
+// SyncGroupsToTeams maps the asserted SAML groups to organization and team memberships
+func (source *Source) SyncGroupsToTeams(user *user_model.User, groups []string) {
+	if source.GroupTeamMap == "" && !source.GroupTeamMapRemoval {
+		return
+	}
+	teamAdd, teamRemove := source.getMappedMemberships(groups)
+	orgCache := make(map[string]*organization.Organization)
+	teamCache := make(map[string]*organization.Team)
+
+	if source.GroupTeamMapRemoval {
+		// when the user is not a member of a mapped SAML group, remove mapped organizations/teams memberships
+		syncMappedMemberships(user, teamRemove, orgCache, teamCache, false)
+	}
+	syncMappedMemberships(user, teamAdd, orgCache, teamCache, true)
+}
+
+// syncMappedMemberships adds the user to or removes the user from the mapped teams
+func syncMappedMemberships(user *user_model.User, memberships map[string][]string, orgCache map[string]*organization.Organization, teamCache map[string]*organization.Team, add bool) {
+	var err error
+	for orgName, teamNames := range memberships {
+		org, ok := orgCache[orgName]
+		if !ok {
+			org, err = organization.GetOrgByName(orgName)
+			if err != nil {
+				// organization must be created before SAML group sync
+				log.Warn("SAML group sync: Could not find organisation %s: %v", orgName, err)
+				continue
+			}
+			orgCache[orgName] = org
+		}
+
+		for _, teamName := range teamNames {
+			team, ok := teamCache[orgName+teamName]
+			if !ok {
+				team, err = org.GetTeam(teamName)
+				if err != nil {
+					// team must be created before SAML group sync
+					log.Warn("SAML group sync: Could not find team %s: %v", teamName, err)
+					continue
+				}
+				teamCache[orgName+teamName] = team
+			}
+
+			isMember, err := organization.IsTeamMember(db.DefaultContext, org.ID, team.ID, user.ID)
+			if err != nil || isMember == add {
+				continue
+			}
+			if add {
+				log.Trace("SAML group sync: adding user [%s] to team [%s]", user.Name, org.Name)
+				err = org_service.AddTeamMember(db.DefaultContext, nil, team, user)
+			} else {
+				log.Trace("SAML group sync: removing user [%s] from team [%s]", user.Name, org.Name)
+				err = org_service.RemoveTeamMember(db.DefaultContext, nil, team, user)
+			}
+			if err != nil {
+				log.Error("SAML group sync: Could not change team membership: %v", err)
+			}
+		}
+	}
+}
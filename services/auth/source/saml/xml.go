@@ -0,0 +1,88 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package saml
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/russellhaering/goxmldsig/etreeutils"
+)
+
+// The namespaces of the SAML elements
+const (
+	nsAssertion = "urn:oasis:names:tc:SAML:2.0:assertion"
+	nsProtocol  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	nsMetadata  = "urn:oasis:names:tc:SAML:2.0:metadata"
+)
+
+// parseXML parses a document and returns its root element
+func parseXML(data []byte) (*etree.Element, error) {
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(data); err != nil {
+		return nil, err
+	}
+	var root *etree.Element
+	for _, tok := range doc.Child {
+		switch t := tok.(type) {
+		case *etree.Directive:
+			// the documents exchanged by SAML never have a DTD
+			return nil, errors.New("unexpected XML directive")
+		case *etree.Element:
+			if root != nil {
+				return nil, errors.New("multiple root elements")
+			}
+			root = t
+		}
+	}
+	if root == nil {
+		return nil, errors.New("no root element")
+	}
+	return root, nil
+}
+
+// isElement returns whether the element has the namespace and the local name
+func isElement(el *etree.Element, space, local string) bool {
+	return el.Tag == local && el.NamespaceURI() == space
+}
+
+// childElements returns the child elements which have the namespace and the local name
+func childElements(el *etree.Element, space, local string) []*etree.Element {
+	var children []*etree.Element
+	for _, child := range el.ChildElements() {
+		if isElement(child, space, local) {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// childElement returns the only child element which has the namespace and the local name
+func childElement(el *etree.Element, space, local string) (*etree.Element, error) {
+	children := childElements(el, space, local)
+	if len(children) != 1 {
+		return nil, fmt.Errorf("expected one %s element in %s but found %d", local, el.Tag, len(children))
+	}
+	return children[0], nil
+}
+
+// detach returns a copy of the element which declares the namespaces that are declared by its ancestors
+func detach(el *etree.Element) (*etree.Element, error) {
+	ctx, err := etreeutils.NSBuildParentContext(el)
+	if err != nil {
+		return nil, err
+	}
+	return etreeutils.NSDetatch(ctx, el)
+}
+
+// canonicalize returns the exclusive canonical form of the element without comments
+func canonicalize(el *etree.Element) ([]byte, error) {
+	detached, err := detach(el)
+	if err != nil {
+		return nil, err
+	}
+	return dsig.MakeC14N10ExclusiveCanonicalizerWithPrefixList("").Canonicalize(detached)
+}
@@ -0,0 +1,105 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	"context"
+
+	audit_model "code.gitea.io/gitea/models/audit"
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/perm"
+	access_model "code.gitea.io/gitea/models/perm/access"
+	repo_model "code.gitea.io/gitea/models/repo"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/services/audit"
+)
+
+// FineGrainedTokenOptions describes the restrictions of a fine-grained access token
+type FineGrainedTokenOptions struct {
+	// ResourceOwner is the name of the user or organization owning the repositories
+	ResourceOwner string
+	// Repositories are the names of the repositories, all repositories of the owner if empty
+	Repositories []string
+	// Permissions maps the unit names of FineGrainedTokenUnits to "read" or "write"
+	Permissions map[string]string
+	ExpiresUnix timeutil.TimeStamp
+}
+
+// RestrictAccessToken validates the options and applies them to a new access token of doer
+func RestrictAccessToken(ctx context.Context, doer *user_model.User, t *auth_model.AccessToken, opts *FineGrainedTokenOptions) error {
+	if opts.ExpiresUnix <= timeutil.TimeStampNow() {
+		return util.NewInvalidArgumentErrorf("a fine-grained access token needs an expiry date in the future")
+	}
+
+	owner, err := user_model.GetUserByName(ctx, opts.ResourceOwner)
+	if err != nil {
+		if user_model.IsErrUserNotExist(err) {
+			return util.NewInvalidArgumentErrorf("resource owner %q does not exist", opts.ResourceOwner)
+		}
+		return err
+	}
+
+	repoIDs := make([]int64, 0, len(opts.Repositories))
+	for _, name := range opts.Repositories {
+		repo, err := repo_model.GetRepositoryByName(owner.ID, name)
+		if err != nil {
+			if repo_model.IsErrRepoNotExist(err) {
+				return util.NewInvalidArgumentErrorf("repository %s/%s does not exist", owner.Name, name)
+			}
+			return err
+		}
+		// don't reveal the existence of repositories the doer can't see
+		if hasAccess, err := access_model.HasAccess(ctx, doer.ID, repo); err != nil {
+			return err
+		} else if !hasAccess {
+			return util.NewInvalidArgumentErrorf("repository %s/%s does not exist", owner.Name, name)
+		}
+		repoIDs = append(repoIDs, repo.ID)
+	}
+
+	if len(opts.Permissions) == 0 {
+		return util.NewInvalidArgumentErrorf("a fine-grained access token needs at least one permission")
+	}
+	units := make(auth_model.AccessTokenUnits, len(opts.Permissions))
+	for name, permission := range opts.Permissions {
+		unitType, ok := auth_model.FineGrainedTokenUnitByName(name)
+		if !ok {
+			return util.NewInvalidArgumentErrorf("unknown permission %q", name)
+		}
+		mode := perm.ParseAccessMode(permission)
+		if mode != perm.AccessModeRead && mode != perm.AccessModeWrite {
+			return util.NewInvalidArgumentErrorf("permission %q must be either read or write", name)
+		}
+		units[unitType] = mode
+	}
+
+	t.ResourceOwnerID = owner.ID
+	t.RepoIDs = repoIDs
+	t.Units = units
+	t.ExpiresUnix = opts.ExpiresUnix
+	// the scope only has to allow the repository and package routes, the token restricts everything else
+	t.Scope = auth_model.AccessTokenScope(auth_model.AccessTokenScopeRepo + "," + auth_model.AccessTokenScopePackage)
+	return nil
+}
+
+// CreateAccessToken creates the access token t of u, the doer is nil if the token is created by the system
+func CreateAccessToken(ctx context.Context, doer, u *user_model.User, t *auth_model.AccessToken) error {
+	t.UID = u.ID
+	if err := auth_model.NewAccessToken(t); err != nil {
+		return err
+	}
+	audit.RecordUser(ctx, audit_model.ActionAccessTokenCreate, doer, u, "Created access token %q with scope %q", t.Name, t.Scope)
+	return nil
+}
+
+// DeleteAccessToken deletes the access token id of doer
+func DeleteAccessToken(ctx context.Context, doer *user_model.User, id int64) error {
+	if err := auth_model.DeleteAccessTokenByID(id, doer.ID); err != nil {
+		return err
+	}
+	audit.RecordUser(ctx, audit_model.ActionAccessTokenDelete, doer, doer, "Deleted access token %d", id)
+	return nil
+}
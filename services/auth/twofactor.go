@@ -0,0 +1,76 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	"context"
+
+	audit_model "code.gitea.io/gitea/models/audit"
+	auth_model "code.gitea.io/gitea/models/auth"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/services/audit"
+
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+// EnrollTwoFactor enables the two-factor authentication t for its user
+func EnrollTwoFactor(ctx context.Context, doer *user_model.User, t *auth_model.TwoFactor) error {
+	if err := auth_model.NewTwoFactor(t); err != nil {
+		return err
+	}
+	audit.RecordUser(ctx, audit_model.ActionTwoFactorEnable, doer, doer, "Enabled two-factor authentication")
+	return nil
+}
+
+// DisableTwoFactor removes the two-factor authentication t of u
+func DisableTwoFactor(ctx context.Context, doer, u *user_model.User, t *auth_model.TwoFactor) error {
+	if err := auth_model.DeleteTwoFactorByID(t.ID, u.ID); err != nil {
+		return err
+	}
+	audit.RecordUser(ctx, audit_model.ActionTwoFactorDisable, doer, u, "Disabled two-factor authentication of %s", u.Name)
+	return nil
+}
+
+// AddWebAuthnCredential registers a security key for doer
+func AddWebAuthnCredential(ctx context.Context, doer *user_model.User, name string, cred *webauthn.Credential) (*auth_model.WebAuthnCredential, error) {
+	dbCred, err := auth_model.CreateCredential(doer.ID, name, cred)
+	if err != nil {
+		return nil, err
+	}
+	audit.RecordUser(ctx, audit_model.ActionWebAuthnAdd, doer, doer, "Added security key %q", name)
+	return dbCred, nil
+}
+
+// RemoveWebAuthnCredential removes the security key id of u and returns false if it doesn't exist
+func RemoveWebAuthnCredential(ctx context.Context, doer, u *user_model.User, id int64) (bool, error) {
+	removed, err := auth_model.DeleteCredential(id, u.ID)
+	if err != nil || !removed {
+		return removed, err
+	}
+	audit.RecordUser(ctx, audit_model.ActionWebAuthnRemove, doer, u, "Removed security key %d of %s", id, u.Name)
+	return true, nil
+}
+
+// ResetTwoFactor removes the two-factor authentication and all security keys of u
+func ResetTwoFactor(ctx context.Context, doer, u *user_model.User) error {
+	t, err := auth_model.GetTwoFactorByUID(u.ID)
+	if err != nil && !auth_model.IsErrTwoFactorNotEnrolled(err) {
+		return err
+	} else if t != nil {
+		if err := DisableTwoFactor(ctx, doer, u, t); err != nil {
+			return err
+		}
+	}
+
+	creds, err := auth_model.GetWebAuthnCredentialsByUID(u.ID)
+	if err != nil {
+		return err
+	}
+	for _, cred := range creds {
+		if _, err := RemoveWebAuthnCredential(ctx, doer, u, cred.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
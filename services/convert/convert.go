@@ -381,6 +381,25 @@ func ToOAuth2Application(app *auth.OAuth2Application) *api.OAuth2Application {
 	}
 }
 
+// ToAccessToken converts an AccessToken to an api.AccessToken, without the token itself
+func ToAccessToken(t *auth.AccessToken) *api.AccessToken {
+	token := &api.AccessToken{
+		ID:             t.ID,
+		Name:           t.Name,
+		TokenLastEight: t.TokenLastEight,
+	}
+	if t.IsFineGrained() {
+		token.ResourceOwnerID = t.ResourceOwnerID
+		token.RepositoryIDs = t.RepoIDs
+		token.Permissions = t.UnitPermissions()
+	}
+	if t.ExpiresUnix > 0 {
+		expiresAt := t.ExpiresUnix.AsTime()
+		token.ExpiresAt = &expiresAt
+	}
+	return token
+}
+
 // ToLFSLock convert a LFSLock to api.LFSLock
 func ToLFSLock(ctx context.Context, l *git_model.LFSLock) *api.LFSLock {
 	u, err := user_model.GetUserByID(ctx, l.OwnerID)
@@ -26,6 +26,22 @@ func ToPackage(ctx context.Context, pd *packages.PackageDescriptor, doer *user_m
 		}
 	}
 
+	var provenance *api.PackageProvenance
+	if pd.Provenance != nil && pd.Provenance.Repository != nil {
+		permission, err := access_model.GetUserRepoPermission(ctx, pd.Provenance.Repository, doer)
+		if err != nil {
+			return nil, err
+		}
+
+		if permission.HasAccess() {
+			provenance = &api.PackageProvenance{
+				Repository: ToRepo(ctx, pd.Provenance.Repository, permission.AccessMode),
+				CommitSHA:  pd.Provenance.CommitSHA,
+				Release:    pd.Provenance.Release,
+			}
+		}
+	}
+
 	return &api.Package{
 		ID:         pd.Version.ID,
 		Owner:      ToUser(pd.Owner, doer),
@@ -35,6 +51,7 @@ func ToPackage(ctx context.Context, pd *packages.PackageDescriptor, doer *user_m
 		Name:       pd.Package.Name,
 		Version:    pd.Version.Version,
 		CreatedAt:  pd.Version.CreatedUnix.AsTime(),
+		Provenance: provenance,
 	}, nil
 }
 
@@ -69,6 +69,58 @@ func registerGarbageCollectRepositories() {
 	})
 }
 
+func registerMaintainRepositories() {
+	type RepoMaintenanceConfig struct {
+		BaseConfig
+		Timeout               time.Duration
+		LooseObjectsThreshold int64
+		PacksThreshold        int64
+		LooseRefsThreshold    int64
+		PruneExpire           string
+	}
+	RegisterTaskFatal("maintain_repos", &RepoMaintenanceConfig{
+		BaseConfig: BaseConfig{
+			Enabled:    false,
+			RunAtStart: false,
+			Schedule:   "@every 1h",
+		},
+		Timeout:               time.Duration(setting.Git.Timeout.GC) * time.Second,
+		LooseObjectsThreshold: 100,
+		PacksThreshold:        50,
+		LooseRefsThreshold:    100,
+		PruneExpire:           "2.weeks.ago",
+	}, func(ctx context.Context, _ *user_model.User, config Config) error {
+		maintenanceConfig := config.(*RepoMaintenanceConfig)
+		return repo_service.MaintainRepositories(ctx, repo_service.MaintenanceOptions{
+			Timeout:               maintenanceConfig.Timeout,
+			LooseObjectsThreshold: maintenanceConfig.LooseObjectsThreshold,
+			PacksThreshold:        maintenanceConfig.PacksThreshold,
+			LooseRefsThreshold:    maintenanceConfig.LooseRefsThreshold,
+			PruneExpire:           maintenanceConfig.PruneExpire,
+		})
+	})
+}
+
+func registerGenerateRepositoryBundles() {
+	type RepoBundleConfig struct {
+		BaseConfig
+		Timeout time.Duration
+		MinSize int64
+	}
+	RegisterTaskFatal("generate_repo_bundles", &RepoBundleConfig{
+		BaseConfig: BaseConfig{
+			Enabled:    false,
+			RunAtStart: false,
+			Schedule:   "@every 24h",
+		},
+		Timeout: 30 * time.Minute,
+		MinSize: 100 * 1024 * 1024,
+	}, func(ctx context.Context, _ *user_model.User, config Config) error {
+		bundleConfig := config.(*RepoBundleConfig)
+		return repo_service.GenerateRepoBundles(ctx, bundleConfig.MinSize, bundleConfig.Timeout)
+	})
+}
+
 func registerRewriteAllPublicKeys() {
 	RegisterTaskFatal("resync_all_sshkeys", &BaseConfig{
 		Enabled:    false,
@@ -221,6 +273,8 @@ func initExtendedTasks() {
 	registerDeleteInactiveUsers()
 	registerDeleteRepositoryArchives()
 	registerGarbageCollectRepositories()
+	registerMaintainRepositories()
+	registerGenerateRepositoryBundles()
 	registerRewriteAllPublicKeys()
 	registerRewriteAllPrincipalKeys()
 	registerRepositoryUpdateHook()
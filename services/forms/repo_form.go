@@ -50,6 +50,8 @@ type CreateRepoForm struct {
 	Avatar       bool
 	Labels       bool
 	TrustModel   string
+
+	ObjectFormatName string `binding:"MaxSize(6)"`
 }
 
 // Validate validates the fields
@@ -166,6 +168,14 @@ type RepoSettingForm struct {
 	// Signing Settings
 	TrustModel string
 
+	// Code Search Settings
+	CodeIndexerBranches string
+	CodeIndexerTags     string
+
+	// Clone Settings
+	EnablePartialClone bool
+	EnableProtocolV2   bool
+
 	// Admin settings
 	EnableHealthCheck  bool
 	RequestReindexType string
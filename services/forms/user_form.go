@@ -212,6 +212,9 @@ type AccessTokenForm struct {
 
 	// PKCE support
 	CodeVerifier string `json:"code_verifier"`
+
+	// device authorization grant
+	DeviceCode string `json:"device_code"`
 }
 
 // Validate validates the fields
@@ -220,6 +223,31 @@ func (f *AccessTokenForm) Validate(req *http.Request, errs binding.Errors) bindi
 	return middleware.Validate(errs, ctx.Data, f, ctx.Locale)
 }
 
+// DeviceAuthorizationForm for requesting a device code (RFC 8628)
+type DeviceAuthorizationForm struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	Scope        string `json:"scope"`
+}
+
+// Validate validates the fields
+func (f *DeviceAuthorizationForm) Validate(req *http.Request, errs binding.Errors) binding.Errors {
+	ctx := context.GetContext(req)
+	return middleware.Validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+// DeviceVerificationForm form for approving or denying a device authorization request
+type DeviceVerificationForm struct {
+	UserCode string `binding:"Required"`
+	Approve  bool
+}
+
+// Validate validates the fields
+func (f *DeviceVerificationForm) Validate(req *http.Request, errs binding.Errors) binding.Errors {
+	ctx := context.GetContext(req)
+	return middleware.Validate(errs, ctx.Data, f, ctx.Locale)
+}
+
 // IntrospectTokenForm for introspecting tokens
 type IntrospectTokenForm struct {
 	Token string `json:"token"`
@@ -380,6 +408,11 @@ func (f *AddSecretForm) Validate(req *http.Request, errs binding.Errors) binding
 type NewAccessTokenForm struct {
 	Name  string `binding:"Required;MaxSize(255)"`
 	Scope []string
+
+	// restrictions of a fine-grained token, the unit permissions are read from "permission_<unit>"
+	ResourceOwner string
+	Repositories  string
+	ExpiresAt     string
 }
 
 // Validate validates the fields
@@ -0,0 +1,45 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package issue
+
+import (
+	"context"
+
+	issues_model "code.gitea.io/gitea/models/issues"
+	user_model "code.gitea.io/gitea/models/user"
+	issue_indexer "code.gitea.io/gitea/modules/indexer/issues"
+)
+
+// SearchIssues executes the query and the filters by the issue indexer and returns the issues and the total number of matches.
+// The query can contain qualifiers like `is:open label:bug`, the labels and milestones match if an issue has one of the names.
+// WARNNING: You have to ensure the doer is allowed to read the issues of the repositories
+func SearchIssues(ctx context.Context, doer *user_model.User, query string, labelNames, milestoneNames []string, opts *issue_indexer.SearchOptions) ([]*issues_model.Issue, int64, error) {
+	if len(opts.RepoIDs) == 0 {
+		return []*issues_model.Issue{}, 0, nil
+	}
+
+	q := issue_indexer.ParseQuery(query)
+	if len(labelNames) > 0 {
+		q.Labels = append(q.Labels, labelNames)
+	}
+	q.Milestones = append(q.Milestones, milestoneNames...)
+	ok, err := q.Apply(ctx, doer, opts)
+	if err != nil || !ok {
+		return []*issues_model.Issue{}, 0, err
+	}
+
+	res, err := issue_indexer.SearchIssues(ctx, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	ids := make([]int64, 0, len(res.Hits))
+	for _, hit := range res.Hits {
+		ids = append(ids, hit.ID)
+	}
+	issues, err := issues_model.GetIssuesWithAttributesByIDs(ctx, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	return issues, res.Total, nil
+}
@@ -18,6 +18,7 @@ import (
 	"strconv"
 	"strings"
 
+	auth_model "code.gitea.io/gitea/models/auth"
 	git_model "code.gitea.io/gitea/models/git"
 	"code.gitea.io/gitea/models/perm"
 	access_model "code.gitea.io/gitea/models/perm/access"
@@ -501,6 +502,8 @@ func authenticate(ctx *context.Context, repository *repo_model.Repository, autho
 		log.Error("Unable to GetUserRepoPermission for user %-v in repo %-v Error: %v", ctx.Doer, repository)
 		return false
 	}
+	token, _ := ctx.Data["ApiToken"].(*auth_model.AccessToken)
+	perm.RestrictByAccessToken(repository, token)
 
 	canRead := perm.CanAccess(accessMode, unit.TypeCode)
 	if canRead && (!requireSigned || ctx.IsSigned) {
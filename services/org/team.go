@@ -0,0 +1,59 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package org
+
+import (
+	"context"
+
+	"code.gitea.io/gitea/models"
+	audit_model "code.gitea.io/gitea/models/audit"
+	"code.gitea.io/gitea/models/organization"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/services/audit"
+)
+
+// NewTeam creates the team t, the doer is nil for changes by the system
+func NewTeam(ctx context.Context, doer *user_model.User, t *organization.Team) error {
+	if err := models.NewTeam(t); err != nil {
+		return err
+	}
+	audit.RecordTeam(ctx, audit_model.ActionTeamCreate, doer, t, "Created team %s with %s access", t.Name, t.AccessMode)
+	return nil
+}
+
+// UpdateTeam updates the team t
+func UpdateTeam(ctx context.Context, doer *user_model.User, t *organization.Team, authChanged, includeAllChanged bool) error {
+	if err := models.UpdateTeam(t, authChanged, includeAllChanged); err != nil {
+		return err
+	}
+	audit.RecordTeam(ctx, audit_model.ActionTeamUpdate, doer, t, "Updated team %s with %s access", t.Name, t.AccessMode)
+	return nil
+}
+
+// DeleteTeam deletes the team t
+func DeleteTeam(ctx context.Context, doer *user_model.User, t *organization.Team) error {
+	if err := models.DeleteTeam(t); err != nil {
+		return err
+	}
+	audit.RecordTeam(ctx, audit_model.ActionTeamDelete, doer, t, "Deleted team %s", t.Name)
+	return nil
+}
+
+// AddTeamMember adds u to the team t
+func AddTeamMember(ctx context.Context, doer *user_model.User, t *organization.Team, u *user_model.User) error {
+	if err := models.AddTeamMember(t, u.ID); err != nil {
+		return err
+	}
+	audit.RecordTeam(ctx, audit_model.ActionTeamMemberAdd, doer, t, "Added %s to team %s", u.Name, t.Name)
+	return nil
+}
+
+// RemoveTeamMember removes u from the team t
+func RemoveTeamMember(ctx context.Context, doer *user_model.User, t *organization.Team, u *user_model.User) error {
+	if err := models.RemoveTeamMember(t, u.ID); err != nil {
+		return err
+	}
+	audit.RecordTeam(ctx, audit_model.ActionTeamMemberRemove, doer, t, "Removed %s from team %s", u.Name, t.Name)
+	return nil
+}
@@ -0,0 +1,511 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package alpine
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"code.gitea.io/gitea/models/db"
+	packages_model "code.gitea.io/gitea/models/packages"
+	alpine_model "code.gitea.io/gitea/models/packages/alpine"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	alpine_module "code.gitea.io/gitea/modules/packages/alpine"
+	"code.gitea.io/gitea/modules/util"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+const (
+	IndexFilename = "APKINDEX.tar.gz"
+
+	keyBits = 4096
+)
+
+// ErrRepositoryNotExist is returned if no package is available in the requested branch, repository or architecture
+var ErrRepositoryNotExist = util.NewNotExistErrorf("repository does not exist")
+
+var (
+	keyPairMutex    sync.Mutex
+	repositoryMutex sync.Mutex
+)
+
+// GetOrCreateKeyPair gets or creates the RSA keys used to sign the repository indices of the owner
+func GetOrCreateKeyPair(ownerID int64) (string, string, error) {
+	keyPairMutex.Lock()
+	defer keyPairMutex.Unlock()
+
+	priv, err := user_model.GetUserSetting(ownerID, alpine_module.SettingKeyPrivate)
+	if err != nil {
+		return "", "", err
+	}
+
+	pub, err := user_model.GetUserSetting(ownerID, alpine_module.SettingKeyPublic)
+	if err != nil {
+		return "", "", err
+	}
+
+	if priv == "" || pub == "" {
+		priv, pub, err = generateKeypair()
+		if err != nil {
+			return "", "", err
+		}
+
+		if err := user_model.SetUserSetting(ownerID, alpine_module.SettingKeyPrivate, priv); err != nil {
+			return "", "", err
+		}
+
+		if err := user_model.SetUserSetting(ownerID, alpine_module.SettingKeyPublic, pub); err != nil {
+			return "", "", err
+		}
+	}
+
+	return priv, pub, nil
+}
+
+func generateKeypair() (string, string, error) {
+	key, err := rsa.GenerateKey(rand.Reader, keyBits)
+	if err != nil {
+		return "", "", err
+	}
+
+	pubBytes, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	priv := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	pub := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubBytes})
+
+	return string(priv), string(pub), nil
+}
+
+// KeyName returns the name of the public key file
+// apk looks up the key used to verify a signature by this name in /etc/apk/keys/
+func KeyName(owner *user_model.User, pub string) (string, error) {
+	block, _ := pem.Decode([]byte(pub))
+	if block == nil {
+		return "", errors.New("failed to decode public key")
+	}
+
+	fingerprint := sha1.Sum(block.Bytes)
+
+	return fmt.Sprintf("%s@%s.rsa.pub", owner.LowerName, hex.EncodeToString(fingerprint[:])), nil
+}
+
+// FileName returns the name of the file of a package
+func FileName(name, version string) string {
+	return fmt.Sprintf("%s-%s.apk", name, version)
+}
+
+// CompositeKey returns the composite key of the files of a branch, repository and architecture
+func CompositeKey(branch, repository, architecture string) string {
+	return branch + "|" + repository + "|" + architecture
+}
+
+// repositoryKey returns the key of a branch and repository, it is the prefix of the composite keys of their files
+func repositoryKey(branch, repository string) string {
+	return branch + "|" + repository
+}
+
+// buildIndex creates the signed APKINDEX.tar.gz file of a branch, repository and architecture.
+// Packages of the architecture "noarch" are part of every architecture.
+// https://wiki.alpinelinux.org/wiki/Apk_spec#APKINDEX_Format
+func buildIndex(ctx context.Context, owner *user_model.User, branch, repository, architecture string) ([]byte, error) {
+	pfds, err := alpine_model.SearchPackages(ctx, &alpine_model.PackageSearchOptions{
+		OwnerID:      owner.ID,
+		Branch:       branch,
+		Repository:   repository,
+		Architecture: architecture,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if architecture != alpine_module.ArchitectureNoarch {
+		noarch, err := alpine_model.SearchPackages(ctx, &alpine_model.PackageSearchOptions{
+			OwnerID:      owner.ID,
+			Branch:       branch,
+			Repository:   repository,
+			Architecture: alpine_module.ArchitectureNoarch,
+		})
+		if err != nil {
+			return nil, err
+		}
+		pfds = append(pfds, noarch...)
+	}
+
+	if len(pfds) == 0 {
+		return nil, ErrRepositoryNotExist
+	}
+
+	var lastModified time.Time
+	var buf bytes.Buffer
+	for _, pfd := range pfds {
+		if created := pfd.File.CreatedUnix.AsTime(); created.After(lastModified) {
+			lastModified = created
+		}
+
+		pv, err := packages_model.GetVersionByID(ctx, pfd.File.VersionID)
+		if err != nil {
+			return nil, err
+		}
+		p, err := packages_model.GetPackageByID(ctx, pv.PackageID)
+		if err != nil {
+			return nil, err
+		}
+
+		var vm *alpine_module.VersionMetadata
+		if err := json.Unmarshal([]byte(pv.MetadataJSON), &vm); err != nil {
+			return nil, err
+		}
+		var fm *alpine_module.FileMetadata
+		if err := json.Unmarshal([]byte(pfd.Properties.GetByName(alpine_module.PropertyMetadata)), &fm); err != nil {
+			return nil, err
+		}
+
+		writeField := func(key, value string) {
+			if value != "" {
+				fmt.Fprintf(&buf, "%s:%s\n", key, value)
+			}
+		}
+
+		writeField("C", fm.Checksum)
+		writeField("P", p.Name)
+		writeField("V", pv.Version)
+		writeField("A", fm.Architecture)
+		writeField("S", fmt.Sprint(pfd.Blob.Size))
+		writeField("I", fmt.Sprint(fm.InstalledSize))
+		writeField("T", vm.Description)
+		writeField("U", vm.ProjectURL)
+		writeField("L", vm.License)
+		writeField("o", fm.Origin)
+		writeField("m", vm.Maintainer)
+		writeField("t", fmt.Sprint(fm.BuildDate))
+		writeField("c", fm.CommitHash)
+		writeField("D", strings.Join(fm.Dependencies, " "))
+		writeField("p", strings.Join(fm.Provides, " "))
+		writeField("i", fm.InstallIf)
+		buf.WriteByte('\n')
+	}
+
+	index, err := createTarGz(lastModified, true, map[string][]byte{
+		"DESCRIPTION": []byte(fmt.Sprintf("%s %s/%s", owner.Name, branch, repository)),
+		"APKINDEX":    buf.Bytes(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := signIndex(owner, index, lastModified)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(signature, index...), nil
+}
+
+// signIndex creates the signature segment of the index
+// The segment is prepended to the index, so the tar end-of-archive marker must be omitted.
+func signIndex(owner *user_model.User, index []byte, modTime time.Time) ([]byte, error) {
+	priv, pub, err := GetOrCreateKeyPair(owner.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode([]byte(priv))
+	if block == nil {
+		return nil, errors.New("failed to decode private key")
+	}
+
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	h := sha1.Sum(index)
+
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA1, h[:])
+	if err != nil {
+		return nil, err
+	}
+
+	keyName, err := KeyName(owner, pub)
+	if err != nil {
+		return nil, err
+	}
+
+	return createTarGz(modTime, false, map[string][]byte{
+		".SIGN.RSA." + keyName: sig,
+	})
+}
+
+func createTarGz(modTime time.Time, endOfArchive bool, files map[string][]byte) ([]byte, error) {
+	// the order of the files is stable to get reproducible indices
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	for _, name := range names {
+		content := files[name]
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    name,
+			Mode:    0o600,
+			Size:    int64(len(content)),
+			ModTime: modTime,
+		}); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(content); err != nil {
+			return nil, err
+		}
+	}
+	if endOfArchive {
+		if err := tw.Close(); err != nil {
+			return nil, err
+		}
+	} else if err := tw.Flush(); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GetOrCreateRepositoryVersion gets or creates the internal package version which stores the repository indices of the owner
+func GetOrCreateRepositoryVersion(ctx context.Context, ownerID int64) (*packages_model.PackageVersion, error) {
+	var repoVersion *packages_model.PackageVersion
+
+	return repoVersion, db.WithTx(ctx, func(ctx context.Context) error {
+		p := &packages_model.Package{
+			OwnerID:   ownerID,
+			Type:      packages_model.TypeAlpine,
+			Name:      alpine_module.RepositoryPackage,
+			LowerName: alpine_module.RepositoryPackage,
+		}
+		var err error
+		if p, err = packages_model.TryInsertPackage(ctx, p); err != nil {
+			if err != packages_model.ErrDuplicatePackage {
+				log.Error("Error inserting package: %v", err)
+				return err
+			}
+		}
+
+		pv := &packages_model.PackageVersion{
+			PackageID:    p.ID,
+			CreatorID:    ownerID,
+			Version:      alpine_module.RepositoryVersion,
+			LowerVersion: alpine_module.RepositoryVersion,
+			IsInternal:   true,
+			MetadataJSON: "null",
+		}
+		if pv, err = packages_model.GetOrInsertVersion(ctx, pv); err != nil {
+			if err != packages_model.ErrDuplicatePackageVersion {
+				log.Error("Error inserting package version: %v", err)
+				return err
+			}
+		}
+
+		repoVersion = pv
+
+		return nil
+	})
+}
+
+// BuildSpecificRepositoryFiles creates the indices of all architectures of a branch and repository and stores them.
+// The indices of architectures without packages are removed.
+func BuildSpecificRepositoryFiles(ctx context.Context, ownerID int64, branch, repository string) error {
+	repositoryMutex.Lock()
+	defer repositoryMutex.Unlock()
+
+	owner, err := user_model.GetUserByID(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	return buildAndStoreRepositoryFiles(ctx, owner, []string{repositoryKey(branch, repository)})
+}
+
+// BuildAllRepositoryFiles creates the indices of all branches and repositories of the owner and stores them.
+// It must be used if the removed packages may belong to any branch or repository.
+func BuildAllRepositoryFiles(ctx context.Context, ownerID int64) error {
+	repositoryMutex.Lock()
+	defer repositoryMutex.Unlock()
+
+	owner, err := user_model.GetUserByID(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	repositoryKeys := make(container.Set[string])
+
+	branches, err := alpine_model.GetBranches(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+	for _, branch := range branches {
+		repositories, err := alpine_model.GetRepositories(ctx, ownerID, branch)
+		if err != nil {
+			return err
+		}
+		for _, repository := range repositories {
+			repositoryKeys.Add(repositoryKey(branch, repository))
+		}
+	}
+
+	// the stored indices of repositories without packages must be removed too
+	pv, err := packages_model.GetInternalVersionByNameAndVersion(ctx, ownerID, packages_model.TypeAlpine, alpine_module.RepositoryPackage, alpine_module.RepositoryVersion)
+	if err != nil && err != packages_model.ErrPackageNotExist {
+		return err
+	}
+	if pv != nil {
+		pfs, err := packages_model.GetFilesByVersionID(ctx, pv.ID)
+		if err != nil {
+			return err
+		}
+		for _, pf := range pfs {
+			repositoryKeys.Add(repositoryKeyOfIndex(pf))
+		}
+	}
+
+	keys := repositoryKeys.Values()
+	sort.Strings(keys)
+
+	return buildAndStoreRepositoryFiles(ctx, owner, keys)
+}
+
+// repositoryKeyOfIndex returns the key of the branch and repository of a stored index
+func repositoryKeyOfIndex(pf *packages_model.PackageFile) string {
+	if i := strings.LastIndexByte(pf.CompositeKey, '|'); i != -1 {
+		return pf.CompositeKey[:i]
+	}
+	return pf.CompositeKey
+}
+
+func buildAndStoreRepositoryFiles(ctx context.Context, owner *user_model.User, repositoryKeys []string) error {
+	// the indices by their composite key
+	indices := make(map[string][]byte)
+	for _, key := range repositoryKeys {
+		branch, repository, _ := strings.Cut(key, "|")
+
+		architectures, err := alpine_model.GetArchitectures(ctx, owner.ID, branch, repository)
+		if err != nil {
+			return err
+		}
+		for _, architecture := range architectures {
+			index, err := buildIndex(ctx, owner, branch, repository, architecture)
+			if err != nil {
+				return err
+			}
+			indices[CompositeKey(branch, repository, architecture)] = index
+		}
+	}
+
+	var pv *packages_model.PackageVersion
+	var err error
+	if len(indices) == 0 {
+		pv, err = packages_model.GetInternalVersionByNameAndVersion(ctx, owner.ID, packages_model.TypeAlpine, alpine_module.RepositoryPackage, alpine_module.RepositoryVersion)
+		if err != nil {
+			if err == packages_model.ErrPackageNotExist {
+				return nil
+			}
+			return err
+		}
+	} else {
+		pv, err = GetOrCreateRepositoryVersion(ctx, owner.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		pfs, err := packages_model.GetFilesByVersionID(ctx, pv.ID)
+		if err != nil {
+			return err
+		}
+
+		// remove the stale indices of the rebuilt repositories, like the index of an architecture without packages
+		remaining := 0
+		for _, pf := range pfs {
+			if _, ok := indices[pf.CompositeKey]; ok || !util.SliceContainsString(repositoryKeys, repositoryKeyOfIndex(pf)) {
+				remaining++
+				continue
+			}
+			if err := packages_service.DeletePackageFile(ctx, pf); err != nil {
+				return err
+			}
+		}
+
+		if len(indices) == 0 && remaining == 0 {
+			return packages_service.DeletePackageVersionAndReferences(ctx, pv)
+		}
+
+		for compositeKey, index := range indices {
+			if err := addIndexFile(ctx, pv, compositeKey, index); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func addIndexFile(ctx context.Context, pv *packages_model.PackageVersion, compositeKey string, index []byte) error {
+	buf, err := packages_module.CreateHashedBufferFromReader(bytes.NewReader(index), 32*1024*1024)
+	if err != nil {
+		return err
+	}
+	defer buf.Close()
+
+	_, err = packages_service.AddFileToPackageVersionInternal(ctx, pv, &packages_service.PackageFileCreationInfo{
+		PackageFileInfo: packages_service.PackageFileInfo{
+			Filename:     IndexFilename,
+			CompositeKey: compositeKey,
+		},
+		Data:              buf,
+		OverwriteExisting: true,
+	})
+	return err
+}
+
+// GetPackageFileDescriptor gets the file with the given name of a branch, repository and architecture
+func GetPackageFileDescriptor(ctx context.Context, ownerID int64, branch, repository, architecture, filename string) (*packages_model.PackageFileDescriptor, error) {
+	pfds, err := alpine_model.SearchPackages(ctx, &alpine_model.PackageSearchOptions{
+		OwnerID:      ownerID,
+		Branch:       branch,
+		Repository:   repository,
+		Architecture: architecture,
+		FileName:     filename,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(pfds) != 1 {
+		return nil, packages_model.ErrPackageFileNotExist
+	}
+	return pfds[0], nil
+}
@@ -0,0 +1,143 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package cargo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models/db"
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/modules/json"
+	cargo_module "code.gitea.io/gitea/modules/packages/cargo"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// ErrIndexFileNotExist is returned if the package has no versions to list in the index
+var ErrIndexFileNotExist = util.NewNotExistErrorf("index file does not exist")
+
+// Config represents the config.json file at the root of the sparse index
+// https://doc.rust-lang.org/cargo/reference/registries.html#index-format
+type Config struct {
+	DownloadURL  string `json:"dl"`
+	APIURL       string `json:"api"`
+	AuthRequired bool   `json:"auth-required,omitempty"`
+}
+
+// IndexVersionEntry represents a line of an index file
+// https://doc.rust-lang.org/cargo/reference/registries.html#index-format
+type IndexVersionEntry struct {
+	Name         string                     `json:"name"`
+	Version      string                     `json:"vers"`
+	Dependencies []*cargo_module.Dependency `json:"deps"`
+	FileChecksum string                     `json:"cksum"`
+	Features     map[string][]string        `json:"features"`
+	Yanked       bool                       `json:"yanked"`
+	Links        string                     `json:"links,omitempty"`
+}
+
+// IndexFilePath returns the path of the index file of the package, relative to the index root
+// https://doc.rust-lang.org/cargo/reference/registries.html#index-files
+func IndexFilePath(name string) string {
+	name = strings.ToLower(name)
+	switch len(name) {
+	case 0:
+		return ""
+	case 1:
+		return "1/" + name
+	case 2:
+		return "2/" + name
+	case 3:
+		return "3/" + name[0:1] + "/" + name
+	default:
+		return name[0:2] + "/" + name[2:4] + "/" + name
+	}
+}
+
+// BuildPackageIndex builds the content of the index file of the package.
+// The file is generated from the current versions of the package so it reflects every publish, yank and delete.
+func BuildPackageIndex(ctx context.Context, p *packages_model.Package) (*bytes.Buffer, error) {
+	pvs, _, err := packages_model.SearchVersions(ctx, &packages_model.PackageSearchOptions{
+		PackageID:  p.ID,
+		IsInternal: util.OptionalBoolFalse,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SearchVersions[%s]: %w", p.Name, err)
+	}
+	if len(pvs) == 0 {
+		return nil, ErrIndexFileNotExist
+	}
+
+	pds, err := packages_model.GetPackageDescriptors(ctx, pvs)
+	if err != nil {
+		return nil, fmt.Errorf("GetPackageDescriptors[%s]: %w", p.Name, err)
+	}
+
+	sort.Slice(pds, func(i, j int) bool {
+		return pds[i].SemVer.LessThan(pds[j].SemVer)
+	})
+
+	var b bytes.Buffer
+	for _, pd := range pds {
+		if len(pd.Files) == 0 {
+			continue
+		}
+
+		metadata := pd.Metadata.(*cargo_module.Metadata)
+
+		dependencies := metadata.Dependencies
+		if dependencies == nil {
+			dependencies = make([]*cargo_module.Dependency, 0)
+		}
+
+		features := metadata.Features
+		if features == nil {
+			features = make(map[string][]string)
+		}
+
+		yanked, _ := strconv.ParseBool(pd.VersionProperties.GetByName(cargo_module.PropertyYanked))
+
+		entry, err := json.Marshal(&IndexVersionEntry{
+			Name:         pd.Package.Name,
+			Version:      pd.Version.Version,
+			Dependencies: dependencies,
+			FileChecksum: pd.Files[0].Blob.HashSHA256,
+			Features:     features,
+			Yanked:       yanked,
+			Links:        metadata.Links,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		b.Write(entry)
+		b.WriteString("\n")
+	}
+
+	return &b, nil
+}
+
+// SetYanked sets or removes the yanked flag of a package version
+func SetYanked(ctx context.Context, pv *packages_model.PackageVersion, yanked bool) error {
+	ctx, committer, err := db.TxContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer committer.Close()
+
+	if err := packages_model.DeletePropertyByName(ctx, packages_model.PropertyTypeVersion, pv.ID, cargo_module.PropertyYanked); err != nil {
+		return err
+	}
+	if yanked {
+		if _, err := packages_model.InsertProperty(ctx, packages_model.PropertyTypeVersion, pv.ID, cargo_module.PropertyYanked, strconv.FormatBool(yanked)); err != nil {
+			return err
+		}
+	}
+
+	return committer.Commit()
+}
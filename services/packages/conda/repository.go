@@ -0,0 +1,197 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package conda
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	conda_model "code.gitea.io/gitea/models/packages/conda"
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/json"
+	conda_module "code.gitea.io/gitea/modules/packages/conda"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// ErrChannelNotExist is returned if no package is available in the requested channel
+var ErrChannelNotExist = util.NewNotExistErrorf("channel does not exist")
+
+// Repodata represents the repodata.json file of a subdir
+// https://docs.conda.io/projects/conda-build/en/latest/concepts/generating-index.html#repodata-json
+type Repodata struct {
+	Info            RepodataInfo                      `json:"info"`
+	Packages        map[string]map[string]interface{} `json:"packages"`
+	PackagesConda   map[string]map[string]interface{} `json:"packages.conda"`
+	Removed         []string                          `json:"removed"`
+	RepodataVersion int                               `json:"repodata_version"`
+}
+
+type RepodataInfo struct {
+	Subdir string `json:"subdir"`
+}
+
+// Channeldata represents the channeldata.json file of a channel
+// https://docs.conda.io/projects/conda-build/en/latest/concepts/generating-index.html#channeldata-json
+type Channeldata struct {
+	ChanneldataVersion int                            `json:"channeldata_version"`
+	Packages           map[string]*ChanneldataPackage `json:"packages"`
+	Subdirs            []string                       `json:"subdirs"`
+}
+
+type ChanneldataPackage struct {
+	Description string   `json:"description,omitempty"`
+	Summary     string   `json:"summary,omitempty"`
+	Home        string   `json:"home,omitempty"`
+	DevURL      string   `json:"dev_url,omitempty"`
+	DocURL      string   `json:"doc_url,omitempty"`
+	License     string   `json:"license,omitempty"`
+	Keywords    []string `json:"keywords,omitempty"`
+	Subdirs     []string `json:"subdirs"`
+	Version     string   `json:"version"`
+	Timestamp   int64    `json:"timestamp"`
+}
+
+// BuildRepodata creates the repodata.json content of a channel and subdir.
+// The entries contain the info/index.json content of the package files extended with their checksums.
+// An empty index is returned if there are no packages because conda requires every channel to have a noarch subdir.
+func BuildRepodata(ctx context.Context, ownerID int64, channel, subdir string) (*Repodata, error) {
+	pfds, err := conda_model.SearchFiles(ctx, &conda_model.FileSearchOptions{
+		OwnerID: ownerID,
+		Channel: channel,
+		Subdir:  subdir,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	repodata := &Repodata{
+		Info: RepodataInfo{
+			Subdir: subdir,
+		},
+		Packages:        make(map[string]map[string]interface{}),
+		PackagesConda:   make(map[string]map[string]interface{}),
+		Removed:         make([]string, 0),
+		RepodataVersion: 1,
+	}
+
+	for _, pfd := range pfds {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(pfd.Properties.GetByName(conda_module.PropertyIndex)), &entry); err != nil {
+			return nil, err
+		}
+
+		entry["md5"] = pfd.Blob.HashMD5
+		entry["sha256"] = pfd.Blob.HashSHA256
+		entry["size"] = pfd.Blob.Size
+
+		if strings.HasSuffix(pfd.File.Name, conda_module.ExtensionConda) {
+			repodata.PackagesConda[pfd.File.Name] = entry
+		} else {
+			repodata.Packages[pfd.File.Name] = entry
+		}
+	}
+
+	return repodata, nil
+}
+
+// BuildChanneldata creates the channeldata.json content of a channel
+func BuildChanneldata(ctx context.Context, ownerID int64, channel string) (*Channeldata, error) {
+	pfds, err := conda_model.SearchFiles(ctx, &conda_model.FileSearchOptions{
+		OwnerID: ownerID,
+		Channel: channel,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(pfds) == 0 {
+		return nil, ErrChannelNotExist
+	}
+
+	pds := make(map[int64]*packages_model.PackageDescriptor)
+	packageSubdirs := make(map[string]container.Set[string])
+	subdirs := make(container.Set[string])
+
+	channeldata := &Channeldata{
+		ChanneldataVersion: 1,
+		Packages:           make(map[string]*ChanneldataPackage),
+	}
+
+	for _, pfd := range pfds {
+		pd, ok := pds[pfd.File.VersionID]
+		if !ok {
+			pv, err := packages_model.GetVersionByID(ctx, pfd.File.VersionID)
+			if err != nil {
+				return nil, err
+			}
+			pd, err = packages_model.GetPackageDescriptor(ctx, pv)
+			if err != nil {
+				return nil, err
+			}
+			pds[pfd.File.VersionID] = pd
+		}
+
+		subdir := pfd.Properties.GetByName(conda_module.PropertySubdir)
+		subdirs.Add(subdir)
+		if _, ok := packageSubdirs[pd.Package.Name]; !ok {
+			packageSubdirs[pd.Package.Name] = make(container.Set[string])
+		}
+		packageSubdirs[pd.Package.Name].Add(subdir)
+
+		// the most recently published version is used for the package information
+		cp, ok := channeldata.Packages[pd.Package.Name]
+		if ok && cp.Timestamp >= int64(pd.Version.CreatedUnix) {
+			continue
+		}
+
+		metadata := pd.Metadata.(*conda_module.VersionMetadata)
+		channeldata.Packages[pd.Package.Name] = &ChanneldataPackage{
+			Description: metadata.Description,
+			Summary:     metadata.Summary,
+			Home:        metadata.ProjectURL,
+			DevURL:      metadata.RepositoryURL,
+			DocURL:      metadata.DocumentationURL,
+			License:     metadata.License,
+			Keywords:    metadata.Keywords,
+			Version:     pd.Version.Version,
+			Timestamp:   int64(pd.Version.CreatedUnix),
+		}
+	}
+
+	for name, cp := range channeldata.Packages {
+		cp.Subdirs = sortedValues(packageSubdirs[name])
+	}
+	channeldata.Subdirs = sortedValues(subdirs)
+
+	return channeldata, nil
+}
+
+func sortedValues(s container.Set[string]) []string {
+	values := s.Values()
+	sort.Strings(values)
+	return values
+}
+
+// GetPackageFileDescriptor gets the file with the given name of a channel and subdir
+func GetPackageFileDescriptor(ctx context.Context, ownerID int64, channel, subdir, filename string) (*packages_model.PackageFileDescriptor, error) {
+	pfds, err := conda_model.SearchFiles(ctx, &conda_model.FileSearchOptions{
+		OwnerID:  ownerID,
+		Channel:  channel,
+		Subdir:   subdir,
+		FileName: filename,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(pfds) != 1 {
+		return nil, packages_model.ErrPackageFileNotExist
+	}
+	return pfds[0], nil
+}
+
+// CompositeKey returns the composite key of the files of a channel and subdir
+func CompositeKey(channel, subdir string) string {
+	return channel + "|" + subdir
+}
@@ -0,0 +1,473 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package debian
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"code.gitea.io/gitea/models/db"
+	packages_model "code.gitea.io/gitea/models/packages"
+	debian_model "code.gitea.io/gitea/models/packages/debian"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/log"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	debian_module "code.gitea.io/gitea/modules/packages/debian"
+	"code.gitea.io/gitea/modules/util"
+	packages_service "code.gitea.io/gitea/services/packages"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/clearsign"
+)
+
+// ErrDistributionNotExist is returned if no package is available in the requested distribution, component or architecture
+var ErrDistributionNotExist = util.NewNotExistErrorf("distribution does not exist")
+
+var repositoryMutex sync.Mutex
+
+// GetOrCreateKeyPair gets or creates the PGP keys used to sign the repository files of the owner
+func GetOrCreateKeyPair(ownerID int64) (string, string, error) {
+	return packages_service.GetOrCreatePGPKeyPair(ownerID, "Debian Registry", debian_module.SettingKeyPrivate, debian_module.SettingKeyPublic)
+}
+
+func readPrivateKey(ownerID int64) (*openpgp.Entity, error) {
+	priv, _, err := GetOrCreateKeyPair(ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(priv))
+	if err != nil {
+		return nil, err
+	}
+
+	return keyring[0], nil
+}
+
+// buildPackagesIndex creates the content of the Packages file of a distribution, component and architecture.
+// Packages of the architecture "all" are part of every architecture.
+func buildPackagesIndex(ctx context.Context, ownerID int64, distribution, component, architecture string) ([]byte, time.Time, error) {
+	pfds, err := debian_model.SearchPackages(ctx, &debian_model.PackageSearchOptions{
+		OwnerID:      ownerID,
+		Distribution: distribution,
+		Component:    component,
+		Architecture: architecture,
+	})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	if architecture != debian_module.ArchitectureAll {
+		all, err := debian_model.SearchPackages(ctx, &debian_model.PackageSearchOptions{
+			OwnerID:      ownerID,
+			Distribution: distribution,
+			Component:    component,
+			Architecture: debian_module.ArchitectureAll,
+		})
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		pfds = append(pfds, all...)
+	}
+
+	if len(pfds) == 0 {
+		return nil, time.Time{}, ErrDistributionNotExist
+	}
+
+	var lastModified time.Time
+	var buf bytes.Buffer
+	for _, pfd := range pfds {
+		if created := pfd.File.CreatedUnix.AsTime(); created.After(lastModified) {
+			lastModified = created
+		}
+
+		fmt.Fprintf(&buf, "%s\n", pfd.Properties.GetByName(debian_module.PropertyControl))
+		fmt.Fprintf(&buf, "Filename: pool/%s/%s/%s\n", distribution, component, pfd.File.Name)
+		fmt.Fprintf(&buf, "Size: %d\n", pfd.Blob.Size)
+		fmt.Fprintf(&buf, "MD5sum: %s\n", pfd.Blob.HashMD5)
+		fmt.Fprintf(&buf, "SHA1: %s\n", pfd.Blob.HashSHA1)
+		fmt.Fprintf(&buf, "SHA256: %s\n", pfd.Blob.HashSHA256)
+		fmt.Fprintf(&buf, "SHA512: %s\n\n", pfd.Blob.HashSHA512)
+	}
+
+	return buf.Bytes(), lastModified, nil
+}
+
+func compress(content []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(content); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type indexFile struct {
+	Path    string
+	Content []byte
+}
+
+// repositoryFile is a file of the dists directory of a distribution.
+// The Release files are stored with the distribution as composite key,
+// the Packages files with the key of their component and architecture.
+type repositoryFile struct {
+	CompositeKey string
+	Filename     string
+	Content      []byte
+}
+
+// buildRepositoryFiles creates the Packages files and the signed Release files of a distribution.
+// No files are returned if the distribution has no packages.
+func buildRepositoryFiles(ctx context.Context, owner *user_model.User, distribution string) ([]*repositoryFile, error) {
+	components, err := debian_model.GetComponents(ctx, owner.ID, distribution)
+	if err != nil {
+		return nil, err
+	}
+	if len(components) == 0 {
+		return nil, nil
+	}
+
+	architectures, err := debian_model.GetArchitectures(ctx, owner.ID, distribution)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(architectures)
+	// packages of the architecture "all" are part of the indices of the other architectures,
+	// but clients without a specific architecture may still request their own index
+	releaseArchitectures := architectures
+	if len(architectures) > 1 {
+		releaseArchitectures = util.SliceRemoveAll(append([]string(nil), architectures...), debian_module.ArchitectureAll)
+	}
+
+	var lastModified time.Time
+	files := make([]*repositoryFile, 0, len(components)*len(architectures)*2+3)
+	indices := make([]*indexFile, 0, len(components)*len(architectures)*2)
+	for _, component := range components {
+		for _, architecture := range architectures {
+			content, modified, err := buildPackagesIndex(ctx, owner.ID, distribution, component, architecture)
+			if err == ErrDistributionNotExist {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if modified.After(lastModified) {
+				lastModified = modified
+			}
+
+			compressed, err := compress(content)
+			if err != nil {
+				return nil, err
+			}
+
+			key := IndexCompositeKey(distribution, component, architecture)
+			files = append(files, &repositoryFile{key, "Packages", content}, &repositoryFile{key, "Packages.gz", compressed})
+
+			path := fmt.Sprintf("%s/binary-%s/Packages", component, architecture)
+			indices = append(indices, &indexFile{path, content}, &indexFile{path + ".gz", compressed})
+		}
+	}
+
+	// https://wiki.debian.org/DebianRepository/Format#A.22Release.22_files
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Origin: %s\n", owner.Name)
+	fmt.Fprintf(&buf, "Label: %s\n", owner.Name)
+	fmt.Fprintf(&buf, "Suite: %s\n", distribution)
+	fmt.Fprintf(&buf, "Codename: %s\n", distribution)
+	fmt.Fprintf(&buf, "Date: %s\n", lastModified.UTC().Format(time.RFC1123))
+	fmt.Fprintf(&buf, "Architectures: %s\n", strings.Join(releaseArchitectures, " "))
+	fmt.Fprintf(&buf, "Components: %s\n", strings.Join(components, " "))
+
+	hashes := []struct {
+		Name string
+		Sum  func([]byte) string
+	}{
+		{"MD5Sum", func(b []byte) string { s := md5.Sum(b); return hex.EncodeToString(s[:]) }},
+		{"SHA1", func(b []byte) string { s := sha1.Sum(b); return hex.EncodeToString(s[:]) }},
+		{"SHA256", func(b []byte) string { s := sha256.Sum256(b); return hex.EncodeToString(s[:]) }},
+		{"SHA512", func(b []byte) string { s := sha512.Sum512(b); return hex.EncodeToString(s[:]) }},
+	}
+	for _, h := range hashes {
+		fmt.Fprintf(&buf, "%s:\n", h.Name)
+		for _, f := range indices {
+			fmt.Fprintf(&buf, " %s %d %s\n", h.Sum(f.Content), len(f.Content), f.Path)
+		}
+	}
+	release := buf.Bytes()
+
+	inRelease, err := signInline(owner.ID, release)
+	if err != nil {
+		return nil, err
+	}
+	releaseGpg, err := signDetached(owner.ID, release)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(files,
+		&repositoryFile{distribution, "Release", release},
+		&repositoryFile{distribution, "Release.gpg", releaseGpg},
+		&repositoryFile{distribution, "InRelease", inRelease},
+	), nil
+}
+
+// signInline creates the InRelease file by clearsigning the Release file
+func signInline(ownerID int64, release []byte) ([]byte, error) {
+	e, err := readPrivateKey(ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w, err := clearsign.Encode(&buf, e.PrivateKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(release); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// signDetached creates the Release.gpg file containing the detached signature of the Release file
+func signDetached(ownerID int64, release []byte) ([]byte, error) {
+	e, err := readPrivateKey(ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&buf, e, bytes.NewReader(release), nil); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GetOrCreateRepositoryVersion gets or creates the internal package version which stores the repository files of the owner
+func GetOrCreateRepositoryVersion(ctx context.Context, ownerID int64) (*packages_model.PackageVersion, error) {
+	var repoVersion *packages_model.PackageVersion
+
+	return repoVersion, db.WithTx(ctx, func(ctx context.Context) error {
+		p := &packages_model.Package{
+			OwnerID:   ownerID,
+			Type:      packages_model.TypeDebian,
+			Name:      debian_module.RepositoryPackage,
+			LowerName: debian_module.RepositoryPackage,
+		}
+		var err error
+		if p, err = packages_model.TryInsertPackage(ctx, p); err != nil {
+			if err != packages_model.ErrDuplicatePackage {
+				log.Error("Error inserting package: %v", err)
+				return err
+			}
+		}
+
+		pv := &packages_model.PackageVersion{
+			PackageID:    p.ID,
+			CreatorID:    ownerID,
+			Version:      debian_module.RepositoryVersion,
+			LowerVersion: debian_module.RepositoryVersion,
+			IsInternal:   true,
+			MetadataJSON: "null",
+		}
+		if pv, err = packages_model.GetOrInsertVersion(ctx, pv); err != nil {
+			if err != packages_model.ErrDuplicatePackageVersion {
+				log.Error("Error inserting package version: %v", err)
+				return err
+			}
+		}
+
+		repoVersion = pv
+
+		return nil
+	})
+}
+
+// BuildSpecificRepositoryFiles creates the repository files of a distribution and stores them.
+// The files are replaced in one transaction, so the checksums in the Release files always match
+// the served Packages files. The files of a distribution without packages are removed.
+func BuildSpecificRepositoryFiles(ctx context.Context, ownerID int64, distribution string) error {
+	repositoryMutex.Lock()
+	defer repositoryMutex.Unlock()
+
+	owner, err := user_model.GetUserByID(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	return buildAndStoreRepositoryFiles(ctx, owner, []string{distribution})
+}
+
+// BuildAllRepositoryFiles creates the repository files of all distributions of the owner and stores them.
+// It must be used if the removed packages may belong to any distribution.
+func BuildAllRepositoryFiles(ctx context.Context, ownerID int64) error {
+	repositoryMutex.Lock()
+	defer repositoryMutex.Unlock()
+
+	owner, err := user_model.GetUserByID(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	distributions, err := debian_model.GetDistributions(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	// the stored files of distributions without packages must be removed too
+	pv, err := packages_model.GetInternalVersionByNameAndVersion(ctx, ownerID, packages_model.TypeDebian, debian_module.RepositoryPackage, debian_module.RepositoryVersion)
+	if err != nil && err != packages_model.ErrPackageNotExist {
+		return err
+	}
+	if pv != nil {
+		pfs, err := packages_model.GetFilesByVersionID(ctx, pv.ID)
+		if err != nil {
+			return err
+		}
+		for _, pf := range pfs {
+			distribution, _, _ := strings.Cut(pf.CompositeKey, "|")
+			if !util.SliceContainsString(distributions, distribution) {
+				distributions = append(distributions, distribution)
+			}
+		}
+	}
+
+	return buildAndStoreRepositoryFiles(ctx, owner, distributions)
+}
+
+func buildAndStoreRepositoryFiles(ctx context.Context, owner *user_model.User, distributions []string) error {
+	files := make([]*repositoryFile, 0, 10)
+	for _, distribution := range distributions {
+		distributionFiles, err := buildRepositoryFiles(ctx, owner, distribution)
+		if err != nil {
+			return err
+		}
+		files = append(files, distributionFiles...)
+	}
+
+	var pv *packages_model.PackageVersion
+	var err error
+	if len(files) == 0 {
+		pv, err = packages_model.GetInternalVersionByNameAndVersion(ctx, owner.ID, packages_model.TypeDebian, debian_module.RepositoryPackage, debian_module.RepositoryVersion)
+		if err != nil {
+			if err == packages_model.ErrPackageNotExist {
+				return nil
+			}
+			return err
+		}
+	} else {
+		pv, err = GetOrCreateRepositoryVersion(ctx, owner.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		pfs, err := packages_model.GetFilesByVersionID(ctx, pv.ID)
+		if err != nil {
+			return err
+		}
+
+		keep := make(container.Set[string], len(files))
+		for _, f := range files {
+			keep.Add(f.CompositeKey + "/" + f.Filename)
+		}
+
+		// remove the stale files of the rebuilt distributions, like the indices of an architecture without packages
+		remaining := 0
+		for _, pf := range pfs {
+			distribution, _, _ := strings.Cut(pf.CompositeKey, "|")
+			if !util.SliceContainsString(distributions, distribution) || keep.Contains(pf.CompositeKey+"/"+pf.Name) {
+				remaining++
+				continue
+			}
+			if err := packages_service.DeletePackageFile(ctx, pf); err != nil {
+				return err
+			}
+		}
+
+		if len(files) == 0 && remaining == 0 {
+			return packages_service.DeletePackageVersionAndReferences(ctx, pv)
+		}
+
+		for _, f := range files {
+			if err := addRepositoryFile(ctx, pv, f); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func addRepositoryFile(ctx context.Context, pv *packages_model.PackageVersion, f *repositoryFile) error {
+	buf, err := packages_module.CreateHashedBufferFromReader(bytes.NewReader(f.Content), 32*1024*1024)
+	if err != nil {
+		return err
+	}
+	defer buf.Close()
+
+	_, err = packages_service.AddFileToPackageVersionInternal(ctx, pv, &packages_service.PackageFileCreationInfo{
+		PackageFileInfo: packages_service.PackageFileInfo{
+			Filename:     f.Filename,
+			CompositeKey: f.CompositeKey,
+		},
+		Data:              buf,
+		OverwriteExisting: true,
+	})
+	return err
+}
+
+// GetPackageFileDescriptor gets the file with the given name of a distribution and component
+func GetPackageFileDescriptor(ctx context.Context, ownerID int64, distribution, component, filename string) (*packages_model.PackageFileDescriptor, error) {
+	pfds, err := debian_model.SearchPackages(ctx, &debian_model.PackageSearchOptions{
+		OwnerID:      ownerID,
+		Distribution: distribution,
+		Component:    component,
+		FileName:     filename,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(pfds) != 1 {
+		return nil, packages_model.ErrPackageFileNotExist
+	}
+	return pfds[0], nil
+}
+
+// FileName returns the name of the file of a package in the pool
+func FileName(name, version, architecture string) string {
+	// the epoch is not part of the file name
+	if i := strings.IndexByte(version, ':'); i != -1 {
+		version = version[i+1:]
+	}
+	return fmt.Sprintf("%s_%s_%s.deb", name, version, architecture)
+}
+
+// CompositeKey returns the composite key of the files of a distribution and component
+func CompositeKey(distribution, component string) string {
+	return distribution + "|" + component
+}
+
+// IndexCompositeKey returns the composite key of the stored Packages files of a distribution, component and architecture
+func IndexCompositeKey(distribution, component, architecture string) string {
+	return distribution + "|" + component + "|" + architecture
+}
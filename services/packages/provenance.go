@@ -0,0 +1,68 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package packages
+
+import (
+	"context"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	access_model "code.gitea.io/gitea/models/perm/access"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unit"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/util"
+)
+
+var (
+	// ErrProvenanceRepositoryOwner is returned if the repository is owned by someone else than the package
+	ErrProvenanceRepositoryOwner = util.NewInvalidArgumentErrorf("repository must belong to the package owner")
+	// ErrProvenanceCommitNotExist is returned if the commit is invalid or doesn't exist in the repository
+	ErrProvenanceCommitNotExist = util.NewInvalidArgumentErrorf("commit does not exist in the repository")
+	// ErrProvenanceReleaseNotExist is returned if the release tag doesn't exist in the repository
+	ErrProvenanceReleaseNotExist = util.NewInvalidArgumentErrorf("release tag does not exist in the repository")
+)
+
+// SetVersionProvenance records the repository, commit and optional release tag a package version was built from.
+// An abbreviated commit sha is resolved to the full sha before it gets stored.
+func SetVersionProvenance(ctx context.Context, doer *user_model.User, pd *packages_model.PackageDescriptor, repo *repo_model.Repository, commitID, release string) error {
+	if !git.IsValidSHAPattern(commitID) {
+		return ErrProvenanceCommitNotExist
+	}
+	if repo.OwnerID != pd.Owner.ID {
+		return ErrProvenanceRepositoryOwner
+	}
+
+	perm, err := access_model.GetUserRepoPermission(ctx, repo, doer)
+	if err != nil {
+		return err
+	}
+	if !perm.CanRead(unit.TypeCode) {
+		return util.NewPermissionDeniedErrorf("no permission to read the code of the repository")
+	}
+
+	gitRepo, closer, err := git.RepositoryFromContextOrOpen(ctx, repo.RepoPath())
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	commit, err := gitRepo.GetCommit(commitID)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			return ErrProvenanceCommitNotExist
+		}
+		return err
+	}
+
+	if release != "" && !gitRepo.IsTagExist(release) {
+		return ErrProvenanceReleaseNotExist
+	}
+
+	return packages_model.SetVersionProvenance(ctx, pd.Version.ID, &packages_model.Provenance{
+		RepoID:    repo.ID,
+		CommitSHA: commit.ID.String(),
+		Release:   release,
+	})
+}
@@ -0,0 +1,338 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/modules/hostmatcher"
+	"code.gitea.io/gitea/modules/json"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	"code.gitea.io/gitea/modules/proxy"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+var (
+	// ErrRemoteNotExist is returned if the remote registry does not have the requested resource
+	ErrRemoteNotExist = util.NewNotExistErrorf("resource does not exist in the remote registry")
+	// ErrRemoteUnavailable is returned if the remote registry could not be reached or responded with an unexpected status
+	ErrRemoteUnavailable = errors.New("remote registry is unavailable")
+)
+
+// Client fetches resources from the remote registry of a package type
+type Client struct {
+	baseURL    *url.URL
+	username   string
+	password   string
+	token      string
+	sizeLimit  int64
+	httpClient *http.Client
+}
+
+// GetClient creates a client for the enabled remote registry of the owner and package type.
+// It returns nil if the owner has no enabled remote registry for the package type.
+func GetClient(ctx context.Context, ownerID int64, packageType packages_model.Type) (*Client, error) {
+	pr, err := packages_model.GetEnabledRemoteByType(ctx, ownerID, packageType)
+	if err != nil {
+		if err == packages_model.ErrPackageRemoteNotExist {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return NewClient(pr)
+}
+
+// NewClient creates a client for the remote registry
+func NewClient(pr *packages_model.PackageRemote) (*Client, error) {
+	baseURL, err := url.Parse(strings.TrimSuffix(pr.URL, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := pr.Password()
+	if err != nil {
+		return nil, err
+	}
+
+	allowedHostListValue := setting.Packages.RemoteAllowedHostList
+	if allowedHostListValue == "" {
+		allowedHostListValue = hostmatcher.MatchBuiltinExternal
+	}
+	allowedHostMatcher := hostmatcher.ParseHostMatchList("packages.REMOTE_ALLOWED_HOST_LIST", allowedHostListValue)
+
+	// the smaller limit applies, -1 means no limit
+	sizeLimit := packages_service.GetTypeSpecificSizeLimit(pr.Type)
+	if setting.Packages.RemoteLimitSize > -1 && (sizeLimit == -1 || setting.Packages.RemoteLimitSize < sizeLimit) {
+		sizeLimit = setting.Packages.RemoteLimitSize
+	}
+
+	return &Client{
+		baseURL:   baseURL,
+		username:  pr.Username,
+		password:  password,
+		sizeLimit: sizeLimit,
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				Proxy:       proxy.Proxy(),
+				DialContext: hostmatcher.NewDialContext("packages", allowedHostMatcher, nil),
+			},
+		},
+	}, nil
+}
+
+// resolve returns the url of the resource. Absolute urls are used as they are,
+// relative paths are appended to the url of the remote registry.
+func (c *Client) resolve(p string) (*url.URL, error) {
+	if strings.HasPrefix(p, "http://") || strings.HasPrefix(p, "https://") {
+		return url.Parse(p)
+	}
+	return url.Parse(c.baseURL.String() + "/" + strings.TrimPrefix(p, "/"))
+}
+
+// Get requests the resource from the remote registry.
+// ErrRemoteNotExist is returned if the remote registry responds with 404, ErrRemoteUnavailable
+// if the request fails or the remote registry responds with another non 2xx status.
+// The caller must close the body of the response.
+func (c *Client) Get(ctx context.Context, p string, header http.Header) (*http.Response, error) {
+	u, err := c.resolve(p)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(ctx, u, header)
+	if err != nil {
+		return nil, err
+	}
+
+	// Registries like the Docker Hub require a token which is requested from an authorization service
+	if resp.StatusCode == http.StatusUnauthorized && c.token == "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+
+		if err := c.requestToken(ctx, challenge); err != nil {
+			return nil, err
+		}
+
+		if resp, err = c.do(ctx, u, header); err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrRemoteNotExist
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s responded with status %d", ErrRemoteUnavailable, u.Redacted(), resp.StatusCode)
+	}
+
+	return resp, nil
+}
+
+// GetJSON requests the resource from the remote registry and decodes the response into v.
+// ErrQuotaTypeSize is returned if the response is larger than the size limit.
+func (c *Client) GetJSON(ctx context.Context, p string, v interface{}) error {
+	resp, err := c.Get(ctx, p, http.Header{"Accept": []string{"application/json"}})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := c.limitBody(resp)
+	if err != nil {
+		return err
+	}
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		if body.exceeded() {
+			return packages_service.ErrQuotaTypeSize
+		}
+		return fmt.Errorf("%w: invalid response: %v", ErrRemoteUnavailable, err)
+	}
+	return nil
+}
+
+// Download requests the resource from the remote registry and stores the content in a buffer.
+// ErrQuotaTypeSize is returned if the content is larger than the size limit.
+func (c *Client) Download(ctx context.Context, p string, header http.Header) (*packages_module.HashedBuffer, error) {
+	resp, err := c.Get(ctx, p, header)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := c.limitBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := packages_module.CreateHashedBufferFromReader(body, 32*1024*1024)
+	if err != nil {
+		if body.exceeded() {
+			return nil, packages_service.ErrQuotaTypeSize
+		}
+		return nil, fmt.Errorf("%w: %v", ErrRemoteUnavailable, err)
+	}
+	return buf, nil
+}
+
+// limitBody limits the size of the body of the response. The response is rejected early
+// if the announced content length is already larger than the size limit.
+func (c *Client) limitBody(resp *http.Response) (*limitedBody, error) {
+	limit := c.sizeLimit
+	if limit < 0 {
+		limit = math.MaxInt64 - 1
+	}
+	if resp.ContentLength > limit {
+		return nil, packages_service.ErrQuotaTypeSize
+	}
+	return &limitedBody{r: resp.Body, remaining: limit}, nil
+}
+
+// limitedBody fails with ErrQuotaTypeSize once more than the remaining bytes are read
+type limitedBody struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.exceeded() {
+		return 0, packages_service.ErrQuotaTypeSize
+	}
+	// one more byte than allowed is read to detect content which is too large
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.r.Read(p)
+	b.remaining -= int64(n)
+	if b.exceeded() {
+		return 0, packages_service.ErrQuotaTypeSize
+	}
+	return n, err
+}
+
+func (b *limitedBody) exceeded() bool {
+	return b.remaining < 0
+}
+
+func (c *Client) do(ctx context.Context, u *url.URL, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	// Credentials are only sent to the remote registry and not to other hosts like a CDN
+	if u.Host == c.baseURL.Host {
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		} else if c.username != "" || c.password != "" {
+			req.SetBasicAuth(c.username, c.password)
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRemoteUnavailable, err)
+	}
+	return resp, nil
+}
+
+// requestToken requests a token from the authorization service described in the challenge
+// https://docs.docker.com/registry/spec/auth/token/
+func (c *Client) requestToken(ctx context.Context, challenge string) error {
+	params, ok := parseBearerChallenge(challenge)
+	if !ok || params["realm"] == "" {
+		return fmt.Errorf("%w: authentication required", ErrRemoteUnavailable)
+	}
+
+	u, err := url.Parse(params["realm"])
+	if err != nil {
+		return err
+	}
+	q := u.Query()
+	for _, key := range []string{"service", "scope"} {
+		if value := params[key]; value != "" {
+			q.Set(key, value)
+		}
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	if c.username != "" || c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrRemoteUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s responded with status %d", ErrRemoteUnavailable, u.Redacted(), resp.StatusCode)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("%w: invalid token response: %v", ErrRemoteUnavailable, err)
+	}
+
+	c.token = token.Token
+	if c.token == "" {
+		c.token = token.AccessToken
+	}
+	if c.token == "" {
+		return fmt.Errorf("%w: empty token", ErrRemoteUnavailable)
+	}
+	return nil
+}
+
+// parseBearerChallenge parses the parameters of a header like
+// Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/alpine:pull"
+func parseBearerChallenge(challenge string) (map[string]string, bool) {
+	scheme, rest, ok := strings.Cut(strings.TrimSpace(challenge), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimSpace(rest) {
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			return nil, false
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end == -1 {
+				return nil, false
+			}
+			params[key] = value[1 : end+1]
+			rest = strings.TrimPrefix(value[end+2:], ",")
+		} else {
+			value, rest, _ = strings.Cut(value, ",")
+			params[key] = strings.TrimSpace(value)
+		}
+	}
+	return params, true
+}
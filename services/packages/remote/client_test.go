@@ -0,0 +1,103 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package remote
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	packages_service "code.gitea.io/gitea/services/packages"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBearerChallenge(t *testing.T) {
+	cases := []struct {
+		Challenge string
+		Params    map[string]string
+		Valid     bool
+	}{
+		{
+			Challenge: `Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/alpine:pull"`,
+			Params: map[string]string{
+				"realm":   "https://auth.docker.io/token",
+				"service": "registry.docker.io",
+				"scope":   "repository:library/alpine:pull",
+			},
+			Valid: true,
+		},
+		{
+			Challenge: `bearer Realm="https://example.com/token", scope="a,b"`,
+			Params: map[string]string{
+				"realm": "https://example.com/token",
+				"scope": "a,b",
+			},
+			Valid: true,
+		},
+		{
+			Challenge: `Bearer realm=https://example.com/token,service=test`,
+			Params: map[string]string{
+				"realm":   "https://example.com/token",
+				"service": "test",
+			},
+			Valid: true,
+		},
+		{Challenge: `Basic realm="test"`},
+		{Challenge: `Bearer realm="test`},
+		{Challenge: `Bearer realm`},
+		{Challenge: ``},
+	}
+
+	for _, c := range cases {
+		params, ok := parseBearerChallenge(c.Challenge)
+		assert.Equal(t, c.Valid, ok, c.Challenge)
+		if c.Valid {
+			assert.Equal(t, c.Params, params, c.Challenge)
+		}
+	}
+}
+
+func TestSizeLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content := `{"name":"` + strings.Repeat("a", 100) + `"}`
+		if r.URL.Path == "/chunked" {
+			// without Content-Length the limit is only noticed while reading
+			w.Header().Set("Transfer-Encoding", "chunked")
+			w.(http.Flusher).Flush()
+		}
+		_, _ = io.WriteString(w, content)
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL)
+	newClient := func(sizeLimit int64) *Client {
+		return &Client{baseURL: baseURL, sizeLimit: sizeLimit, httpClient: server.Client()}
+	}
+
+	for _, p := range []string{"/content-length", "/chunked"} {
+		buf, err := newClient(-1).Download(context.Background(), p, nil)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 111, buf.Size())
+		buf.Close()
+
+		buf, err = newClient(111).Download(context.Background(), p, nil)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 111, buf.Size())
+		buf.Close()
+
+		_, err = newClient(110).Download(context.Background(), p, nil)
+		assert.ErrorIs(t, err, packages_service.ErrQuotaTypeSize)
+
+		var v map[string]string
+		assert.NoError(t, newClient(111).GetJSON(context.Background(), p, &v))
+		assert.Len(t, v["name"], 100)
+
+		assert.ErrorIs(t, newClient(50).GetJSON(context.Background(), p, &v), packages_service.ErrQuotaTypeSize)
+	}
+}
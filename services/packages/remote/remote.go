@@ -0,0 +1,58 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package remote
+
+import (
+	"context"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	user_model "code.gitea.io/gitea/models/user"
+)
+
+// PropertyRemoteURL is the version property which contains the url of the remote registry a version was fetched from
+const PropertyRemoteURL = "remote.url"
+
+// GetClientForPackage creates a client for the enabled remote registry if missing versions of the package should be fetched from it.
+// Packages with uploaded versions are never resolved through the remote registry to prevent a public package from shadowing a private one.
+// It returns nil if the package must not be fetched.
+func GetClientForPackage(ctx context.Context, ownerID int64, packageType packages_model.Type, packageName string) (*Client, error) {
+	client, err := GetClient(ctx, ownerID, packageType)
+	if err != nil || client == nil {
+		return nil, err
+	}
+
+	p, err := packages_model.GetPackageByName(ctx, ownerID, packageType, packageName)
+	if err != nil {
+		if err == packages_model.ErrPackageNotExist {
+			return client, nil
+		}
+		return nil, err
+	}
+
+	hasUploadedVersions, err := packages_model.HasVersionsWithoutProperty(ctx, p.ID, PropertyRemoteURL)
+	if err != nil {
+		return nil, err
+	}
+	if hasUploadedVersions {
+		return nil, nil
+	}
+	return client, nil
+}
+
+// URL returns the url of the remote registry
+func (c *Client) URL() string {
+	return c.baseURL.String()
+}
+
+// VersionProperties returns the properties to store on a version fetched from the remote registry
+func (c *Client) VersionProperties() map[string]string {
+	return map[string]string{
+		PropertyRemoteURL: c.URL(),
+	}
+}
+
+// Creator returns the user recorded as creator of versions fetched from a remote registry
+func Creator() *user_model.User {
+	return user_model.NewGhostUser()
+}
@@ -0,0 +1,514 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package rpm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"sync"
+
+	"code.gitea.io/gitea/models/db"
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	rpm_module "code.gitea.io/gitea/modules/packages/rpm"
+	"code.gitea.io/gitea/modules/timeutil"
+	packages_service "code.gitea.io/gitea/services/packages"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+const (
+	RepoMdFile        = "repomd.xml"
+	RepoMdSignature   = "repomd.xml.asc"
+	PrimaryFile       = "primary.xml.gz"
+	FilelistsFile     = "filelists.xml.gz"
+	OtherFile         = "other.xml.gz"
+	repositoryDataDir = "repodata"
+)
+
+var repositoryMutex sync.Mutex
+
+// GetOrCreateKeyPair gets or creates the PGP keys used to sign the repository metadata of the owner
+func GetOrCreateKeyPair(ownerID int64) (string, string, error) {
+	return packages_service.GetOrCreatePGPKeyPair(ownerID, "RPM Registry", rpm_module.SettingKeyPrivate, rpm_module.SettingKeyPublic)
+}
+
+// FileName returns the name of the file of a package
+func FileName(name, version, architecture string) string {
+	return fmt.Sprintf("%s-%s.%s.rpm", name, version, architecture)
+}
+
+// GetPackageFile gets the package file of the given name, version and architecture
+func GetPackageFile(ctx context.Context, ownerID int64, name, version, architecture string) (*packages_model.PackageVersion, *packages_model.PackageFile, error) {
+	pv, err := packages_model.GetVersionByNameAndVersion(ctx, ownerID, packages_model.TypeRpm, name, version)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pf, err := packages_model.GetFileForVersionByName(ctx, pv.ID, FileName(name, version, architecture), packages_model.EmptyFileKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pv, pf, nil
+}
+
+type packageData struct {
+	Package      *packages_model.Package
+	Version      *packages_model.PackageVersion
+	Blob         *packages_model.PackageBlob
+	Metadata     *rpm_module.VersionMetadata
+	FileMetadata *rpm_module.FileMetadata
+	FileTime     timeutil.TimeStamp
+}
+
+func getPackages(ctx context.Context, ownerID int64) ([]*packageData, error) {
+	pvs, err := packages_model.GetVersionsByPackageType(ctx, ownerID, packages_model.TypeRpm)
+	if err != nil {
+		return nil, err
+	}
+
+	pds, err := packages_model.GetPackageDescriptors(ctx, pvs)
+	if err != nil {
+		return nil, err
+	}
+
+	pkgs := make([]*packageData, 0, len(pds))
+	for _, pd := range pds {
+		for _, pfd := range pd.Files {
+			var fm *rpm_module.FileMetadata
+			if err := json.Unmarshal([]byte(pfd.Properties.GetByName(rpm_module.PropertyMetadata)), &fm); err != nil {
+				return nil, err
+			}
+
+			pkgs = append(pkgs, &packageData{
+				Package:      pd.Package,
+				Version:      pd.Version,
+				Blob:         pfd.Blob,
+				Metadata:     pd.Metadata.(*rpm_module.VersionMetadata),
+				FileMetadata: fm,
+				FileTime:     pfd.File.CreatedUnix,
+			})
+		}
+	}
+
+	return pkgs, nil
+}
+
+type repoChecksum struct {
+	Value string `xml:",chardata"`
+	Type  string `xml:"type,attr"`
+}
+
+type repoLocation struct {
+	Href string `xml:"href,attr"`
+}
+
+type repoData struct {
+	Type         string       `xml:"type,attr"`
+	Checksum     repoChecksum `xml:"checksum"`
+	OpenChecksum repoChecksum `xml:"open-checksum"`
+	Location     repoLocation `xml:"location"`
+	Timestamp    int64        `xml:"timestamp"`
+	Size         int64        `xml:"size"`
+	OpenSize     int64        `xml:"open-size"`
+}
+
+type repomd struct {
+	XMLName  xml.Name    `xml:"repomd"`
+	Xmlns    string      `xml:"xmlns,attr"`
+	XmlnsRpm string      `xml:"xmlns:rpm,attr"`
+	Revision int64       `xml:"revision"`
+	Data     []*repoData `xml:"data"`
+}
+
+type versionEntry struct {
+	Epoch   string `xml:"epoch,attr"`
+	Version string `xml:"ver,attr"`
+	Release string `xml:"rel,attr"`
+}
+
+func newVersionEntry(fm *rpm_module.FileMetadata) versionEntry {
+	epoch := fm.Epoch
+	if epoch == "" {
+		epoch = "0"
+	}
+	return versionEntry{
+		Epoch:   epoch,
+		Version: fm.Version,
+		Release: fm.Release,
+	}
+}
+
+type entryList struct {
+	Entries []*rpm_module.Entry `xml:"rpm:entry"`
+}
+
+func newEntryList(entries []*rpm_module.Entry) *entryList {
+	if len(entries) == 0 {
+		return nil
+	}
+	return &entryList{entries}
+}
+
+type primaryPackage struct {
+	XMLName      xml.Name     `xml:"package"`
+	Type         string       `xml:"type,attr"`
+	Name         string       `xml:"name"`
+	Architecture string       `xml:"arch"`
+	Version      versionEntry `xml:"version"`
+	Checksum     struct {
+		Checksum string `xml:",chardata"`
+		Type     string `xml:"type,attr"`
+		Pkgid    string `xml:"pkgid,attr"`
+	} `xml:"checksum"`
+	Summary     string `xml:"summary"`
+	Description string `xml:"description"`
+	Packager    string `xml:"packager"`
+	URL         string `xml:"url"`
+	Time        struct {
+		File  uint64 `xml:"file,attr"`
+		Build uint64 `xml:"build,attr"`
+	} `xml:"time"`
+	Size struct {
+		Package   int64  `xml:"package,attr"`
+		Installed uint64 `xml:"installed,attr"`
+		Archive   uint64 `xml:"archive,attr"`
+	} `xml:"size"`
+	Location repoLocation `xml:"location"`
+	Format   struct {
+		License     string `xml:"rpm:license"`
+		Vendor      string `xml:"rpm:vendor"`
+		Group       string `xml:"rpm:group"`
+		Buildhost   string `xml:"rpm:buildhost"`
+		Sourcerpm   string `xml:"rpm:sourcerpm"`
+		HeaderRange struct {
+			Start int64 `xml:"start,attr"`
+			End   int64 `xml:"end,attr"`
+		} `xml:"rpm:header-range"`
+		Provides  *entryList         `xml:"rpm:provides"`
+		Requires  *entryList         `xml:"rpm:requires"`
+		Conflicts *entryList         `xml:"rpm:conflicts"`
+		Obsoletes *entryList         `xml:"rpm:obsoletes"`
+		Files     []*rpm_module.File `xml:"file"`
+	} `xml:"format"`
+}
+
+type primaryMetadata struct {
+	XMLName      xml.Name          `xml:"metadata"`
+	Xmlns        string            `xml:"xmlns,attr"`
+	XmlnsRpm     string            `xml:"xmlns:rpm,attr"`
+	PackageCount int               `xml:"packages,attr"`
+	Packages     []*primaryPackage `xml:"package"`
+}
+
+type filelistsPackage struct {
+	Pkgid        string             `xml:"pkgid,attr"`
+	Name         string             `xml:"name,attr"`
+	Architecture string             `xml:"arch,attr"`
+	Version      versionEntry       `xml:"version"`
+	Files        []*rpm_module.File `xml:"file"`
+}
+
+type filelists struct {
+	XMLName      xml.Name            `xml:"filelists"`
+	Xmlns        string              `xml:"xmlns,attr"`
+	PackageCount int                 `xml:"packages,attr"`
+	Packages     []*filelistsPackage `xml:"package"`
+}
+
+type otherPackage struct {
+	Pkgid        string                  `xml:"pkgid,attr"`
+	Name         string                  `xml:"name,attr"`
+	Architecture string                  `xml:"arch,attr"`
+	Version      versionEntry            `xml:"version"`
+	Changelogs   []*rpm_module.Changelog `xml:"changelog"`
+}
+
+type otherdata struct {
+	XMLName      xml.Name        `xml:"otherdata"`
+	Xmlns        string          `xml:"xmlns,attr"`
+	PackageCount int             `xml:"packages,attr"`
+	Packages     []*otherPackage `xml:"package"`
+}
+
+// isPrimaryFile checks if a file belongs into the primary index instead of the filelists index only
+// https://github.com/rpm-software-management/createrepo_c/blob/master/src/parsehdr.c
+func isPrimaryFile(f *rpm_module.File) bool {
+	return strings.HasPrefix(f.Path, "/etc/") || f.Path == "/usr/lib/sendmail" || strings.Contains(f.Path, "bin/")
+}
+
+// GetOrCreateRepositoryVersion gets or creates the internal package version which stores the repodata files of the owner
+func GetOrCreateRepositoryVersion(ctx context.Context, ownerID int64) (*packages_model.PackageVersion, error) {
+	var repoVersion *packages_model.PackageVersion
+
+	return repoVersion, db.WithTx(ctx, func(ctx context.Context) error {
+		p := &packages_model.Package{
+			OwnerID:   ownerID,
+			Type:      packages_model.TypeRpm,
+			Name:      rpm_module.RepositoryPackage,
+			LowerName: rpm_module.RepositoryPackage,
+		}
+		var err error
+		if p, err = packages_model.TryInsertPackage(ctx, p); err != nil {
+			if err != packages_model.ErrDuplicatePackage {
+				log.Error("Error inserting package: %v", err)
+				return err
+			}
+		}
+
+		pv := &packages_model.PackageVersion{
+			PackageID:    p.ID,
+			CreatorID:    ownerID,
+			Version:      rpm_module.RepositoryVersion,
+			LowerVersion: rpm_module.RepositoryVersion,
+			IsInternal:   true,
+			MetadataJSON: "null",
+		}
+		if pv, err = packages_model.GetOrInsertVersion(ctx, pv); err != nil {
+			if err != packages_model.ErrDuplicatePackageVersion {
+				log.Error("Error inserting package version: %v", err)
+				return err
+			}
+		}
+
+		repoVersion = pv
+
+		return nil
+	})
+}
+
+// BuildRepositoryFiles creates the files of the repodata directory of the owner and stores them.
+// All files are replaced in one transaction, so the checksums in repomd.xml always match the
+// served files. The repodata is removed if the owner has no packages left.
+func BuildRepositoryFiles(ctx context.Context, ownerID int64) error {
+	repositoryMutex.Lock()
+	defer repositoryMutex.Unlock()
+
+	pkgs, err := getPackages(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	if len(pkgs) == 0 {
+		pv, err := packages_model.GetInternalVersionByNameAndVersion(ctx, ownerID, packages_model.TypeRpm, rpm_module.RepositoryPackage, rpm_module.RepositoryVersion)
+		if err != nil {
+			if err == packages_model.ErrPackageNotExist {
+				return nil
+			}
+			return err
+		}
+		return db.WithTx(ctx, func(ctx context.Context) error {
+			return packages_service.DeletePackageVersionAndReferences(ctx, pv)
+		})
+	}
+
+	now := timeutil.TimeStampNow()
+
+	primary := &primaryMetadata{
+		Xmlns:        "http://linux.duke.edu/metadata/common",
+		XmlnsRpm:     "http://linux.duke.edu/metadata/rpm",
+		PackageCount: len(pkgs),
+	}
+	filelistsData := &filelists{
+		Xmlns:        "http://linux.duke.edu/metadata/filelists",
+		PackageCount: len(pkgs),
+	}
+	other := &otherdata{
+		Xmlns:        "http://linux.duke.edu/metadata/other",
+		PackageCount: len(pkgs),
+	}
+
+	for _, pkg := range pkgs {
+		fm := pkg.FileMetadata
+		version := newVersionEntry(fm)
+
+		pp := &primaryPackage{
+			Type:         "rpm",
+			Name:         pkg.Package.Name,
+			Architecture: fm.Architecture,
+			Version:      version,
+			Summary:      pkg.Metadata.Summary,
+			Description:  pkg.Metadata.Description,
+			Packager:     fm.Packager,
+			URL:          pkg.Metadata.ProjectURL,
+			Location: repoLocation{
+				Href: fmt.Sprintf("package/%s/%s/%s", pkg.Package.Name, pkg.Version.Version, fm.Architecture),
+			},
+		}
+		pp.Checksum.Checksum = pkg.Blob.HashSHA256
+		pp.Checksum.Type = "sha256"
+		pp.Checksum.Pkgid = "YES"
+		pp.Time.File = uint64(pkg.FileTime)
+		pp.Time.Build = fm.BuildTime
+		pp.Size.Package = pkg.Blob.Size
+		pp.Size.Installed = fm.InstalledSize
+		pp.Size.Archive = fm.ArchiveSize
+		pp.Format.License = pkg.Metadata.License
+		pp.Format.Vendor = fm.Vendor
+		pp.Format.Group = fm.Group
+		pp.Format.Buildhost = fm.BuildHost
+		pp.Format.Sourcerpm = fm.SourceRpm
+		pp.Format.HeaderRange.Start = fm.HeaderStart
+		pp.Format.HeaderRange.End = fm.HeaderEnd
+		pp.Format.Provides = newEntryList(fm.Provides)
+		pp.Format.Requires = newEntryList(fm.Requires)
+		pp.Format.Conflicts = newEntryList(fm.Conflicts)
+		pp.Format.Obsoletes = newEntryList(fm.Obsoletes)
+		for _, f := range fm.Files {
+			if isPrimaryFile(f) {
+				pp.Format.Files = append(pp.Format.Files, f)
+			}
+		}
+		primary.Packages = append(primary.Packages, pp)
+
+		filelistsData.Packages = append(filelistsData.Packages, &filelistsPackage{
+			Pkgid:        pkg.Blob.HashSHA256,
+			Name:         pkg.Package.Name,
+			Architecture: fm.Architecture,
+			Version:      version,
+			Files:        fm.Files,
+		})
+
+		other.Packages = append(other.Packages, &otherPackage{
+			Pkgid:        pkg.Blob.HashSHA256,
+			Name:         pkg.Package.Name,
+			Architecture: fm.Architecture,
+			Version:      version,
+			Changelogs:   fm.Changelogs,
+		})
+	}
+
+	md := &repomd{
+		Xmlns:    "http://linux.duke.edu/metadata/repo",
+		XmlnsRpm: "http://linux.duke.edu/metadata/rpm",
+		Revision: int64(now),
+	}
+
+	files := make(map[string][]byte, 5)
+	for _, index := range []struct {
+		Type     string
+		Filename string
+		Content  interface{}
+	}{
+		{"primary", PrimaryFile, primary},
+		{"filelists", FilelistsFile, filelistsData},
+		{"other", OtherFile, other},
+	} {
+		content, err := marshalXML(index.Content)
+		if err != nil {
+			return err
+		}
+
+		compressed, err := compress(content)
+		if err != nil {
+			return err
+		}
+		files[index.Filename] = compressed
+
+		md.Data = append(md.Data, &repoData{
+			Type:         index.Type,
+			Checksum:     repoChecksum{Type: "sha256", Value: sha256Sum(compressed)},
+			OpenChecksum: repoChecksum{Type: "sha256", Value: sha256Sum(content)},
+			Location:     repoLocation{Href: repositoryDataDir + "/" + index.Filename},
+			Timestamp:    int64(now),
+			Size:         int64(len(compressed)),
+			OpenSize:     int64(len(content)),
+		})
+	}
+
+	content, err := marshalXML(md)
+	if err != nil {
+		return err
+	}
+	files[RepoMdFile] = content
+
+	files[RepoMdSignature], err = signDetached(ownerID, content)
+	if err != nil {
+		return err
+	}
+
+	pv, err := GetOrCreateRepositoryVersion(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		for filename, content := range files {
+			if err := addRepositoryFile(ctx, pv, filename, content); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func addRepositoryFile(ctx context.Context, pv *packages_model.PackageVersion, filename string, content []byte) error {
+	buf, err := packages_module.CreateHashedBufferFromReader(bytes.NewReader(content), 32*1024*1024)
+	if err != nil {
+		return err
+	}
+	defer buf.Close()
+
+	_, err = packages_service.AddFileToPackageVersionInternal(ctx, pv, &packages_service.PackageFileCreationInfo{
+		PackageFileInfo: packages_service.PackageFileInfo{
+			Filename: filename,
+		},
+		Data:              buf,
+		OverwriteExisting: true,
+	})
+	return err
+}
+
+func marshalXML(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	if err := xml.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func compress(content []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(content); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func sha256Sum(content []byte) string {
+	s := sha256.Sum256(content)
+	return hex.EncodeToString(s[:])
+}
+
+func signDetached(ownerID int64, content []byte) ([]byte, error) {
+	priv, _, err := GetOrCreateKeyPair(ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(priv))
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&buf, keyring[0], bytes.NewReader(content), nil); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
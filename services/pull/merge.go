@@ -857,7 +857,7 @@ func MergedManually(pr *issues_model.PullRequest, doer *user_model.User, baseGit
 			return models.ErrInvalidMergeStyle{ID: pr.BaseRepo.ID, Style: repo_model.MergeStyleManuallyMerged}
 		}
 
-		if len(commitID) < git.SHAFullLength {
+		if !git.IsFullCommitID(commitID) {
 			return fmt.Errorf("Wrong commit ID")
 		}
 
@@ -0,0 +1,257 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	system_model "code.gitea.io/gitea/models/system"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification"
+	"code.gitea.io/gitea/modules/notification/base"
+	repo_module "code.gitea.io/gitea/modules/repository"
+	"code.gitea.io/gitea/modules/storage"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// GitServiceOptions returns the options of the git services of the repository,
+// they advertise the bundle of the repository when it has one
+func GitServiceOptions(ctx context.Context, repo *repo_model.Repository, isWiki bool) git.ServiceOptions {
+	opts := repo.GitServiceOptions()
+	if isWiki {
+		return opts
+	}
+	bundle, err := repo_model.GetRepoBundle(ctx, repo.ID)
+	if err != nil {
+		log.Error("GetRepoBundle for %-v: %v", repo, err)
+	} else if bundle != nil {
+		opts.BundleURI = repo.HTMLURL() + "/clone.bundle"
+	}
+	return opts
+}
+
+// GenerateRepoBundles generates the bundles of the repositories which are at least minSize bytes large
+func GenerateRepoBundles(ctx context.Context, minSize int64, timeout time.Duration) error {
+	log.Trace("Doing: GenerateRepoBundles")
+
+	if err := db.Iterate(
+		ctx,
+		builder.Gte{"size": minSize}.And(builder.Eq{"is_empty": false, "status": repo_model.RepositoryReady}),
+		func(ctx context.Context, repo *repo_model.Repository) error {
+			select {
+			case <-ctx.Done():
+				return db.ErrCancelledf("before generating the bundle of %s", repo.FullName())
+			default:
+			}
+			// we can ignore the error here because it will be logged in GenerateRepoBundle
+			_ = GenerateRepoBundle(ctx, repo, timeout)
+			return nil
+		},
+	); err != nil {
+		return err
+	}
+
+	log.Trace("Finished: GenerateRepoBundles")
+	return nil
+}
+
+// GenerateRepoBundle generates the bundle of the branches and tags of the repository which clones can bootstrap from,
+// the bundle is only regenerated when a branch or tag has changed since the last one.
+func GenerateRepoBundle(ctx context.Context, repo *repo_model.Repository, timeout time.Duration) error {
+	if err := generateRepoBundle(ctx, repo, timeout); err != nil {
+		log.Error("Generating the bundle failed for %-v: %v", repo, err)
+		desc := fmt.Sprintf("Generating the bundle failed for %s: %v", repo.RepoPath(), err)
+		if err := system_model.CreateRepositoryNotice(desc); err != nil {
+			log.Error("CreateRepositoryNotice: %v", err)
+		}
+		return fmt.Errorf("Generating the bundle failed in repo: %s: Error: %w", repo.FullName(), err)
+	}
+	return nil
+}
+
+// bundledRefsHash returns the hash of the names and targets of all branches and tags of the repository
+func bundledRefsHash(ctx context.Context, repo *repo_model.Repository) (string, error) {
+	stdout, stderr, err := git.NewCommand(ctx, "for-each-ref", "--format=%(objectname) %(refname)", git.BranchPrefix, git.TagPrefix).
+		SetDescription(fmt.Sprintf("bundledRefsHash: %s", repo.FullName())).
+		RunStdBytes(&git.RunOpts{Dir: repo.RepoPath()})
+	if err != nil {
+		return "", fmt.Errorf("git for-each-ref: %w - %s", err, stderr)
+	}
+	hash := sha256.Sum256(stdout)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+func generateRepoBundle(ctx context.Context, repo *repo_model.Repository, timeout time.Duration) error {
+	refsHash, err := bundledRefsHash(ctx, repo)
+	if err != nil {
+		return err
+	}
+
+	bundle, err := repo_model.GetRepoBundle(ctx, repo.ID)
+	if err != nil {
+		return fmt.Errorf("GetRepoBundle: %w", err)
+	}
+	if bundle != nil && bundle.RefsHash == refsHash {
+		return nil
+	}
+
+	tmpPath, err := repo_module.CreateTemporaryPath("bundle")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := repo_module.RemoveTemporaryPath(tmpPath); err != nil {
+			log.Error("GenerateRepoBundle: RemoveTemporaryPath: %v", err)
+		}
+	}()
+
+	// only the branches and tags are bundled, the clients fetch the other references from the repository
+	bundlePath := filepath.Join(tmpPath, "clone.bundle")
+	if _, stderr, err := git.NewCommand(ctx, "bundle", "create", "--quiet").AddDynamicArguments(bundlePath).AddArguments("--branches", "--tags").
+		SetDescription(fmt.Sprintf("GenerateRepoBundle: %s", repo.FullName())).
+		RunStdString(&git.RunOpts{Dir: repo.RepoPath(), Timeout: timeout}); err != nil {
+		return fmt.Errorf("git bundle create: %w - %s", err, stderr)
+	}
+
+	// a branch or tag which has been deleted or rewritten while the bundle was created must not be served,
+	// the next run bundles the current state instead
+	if currentHash, err := bundledRefsHash(ctx, repo); err != nil {
+		return err
+	} else if currentHash != refsHash {
+		log.Debug("The branches or tags of %-v have changed while generating the bundle, skipping it", repo)
+		return nil
+	}
+
+	f, err := os.Open(bundlePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	var oldPath string
+	if bundle == nil {
+		bundle = &repo_model.RepoBundle{RepoID: repo.ID}
+	} else {
+		oldPath = bundle.RelativePath()
+	}
+	bundle.RefsHash = refsHash
+	bundle.Size = fi.Size()
+	bundle.GeneratedUnix = timeutil.TimeStampNow()
+
+	if _, err := storage.RepoBundles.Save(bundle.RelativePath(), f, fi.Size()); err != nil {
+		return fmt.Errorf("unable to write the bundle to %s: %w", bundle.RelativePath(), err)
+	}
+	if err := repo_model.SaveRepoBundle(ctx, bundle); err != nil {
+		return fmt.Errorf("SaveRepoBundle: %w", err)
+	}
+
+	// the previous bundle is only deleted after the new one is in place, so the clients can always download one
+	if oldPath != "" && oldPath != bundle.RelativePath() {
+		if err := storage.RepoBundles.Delete(oldPath); err != nil {
+			log.Warn("Unable to delete the previous bundle %s of %-v: %v", oldPath, repo, err)
+		}
+	}
+
+	log.Trace("Generated the bundle of %-v with the refs hash %s", repo, refsHash)
+	return nil
+}
+
+// DeleteRepoBundle deletes the bundle of the repository, so the objects of branches and tags which have been
+// deleted or rewritten can't be downloaded anymore. The next run of the generation bundles the current state.
+func DeleteRepoBundle(ctx context.Context, repo *repo_model.Repository) error {
+	bundle, err := repo_model.GetRepoBundle(ctx, repo.ID)
+	if err != nil {
+		return fmt.Errorf("GetRepoBundle: %w", err)
+	} else if bundle == nil {
+		return nil
+	}
+
+	if err := repo_model.DeleteRepoBundle(ctx, bundle); err != nil {
+		return fmt.Errorf("DeleteRepoBundle: %w", err)
+	}
+	if err := storage.RepoBundles.Delete(bundle.RelativePath()); err != nil {
+		log.Warn("Unable to delete the bundle %s of %-v: %v", bundle.RelativePath(), repo, err)
+	}
+	log.Trace("Deleted the bundle of %-v", repo)
+	return nil
+}
+
+// isRefRewritten returns true if the push deleted the reference or moved it to a commit which doesn't contain the old one
+func isRefRewritten(ctx context.Context, repo *repo_model.Repository, opts *repo_module.PushUpdateOptions) bool {
+	if opts.IsDelRef() {
+		return true
+	}
+	if opts.IsNewRef() {
+		return false
+	}
+	if opts.IsTag() {
+		return true
+	}
+	output, _, err := git.NewCommand(ctx, "rev-list", "--max-count=1").AddDynamicArguments(opts.OldCommitID, "^"+opts.NewCommitID).
+		RunStdString(&git.RunOpts{Dir: repo.RepoPath()})
+	if err != nil {
+		log.Error("Unable to check whether %s of %-v has been force pushed: %v", opts.RefFullName, repo, err)
+		return true
+	}
+	return len(output) > 0
+}
+
+func init() {
+	notification.RegisterNotifier(&bundleNotifier{})
+}
+
+// bundleNotifier deletes the bundle of a repository when one of its branches or tags is deleted or rewritten
+type bundleNotifier struct {
+	base.NullNotifier
+}
+
+var _ base.Notifier = &bundleNotifier{}
+
+func (n *bundleNotifier) deleteBundle(ctx context.Context, repo *repo_model.Repository) {
+	if err := DeleteRepoBundle(ctx, repo); err != nil {
+		log.Error("Unable to delete the bundle of %-v: %v", repo, err)
+	}
+}
+
+func (n *bundleNotifier) deleteBundleIfRewritten(ctx context.Context, repo *repo_model.Repository, opts *repo_module.PushUpdateOptions) {
+	bundle, err := repo_model.GetRepoBundle(ctx, repo.ID)
+	if err != nil {
+		log.Error("GetRepoBundle for %-v: %v", repo, err)
+	} else if bundle == nil || !isRefRewritten(ctx, repo, opts) {
+		return
+	}
+	n.deleteBundle(ctx, repo)
+}
+
+func (n *bundleNotifier) NotifyPushCommits(ctx context.Context, pusher *user_model.User, repo *repo_model.Repository, opts *repo_module.PushUpdateOptions, commits *repo_module.PushCommits) {
+	n.deleteBundleIfRewritten(ctx, repo, opts)
+}
+
+func (n *bundleNotifier) NotifySyncPushCommits(ctx context.Context, pusher *user_model.User, repo *repo_model.Repository, opts *repo_module.PushUpdateOptions, commits *repo_module.PushCommits) {
+	n.deleteBundleIfRewritten(ctx, repo, opts)
+}
+
+func (n *bundleNotifier) NotifyDeleteRef(ctx context.Context, doer *user_model.User, repo *repo_model.Repository, refType, refFullName string) {
+	n.deleteBundle(ctx, repo)
+}
+
+func (n *bundleNotifier) NotifySyncDeleteRef(ctx context.Context, doer *user_model.User, repo *repo_model.Repository, refType, refFullName string) {
+	n.deleteBundle(ctx, repo)
+}
@@ -0,0 +1,43 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package repository
+
+import (
+	"context"
+
+	"code.gitea.io/gitea/models"
+	audit_model "code.gitea.io/gitea/models/audit"
+	"code.gitea.io/gitea/models/perm"
+	repo_model "code.gitea.io/gitea/models/repo"
+	user_model "code.gitea.io/gitea/models/user"
+	repo_module "code.gitea.io/gitea/modules/repository"
+	"code.gitea.io/gitea/services/audit"
+)
+
+// AddCollaborator adds u as a collaborator of the repository
+func AddCollaborator(ctx context.Context, doer *user_model.User, repo *repo_model.Repository, u *user_model.User) error {
+	if err := repo_module.AddCollaborator(ctx, repo, u); err != nil {
+		return err
+	}
+	audit.RecordRepository(ctx, audit_model.ActionCollaboratorAdd, doer, repo, "Added collaborator %s", u.Name)
+	return nil
+}
+
+// ChangeCollaborationAccessMode changes the access mode of the collaborator u
+func ChangeCollaborationAccessMode(ctx context.Context, doer *user_model.User, repo *repo_model.Repository, u *user_model.User, mode perm.AccessMode) error {
+	if err := repo_model.ChangeCollaborationAccessMode(ctx, repo, u.ID, mode); err != nil {
+		return err
+	}
+	audit.RecordRepository(ctx, audit_model.ActionCollaboratorAccessMode, doer, repo, "Changed the access of collaborator %s to %s", u.Name, mode)
+	return nil
+}
+
+// DeleteCollaboration removes the collaborator u from the repository
+func DeleteCollaboration(ctx context.Context, doer *user_model.User, repo *repo_model.Repository, u *user_model.User) error {
+	if err := models.DeleteCollaboration(repo, u.ID); err != nil {
+		return err
+	}
+	audit.RecordRepository(ctx, audit_model.ActionCollaboratorRemove, doer, repo, "Removed collaborator %s", u.Name)
+	return nil
+}
@@ -67,7 +67,11 @@ func CherryPick(ctx context.Context, repo *repo_model.Repository, doer *user_mod
 	}
 	parent, err := commit.ParentID(0)
 	if err != nil {
-		parent = git.MustIDFromString(git.EmptyTreeSHA)
+		objectFormat, err := t.gitRepo.GetObjectFormat()
+		if err != nil {
+			return nil, err
+		}
+		parent = git.MustIDFromString(objectFormat.EmptyTree())
 	}
 
 	base, right := parent.String(), commit.ID.String()
@@ -37,19 +37,20 @@ func GetTreeBySHA(ctx context.Context, repo *repo_model.Repository, gitRepo *git
 	}
 	apiURL := repo.APIURL()
 	apiURLLen := len(apiURL)
+	// the entries are named like the tree, 40 for SHA-1 and 64 for SHA-256
+	shaLen := len(tree.SHA)
 
-	// 51 is len(sha1) + len("/git/blobs/"). 40 + 11.
-	blobURL := make([]byte, apiURLLen+51)
+	// 11 is len("/git/blobs/").
+	blobURL := make([]byte, apiURLLen+11+shaLen)
 	copy(blobURL, apiURL)
 	copy(blobURL[apiURLLen:], "/git/blobs/")
 
-	// 51 is len(sha1) + len("/git/trees/"). 40 + 11.
-	treeURL := make([]byte, apiURLLen+51)
+	// 11 is len("/git/trees/").
+	treeURL := make([]byte, apiURLLen+11+shaLen)
 	copy(treeURL, apiURL)
 	copy(treeURL[apiURLLen:], "/git/trees/")
 
-	// 40 is the size of the sha1 hash in hexadecimal format.
-	copyPos := len(treeURL) - git.SHAFullLength
+	copyPos := len(treeURL) - shaLen
 
 	if perPage <= 0 || perPage > setting.API.DefaultGitTreesPerPage {
 		perPage = setting.API.DefaultGitTreesPerPage
@@ -0,0 +1,159 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	system_model "code.gitea.io/gitea/models/system"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	repo_module "code.gitea.io/gitea/modules/repository"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// MaintenanceOptions are the thresholds which decide the maintenance tasks a repository needs,
+// a task whose threshold is not positive is never run.
+type MaintenanceOptions struct {
+	Timeout               time.Duration
+	LooseObjectsThreshold int64
+	PacksThreshold        int64
+	LooseRefsThreshold    int64
+	PruneExpire           string
+}
+
+// MaintainRepositories runs the maintenance of the repositories which have been pushed to since their last maintenance
+func MaintainRepositories(ctx context.Context, opts MaintenanceOptions) error {
+	log.Trace("Doing: MaintainRepositories")
+
+	// the push counts are reset while iterating, so the records are not filtered by the query
+	// which would shift the pages of the iteration
+	if err := db.Iterate(
+		ctx,
+		builder.Gt{"id": 0},
+		func(ctx context.Context, m *repo_model.RepoMaintenance) error {
+			if m.PushCount <= 0 {
+				return nil
+			}
+			select {
+			case <-ctx.Done():
+				return db.ErrCancelledf("before the maintenance of repository %d", m.RepoID)
+			default:
+			}
+			if err := m.LoadRepo(ctx); err != nil {
+				if repo_model.IsErrRepoNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			// we can ignore the error here because it will be logged in MaintainRepository
+			_ = MaintainRepository(ctx, m.Repo, opts)
+			return nil
+		},
+	); err != nil {
+		return err
+	}
+
+	log.Trace("Finished: MaintainRepositories")
+	return nil
+}
+
+// MaintainRepository inspects the repository and runs the maintenance tasks it needs
+func MaintainRepository(ctx context.Context, repo *repo_model.Repository, opts MaintenanceOptions) error {
+	log.Trace("Running the maintenance of %-v", repo)
+
+	m, err := repo_model.GetRepoMaintenance(ctx, repo.ID)
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		m = &repo_model.RepoMaintenance{RepoID: repo.ID}
+	}
+	seenPushes := m.PushCount
+
+	start := time.Now()
+	tasks, runErr := maintainRepository(ctx, repo, m, opts)
+	m.LastTasks = strings.Join(tasks, ",")
+	m.LastError = ""
+	if runErr != nil {
+		m.LastError = runErr.Error()
+	}
+	m.LastDuration = time.Since(start).Milliseconds()
+	m.LastRunUnix = timeutil.TimeStampNow()
+	if err := repo_model.SaveRepoMaintenanceResult(ctx, m, seenPushes); err != nil {
+		log.Error("SaveRepoMaintenanceResult for %-v: %v", repo, err)
+	}
+
+	if runErr != nil {
+		log.Error("Repository maintenance failed for %-v: %v", repo, runErr)
+		desc := fmt.Sprintf("Repository maintenance failed for %s: %v", repo.RepoPath(), runErr)
+		if err := system_model.CreateRepositoryNotice(desc); err != nil {
+			log.Error("CreateRepositoryNotice: %v", err)
+		}
+		return fmt.Errorf("Repository maintenance failed in repo: %s: Error: %w", repo.FullName(), runErr)
+	}
+	return nil
+}
+
+func maintainRepository(ctx context.Context, repo *repo_model.Repository, m *repo_model.RepoMaintenance, opts MaintenanceOptions) ([]string, error) {
+	repoPath := repo.RepoPath()
+	stats, err := git.GetObjectStats(ctx, repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("GetObjectStats: %w", err)
+	}
+	looseRefs, err := git.CountLooseRefs(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("CountLooseRefs: %w", err)
+	}
+
+	needed := map[git.MaintenanceTask]bool{
+		git.MaintenanceTaskPackRefs:          opts.LooseRefsThreshold > 0 && looseRefs > opts.LooseRefsThreshold,
+		git.MaintenanceTaskLooseObjects:      opts.LooseObjectsThreshold > 0 && stats.LooseObjects > opts.LooseObjectsThreshold,
+		git.MaintenanceTaskIncrementalRepack: opts.PacksThreshold > 0 && stats.Packs > opts.PacksThreshold,
+		git.MaintenanceTaskCommitGraph:       m.PushCount > 0,
+	}
+
+	var tasks []string
+	var repacked bool
+	for _, task := range git.MaintenanceTasks {
+		if !needed[task] {
+			continue
+		}
+		if err := git.RunMaintenanceTask(ctx, repoPath, task, git.RunMaintenanceTaskOptions{
+			Timeout:     opts.Timeout,
+			PruneExpire: opts.PruneExpire,
+		}); err != nil {
+			return tasks, err
+		}
+		tasks = append(tasks, string(task))
+		repacked = repacked || task == git.MaintenanceTaskLooseObjects || task == git.MaintenanceTaskIncrementalRepack
+	}
+
+	if repacked {
+		if err := repo_module.UpdateRepoSize(ctx, repo); err != nil {
+			return tasks, fmt.Errorf("UpdateRepoSize: %w", err)
+		}
+	}
+
+	// the statistics are measured again, so they show the state the maintenance has left the repository in
+	if len(tasks) > 0 {
+		if stats, err = git.GetObjectStats(ctx, repoPath); err != nil {
+			return tasks, fmt.Errorf("GetObjectStats: %w", err)
+		}
+		if looseRefs, err = git.CountLooseRefs(repoPath); err != nil {
+			return tasks, fmt.Errorf("CountLooseRefs: %w", err)
+		}
+	}
+	m.LooseObjects = stats.LooseObjects
+	m.Packs = stats.Packs
+	m.LooseRefs = looseRefs
+	return tasks, nil
+}
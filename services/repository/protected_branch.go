@@ -0,0 +1,32 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package repository
+
+import (
+	"context"
+
+	audit_model "code.gitea.io/gitea/models/audit"
+	git_model "code.gitea.io/gitea/models/git"
+	repo_model "code.gitea.io/gitea/models/repo"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/services/audit"
+)
+
+// UpdateProtectBranch creates or updates the branch protection rule protectBranch of a repository
+func UpdateProtectBranch(ctx context.Context, doer *user_model.User, repo *repo_model.Repository, protectBranch *git_model.ProtectedBranch, opts git_model.WhitelistOptions) error {
+	if err := git_model.UpdateProtectBranch(ctx, repo, protectBranch, opts); err != nil {
+		return err
+	}
+	audit.RecordRepository(ctx, audit_model.ActionBranchProtectionUpdate, doer, repo, "Updated branch protection rule %q", protectBranch.RuleName)
+	return nil
+}
+
+// DeleteProtectedBranch deletes the branch protection rule of a repository
+func DeleteProtectedBranch(ctx context.Context, doer *user_model.User, repo *repo_model.Repository, rule *git_model.ProtectedBranch) error {
+	if err := git_model.DeleteProtectedBranch(ctx, repo.ID, rule.ID); err != nil {
+		return err
+	}
+	audit.RecordRepository(ctx, audit_model.ActionBranchProtectionDelete, doer, repo, "Deleted branch protection rule %q", rule.RuleName)
+	return nil
+}
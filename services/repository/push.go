@@ -98,6 +98,11 @@ func pushUpdates(optsList []*repo_module.PushUpdateOptions) error {
 		log.Error("Failed to update size for repository: %v", err)
 	}
 
+	// the maintenance scheduler only visits the repositories which have been pushed to
+	if err = repo_model.IncreaseRepoMaintenancePushCount(ctx, repo.ID); err != nil {
+		log.Error("Failed to record the push for the maintenance of %-v: %v", repo, err)
+	}
+
 	addTags := make([]string, 0, len(optsList))
 	delTags := make([]string, 0, len(optsList))
 	var pusher *user_model.User
@@ -221,7 +226,7 @@ func pushUpdates(optsList []*repo_module.PushUpdateOptions) error {
 				}
 
 				oldCommitID := opts.OldCommitID
-				if oldCommitID == git.EmptySHA && len(commits.Commits) > 0 {
+				if git.IsEmptyCommitID(oldCommitID) && len(commits.Commits) > 0 {
 					oldCommit, err := gitRepo.GetCommit(commits.Commits[len(commits.Commits)-1].Sha1)
 					if err != nil && !git.IsErrNotExist(err) {
 						log.Error("unable to GetCommit %s from %-v: %v", oldCommitID, repo, err)
@@ -237,11 +242,11 @@ func pushUpdates(optsList []*repo_module.PushUpdateOptions) error {
 					}
 				}
 
-				if oldCommitID == git.EmptySHA && repo.DefaultBranch != branch {
+				if git.IsEmptyCommitID(oldCommitID) && repo.DefaultBranch != branch {
 					oldCommitID = repo.DefaultBranch
 				}
 
-				if oldCommitID != git.EmptySHA {
+				if !git.IsEmptyCommitID(oldCommitID) {
 					commits.CompareURL = repo.ComposeCompareURL(oldCommitID, opts.NewCommitID)
 				} else {
 					commits.CompareURL = ""
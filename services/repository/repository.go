@@ -8,6 +8,7 @@ import (
 	"fmt"
 
 	"code.gitea.io/gitea/models"
+	audit_model "code.gitea.io/gitea/models/audit"
 	"code.gitea.io/gitea/models/db"
 	issues_model "code.gitea.io/gitea/models/issues"
 	"code.gitea.io/gitea/models/organization"
@@ -16,10 +17,12 @@ import (
 	system_model "code.gitea.io/gitea/models/system"
 	"code.gitea.io/gitea/models/unit"
 	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/notification"
 	repo_module "code.gitea.io/gitea/modules/repository"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/services/audit"
 	pull_service "code.gitea.io/gitea/services/pull"
 )
 
@@ -50,6 +53,7 @@ func DeleteRepository(ctx context.Context, doer *user_model.User, repo *repo_mod
 	if err := models.DeleteRepository(doer, repo.OwnerID, repo.ID); err != nil {
 		return err
 	}
+	audit.RecordRepository(ctx, audit_model.ActionRepositoryDelete, doer, repo, "Deleted repository %s", repo.FullName())
 
 	return packages_model.UnlinkRepositoryFromAllPackages(ctx, repo.ID)
 }
@@ -71,6 +75,8 @@ func PushCreateRepo(authUser, owner *user_model.User, repoName string) (*repo_mo
 	repo, err := CreateRepository(authUser, owner, repo_module.CreateRepoOptions{
 		Name:      repoName,
 		IsPrivate: setting.Repository.DefaultPushCreatePrivate,
+		// the advertised refs of the repository to create are the ones of an empty SHA-1 repository
+		ObjectFormatName: string(git.ObjectFormatSHA1),
 	})
 	if err != nil {
 		return nil, err
@@ -88,18 +94,33 @@ func Init() error {
 }
 
 // UpdateRepository updates a repository
-func UpdateRepository(repo *repo_model.Repository, visibilityChanged bool) (err error) {
-	ctx, committer, err := db.TxContext(db.DefaultContext)
+func UpdateRepository(ctx context.Context, doer *user_model.User, repo *repo_model.Repository, visibilityChanged bool) (err error) {
+	wasPrivate := repo.IsPrivate
+	if visibilityChanged {
+		old, err := repo_model.GetRepositoryByID(ctx, repo.ID)
+		if err != nil {
+			return err
+		}
+		wasPrivate = old.IsPrivate
+	}
+
+	txCtx, committer, err := db.TxContext(db.DefaultContext)
 	if err != nil {
 		return err
 	}
 	defer committer.Close()
 
-	if err = repo_module.UpdateRepository(ctx, repo, visibilityChanged); err != nil {
+	if err = repo_module.UpdateRepository(txCtx, repo, visibilityChanged); err != nil {
 		return fmt.Errorf("updateRepository: %w", err)
 	}
 
-	return committer.Commit()
+	if err := committer.Commit(); err != nil {
+		return err
+	}
+	if repo.IsPrivate != wasPrivate {
+		audit.RecordRepositoryVisibility(ctx, doer, repo)
+	}
+	return nil
 }
 
 // LinkedRepository returns the linked repo if any
@@ -0,0 +1,230 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"context"
+
+	"code.gitea.io/gitea/models/db"
+	issues_model "code.gitea.io/gitea/models/issues"
+	packages_model "code.gitea.io/gitea/models/packages"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unit"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/container"
+	code_indexer "code.gitea.io/gitea/modules/indexer/code"
+	issue_indexer "code.gitea.io/gitea/modules/indexer/issues"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+	issue_service "code.gitea.io/gitea/services/issue"
+)
+
+// Options represents the options of a global search
+type Options struct {
+	Keyword  string
+	PageSize int // the maximum number of results of every kind
+}
+
+// Results are the results of a global search grouped by their kind, the results of a kind are ranked by its search
+type Results struct {
+	Repos         []*repo_model.Repository
+	ReposTotal    int64
+	Issues        []*issues_model.Issue
+	IssuesTotal   int64
+	Users         []*user_model.User
+	UsersTotal    int64
+	Orgs          []*user_model.User
+	OrgsTotal     int64
+	Code          []*code_indexer.Result
+	CodeRepos     map[int64]*repo_model.Repository // the repositories of the code results
+	CodeTotal     int
+	Packages      []*packages_model.PackageDescriptor
+	PackagesTotal int64
+}
+
+// Search searches the repositories, issues, users, organizations, code and packages which the doer can see
+func Search(ctx context.Context, doer *user_model.User, opts *Options) (*Results, error) {
+	results := &Results{}
+	if opts.Keyword == "" {
+		return results, nil
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = setting.UI.ExplorePagingNum
+	}
+
+	var err error
+	if results.Repos, results.ReposTotal, err = searchRepos(ctx, doer, opts); err != nil {
+		return nil, err
+	}
+	if results.Issues, results.IssuesTotal, err = searchIssues(ctx, doer, opts); err != nil {
+		return nil, err
+	}
+	if results.Users, results.UsersTotal, err = searchUsers(ctx, doer, user_model.UserTypeIndividual, opts); err != nil {
+		return nil, err
+	}
+	if results.Orgs, results.OrgsTotal, err = searchUsers(ctx, doer, user_model.UserTypeOrganization, opts); err != nil {
+		return nil, err
+	}
+	if results.Code, results.CodeRepos, results.CodeTotal, err = searchCode(ctx, doer, opts); err != nil {
+		return nil, err
+	}
+	if setting.Packages.Enabled {
+		if results.Packages, results.PackagesTotal, err = searchPackages(ctx, doer, opts); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+func searchRepos(ctx context.Context, doer *user_model.User, opts *Options) ([]*repo_model.Repository, int64, error) {
+	return repo_model.SearchRepository(ctx, &repo_model.SearchRepoOptions{
+		ListOptions:        db.ListOptions{PageSize: opts.PageSize},
+		Actor:              doer,
+		Keyword:            opts.Keyword,
+		Private:            doer != nil,
+		AllPublic:          true,
+		AllLimited:         true,
+		IncludeDescription: setting.UI.SearchRepoDescription,
+		OrderBy:            db.SearchOrderByStarsReverse,
+	})
+}
+
+// searchIssues searches the issues and pull requests by the issue indexer in the repositories whose
+// issues or pull requests the doer can read
+func searchIssues(ctx context.Context, doer *user_model.User, opts *Options) ([]*issues_model.Issue, int64, error) {
+	issueRepoIDs, err := repo_model.SearchRepositoryIDsByCondition(ctx, repo_model.AccessibleRepositoryCondition(doer, unit.TypeIssues))
+	if err != nil {
+		return nil, 0, err
+	}
+	pullRepoIDs, err := repo_model.SearchRepositoryIDsByCondition(ctx, repo_model.AccessibleRepositoryCondition(doer, unit.TypePullRequests))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// a repository can have only one of the units enabled or readable, so the issues and the pull requests
+	// are searched separately to keep the total free of results the doer can't read
+	q := issue_indexer.ParseQuery(opts.Keyword)
+	var issues, pulls []*issues_model.Issue
+	var issuesTotal, pullsTotal int64
+	if !q.IsPull.IsTrue() {
+		if issues, issuesTotal, err = searchIssuesOfRepos(ctx, doer, opts, issueRepoIDs, util.OptionalBoolFalse); err != nil {
+			return nil, 0, err
+		}
+	}
+	if !q.IsPull.IsFalse() {
+		if pulls, pullsTotal, err = searchIssuesOfRepos(ctx, doer, opts, pullRepoIDs, util.OptionalBoolTrue); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	// both lists are sorted by score, alternate them so neither kind hides the other
+	results := make([]*issues_model.Issue, 0, opts.PageSize)
+	for i := 0; len(results) < opts.PageSize && (i < len(issues) || i < len(pulls)); i++ {
+		if i < len(issues) {
+			results = append(results, issues[i])
+		}
+		if i < len(pulls) && len(results) < opts.PageSize {
+			results = append(results, pulls[i])
+		}
+	}
+	return results, issuesTotal + pullsTotal, nil
+}
+
+func searchIssuesOfRepos(ctx context.Context, doer *user_model.User, opts *Options, repoIDs []int64, isPull util.OptionalBool) ([]*issues_model.Issue, int64, error) {
+	// the query can contain qualifiers like on the issue search pages
+	return issue_service.SearchIssues(ctx, doer, opts.Keyword, nil, nil, &issue_indexer.SearchOptions{
+		RepoIDs: repoIDs,
+		IsPull:  isPull,
+		SortBy:  issue_indexer.SortByScore,
+		Limit:   opts.PageSize,
+	})
+}
+
+// searchUsers searches the users or organizations like the explore pages do
+func searchUsers(ctx context.Context, doer *user_model.User, userType user_model.UserType, opts *Options) ([]*user_model.User, int64, error) {
+	searchOpts := &user_model.SearchUserOptions{
+		Actor:       doer,
+		Type:        userType,
+		Keyword:     opts.Keyword,
+		ListOptions: db.ListOptions{PageSize: opts.PageSize},
+	}
+	if userType == user_model.UserTypeIndividual {
+		if setting.Service.Explore.DisableUsersPage {
+			return nil, 0, nil
+		}
+		searchOpts.IsActive = util.OptionalBoolTrue
+		searchOpts.Visible = []structs.VisibleType{structs.VisibleTypePublic, structs.VisibleTypeLimited, structs.VisibleTypePrivate}
+	} else {
+		searchOpts.Visible = []structs.VisibleType{structs.VisibleTypePublic}
+		if doer != nil {
+			searchOpts.Visible = append(searchOpts.Visible, structs.VisibleTypeLimited, structs.VisibleTypePrivate)
+		}
+	}
+	return user_model.SearchUsers(searchOpts)
+}
+
+// searchCode searches the code in the repositories whose code the doer can read, an admin can read all repositories
+func searchCode(ctx context.Context, doer *user_model.User, opts *Options) ([]*code_indexer.Result, map[int64]*repo_model.Repository, int, error) {
+	if !setting.Indexer.RepoIndexerEnabled || !code_indexer.IsAvailable() {
+		return nil, nil, 0, nil
+	}
+
+	var repoIDs []int64
+	if doer == nil || !doer.IsAdmin {
+		var err error
+		if repoIDs, err = repo_model.FindUserCodeAccessibleRepoIDs(ctx, doer); err != nil {
+			return nil, nil, 0, err
+		}
+		if len(repoIDs) == 0 {
+			return nil, nil, 0, nil
+		}
+	}
+
+	total, results, _, err := code_indexer.PerformSearch(ctx, &code_indexer.SearchOptions{
+		RepoIDs:  repoIDs,
+		Keyword:  opts.Keyword,
+		Page:     1,
+		PageSize: opts.PageSize,
+	})
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	ids := make(container.Set[int64], len(results))
+	for _, result := range results {
+		ids.Add(result.RepoID)
+	}
+	repos, err := repo_model.GetRepositoriesMapByIDs(ids.Values())
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	// the index may still contain the files of deleted repositories
+	existing := make([]*code_indexer.Result, 0, len(results))
+	for _, result := range results {
+		if _, ok := repos[result.RepoID]; ok {
+			existing = append(existing, result)
+		}
+	}
+	return existing, repos, total, nil
+}
+
+// searchPackages searches the latest versions of the packages by their name, the owners of the packages must be visible to the doer
+func searchPackages(ctx context.Context, doer *user_model.User, opts *Options) ([]*packages_model.PackageDescriptor, int64, error) {
+	pvs, total, err := packages_model.SearchLatestVersions(ctx, &packages_model.PackageSearchOptions{
+		OwnerCond:  user_model.BuildCanSeeUserCondition(doer),
+		Name:       packages_model.SearchValue{Value: opts.Keyword},
+		IsInternal: util.OptionalBoolFalse,
+		Paginator:  db.NewAbsoluteListOptions(0, opts.PageSize),
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	pds, err := packages_model.GetPackageDescriptors(ctx, pvs)
+	if err != nil {
+		return nil, 0, err
+	}
+	return pds, total, nil
+}
@@ -12,6 +12,7 @@ import (
 
 	"code.gitea.io/gitea/models"
 	asymkey_model "code.gitea.io/gitea/models/asymkey"
+	audit_model "code.gitea.io/gitea/models/audit"
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/models/organization"
 	packages_model "code.gitea.io/gitea/models/packages"
@@ -24,13 +25,28 @@ import (
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/services/audit"
 	"code.gitea.io/gitea/services/packages"
 )
 
+// UpdateUser updates a user on behalf of doer and records the changes of its privileges in the audit log
+func UpdateUser(ctx context.Context, doer, u *user_model.User, changePrimaryEmail bool, cols ...string) error {
+	old, err := user_model.GetUserByID(ctx, u.ID)
+	if err != nil {
+		return err
+	}
+	if err := user_model.UpdateUser(ctx, u, changePrimaryEmail, cols...); err != nil {
+		return err
+	}
+	audit.RecordUserPrivileges(ctx, doer, u, old.IsAdmin, old.ProhibitLogin)
+	return nil
+}
+
 // DeleteUser completely and permanently deletes everything of a user,
 // but issues/comments/pulls will be kept and shown as someone has been deleted,
 // unless the user is younger than USER_DELETE_WITH_COMMENTS_MAX_DAYS.
-func DeleteUser(ctx context.Context, u *user_model.User, purge bool) error {
+// The doer is recorded in the audit log and is nil for deletions by the system.
+func DeleteUser(ctx context.Context, doer, u *user_model.User, purge bool) error {
 	if u.IsOrganization() {
 		return fmt.Errorf("%s is an organization not a user", u.Name)
 	}
@@ -130,6 +146,8 @@ func DeleteUser(ctx context.Context, u *user_model.User, purge bool) error {
 		}
 	}
 
+	// the transaction doesn't carry the request, which is needed for the audit log
+	auditCtx := ctx
 	ctx, committer, err := db.TxContext(db.DefaultContext)
 	if err != nil {
 		return err
@@ -171,6 +189,7 @@ func DeleteUser(ctx context.Context, u *user_model.User, purge bool) error {
 		return err
 	}
 	committer.Close()
+	audit.RecordSystem(auditCtx, audit_model.ActionUserDelete, doer, "Deleted user %s", u.Name)
 
 	if err = asymkey_model.RewriteAllPublicKeys(); err != nil {
 		return err
@@ -214,7 +233,7 @@ func DeleteInactiveUsers(ctx context.Context, olderThan time.Duration) error {
 			return db.ErrCancelledf("Before delete inactive user %s", u.Name)
 		default:
 		}
-		if err := DeleteUser(ctx, u, false); err != nil {
+		if err := DeleteUser(ctx, nil, u, false); err != nil {
 			// Ignore users that were set inactive by admin.
 			if models.IsErrUserOwnRepos(err) || models.IsErrUserHasOrgs(err) || models.IsErrUserOwnPackages(err) {
 				continue
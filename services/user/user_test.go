@@ -32,7 +32,7 @@ func TestDeleteUser(t *testing.T) {
 		ownedRepos := make([]*repo_model.Repository, 0, 10)
 		assert.NoError(t, db.GetEngine(db.DefaultContext).Find(&ownedRepos, &repo_model.Repository{OwnerID: userID}))
 		if len(ownedRepos) > 0 {
-			err := DeleteUser(db.DefaultContext, user, false)
+			err := DeleteUser(db.DefaultContext, nil, user, false)
 			assert.Error(t, err)
 			assert.True(t, models.IsErrUserOwnRepos(err))
 			return
@@ -46,7 +46,7 @@ func TestDeleteUser(t *testing.T) {
 				return
 			}
 		}
-		assert.NoError(t, DeleteUser(db.DefaultContext, user, false))
+		assert.NoError(t, DeleteUser(db.DefaultContext, nil, user, false))
 		unittest.AssertNotExistsBean(t, &user_model.User{ID: userID})
 		unittest.CheckConsistencyFor(t, &user_model.User{}, &repo_model.Repository{})
 	}
@@ -56,7 +56,7 @@ func TestDeleteUser(t *testing.T) {
 	test(11)
 
 	org := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 3})
-	assert.Error(t, DeleteUser(db.DefaultContext, org, false))
+	assert.Error(t, DeleteUser(db.DefaultContext, nil, org, false))
 }
 
 func TestPurgeUser(t *testing.T) {
@@ -64,7 +64,7 @@ func TestPurgeUser(t *testing.T) {
 		assert.NoError(t, unittest.PrepareTestDatabase())
 		user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: userID})
 
-		err := DeleteUser(db.DefaultContext, user, true)
+		err := DeleteUser(db.DefaultContext, nil, user, true)
 		assert.NoError(t, err)
 
 		unittest.AssertNotExistsBean(t, &user_model.User{ID: userID})
@@ -76,7 +76,7 @@ func TestPurgeUser(t *testing.T) {
 	test(11)
 
 	org := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 3})
-	assert.Error(t, DeleteUser(db.DefaultContext, org, false))
+	assert.Error(t, DeleteUser(db.DefaultContext, nil, org, false))
 }
 
 func TestCreateUser(t *testing.T) {
@@ -91,7 +91,7 @@ func TestCreateUser(t *testing.T) {
 
 	assert.NoError(t, user_model.CreateUser(user))
 
-	assert.NoError(t, DeleteUser(db.DefaultContext, user, false))
+	assert.NoError(t, DeleteUser(db.DefaultContext, nil, user, false))
 }
 
 func TestCreateUser_Issue5882(t *testing.T) {
@@ -120,6 +120,6 @@ func TestCreateUser_Issue5882(t *testing.T) {
 
 		assert.Equal(t, !u.AllowCreateOrganization, v.disableOrgCreation)
 
-		assert.NoError(t, DeleteUser(db.DefaultContext, v.user, false))
+		assert.NoError(t, DeleteUser(db.DefaultContext, nil, v.user, false))
 	}
 }
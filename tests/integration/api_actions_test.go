@@ -0,0 +1,271 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/git"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testActionsWorkflow = `on: push
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo test
+`
+
+func registerActionsRunner(t *testing.T, repo *repo_model.Repository) *api.ActionRunner {
+	token, err := actions_model.NewRunnerToken(db.DefaultContext, 0, repo.ID)
+	assert.NoError(t, err)
+
+	req := NewRequestWithJSON(t, "POST", "/api/actions/runner/register", &api.ActionRunnerRegisterOption{
+		Token:   token.Token,
+		Name:    "test-runner",
+		Version: "v1.0.0",
+		Labels:  []string{"ubuntu-latest"},
+	})
+	resp := MakeRequest(t, req, http.StatusCreated)
+
+	var runner *api.ActionRunner
+	DecodeJSON(t, resp, &runner)
+	assert.NotEmpty(t, runner.UUID)
+	assert.NotEmpty(t, runner.Token)
+	return runner
+}
+
+func insertActionsRun(t *testing.T, repo *repo_model.Repository) *actions_model.ActionRun {
+	doer := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: repo.OwnerID})
+
+	gitRepo, err := git.OpenRepository(git.DefaultContext, repo.RepoPath())
+	assert.NoError(t, err)
+	defer gitRepo.Close()
+	commitID, err := gitRepo.GetBranchCommitID(repo.DefaultBranch)
+	assert.NoError(t, err)
+
+	run := &actions_model.ActionRun{
+		Title:         "test",
+		RepoID:        repo.ID,
+		OwnerID:       repo.OwnerID,
+		WorkflowID:    "test.yml",
+		TriggerUserID: doer.ID,
+		Ref:           "refs/heads/" + repo.DefaultBranch,
+		CommitSHA:     commitID,
+		Event:         "push",
+		EventPayload:  "{}",
+	}
+	assert.NoError(t, actions_model.InsertRun(db.DefaultContext, run, []*actions_model.ActionRunJob{{
+		Name:            "test",
+		JobID:           "test",
+		WorkflowPayload: []byte(testActionsWorkflow),
+		RunsOn:          []string{"ubuntu-latest"},
+	}}))
+	return run
+}
+
+func setActionsRunnerHeaders(req *http.Request, runner *api.ActionRunner) *http.Request {
+	req.Header.Set("X-Runner-UUID", runner.UUID)
+	req.Header.Set("X-Runner-Token", runner.Token)
+	return req
+}
+
+func newActionsRunnerRequest(t *testing.T, runner *api.ActionRunner, method, urlStr string) *http.Request {
+	return setActionsRunnerHeaders(NewRequest(t, method, urlStr), runner)
+}
+
+func fetchActionsTask(t *testing.T, runner *api.ActionRunner) *api.ActionTask {
+	resp := MakeRequest(t, newActionsRunnerRequest(t, runner, "POST", "/api/actions/runner/fetch"), http.StatusOK)
+
+	var task *api.ActionTask
+	DecodeJSON(t, resp, &task)
+	return task
+}
+
+func updateActionsTaskStatus(t *testing.T, runner *api.ActionRunner, taskID int64, status string, expectedStatus int) {
+	req := NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/actions/runner/tasks/%d/status", taskID), &api.ActionTaskStateOption{
+		Status: status,
+	})
+	MakeRequest(t, setActionsRunnerHeaders(req, runner), expectedStatus)
+}
+
+func TestActionsRunnerAPI(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	repo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 2})
+
+	t.Run("Register", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequestWithJSON(t, "POST", "/api/actions/runner/register", &api.ActionRunnerRegisterOption{
+			Token: "invalid",
+			Name:  "test-runner",
+		})
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequestWithJSON(t, "POST", "/api/actions/runner/register", &api.ActionRunnerRegisterOption{})
+		MakeRequest(t, req, http.StatusBadRequest)
+	})
+
+	runner := registerActionsRunner(t, repo)
+
+	t.Run("FetchWithoutCredentials", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "POST", "/api/actions/runner/fetch")
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		MakeRequest(t, newActionsRunnerRequest(t, &api.ActionRunner{UUID: runner.UUID, Token: "invalid"}, "POST", "/api/actions/runner/fetch"), http.StatusUnauthorized)
+	})
+
+	t.Run("FetchWithoutJobs", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		MakeRequest(t, newActionsRunnerRequest(t, runner, "POST", "/api/actions/runner/fetch"), http.StatusNoContent)
+	})
+
+	run := insertActionsRun(t, repo)
+	job := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRunJob{RunID: run.ID})
+	assert.Equal(t, actions_model.StatusWaiting, job.Status)
+
+	var task *api.ActionTask
+	t.Run("Fetch", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		task = fetchActionsTask(t, runner)
+		assert.Equal(t, job.ID, task.ID)
+		assert.Equal(t, "test", task.JobID)
+		assert.Equal(t, []string{"ubuntu-latest"}, task.RunsOn)
+		assert.Equal(t, repo.FullName(), task.Context.Repository)
+		assert.Equal(t, "push", task.Context.Event)
+		assert.NotEmpty(t, task.Context.Token)
+		assert.Contains(t, task.Workflow, "echo test")
+
+		job = unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRunJob{ID: job.ID})
+		assert.Equal(t, actions_model.StatusRunning, job.Status)
+		assert.Equal(t, runner.ID, job.RunnerID)
+
+		// the job is assigned to a single runner only
+		MakeRequest(t, newActionsRunnerRequest(t, runner, "POST", "/api/actions/runner/fetch"), http.StatusNoContent)
+	})
+
+	t.Run("UpdateStatus", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		updateActionsTaskStatus(t, runner, task.ID, "running", http.StatusNoContent)
+		updateActionsTaskStatus(t, runner, task.ID, "invalid", http.StatusBadRequest)
+		updateActionsTaskStatus(t, runner, task.ID+1000, "running", http.StatusNotFound)
+	})
+
+	t.Run("UploadLog", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		content := "step 1\nstep 2\n"
+
+		req := NewRequestWithBody(t, "PUT", fmt.Sprintf("/api/actions/runner/tasks/%d/log", task.ID), strings.NewReader(content))
+		setActionsRunnerHeaders(req, runner)
+		MakeRequest(t, req, http.StatusNoContent)
+
+		job = unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRunJob{ID: job.ID})
+		assert.NotEmpty(t, job.LogFilename)
+		assert.EqualValues(t, len(content), job.LogSize)
+	})
+
+	t.Run("Finish", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		updateActionsTaskStatus(t, runner, task.ID, "success", http.StatusNoContent)
+
+		job = unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRunJob{ID: job.ID})
+		assert.Equal(t, actions_model.StatusSuccess, job.Status)
+		run = unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRun{ID: run.ID})
+		assert.Equal(t, actions_model.StatusSuccess, run.Status)
+
+		// the status of a finished job can't be changed anymore
+		updateActionsTaskStatus(t, runner, task.ID, "failure", http.StatusBadRequest)
+	})
+}
+
+func TestActionsJobToken(t *testing.T) {
+	onGiteaRun(t, testActionsJobToken)
+}
+
+func testActionsJobToken(t *testing.T, u *url.URL) {
+	repo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 2})
+	otherRepo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 16})
+	assert.True(t, repo.IsPrivate)
+	assert.True(t, otherRepo.IsPrivate)
+
+	runner := registerActionsRunner(t, repo)
+	insertActionsRun(t, repo)
+	task := fetchActionsTask(t, runner)
+	token := task.Context.Token
+
+	infoRefs := func(repo *repo_model.Repository, service string, expectedStatus int) {
+		req := NewRequest(t, "GET", fmt.Sprintf("/%s.git/info/refs?service=%s", repo.FullName(), service))
+		req.SetBasicAuth("x-access-token", token)
+		MakeRequest(t, req, expectedStatus)
+	}
+
+	t.Run("CloneOwnRepository", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		infoRefs(repo, "git-upload-pack", http.StatusOK)
+
+		cloneURL, _ := url.Parse(u.String())
+		cloneURL.Path = repo.FullName() + ".git"
+		cloneURL.User = url.UserPassword("x-access-token", token)
+
+		dstPath := t.TempDir()
+		assert.NoError(t, git.CloneWithArgs(git.DefaultContext, nil, cloneURL.String(), dstPath, git.CloneRepoOptions{}))
+		assert.DirExists(t, filepath.Join(dstPath, ".git"))
+	})
+
+	t.Run("RefuseOtherRepository", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		infoRefs(otherRepo, "git-upload-pack", http.StatusForbidden)
+	})
+
+	t.Run("RefusePush", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		infoRefs(repo, "git-receive-pack", http.StatusForbidden)
+
+		req := NewRequestWithBody(t, "POST", fmt.Sprintf("/%s.git/git-receive-pack", repo.FullName()), strings.NewReader(""))
+		req.SetBasicAuth("x-access-token", token)
+		req.Header.Set("Content-Type", "application/x-git-receive-pack-request")
+		MakeRequest(t, req, http.StatusForbidden)
+	})
+
+	t.Run("RefuseWiki", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", fmt.Sprintf("/%s.wiki.git/info/refs?service=git-upload-pack", repo.FullName()))
+		req.SetBasicAuth("x-access-token", token)
+		MakeRequest(t, req, http.StatusForbidden)
+	})
+
+	t.Run("RefuseAfterJobIsDone", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		updateActionsTaskStatus(t, runner, task.ID, "success", http.StatusNoContent)
+
+		infoRefs(repo, "git-upload-pack", http.StatusUnauthorized)
+	})
+}
@@ -0,0 +1,64 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	audit_model "code.gitea.io/gitea/models/audit"
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIAdminAuditEvents(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+	// there are no fixtures for the audit events, so they are not reset by PrepareTestEnv
+	assert.NoError(t, db.DeleteAllRecords("audit_event"))
+
+	// creating the token is audited
+	userToken := getUserToken(t, "user2", auth_model.AccessTokenScopeRepo)
+	unittest.AssertExistsAndLoadBean(t, &audit_model.Event{
+		Action:    audit_model.ActionAccessTokenCreate,
+		ActorName: "user2",
+		ScopeType: audit_model.ScopeUser,
+		ScopeName: "user2",
+	})
+
+	req := NewRequestWithJSON(t, "PUT", "/api/v1/repos/user2/repo1/collaborators/user4?token="+userToken, &api.AddCollaboratorOption{})
+	MakeRequest(t, req, http.StatusNoContent)
+	req = NewRequest(t, "DELETE", "/api/v1/repos/user2/repo1/collaborators/user4?token="+userToken)
+	MakeRequest(t, req, http.StatusNoContent)
+
+	adminToken := getUserToken(t, "user1", auth_model.AccessTokenScopeSudo)
+
+	req = NewRequest(t, "GET", "/api/v1/admin/audit?scope_type=repository&scope=USER2/repo1&token="+adminToken)
+	resp := MakeRequest(t, req, http.StatusOK)
+	var events []*api.AuditEvent
+	DecodeJSON(t, resp, &events)
+	assert.Equal(t, "2", resp.Header().Get("X-Total-Count"))
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, string(audit_model.ActionCollaboratorRemove), events[0].Action)
+		assert.Equal(t, string(audit_model.ActionCollaboratorAdd), events[1].Action)
+		assert.EqualValues(t, 2, events[1].ActorID)
+		assert.Equal(t, "user2/repo1", events[1].ScopeName)
+		assert.Equal(t, "Added collaborator user4", events[1].Message)
+	}
+
+	req = NewRequest(t, "GET", "/api/v1/admin/audit?actor=user2&action=access_token.create&token="+adminToken)
+	resp = MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &events)
+	assert.Len(t, events, 1)
+
+	req = NewRequest(t, "GET", "/api/v1/admin/audit?action=unknown&token="+adminToken)
+	MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	req = NewRequest(t, "GET", "/api/v1/admin/audit?token="+getUserToken(t, "user2", auth_model.AccessTokenScopeSudo))
+	MakeRequest(t, req, http.StatusForbidden)
+}
@@ -0,0 +1,299 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	alpine_module "code.gitea.io/gitea/modules/packages/alpine"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageAlpine(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+	user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+
+	packageName := "gitea-test"
+	packageVersion := "1.0.1-r0"
+	packageDescription := "Test package"
+
+	branch := "v3.17"
+	repository := "main"
+
+	createTarGz := func(files map[string]string) []byte {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(zw)
+		for name, content := range files {
+			tw.WriteHeader(&tar.Header{
+				Name: name,
+				Mode: 0o600,
+				Size: int64(len(content)),
+			})
+			tw.Write([]byte(content))
+		}
+		tw.Close()
+		zw.Close()
+		return buf.Bytes()
+	}
+
+	createPackage := func(version, architecture string) []byte {
+		control := createTarGz(map[string]string{
+			".PKGINFO": fmt.Sprintf("pkgname = %s\npkgver = %s\npkgdesc = %s\narch = %s\nsize = 123\ndepend = musl\n", packageName, version, packageDescription, architecture),
+		})
+		data := createTarGz(map[string]string{
+			"usr/bin/gitea-test": "binary",
+		})
+		return append(control, data...)
+	}
+
+	url := fmt.Sprintf("/api/packages/%s/alpine", user.Name)
+
+	var keyName string
+	var publicKey *rsa.PublicKey
+
+	t.Run("RepositoryKey", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", url+"/key")
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		assert.Equal(t, "application/x-pem-file", resp.Header().Get("Content-Type"))
+
+		// the key must be stored with the name used in the signature file names
+		_, filename, _ := strings.Cut(resp.Header().Get("Content-Disposition"), `filename="`)
+		keyName, _, _ = strings.Cut(filename, `"`)
+		assert.True(t, strings.HasPrefix(keyName, user.LowerName+"@"))
+		assert.True(t, strings.HasSuffix(keyName, ".rsa.pub"))
+
+		block, _ := pem.Decode(resp.Body.Bytes())
+		assert.NotNil(t, block)
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		assert.NoError(t, err)
+		publicKey = pub.(*rsa.PublicKey)
+	})
+
+	for _, architecture := range []string{"x86_64", "noarch"} {
+		t.Run(fmt.Sprintf("Upload[%s]", architecture), func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			uploadURL := fmt.Sprintf("%s/%s/%s", url, branch, repository)
+			content := createPackage(packageVersion, architecture)
+
+			req := NewRequestWithBody(t, "PUT", uploadURL, bytes.NewReader(content))
+			MakeRequest(t, req, http.StatusUnauthorized)
+
+			req = NewRequestWithBody(t, "PUT", uploadURL, bytes.NewReader(createTarGz(map[string]string{"dummy.txt": ""})))
+			AddBasicAuthHeader(req, user.Name)
+			MakeRequest(t, req, http.StatusBadRequest)
+
+			req = NewRequestWithBody(t, "PUT", fmt.Sprintf("%s/%s/%s", url, "-invalid", repository), bytes.NewReader(content))
+			AddBasicAuthHeader(req, user.Name)
+			MakeRequest(t, req, http.StatusBadRequest)
+
+			req = NewRequestWithBody(t, "PUT", uploadURL, bytes.NewReader(content))
+			AddBasicAuthHeader(req, user.Name)
+			MakeRequest(t, req, http.StatusCreated)
+
+			pv, err := packages.GetVersionByNameAndVersion(db.DefaultContext, user.ID, packages.TypeAlpine, packageName, packageVersion)
+			assert.NoError(t, err)
+
+			pd, err := packages.GetPackageDescriptor(db.DefaultContext, pv)
+			assert.NoError(t, err)
+			assert.IsType(t, &alpine_module.VersionMetadata{}, pd.Metadata)
+			assert.Equal(t, packageName, pd.Package.Name)
+			assert.Equal(t, packageVersion, pd.Version.Version)
+			assert.Equal(t, packageDescription, pd.Metadata.(*alpine_module.VersionMetadata).Description)
+
+			pfs, err := packages.GetFilesByVersionID(db.DefaultContext, pv.ID)
+			assert.NoError(t, err)
+			assert.NotEmpty(t, pfs)
+
+			var pf *packages.PackageFile
+			for _, f := range pfs {
+				if f.CompositeKey == fmt.Sprintf("%s|%s|%s", branch, repository, architecture) {
+					pf = f
+				}
+			}
+			assert.NotNil(t, pf)
+			assert.Equal(t, fmt.Sprintf("%s-%s.apk", packageName, packageVersion), pf.Name)
+			assert.True(t, pf.IsLead)
+
+			pfps, err := packages.GetProperties(db.DefaultContext, packages.PropertyTypeFile, pf.ID)
+			assert.NoError(t, err)
+			for _, pfp := range pfps {
+				switch pfp.Name {
+				case alpine_module.PropertyBranch:
+					assert.Equal(t, branch, pfp.Value)
+				case alpine_module.PropertyRepository:
+					assert.Equal(t, repository, pfp.Value)
+				case alpine_module.PropertyArchitecture:
+					assert.Equal(t, architecture, pfp.Value)
+				}
+			}
+
+			req = NewRequestWithBody(t, "PUT", uploadURL, bytes.NewReader(content))
+			AddBasicAuthHeader(req, user.Name)
+			MakeRequest(t, req, http.StatusConflict)
+		})
+	}
+
+	t.Run("Index", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s/%s/APKINDEX.tar.gz", url, branch, repository, "aarch64"))
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		// aarch64 only contains the noarch package
+		index := readIndex(t, resp.Body.Bytes(), keyName, publicKey)
+		assert.Equal(t, 1, strings.Count(index, "P:"+packageName+"\n"))
+		assert.Contains(t, index, "A:noarch\n")
+		assert.Contains(t, index, "D:musl\n")
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s/%s/APKINDEX.tar.gz", url, branch, repository, "x86_64"))
+		resp = MakeRequest(t, req, http.StatusOK)
+
+		index = readIndex(t, resp.Body.Bytes(), keyName, publicKey)
+		assert.Equal(t, 2, strings.Count(index, "P:"+packageName+"\n"))
+		assert.Contains(t, index, "A:x86_64\n")
+		assert.Contains(t, index, "V:"+packageVersion+"\n")
+		assert.Contains(t, index, "T:"+packageDescription+"\n")
+		assert.Contains(t, index, "C:Q1")
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s/%s/APKINDEX.tar.gz", url, "v3.16", repository, "x86_64"))
+		MakeRequest(t, req, http.StatusNotFound)
+
+		// the indices are built on upload and served from storage
+		pv, err := packages.GetInternalVersionByNameAndVersion(db.DefaultContext, user.ID, packages.TypeAlpine, alpine_module.RepositoryPackage, alpine_module.RepositoryVersion)
+		assert.NoError(t, err)
+		pfs, err := packages.GetFilesByVersionID(db.DefaultContext, pv.ID)
+		assert.NoError(t, err)
+		compositeKeys := make([]string, 0, len(pfs))
+		for _, pf := range pfs {
+			assert.Equal(t, "APKINDEX.tar.gz", pf.Name)
+			compositeKeys = append(compositeKeys, pf.CompositeKey)
+		}
+		assert.ElementsMatch(t, []string{branch + "|" + repository + "|noarch", branch + "|" + repository + "|x86_64"}, compositeKeys)
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		filename := fmt.Sprintf("%s-%s.apk", packageName, packageVersion)
+
+		req := NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s/%s/%s", url, branch, repository, "x86_64", filename))
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, createPackage(packageVersion, "x86_64"), resp.Body.Bytes())
+
+		// noarch packages are available in every architecture
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s/%s/%s", url, branch, repository, "aarch64", filename))
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, createPackage(packageVersion, "noarch"), resp.Body.Bytes())
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s/%s/%s", url, branch, "community", "x86_64", filename))
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		filename := fmt.Sprintf("%s-%s.apk", packageName, packageVersion)
+		fileURL := fmt.Sprintf("%s/%s/%s/%s/%s", url, branch, repository, "x86_64", filename)
+
+		req := NewRequest(t, "DELETE", fileURL)
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequest(t, "DELETE", fileURL)
+		AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusNoContent)
+
+		req = NewRequest(t, "DELETE", fileURL)
+		AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusNotFound)
+
+		// the index of x86_64 only contains the noarch package now
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s/%s/APKINDEX.tar.gz", url, branch, repository, "x86_64"))
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		index := readIndex(t, resp.Body.Bytes(), keyName, publicKey)
+		assert.Equal(t, 1, strings.Count(index, "P:"+packageName+"\n"))
+		assert.NotContains(t, index, "A:x86_64\n")
+
+		// removing the package version removes the indices too
+		req = NewRequest(t, "DELETE", fmt.Sprintf("/api/v1/packages/%s/alpine/%s/%s", user.Name, packageName, packageVersion))
+		AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusNoContent)
+
+		pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeAlpine)
+		assert.NoError(t, err)
+		assert.Empty(t, pvs)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s/%s/APKINDEX.tar.gz", url, branch, repository, "x86_64"))
+		MakeRequest(t, req, http.StatusNotFound)
+
+		_, err = packages.GetInternalVersionByNameAndVersion(db.DefaultContext, user.ID, packages.TypeAlpine, alpine_module.RepositoryPackage, alpine_module.RepositoryVersion)
+		assert.ErrorIs(t, err, packages.ErrPackageNotExist)
+	})
+}
+
+// readIndex verifies the signature of an APKINDEX.tar.gz file and returns the content of the APKINDEX file
+func readIndex(t *testing.T, content []byte, keyName string, publicKey *rsa.PublicKey) string {
+	br := bufio.NewReader(bytes.NewReader(content))
+
+	zr, err := gzip.NewReader(br)
+	assert.NoError(t, err)
+	zr.Multistream(false)
+
+	tr := tar.NewReader(zr)
+	hd, err := tr.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, ".SIGN.RSA."+keyName, hd.Name)
+	signature, err := io.ReadAll(tr)
+	assert.NoError(t, err)
+	_, err = io.Copy(io.Discard, zr)
+	assert.NoError(t, err)
+
+	index, err := io.ReadAll(br)
+	assert.NoError(t, err)
+
+	h := sha1.Sum(index)
+	assert.NoError(t, rsa.VerifyPKCS1v15(publicKey, crypto.SHA1, h[:], signature))
+
+	zr, err = gzip.NewReader(bytes.NewReader(index))
+	assert.NoError(t, err)
+	tr = tar.NewReader(zr)
+	for {
+		hd, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		if hd.Name == "APKINDEX" {
+			data, err := io.ReadAll(tr)
+			assert.NoError(t, err)
+			return string(data)
+		}
+	}
+	assert.Fail(t, "APKINDEX file is missing")
+	return ""
+}
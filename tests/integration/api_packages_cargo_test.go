@@ -0,0 +1,337 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/json"
+	cargo_module "code.gitea.io/gitea/modules/packages/cargo"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/routers/api/packages/cargo"
+	cargo_service "code.gitea.io/gitea/services/packages/cargo"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageCargo(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+	user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+
+	packageName := "cargo-package"
+	packageVersion := "1.0.3"
+	packageDescription := "Package Description"
+	packageAuthor := "KN4CK3R"
+	packageHomepage := "https://gitea.io/"
+	packageLicense := "MIT"
+
+	createPackage := func(name, version string) []byte {
+		metadata := `{
+   "name":"` + name + `",
+   "vers":"` + version + `",
+   "description":"` + packageDescription + `",
+   "authors": ["` + packageAuthor + `"],
+   "deps":[
+      {
+         "name":"dep",
+         "version_req":"1.0",
+         "registry": "https://gitea.io/user/_cargo-index",
+         "kind": "normal",
+         "default_features": true
+      }
+   ],
+   "features": {
+      "default": ["dep"]
+   },
+   "homepage":"` + packageHomepage + `",
+   "license":"` + packageLicense + `"
+}`
+
+		var buf bytes.Buffer
+		binary.Write(&buf, binary.LittleEndian, uint32(len(metadata)))
+		buf.WriteString(metadata)
+		binary.Write(&buf, binary.LittleEndian, uint32(4))
+		buf.WriteString("te\x00t")
+		return buf.Bytes()
+	}
+
+	token := getUserToken(t, user.Name)
+
+	url := fmt.Sprintf("/api/packages/%s/cargo", user.Name)
+
+	t.Run("Config", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", url+"/config.json")
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var config cargo_service.Config
+		DecodeJSON(t, resp, &config)
+
+		assert.Equal(t, setting.AppURL+url[1:]+"/api/v1/crates", config.DownloadURL)
+		assert.Equal(t, setting.AppURL+url[1:], config.APIURL)
+		assert.False(t, config.AuthRequired)
+	})
+
+	t.Run("Upload", func(t *testing.T) {
+		t.Run("InvalidNameOrVersion", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			content := createPackage("0test", "1.0.0")
+
+			req := NewRequestWithBody(t, "PUT", url+"/api/v1/crates/new", bytes.NewReader(content))
+			req = AddBasicAuthHeader(req, user.Name)
+			resp := MakeRequest(t, req, http.StatusBadRequest)
+
+			var status cargo.StatusResponse
+			DecodeJSON(t, resp, &status)
+			assert.False(t, status.OK)
+			assert.NotEmpty(t, status.Errors)
+
+			content = createPackage("test", "1-")
+
+			req = NewRequestWithBody(t, "PUT", url+"/api/v1/crates/new", bytes.NewReader(content))
+			req = AddBasicAuthHeader(req, user.Name)
+			MakeRequest(t, req, http.StatusBadRequest)
+		})
+
+		t.Run("Valid", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			req := NewRequestWithBody(t, "PUT", url+"/api/v1/crates/new", bytes.NewReader(createPackage(packageName, packageVersion)))
+			MakeRequest(t, req, http.StatusUnauthorized)
+
+			// cargo sends the token without a scheme
+			req = NewRequestWithBody(t, "PUT", url+"/api/v1/crates/new", bytes.NewReader(createPackage(packageName, packageVersion)))
+			req = addTokenAuthHeader(req, token)
+			resp := MakeRequest(t, req, http.StatusOK)
+
+			type Warnings struct {
+				InvalidCategories []string `json:"invalid_categories"`
+				InvalidBadges     []string `json:"invalid_badges"`
+				Other             []string `json:"other"`
+			}
+			type UploadResponse struct {
+				Warnings *Warnings `json:"warnings"`
+			}
+
+			var result UploadResponse
+			DecodeJSON(t, resp, &result)
+			assert.NotNil(t, result.Warnings)
+			assert.Empty(t, result.Warnings.InvalidCategories)
+			assert.Empty(t, result.Warnings.InvalidBadges)
+			assert.Empty(t, result.Warnings.Other)
+
+			pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeCargo)
+			assert.NoError(t, err)
+			assert.Len(t, pvs, 1)
+
+			pd, err := packages.GetPackageDescriptor(db.DefaultContext, pvs[0])
+			assert.NoError(t, err)
+			assert.NotNil(t, pd.SemVer)
+			assert.IsType(t, &cargo_module.Metadata{}, pd.Metadata)
+			assert.Equal(t, packageName, pd.Package.Name)
+			assert.Equal(t, packageVersion, pd.Version.Version)
+
+			pfs, err := packages.GetFilesByVersionID(db.DefaultContext, pvs[0].ID)
+			assert.NoError(t, err)
+			assert.Len(t, pfs, 1)
+			assert.Equal(t, fmt.Sprintf("%s-%s.crate", packageName, packageVersion), pfs[0].Name)
+			assert.True(t, pfs[0].IsLead)
+
+			pb, err := packages.GetBlobByID(db.DefaultContext, pfs[0].BlobID)
+			assert.NoError(t, err)
+			assert.EqualValues(t, 4, pb.Size)
+
+			req = NewRequestWithBody(t, "PUT", url+"/api/v1/crates/new", bytes.NewReader(createPackage(packageName, packageVersion)))
+			req = addTokenAuthHeader(req, "Bearer "+token)
+			MakeRequest(t, req, http.StatusConflict)
+		})
+	})
+
+	t.Run("Index", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", fmt.Sprintf("%s/%s", url, cargo_service.IndexFilePath("unknown-package")))
+		MakeRequest(t, req, http.StatusNotFound)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/%s", url, cargo_service.IndexFilePath(packageName)))
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var entry cargo_service.IndexVersionEntry
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &entry))
+
+		assert.Equal(t, packageName, entry.Name)
+		assert.Equal(t, packageVersion, entry.Version)
+		assert.Equal(t, "00bb15ec7fc23d71f04e6e9790d3fbe4724f21eccf023b70505956c4f9426299", entry.FileChecksum)
+		assert.False(t, entry.Yanked)
+		assert.Len(t, entry.Dependencies, 1)
+		dep := entry.Dependencies[0]
+		assert.Equal(t, "dep", dep.Name)
+		assert.Equal(t, "1.0", dep.Req)
+		assert.Equal(t, "normal", dep.Kind)
+		assert.True(t, dep.DefaultFeatures)
+		assert.Empty(t, dep.Features)
+		assert.False(t, dep.Optional)
+		assert.Nil(t, dep.Target)
+		assert.NotNil(t, dep.Registry)
+		assert.Equal(t, "https://gitea.io/user/_cargo-index", *dep.Registry)
+		assert.Nil(t, dep.Package)
+		assert.Len(t, entry.Features, 1)
+		assert.ElementsMatch(t, []string{"dep"}, entry.Features["default"])
+
+		// the index file path is case insensitive
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/ca/rg/Cargo-Package", url))
+		MakeRequest(t, req, http.StatusOK)
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		pv, err := packages.GetVersionByNameAndVersion(db.DefaultContext, user.ID, packages.TypeCargo, packageName, packageVersion)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 0, pv.DownloadCount)
+
+		pfs, err := packages.GetFilesByVersionID(db.DefaultContext, pv.ID)
+		assert.NoError(t, err)
+		assert.Len(t, pfs, 1)
+
+		req := NewRequest(t, "GET", fmt.Sprintf("%s/api/v1/crates/%s/%s/download", url, packageName, packageVersion))
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		assert.Equal(t, "te\x00t", resp.Body.String())
+
+		pv, err = packages.GetVersionByNameAndVersion(db.DefaultContext, user.ID, packages.TypeCargo, packageName, packageVersion)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, pv.DownloadCount)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/api/v1/crates/%s/%s/download", url, packageName, "9.9.9"))
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		cases := []struct {
+			Query           string
+			Page            int
+			PerPage         int
+			ExpectedTotal   int64
+			ExpectedResults int
+		}{
+			{"", 0, 0, 1, 1},
+			{"", 1, 10, 1, 1},
+			{"cargo", 1, 0, 1, 1},
+			{"cargo", 1, 10, 1, 1},
+			{"cargo", 2, 10, 1, 0},
+			{"test", 0, 10, 0, 0},
+		}
+
+		for i, c := range cases {
+			req := NewRequest(t, "GET", fmt.Sprintf("%s/api/v1/crates?q=%s&page=%d&per_page=%d", url, c.Query, c.Page, c.PerPage))
+			resp := MakeRequest(t, req, http.StatusOK)
+
+			var result cargo.SearchResult
+			DecodeJSON(t, resp, &result)
+
+			assert.Equal(t, c.ExpectedTotal, result.Meta.Total, "case %d: unexpected total hits", i)
+			assert.Len(t, result.Crates, c.ExpectedResults, "case %d: unexpected result count", i)
+		}
+	})
+
+	t.Run("Yank", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "DELETE", fmt.Sprintf("%s/api/v1/crates/%s/%s/yank", url, packageName, packageVersion))
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequest(t, "DELETE", fmt.Sprintf("%s/api/v1/crates/%s/%s/yank", url, packageName, packageVersion))
+		req = addTokenAuthHeader(req, token)
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var status cargo.StatusResponse
+		DecodeJSON(t, resp, &status)
+		assert.True(t, status.OK)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/%s", url, cargo_service.IndexFilePath(packageName)))
+		resp = MakeRequest(t, req, http.StatusOK)
+
+		var entry cargo_service.IndexVersionEntry
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &entry))
+		assert.True(t, entry.Yanked)
+	})
+
+	t.Run("Unyank", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "PUT", fmt.Sprintf("%s/api/v1/crates/%s/%s/unyank", url, packageName, packageVersion))
+		req = addTokenAuthHeader(req, token)
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var status cargo.StatusResponse
+		DecodeJSON(t, resp, &status)
+		assert.True(t, status.OK)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/%s", url, cargo_service.IndexFilePath(packageName)))
+		resp = MakeRequest(t, req, http.StatusOK)
+
+		var entry cargo_service.IndexVersionEntry
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &entry))
+		assert.False(t, entry.Yanked)
+	})
+
+	t.Run("ListOwners", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", fmt.Sprintf("%s/api/v1/crates/%s/owners", url, packageName))
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var owners cargo.Owners
+		DecodeJSON(t, resp, &owners)
+
+		assert.Len(t, owners.Owners, 1)
+		assert.Equal(t, user.ID, owners.Owners[0].ID)
+		assert.Equal(t, user.Name, owners.Owners[0].Login)
+		assert.Equal(t, user.DisplayName(), owners.Owners[0].Name)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequestWithBody(t, "PUT", url+"/api/v1/crates/new", bytes.NewReader(createPackage(packageName, "1.0.4")))
+		req = addTokenAuthHeader(req, token)
+		MakeRequest(t, req, http.StatusOK)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/%s", url, cargo_service.IndexFilePath(packageName)))
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var versions []string
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			var entry cargo_service.IndexVersionEntry
+			assert.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+			versions = append(versions, entry.Version)
+		}
+		assert.Equal(t, []string{packageVersion, "1.0.4"}, versions)
+
+		for _, version := range []string{packageVersion, "1.0.4"} {
+			req = NewRequest(t, "DELETE", fmt.Sprintf("/api/v1/packages/%s/cargo/%s/%s", user.Name, packageName, version))
+			req = AddBasicAuthHeader(req, user.Name)
+			MakeRequest(t, req, http.StatusNoContent)
+		}
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/%s", url, cargo_service.IndexFilePath(packageName)))
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+}
@@ -0,0 +1,245 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	conda_module "code.gitea.io/gitea/modules/packages/conda"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/dsnet/compress/bzip2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageConda(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+	user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+
+	packageName := "test_package"
+	packageVersion := "1.0.1"
+	packageBuild := "py39_0"
+	packageSubdir := "linux-64"
+	packageDescription := "Test package"
+
+	createInfoTar := func(subdir string) []byte {
+		files := map[string]string{
+			"info/index.json": fmt.Sprintf(`{"name":"%s","version":"%s","build":"%s","build_number":0,"subdir":"%s","depends":["python >=3.9"]}`, packageName, packageVersion, packageBuild, subdir),
+			"info/about.json": fmt.Sprintf(`{"description":"%s"}`, packageDescription),
+		}
+
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		for name, content := range files {
+			tw.WriteHeader(&tar.Header{
+				Name:     name,
+				Mode:     0o600,
+				Size:     int64(len(content)),
+				Typeflag: tar.TypeReg,
+			})
+			tw.Write([]byte(content))
+		}
+		tw.Close()
+		return buf.Bytes()
+	}
+
+	createTarBz2 := func(subdir string) []byte {
+		var buf bytes.Buffer
+		bw, _ := bzip2.NewWriter(&buf, nil)
+		io.Copy(bw, bytes.NewReader(createInfoTar(subdir)))
+		bw.Close()
+		return buf.Bytes()
+	}
+
+	createConda := func(subdir string) []byte {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		w, _ := zw.Create(fmt.Sprintf("info-%s-%s-%s.tar.zst", packageName, packageVersion, packageBuild))
+		zsw, _ := zstd.NewWriter(w)
+		zsw.Write(createInfoTar(subdir))
+		zsw.Close()
+		zw.Close()
+		return buf.Bytes()
+	}
+
+	root := fmt.Sprintf("/api/packages/%s/conda", user.Name)
+	channel := "test-channel"
+
+	filenameTarBz2 := fmt.Sprintf("%s-%s-%s.tar.bz2", packageName, packageVersion, packageBuild)
+	filenameConda := fmt.Sprintf("%s-%s-%s.conda", packageName, packageVersion, packageBuild)
+
+	t.Run("Upload", func(t *testing.T) {
+		t.Run(".tar.bz2", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			content := createTarBz2(packageSubdir)
+
+			req := NewRequestWithBody(t, "PUT", root+"/"+filenameTarBz2, bytes.NewReader(content))
+			MakeRequest(t, req, http.StatusUnauthorized)
+
+			req = NewRequestWithBody(t, "PUT", root+"/"+filenameTarBz2, bytes.NewReader(content))
+			AddBasicAuthHeader(req, user.Name)
+			MakeRequest(t, req, http.StatusCreated)
+
+			req = NewRequestWithBody(t, "PUT", root+"/"+filenameTarBz2, bytes.NewReader(content))
+			AddBasicAuthHeader(req, user.Name)
+			MakeRequest(t, req, http.StatusConflict)
+
+			// the file name must match the package
+			req = NewRequestWithBody(t, "PUT", root+"/"+channel+"/other-1.0-0.tar.bz2", bytes.NewReader(content))
+			AddBasicAuthHeader(req, user.Name)
+			MakeRequest(t, req, http.StatusBadRequest)
+
+			req = NewRequestWithBody(t, "PUT", root+"/"+channel+"/"+filenameTarBz2, bytes.NewReader(content))
+			AddBasicAuthHeader(req, user.Name)
+			MakeRequest(t, req, http.StatusCreated)
+
+			pv, err := packages.GetVersionByNameAndVersion(db.DefaultContext, user.ID, packages.TypeConda, packageName, packageVersion)
+			assert.NoError(t, err)
+
+			pd, err := packages.GetPackageDescriptor(db.DefaultContext, pv)
+			assert.NoError(t, err)
+			assert.IsType(t, &conda_module.VersionMetadata{}, pd.Metadata)
+			assert.Equal(t, packageName, pd.Package.Name)
+			assert.Equal(t, packageVersion, pd.Version.Version)
+			assert.Equal(t, packageDescription, pd.Metadata.(*conda_module.VersionMetadata).Description)
+			assert.Len(t, pd.Files, 2)
+			for _, pfd := range pd.Files {
+				assert.Equal(t, filenameTarBz2, pfd.File.Name)
+				assert.Equal(t, packageSubdir, pfd.Properties.GetByName(conda_module.PropertySubdir))
+			}
+		})
+
+		t.Run(".conda", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			content := createConda(conda_module.SubdirNoarch)
+
+			req := NewRequestWithBody(t, "PUT", root+"/"+channel+"/"+filenameConda, bytes.NewReader(content))
+			AddBasicAuthHeader(req, user.Name)
+			MakeRequest(t, req, http.StatusCreated)
+
+			req = NewRequestWithBody(t, "PUT", root+"/"+channel+"/"+filenameConda, bytes.NewReader(content))
+			AddBasicAuthHeader(req, user.Name)
+			MakeRequest(t, req, http.StatusConflict)
+
+			req = NewRequestWithBody(t, "PUT", root+"/"+channel+"/"+packageName+".zip", bytes.NewReader(content))
+			AddBasicAuthHeader(req, user.Name)
+			MakeRequest(t, req, http.StatusBadRequest)
+
+			pfs, err := packages.GetFilesByVersionID(db.DefaultContext, unittest.AssertExistsAndLoadBean(t, &packages.PackageVersion{Version: packageVersion}).ID)
+			assert.NoError(t, err)
+			assert.Len(t, pfs, 3)
+		})
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s", root, packageSubdir, filenameTarBz2))
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, createTarBz2(packageSubdir), resp.Body.Bytes())
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s/%s", root, channel, conda_module.SubdirNoarch, filenameConda))
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, createConda(conda_module.SubdirNoarch), resp.Body.Bytes())
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s", root, conda_module.SubdirNoarch, filenameConda))
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+
+	t.Run("EnumeratePackages", func(t *testing.T) {
+		type Repodata struct {
+			Info struct {
+				Subdir string `json:"subdir"`
+			} `json:"info"`
+			Packages      map[string]map[string]interface{} `json:"packages"`
+			PackagesConda map[string]map[string]interface{} `json:"packages.conda"`
+		}
+
+		t.Run("Repodata", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			req := NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s/repodata.json", root, channel, packageSubdir))
+			resp := MakeRequest(t, req, http.StatusOK)
+
+			var result Repodata
+			DecodeJSON(t, resp, &result)
+
+			assert.Equal(t, packageSubdir, result.Info.Subdir)
+			assert.Len(t, result.Packages, 1)
+			assert.Empty(t, result.PackagesConda)
+
+			entry := result.Packages[filenameTarBz2]
+			assert.NotNil(t, entry)
+			assert.Equal(t, packageName, entry["name"])
+			assert.Equal(t, packageVersion, entry["version"])
+			assert.Equal(t, packageBuild, entry["build"])
+			assert.Equal(t, []interface{}{"python >=3.9"}, entry["depends"])
+			assert.Equal(t, float64(len(createTarBz2(packageSubdir))), entry["size"])
+			assert.NotEmpty(t, entry["md5"])
+			assert.NotEmpty(t, entry["sha256"])
+
+			req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s/repodata.json", root, channel, conda_module.SubdirNoarch))
+			resp = MakeRequest(t, req, http.StatusOK)
+
+			result = Repodata{}
+			DecodeJSON(t, resp, &result)
+
+			assert.Empty(t, result.Packages)
+			assert.Len(t, result.PackagesConda, 1)
+			assert.Contains(t, result.PackagesConda, filenameConda)
+
+			// the default channel has no noarch packages but the index must exist
+			req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/repodata.json", root, conda_module.SubdirNoarch))
+			resp = MakeRequest(t, req, http.StatusOK)
+
+			result = Repodata{}
+			DecodeJSON(t, resp, &result)
+
+			assert.Empty(t, result.Packages)
+			assert.Empty(t, result.PackagesConda)
+		})
+
+		t.Run("Channeldata", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			type Channeldata struct {
+				Packages map[string]struct {
+					Description string   `json:"description"`
+					Subdirs     []string `json:"subdirs"`
+					Version     string   `json:"version"`
+				} `json:"packages"`
+				Subdirs []string `json:"subdirs"`
+			}
+
+			req := NewRequest(t, "GET", fmt.Sprintf("%s/%s/channeldata.json", root, channel))
+			resp := MakeRequest(t, req, http.StatusOK)
+
+			var result Channeldata
+			DecodeJSON(t, resp, &result)
+
+			assert.Equal(t, []string{packageSubdir, conda_module.SubdirNoarch}, result.Subdirs)
+			assert.Len(t, result.Packages, 1)
+			assert.Contains(t, result.Packages, packageName)
+			assert.Equal(t, packageDescription, result.Packages[packageName].Description)
+			assert.Equal(t, packageVersion, result.Packages[packageName].Version)
+			assert.Equal(t, []string{packageSubdir, conda_module.SubdirNoarch}, result.Packages[packageName].Subdirs)
+
+			req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/channeldata.json", root, "unknown-channel"))
+			MakeRequest(t, req, http.StatusNotFound)
+		})
+	})
+}
@@ -0,0 +1,285 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	debian_module "code.gitea.io/gitea/modules/packages/debian"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageDebian(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+	user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+
+	packageName := "gitea"
+	packageVersion := "1.0.3"
+	packageDescription := "Package Description"
+
+	createArchive := func(name, version, architecture string) []byte {
+		var cbuf bytes.Buffer
+		zw := gzip.NewWriter(&cbuf)
+		tw := tar.NewWriter(zw)
+		content := fmt.Sprintf("Package: %s\nVersion: %s\nArchitecture: %s\nMaintainer: KN4CK3R <dummy@gitea.io>\nDescription: %s\n", name, version, architecture, packageDescription)
+		tw.WriteHeader(&tar.Header{
+			Name: "control",
+			Mode: 0o600,
+			Size: int64(len(content)),
+		})
+		tw.Write([]byte(content))
+		tw.Close()
+		zw.Close()
+
+		var buf bytes.Buffer
+		buf.WriteString("!<arch>\n")
+		for _, member := range []struct {
+			Name    string
+			Content []byte
+		}{
+			{"debian-binary", []byte("2.0\n")},
+			{"control.tar.gz", cbuf.Bytes()},
+		} {
+			fmt.Fprintf(&buf, "%-16s%-12s%-6s%-6s%-8s%-10d`\n", member.Name+"/", "0", "0", "0", "100644", len(member.Content))
+			buf.Write(member.Content)
+			if len(member.Content)%2 == 1 {
+				buf.WriteByte('\n')
+			}
+		}
+		return buf.Bytes()
+	}
+
+	distributions := []string{"test", "gitea"}
+	components := []string{"main", "stable"}
+	architectures := []string{"all", "amd64"}
+
+	rootURL := fmt.Sprintf("/api/packages/%s/debian", user.Name)
+
+	t.Run("RepositoryKey", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", rootURL+"/repository.key")
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		assert.Equal(t, "application/pgp-keys", resp.Header().Get("Content-Type"))
+		assert.Contains(t, resp.Body.String(), "-----BEGIN PGP PUBLIC KEY BLOCK-----")
+	})
+
+	for _, distribution := range distributions {
+		t.Run(fmt.Sprintf("[Distribution:%s]", distribution), func(t *testing.T) {
+			for _, component := range components {
+				for _, architecture := range architectures {
+					t.Run(fmt.Sprintf("[Component:%s,Architecture:%s]", component, architecture), func(t *testing.T) {
+						t.Run("Upload", func(t *testing.T) {
+							defer tests.PrintCurrentTest(t)()
+
+							uploadURL := fmt.Sprintf("%s/pool/%s/%s/upload", rootURL, distribution, component)
+
+							req := NewRequestWithBody(t, "PUT", uploadURL, bytes.NewReader([]byte{}))
+							MakeRequest(t, req, http.StatusUnauthorized)
+
+							req = NewRequestWithBody(t, "PUT", uploadURL, bytes.NewReader([]byte{}))
+							req = AddBasicAuthHeader(req, user.Name)
+							MakeRequest(t, req, http.StatusBadRequest)
+
+							req = NewRequestWithBody(t, "PUT", uploadURL, bytes.NewReader(createArchive("", "", "")))
+							req = AddBasicAuthHeader(req, user.Name)
+							MakeRequest(t, req, http.StatusBadRequest)
+
+							req = NewRequestWithBody(t, "PUT", uploadURL, bytes.NewReader(createArchive(packageName, packageVersion, architecture)))
+							req = AddBasicAuthHeader(req, user.Name)
+							MakeRequest(t, req, http.StatusCreated)
+
+							pv, err := packages.GetVersionByNameAndVersion(db.DefaultContext, user.ID, packages.TypeDebian, packageName, packageVersion)
+							assert.NoError(t, err)
+
+							pd, err := packages.GetPackageDescriptor(db.DefaultContext, pv)
+							assert.NoError(t, err)
+							assert.Nil(t, pd.SemVer)
+							assert.IsType(t, &debian_module.Metadata{}, pd.Metadata)
+							assert.Equal(t, packageName, pd.Package.Name)
+							assert.Equal(t, packageVersion, pd.Version.Version)
+
+							pfs, err := packages.GetFilesByVersionID(db.DefaultContext, pv.ID)
+							assert.NoError(t, err)
+							assert.NotEmpty(t, pfs)
+							assert.Condition(t, func() bool {
+								seen := false
+								expectedFilename := fmt.Sprintf("%s_%s_%s.deb", packageName, packageVersion, architecture)
+								expectedCompositeKey := fmt.Sprintf("%s|%s", distribution, component)
+								for _, pf := range pfs {
+									if pf.Name != expectedFilename || pf.CompositeKey != expectedCompositeKey {
+										continue
+									}
+									if seen {
+										return false
+									}
+									seen = true
+
+									assert.True(t, pf.IsLead)
+
+									pfps, err := packages.GetProperties(db.DefaultContext, packages.PropertyTypeFile, pf.ID)
+									assert.NoError(t, err)
+
+									for _, pfp := range pfps {
+										switch pfp.Name {
+										case debian_module.PropertyDistribution:
+											assert.Equal(t, distribution, pfp.Value)
+										case debian_module.PropertyComponent:
+											assert.Equal(t, component, pfp.Value)
+										case debian_module.PropertyArchitecture:
+											assert.Equal(t, architecture, pfp.Value)
+										}
+									}
+								}
+								return seen
+							})
+
+							req = NewRequestWithBody(t, "PUT", uploadURL, bytes.NewReader(createArchive(packageName, packageVersion, architecture)))
+							req = AddBasicAuthHeader(req, user.Name)
+							MakeRequest(t, req, http.StatusConflict)
+						})
+
+						t.Run("Download", func(t *testing.T) {
+							defer tests.PrintCurrentTest(t)()
+
+							req := NewRequest(t, "GET", fmt.Sprintf("%s/pool/%s/%s/%s_%s_%s.deb", rootURL, distribution, component, packageName, packageVersion, architecture))
+							resp := MakeRequest(t, req, http.StatusOK)
+
+							assert.Equal(t, createArchive(packageName, packageVersion, architecture), resp.Body.Bytes())
+						})
+
+						t.Run("Packages", func(t *testing.T) {
+							defer tests.PrintCurrentTest(t)()
+
+							url := fmt.Sprintf("%s/dists/%s/%s/binary-%s/Packages", rootURL, distribution, component, architecture)
+
+							req := NewRequest(t, "GET", url)
+							resp := MakeRequest(t, req, http.StatusOK)
+
+							body := resp.Body.String()
+
+							assert.Contains(t, body, "Package: "+packageName)
+							assert.Contains(t, body, "Version: "+packageVersion)
+							assert.Contains(t, body, "Architecture: "+architecture)
+							assert.Contains(t, body, fmt.Sprintf("Filename: pool/%s/%s/%s_%s_%s.deb", distribution, component, packageName, packageVersion, architecture))
+
+							req = NewRequest(t, "GET", url+".gz")
+							resp = MakeRequest(t, req, http.StatusOK)
+
+							zr, err := gzip.NewReader(resp.Body)
+							assert.NoError(t, err)
+							uncompressed, err := io.ReadAll(zr)
+							assert.NoError(t, err)
+							assert.Equal(t, body, string(uncompressed))
+						})
+					})
+				}
+			}
+
+			t.Run("Release", func(t *testing.T) {
+				defer tests.PrintCurrentTest(t)()
+
+				req := NewRequest(t, "GET", fmt.Sprintf("%s/dists/%s/Release", rootURL, distribution))
+				resp := MakeRequest(t, req, http.StatusOK)
+
+				body := resp.Body.String()
+
+				assert.Contains(t, body, "Components: "+strings.Join(components, " "))
+				assert.Contains(t, body, "Architectures: amd64\n")
+				assert.Contains(t, body, "main/binary-amd64/Packages")
+				assert.Contains(t, body, "stable/binary-amd64/Packages.gz")
+
+				req = NewRequest(t, "GET", fmt.Sprintf("%s/dists/%s/Release.gpg", rootURL, distribution))
+				resp = MakeRequest(t, req, http.StatusOK)
+
+				assert.Contains(t, resp.Body.String(), "-----BEGIN PGP SIGNATURE-----")
+
+				req = NewRequest(t, "GET", fmt.Sprintf("%s/dists/%s/InRelease", rootURL, distribution))
+				resp = MakeRequest(t, req, http.StatusOK)
+
+				assert.Contains(t, resp.Body.String(), "-----BEGIN PGP SIGNED MESSAGE-----")
+				assert.Contains(t, resp.Body.String(), body)
+
+				// the files are built on upload and served from storage
+				pv, err := packages.GetInternalVersionByNameAndVersion(db.DefaultContext, user.ID, packages.TypeDebian, debian_module.RepositoryPackage, debian_module.RepositoryVersion)
+				assert.NoError(t, err)
+				for _, filename := range []string{"Release", "Release.gpg", "InRelease"} {
+					_, err := packages.GetFileForVersionByName(db.DefaultContext, pv.ID, filename, distribution)
+					assert.NoError(t, err)
+				}
+
+				// the checksums of the Release file must match the served indices
+				_, checksums, _ := strings.Cut(body, "SHA256:\n")
+				checksums, _, _ = strings.Cut(checksums, "SHA512:\n")
+				for _, line := range strings.Split(strings.TrimSpace(checksums), "\n") {
+					fields := strings.Fields(line)
+					assert.Len(t, fields, 3)
+
+					req = NewRequest(t, "GET", fmt.Sprintf("%s/dists/%s/%s", rootURL, distribution, fields[2]))
+					data := MakeRequest(t, req, http.StatusOK).Body.Bytes()
+					sum := sha256.Sum256(data)
+					assert.Equal(t, fields[0], hex.EncodeToString(sum[:]))
+					assert.Equal(t, fields[1], strconv.Itoa(len(data)))
+				}
+			})
+		})
+	}
+
+	t.Run("Delete", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		distribution := distributions[0]
+		architecture := architectures[0]
+
+		for _, component := range components {
+			req := NewRequest(t, "DELETE", fmt.Sprintf("%s/pool/%s/%s/%s/%s/%s", rootURL, distribution, component, packageName, packageVersion, architecture))
+			MakeRequest(t, req, http.StatusUnauthorized)
+
+			req = NewRequest(t, "DELETE", fmt.Sprintf("%s/pool/%s/%s/%s/%s/%s", rootURL, distribution, component, packageName, packageVersion, architecture))
+			req = AddBasicAuthHeader(req, user.Name)
+			MakeRequest(t, req, http.StatusNoContent)
+
+			req = NewRequest(t, "DELETE", fmt.Sprintf("%s/pool/%s/%s/%s/%s/%s", rootURL, distribution, component, packageName, packageVersion, architecture))
+			req = AddBasicAuthHeader(req, user.Name)
+			MakeRequest(t, req, http.StatusNotFound)
+		}
+
+		req := NewRequest(t, "GET", fmt.Sprintf("%s/dists/%s/main/binary-%s/Packages", rootURL, distribution, architecture))
+		MakeRequest(t, req, http.StatusNotFound)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/dists/%s/main/binary-amd64/Packages", rootURL, distribution))
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.NotContains(t, resp.Body.String(), "Architecture: all")
+
+		// removing the package version from all distributions removes the repository files too
+		req = NewRequest(t, "DELETE", fmt.Sprintf("/api/v1/packages/%s/debian/%s/%s", user.Name, packageName, packageVersion))
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusNoContent)
+
+		for _, distribution := range distributions {
+			req = NewRequest(t, "GET", fmt.Sprintf("%s/dists/%s/Release", rootURL, distribution))
+			MakeRequest(t, req, http.StatusNotFound)
+		}
+
+		_, err := packages.GetInternalVersionByNameAndVersion(db.DefaultContext, user.ID, packages.TypeDebian, debian_module.RepositoryPackage, debian_module.RepositoryVersion)
+		assert.ErrorIs(t, err, packages.ErrPackageNotExist)
+	})
+}
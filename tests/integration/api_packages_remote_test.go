@@ -0,0 +1,473 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/packages/container/oci"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/services/packages/remote"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageRemote(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	defer func(allowedHostList string) {
+		setting.Packages.RemoteAllowedHostList = allowedHostList
+	}(setting.Packages.RemoteAllowedHostList)
+	setting.Packages.RemoteAllowedHostList = "loopback"
+
+	user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+
+	sha1Hex := func(data string) string {
+		h := sha1.Sum([]byte(data))
+		return hex.EncodeToString(h[:])
+	}
+	sha256Hex := func(data string) string {
+		h := sha256.Sum256([]byte(data))
+		return hex.EncodeToString(h[:])
+	}
+
+	// the upstream registry responds with 503 to every request while it is offline
+	var offline int32
+	var requests int64
+	mux := http.NewServeMux()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		if atomic.LoadInt32(&offline) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	defer upstream.Close()
+
+	addRemote := func(t *testing.T, packageType packages_model.Type, url string) {
+		_, err := packages_model.InsertRemote(db.DefaultContext, &packages_model.PackageRemote{
+			Enabled: true,
+			OwnerID: user.ID,
+			Type:    packageType,
+			URL:     url,
+		})
+		assert.NoError(t, err)
+	}
+
+	assertRemoteVersion := func(t *testing.T, packageType packages_model.Type, packageName, packageVersion, url string) {
+		pv, err := packages_model.GetVersionByNameAndVersion(db.DefaultContext, user.ID, packageType, packageName, packageVersion)
+		assert.NoError(t, err)
+		assert.Equal(t, user_model.NewGhostUser().ID, pv.CreatorID)
+
+		pps, err := packages_model.GetPropertiesByName(db.DefaultContext, packages_model.PropertyTypeVersion, pv.ID, remote.PropertyRemoteURL)
+		assert.NoError(t, err)
+		assert.Len(t, pps, 1)
+		assert.Equal(t, url, pps[0].Value)
+	}
+
+	t.Run("Settings", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		session := loginUser(t, user.Name)
+
+		req := NewRequestWithValues(t, "POST", "/user/settings/packages/remotes/add", map[string]string{
+			"_csrf":   GetCSRF(t, session, "/user/settings/packages"),
+			"enabled": "on",
+			"type":    "npm",
+			"url":     upstream.URL + "/npm",
+			"action":  "save",
+		})
+		session.MakeRequest(t, req, http.StatusSeeOther)
+
+		pr, err := packages_model.GetEnabledRemoteByType(db.DefaultContext, user.ID, packages_model.TypeNpm)
+		assert.NoError(t, err)
+		assert.Equal(t, upstream.URL+"/npm", pr.URL)
+
+		req = NewRequest(t, "GET", "/user/settings/packages")
+		resp := session.MakeRequest(t, req, http.StatusOK)
+		assert.Contains(t, resp.Body.String(), upstream.URL+"/npm")
+
+		req = NewRequestWithValues(t, "POST", "/user/settings/packages/remotes/add", map[string]string{
+			"_csrf":   GetCSRF(t, session, "/user/settings/packages"),
+			"enabled": "on",
+			"type":    "generic",
+			"url":     upstream.URL,
+			"action":  "save",
+		})
+		session.MakeRequest(t, req, http.StatusOK)
+
+		has, err := packages_model.HasOwnerRemoteForPackageType(db.DefaultContext, user.ID, packages_model.TypeGeneric)
+		assert.NoError(t, err)
+		assert.False(t, has)
+	})
+
+	t.Run("Npm", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+		defer atomic.StoreInt32(&offline, 0)
+
+		packageName := "remote-package"
+		packageVersion := "1.0.0"
+		filename := packageName + "-" + packageVersion + ".tgz"
+		content := "npm package content"
+
+		hashSHA512 := sha512.Sum512([]byte(content))
+
+		mux.HandleFunc("/npm/"+packageName, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{
+				"name": "%[1]s",
+				"dist-tags": {"latest": "%[2]s"},
+				"versions": {
+					"%[2]s": {
+						"name": "%[1]s",
+						"version": "%[2]s",
+						"description": "Remote Description",
+						"dist": {
+							"tarball": "%[3]s/npm/%[1]s/-/%[4]s",
+							"integrity": "sha512-%[5]s",
+							"shasum": "%[6]s"
+						}
+					}
+				}
+			}`, packageName, packageVersion, upstream.URL, filename, base64.StdEncoding.EncodeToString(hashSHA512[:]), sha1Hex(content))
+		})
+		mux.HandleFunc("/npm/"+packageName+"/-/"+filename, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(content))
+		})
+
+		root := fmt.Sprintf("/api/packages/%s/npm/%s", user.Name, packageName)
+		tarballURL := fmt.Sprintf("%s/-/%s/%s", root, packageVersion, filename)
+
+		req := NewRequest(t, "GET", root)
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var metadata struct {
+			Versions map[string]struct {
+				Dist struct {
+					Tarball string `json:"tarball"`
+				} `json:"dist"`
+			} `json:"versions"`
+		}
+		DecodeJSON(t, resp, &metadata)
+		assert.Contains(t, metadata.Versions, packageVersion)
+		assert.Equal(t, setting.AppURL+strings.TrimPrefix(tarballURL, "/"), metadata.Versions[packageVersion].Dist.Tarball)
+
+		pvs, err := packages_model.GetVersionsByPackageType(db.DefaultContext, user.ID, packages_model.TypeNpm)
+		assert.NoError(t, err)
+		assert.Empty(t, pvs)
+
+		req = NewRequest(t, "GET", tarballURL)
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, content, resp.Body.String())
+
+		assertRemoteVersion(t, packages_model.TypeNpm, packageName, packageVersion, upstream.URL+"/npm")
+
+		req = NewRequest(t, "GET", fmt.Sprintf("/api/packages/%s/npm/-/package/%s/dist-tags", user.Name, packageName))
+		resp = MakeRequest(t, req, http.StatusOK)
+		var tags map[string]string
+		DecodeJSON(t, resp, &tags)
+		assert.Equal(t, map[string]string{"latest": packageVersion}, tags)
+
+		atomic.StoreInt32(&offline, 1)
+
+		req = NewRequest(t, "GET", root)
+		resp = MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &metadata)
+		assert.Contains(t, metadata.Versions, packageVersion)
+
+		req = NewRequest(t, "GET", tarballURL)
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, content, resp.Body.String())
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/-/2.0.0/%s-2.0.0.tgz", root, packageName))
+		MakeRequest(t, req, http.StatusBadGateway)
+
+		t.Run("Uploaded", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			atomic.StoreInt32(&offline, 0)
+
+			// a package with uploaded versions is never resolved through the remote registry
+			localName := "local-package"
+			mux.HandleFunc("/npm/"+localName, func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"name":"` + localName + `","versions":{}}`))
+			})
+
+			req := NewRequestWithBody(t, "PUT", fmt.Sprintf("/api/packages/%s/npm/%s", user.Name, localName), strings.NewReader(`{
+				"name": "`+localName+`",
+				"versions": {
+					"1.0.0": {
+						"name": "`+localName+`",
+						"version": "1.0.0",
+						"dist": {
+							"integrity": "sha512-yA4FJsVhetynGfOC1jFf79BuS+jrHbm0fhh+aHzCQkOaOBXKf9oBnC4a6DnLLnEsHQDRLYd00cwj8sCXpC+wIg==",
+							"shasum": "aaa7eaf852a948b0aa05afeda35b1badca155d90"
+						}
+					}
+				},
+				"_attachments": {
+					"`+localName+`-1.0.0.tgz": {
+						"data": "H4sIAAAAAAAA/ytITM5OTE/VL4DQelnF+XkMVAYGBgZmJiYK2MRBwNDcSIHB2NTMwNDQzMwAqA7IMDUxA9LUdgg2UFpcklgEdAql5kD8ogCnhwio5lJQUMpLzE1VslJQcihOzi9I1S9JLS7RhSYIJR2QgrLUouLM/DyQGkM9Az1D3YIiqExKanFyUWZBCVQ2BKhVwQVJDKwosbQkI78IJO/tZ+LsbRykxFXLNdA+HwWjYBSMgpENACgAbtAACAAA"
+					}
+				}
+			}`))
+			req = AddBasicAuthHeader(req, user.Name)
+			MakeRequest(t, req, http.StatusCreated)
+
+			count := atomic.LoadInt64(&requests)
+
+			req = NewRequest(t, "GET", fmt.Sprintf("/api/packages/%s/npm/%s", user.Name, localName))
+			resp := MakeRequest(t, req, http.StatusOK)
+			DecodeJSON(t, resp, &metadata)
+			assert.Contains(t, metadata.Versions, "1.0.0")
+
+			req = NewRequest(t, "GET", fmt.Sprintf("/api/packages/%s/npm/%s/-/2.0.0/%s-2.0.0.tgz", user.Name, localName, localName))
+			MakeRequest(t, req, http.StatusNotFound)
+
+			assert.Equal(t, count, atomic.LoadInt64(&requests))
+		})
+	})
+
+	t.Run("PyPI", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+		defer atomic.StoreInt32(&offline, 0)
+
+		addRemote(t, packages_model.TypePyPI, upstream.URL+"/pypi-upstream")
+
+		packageName := "remote-package"
+		packageVersion := "1.0.0"
+		filename := packageName + "-" + packageVersion + ".tar.gz"
+		content := "pypi package content"
+
+		file := fmt.Sprintf(`{
+			"filename": "%s",
+			"url": "%s/files/%s",
+			"digests": {"sha256": "%s"},
+			"requires_python": ">=3.7",
+			"yanked": false
+		}`, filename, upstream.URL, filename, sha256Hex(content))
+		info := `{"name": "` + packageName + `", "version": "` + packageVersion + `", "summary": "Remote Summary"}`
+
+		mux.HandleFunc("/pypi-upstream/pypi/"+packageName+"/json", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"info": ` + info + `, "releases": {"` + packageVersion + `": [` + file + `]}}`))
+		})
+		mux.HandleFunc("/pypi-upstream/pypi/"+packageName+"/"+packageVersion+"/json", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"info": ` + info + `, "urls": [` + file + `]}`))
+		})
+		mux.HandleFunc("/files/"+filename, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(content))
+		})
+
+		root := fmt.Sprintf("/api/packages/%s/pypi", user.Name)
+		fileURL := fmt.Sprintf("%s/files/%s/%s/%s", root, packageName, packageVersion, filename)
+
+		req := NewRequest(t, "GET", fmt.Sprintf("%s/simple/%s", root, packageName))
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		htmlDoc := NewHTMLParser(t, resp.Body)
+		nodes := htmlDoc.doc.Find("a")
+		assert.Equal(t, 1, nodes.Length())
+		href, _ := nodes.Attr("href")
+		assert.Equal(t, setting.AppURL+strings.TrimPrefix(fileURL, "/")+"#sha256-"+sha256Hex(content), href)
+		requiresPython, _ := nodes.Attr("data-requires-python")
+		assert.Equal(t, ">=3.7", requiresPython)
+
+		req = NewRequest(t, "GET", fileURL)
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, content, resp.Body.String())
+
+		assertRemoteVersion(t, packages_model.TypePyPI, packageName, packageVersion, upstream.URL+"/pypi-upstream")
+
+		atomic.StoreInt32(&offline, 1)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/simple/%s", root, packageName))
+		resp = MakeRequest(t, req, http.StatusOK)
+		htmlDoc = NewHTMLParser(t, resp.Body)
+		assert.Equal(t, 1, htmlDoc.doc.Find("a").Length())
+
+		req = NewRequest(t, "GET", fileURL)
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, content, resp.Body.String())
+	})
+
+	t.Run("Maven", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+		defer atomic.StoreInt32(&offline, 0)
+
+		addRemote(t, packages_model.TypeMaven, upstream.URL+"/maven2")
+
+		groupID := "com.gitea"
+		artifactID := "remote-project"
+		packageVersion := "1.0.1"
+		filename := fmt.Sprintf("%s-%s.jar", artifactID, packageVersion)
+		content := "maven jar content"
+		metadata := `<?xml version="1.0" encoding="UTF-8"?><metadata><groupId>com.gitea</groupId><artifactId>remote-project</artifactId></metadata>`
+
+		upstreamRoot := "/maven2/com/gitea/" + artifactID
+		mux.HandleFunc(upstreamRoot+"/maven-metadata.xml", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(metadata))
+		})
+		mux.HandleFunc(upstreamRoot+"/"+packageVersion+"/"+filename, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(content))
+		})
+		mux.HandleFunc(upstreamRoot+"/"+packageVersion+"/"+filename+".sha1", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(sha1Hex(content)))
+		})
+		mux.HandleFunc(upstreamRoot+"/"+packageVersion+"/invalid.jar", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(content))
+		})
+		mux.HandleFunc(upstreamRoot+"/"+packageVersion+"/invalid.jar.sha1", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(sha1Hex("other content")))
+		})
+
+		root := fmt.Sprintf("/api/packages/%s/maven/com/gitea/%s", user.Name, artifactID)
+
+		req := NewRequest(t, "GET", root+"/maven-metadata.xml")
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, metadata, resp.Body.String())
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s.sha1", root, packageVersion, filename))
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, sha1Hex(content), resp.Body.String())
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s", root, packageVersion, filename))
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, content, resp.Body.String())
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/invalid.jar", root, packageVersion))
+		MakeRequest(t, req, http.StatusBadGateway)
+
+		assertRemoteVersion(t, packages_model.TypeMaven, groupID+"-"+artifactID, packageVersion, upstream.URL+"/maven2")
+
+		atomic.StoreInt32(&offline, 1)
+
+		req = NewRequest(t, "GET", root+"/maven-metadata.xml")
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Contains(t, resp.Body.String(), "<version>"+packageVersion+"</version>")
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s", root, packageVersion, filename))
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, content, resp.Body.String())
+	})
+
+	t.Run("Container", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+		defer atomic.StoreInt32(&offline, 0)
+
+		addRemote(t, packages_model.TypeContainer, upstream.URL)
+
+		image := "remote/image"
+		tag := "latest"
+
+		blobContent := "layer content"
+		blobDigest := "sha256:" + sha256Hex(blobContent)
+		configContent := `{"architecture":"amd64","os":"linux","config":{},"rootfs":{"type":"layers","diff_ids":[]}}`
+		configDigest := "sha256:" + sha256Hex(configContent)
+		manifestContent := fmt.Sprintf(`{"schemaVersion":2,"mediaType":"%s","config":{"mediaType":"application/vnd.oci.image.config.v1+json","digest":"%s","size":%d},"layers":[{"mediaType":"application/vnd.oci.image.layer.v1.tar+gzip","digest":"%s","size":%d}]}`, oci.MediaTypeImageManifest, configDigest, len(configContent), blobDigest, len(blobContent))
+		manifestDigest := "sha256:" + sha256Hex(manifestContent)
+		indexContent := fmt.Sprintf(`{"schemaVersion":2,"mediaType":"%s","manifests":[{"mediaType":"%s","digest":"%s","size":%d,"platform":{"os":"linux","architecture":"amd64"}}]}`, oci.MediaTypeImageIndex, oci.MediaTypeImageManifest, manifestDigest, len(manifestContent))
+		indexDigest := "sha256:" + sha256Hex(indexContent)
+
+		// the remote registry requires a token like the Docker Hub
+		upstreamToken := "upstream-token"
+		mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "repository:"+image+":pull", r.URL.Query().Get("scope"))
+			w.Write([]byte(`{"token":"` + upstreamToken + `"}`))
+		})
+		mux.HandleFunc("/v2/"+image+"/", func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer "+upstreamToken {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="upstream",scope="repository:%s:pull"`, upstream.URL, image))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			contents := map[string]struct {
+				ContentType string
+				Content     string
+			}{
+				"manifests/" + tag:            {oci.MediaTypeImageIndex, indexContent},
+				"manifests/" + indexDigest:    {oci.MediaTypeImageIndex, indexContent},
+				"manifests/" + manifestDigest: {oci.MediaTypeImageManifest, manifestContent},
+				"blobs/" + configDigest:       {"application/octet-stream", configContent},
+				"blobs/" + blobDigest:         {"application/octet-stream", blobContent},
+				"tags/list":                   {"application/json", `{"name":"` + image + `","tags":["` + tag + `"]}`},
+			}
+			c, ok := contents[strings.TrimPrefix(r.URL.Path, "/v2/"+image+"/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", c.ContentType)
+			w.Write([]byte(c.Content))
+		})
+
+		req := NewRequest(t, "GET", fmt.Sprintf("%sv2/token", setting.AppURL))
+		resp := MakeRequest(t, req, http.StatusOK)
+		var tokenResponse struct {
+			Token string `json:"token"`
+		}
+		DecodeJSON(t, resp, &tokenResponse)
+		anonymousToken := "Bearer " + tokenResponse.Token
+
+		root := fmt.Sprintf("/v2/%s/%s", user.Name, image)
+
+		req = NewRequest(t, "GET", root+"/tags/list")
+		addTokenAuthHeader(req, anonymousToken)
+		resp = MakeRequest(t, req, http.StatusOK)
+		var tagList struct {
+			Tags []string `json:"tags"`
+		}
+		DecodeJSON(t, resp, &tagList)
+		assert.Equal(t, []string{tag}, tagList.Tags)
+
+		req = NewRequest(t, "GET", root+"/manifests/"+tag)
+		addTokenAuthHeader(req, anonymousToken)
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, indexDigest, resp.Header().Get("Docker-Content-Digest"))
+		assert.Equal(t, oci.MediaTypeImageIndex, resp.Header().Get("Content-Type"))
+		assert.Equal(t, indexContent, resp.Body.String())
+
+		assertRemoteVersion(t, packages_model.TypeContainer, image, tag, upstream.URL)
+		assertRemoteVersion(t, packages_model.TypeContainer, image, manifestDigest, upstream.URL)
+
+		atomic.StoreInt32(&offline, 1)
+
+		req = NewRequest(t, "GET", root+"/tags/list")
+		addTokenAuthHeader(req, anonymousToken)
+		resp = MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &tagList)
+		assert.Equal(t, []string{tag}, tagList.Tags)
+
+		req = NewRequest(t, "GET", root+"/manifests/"+manifestDigest)
+		addTokenAuthHeader(req, anonymousToken)
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, manifestContent, resp.Body.String())
+
+		for digest, content := range map[string]string{configDigest: configContent, blobDigest: blobContent} {
+			req = NewRequest(t, "GET", root+"/blobs/"+digest)
+			addTokenAuthHeader(req, anonymousToken)
+			resp = MakeRequest(t, req, http.StatusOK)
+			assert.Equal(t, content, resp.Body.String())
+		}
+
+		req = NewRequest(t, "GET", root+"/manifests/other")
+		addTokenAuthHeader(req, anonymousToken)
+		MakeRequest(t, req, http.StatusBadGateway)
+	})
+}
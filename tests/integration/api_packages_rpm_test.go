@@ -0,0 +1,489 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	rpm_module "code.gitea.io/gitea/modules/packages/rpm"
+	"code.gitea.io/gitea/modules/setting"
+	packages_cleanup_service "code.gitea.io/gitea/services/packages/cleanup"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/stretchr/testify/assert"
+)
+
+// createRpmPackage creates a minimal RPM package containing only the lead and the headers
+func createRpmPackage(name, version, release, architecture string) []byte {
+	createHeader := func(tags map[int]interface{}) []byte {
+		keys := make([]int, 0, len(tags))
+		for tag := range tags {
+			keys = append(keys, tag)
+		}
+		sort.Ints(keys)
+
+		var index, data bytes.Buffer
+		for _, key := range keys {
+			var typ, count int
+			offset := data.Len()
+			switch v := tags[key].(type) {
+			case string:
+				typ, count = 6, 1
+				data.WriteString(v + "\x00")
+			case []string:
+				typ, count = 8, len(v)
+				for _, s := range v {
+					data.WriteString(s + "\x00")
+				}
+			case []uint32:
+				for data.Len()%4 != 0 {
+					data.WriteByte(0)
+				}
+				offset = data.Len()
+				typ, count = 4, len(v)
+				binary.Write(&data, binary.BigEndian, v)
+			}
+			binary.Write(&index, binary.BigEndian, []uint32{uint32(key), uint32(typ), uint32(offset), uint32(count)})
+		}
+
+		var buf bytes.Buffer
+		buf.Write([]byte{0x8e, 0xad, 0xe8, 0x01, 0, 0, 0, 0})
+		binary.Write(&buf, binary.BigEndian, []uint32{uint32(len(keys)), uint32(data.Len())})
+		buf.Write(index.Bytes())
+		buf.Write(data.Bytes())
+		return buf.Bytes()
+	}
+
+	var buf bytes.Buffer
+	buf.Write([]byte{0xed, 0xab, 0xee, 0xdb})
+	buf.Write(make([]byte, 92))
+	buf.Write(createHeader(map[int]interface{}{1007: []uint32{1024}}))
+	for buf.Len()%8 != 0 {
+		buf.WriteByte(0)
+	}
+	buf.Write(createHeader(map[int]interface{}{
+		1000: name,
+		1001: version,
+		1002: release,
+		1004: "Package Summary",
+		1005: "Package Description",
+		1014: "MIT",
+		1020: "https://gitea.io",
+		1022: architecture,
+		1047: []string{name},
+		1112: []uint32{8},
+		1113: []string{version + "-" + release},
+		1049: []string{"glibc"},
+		1048: []uint32{0},
+		1050: []string{""},
+		1116: []uint32{0},
+		1117: []string{name},
+		1118: []string{"/usr/bin/"},
+		1080: []uint32{1678225964},
+		1081: []string{"KN4CK3R <dummy@gitea.io>"},
+		1082: []string{"- Changelog message."},
+	}))
+	buf.WriteString("payload")
+	return buf.Bytes()
+}
+
+func TestPackageRpm(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+	user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+
+	packageName := "gitea-test"
+	packageVersion := "1.0.2"
+	packageRelease := "1"
+	packageArchitecture := "x86_64"
+
+	content := createRpmPackage(packageName, packageVersion, packageRelease, packageArchitecture)
+	fullVersion := packageVersion + "-" + packageRelease
+
+	rootURL := fmt.Sprintf("/api/packages/%s/rpm", user.Name)
+
+	t.Run("RepositoryConfig", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", rootURL+".repo")
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		expected := fmt.Sprintf(`[gitea-%s]
+name=%s - %s
+baseurl=%sapi/packages/%s/rpm
+enabled=1
+gpgcheck=0
+repo_gpgcheck=1
+gpgkey=%sapi/packages/%s/rpm/repository.key
+`, user.Name, user.Name, setting.AppName, setting.AppURL, user.Name, setting.AppURL, user.Name)
+
+		assert.Equal(t, expected, resp.Body.String())
+	})
+
+	t.Run("RepositoryKey", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", rootURL+"/repository.key")
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		assert.Equal(t, "application/pgp-keys", resp.Header().Get("Content-Type"))
+		assert.Contains(t, resp.Body.String(), "-----BEGIN PGP PUBLIC KEY BLOCK-----")
+	})
+
+	t.Run("Upload", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		url := rootURL + "/upload"
+
+		req := NewRequestWithBody(t, "PUT", url, bytes.NewReader(content))
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequestWithBody(t, "PUT", url, bytes.NewReader([]byte("dummy")))
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusBadRequest)
+
+		req = NewRequestWithBody(t, "PUT", url, bytes.NewReader(content))
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusCreated)
+
+		pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeRpm)
+		assert.NoError(t, err)
+		assert.Len(t, pvs, 1)
+
+		pd, err := packages.GetPackageDescriptor(db.DefaultContext, pvs[0])
+		assert.NoError(t, err)
+		assert.Nil(t, pd.SemVer)
+		assert.IsType(t, &rpm_module.VersionMetadata{}, pd.Metadata)
+		assert.Equal(t, packageName, pd.Package.Name)
+		assert.Equal(t, fullVersion, pd.Version.Version)
+
+		pfs, err := packages.GetFilesByVersionID(db.DefaultContext, pvs[0].ID)
+		assert.NoError(t, err)
+		assert.Len(t, pfs, 1)
+		assert.Equal(t, fmt.Sprintf("%s-%s.%s.rpm", packageName, fullVersion, packageArchitecture), pfs[0].Name)
+		assert.True(t, pfs[0].IsLead)
+
+		pb, err := packages.GetBlobByID(db.DefaultContext, pfs[0].BlobID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(len(content)), pb.Size)
+
+		req = NewRequestWithBody(t, "PUT", url, bytes.NewReader(content))
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusConflict)
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", fmt.Sprintf("%s/package/%s/%s/%s", rootURL, packageName, fullVersion, packageArchitecture))
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		assert.Equal(t, content, resp.Body.Bytes())
+	})
+
+	t.Run("Repository", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		url := rootURL + "/repodata"
+
+		req := NewRequest(t, "GET", url+"/dummy.xml")
+		MakeRequest(t, req, http.StatusNotFound)
+
+		t.Run("repomd.xml", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			req = NewRequest(t, "GET", url+"/repomd.xml")
+			resp := MakeRequest(t, req, http.StatusOK)
+
+			type Repomd struct {
+				XMLName xml.Name `xml:"repomd"`
+				Data    []struct {
+					Type     string `xml:"type,attr"`
+					Checksum struct {
+						Value string `xml:",chardata"`
+						Type  string `xml:"type,attr"`
+					} `xml:"checksum"`
+					Location struct {
+						Href string `xml:"href,attr"`
+					} `xml:"location"`
+					Size int64 `xml:"size"`
+				} `xml:"data"`
+			}
+
+			var result Repomd
+			decodeXML(t, resp, &result)
+
+			assert.Len(t, result.Data, 3)
+			for _, d := range result.Data {
+				assert.Equal(t, "sha256", d.Checksum.Type)
+				assert.NotEmpty(t, d.Checksum.Value)
+				assert.Positive(t, d.Size)
+
+				switch d.Type {
+				case "primary":
+					assert.Equal(t, "repodata/primary.xml.gz", d.Location.Href)
+				case "filelists":
+					assert.Equal(t, "repodata/filelists.xml.gz", d.Location.Href)
+				case "other":
+					assert.Equal(t, "repodata/other.xml.gz", d.Location.Href)
+				default:
+					assert.Fail(t, "unexpected data type "+d.Type)
+				}
+
+				req = NewRequest(t, "GET", rootURL+"/"+d.Location.Href)
+				data := MakeRequest(t, req, http.StatusOK).Body.Bytes()
+				sum := sha256.Sum256(data)
+				assert.Equal(t, hex.EncodeToString(sum[:]), d.Checksum.Value)
+				assert.EqualValues(t, len(data), d.Size)
+			}
+		})
+
+		t.Run("repomd.xml.asc", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			req = NewRequest(t, "GET", url+"/repomd.xml")
+			repomd := MakeRequest(t, req, http.StatusOK).Body.String()
+
+			req = NewRequest(t, "GET", url+"/repomd.xml.asc")
+			signature := MakeRequest(t, req, http.StatusOK).Body.String()
+
+			req = NewRequest(t, "GET", rootURL+"/repository.key")
+			key := MakeRequest(t, req, http.StatusOK).Body.String()
+
+			keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(key))
+			assert.NoError(t, err)
+
+			_, err = openpgp.CheckArmoredDetachedSignature(keyring, strings.NewReader(repomd), strings.NewReader(signature), nil)
+			assert.NoError(t, err)
+		})
+
+		decodeGzipXML := func(t testing.TB, resp *httptest.ResponseRecorder, v interface{}) {
+			t.Helper()
+
+			zr, err := gzip.NewReader(resp.Body)
+			assert.NoError(t, err)
+
+			assert.NoError(t, xml.NewDecoder(zr).Decode(v))
+		}
+
+		t.Run("primary.xml.gz", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			req = NewRequest(t, "GET", url+"/primary.xml.gz")
+			resp := MakeRequest(t, req, http.StatusOK)
+
+			type EntryList struct {
+				Entries []*rpm_module.Entry `xml:"entry"`
+			}
+
+			type Metadata struct {
+				XMLName      xml.Name `xml:"metadata"`
+				PackageCount int      `xml:"packages,attr"`
+				Packages     []struct {
+					Name         string `xml:"name"`
+					Architecture string `xml:"arch"`
+					Version      struct {
+						Epoch   string `xml:"epoch,attr"`
+						Version string `xml:"ver,attr"`
+						Release string `xml:"rel,attr"`
+					} `xml:"version"`
+					Checksum struct {
+						Checksum string `xml:",chardata"`
+						Type     string `xml:"type,attr"`
+					} `xml:"checksum"`
+					Summary  string `xml:"summary"`
+					URL      string `xml:"url"`
+					Location struct {
+						Href string `xml:"href,attr"`
+					} `xml:"location"`
+					Format struct {
+						License  string             `xml:"license"`
+						Provides EntryList          `xml:"provides"`
+						Requires EntryList          `xml:"requires"`
+						Files    []*rpm_module.File `xml:"file"`
+					} `xml:"format"`
+				} `xml:"package"`
+			}
+
+			var result Metadata
+			decodeGzipXML(t, resp, &result)
+
+			assert.EqualValues(t, 1, result.PackageCount)
+			assert.Len(t, result.Packages, 1)
+			p := result.Packages[0]
+			assert.Equal(t, packageName, p.Name)
+			assert.Equal(t, packageArchitecture, p.Architecture)
+			assert.Equal(t, "0", p.Version.Epoch)
+			assert.Equal(t, packageVersion, p.Version.Version)
+			assert.Equal(t, packageRelease, p.Version.Release)
+			assert.Equal(t, "sha256", p.Checksum.Type)
+			assert.Len(t, p.Checksum.Checksum, 64)
+			assert.Equal(t, "Package Summary", p.Summary)
+			assert.Equal(t, "https://gitea.io", p.URL)
+			assert.Equal(t, fmt.Sprintf("package/%s/%s/%s", packageName, fullVersion, packageArchitecture), p.Location.Href)
+			assert.Equal(t, "MIT", p.Format.License)
+			assert.Len(t, p.Format.Provides.Entries, 1)
+			assert.Equal(t, packageName, p.Format.Provides.Entries[0].Name)
+			assert.Equal(t, "EQ", p.Format.Provides.Entries[0].Flags)
+			assert.Len(t, p.Format.Requires.Entries, 1)
+			assert.Equal(t, "glibc", p.Format.Requires.Entries[0].Name)
+			assert.Len(t, p.Format.Files, 1)
+			assert.Equal(t, "/usr/bin/"+packageName, p.Format.Files[0].Path)
+		})
+
+		t.Run("filelists.xml.gz", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			req = NewRequest(t, "GET", url+"/filelists.xml.gz")
+			resp := MakeRequest(t, req, http.StatusOK)
+
+			type Filelists struct {
+				XMLName      xml.Name `xml:"filelists"`
+				PackageCount int      `xml:"packages,attr"`
+				Packages     []struct {
+					Pkgid string             `xml:"pkgid,attr"`
+					Name  string             `xml:"name,attr"`
+					Files []*rpm_module.File `xml:"file"`
+				} `xml:"package"`
+			}
+
+			var result Filelists
+			decodeGzipXML(t, resp, &result)
+
+			assert.EqualValues(t, 1, result.PackageCount)
+			assert.Len(t, result.Packages, 1)
+			assert.Equal(t, packageName, result.Packages[0].Name)
+			assert.Len(t, result.Packages[0].Pkgid, 64)
+			assert.Len(t, result.Packages[0].Files, 1)
+		})
+
+		t.Run("other.xml.gz", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			req = NewRequest(t, "GET", url+"/other.xml.gz")
+			resp := MakeRequest(t, req, http.StatusOK)
+
+			type Other struct {
+				XMLName      xml.Name `xml:"otherdata"`
+				PackageCount int      `xml:"packages,attr"`
+				Packages     []struct {
+					Name       string                  `xml:"name,attr"`
+					Changelogs []*rpm_module.Changelog `xml:"changelog"`
+				} `xml:"package"`
+			}
+
+			var result Other
+			decodeGzipXML(t, resp, &result)
+
+			assert.EqualValues(t, 1, result.PackageCount)
+			assert.Len(t, result.Packages, 1)
+			assert.Equal(t, packageName, result.Packages[0].Name)
+			assert.Len(t, result.Packages[0].Changelogs, 1)
+			assert.Equal(t, "- Changelog message.", result.Packages[0].Changelogs[0].Text)
+		})
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		oldVersion := "0.9.0"
+
+		req := NewRequestWithBody(t, "PUT", rootURL+"/upload", bytes.NewReader(createRpmPackage(packageName, oldVersion, packageRelease, packageArchitecture)))
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusCreated)
+
+		getPrimaryVersions := func(t *testing.T) []string {
+			t.Helper()
+
+			req := NewRequest(t, "GET", rootURL+"/repodata/primary.xml.gz")
+			resp := MakeRequest(t, req, http.StatusOK)
+
+			zr, err := gzip.NewReader(resp.Body)
+			assert.NoError(t, err)
+
+			var result struct {
+				Packages []struct {
+					Version struct {
+						Version string `xml:"ver,attr"`
+					} `xml:"version"`
+				} `xml:"package"`
+			}
+			assert.NoError(t, xml.NewDecoder(zr).Decode(&result))
+
+			versions := make([]string, 0, len(result.Packages))
+			for _, p := range result.Packages {
+				versions = append(versions, p.Version.Version)
+			}
+			sort.Strings(versions)
+			return versions
+		}
+
+		assert.Equal(t, []string{oldVersion, packageVersion}, getPrimaryVersions(t))
+
+		pv, err := packages.GetVersionByNameAndVersion(db.DefaultContext, user.ID, packages.TypeRpm, packageName, oldVersion+"-"+packageRelease)
+		assert.NoError(t, err)
+		_, err = db.GetEngine(db.DefaultContext).Exec("UPDATE package_version SET created_unix = ? WHERE id = ?", 1, pv.ID)
+		assert.NoError(t, err)
+
+		pcr, err := packages.InsertCleanupRule(db.DefaultContext, &packages.PackageCleanupRule{
+			Enabled:       true,
+			OwnerID:       user.ID,
+			Type:          packages.TypeRpm,
+			RemoveDays:    7,
+			RemovePattern: regexp.QuoteMeta(oldVersion) + ".*",
+		})
+		assert.NoError(t, err)
+		defer func() {
+			assert.NoError(t, packages.DeleteCleanupRuleByID(db.DefaultContext, pcr.ID))
+		}()
+
+		assert.NoError(t, packages_cleanup_service.Cleanup(db.DefaultContext, time.Hour))
+
+		_, err = packages.GetVersionByNameAndVersion(db.DefaultContext, user.ID, packages.TypeRpm, packageName, oldVersion+"-"+packageRelease)
+		assert.ErrorIs(t, err, packages.ErrPackageNotExist)
+
+		assert.Equal(t, []string{packageVersion}, getPrimaryVersions(t))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		url := fmt.Sprintf("%s/package/%s/%s/%s", rootURL, packageName, fullVersion, packageArchitecture)
+
+		req := NewRequest(t, "DELETE", url)
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequest(t, "DELETE", url)
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusNoContent)
+
+		pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeRpm)
+		assert.NoError(t, err)
+		assert.Empty(t, pvs)
+
+		req = NewRequest(t, "DELETE", url)
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusNotFound)
+
+		req = NewRequest(t, "GET", rootURL+"/repodata/repomd.xml")
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+}
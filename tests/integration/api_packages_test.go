@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"fmt"
 	"net/http"
+	"strconv"
 	"testing"
 	"time"
 
@@ -14,11 +15,13 @@ import (
 	"code.gitea.io/gitea/models/db"
 	packages_model "code.gitea.io/gitea/models/packages"
 	container_model "code.gitea.io/gitea/models/packages/container"
+	repo_model "code.gitea.io/gitea/models/repo"
 	"code.gitea.io/gitea/models/unittest"
 	user_model "code.gitea.io/gitea/models/user"
 	"code.gitea.io/gitea/modules/setting"
 	api "code.gitea.io/gitea/modules/structs"
 	packages_service "code.gitea.io/gitea/services/packages"
+	packages_cleanup_service "code.gitea.io/gitea/services/packages/cleanup"
 	"code.gitea.io/gitea/tests"
 
 	"github.com/stretchr/testify/assert"
@@ -147,6 +150,111 @@ func TestPackageAPI(t *testing.T) {
 	})
 }
 
+func TestPackageProvenance(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+	repo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 1})
+	session := loginUser(t, user.Name)
+	tokenReadPackage := getTokenForLoggedInUser(t, session, auth_model.AccessTokenScopeReadPackage)
+	tokenWritePackage := getTokenForLoggedInUser(t, session, auth_model.AccessTokenScopeWritePackage)
+
+	packageName := "test-package"
+	packageVersion := "1.0.3"
+	commitSHA := "65f1bf27bc3bf70f64657658635e66094edbcb4d"
+
+	url := fmt.Sprintf("/api/packages/%s/generic/%s/%s/file.bin", user.Name, packageName, packageVersion)
+	req := NewRequestWithBody(t, "PUT", url, bytes.NewReader([]byte{}))
+	AddBasicAuthHeader(req, user.Name)
+	MakeRequest(t, req, http.StatusCreated)
+
+	url = fmt.Sprintf("/api/v1/packages/%s/generic/%s/%s", user.Name, packageName, packageVersion)
+
+	t.Run("Set", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequestWithJSON(t, "PUT", fmt.Sprintf("%s/provenance?token=%s", url, tokenReadPackage), &api.SetPackageProvenanceOption{Repository: repo.Name, CommitSHA: commitSHA})
+		MakeRequest(t, req, http.StatusForbidden)
+
+		cases := []struct {
+			Option         api.SetPackageProvenanceOption
+			ExpectedStatus int
+		}{
+			{api.SetPackageProvenanceOption{Repository: "dummy", CommitSHA: commitSHA}, http.StatusUnprocessableEntity},
+			{api.SetPackageProvenanceOption{Repository: repo.Name, CommitSHA: "main"}, http.StatusUnprocessableEntity},
+			{api.SetPackageProvenanceOption{Repository: repo.Name, CommitSHA: "0000000000000000000000000000000000000000"}, http.StatusUnprocessableEntity},
+			{api.SetPackageProvenanceOption{Repository: repo.Name, CommitSHA: commitSHA, Release: "v9.9"}, http.StatusUnprocessableEntity},
+			{api.SetPackageProvenanceOption{Repository: repo.Name, CommitSHA: commitSHA[:10], Release: "v1.1"}, http.StatusOK},
+		}
+
+		for _, c := range cases {
+			req := NewRequestWithJSON(t, "PUT", fmt.Sprintf("%s/provenance?token=%s", url, tokenWritePackage), &c.Option)
+			resp := MakeRequest(t, req, c.ExpectedStatus)
+
+			if c.ExpectedStatus == http.StatusOK {
+				var p *api.Package
+				DecodeJSON(t, resp, &p)
+
+				assert.NotNil(t, p.Provenance)
+				assert.Equal(t, repo.ID, p.Provenance.Repository.ID)
+				assert.Equal(t, commitSHA, p.Provenance.CommitSHA)
+				assert.Equal(t, "v1.1", p.Provenance.Release)
+			}
+		}
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", fmt.Sprintf("%s?token=%s", url, tokenReadPackage))
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var p *api.Package
+		DecodeJSON(t, resp, &p)
+		assert.NotNil(t, p.Provenance)
+		assert.Equal(t, commitSHA, p.Provenance.CommitSHA)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("/%s/-/packages/generic/%s/%s", user.Name, packageName, packageVersion))
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Contains(t, resp.Body.String(), fmt.Sprintf("%s/commit/%s", repo.Link(), commitSHA))
+	})
+
+	t.Run("RepositoryPackages", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		// the package is not linked to the repository
+		req := NewRequest(t, "GET", repo.Link()+"/packages")
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.NotContains(t, resp.Body.String(), packageName)
+
+		req = NewRequest(t, "GET", repo.Link()+"/packages?source=built")
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Contains(t, resp.Body.String(), fmt.Sprintf("/%s/-/packages/generic/%s/%s", user.Name, packageName, packageVersion))
+
+		pvs, _, err := packages_model.SearchVersions(db.DefaultContext, &packages_model.PackageSearchOptions{
+			Properties: map[string]string{
+				packages_model.PropertyProvenanceRepository: strconv.FormatInt(repo.ID, 10),
+			},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, pvs, 1)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "DELETE", fmt.Sprintf("%s/provenance?token=%s", url, tokenWritePackage))
+		MakeRequest(t, req, http.StatusNoContent)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s?token=%s", url, tokenReadPackage))
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var p *api.Package
+		DecodeJSON(t, resp, &p)
+		assert.Nil(t, p.Provenance)
+	})
+}
+
 func TestPackageAccess(t *testing.T) {
 	defer tests.PrepareTestEnv(t)()
 
@@ -216,7 +324,7 @@ func TestPackageCleanup(t *testing.T) {
 		_, err = packages_model.GetInternalVersionByNameAndVersion(db.DefaultContext, 2, packages_model.TypeContainer, "test", container_model.UploadVersion)
 		assert.NoError(t, err)
 
-		err = packages_service.Cleanup(db.DefaultContext, duration)
+		err = packages_cleanup_service.Cleanup(db.DefaultContext, duration)
 		assert.NoError(t, err)
 
 		pbs, err = packages_model.FindExpiredUnreferencedBlobs(db.DefaultContext, duration)
@@ -353,7 +461,7 @@ func TestPackageCleanup(t *testing.T) {
 				pcr, err := packages_model.InsertCleanupRule(db.DefaultContext, c.Rule)
 				assert.NoError(t, err)
 
-				err = packages_service.Cleanup(db.DefaultContext, duration)
+				err = packages_cleanup_service.Cleanup(db.DefaultContext, duration)
 				assert.NoError(t, err)
 
 				for _, v := range c.Versions {
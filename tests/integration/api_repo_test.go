@@ -16,6 +16,7 @@ import (
 	repo_model "code.gitea.io/gitea/models/repo"
 	"code.gitea.io/gitea/models/unittest"
 	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/setting"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/tests"
@@ -447,6 +448,44 @@ func TestAPIOrgRepoCreate(t *testing.T) {
 	}
 }
 
+func TestAPIRepoCreateSHA256(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+	if !git.SupportSHA256 {
+		t.Skip("SHA-256 repositories are not supported")
+	}
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session, auth_model.AccessTokenScopeRepo)
+
+	req := NewRequestWithJSON(t, "POST", "/api/v1/user/repos?token="+token, &api.CreateRepoOption{
+		Name:             "repo-sha256",
+		AutoInit:         true,
+		Readme:           "Default",
+		ObjectFormatName: "sha256",
+	})
+	resp := MakeRequest(t, req, http.StatusCreated)
+	var repo api.Repository
+	DecodeJSON(t, resp, &repo)
+	assert.EqualValues(t, "sha256", repo.ObjectFormatName)
+
+	req = NewRequestf(t, "GET", "/api/v1/repos/user2/repo-sha256/commits?token=%s", token)
+	resp = MakeRequest(t, req, http.StatusOK)
+	var commits []*api.Commit
+	DecodeJSON(t, resp, &commits)
+	if assert.Len(t, commits, 1) {
+		assert.Len(t, commits[0].SHA, git.SHA256FullLength)
+
+		req = NewRequestf(t, "GET", "/user2/repo-sha256/commit/%s", commits[0].SHA)
+		MakeRequest(t, req, http.StatusOK)
+	}
+
+	req = NewRequestWithJSON(t, "POST", "/api/v1/user/repos?token="+token, &api.CreateRepoOption{
+		Name:             "repo-sha512",
+		ObjectFormatName: "sha512",
+	})
+	MakeRequest(t, req, http.StatusUnprocessableEntity)
+}
+
 func TestAPIRepoCreateConflict(t *testing.T) {
 	onGiteaRun(t, testAPIRepoCreateConflict)
 }
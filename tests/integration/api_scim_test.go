@@ -0,0 +1,282 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/organization"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/scim"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+	"xorm.io/builder"
+)
+
+func TestAPISCIM(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	adminToken := getUserToken(t, "user1", auth_model.AccessTokenScopeSudo)
+	userToken := getUserToken(t, "user2", auth_model.AccessTokenScopeSudo)
+
+	newRequest := func(t *testing.T, method, url string, body interface{}, token string) *http.Request {
+		var req *http.Request
+		if body != nil {
+			req = NewRequestWithJSON(t, method, "/api/scim/v2"+url, body)
+		} else {
+			req = NewRequest(t, method, "/api/scim/v2"+url)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		return req
+	}
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		MakeRequest(t, newRequest(t, "GET", "/Users", nil, ""), http.StatusUnauthorized)
+		MakeRequest(t, newRequest(t, "GET", "/Users", nil, userToken), http.StatusForbidden)
+		MakeRequest(t, newRequest(t, "GET", "/Users", nil, getUserToken(t, "user1", auth_model.AccessTokenScopeAdminOrg)), http.StatusForbidden)
+	})
+
+	t.Run("ServiceProviderConfig", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		resp := MakeRequest(t, newRequest(t, "GET", "/ServiceProviderConfig", nil, adminToken), http.StatusOK)
+		assert.Equal(t, scim.ContentType+";charset=utf-8", resp.Header().Get("Content-Type"))
+		assert.Contains(t, resp.Body.String(), scim.SchemaServiceProviderConfig)
+	})
+
+	var userID string
+	t.Run("Users", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		active := true
+		resp := MakeRequest(t, newRequest(t, "POST", "/Users", &scim.User{
+			Schemas:    []string{scim.SchemaUser},
+			ExternalID: "ext-jdoe",
+			UserName:   "jdoe@example.com",
+			Name:       &scim.Name{GivenName: "John", FamilyName: "Doe"},
+			Emails:     []scim.MultiValued{{Value: "jdoe@example.com", Primary: true}},
+			Active:     &active,
+		}, adminToken), http.StatusCreated)
+		su := &scim.User{}
+		DecodeJSON(t, resp, su)
+		userID = su.ID
+		assert.Equal(t, setting.AppURL+"api/scim/v2/Users/"+userID, resp.Header().Get("Location"))
+		assert.Equal(t, "jdoe@example.com", su.UserName)
+		assert.Equal(t, "ext-jdoe", su.ExternalID)
+		assert.True(t, su.IsActive())
+
+		id, _ := strconv.ParseInt(userID, 10, 64)
+		u := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: id})
+		assert.Equal(t, "jdoe", u.Name)
+		assert.Equal(t, "John Doe", u.FullName)
+		assert.Equal(t, "jdoe@example.com", u.LoginName)
+
+		MakeRequest(t, newRequest(t, "POST", "/Users", &scim.User{UserName: "jdoe@example.com", Emails: su.Emails}, adminToken), http.StatusConflict)
+
+		resp = MakeRequest(t, newRequest(t, "GET", "/Users?filter="+`userName+eq+"JDOE@example.com"`, nil, adminToken), http.StatusOK)
+		list := &struct {
+			TotalResults int
+			Resources    []*scim.User
+		}{}
+		DecodeJSON(t, resp, list)
+		assert.Equal(t, 1, list.TotalResults)
+		if assert.Len(t, list.Resources, 1) {
+			assert.Equal(t, userID, list.Resources[0].ID)
+		}
+
+		resp = MakeRequest(t, newRequest(t, "GET", "/Users?filter="+`externalId+eq+"ext-jdoe"`, nil, adminToken), http.StatusOK)
+		DecodeJSON(t, resp, list)
+		assert.Equal(t, 1, list.TotalResults)
+		if assert.Len(t, list.Resources, 1) {
+			assert.Equal(t, userID, list.Resources[0].ID)
+		}
+
+		resp = MakeRequest(t, newRequest(t, "GET", "/Users?filter="+`externalId+eq+"ext-unknown"`, nil, adminToken), http.StatusOK)
+		DecodeJSON(t, resp, list)
+		assert.Equal(t, 0, list.TotalResults)
+		assert.Empty(t, list.Resources)
+
+		MakeRequest(t, newRequest(t, "GET", "/Users?filter=userName+eq", nil, adminToken), http.StatusBadRequest)
+		// filters which can't be resolved by the database are not supported
+		MakeRequest(t, newRequest(t, "GET", "/Users?filter="+`userName+co+"doe"`, nil, adminToken), http.StatusBadRequest)
+
+		total := int(unittest.GetCountByCond(t, "user", builder.Eq{"type": user_model.UserTypeIndividual}))
+		resp = MakeRequest(t, newRequest(t, "GET", "/Users?startIndex=2&count=1", nil, adminToken), http.StatusOK)
+		DecodeJSON(t, resp, list)
+		assert.Equal(t, total, list.TotalResults)
+		if assert.Len(t, list.Resources, 1) {
+			assert.Equal(t, "2", list.Resources[0].ID)
+		}
+
+		resp = MakeRequest(t, newRequest(t, "GET", "/Users?count=0", nil, adminToken), http.StatusOK)
+		DecodeJSON(t, resp, list)
+		assert.Equal(t, total, list.TotalResults)
+		assert.Empty(t, list.Resources)
+
+		token := &auth_model.AccessToken{UID: id, Name: "scim-test", Scope: auth_model.AccessTokenScopeAll}
+		assert.NoError(t, auth_model.NewAccessToken(token))
+		MakeRequest(t, NewRequest(t, "GET", "/api/v1/user?token="+token.Token), http.StatusOK)
+
+		// the user is locked out as soon as it is deactivated
+		MakeRequest(t, newRequest(t, "PATCH", "/Users/"+userID, &scim.PatchRequest{
+			Schemas: []string{scim.SchemaPatchOp},
+			Operations: []scim.PatchOperation{
+				{Op: "Replace", Path: "active", Value: "False"},
+				{Op: "replace", Path: "name.formatted", Value: "Johnny Doe"},
+			},
+		}, adminToken), http.StatusOK)
+		u = unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: id})
+		assert.True(t, u.ProhibitLogin)
+		assert.False(t, u.IsActive)
+		assert.Equal(t, "Johnny Doe", u.FullName)
+		MakeRequest(t, NewRequest(t, "GET", "/api/v1/user?token="+token.Token), http.StatusUnauthorized)
+		unittest.AssertNotExistsBean(t, &auth_model.AccessToken{ID: token.ID})
+
+		active = true
+		resp = MakeRequest(t, newRequest(t, "PUT", "/Users/"+userID, &scim.User{
+			UserName: "john.doe@example.com",
+			Emails:   []scim.MultiValued{{Value: "john.doe@example.com", Primary: true}},
+			Active:   &active,
+		}, adminToken), http.StatusOK)
+		su = &scim.User{}
+		DecodeJSON(t, resp, su)
+		assert.Equal(t, "john.doe@example.com", su.UserName)
+		assert.Empty(t, su.ExternalID)
+		u = unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: id})
+		assert.False(t, u.ProhibitLogin)
+		assert.Equal(t, "jdoe", u.Name)
+		assert.Equal(t, "john.doe@example.com", u.Email)
+
+		MakeRequest(t, newRequest(t, "PATCH", "/Users/1", &scim.PatchRequest{
+			Operations: []scim.PatchOperation{{Op: "replace", Path: "active", Value: false}},
+		}, adminToken), http.StatusBadRequest)
+		MakeRequest(t, newRequest(t, "GET", "/Users/3", nil, adminToken), http.StatusNotFound)
+	})
+
+	t.Run("Groups", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		MakeRequest(t, newRequest(t, "GET", "/Groups", nil, adminToken), http.StatusNotFound)
+
+		defer func(org string) {
+			setting.SCIM.Organization = org
+		}(setting.SCIM.Organization)
+		setting.SCIM.Organization = "user3"
+		org := unittest.AssertExistsAndLoadBean(t, &user_model.User{Name: "user3"})
+
+		resp := MakeRequest(t, newRequest(t, "POST", "/Groups", &scim.Group{
+			Schemas:     []string{scim.SchemaGroup},
+			ExternalID:  "ext-engineering",
+			DisplayName: "Engineering Team",
+			Members:     []scim.MultiValued{{Value: userID}},
+		}, adminToken), http.StatusCreated)
+		sg := &scim.Group{}
+		DecodeJSON(t, resp, sg)
+		assert.Equal(t, "Engineering-Team", sg.DisplayName)
+		assert.Len(t, sg.Members, 1)
+
+		id, _ := strconv.ParseInt(sg.ID, 10, 64)
+		team := unittest.AssertExistsAndLoadBean(t, &organization.Team{ID: id, OrgID: org.ID, Name: "Engineering-Team"})
+		uid, _ := strconv.ParseInt(userID, 10, 64)
+		unittest.AssertExistsAndLoadBean(t, &organization.TeamUser{TeamID: team.ID, UID: uid})
+
+		resp = MakeRequest(t, newRequest(t, "GET", "/Groups?excludedAttributes=members&filter="+`displayName+eq+"engineering-team"`, nil, adminToken), http.StatusOK)
+		list := &struct {
+			TotalResults int
+			Resources    []*scim.Group
+		}{}
+		DecodeJSON(t, resp, list)
+		assert.Equal(t, 1, list.TotalResults)
+		if assert.Len(t, list.Resources, 1) {
+			assert.Empty(t, list.Resources[0].Members)
+		}
+
+		resp = MakeRequest(t, newRequest(t, "GET", "/Groups?filter="+`externalId+eq+"ext-engineering"`, nil, adminToken), http.StatusOK)
+		DecodeJSON(t, resp, list)
+		assert.Equal(t, 1, list.TotalResults)
+		if assert.Len(t, list.Resources, 1) {
+			assert.Equal(t, sg.ID, list.Resources[0].ID)
+			assert.Len(t, list.Resources[0].Members, 1)
+		}
+
+		// filters which can't be resolved by the database are not supported
+		MakeRequest(t, newRequest(t, "GET", "/Groups?filter="+`displayName+co+"engineering"`, nil, adminToken), http.StatusBadRequest)
+
+		// the owners are not listed
+		total := int(unittest.GetCountByCond(t, "team", builder.Eq{"org_id": org.ID})) - 1
+		resp = MakeRequest(t, newRequest(t, "GET", "/Groups?startIndex=2&count=1", nil, adminToken), http.StatusOK)
+		DecodeJSON(t, resp, list)
+		assert.Equal(t, total, list.TotalResults)
+		assert.Len(t, list.Resources, 1)
+
+		MakeRequest(t, newRequest(t, "PATCH", "/Groups/"+sg.ID, &scim.PatchRequest{
+			Schemas: []string{scim.SchemaPatchOp},
+			Operations: []scim.PatchOperation{
+				{Op: "add", Path: "members", Value: []interface{}{map[string]interface{}{"value": "2"}}},
+				{Op: "remove", Path: "members", Value: []interface{}{map[string]interface{}{"value": userID}}},
+			},
+		}, adminToken), http.StatusOK)
+		unittest.AssertNotExistsBean(t, &organization.TeamUser{TeamID: team.ID, UID: uid})
+		unittest.AssertExistsAndLoadBean(t, &organization.TeamUser{TeamID: team.ID, UID: 2})
+
+		MakeRequest(t, newRequest(t, "PATCH", "/Groups/"+sg.ID, &scim.PatchRequest{
+			Operations: []scim.PatchOperation{
+				{Op: "replace", Path: "displayName", Value: "Renamed"},
+				{Op: "add", Path: "members", Value: map[string]interface{}{"value": "3"}},
+			},
+		}, adminToken), http.StatusBadRequest)
+		// nothing is changed if a member is invalid
+		unittest.AssertExistsAndLoadBean(t, &organization.Team{ID: team.ID, Name: "Engineering-Team"})
+
+		// a group with an invalid member is not created, so it can be created once the member is fixed
+		MakeRequest(t, newRequest(t, "POST", "/Groups", &scim.Group{
+			DisplayName: "Sales",
+			Members:     []scim.MultiValued{{Value: "3"}},
+		}, adminToken), http.StatusBadRequest)
+		unittest.AssertNotExistsBean(t, &organization.Team{OrgID: org.ID, LowerName: "sales"})
+
+		// the owners are not managed by SCIM
+		owners, err := organization.GetOwnerTeam(db.DefaultContext, org.ID)
+		assert.NoError(t, err)
+		MakeRequest(t, newRequest(t, "DELETE", fmt.Sprintf("/Groups/%d", owners.ID), nil, adminToken), http.StatusNotFound)
+
+		MakeRequest(t, newRequest(t, "DELETE", "/Groups/"+sg.ID, nil, adminToken), http.StatusNoContent)
+		unittest.AssertNotExistsBean(t, &organization.Team{ID: team.ID})
+		unittest.AssertNotExistsBean(t, &auth_model.SCIMExternalID{Type: auth_model.SCIMResourceGroup, ResourceID: team.ID})
+	})
+
+	t.Run("DeleteUser", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		MakeRequest(t, newRequest(t, "DELETE", "/Users/"+userID, nil, adminToken), http.StatusNoContent)
+		MakeRequest(t, newRequest(t, "GET", "/Users/"+userID, nil, adminToken), http.StatusNotFound)
+		MakeRequest(t, newRequest(t, "DELETE", "/Users/1", nil, adminToken), http.StatusBadRequest)
+
+		assert.NoError(t, db.Insert(db.DefaultContext, &auth_model.OAuth2Grant{UserID: 2, ApplicationID: 1, Scope: "openid"}))
+		MakeRequest(t, NewRequest(t, "GET", "/api/v1/user?token="+userToken), http.StatusOK)
+
+		// users owning repositories are deactivated instead
+		MakeRequest(t, newRequest(t, "DELETE", "/Users/2", nil, adminToken), http.StatusNoContent)
+		user2 := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+		assert.False(t, user2.IsActive)
+		assert.True(t, user2.ProhibitLogin)
+
+		// the tokens and grants created before are revoked
+		MakeRequest(t, NewRequest(t, "GET", "/api/v1/user?token="+userToken), http.StatusUnauthorized)
+		unittest.AssertNotExistsBean(t, &auth_model.AccessToken{UID: 2})
+		unittest.AssertNotExistsBean(t, &auth_model.OAuth2Grant{UserID: 2})
+	})
+}
@@ -0,0 +1,139 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/lfs"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createFineGrainedToken(t *testing.T, options map[string]interface{}, expectedStatus int) *api.AccessToken {
+	req := NewRequestWithJSON(t, "POST", "/api/v1/users/user2/tokens", options)
+	req = AddBasicAuthHeader(req, "user2")
+	resp := MakeRequest(t, req, expectedStatus)
+	if expectedStatus != http.StatusCreated {
+		return nil
+	}
+	token := new(api.AccessToken)
+	DecodeJSON(t, resp, token)
+	return token
+}
+
+func TestAPIFineGrainedToken(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	expiresAt := time.Now().Add(time.Hour)
+	token := createFineGrainedToken(t, map[string]interface{}{
+		"name":           "fine-grained",
+		"resource_owner": "user2",
+		"repositories":   []string{"repo1"},
+		"permissions":    map[string]string{"code": "read", "issues": "write"},
+		"expires_at":     expiresAt,
+	}, http.StatusCreated)
+	assert.EqualValues(t, 2, token.ResourceOwnerID)
+	assert.Equal(t, []int64{1}, token.RepositoryIDs)
+	assert.Equal(t, map[string]string{"code": "read", "issues": "write"}, token.Permissions)
+	assert.Equal(t, expiresAt.Unix(), token.ExpiresAt.Unix())
+
+	// only the repository the token is restricted to is visible
+	MakeRequest(t, NewRequest(t, "GET", "/api/v1/repos/user2/repo1?token="+token.Token), http.StatusOK)
+	MakeRequest(t, NewRequest(t, "GET", "/api/v1/repos/user2/repo2?token="+token.Token), http.StatusNotFound)
+	MakeRequest(t, NewRequest(t, "GET", "/api/v1/repos/user3/repo3?token="+token.Token), http.StatusNotFound)
+
+	// routes outside of the repositories are denied
+	MakeRequest(t, NewRequest(t, "GET", "/api/v1/user/repos?token="+token.Token), http.StatusForbidden)
+
+	// the units are limited to the granted access modes
+	req := NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/issues?token="+token.Token, &api.CreateIssueOption{
+		Title: "created with a fine-grained token",
+	})
+	MakeRequest(t, req, http.StatusCreated)
+	req = NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/releases?token="+token.Token, &api.CreateReleaseOption{
+		TagName: "v100",
+	})
+	MakeRequest(t, req, http.StatusForbidden)
+	req = NewRequestWithJSON(t, "PATCH", "/api/v1/repos/user2/repo1?token="+token.Token, &api.EditRepoOption{})
+	MakeRequest(t, req, http.StatusForbidden)
+
+	// expired tokens are rejected
+	_, err := db.GetEngine(db.DefaultContext).ID(token.ID).Cols("expires_unix").
+		Update(&auth_model.AccessToken{ExpiresUnix: 1})
+	assert.NoError(t, err)
+	req = NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/issues?token="+token.Token, &api.CreateIssueOption{
+		Title: "created with an expired token",
+	})
+	MakeRequest(t, req, http.StatusUnauthorized)
+}
+
+func TestFineGrainedTokenWebRoutes(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	token := createFineGrainedToken(t, map[string]interface{}{
+		"name":           "fine-grained-web",
+		"resource_owner": "user2",
+		"repositories":   []string{"repo1"},
+		"permissions":    map[string]string{"code": "read"},
+		"expires_at":     time.Now().Add(time.Hour),
+	}, http.StatusCreated)
+
+	// raw files are only served from the repositories the token is restricted to
+	req := NewRequest(t, "GET", "/user2/repo1/raw/branch/master/README.md")
+	req.SetBasicAuth("user2", token.Token)
+	MakeRequest(t, req, http.StatusOK)
+	req = NewRequest(t, "GET", "/user2/repo2/raw/branch/master/test.xml")
+	req.SetBasicAuth("user2", token.Token)
+	MakeRequest(t, req, http.StatusNotFound)
+
+	// the same applies to the LFS batch endpoint
+	newBatchRequest := func(repo string) *http.Request {
+		req := NewRequestWithJSON(t, "POST", "/user2/"+repo+".git/info/lfs/objects/batch", &lfs.BatchRequest{
+			Operation: "download",
+			Objects:   []lfs.Pointer{{Oid: "fb8f7d8435968c4f82a726a92395be4d16f2f63116caf36c8ad35c60831ab041", Size: 6}},
+		})
+		req.Header.Set("Accept", lfs.MediaType)
+		req.Header.Set("Content-Type", lfs.MediaType)
+		req.SetBasicAuth("user2", token.Token)
+		return req
+	}
+	MakeRequest(t, newBatchRequest("repo1"), http.StatusOK)
+	MakeRequest(t, newBatchRequest("repo2"), http.StatusUnauthorized)
+}
+
+func TestAPIFineGrainedTokenInvalid(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	expiresAt := time.Now().Add(time.Hour)
+	// the expiry is mandatory
+	createFineGrainedToken(t, map[string]interface{}{
+		"name":           "no-expiry",
+		"resource_owner": "user2",
+		"permissions":    map[string]string{"code": "read"},
+	}, http.StatusBadRequest)
+	// only the known units can be granted
+	createFineGrainedToken(t, map[string]interface{}{
+		"name":           "unknown-unit",
+		"resource_owner": "user2",
+		"permissions":    map[string]string{"wiki": "read"},
+		"expires_at":     expiresAt,
+	}, http.StatusBadRequest)
+	// private repositories the user can't access are treated as missing
+	createFineGrainedToken(t, map[string]interface{}{
+		"name":           "inaccessible-repo",
+		"resource_owner": "user5",
+		"repositories":   []string{"repo4"},
+		"permissions":    map[string]string{"code": "read"},
+		"expires_at":     expiresAt,
+	}, http.StatusBadRequest)
+	unittest.AssertNotExistsBean(t, &auth_model.AccessToken{UID: 2, Name: "inaccessible-repo"})
+}
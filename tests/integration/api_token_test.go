@@ -4,6 +4,7 @@
 package integration
 
 import (
+	"bytes"
 	"net/http"
 	"testing"
 
@@ -63,3 +64,26 @@ func TestAPIDeleteMissingToken(t *testing.T) {
 	req = AddBasicAuthHeader(req, user.Name)
 	MakeRequest(t, req, http.StatusNotFound)
 }
+
+// TestAPITokenWithoutScheme ensures that an access token without a scheme is only accepted by the cargo registry
+func TestAPITokenWithoutScheme(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+	user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+	token := getUserToken(t, user.Name)
+
+	req := NewRequest(t, "GET", "/api/v1/user")
+	req = addTokenAuthHeader(req, token)
+	MakeRequest(t, req, http.StatusUnauthorized)
+
+	req = NewRequest(t, "GET", "/api/v1/user")
+	req = addTokenAuthHeader(req, "token "+token)
+	MakeRequest(t, req, http.StatusOK)
+
+	// the upload requires write access, a request which is not authenticated is rejected before the body is read
+	req = NewRequestWithBody(t, "PUT", "/api/packages/"+user.Name+"/cargo/api/v1/crates/new", bytes.NewReader([]byte{}))
+	MakeRequest(t, req, http.StatusUnauthorized)
+
+	req = NewRequestWithBody(t, "PUT", "/api/packages/"+user.Name+"/cargo/api/v1/crates/new", bytes.NewReader([]byte{}))
+	req = addTokenAuthHeader(req, token)
+	MakeRequest(t, req, http.StatusBadRequest)
+}
@@ -0,0 +1,57 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	commits_indexer "code.gitea.io/gitea/modules/indexer/commits"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+)
+
+func exploreCommitsResult(t *testing.T, url string) []string {
+	req := NewRequest(t, "GET", url)
+	resp := MakeRequest(t, req, http.StatusOK)
+
+	rows := NewHTMLParser(t, resp.Body).Find(".explore-commits-table tbody tr")
+	result := make([]string, 0, rows.Length())
+	rows.Each(func(i int, row *goquery.Selection) {
+		result = append(result, row.Find("td").First().Text()+" "+row.Find(".commit-summary").Text())
+	})
+	return result
+}
+
+func TestExploreCommits(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	for _, name := range []string{"repo1", "commits_search_test"} {
+		repo, err := repo_model.GetRepositoryByOwnerAndName(db.DefaultContext, "user2", name)
+		assert.NoError(t, err)
+		executeIndexer(t, repo, commits_indexer.UpdateRepoIndexer)
+	}
+
+	req := NewRequest(t, "GET", "/explore/commits")
+	MakeRequest(t, req, http.StatusOK)
+
+	assert.EqualValues(t, []string{"user2/commits_search_test commit-3"}, exploreCommitsResult(t, "/explore/commits?q=commit-3"))
+	assert.EqualValues(t, []string{"user2/commits_search_test commit-4", "user2/commits_search_test commit-2"},
+		exploreCommitsResult(t, "/explore/commits?q=commit+author:bob"))
+	assert.EqualValues(t, []string{"user2/commits_search_test commit-3", "user2/commits_search_test commit-2"},
+		exploreCommitsResult(t, "/explore/commits?q=commit+after:2019-03-02+before:2019-03-03"))
+	assert.EqualValues(t, []string{"user2/repo1 Initial commit"}, exploreCommitsResult(t, "/explore/commits?q=Initial"))
+	assert.Empty(t, exploreCommitsResult(t, "/explore/commits?q=commit+before:yesterday"))
+
+	// the commits of the default branch are searched by the indexer too
+	req = NewRequest(t, "GET", "/user2/commits_search_test/commits/branch/master/search?q=commit-3")
+	resp := MakeRequest(t, req, http.StatusOK)
+	sel := NewHTMLParser(t, resp.Body).doc.Find("#commits-table tbody tr td.sha a")
+	assert.EqualValues(t, 1, sel.Length())
+	assert.Contains(t, sel.AttrOr("href", ""), "0a8499a22ad32a80beda9d75efe15f9f94582468")
+}
@@ -0,0 +1,73 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExploreSearch(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	req := NewRequest(t, "GET", "/explore/search")
+	MakeRequest(t, req, http.StatusOK)
+
+	req = NewRequest(t, "GET", "/explore/search?q=repo1")
+	resp := MakeRequest(t, req, http.StatusOK)
+	doc := NewHTMLParser(t, resp.Body)
+	assert.EqualValues(t, 1, doc.Find(".global-search-repos a[href='/user2/repo1']").Length())
+
+	req = NewRequest(t, "GET", "/explore/search?q=user2")
+	resp = MakeRequest(t, req, http.StatusOK)
+	doc = NewHTMLParser(t, resp.Body)
+	assert.EqualValues(t, 1, doc.Find(".global-search-users a[href='/user2']").Length())
+}
+
+func TestAPIGlobalSearch(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	repoNames := func(results *api.GlobalSearchResults) []string {
+		names := make([]string, 0, len(results.Repositories))
+		for _, repo := range results.Repositories {
+			names = append(names, repo.FullName)
+		}
+		return names
+	}
+
+	req := NewRequest(t, "GET", "/api/v1/search")
+	MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	// the private repositories are hidden from anonymous users
+	req = NewRequest(t, "GET", "/api/v1/search?q=repo2&limit=50")
+	resp := MakeRequest(t, req, http.StatusOK)
+	var results api.GlobalSearchResults
+	DecodeJSON(t, resp, &results)
+	assert.NotContains(t, repoNames(&results), "user2/repo2")
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+	req = NewRequest(t, "GET", "/api/v1/search?q=repo2&limit=50&token="+token)
+	resp = MakeRequest(t, req, http.StatusOK)
+	results = api.GlobalSearchResults{}
+	DecodeJSON(t, resp, &results)
+	assert.Contains(t, repoNames(&results), "user2/repo2")
+
+	req = NewRequest(t, "GET", "/api/v1/search?q=user3")
+	resp = MakeRequest(t, req, http.StatusOK)
+	results = api.GlobalSearchResults{}
+	DecodeJSON(t, resp, &results)
+	if assert.Len(t, results.Organizations, 1) {
+		assert.EqualValues(t, "user3", results.Organizations[0].UserName)
+	}
+	assert.EqualValues(t, 1, results.OrganizationsTotal)
+	for _, user := range results.Users {
+		assert.NotEqual(t, "user3", user.UserName)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/git"
+	repo_service "code.gitea.io/gitea/services/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitCloneBundle(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		repo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 1})
+
+		// no bundle has been generated yet
+		MakeRequest(t, NewRequest(t, "GET", "/user2/repo1/clone.bundle"), http.StatusNotFound)
+		assert.Empty(t, repo_service.GitServiceOptions(db.DefaultContext, repo, false).BundleURI)
+
+		assert.NoError(t, repo_service.GenerateRepoBundle(db.DefaultContext, repo, time.Minute))
+		bundle, err := repo_model.GetRepoBundle(db.DefaultContext, repo.ID)
+		assert.NoError(t, err)
+		if !assert.NotNil(t, bundle) {
+			return
+		}
+		assert.Len(t, bundle.RefsHash, 64)
+
+		// the bundle is not regenerated while the branches and tags are unchanged
+		assert.NoError(t, repo_service.GenerateRepoBundle(db.DefaultContext, repo, time.Minute))
+		unchanged, err := repo_model.GetRepoBundle(db.DefaultContext, repo.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, bundle.GeneratedUnix, unchanged.GeneratedUnix)
+
+		resp := MakeRequest(t, NewRequest(t, "GET", "/user2/repo1/clone.bundle"), http.StatusOK)
+		assert.True(t, strings.HasPrefix(resp.Body.String(), "# v2 git bundle\n"))
+		assert.EqualValues(t, bundle.Size, resp.Body.Len())
+
+		opts := repo_service.GitServiceOptions(db.DefaultContext, repo, false)
+		assert.Equal(t, repo.HTMLURL()+"/clone.bundle", opts.BundleURI)
+		assert.Empty(t, repo_service.GitServiceOptions(db.DefaultContext, repo, true).BundleURI)
+		MakeRequest(t, NewRequest(t, "GET", "/user2/repo1.wiki/clone.bundle"), http.StatusNotFound)
+
+		t.Run("CloneWithBundleURI", func(t *testing.T) {
+			dstPath := t.TempDir()
+			_, _, runErr := git.NewCommand(git.DefaultContext, "clone", "--bare", git.CmdArg("--bundle-uri="+u.String()+"user2/repo1/clone.bundle")).
+				AddDynamicArguments(u.String()+"user2/repo1.git", dstPath).
+				RunStdString(nil)
+			assert.NoError(t, runErr)
+
+			gitRepo, err := git.OpenRepository(git.DefaultContext, dstPath)
+			assert.NoError(t, err)
+			defer gitRepo.Close()
+			commitID, err := gitRepo.GetBranchCommitID("master")
+			assert.NoError(t, err)
+			assert.Equal(t, "65f1bf27bc3bf70f64657658635e66094edbcb4d", commitID)
+		})
+
+		listBundleHeads := func(t *testing.T) string {
+			resp := MakeRequest(t, NewRequest(t, "GET", "/user2/repo1/clone.bundle"), http.StatusOK)
+			bundlePath := filepath.Join(t.TempDir(), "clone.bundle")
+			assert.NoError(t, os.WriteFile(bundlePath, resp.Body.Bytes(), 0o644))
+			stdout, _, err := git.NewCommand(git.DefaultContext, "bundle", "list-heads").AddDynamicArguments(bundlePath).RunStdString(nil)
+			assert.NoError(t, err)
+			return stdout
+		}
+
+		t.Run("DeleteBranch", func(t *testing.T) {
+			assert.Contains(t, listBundleHeads(t), "refs/heads/branch2")
+
+			session := loginUser(t, "user2")
+			token := getTokenForLoggedInUser(t, session, auth_model.AccessTokenScopeRepo)
+			req := NewRequestf(t, "DELETE", "/api/v1/repos/user2/repo1/branches/branch2?token=%s", token)
+			MakeRequest(t, req, http.StatusNoContent)
+
+			// the bundle still contains the deleted branch, so it must not be served anymore
+			assert.Eventually(t, func() bool {
+				bundle, err := repo_model.GetRepoBundle(db.DefaultContext, repo.ID)
+				return err == nil && bundle == nil
+			}, 10*time.Second, 100*time.Millisecond)
+			MakeRequest(t, NewRequest(t, "GET", "/user2/repo1/clone.bundle"), http.StatusNotFound)
+			assert.Empty(t, repo_service.GitServiceOptions(db.DefaultContext, repo, false).BundleURI)
+
+			assert.NoError(t, repo_service.GenerateRepoBundle(db.DefaultContext, repo, time.Minute))
+			heads := listBundleHeads(t)
+			assert.Contains(t, heads, "refs/heads/master")
+			assert.NotContains(t, heads, "refs/heads/branch2")
+		})
+
+		t.Run("StaleRefs", func(t *testing.T) {
+			bundle, err := repo_model.GetRepoBundle(db.DefaultContext, repo.ID)
+			assert.NoError(t, err)
+			assert.Contains(t, listBundleHeads(t), "refs/heads/develop")
+
+			// a reference which has been deleted without a notification is detected by the refs hash
+			_, _, err = git.NewCommand(git.DefaultContext, "update-ref", "-d", "refs/heads/develop").RunStdString(&git.RunOpts{Dir: repo.RepoPath()})
+			assert.NoError(t, err)
+
+			assert.NoError(t, repo_service.GenerateRepoBundle(db.DefaultContext, repo, time.Minute))
+			regenerated, err := repo_model.GetRepoBundle(db.DefaultContext, repo.ID)
+			assert.NoError(t, err)
+			assert.NotEqual(t, bundle.RefsHash, regenerated.RefsHash)
+			assert.NotContains(t, listBundleHeads(t), "refs/heads/develop")
+		})
+	})
+}
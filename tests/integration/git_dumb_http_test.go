@@ -0,0 +1,60 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/modules/git"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitDumbHTTPSHA256(t *testing.T) {
+	onGiteaRun(t, testGitDumbHTTPSHA256)
+}
+
+func testGitDumbHTTPSHA256(t *testing.T, u *url.URL) {
+	if !git.SupportSHA256 {
+		t.Skip("SHA-256 repositories are not supported")
+	}
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session, auth_model.AccessTokenScopeRepo)
+	req := NewRequestWithJSON(t, "POST", "/api/v1/user/repos?token="+token, &api.CreateRepoOption{
+		Name:             "repo-sha256-dumb",
+		AutoInit:         true,
+		Readme:           "Default",
+		ObjectFormatName: "sha256",
+	})
+	MakeRequest(t, req, http.StatusCreated)
+
+	u.Path = "user2/repo-sha256-dumb.git"
+
+	dumbClone := func(t *testing.T) {
+		dstPath := t.TempDir()
+		_, _, err := git.NewCommand(git.DefaultContext, "clone").AddDynamicArguments(u.String(), dstPath).
+			RunStdString(&git.RunOpts{Env: append(os.Environ(), "GIT_SMART_HTTP=0")})
+		assert.NoError(t, err)
+		assert.FileExists(t, filepath.Join(dstPath, "README.md"))
+	}
+
+	// the objects pushed by the initial commit are loose
+	t.Run("LooseObjects", dumbClone)
+
+	t.Run("PackedObjects", func(t *testing.T) {
+		_, _, err := git.NewCommand(git.DefaultContext, "repack", "-a", "-d").
+			RunStdString(&git.RunOpts{Dir: repo_model.RepoPath("user2", "repo-sha256-dumb")})
+		assert.NoError(t, err)
+
+		dumbClone(t)
+	})
+}
@@ -0,0 +1,105 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countMissingObjects returns the number of the objects which a partial clone has not fetched
+func countMissingObjects(t *testing.T, repoPath string) int {
+	stdout, _, err := git.NewCommand(git.DefaultContext, "rev-list", "--objects", "--all", "--missing=print").RunStdString(&git.RunOpts{Dir: repoPath})
+	assert.NoError(t, err)
+	count := 0
+	for _, line := range strings.Split(stdout, "\n") {
+		if strings.HasPrefix(line, "?") {
+			count++
+		}
+	}
+	return count
+}
+
+func doBloblessClone(u *url.URL, expectMissing bool) func(*testing.T) {
+	return func(t *testing.T) {
+		dstPath := t.TempDir()
+		assert.NoError(t, git.Clone(git.DefaultContext, u.String(), dstPath, git.CloneRepoOptions{
+			Bare:   true,
+			Filter: "blob:none",
+		}))
+		if expectMissing {
+			assert.NotZero(t, countMissingObjects(t, dstPath))
+		} else {
+			assert.Zero(t, countMissingObjects(t, dstPath))
+		}
+	}
+}
+
+func setRepoServiceOptions(t *testing.T, repo *repo_model.Repository, disablePartialClone, disableProtocolV2 bool) {
+	repo.DisablePartialClone = disablePartialClone
+	repo.DisableProtocolV2 = disableProtocolV2
+	assert.NoError(t, repo_model.UpdateRepositoryCols(db.DefaultContext, repo, "disable_partial_clone", "disable_protocol_v2"))
+}
+
+func TestGitPartialClone(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		repo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 1})
+		ctx := NewAPITestContext(t, "user2", "repo1", auth_model.AccessTokenScopeRepo, auth_model.AccessTokenScopeWritePublicKey)
+		httpURL, _ := url.Parse(u.String() + "user2/repo1.git")
+
+		t.Run("HTTP", doBloblessClone(httpURL, true))
+
+		withKeyFile(t, "my-testing-key", func(keyFile string) {
+			t.Run("CreateUserKey", doAPICreateUserKey(ctx, "test-key", keyFile))
+			sshURL := createSSHUrl(ctx.GitPath(), u)
+
+			t.Run("SSH", doBloblessClone(sshURL, true))
+
+			setRepoServiceOptions(t, repo, true, false)
+			t.Run("HTTPDisabled", doBloblessClone(httpURL, false))
+			t.Run("SSHDisabled", doBloblessClone(sshURL, false))
+			setRepoServiceOptions(t, repo, false, false)
+		})
+
+		defer func(filters []string) {
+			setting.Git.PartialCloneFilters = filters
+		}(setting.Git.PartialCloneFilters)
+		setting.Git.PartialCloneFilters = []string{"tree"}
+		t.Run("HTTPFilterNotAllowed", func(t *testing.T) {
+			assert.Error(t, git.Clone(git.DefaultContext, httpURL.String(), t.TempDir(), git.CloneRepoOptions{
+				Bare:   true,
+				Filter: "blob:none",
+			}))
+		})
+	})
+}
+
+func TestGitProtocolV2(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	getInfoRefs := func(t *testing.T) string {
+		req := NewRequest(t, "GET", "/user2/repo1.git/info/refs?service=git-upload-pack")
+		req.Header.Set("Git-Protocol", "version=2")
+		return MakeRequest(t, req, http.StatusOK).Body.String()
+	}
+
+	assert.Contains(t, getInfoRefs(t), "version 2")
+
+	repo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 1})
+	setRepoServiceOptions(t, repo, false, true)
+	assert.NotContains(t, getInfoRefs(t), "version 2")
+	assert.Contains(t, getInfoRefs(t), "refs/heads/master")
+}
@@ -445,6 +445,26 @@ func TestSearchIssues(t *testing.T) {
 	resp = session.MakeRequest(t, req, http.StatusOK)
 	DecodeJSON(t, resp, &apiIssues)
 	assert.Len(t, apiIssues, 2)
+
+	issues.UpdateIssueIndexer(unittest.AssertExistsAndLoadBean(t, &issues_model.Issue{ID: 1}))
+	time.Sleep(time.Second * 1)
+
+	query = url.Values{"q": {"first label:label1 is:issue author:user1"}, "state": {"all"}}
+	link.RawQuery = query.Encode()
+	req = NewRequest(t, "GET", link.String())
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &apiIssues)
+	assert.EqualValues(t, "1", resp.Header().Get("X-Total-Count"))
+	if assert.Len(t, apiIssues, 1) {
+		assert.EqualValues(t, 1, apiIssues[0].ID)
+	}
+
+	query = url.Values{"q": {"first -label:label1"}, "state": {"all"}}
+	link.RawQuery = query.Encode()
+	req = NewRequest(t, "GET", link.String())
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &apiIssues)
+	assert.Len(t, apiIssues, 0)
 }
 
 func TestSearchIssuesWithLabels(t *testing.T) {
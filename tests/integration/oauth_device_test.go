@@ -0,0 +1,160 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/routers/web/auth"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const publicClientID = "ce5a1322-42a7-11ed-b878-0242ac120002"
+
+func requestDeviceCode(t *testing.T) *auth.DeviceAuthorizationResponse {
+	req := NewRequestWithValues(t, "POST", "/login/oauth/device/code", map[string]string{
+		"client_id": publicClientID,
+		"scope":     "openid",
+	})
+	resp := MakeRequest(t, req, http.StatusOK)
+	parsed := new(auth.DeviceAuthorizationResponse)
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), parsed))
+	return parsed
+}
+
+func pollDeviceCode(t *testing.T, deviceCode string, expectedStatus int) *auth.AccessTokenError {
+	req := NewRequestWithValues(t, "POST", "/login/oauth/access_token", map[string]string{
+		"grant_type":  "urn:ietf:params:oauth:grant-type:device_code",
+		"client_id":   publicClientID,
+		"device_code": deviceCode,
+	})
+	resp := MakeRequest(t, req, expectedStatus)
+	if expectedStatus == http.StatusOK {
+		return nil
+	}
+	parsed := new(auth.AccessTokenError)
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), parsed))
+	return parsed
+}
+
+func TestDeviceAuthorizationGrant(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	code := requestDeviceCode(t)
+	assert.Equal(t, setting.AppURL+"login/device", code.VerificationURI)
+	assert.Equal(t, code.VerificationURI+"?user_code="+code.UserCode, code.VerificationURIComplete)
+	assert.EqualValues(t, setting.OAuth2.DeviceCodePollingInterval, code.Interval)
+
+	assert.EqualValues(t, auth.AccessTokenErrorCodeAuthorizationPending, pollDeviceCode(t, code.DeviceCode, http.StatusBadRequest).ErrorCode)
+	assert.EqualValues(t, auth.AccessTokenErrorCodeSlowDown, pollDeviceCode(t, code.DeviceCode, http.StatusBadRequest).ErrorCode)
+
+	session := loginUser(t, "user2")
+	resp := session.MakeRequest(t, NewRequest(t, "GET", "/login/device?user_code=XXXX-XXXX"), http.StatusOK)
+	assert.Contains(t, resp.Body.String(), "The code is invalid")
+
+	csrf := GetCSRF(t, session, code.VerificationURIComplete)
+	req := NewRequestWithValues(t, "POST", "/login/device", map[string]string{
+		"_csrf":     csrf,
+		"user_code": code.UserCode,
+		"approve":   "true",
+	})
+	session.MakeRequest(t, req, http.StatusSeeOther)
+	unittest.AssertExistsAndLoadBean(t, &auth_model.OAuth2Grant{UserID: 2, ApplicationID: 2, Scope: "openid"})
+
+	pollDeviceCode(t, code.DeviceCode, http.StatusOK)
+	// the device code can only be exchanged once
+	assert.EqualValues(t, auth.AccessTokenErrorCodeInvalidGrant, pollDeviceCode(t, code.DeviceCode, http.StatusBadRequest).ErrorCode)
+}
+
+func TestDeviceAuthorizationGrantDenied(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	code := requestDeviceCode(t)
+
+	session := loginUser(t, "user2")
+	csrf := GetCSRF(t, session, "/login/device")
+	req := NewRequestWithValues(t, "POST", "/login/device", map[string]string{
+		"_csrf":     csrf,
+		"user_code": code.UserCode,
+		"approve":   "false",
+	})
+	session.MakeRequest(t, req, http.StatusSeeOther)
+
+	// approving the denied code must not create or widen the grant
+	req = NewRequestWithValues(t, "POST", "/login/device", map[string]string{
+		"_csrf":     csrf,
+		"user_code": code.UserCode,
+		"approve":   "true",
+	})
+	session.MakeRequest(t, req, http.StatusSeeOther)
+	unittest.AssertNotExistsBean(t, &auth_model.OAuth2Grant{UserID: 2, ApplicationID: 2})
+
+	assert.EqualValues(t, auth.AccessTokenErrorCodeAccessDenied, pollDeviceCode(t, code.DeviceCode, http.StatusBadRequest).ErrorCode)
+	unittest.AssertNotExistsBean(t, &auth_model.OAuth2Grant{UserID: 2, ApplicationID: 2})
+}
+
+func TestDeviceAuthorizationGrantExpired(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	code := requestDeviceCode(t)
+	_, err := db.GetEngine(db.DefaultContext).Where("device_code = ?", code.DeviceCode).Cols("valid_until").
+		Update(&auth_model.OAuth2DeviceCode{ValidUntil: 1})
+	assert.NoError(t, err)
+
+	assert.EqualValues(t, auth.AccessTokenErrorCodeExpiredToken, pollDeviceCode(t, code.DeviceCode, http.StatusBadRequest).ErrorCode)
+}
+
+func TestDeviceAuthorizationConfidentialClient(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	req := NewRequestWithValues(t, "POST", "/login/oauth/device/code", map[string]string{
+		"client_id": "da7da3ba-9a13-4167-856f-3899de0b0138",
+	})
+	MakeRequest(t, req, http.StatusBadRequest)
+
+	req = NewRequestWithValues(t, "POST", "/login/oauth/device/code", map[string]string{
+		"client_id":     "da7da3ba-9a13-4167-856f-3899de0b0138",
+		"client_secret": "4MK8Na6R55smdCY0WuCCumZ6hjRPnGY5saWVRHHjJiA=",
+	})
+	MakeRequest(t, req, http.StatusOK)
+}
+
+func TestDeviceAuthorizationRateLimit(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+	defer func(limit, appLimit int64) {
+		setting.OAuth2.DeviceCodeRateLimit = limit
+		setting.OAuth2.DeviceCodeAppRateLimit = appLimit
+	}(setting.OAuth2.DeviceCodeRateLimit, setting.OAuth2.DeviceCodeAppRateLimit)
+	setting.OAuth2.DeviceCodeRateLimit = 1
+	setting.OAuth2.DeviceCodeAppRateLimit = 2
+
+	requestDeviceCodeFrom := func(t *testing.T, remoteAddr string, expectedStatus int) {
+		req := NewRequestWithValues(t, "POST", "/login/oauth/device/code", map[string]string{
+			"client_id": publicClientID,
+		})
+		req.RemoteAddr = remoteAddr
+		resp := MakeRequest(t, req, expectedStatus)
+		if expectedStatus == http.StatusTooManyRequests {
+			parsed := new(auth.AccessTokenError)
+			assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), parsed))
+			assert.EqualValues(t, auth.AccessTokenErrorCodeSlowDown, parsed.ErrorCode)
+		}
+	}
+
+	// the limit is applied per client address, so one client can't use up the codes of the application
+	requestDeviceCodeFrom(t, "192.0.2.1:1234", http.StatusOK)
+	requestDeviceCodeFrom(t, "192.0.2.1:5678", http.StatusTooManyRequests)
+	requestDeviceCodeFrom(t, "192.0.2.2:1234", http.StatusOK)
+
+	// the limit of the application applies to all clients together
+	requestDeviceCodeFrom(t, "192.0.2.3:1234", http.StatusTooManyRequests)
+}
@@ -0,0 +1,60 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unittest"
+	repo_service "code.gitea.io/gitea/services/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepoMaintenance(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		session := loginUser(t, "user2")
+		testEditFile(t, session, "user2", "repo1", "master", "README.md", "Hello, maintenance!\n")
+
+		// the pushes are recorded by the push queue
+		assert.Eventually(t, func() bool {
+			m, err := repo_model.GetRepoMaintenance(db.DefaultContext, 1)
+			assert.NoError(t, err)
+			return m != nil && m.PushCount > 0
+		}, 10*time.Second, 100*time.Millisecond)
+
+		assert.NoError(t, repo_service.MaintainRepositories(db.DefaultContext, repo_service.MaintenanceOptions{
+			Timeout:               time.Minute,
+			LooseObjectsThreshold: 1,
+			PacksThreshold:        1,
+			LooseRefsThreshold:    1,
+		}))
+
+		m := unittest.AssertExistsAndLoadBean(t, &repo_model.RepoMaintenance{RepoID: 1})
+		assert.EqualValues(t, 0, m.PushCount)
+		assert.Empty(t, m.LastError)
+		assert.Contains(t, m.LastTasks, "loose-objects")
+		assert.Contains(t, m.LastTasks, "commit-graph")
+		assert.EqualValues(t, 0, m.LooseObjects)
+		assert.NotZero(t, m.LastRunUnix)
+
+		// the repository is not visited again until it is pushed to
+		assert.NoError(t, repo_service.MaintainRepositories(db.DefaultContext, repo_service.MaintenanceOptions{}))
+		assert.Equal(t, m.LastRunUnix, unittest.AssertExistsAndLoadBean(t, &repo_model.RepoMaintenance{RepoID: 1}).LastRunUnix)
+
+		adminSession := loginUser(t, "user1")
+		resp := adminSession.MakeRequest(t, NewRequest(t, "GET", "/admin/repos/maintenance"), http.StatusOK)
+		htmlDoc := NewHTMLParser(t, resp.Body)
+		assert.Contains(t, htmlDoc.doc.Find("table").Text(), "user2/repo1")
+
+		resp = adminSession.MakeRequest(t, NewRequest(t, "GET", "/admin/repos/maintenance?pushed=true"), http.StatusOK)
+		htmlDoc = NewHTMLParser(t, resp.Body)
+		assert.NotContains(t, htmlDoc.doc.Find("table").Text(), "user2/repo1")
+	})
+}
@@ -35,6 +35,28 @@ func TestSearchRepo(t *testing.T) {
 	executeIndexer(t, repo, code_indexer.UpdateRepoIndexer)
 
 	testSearch(t, "/user2/repo1/search?q=Description&page=1", []string{"README.md"})
+	testSearch(t, "/user2/repo1/search?q=branch2&page=1", []string{})
+	testSearch(t, "/user2/repo1/search?q=^Desc.*repo1$&t=regexp", []string{"README.md"})
+	testSearch(t, "/user2/repo1/search?q=Desc(&t=regexp", []string{})
+	testSearch(t, "/user2/repo1/symbols?q=Description", []string{})
+	testSearch(t, "/user2/repo1/symbols?q=Description&type=references", []string{"README.md"})
+
+	session := loginUser(t, "user2")
+	req := NewRequestWithValues(t, "POST", "/user2/repo1/settings", map[string]string{
+		"_csrf":                 GetCSRF(t, session, "/user2/repo1/settings"),
+		"action":                "code_indexer",
+		"code_indexer_branches": "branch2",
+	})
+	session.MakeRequest(t, req, http.StatusSeeOther)
+
+	repo, err = repo_model.GetRepositoryByOwnerAndName(db.DefaultContext, "user2", "repo1")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "branch2", repo.CodeIndexerBranches)
+
+	executeIndexer(t, repo, code_indexer.UpdateRepoIndexer)
+
+	testSearch(t, "/user2/repo1/search?q=branch2&page=1", []string{})
+	testSearch(t, "/user2/repo1/search?q=branch2&ref=refs/heads/branch2&page=1", []string{"README.md"})
 
 	setting.Indexer.IncludePatterns = setting.IndexerGlobFromString("**.txt")
 	setting.Indexer.ExcludePatterns = setting.IndexerGlobFromString("**/y/**")
@@ -0,0 +1,45 @@
+import {createTippy} from '../modules/tippy.js';
+
+// the chroma classes of names, see https://github.com/alecthomas/chroma/blob/master/types.go
+const nameClasses = ['n', 'na', 'nb', 'bp', 'nc', 'no', 'nd', 'ni', 'ne', 'nf', 'fm', 'py', 'nl', 'nn', 'nx', 'nt', 'nv', 'vc', 'vg', 'vi', 'vm'];
+const identifierRegex = /^[A-Za-z_$][\w$]*$/;
+
+function symbolNameOf(el) {
+  if (!nameClasses.some((cls) => el.classList.contains(cls))) return null;
+  const name = el.textContent.trim();
+  return identifierRegex.test(name) ? name : null;
+}
+
+export function initRepoCodeNavigation() {
+  const menu = document.querySelector('.code-symbol-menu');
+  if (!menu) return;
+
+  document.addEventListener('click', (e) => {
+    const container = e.target.closest('[data-symbol-url]');
+    if (!container || !e.target.closest('.code-inner')) return;
+    // don't interrupt selecting text
+    if (window.getSelection().toString()) return;
+
+    const name = symbolNameOf(e.target);
+    if (!name) return;
+
+    const content = menu.cloneNode(true);
+    content.classList.remove('tippy-target');
+    for (const item of content.querySelectorAll('[data-symbol-type]')) {
+      const params = new URLSearchParams({q: name});
+      if (container.getAttribute('data-symbol-ref')) params.set('ref', container.getAttribute('data-symbol-ref'));
+      if (item.getAttribute('data-symbol-type') === 'references') params.set('type', 'references');
+      item.setAttribute('href', `${container.getAttribute('data-symbol-url')}?${params}`);
+    }
+
+    const tippy = createTippy(e.target, {
+      content,
+      trigger: 'manual',
+      interactive: true,
+      hideOnClick: true,
+      placement: 'bottom-start',
+      onHidden: (instance) => instance.destroy(),
+    });
+    tippy.show();
+  });
+}
@@ -65,6 +65,7 @@ import {initAdminEmails} from './features/admin/emails.js';
 import {initAdminCommon} from './features/admin/common.js';
 import {initRepoTemplateSearch} from './features/repo-template.js';
 import {initRepoCodeView} from './features/repo-code.js';
+import {initRepoCodeNavigation} from './features/repo-code-navigation.js';
 import {initSshKeyFormParser} from './features/sshkey-helper.js';
 import {initUserSettings} from './features/user-settings.js';
 import {initRepoArchiveLinks} from './features/repo-common.js';
@@ -156,6 +157,7 @@ $(document).ready(() => {
   initRepoArchiveLinks();
   initRepoBranchButton();
   initRepoCodeView();
+  initRepoCodeNavigation();
   initRepoCommentForm();
   initRepoEllipsisButton();
   initRepoCommitLastCommitLoader();